@@ -0,0 +1,53 @@
+package antireplay
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"strings"
+
+	"github.com/OneOfOne/xxhash"
+)
+
+const (
+	// HashXXHash is 64-bit xxhash. This is a fast non-cryptographic hash and
+	// the default one.
+	HashXXHash = "xxhash"
+
+	// HashFNV is 64-bit FNV-1a hash.
+	HashFNV = "fnv"
+
+	// HashSHA256 is SHA-256 hash. Only first 8 bytes of a digest are used
+	// as a token, they are read as a big endian number.
+	HashSHA256 = "sha256"
+)
+
+type sha256Hash64 struct {
+	hash.Hash
+}
+
+func (s sha256Hash64) Sum64() uint64 {
+	return binary.BigEndian.Uint64(s.Sum(nil)[:8]) //nolint: gomnd
+}
+
+// NewHash returns a hash function by its name. This function computes a
+// token of the handshake which is stored in anti-replay cache.
+//
+// Tokens computed by different functions are not compatible. If you share
+// a cache with other systems, all of them have to use the same function. If
+// you change a function of existing persistent cache, all previously
+// stored handshakes are effectively forgotten.
+func NewHash(name string) (hash.Hash64, error) {
+	switch strings.ToLower(name) {
+	case HashXXHash:
+		return xxhash.New64(), nil
+	case HashFNV:
+		return fnv.New64a(), nil
+	case HashSHA256:
+		return sha256Hash64{sha256.New()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash: %s", name)
+	}
+}
@@ -0,0 +1,55 @@
+package antireplay_test
+
+import (
+	"testing"
+
+	"github.com/IceCodeNew/mtg/antireplay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type HashTestSuite struct {
+	suite.Suite
+}
+
+func (suite *HashTestSuite) TestUnknown() {
+	_, err := antireplay.NewHash("md5")
+	suite.Error(err)
+}
+
+func (suite *HashTestSuite) TestSum64() {
+	testData := map[string]uint64{
+		antireplay.HashXXHash: 0x26c7827d889f6da3,
+		antireplay.HashFNV:    0xa430d84680aabd0b,
+		antireplay.HashSHA256: 0x2cf24dba5fb0a30e,
+		"SHA256":              0x2cf24dba5fb0a30e,
+	}
+
+	for k, v := range testData {
+		name := k
+		expected := v
+
+		suite.T().Run(name, func(t *testing.T) {
+			hashFunc, err := antireplay.NewHash(name)
+			assert.NoError(t, err)
+
+			hashFunc.Write([]byte("hello")) //nolint: errcheck
+			assert.Equal(t, expected, hashFunc.Sum64())
+		})
+	}
+}
+
+func (suite *HashTestSuite) TestStableBloomFilter() {
+	hashFunc, err := antireplay.NewHash(antireplay.HashSHA256)
+	suite.NoError(err)
+
+	filter := antireplay.NewStableBloomFilterWithHash(500, 0.001, hashFunc)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &HashTestSuite{})
+}
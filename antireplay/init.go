@@ -6,6 +6,8 @@
 // implementations of this interface.
 package antireplay
 
+import "time"
+
 const (
 	// DefaultStableBloomFilterMaxSize is a recommended byte size for a stable
 	// bloom filter.
@@ -14,4 +16,34 @@ const (
 	// DefaultStableBloomFilterErrorRate is a recommended default error rate for a
 	// stable bloom filter.
 	DefaultStableBloomFilterErrorRate = 0.001
+
+	// DefaultHash is a default hash function for tokens of handshakes.
+	DefaultHash = HashXXHash
+
+	// DefaultRedisKeyPrefix is a default prefix of keys which are stored
+	// in Redis.
+	DefaultRedisKeyPrefix = "mtg:antireplay:"
+
+	// DefaultRedisTTL is a default time period a handshake is remembered
+	// in Redis. It should be much longer than a tolerated time skewness of
+	// client hello: older handshakes are rejected anyway.
+	DefaultRedisTTL = 10 * time.Minute
+
+	// RedisTimeout is a max time period of a single request to Redis,
+	// including a connection establishment. Requests are made within a
+	// handshake so it is short.
+	RedisTimeout = time.Second
+
+	// RedisMaxIdleConns is a max number of idle connections to Redis which
+	// are kept for reuse.
+	RedisMaxIdleConns = 16
+
+	// PersistentFlushInterval is a time period between flushes of a
+	// persistent cache to a disk.
+	PersistentFlushInterval = 10 * time.Second
+
+	// DefaultPersistentMaxAge is a default max time period since the last
+	// flush of a persistent cache. If a proxy was down for longer, a cache
+	// is started from scratch.
+	DefaultPersistentMaxAge = time.Hour
 )
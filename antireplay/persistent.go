@@ -0,0 +1,397 @@
+package antireplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"math/bits"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+	boom "github.com/tylertreat/BoomFilters"
+)
+
+const (
+	// persistentHeaderSize is a size of a header of a persistent filter
+	// file: a magic string, m, k and p as big endian uint64 and a time of
+	// the last flush as big endian unix nanoseconds.
+	persistentHeaderSize = 40
+
+	// persistentParamsSize is a size of a part of the header with a magic
+	// string and parameters of the filter.
+	persistentParamsSize = 32
+)
+
+var (
+	persistentMagic = []byte("MTGAR\x00\x00\x02")
+
+	// ErrPersistentUnsupported is returned if a persistent anti-replay
+	// cache cannot be used on this platform.
+	ErrPersistentUnsupported = errors.New("persistent anti-replay cache is not supported on this platform")
+)
+
+// Persistent is a stable bloom filter with 1-bit cells which are stored in
+// a memory-mapped file. So, handshakes seen before restart of the proxy are
+// still known after it.
+//
+// Cells are flushed to a disk each [PersistentFlushInterval] and on
+// [Persistent.Close]. A crash loses only changes made after the last
+// flush.
+type Persistent struct {
+	mutex    sync.Mutex
+	hashFunc hash.Hash64
+	maxAge   time.Duration
+	file     *os.File
+	region   []byte
+	cells    []byte
+	m        uint64
+	k        uint64
+	p        uint64
+	closed   bool
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	done      chan struct{}
+}
+
+// SeenBefore checks if a digest was seen before and remembers it. A closed
+// cache has seen nothing.
+func (p *Persistent) SeenBefore(digest []byte) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return false
+	}
+
+	p.hashFunc.Write(digest) //nolint: errcheck
+	sum := p.hashFunc.Sum64()
+	p.hashFunc.Reset()
+
+	lower := sum & 0xffffffff //nolint: gomnd
+	upper := sum >> 32        //nolint: gomnd
+	member := true
+
+	indices := make([]uint64, p.k)
+
+	for i := range indices {
+		indices[i] = (lower + upper*uint64(i)) % p.m
+
+		if !p.get(indices[i]) {
+			member = false
+		}
+	}
+
+	start := uint64(rand.Int63n(int64(p.m))) //nolint: gosec
+
+	for i := uint64(0); i < p.p; i++ {
+		p.clear((start + i) % p.m)
+	}
+
+	for _, idx := range indices {
+		p.set(idx)
+	}
+
+	return member
+}
+
+// FillRatio returns a share of cells which are set.
+func (p *Persistent) FillRatio() float64 {
+	p.mutex.Lock()
+
+	if p.closed {
+		p.mutex.Unlock()
+
+		return 0
+	}
+
+	cells := append([]byte{}, p.cells...)
+	p.mutex.Unlock()
+
+	filled := 0
+
+	for _, v := range cells {
+		filled += bits.OnesCount8(v)
+	}
+
+	return float64(filled) / float64(p.m)
+}
+
+// FalsePositiveRate returns an estimated false-positive rate at a current
+// fill ratio.
+func (p *Persistent) FalsePositiveRate() float64 {
+	return math.Pow(p.FillRatio(), float64(p.k))
+}
+
+// Close flushes cells to a disk and unmaps a file. It is safe to call it
+// several times.
+func (p *Persistent) Close() error {
+	p.ctxCancel()
+	<-p.done
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	p.touch()
+
+	if err := msyncRegion(p.region, true); err != nil {
+		unmapRegion(p.region) //nolint: errcheck
+		p.file.Close()
+
+		return fmt.Errorf("cannot flush a file: %w", err)
+	}
+
+	if err := unmapRegion(p.region); err != nil {
+		p.file.Close()
+
+		return fmt.Errorf("cannot unmap a file: %w", err)
+	}
+
+	if err := p.file.Close(); err != nil {
+		return fmt.Errorf("cannot close a file: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Persistent) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(PersistentFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			// close waits for this goroutine before unmapping so a region
+			// is always mapped here.
+			p.mutex.Lock()
+			p.touch()
+			p.mutex.Unlock()
+
+			msyncRegion(p.region, false) //nolint: errcheck
+		}
+	}
+}
+
+func (p *Persistent) get(idx uint64) bool {
+	return p.cells[idx/8]&(1<<(idx%8)) != 0
+}
+
+func (p *Persistent) set(idx uint64) {
+	p.cells[idx/8] |= 1 << (idx % 8)
+}
+
+func (p *Persistent) clear(idx uint64) {
+	p.cells[idx/8] &^= 1 << (idx % 8)
+}
+
+// touch writes a current time into a header. It is called before each
+// flush so a header has a time of the last one.
+func (p *Persistent) touch() {
+	binary.BigEndian.PutUint64(p.region[persistentParamsSize:], uint64(time.Now().UnixNano()))
+}
+
+// restore checks that a header of a mapped file has the same parameters
+// as expected and that a file was flushed within a max age. Otherwise, a
+// file is considered as corrupted, stale or created for another
+// configuration: cells are reset and a new header is written.
+func (p *Persistent) restore() {
+	header := make([]byte, persistentParamsSize)
+
+	copy(header, persistentMagic)
+	binary.BigEndian.PutUint64(header[8:], p.m)
+	binary.BigEndian.PutUint64(header[16:], p.k)
+	binary.BigEndian.PutUint64(header[24:], p.p)
+
+	flushedAt := time.Unix(0, int64(binary.BigEndian.Uint64(p.region[persistentParamsSize:])))
+
+	if bytes.Equal(p.region[:persistentParamsSize], header) && time.Since(flushedAt) <= p.maxAge {
+		return
+	}
+
+	for i := range p.cells {
+		p.cells[i] = 0
+	}
+
+	copy(p.region, header)
+	p.touch()
+}
+
+// PersistentOpts defines settings of a cache made by
+// [NewPersistentWithOpts].
+type PersistentOpts struct {
+	// Path is a path of a file with cells of the filter.
+	//
+	// This is a mandatory setting.
+	Path string
+
+	// MaxSize is a byte size of the filter.
+	//
+	// This is an optional setting. Default is
+	// DefaultStableBloomFilterMaxSize.
+	MaxSize uint
+
+	// ErrorRate is a desired false-positive error rate.
+	//
+	// This is an optional setting. 0 means
+	// DefaultStableBloomFilterErrorRate.
+	ErrorRate float64
+
+	// Hash computes tokens of handshakes. A hash function is not stored in
+	// a file, so if it is changed, previous handshakes are forgotten
+	// eventually. Please use [NewHash] to get one.
+	//
+	// This is an optional setting. Default is xxhash.
+	Hash hash.Hash64
+
+	// MaxAge is a max time period since the last flush of a file. An older
+	// file is stale: a proxy was down for a long time, so its handshakes
+	// only waste a capacity of the filter. Such file is rebuilt from
+	// scratch.
+	//
+	// This is an optional setting. Default is DefaultPersistentMaxAge.
+	MaxAge time.Duration
+}
+
+func (p PersistentOpts) getMaxSize() uint {
+	if p.MaxSize == 0 {
+		return DefaultStableBloomFilterMaxSize
+	}
+
+	return p.MaxSize
+}
+
+func (p PersistentOpts) getErrorRate() float64 {
+	if p.ErrorRate <= 0 {
+		return DefaultStableBloomFilterErrorRate
+	}
+
+	return p.ErrorRate
+}
+
+func (p PersistentOpts) getHash() hash.Hash64 {
+	if p.Hash == nil {
+		return xxhash.New64()
+	}
+
+	return p.Hash
+}
+
+func (p PersistentOpts) getMaxAge() time.Duration {
+	if p.MaxAge == 0 {
+		return DefaultPersistentMaxAge
+	}
+
+	return p.MaxAge
+}
+
+// NewPersistent returns an implementation of AntiReplayCache based on
+// stable bloom filter which keeps its cells in a file of a given path.
+// Parameters have the same meaning as for [NewStableBloomFilter].
+//
+// If a file is missing, has a wrong size, a header of other parameters or
+// is older than [DefaultPersistentMaxAge], it is rebuilt from scratch.
+// Please call [Persistent.Close] on shutdown.
+func NewPersistent(path string, byteSize uint, errorRate float64) (*Persistent, error) {
+	return NewPersistentWithOpts(PersistentOpts{
+		Path:      path,
+		MaxSize:   byteSize,
+		ErrorRate: errorRate,
+	})
+}
+
+// NewPersistentWithOpts returns persistent stable bloom filter with given
+// settings. Please see [NewPersistent] for details.
+func NewPersistentWithOpts(opts PersistentOpts) (*Persistent, error) {
+	byteSize := opts.getMaxSize()
+	errorRate := opts.getErrorRate()
+
+	m := uint64(byteSize) * 8 //nolint: gomnd
+	k := uint64(boom.OptimalK(errorRate) / 2)
+
+	switch {
+	case k == 0:
+		k = 1
+	case k > m:
+		k = m
+	}
+
+	file, err := os.OpenFile(opts.Path, os.O_RDWR|os.O_CREATE, 0o600) //nolint: gomnd
+	if err != nil {
+		return nil, fmt.Errorf("cannot open a file: %w", err)
+	}
+
+	size := int64(persistentHeaderSize) + int64(byteSize)
+
+	if stat, err := file.Stat(); err != nil || stat.Size() != size {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+
+			return nil, fmt.Errorf("cannot truncate a file: %w", err)
+		}
+
+		if err := file.Truncate(size); err != nil {
+			file.Close()
+
+			return nil, fmt.Errorf("cannot resize a file: %w", err)
+		}
+	}
+
+	region, err := mapRegion(file, int(size))
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("cannot map a file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rv := &Persistent{
+		hashFunc:  opts.getHash(),
+		maxAge:    opts.getMaxAge(),
+		file:      file,
+		region:    region,
+		cells:     region[persistentHeaderSize:],
+		m:         m,
+		k:         k,
+		p:         optimalPersistentP(m, k, errorRate),
+		ctx:       ctx,
+		ctxCancel: cancel,
+		done:      make(chan struct{}),
+	}
+
+	rv.restore()
+
+	go rv.run()
+
+	return rv, nil
+}
+
+// optimalPersistentP returns a number of cells to reset on each insert.
+// This is the same formula as the one of [boom.StableBloomFilter] for
+// 1-bit cells.
+func optimalPersistentP(m, k uint64, errorRate float64) uint64 {
+	subDenom := 1 - math.Pow(errorRate, 1/float64(k))
+	denom := (1/subDenom - 1) * (1/float64(k) - 1/float64(m))
+	p := uint64(1 / denom)
+
+	if p == 0 {
+		p = 1
+	}
+
+	return p
+}
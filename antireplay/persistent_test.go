@@ -0,0 +1,216 @@
+package antireplay_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/antireplay"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type PersistentTestSuite struct {
+	suite.Suite
+
+	path string
+}
+
+func (suite *PersistentTestSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "anti-replay.bin")
+}
+
+func (suite *PersistentTestSuite) TestOp() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.False(filter.SeenBefore([]byte{4, 5, 6}))
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.True(filter.SeenBefore([]byte{4, 5, 6}))
+}
+
+func (suite *PersistentTestSuite) TestReopen() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+
+	filter, err = antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.False(filter.SeenBefore([]byte{4, 5, 6}))
+}
+
+func (suite *PersistentTestSuite) TestReopenOtherSize() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+
+	filter, err = antireplay.NewPersistent(suite.path, 1000, 0.001)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *PersistentTestSuite) TestCorrupted() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+
+	data, err := os.ReadFile(suite.path)
+	suite.Require().NoError(err)
+
+	data[0] = 'X'
+
+	suite.Require().NoError(os.WriteFile(suite.path, data, 0o600))
+
+	filter, err = antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.Zero(filter.FillRatio())
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *PersistentTestSuite) TestStale() {
+	opts := antireplay.PersistentOpts{
+		Path:    suite.path,
+		MaxSize: 500,
+		MaxAge:  time.Hour,
+	}
+
+	filter, err := antireplay.NewPersistentWithOpts(opts)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+
+	data, err := os.ReadFile(suite.path)
+	suite.Require().NoError(err)
+
+	binary.BigEndian.PutUint64(data[32:], uint64(time.Now().Add(-2*time.Hour).UnixNano()))
+	suite.Require().NoError(os.WriteFile(suite.path, data, 0o600))
+
+	filter, err = antireplay.NewPersistentWithOpts(opts)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.Zero(filter.FillRatio())
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *PersistentTestSuite) TestNotStale() {
+	opts := antireplay.PersistentOpts{
+		Path:    suite.path,
+		MaxSize: 500,
+		MaxAge:  time.Hour,
+	}
+
+	filter, err := antireplay.NewPersistentWithOpts(opts)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+
+	data, err := os.ReadFile(suite.path)
+	suite.Require().NoError(err)
+
+	binary.BigEndian.PutUint64(data[32:], uint64(time.Now().Add(-30*time.Minute).UnixNano()))
+	suite.Require().NoError(os.WriteFile(suite.path, data, 0o600))
+
+	filter, err = antireplay.NewPersistentWithOpts(opts)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *PersistentTestSuite) TestClosed() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.NoError(filter.Close())
+	suite.NoError(filter.Close())
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *PersistentTestSuite) TestConcurrent() {
+	filter, err := antireplay.NewPersistent(suite.path, 0, -1)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	wg := &sync.WaitGroup{}
+
+	for i := uint32(0); i < 8; i++ {
+		wg.Add(1)
+
+		go func(i uint32) {
+			defer wg.Done()
+
+			key := make([]byte, 4)
+			binary.BigEndian.PutUint32(key, i)
+
+			filter.SeenBefore(key)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := uint32(0); i < 8; i++ {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, i)
+
+		suite.True(filter.SeenBefore(key))
+	}
+}
+
+func (suite *PersistentTestSuite) TestStats() {
+	filter, err := antireplay.NewPersistent(suite.path, 500, 0.001)
+	suite.Require().NoError(err)
+
+	defer filter.Close()
+
+	var stats mtglib.AntiReplayCacheStats = filter
+
+	suite.Zero(stats.FillRatio())
+	suite.Zero(stats.FalsePositiveRate())
+
+	for i := uint32(0); i < 100; i++ {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, i)
+
+		filter.SeenBefore(key)
+	}
+
+	fillRatio := stats.FillRatio()
+	suite.Greater(fillRatio, 0.0)
+	suite.Less(fillRatio, 1.0)
+	suite.Less(stats.FalsePositiveRate(), fillRatio)
+}
+
+func TestPersistent(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &PersistentTestSuite{})
+}
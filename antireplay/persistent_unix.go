@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package antireplay
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func mapRegion(file *os.File, size int) ([]byte, error) {
+	region, err := unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap has failed: %w", err)
+	}
+
+	return region, nil
+}
+
+func msyncRegion(region []byte, wait bool) error {
+	flags := unix.MS_ASYNC
+
+	if wait {
+		flags = unix.MS_SYNC
+	}
+
+	return unix.Msync(region, flags) //nolint: wrapcheck
+}
+
+func unmapRegion(region []byte) error {
+	return unix.Munmap(region) //nolint: wrapcheck
+}
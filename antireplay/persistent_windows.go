@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package antireplay
+
+import "os"
+
+func mapRegion(file *os.File, size int) ([]byte, error) {
+	return nil, ErrPersistentUnsupported
+}
+
+func msyncRegion(region []byte, wait bool) error {
+	return nil
+}
+
+func unmapRegion(region []byte) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package antireplay
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/OneOfOne/xxhash"
+)
+
+// RedisClient is a minimal Redis client which is required by an
+// anti-replay cache. Please see [NewRedisClient] for an implementation.
+type RedisClient interface {
+	// SetNX sets a key with a given TTL only if it does not exist. It
+	// returns true if key was set. This is 'SET key value NX PX ttl'.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Close closes all connections which are kept for reuse.
+	Close() error
+}
+
+type redisCache struct {
+	mutex     sync.Mutex
+	hashFunc  hash.Hash64
+	client    RedisClient
+	logger    mtglib.Logger
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// key returns a key of a digest: a prefix and 16 lowercase hex digits of
+// a token computed by a hash function.
+func (r *redisCache) key(data []byte) string {
+	token := [8]byte{}
+
+	r.mutex.Lock()
+	r.hashFunc.Write(data) //nolint: errcheck
+	binary.BigEndian.PutUint64(token[:], r.hashFunc.Sum64())
+	r.hashFunc.Reset()
+	r.mutex.Unlock()
+
+	return r.keyPrefix + hex.EncodeToString(token[:])
+}
+
+func (r *redisCache) SeenBefore(data []byte) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+
+	isSet, err := r.client.SetNX(ctx, r.key(data), []byte{1}, r.ttl)
+	if err != nil {
+		r.logger.WarningError("cannot check handshake in redis", err)
+
+		return false
+	}
+
+	return !isSet
+}
+
+// Close closes a Redis client. A proxy calls it on shutdown.
+func (r *redisCache) Close() error {
+	return r.client.Close() //nolint: wrapcheck
+}
+
+// NewRedis returns an implementation of AntiReplayCache which stores
+// handshakes in Redis. This cache can be shared by many mtg instances: for
+// example, if they are running behind a load balancer, a handshake which
+// was seen by one instance is rejected by all others.
+//
+// If Redis is not available, handshakes are treated as not seen before and
+// a warning is logged: an outage of Redis should not take proxy down.
+//
+// Each handshake is stored as a separate key: keyPrefix and 16 lowercase
+// hex digits of a 64-bit token computed by a given hash function. So, a
+// key of [HashSHA256] token is keyPrefix and first 16 hex digits of
+// SHA-256 digest. Other systems which share keys have to use the same
+// prefix and function. nil hashFunc means xxhash, 0 ttl means
+// [DefaultRedisTTL]. Please use [NewHash] to get a hash function.
+func NewRedis(logger mtglib.Logger, client RedisClient,
+	keyPrefix string, ttl time.Duration, hashFunc hash.Hash64,
+) mtglib.AntiReplayCache {
+	if ttl == 0 {
+		ttl = DefaultRedisTTL
+	}
+
+	if hashFunc == nil {
+		hashFunc = xxhash.New64()
+	}
+
+	return &redisCache{
+		hashFunc:  hashFunc,
+		client:    client,
+		logger:    logger.Named("redis"),
+		keyPrefix: keyPrefix,
+		ttl:       ttl,
+	}
+}
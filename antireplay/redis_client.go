@@ -0,0 +1,189 @@
+package antireplay
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errRedisNil = errors.New("nil reply")
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Do sends a command and reads a simple reply. Only status, error, integer
+// and nil replies are supported: this is all anti-replay cache needs.
+func (r *redisConn) Do(ctx context.Context, args ...string) (string, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		r.conn.SetDeadline(deadline) //nolint: errcheck
+	} else {
+		r.conn.SetDeadline(time.Time{}) //nolint: errcheck
+	}
+
+	buf := make([]byte, 0, 64) //nolint: gomnd
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+
+	for _, v := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(v)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, v...)
+		buf = append(buf, "\r\n"...)
+	}
+
+	if _, err := r.conn.Write(buf); err != nil {
+		return "", fmt.Errorf("cannot send a command: %w", err)
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("cannot read a reply: %w", err)
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	switch {
+	case line == "":
+		return "", fmt.Errorf("empty reply")
+	case line == "$-1" || line == "_":
+		return "", errRedisNil
+	case line[0] == '+' || line[0] == ':':
+		return line[1:], nil
+	case line[0] == '-':
+		return "", fmt.Errorf("redis error: %s", line[1:])
+	}
+
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
+
+func (r *redisConn) Close() {
+	r.conn.Close()
+}
+
+type redisClient struct {
+	address  string
+	password string
+	db       int
+	idle     chan *redisConn
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+func (r *redisClient) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	conn, err := r.getConn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = conn.Do(ctx, "SET", key, string(value), "NX",
+		"PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+
+	switch {
+	case err == nil:
+		r.putConn(conn)
+
+		return true, nil
+	case errors.Is(err, errRedisNil):
+		r.putConn(conn)
+
+		return false, nil
+	}
+
+	conn.Close()
+
+	return false, err
+}
+
+func (r *redisClient) getConn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "tcp", r.address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial to %s: %w", r.address, err)
+	}
+
+	rv := &redisConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+
+	if r.password != "" {
+		if _, err := rv.Do(ctx, "AUTH", r.password); err != nil {
+			rv.Close()
+
+			return nil, fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+
+	if r.db != 0 {
+		if _, err := rv.Do(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			rv.Close()
+
+			return nil, fmt.Errorf("cannot select database %d: %w", r.db, err)
+		}
+	}
+
+	return rv, nil
+}
+
+func (r *redisClient) putConn(conn *redisConn) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.closed {
+		select {
+		case r.idle <- conn:
+			return
+		default:
+		}
+	}
+
+	conn.Close()
+}
+
+// Close closes idle connections. Connections which are in use are closed
+// as soon as a request is done. It is safe to call it several times.
+func (r *redisClient) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.closed = true
+
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// NewRedisClient returns a minimal Redis client for [NewRedis]. It speaks
+// RESP protocol over plain TCP and keeps up to [RedisMaxIdleConns] idle
+// connections for reuse. An empty password means no authentication.
+func NewRedisClient(address, password string, db int) RedisClient {
+	return &redisClient{
+		address:  address,
+		password: password,
+		db:       db,
+		idle:     make(chan *redisConn, RedisMaxIdleConns),
+	}
+}
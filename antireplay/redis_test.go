@@ -0,0 +1,278 @@
+package antireplay_test
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/antireplay"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/stretchr/testify/suite"
+)
+
+type redisFakeServer struct {
+	listener net.Listener
+	password string
+
+	mutex    sync.Mutex
+	keys     map[string]time.Time
+	commands []string
+	conns    int
+}
+
+func (s *redisFakeServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *redisFakeServer) Close() {
+	s.listener.Close()
+}
+
+func (s *redisFakeServer) Commands() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.commands...)
+}
+
+func (s *redisFakeServer) Conns() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.conns
+}
+
+func (s *redisFakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *redisFakeServer) handle(conn net.Conn) {
+	s.mutex.Lock()
+	s.conns++
+	s.mutex.Unlock()
+
+	defer func() {
+		conn.Close()
+
+		s.mutex.Lock()
+		s.conns--
+		s.mutex.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	authorized := s.password == ""
+
+	for {
+		args, err := s.readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		s.mutex.Unlock()
+
+		switch {
+		case args[0] == "AUTH" && args[1] == s.password:
+			authorized = true
+
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "AUTH":
+			fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+		case !authorized:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case args[0] == "SELECT":
+			fmt.Fprint(conn, "+OK\r\n")
+		case args[0] == "SET":
+			fmt.Fprint(conn, s.set(args))
+		default:
+			fmt.Fprint(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+func (s *redisFakeServer) set(args []string) string {
+	ttl, _ := strconv.Atoi(args[5])
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if expireAt, ok := s.keys[args[1]]; ok && time.Now().Before(expireAt) {
+		return "$-1\r\n"
+	}
+
+	s.keys[args[1]] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+
+	return "+OK\r\n"
+}
+
+func (s *redisFakeServer) readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	count, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	args := make([]string, count)
+
+	for i := range args {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		length, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		data := make([]byte, length+2)
+
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		args[i] = string(data[:length])
+	}
+
+	return args, nil
+}
+
+func newRedisFakeServer(password string) (*redisFakeServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	server := &redisFakeServer{
+		listener: listener,
+		password: password,
+		keys:     map[string]time.Time{},
+	}
+
+	go server.serve()
+
+	return server, nil
+}
+
+type RedisTestSuite struct {
+	suite.Suite
+
+	server *redisFakeServer
+}
+
+func (suite *RedisTestSuite) SetupTest() {
+	server, err := newRedisFakeServer("password")
+	suite.NoError(err)
+
+	suite.server = server
+}
+
+func (suite *RedisTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *RedisTestSuite) TestOp() {
+	hashFunc, err := antireplay.NewHash(antireplay.HashSHA256)
+	suite.NoError(err)
+
+	client := antireplay.NewRedisClient(suite.server.Addr(), "password", 2)
+	filter := antireplay.NewRedis(logger.NewNoopLogger(), client, "mtg:", time.Minute, hashFunc)
+
+	key1 := sha256.Sum256([]byte{1, 2, 3})
+	key2 := sha256.Sum256([]byte{4, 5, 6})
+	set1 := "SET mtg:" + hex.EncodeToString(key1[:8]) + " \x01 NX PX 60000"
+	set2 := "SET mtg:" + hex.EncodeToString(key2[:8]) + " \x01 NX PX 60000"
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.False(filter.SeenBefore([]byte{4, 5, 6}))
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.True(filter.SeenBefore([]byte{4, 5, 6}))
+
+	suite.Equal([]string{
+		"AUTH password",
+		"SELECT 2",
+		set1,
+		set2,
+		set1,
+		set2,
+	}, suite.server.Commands())
+}
+
+func (suite *RedisTestSuite) TestSharedBetweenClients() {
+	filter1 := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "password", 0), "", 0, nil)
+	filter2 := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "password", 0), "", 0, nil)
+
+	suite.False(filter1.SeenBefore([]byte{1, 2, 3}))
+	suite.True(filter2.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *RedisTestSuite) TestExpired() {
+	filter := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "password", 0), "", 10*time.Millisecond, nil)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+
+	time.Sleep(50 * time.Millisecond)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *RedisTestSuite) TestWrongPassword() {
+	filter := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "wrong", 0), "", 0, nil)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *RedisTestSuite) TestUnavailable() {
+	suite.server.Close()
+
+	filter := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "password", 0), "", 0, nil)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+}
+
+func (suite *RedisTestSuite) TestClose() {
+	filter := antireplay.NewRedis(logger.NewNoopLogger(),
+		antireplay.NewRedisClient(suite.server.Addr(), "password", 0), "", 0, nil)
+
+	suite.False(filter.SeenBefore([]byte{1, 2, 3}))
+	suite.Equal(1, suite.server.Conns())
+
+	closer, ok := filter.(io.Closer)
+	suite.True(ok)
+	suite.NoError(closer.Close())
+	suite.NoError(closer.Close())
+
+	suite.Eventually(func() bool {
+		return suite.server.Conns() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	suite.True(filter.SeenBefore([]byte{1, 2, 3}))
+
+	suite.Eventually(func() bool {
+		return suite.server.Conns() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedis(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &RedisTestSuite{})
+}
@@ -1,6 +1,11 @@
 package antireplay
 
 import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
 	"sync"
 
 	"github.com/IceCodeNew/mtg/mtglib"
@@ -20,6 +25,75 @@ func (s *stableBloomFilter) SeenBefore(digest []byte) bool {
 	return s.filter.TestAndAdd(digest)
 }
 
+// FillRatio returns a share of cells of the filter which are not zero. A
+// stable bloom filter converges to a fill ratio defined by its parameters
+// so this is how full it is now.
+//
+// A filter is copied under lock and cells are counted without it, so
+// SeenBefore is blocked only for a time of memory copy.
+func (s *stableBloomFilter) FillRatio() float64 {
+	buf := &bytes.Buffer{}
+
+	s.mutex.Lock()
+	_, err := s.filter.WriteTo(buf)
+	s.mutex.Unlock()
+
+	if err != nil {
+		return 0
+	}
+
+	cells, err := readStableBloomFilterCells(buf)
+	if err != nil || cells.Count() == 0 {
+		return 0
+	}
+
+	filled := 0
+
+	for i := uint(0); i < cells.Count(); i++ {
+		if cells.Get(i) != 0 {
+			filled++
+		}
+	}
+
+	return float64(filled) / float64(cells.Count())
+}
+
+// FalsePositiveRate returns an estimated false-positive rate at a current
+// fill ratio: a new element is reported as seen before only if all its
+// cells are not zero.
+func (s *stableBloomFilter) FalsePositiveRate() float64 {
+	return math.Pow(s.FillRatio(), float64(s.filter.K()))
+}
+
+// readStableBloomFilterCells skips a header of serialized stable bloom
+// filter and reads its cells: m, p, k, max, a length of index buffer and
+// the buffer itself.
+func readStableBloomFilterCells(buf *bytes.Buffer) (*boom.Buckets, error) {
+	header := struct {
+		M, P, K uint64
+		Max     uint8
+		Indices int64
+	}{}
+
+	if err := binary.Read(buf, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("cannot read a header: %w", err)
+	}
+
+	if header.Indices < 0 || int64(buf.Len()) < header.Indices*8 {
+		return nil, fmt.Errorf("incorrect length of index buffer %d", header.Indices)
+	}
+
+	buf.Next(int(header.Indices) * 8) //nolint: gomnd
+
+	cells := &boom.Buckets{}
+
+	if _, err := cells.ReadFrom(buf); err != nil {
+		return nil, fmt.Errorf("cannot read cells: %w", err)
+	}
+
+	return cells, nil
+}
+
 // NewStableBloomFilter returns an implementation of AntiReplayCache based on
 // stable bloom filter.
 //
@@ -34,6 +108,19 @@ func (s *stableBloomFilter) SeenBefore(digest []byte) bool {
 // errorRate is desired false-positive error rate. If you want to use default
 // values, please pass 0 for byteSize and <0 for errorRate.
 func NewStableBloomFilter(byteSize uint, errorRate float64) mtglib.AntiReplayCache {
+	return NewStableBloomFilterWithHash(byteSize, errorRate, xxhash.New64())
+}
+
+// NewStableBloomFilterWithHash returns stable bloom filter which computes
+// tokens of handshakes with a given hash function. Please use [NewHash] to
+// get a function by its name. nil means a default function.
+func NewStableBloomFilterWithHash(byteSize uint, errorRate float64,
+	hashFunc hash.Hash64,
+) mtglib.AntiReplayCache {
+	if hashFunc == nil {
+		hashFunc = xxhash.New64()
+	}
+
 	if byteSize == 0 {
 		byteSize = DefaultStableBloomFilterMaxSize
 	}
@@ -43,7 +130,7 @@ func NewStableBloomFilter(byteSize uint, errorRate float64) mtglib.AntiReplayCac
 	}
 
 	sf := boom.NewDefaultStableBloomFilter(byteSize*8, errorRate) //nolint: gomnd
-	sf.SetHash(xxhash.New64())
+	sf.SetHash(hashFunc)
 
 	return &stableBloomFilter{
 		filter: *sf,
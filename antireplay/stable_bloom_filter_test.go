@@ -1,9 +1,11 @@
 package antireplay_test
 
 import (
+	"encoding/binary"
 	"testing"
 
 	"github.com/IceCodeNew/mtg/antireplay"
+	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -20,6 +22,31 @@ func (suite *StableBloomFilterTestSuite) TestOp() {
 	suite.True(filter.SeenBefore([]byte{4, 5, 6}))
 }
 
+func (suite *StableBloomFilterTestSuite) TestStats() {
+	filter := antireplay.NewStableBloomFilter(500, 0.001)
+
+	stats, ok := filter.(mtglib.AntiReplayCacheStats)
+	suite.Require().True(ok)
+
+	suite.Zero(stats.FillRatio())
+	suite.Zero(stats.FalsePositiveRate())
+
+	for i := uint32(0); i < 100; i++ {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, i)
+
+		filter.SeenBefore(key)
+	}
+
+	fillRatio := stats.FillRatio()
+	suite.Greater(fillRatio, 0.0)
+	suite.Less(fillRatio, 1.0)
+
+	falsePositiveRate := stats.FalsePositiveRate()
+	suite.Greater(falsePositiveRate, 0.0)
+	suite.Less(falsePositiveRate, fillRatio)
+}
+
 func TestStableBloomFilter(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &StableBloomFilterTestSuite{})
@@ -0,0 +1,264 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+const (
+	// AccessLogFormatJSON writes each record of access log as a JSON
+	// object.
+	AccessLogFormatJSON = "json"
+
+	// AccessLogFormatLogfmt writes each record of access log as a line
+	// of key=value pairs.
+	AccessLogFormatLogfmt = "logfmt"
+)
+
+const (
+	// AccessLogCloseReasonClosed means that a stream was relayed to
+	// Telegram and then closed by any side.
+	AccessLogCloseReasonClosed = "closed"
+
+	// AccessLogCloseReasonDomainFronting means that a stream was relayed
+	// to a fronting domain.
+	AccessLogCloseReasonDomainFronting = "domain-fronting"
+
+	// AccessLogCloseReasonHandshakeFailed means that a client has not
+	// passed a handshake.
+	AccessLogCloseReasonHandshakeFailed = "handshake-failed"
+
+	// AccessLogCloseReasonHandshakeTooLarge means that a client has sent
+	// a handshake record which exceeds a limit.
+	AccessLogCloseReasonHandshakeTooLarge = "handshake-too-large"
+
+	// AccessLogCloseReasonIdleTimeout means that a stream was relayed to
+	// Telegram and then closed because nothing was transmitted within an
+	// idle timeout.
+	AccessLogCloseReasonIdleTimeout = "idle-timeout"
+
+	// AccessLogCloseReasonIncompleteHandshake means that a client has
+	// disconnected or timed out in the middle of a handshake.
+	AccessLogCloseReasonIncompleteHandshake = "incomplete-handshake"
+
+	// AccessLogCloseReasonReplayAttack means that a client has sent a
+	// handshake which was seen before.
+	AccessLogCloseReasonReplayAttack = "replay-attack"
+
+	// AccessLogCloseReasonUnknown means that a stream was closed before
+	// anything meaningful happened.
+	AccessLogCloseReasonUnknown = "unknown"
+)
+
+// accessLogRecord is a single line of access log. It is written when a
+// stream is finished.
+type accessLogRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	StreamID    string    `json:"streamId"`
+	ClientIP    net.IP    `json:"clientIp"`
+	DC          *int      `json:"dc,omitempty"`
+	SecretIndex *int      `json:"secretIndex,omitempty"`
+	BytesUp     uint      `json:"bytesUp"`
+	BytesDown   uint      `json:"bytesDown"`
+	Duration    float64   `json:"duration"`
+	CloseReason string    `json:"closeReason"`
+}
+
+func (a *accessLogRecord) Logfmt() []byte {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintf(buf, "timestamp=%s stream_id=%s client_ip=%s",
+		a.Timestamp.Format(time.RFC3339Nano), a.StreamID, a.ClientIP)
+
+	if a.DC != nil {
+		fmt.Fprintf(buf, " dc=%d", *a.DC)
+	}
+
+	if a.SecretIndex != nil {
+		fmt.Fprintf(buf, " secret_index=%d", *a.SecretIndex)
+	}
+
+	fmt.Fprintf(buf, " bytes_up=%d bytes_down=%d duration=%s close_reason=%s",
+		a.BytesUp, a.BytesDown,
+		strconv.FormatFloat(a.Duration, 'f', -1, 64), a.CloseReason)
+
+	return buf.Bytes()
+}
+
+type accessLogStream struct {
+	record    accessLogRecord
+	startedAt time.Time
+}
+
+// AccessLogFactory is a factory of observers which write a line per each
+// finished stream: client IP, DC, matched secret, bytes transmitted over a
+// client connection, a duration and a reason why the stream was closed.
+//
+// This is not a replacement of the application logger: access log has
+// neither debug messages nor anything which is not related to streams.
+// Bytes up are sent by a client, bytes down are sent to it. A duration is
+// in seconds.
+type AccessLogFactory struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	format string
+}
+
+// Make builds a new observer.
+func (a *AccessLogFactory) Make() Observer {
+	return &accessLogObserver{
+		factory: a,
+		streams: map[string]*accessLogStream{},
+	}
+}
+
+func (a *AccessLogFactory) write(record *accessLogRecord) {
+	var line []byte
+
+	if a.format == AccessLogFormatLogfmt {
+		line = record.Logfmt()
+	} else {
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+
+		line = encoded
+	}
+
+	line = append(line, '\n')
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.writer.Write(line) //nolint: errcheck
+}
+
+type accessLogObserver struct {
+	noopObserver
+
+	factory *AccessLogFactory
+	streams map[string]*accessLogStream
+}
+
+func (a *accessLogObserver) EventStart(evt mtglib.EventStart) {
+	a.streams[evt.StreamID()] = &accessLogStream{
+		record: accessLogRecord{
+			StreamID: evt.StreamID(),
+			ClientIP: evt.RemoteIP,
+		},
+		startedAt: evt.Timestamp(),
+	}
+}
+
+func (a *accessLogObserver) EventSecretMatched(evt mtglib.EventSecretMatched) {
+	if stream, ok := a.streams[evt.StreamID()]; ok {
+		index := evt.SecretIndex
+		stream.record.SecretIndex = &index
+	}
+}
+
+func (a *accessLogObserver) EventConnectedToDC(evt mtglib.EventConnectedToDC) {
+	if stream, ok := a.streams[evt.StreamID()]; ok {
+		dc := evt.DC
+		stream.record.DC = &dc
+		a.setCloseReason(evt, AccessLogCloseReasonClosed)
+	}
+}
+
+func (a *accessLogObserver) EventClientTraffic(evt mtglib.EventClientTraffic) {
+	if stream, ok := a.streams[evt.StreamID()]; ok {
+		if evt.IsRead {
+			stream.record.BytesUp += evt.Traffic
+		} else {
+			stream.record.BytesDown += evt.Traffic
+		}
+	}
+}
+
+func (a *accessLogObserver) EventDomainFronting(evt mtglib.EventDomainFronting) {
+	a.setCloseReason(evt, AccessLogCloseReasonDomainFronting)
+}
+
+func (a *accessLogObserver) EventReplayAttack(evt mtglib.EventReplayAttack) {
+	a.setCloseReason(evt, AccessLogCloseReasonReplayAttack)
+}
+
+func (a *accessLogObserver) EventHandshakeTooLarge(evt mtglib.EventHandshakeTooLarge) {
+	a.setCloseReason(evt, AccessLogCloseReasonHandshakeTooLarge)
+}
+
+func (a *accessLogObserver) EventIncompleteHandshake(evt mtglib.EventIncompleteHandshake) {
+	a.setCloseReason(evt, AccessLogCloseReasonIncompleteHandshake)
+}
+
+func (a *accessLogObserver) EventHandshakeFinished(evt mtglib.EventHandshakeFinished) {
+	if evt.IsFailed {
+		a.setCloseReason(evt, AccessLogCloseReasonHandshakeFailed)
+	}
+}
+
+// EventIdleTimeout overrides a reason set on connection to Telegram: idle
+// timeout is known only when a relay is finished.
+func (a *accessLogObserver) EventIdleTimeout(evt mtglib.EventIdleTimeout) {
+	if stream, ok := a.streams[evt.StreamID()]; ok {
+		stream.record.CloseReason = AccessLogCloseReasonIdleTimeout
+	}
+}
+
+func (a *accessLogObserver) EventFinish(evt mtglib.EventFinish) {
+	stream, ok := a.streams[evt.StreamID()]
+	if !ok {
+		return
+	}
+
+	delete(a.streams, evt.StreamID())
+
+	stream.record.Timestamp = evt.Timestamp()
+	stream.record.Duration = evt.Timestamp().Sub(stream.startedAt).Seconds()
+
+	if stream.record.CloseReason == "" {
+		stream.record.CloseReason = AccessLogCloseReasonUnknown
+	}
+
+	a.factory.write(&stream.record)
+}
+
+func (a *accessLogObserver) Shutdown() {
+	for k := range a.streams {
+		delete(a.streams, k)
+	}
+}
+
+// setCloseReason keeps the first reason of the stream: this is a cause,
+// everything else is a consequence. For example, a replay attack is
+// followed by domain fronting.
+func (a *accessLogObserver) setCloseReason(evt mtglib.Event, reason string) {
+	if stream, ok := a.streams[evt.StreamID()]; ok && stream.record.CloseReason == "" {
+		stream.record.CloseReason = reason
+	}
+}
+
+// NewAccessLog creates a factory of observers which write access log to a
+// given writer. format is either [AccessLogFormatJSON] or
+// [AccessLogFormatLogfmt].
+func NewAccessLog(writer io.Writer, format string) (*AccessLogFactory, error) {
+	switch format {
+	case AccessLogFormatJSON, AccessLogFormatLogfmt:
+	default:
+		return nil, fmt.Errorf("unknown access log format %q", format)
+	}
+
+	return &AccessLogFactory{
+		writer: writer,
+		format: format,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type accessLogTestRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	StreamID    string    `json:"streamId"`
+	ClientIP    string    `json:"clientIp"`
+	DC          *int      `json:"dc"`
+	SecretIndex *int      `json:"secretIndex"`
+	BytesUp     uint      `json:"bytesUp"`
+	BytesDown   uint      `json:"bytesDown"`
+	Duration    float64   `json:"duration"`
+	CloseReason string    `json:"closeReason"`
+}
+
+type AccessLogTestSuite struct {
+	suite.Suite
+
+	buf      *bytes.Buffer
+	observer events.Observer
+}
+
+func (suite *AccessLogTestSuite) SetupTest() {
+	suite.buf = &bytes.Buffer{}
+
+	factory, err := events.NewAccessLog(suite.buf, events.AccessLogFormatJSON)
+	suite.NoError(err)
+
+	suite.observer = factory.Make()
+}
+
+func (suite *AccessLogTestSuite) TearDownTest() {
+	suite.observer.Shutdown()
+}
+
+func (suite *AccessLogTestSuite) Records() []accessLogTestRecord {
+	rv := []accessLogTestRecord{}
+
+	for _, line := range strings.Split(strings.TrimSpace(suite.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		record := accessLogTestRecord{}
+		suite.NoError(json.Unmarshal([]byte(line), &record))
+
+		rv = append(rv, record)
+	}
+
+	return rv
+}
+
+func (suite *AccessLogTestSuite) TestRelayed() {
+	suite.observer.EventStart(mtglib.NewEventStart("stream", net.ParseIP("10.0.0.10")))
+	suite.observer.EventClientTraffic(mtglib.NewEventClientTraffic("stream", 100, true))
+	suite.observer.EventSecretMatched(mtglib.NewEventSecretMatchedWithOpts("stream", mtglib.EventSecretMatchedOpts{
+		SecretFingerprint: "abc",
+		Host:              "example.com",
+		SecretIndex:       2,
+	}))
+	suite.observer.EventHandshakeFinished(mtglib.NewEventHandshakeFinished("stream", time.Second, false))
+	suite.observer.EventConnectedToDC(
+		mtglib.NewEventConnectedToDC("stream", net.ParseIP("149.154.167.51"), 4))
+	suite.observer.EventClientTraffic(mtglib.NewEventClientTraffic("stream", 30, false))
+	suite.observer.EventClientTraffic(mtglib.NewEventClientTraffic("stream", 20, true))
+	suite.observer.EventTraffic(mtglib.NewEventTraffic("stream", 1000, true))
+	suite.observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	records := suite.Records()
+	suite.Len(records, 1)
+
+	record := records[0]
+	suite.Equal("stream", record.StreamID)
+	suite.Equal("10.0.0.10", record.ClientIP)
+	suite.Equal(4, *record.DC)
+	suite.Equal(2, *record.SecretIndex)
+	suite.EqualValues(120, record.BytesUp)
+	suite.EqualValues(30, record.BytesDown)
+	suite.GreaterOrEqual(record.Duration, 0.0)
+	suite.False(record.Timestamp.IsZero())
+	suite.Equal(events.AccessLogCloseReasonClosed, record.CloseReason)
+}
+
+func (suite *AccessLogTestSuite) TestIdleTimeout() {
+	suite.observer.EventStart(mtglib.NewEventStart("stream", net.ParseIP("10.0.0.10")))
+	suite.observer.EventConnectedToDC(
+		mtglib.NewEventConnectedToDC("stream", net.ParseIP("149.154.167.51"), 4))
+	suite.observer.EventIdleTimeout(mtglib.NewEventIdleTimeout("stream"))
+	suite.observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	records := suite.Records()
+	suite.Len(records, 1)
+	suite.Equal(events.AccessLogCloseReasonIdleTimeout, records[0].CloseReason)
+}
+
+func (suite *AccessLogTestSuite) TestReplayAttack() {
+	suite.observer.EventStart(mtglib.NewEventStart("stream", net.ParseIP("10.0.0.10")))
+	suite.observer.EventReplayAttack(mtglib.NewEventReplayAttack("stream"))
+	suite.observer.EventHandshakeFinished(mtglib.NewEventHandshakeFinished("stream", time.Second, true))
+	suite.observer.EventDomainFronting(mtglib.NewEventDomainFronting("stream"))
+	suite.observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	records := suite.Records()
+	suite.Len(records, 1)
+	suite.Nil(records[0].DC)
+	suite.Nil(records[0].SecretIndex)
+	suite.Equal(events.AccessLogCloseReasonReplayAttack, records[0].CloseReason)
+}
+
+func (suite *AccessLogTestSuite) TestUnknown() {
+	suite.observer.EventStart(mtglib.NewEventStart("stream", net.ParseIP("10.0.0.10")))
+	suite.observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	records := suite.Records()
+	suite.Len(records, 1)
+	suite.Equal(events.AccessLogCloseReasonUnknown, records[0].CloseReason)
+}
+
+func (suite *AccessLogTestSuite) TestFinishWithoutStart() {
+	suite.observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	suite.Empty(suite.Records())
+}
+
+func (suite *AccessLogTestSuite) TestLogfmt() {
+	factory, err := events.NewAccessLog(suite.buf, events.AccessLogFormatLogfmt)
+	suite.NoError(err)
+
+	observer := factory.Make()
+	defer observer.Shutdown()
+
+	observer.EventStart(mtglib.NewEventStart("stream", net.ParseIP("10.0.0.10")))
+	observer.EventSecretMatched(mtglib.NewEventSecretMatchedWithOpts("stream", mtglib.EventSecretMatchedOpts{
+		SecretFingerprint: "abc",
+		SecretIndex:       1,
+	}))
+	observer.EventConnectedToDC(mtglib.NewEventConnectedToDC("stream", net.ParseIP("149.154.167.51"), -2))
+	observer.EventClientTraffic(mtglib.NewEventClientTraffic("stream", 10, true))
+	observer.EventFinish(mtglib.NewEventFinish("stream"))
+
+	line := suite.buf.String()
+	suite.True(strings.HasPrefix(line, "timestamp="))
+	suite.True(strings.HasSuffix(line, " close_reason=closed\n"))
+	suite.Contains(line, " stream_id=stream client_ip=10.0.0.10 dc=-2 secret_index=1 bytes_up=10 bytes_down=0 ")
+}
+
+func (suite *AccessLogTestSuite) TestUnknownFormat() {
+	_, err := events.NewAccessLog(suite.buf, "xml")
+	suite.Error(err)
+}
+
+func TestAccessLog(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &AccessLogTestSuite{})
+}
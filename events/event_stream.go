@@ -104,6 +104,54 @@ func eventStreamProcessor(ctx context.Context, eventChan <-chan mtglib.Event, ob
 				observer.EventReplayAttack(typedEvt)
 			case mtglib.EventIPListSize:
 				observer.EventIPListSize(typedEvt)
+			case mtglib.EventSecretMatched:
+				observer.EventSecretMatched(typedEvt)
+			case mtglib.EventSecretsConfigured:
+				observer.EventSecretsConfigured(typedEvt)
+			case mtglib.EventDNSQuery:
+				observer.EventDNSQuery(typedEvt)
+			case mtglib.EventDCEndpointFailover:
+				observer.EventDCEndpointFailover(typedEvt)
+			case mtglib.EventProbeTarpitted:
+				observer.EventProbeTarpitted(typedEvt)
+			case mtglib.EventDNSCacheUpdated:
+				observer.EventDNSCacheUpdated(typedEvt)
+			case mtglib.EventHandshakeTooLarge:
+				observer.EventHandshakeTooLarge(typedEvt)
+			case mtglib.EventGeoIPLoadFailed:
+				observer.EventGeoIPLoadFailed(typedEvt)
+			case mtglib.EventUpstreamMirrored:
+				observer.EventUpstreamMirrored(typedEvt)
+			case mtglib.EventGeoIPUpdated:
+				observer.EventGeoIPUpdated(typedEvt)
+			case mtglib.EventClientTraffic:
+				observer.EventClientTraffic(typedEvt)
+			case mtglib.EventIPBanned:
+				observer.EventIPBanned(typedEvt)
+			case mtglib.EventIPUnbanned:
+				observer.EventIPUnbanned(typedEvt)
+			case mtglib.EventMissingSNI:
+				observer.EventMissingSNI(typedEvt)
+			case mtglib.EventDCConnsLimited:
+				observer.EventDCConnsLimited(typedEvt)
+			case mtglib.EventIncompleteHandshake:
+				observer.EventIncompleteHandshake(typedEvt)
+			case mtglib.EventUpstreamProxyStateChanged:
+				observer.EventUpstreamProxyStateChanged(typedEvt)
+			case mtglib.EventHandshakeFinished:
+				observer.EventHandshakeFinished(typedEvt)
+			case mtglib.EventActiveStreams:
+				observer.EventActiveStreams(typedEvt)
+			case mtglib.EventClientRateLimited:
+				observer.EventClientRateLimited(typedEvt)
+			case mtglib.EventIdleTimeout:
+				observer.EventIdleTimeout(typedEvt)
+			case mtglib.EventAntiReplayStats:
+				observer.EventAntiReplayStats(typedEvt)
+			case mtglib.EventDCTraffic:
+				observer.EventDCTraffic(typedEvt)
+			case mtglib.EventTelegramDial:
+				observer.EventTelegramDial(typedEvt)
 			}
 		}
 	}
@@ -16,7 +16,65 @@
 // EventStart for that session yet.
 package events
 
-import "github.com/IceCodeNew/mtg/mtglib"
+import (
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+const (
+	// DefaultUnixDatagramQueueSize is a default max number of records which
+	// are waiting to be sent to AF_UNIX datagram socket.
+	DefaultUnixDatagramQueueSize = 1024
+
+	// UnixDatagramWriteTimeout is a max time period to wait until a
+	// consumer of AF_UNIX datagram socket accepts a record.
+	UnixDatagramWriteTimeout = 100 * time.Millisecond
+
+	// DefaultSSEQueueSize is a default max number of messages which are
+	// waiting to be sent to each subscriber of Server-Sent Events.
+	DefaultSSEQueueSize = 128
+
+	// DefaultRecentEventsSize is a default max number of events which are
+	// kept in memory for recent-events endpoint.
+	DefaultRecentEventsSize = 1000
+
+	// DefaultNATSQueueSize is a default max number of records which are
+	// waiting to be published to NATS.
+	DefaultNATSQueueSize = 1024
+
+	// DefaultNATSSubject is a default prefix of subjects events are
+	// published to.
+	DefaultNATSSubject = "mtg.events"
+
+	// NATSTimeout is a max time period to connect to NATS server or to
+	// publish a single record.
+	NATSTimeout = 5 * time.Second
+
+	// NATSReconnectEach defines how often a publisher tries to reconnect
+	// to unavailable NATS server. Records published meanwhile are lost.
+	NATSReconnectEach = time.Second
+
+	// DefaultJSONQueueSize is a default max number of records which are
+	// waiting to be written to a sink of newline-delimited JSON.
+	DefaultJSONQueueSize = 4096
+
+	// DefaultJSONBatchSize is a default max number of records which are
+	// written to a sink of newline-delimited JSON at once.
+	DefaultJSONBatchSize = 100
+
+	// DefaultJSONFlushInterval is a default max time period a record of
+	// newline-delimited JSON waits for a batch to be filled.
+	DefaultJSONFlushInterval = time.Second
+
+	// DefaultJSONFileMaxBackups is a default number of rotated files of
+	// newline-delimited JSON to keep.
+	DefaultJSONFileMaxBackups = 3
+
+	// JSONWebhookTimeout is a max time period to send a single batch of
+	// newline-delimited JSON to a webhook.
+	JSONWebhookTimeout = 10 * time.Second
+)
 
 // Observer is an instance that listens for the incoming events.
 //
@@ -56,6 +114,91 @@ type Observer interface {
 	// EventIPListSize reacts on incoming mtglib.EventIPListSize
 	EventIPListSize(mtglib.EventIPListSize)
 
+	// EventSecretMatched reacts on incoming mtglib.EventSecretMatched event.
+	EventSecretMatched(mtglib.EventSecretMatched)
+
+	// EventSecretsConfigured reacts on incoming
+	// mtglib.EventSecretsConfigured event.
+	EventSecretsConfigured(mtglib.EventSecretsConfigured)
+
+	// EventDNSQuery reacts on incoming mtglib.EventDNSQuery event.
+	EventDNSQuery(mtglib.EventDNSQuery)
+
+	// EventDCEndpointFailover reacts on incoming
+	// mtglib.EventDCEndpointFailover event.
+	EventDCEndpointFailover(mtglib.EventDCEndpointFailover)
+
+	// EventProbeTarpitted reacts on incoming mtglib.EventProbeTarpitted
+	// event.
+	EventProbeTarpitted(mtglib.EventProbeTarpitted)
+
+	// EventDNSCacheUpdated reacts on incoming mtglib.EventDNSCacheUpdated
+	// event.
+	EventDNSCacheUpdated(mtglib.EventDNSCacheUpdated)
+
+	// EventHandshakeTooLarge reacts on incoming
+	// mtglib.EventHandshakeTooLarge event.
+	EventHandshakeTooLarge(mtglib.EventHandshakeTooLarge)
+
+	// EventGeoIPLoadFailed reacts on incoming mtglib.EventGeoIPLoadFailed
+	// event.
+	EventGeoIPLoadFailed(mtglib.EventGeoIPLoadFailed)
+
+	// EventUpstreamMirrored reacts on incoming mtglib.EventUpstreamMirrored
+	// event.
+	EventUpstreamMirrored(mtglib.EventUpstreamMirrored)
+
+	// EventGeoIPUpdated reacts on incoming mtglib.EventGeoIPUpdated event.
+	EventGeoIPUpdated(mtglib.EventGeoIPUpdated)
+
+	// EventClientTraffic reacts on incoming mtglib.EventClientTraffic event.
+	EventClientTraffic(mtglib.EventClientTraffic)
+
+	// EventIPBanned reacts on incoming mtglib.EventIPBanned event.
+	EventIPBanned(mtglib.EventIPBanned)
+
+	// EventIPUnbanned reacts on incoming mtglib.EventIPUnbanned event.
+	EventIPUnbanned(mtglib.EventIPUnbanned)
+
+	// EventMissingSNI reacts on incoming mtglib.EventMissingSNI event.
+	EventMissingSNI(mtglib.EventMissingSNI)
+
+	// EventDCConnsLimited reacts on incoming mtglib.EventDCConnsLimited
+	// event.
+	EventDCConnsLimited(mtglib.EventDCConnsLimited)
+
+	// EventIncompleteHandshake reacts on incoming
+	// mtglib.EventIncompleteHandshake event.
+	EventIncompleteHandshake(mtglib.EventIncompleteHandshake)
+
+	// EventUpstreamProxyStateChanged reacts on incoming
+	// mtglib.EventUpstreamProxyStateChanged event.
+	EventUpstreamProxyStateChanged(mtglib.EventUpstreamProxyStateChanged)
+
+	// EventHandshakeFinished reacts on incoming
+	// mtglib.EventHandshakeFinished event.
+	EventHandshakeFinished(mtglib.EventHandshakeFinished)
+
+	// EventActiveStreams reacts on incoming mtglib.EventActiveStreams event.
+	EventActiveStreams(mtglib.EventActiveStreams)
+
+	// EventClientRateLimited reacts on incoming
+	// mtglib.EventClientRateLimited event.
+	EventClientRateLimited(mtglib.EventClientRateLimited)
+
+	// EventIdleTimeout reacts on incoming mtglib.EventIdleTimeout event.
+	EventIdleTimeout(mtglib.EventIdleTimeout)
+
+	// EventAntiReplayStats reacts on incoming mtglib.EventAntiReplayStats
+	// event.
+	EventAntiReplayStats(mtglib.EventAntiReplayStats)
+
+	// EventDCTraffic reacts on incoming mtglib.EventDCTraffic event.
+	EventDCTraffic(mtglib.EventDCTraffic)
+
+	// EventTelegramDial reacts on incoming mtglib.EventTelegramDial event.
+	EventTelegramDial(mtglib.EventTelegramDial)
+
 	// Shutdown stop observer. Default event stream guarantees:
 	//   1. If shutdown is executed, it is executed only once
 	//   2. Observer won't receieve any new message after this
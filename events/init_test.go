@@ -45,6 +45,102 @@ func (o *ObserverMock) EventIPListSize(evt mtglib.EventIPListSize) {
 	o.Called(evt)
 }
 
+func (o *ObserverMock) EventSecretMatched(evt mtglib.EventSecretMatched) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventSecretsConfigured(evt mtglib.EventSecretsConfigured) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventDNSQuery(evt mtglib.EventDNSQuery) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventDCEndpointFailover(evt mtglib.EventDCEndpointFailover) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventProbeTarpitted(evt mtglib.EventProbeTarpitted) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventDNSCacheUpdated(evt mtglib.EventDNSCacheUpdated) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventHandshakeTooLarge(evt mtglib.EventHandshakeTooLarge) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventGeoIPLoadFailed(evt mtglib.EventGeoIPLoadFailed) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventUpstreamMirrored(evt mtglib.EventUpstreamMirrored) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventGeoIPUpdated(evt mtglib.EventGeoIPUpdated) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventClientTraffic(evt mtglib.EventClientTraffic) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventIPBanned(evt mtglib.EventIPBanned) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventIPUnbanned(evt mtglib.EventIPUnbanned) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventMissingSNI(evt mtglib.EventMissingSNI) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventDCConnsLimited(evt mtglib.EventDCConnsLimited) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventIncompleteHandshake(evt mtglib.EventIncompleteHandshake) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventUpstreamProxyStateChanged(evt mtglib.EventUpstreamProxyStateChanged) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventHandshakeFinished(evt mtglib.EventHandshakeFinished) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventActiveStreams(evt mtglib.EventActiveStreams) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventClientRateLimited(evt mtglib.EventClientRateLimited) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventIdleTimeout(evt mtglib.EventIdleTimeout) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventAntiReplayStats(evt mtglib.EventAntiReplayStats) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventDCTraffic(evt mtglib.EventDCTraffic) {
+	o.Called(evt)
+}
+
+func (o *ObserverMock) EventTelegramDial(evt mtglib.EventTelegramDial) {
+	o.Called(evt)
+}
+
 func (o *ObserverMock) Shutdown() {
 	o.Called()
 }
@@ -0,0 +1,154 @@
+package events
+
+import "github.com/IceCodeNew/mtg/mtglib"
+
+// jsonRecord is a JSON representation of the event which is sent to
+// external consumers.
+type jsonRecord struct {
+	Type      string       `json:"type"`
+	StreamID  string       `json:"streamId,omitempty"`
+	Timestamp int64        `json:"timestamp"`
+	Dropped   uint64       `json:"dropped"`
+	Failed    uint64       `json:"failed,omitempty"`
+	Event     mtglib.Event `json:"event"`
+}
+
+// jsonObserver passes each event with a name of its type to a given
+// callback. Callbacks usually serialize it as a [jsonRecord].
+type jsonObserver struct {
+	send func(eventType string, evt mtglib.Event)
+}
+
+func (j jsonObserver) EventStart(evt mtglib.EventStart) {
+	j.send("EventStart", evt)
+}
+
+func (j jsonObserver) EventFinish(evt mtglib.EventFinish) {
+	j.send("EventFinish", evt)
+}
+
+func (j jsonObserver) EventConnectedToDC(evt mtglib.EventConnectedToDC) {
+	j.send("EventConnectedToDC", evt)
+}
+
+func (j jsonObserver) EventDomainFronting(evt mtglib.EventDomainFronting) {
+	j.send("EventDomainFronting", evt)
+}
+
+func (j jsonObserver) EventTraffic(evt mtglib.EventTraffic) {
+	j.send("EventTraffic", evt)
+}
+
+func (j jsonObserver) EventConcurrencyLimited(evt mtglib.EventConcurrencyLimited) {
+	j.send("EventConcurrencyLimited", evt)
+}
+
+func (j jsonObserver) EventIPBlocklisted(evt mtglib.EventIPBlocklisted) {
+	j.send("EventIPBlocklisted", evt)
+}
+
+func (j jsonObserver) EventReplayAttack(evt mtglib.EventReplayAttack) {
+	j.send("EventReplayAttack", evt)
+}
+
+func (j jsonObserver) EventIPListSize(evt mtglib.EventIPListSize) {
+	j.send("EventIPListSize", evt)
+}
+
+func (j jsonObserver) EventSecretMatched(evt mtglib.EventSecretMatched) {
+	j.send("EventSecretMatched", evt)
+}
+
+func (j jsonObserver) EventSecretsConfigured(evt mtglib.EventSecretsConfigured) {
+	j.send("EventSecretsConfigured", evt)
+}
+
+func (j jsonObserver) EventDNSQuery(evt mtglib.EventDNSQuery) {
+	j.send("EventDNSQuery", evt)
+}
+
+func (j jsonObserver) EventDCEndpointFailover(evt mtglib.EventDCEndpointFailover) {
+	j.send("EventDCEndpointFailover", evt)
+}
+
+func (j jsonObserver) EventProbeTarpitted(evt mtglib.EventProbeTarpitted) {
+	j.send("EventProbeTarpitted", evt)
+}
+
+func (j jsonObserver) EventDNSCacheUpdated(evt mtglib.EventDNSCacheUpdated) {
+	j.send("EventDNSCacheUpdated", evt)
+}
+
+func (j jsonObserver) EventHandshakeTooLarge(evt mtglib.EventHandshakeTooLarge) {
+	j.send("EventHandshakeTooLarge", evt)
+}
+
+func (j jsonObserver) EventGeoIPLoadFailed(evt mtglib.EventGeoIPLoadFailed) {
+	j.send("EventGeoIPLoadFailed", evt)
+}
+
+func (j jsonObserver) EventUpstreamMirrored(evt mtglib.EventUpstreamMirrored) {
+	j.send("EventUpstreamMirrored", evt)
+}
+
+func (j jsonObserver) EventGeoIPUpdated(evt mtglib.EventGeoIPUpdated) {
+	j.send("EventGeoIPUpdated", evt)
+}
+
+func (j jsonObserver) EventClientTraffic(evt mtglib.EventClientTraffic) {
+	j.send("EventClientTraffic", evt)
+}
+
+func (j jsonObserver) EventIPBanned(evt mtglib.EventIPBanned) {
+	j.send("EventIPBanned", evt)
+}
+
+func (j jsonObserver) EventIPUnbanned(evt mtglib.EventIPUnbanned) {
+	j.send("EventIPUnbanned", evt)
+}
+
+func (j jsonObserver) EventMissingSNI(evt mtglib.EventMissingSNI) {
+	j.send("EventMissingSNI", evt)
+}
+
+func (j jsonObserver) EventDCConnsLimited(evt mtglib.EventDCConnsLimited) {
+	j.send("EventDCConnsLimited", evt)
+}
+
+func (j jsonObserver) EventIncompleteHandshake(evt mtglib.EventIncompleteHandshake) {
+	j.send("EventIncompleteHandshake", evt)
+}
+
+func (j jsonObserver) EventUpstreamProxyStateChanged(evt mtglib.EventUpstreamProxyStateChanged) {
+	j.send("EventUpstreamProxyStateChanged", evt)
+}
+
+func (j jsonObserver) EventHandshakeFinished(evt mtglib.EventHandshakeFinished) {
+	j.send("EventHandshakeFinished", evt)
+}
+
+func (j jsonObserver) EventActiveStreams(evt mtglib.EventActiveStreams) {
+	j.send("EventActiveStreams", evt)
+}
+
+func (j jsonObserver) EventClientRateLimited(evt mtglib.EventClientRateLimited) {
+	j.send("EventClientRateLimited", evt)
+}
+
+func (j jsonObserver) EventIdleTimeout(evt mtglib.EventIdleTimeout) {
+	j.send("EventIdleTimeout", evt)
+}
+
+func (j jsonObserver) EventAntiReplayStats(evt mtglib.EventAntiReplayStats) {
+	j.send("EventAntiReplayStats", evt)
+}
+
+func (j jsonObserver) EventDCTraffic(evt mtglib.EventDCTraffic) {
+	j.send("EventDCTraffic", evt)
+}
+
+func (j jsonObserver) EventTelegramDial(evt mtglib.EventTelegramDial) {
+	j.send("EventTelegramDial", evt)
+}
+
+func (j jsonObserver) Shutdown() {}
@@ -0,0 +1,336 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// natsEventTypes are events which describe a lifecycle of the connection.
+// Only they are published: traffic events are too frequent for a message
+// broker.
+var natsEventTypes = map[string]bool{
+	"EventStart":          true,
+	"EventSecretMatched":  true,
+	"EventConnectedToDC":  true,
+	"EventDomainFronting": true,
+	"EventFinish":         true,
+}
+
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// natsConn is a minimal client of NATS text protocol which can only publish
+// messages. It answers server pings in a background.
+type natsConn struct {
+	conn       net.Conn
+	writeMutex sync.Mutex
+}
+
+func (n *natsConn) Publish(subject string, payload []byte) error {
+	n.writeMutex.Lock()
+	defer n.writeMutex.Unlock()
+
+	n.conn.SetWriteDeadline(time.Now().Add(NATSTimeout)) //nolint: errcheck
+
+	buf := make([]byte, 0, len(subject)+len(payload)+32) //nolint: gomnd
+	buf = append(buf, fmt.Sprintf("PUB %s %d\r\n", subject, len(payload))...)
+	buf = append(buf, payload...)
+	buf = append(buf, "\r\n"...)
+
+	if _, err := n.conn.Write(buf); err != nil {
+		return fmt.Errorf("cannot publish a message: %w", err)
+	}
+
+	return nil
+}
+
+func (n *natsConn) Close() {
+	n.conn.Close()
+}
+
+func (n *natsConn) readLoop(reader *bufio.Reader) {
+	defer n.Close()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch strings.TrimSpace(line) {
+		case "PING":
+			n.writeMutex.Lock()
+			n.conn.SetWriteDeadline(time.Now().Add(NATSTimeout)) //nolint: errcheck
+			_, err = n.conn.Write([]byte("PONG\r\n"))
+			n.writeMutex.Unlock()
+
+			if err != nil {
+				return
+			}
+		default:
+			if strings.HasPrefix(line, "-ERR") {
+				return
+			}
+		}
+	}
+}
+
+func dialNATS(ctx context.Context, address, token string) (*natsConn, error) {
+	dialer := net.Dialer{
+		Timeout: NATSTimeout,
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial to %s: %w", address, err)
+	}
+
+	conn.SetDeadline(time.Now().Add(NATSTimeout)) //nolint: errcheck
+
+	reader := bufio.NewReader(conn)
+
+	if err := natsHandshake(conn, reader, token); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{}) //nolint: errcheck
+
+	rv := &natsConn{
+		conn: conn,
+	}
+
+	go rv.readLoop(reader)
+
+	return rv, nil
+}
+
+func natsHandshake(conn net.Conn, reader *bufio.Reader, token string) error {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("cannot read server info: %w", err)
+	}
+
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected server greeting: %s", strings.TrimSpace(line))
+	}
+
+	info := natsInfo{}
+
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("cannot parse server info: %w", err)
+	}
+
+	if info.TLSRequired {
+		return fmt.Errorf("server requires tls which is not supported")
+	}
+
+	connect, _ := json.Marshal(natsConnect{
+		Name:      "mtg",
+		Lang:      "go",
+		Version:   "1",
+		AuthToken: token,
+	})
+
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		return fmt.Errorf("cannot send connect: %w", err)
+	}
+
+	for {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("cannot read connect response: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server has rejected connection: %s", line)
+		}
+	}
+}
+
+// NATSFactory is an [ObserverFactory] source which publishes connection
+// lifecycle events (start, matched secret, connection to DC or to a
+// fronting domain, finish) as JSON records to NATS. Format of the records
+// is the same as for [UnixDatagramFactory]. Each event is published to a
+// '<subject>.<event type>' subject, for example, 'mtg.events.EventStart'.
+//
+// It never blocks an event stream: records are queued and published in a
+// background. If a queue is full, records are dropped. If a server is not
+// available or a connection breaks, records are lost. This is an 'at most
+// once' delivery, the same as core NATS provides.
+type NATSFactory struct {
+	// they have to be the first fields for atomic operations on 32-bit
+	// platforms
+	dropped uint64
+	failed  uint64
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	address   string
+	subject   string
+	token     string
+	queue     chan natsMessage
+	done      chan struct{}
+}
+
+// Make builds a new observer.
+func (n *NATSFactory) Make() Observer {
+	return jsonObserver{
+		send: n.send,
+	}
+}
+
+// Dropped returns a number of records which were dropped because a queue
+// was full.
+func (n *NATSFactory) Dropped() uint64 {
+	return atomic.LoadUint64(&n.dropped)
+}
+
+// Failed returns a number of records which could not be published because
+// a server was not available or a connection was broken.
+func (n *NATSFactory) Failed() uint64 {
+	return atomic.LoadUint64(&n.failed)
+}
+
+// Close stops publishing records.
+func (n *NATSFactory) Close() {
+	n.ctxCancel()
+	<-n.done
+}
+
+func (n *NATSFactory) send(eventType string, evt mtglib.Event) {
+	if !natsEventTypes[eventType] {
+		return
+	}
+
+	record, err := json.Marshal(jsonRecord{
+		Type:      eventType,
+		StreamID:  evt.StreamID(),
+		Timestamp: evt.Timestamp().UnixMilli(),
+		Dropped:   n.Dropped(),
+		Failed:    n.Failed(),
+		Event:     evt,
+	})
+	if err != nil {
+		atomic.AddUint64(&n.dropped, 1)
+
+		return
+	}
+
+	select {
+	case n.queue <- natsMessage{subject: n.subject + "." + eventType, payload: record}:
+	default:
+		atomic.AddUint64(&n.dropped, 1)
+	}
+}
+
+func (n *NATSFactory) run() {
+	defer close(n.done)
+
+	var (
+		conn        *natsConn
+		reconnectAt time.Time
+	)
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case message := <-n.queue:
+			if conn == nil && time.Now().After(reconnectAt) {
+				newConn, err := dialNATS(n.ctx, n.address, n.token)
+				if err != nil {
+					reconnectAt = time.Now().Add(NATSReconnectEach)
+				}
+
+				conn = newConn
+			}
+
+			if conn == nil {
+				atomic.AddUint64(&n.failed, 1)
+
+				continue
+			}
+
+			if err := conn.Publish(message.subject, message.payload); err != nil {
+				atomic.AddUint64(&n.failed, 1)
+
+				conn.Close()
+				conn = nil
+			}
+		}
+	}
+}
+
+// NewNATS creates a factory of observers which publish events to NATS
+// server at a given host:port address. A server is not required to be
+// available beforehand: mtg reconnects if it is not.
+//
+// subject is a prefix of subjects, an empty one means [DefaultNATSSubject].
+// token is used for token authentication, an empty token means no
+// authentication. queueSize is a max number of records waiting to be
+// published. 0 means [DefaultNATSQueueSize].
+func NewNATS(address, subject, token string, queueSize uint) (*NATSFactory, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("incorrect address %s: %w", address, err)
+	}
+
+	if subject == "" {
+		subject = DefaultNATSSubject
+	}
+
+	if queueSize == 0 {
+		queueSize = DefaultNATSQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	factory := &NATSFactory{
+		ctx:       ctx,
+		ctxCancel: cancel,
+		address:   address,
+		subject:   subject,
+		token:     token,
+		queue:     make(chan natsMessage, queueSize),
+		done:      make(chan struct{}),
+	}
+
+	go factory.run()
+
+	return factory, nil
+}
@@ -0,0 +1,282 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type natsTestMessage struct {
+	subject string
+	record  unixDatagramTestRecord
+}
+
+type natsFakeServer struct {
+	listener net.Listener
+	token    string
+	connects chan map[string]interface{}
+	messages chan natsTestMessage
+	pongs    chan struct{}
+	conns    chan net.Conn
+}
+
+func (s *natsFakeServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *natsFakeServer) Close() {
+	s.listener.Close()
+}
+
+func (s *natsFakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *natsFakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"auth_required\":%t}\r\n", s.token != "")
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			connect := map[string]interface{}{}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &connect) //nolint: errcheck
+
+			s.connects <- connect
+
+			if token, _ := connect["auth_token"].(string); token != s.token {
+				fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+
+				return
+			}
+
+			s.conns <- conn
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case line == "PONG":
+			s.pongs <- struct{}{}
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+
+			message := natsTestMessage{subject: fields[1]}
+			json.Unmarshal(payload[:size], &message.record) //nolint: errcheck
+
+			s.messages <- message
+		}
+	}
+}
+
+func natsNewFakeServer(token string) *natsFakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	rv := &natsFakeServer{
+		listener: listener,
+		token:    token,
+		connects: make(chan map[string]interface{}, 10),
+		messages: make(chan natsTestMessage, 10),
+		pongs:    make(chan struct{}, 10),
+		conns:    make(chan net.Conn, 10),
+	}
+
+	go rv.serve()
+
+	return rv
+}
+
+type NATSTestSuite struct {
+	suite.Suite
+
+	server  *natsFakeServer
+	factory *events.NATSFactory
+}
+
+func (suite *NATSTestSuite) SetupTest() {
+	suite.server = natsNewFakeServer("")
+
+	factory, err := events.NewNATS(suite.server.Addr(), "", "", 0)
+	suite.NoError(err)
+
+	suite.factory = factory
+}
+
+func (suite *NATSTestSuite) TearDownTest() {
+	suite.factory.Close()
+	suite.server.Close()
+}
+
+func (suite *NATSTestSuite) ReadMessage() natsTestMessage {
+	select {
+	case message := <-suite.server.messages:
+		return message
+	case <-time.After(time.Second):
+		suite.FailNow("no message was published")
+	}
+
+	return natsTestMessage{}
+}
+
+func (suite *NATSTestSuite) TestIncorrectAddress() {
+	_, err := events.NewNATS("localhost", "", "", 0)
+	suite.Error(err)
+}
+
+func (suite *NATSTestSuite) TestPublish() {
+	observer := suite.factory.Make()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID", 100, true))
+	observer.EventFinish(mtglib.NewEventFinish("connID"))
+
+	message := suite.ReadMessage()
+	suite.Equal(events.DefaultNATSSubject+".EventStart", message.subject)
+	suite.Equal("EventStart", message.record.Type)
+	suite.Equal("connID", message.record.StreamID)
+	suite.JSONEq(`{"RemoteIP": "10.0.0.10"}`, string(message.record.Event))
+
+	message = suite.ReadMessage()
+	suite.Equal(events.DefaultNATSSubject+".EventFinish", message.subject)
+
+	connect := <-suite.server.connects
+	suite.Equal("mtg", connect["name"])
+	suite.Equal(false, connect["verbose"])
+	suite.NotContains(connect, "auth_token")
+
+	suite.EqualValues(0, suite.factory.Dropped())
+	suite.EqualValues(0, suite.factory.Failed())
+	suite.EqualValues(0, message.record.Dropped)
+	suite.EqualValues(0, message.record.Failed)
+}
+
+func (suite *NATSTestSuite) TestFailedAfterBrokenConnection() {
+	observer := suite.factory.Make()
+
+	observer.EventFinish(mtglib.NewEventFinish("connID"))
+	suite.ReadMessage()
+
+	(<-suite.server.conns).Close()
+
+	suite.Eventually(func() bool {
+		observer.EventFinish(mtglib.NewEventFinish("connID"))
+
+		return suite.factory.Failed() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	failed := suite.factory.Failed()
+
+	observer.EventFinish(mtglib.NewEventFinish("connID"))
+
+	for {
+		message := suite.ReadMessage()
+		if message.record.Failed >= failed {
+			suite.EqualValues(0, message.record.Dropped)
+
+			break
+		}
+	}
+}
+
+func (suite *NATSTestSuite) TestServerPing() {
+	suite.factory.Make().EventFinish(mtglib.NewEventFinish("connID"))
+	suite.ReadMessage()
+
+	conn := <-suite.server.conns
+	fmt.Fprint(conn, "PING\r\n")
+
+	select {
+	case <-suite.server.pongs:
+	case <-time.After(time.Second):
+		suite.Fail("ping was not answered")
+	}
+}
+
+func (suite *NATSTestSuite) TestToken() {
+	server := natsNewFakeServer("token")
+	defer server.Close()
+
+	factory, err := events.NewNATS(server.Addr(), "custom", "token", 0)
+	suite.NoError(err)
+
+	defer factory.Close()
+
+	factory.Make().EventFinish(mtglib.NewEventFinish("connID"))
+
+	select {
+	case message := <-server.messages:
+		suite.Equal("custom.EventFinish", message.subject)
+	case <-time.After(time.Second):
+		suite.Fail("no message was published")
+	}
+
+	suite.Equal("token", (<-server.connects)["auth_token"])
+}
+
+func (suite *NATSTestSuite) TestWrongToken() {
+	server := natsNewFakeServer("token")
+	defer server.Close()
+
+	factory, err := events.NewNATS(server.Addr(), "", "wrong", 0)
+	suite.NoError(err)
+
+	defer factory.Close()
+
+	factory.Make().EventFinish(mtglib.NewEventFinish("connID"))
+
+	suite.Eventually(func() bool {
+		return factory.Failed() == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *NATSTestSuite) TestNoServer() {
+	suite.server.Close()
+
+	observer := suite.factory.Make()
+
+	for i := 0; i < 100; i++ {
+		observer.EventFinish(mtglib.NewEventFinish("connID"))
+	}
+
+	suite.Eventually(func() bool {
+		return suite.factory.Dropped()+suite.factory.Failed() == 100
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNATS(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &NATSTestSuite{})
+}
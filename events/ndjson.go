@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// JSONSink is a destination of newline-delimited JSON records.
+type JSONSink interface {
+	// Write writes a batch of records. Each record is a JSON object
+	// followed by a newline. If an error is returned, a whole batch is
+	// considered lost.
+	Write(batch []byte) error
+}
+
+// JSONObserverFactory is an [ObserverFactory] source which serializes
+// events as newline-delimited JSON [jsonRecord] and writes them to a
+// [JSONSink] in batches.
+//
+// It never blocks an event stream: records are queued and written in a
+// background. A batch is written when it has enough records or when a
+// flush interval has passed. If a queue is full because a sink is slow,
+// records are dropped. A total number of dropped records is added to each
+// record so a consumer can detect gaps.
+type JSONObserverFactory struct {
+	// has to be the first field to be 64-bit aligned on 32-bit platforms.
+	dropped uint64
+
+	ctx           context.Context
+	ctxCancel     context.CancelFunc
+	sink          JSONSink
+	queue         chan []byte
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+}
+
+// Make builds a new observer.
+func (j *JSONObserverFactory) Make() Observer {
+	return jsonObserver{
+		send: j.send,
+	}
+}
+
+// Dropped returns a number of records which were dropped because a queue
+// was full or a sink has failed to write them.
+func (j *JSONObserverFactory) Dropped() uint64 {
+	return atomic.LoadUint64(&j.dropped)
+}
+
+// Close writes queued records and stops writing.
+func (j *JSONObserverFactory) Close() {
+	j.ctxCancel()
+	<-j.done
+}
+
+func (j *JSONObserverFactory) send(eventType string, evt mtglib.Event) {
+	record, err := json.Marshal(jsonRecord{
+		Type:      eventType,
+		StreamID:  evt.StreamID(),
+		Timestamp: evt.Timestamp().UnixMilli(),
+		Dropped:   j.Dropped(),
+		Event:     evt,
+	})
+	if err != nil {
+		atomic.AddUint64(&j.dropped, 1)
+
+		return
+	}
+
+	select {
+	case j.queue <- record:
+	default:
+		atomic.AddUint64(&j.dropped, 1)
+	}
+}
+
+func (j *JSONObserverFactory) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.flushInterval)
+	defer ticker.Stop()
+
+	batch := &bytes.Buffer{}
+	batchLen := 0
+
+	add := func(record []byte) {
+		batch.Write(record)
+		batch.WriteByte('\n')
+
+		batchLen++
+	}
+
+	flush := func() {
+		if batchLen == 0 {
+			return
+		}
+
+		if err := j.sink.Write(batch.Bytes()); err != nil {
+			atomic.AddUint64(&j.dropped, uint64(batchLen))
+		}
+
+		batch.Reset()
+
+		batchLen = 0
+	}
+
+	for {
+		select {
+		case <-j.ctx.Done():
+			for {
+				select {
+				case record := <-j.queue:
+					add(record)
+				default:
+					flush()
+
+					return
+				}
+			}
+		case <-ticker.C:
+			flush()
+		case record := <-j.queue:
+			add(record)
+
+			if batchLen >= j.batchSize {
+				flush()
+			}
+		}
+	}
+}
+
+// NewJSONObserver creates a factory of observers which write events to a
+// given sink as newline-delimited JSON.
+//
+// queueSize is a max number of records waiting to be written, 0 means
+// [DefaultJSONQueueSize]. batchSize is a max number of records in a single
+// write, 0 means [DefaultJSONBatchSize]. flushInterval is a max time period
+// a record waits for a batch to be filled, 0 means
+// [DefaultJSONFlushInterval].
+func NewJSONObserver(sink JSONSink, queueSize, batchSize uint,
+	flushInterval time.Duration,
+) *JSONObserverFactory {
+	if queueSize == 0 {
+		queueSize = DefaultJSONQueueSize
+	}
+
+	if batchSize == 0 {
+		batchSize = DefaultJSONBatchSize
+	}
+
+	if flushInterval == 0 {
+		flushInterval = DefaultJSONFlushInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	factory := &JSONObserverFactory{
+		ctx:           ctx,
+		ctxCancel:     cancel,
+		sink:          sink,
+		queue:         make(chan []byte, queueSize),
+		batchSize:     int(batchSize),
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+
+	go factory.run()
+
+	return factory
+}
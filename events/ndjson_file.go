@@ -0,0 +1,131 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// JSONFileSink is a [JSONSink] which appends records to a file. If a file
+// exceeds a max size, it is rotated: file becomes file.1, file.1 becomes
+// file.2 and so on. The oldest backup is removed.
+type JSONFileSink struct {
+	mutex      sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// Write appends a batch to a file.
+func (j *JSONFileSink) Write(batch []byte) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.file != nil && j.maxSize > 0 && j.size > 0 && j.size+int64(len(batch)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if j.file == nil {
+		if err := j.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := j.file.Write(batch)
+	j.size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("cannot write to %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+// Close closes a file.
+func (j *JSONFileSink) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+
+	err := j.file.Close()
+	j.file = nil
+
+	return err //nolint: wrapcheck
+}
+
+func (j *JSONFileSink) open() error {
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640) //nolint: gomnd
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", j.path, err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("cannot stat %s: %w", j.path, err)
+	}
+
+	j.file = file
+	j.size = stat.Size()
+
+	return nil
+}
+
+func (j *JSONFileSink) rotate() error {
+	j.file.Close()
+	j.file = nil
+
+	for i := j.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(j.backupPath(i), j.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate %s: %w", j.backupPath(i), err)
+		}
+	}
+
+	if err := os.Rename(j.path, j.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot rotate %s: %w", j.path, err)
+	}
+
+	return nil
+}
+
+func (j *JSONFileSink) backupPath(index int) string {
+	return j.path + "." + strconv.Itoa(index)
+}
+
+// NewJSONFileSink creates a sink which appends records to a file at a
+// given path. A file is created if it does not exist.
+//
+// maxSize is a size of file in bytes which triggers a rotation, 0 means no
+// rotation. maxBackups is a number of rotated files to keep, 0 means
+// [DefaultJSONFileMaxBackups].
+func NewJSONFileSink(path string, maxSize int64, maxBackups uint) (*JSONFileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path is empty")
+	}
+
+	if maxBackups == 0 {
+		maxBackups = DefaultJSONFileMaxBackups
+	}
+
+	sink := &JSONFileSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: int(maxBackups),
+	}
+
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
@@ -0,0 +1,79 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/stretchr/testify/suite"
+)
+
+type JSONFileSinkTestSuite struct {
+	suite.Suite
+
+	path string
+}
+
+func (suite *JSONFileSinkTestSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "events.json")
+}
+
+func (suite *JSONFileSinkTestSuite) ReadFile(path string) string {
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+
+	return string(data)
+}
+
+func (suite *JSONFileSinkTestSuite) TestEmptyPath() {
+	_, err := events.NewJSONFileSink("", 0, 0)
+	suite.Error(err)
+}
+
+func (suite *JSONFileSinkTestSuite) TestAppend() {
+	suite.NoError(os.WriteFile(suite.path, []byte("{\"a\":1}\n"), 0o600))
+
+	sink, err := events.NewJSONFileSink(suite.path, 0, 0)
+	suite.Require().NoError(err)
+
+	defer sink.Close()
+
+	suite.NoError(sink.Write([]byte("{\"b\":2}\n")))
+	suite.NoError(sink.Write([]byte("{\"c\":3}\n")))
+
+	suite.Equal("{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n", suite.ReadFile(suite.path))
+}
+
+func (suite *JSONFileSinkTestSuite) TestRotate() {
+	sink, err := events.NewJSONFileSink(suite.path, 10, 2)
+	suite.Require().NoError(err)
+
+	defer sink.Close()
+
+	suite.NoError(sink.Write([]byte("{\"a\":1}\n")))
+	suite.NoError(sink.Write([]byte("{\"b\":2}\n")))
+	suite.NoError(sink.Write([]byte("{\"c\":3}\n")))
+	suite.NoError(sink.Write([]byte("{\"d\":4}\n")))
+
+	suite.Equal("{\"d\":4}\n", suite.ReadFile(suite.path))
+	suite.Equal("{\"c\":3}\n", suite.ReadFile(suite.path+".1"))
+	suite.Equal("{\"b\":2}\n", suite.ReadFile(suite.path+".2"))
+	suite.NoFileExists(suite.path + ".3")
+}
+
+func (suite *JSONFileSinkTestSuite) TestReopen() {
+	sink, err := events.NewJSONFileSink(suite.path, 0, 0)
+	suite.Require().NoError(err)
+
+	suite.NoError(sink.Close())
+	suite.NoError(sink.Write([]byte("{\"a\":1}\n")))
+	suite.NoError(sink.Close())
+
+	suite.Equal("{\"a\":1}\n", suite.ReadFile(suite.path))
+}
+
+func TestJSONFileSink(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &JSONFileSinkTestSuite{})
+}
@@ -0,0 +1,138 @@
+package events_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type jsonSinkMock struct {
+	mutex   sync.Mutex
+	batches [][]byte
+	err     error
+	block   chan struct{}
+}
+
+func (j *jsonSinkMock) Write(batch []byte) error {
+	if j.block != nil {
+		<-j.block
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.batches = append(j.batches, append([]byte{}, batch...))
+
+	return j.err
+}
+
+func (j *jsonSinkMock) Batches() [][]byte {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return append([][]byte{}, j.batches...)
+}
+
+type JSONObserverTestSuite struct {
+	suite.Suite
+
+	sink *jsonSinkMock
+}
+
+func (suite *JSONObserverTestSuite) SetupTest() {
+	suite.sink = &jsonSinkMock{}
+}
+
+func (suite *JSONObserverTestSuite) TestBatchSize() {
+	factory := events.NewJSONObserver(suite.sink, 0, 2, time.Minute)
+	defer factory.Close()
+
+	observer := factory.Make()
+	evt := mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10"))
+
+	observer.EventStart(evt)
+	observer.EventFinish(mtglib.NewEventFinish("connID"))
+
+	suite.Eventually(func() bool {
+		return len(suite.sink.Batches()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	lines := bytes.Split(bytes.TrimSuffix(suite.sink.Batches()[0], []byte{'\n'}), []byte{'\n'})
+	suite.Len(lines, 2)
+
+	record := unixDatagramTestRecord{}
+	suite.NoError(json.Unmarshal(lines[0], &record))
+	suite.Equal("EventStart", record.Type)
+	suite.Equal("connID", record.StreamID)
+	suite.Equal(evt.Timestamp().UnixMilli(), record.Timestamp)
+	suite.JSONEq(`{"RemoteIP": "10.0.0.10"}`, string(record.Event))
+
+	suite.NoError(json.Unmarshal(lines[1], &record))
+	suite.Equal("EventFinish", record.Type)
+}
+
+func (suite *JSONObserverTestSuite) TestFlushInterval() {
+	factory := events.NewJSONObserver(suite.sink, 0, 0, 50*time.Millisecond)
+	defer factory.Close()
+
+	factory.Make().EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+
+	suite.Eventually(func() bool {
+		return len(suite.sink.Batches()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *JSONObserverTestSuite) TestClose() {
+	factory := events.NewJSONObserver(suite.sink, 0, 0, time.Minute)
+
+	factory.Make().EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+	factory.Close()
+
+	suite.Len(suite.sink.Batches(), 1)
+}
+
+func (suite *JSONObserverTestSuite) TestSinkFailure() {
+	suite.sink.err = errors.New("failure")
+
+	factory := events.NewJSONObserver(suite.sink, 0, 3, time.Minute)
+	defer factory.Close()
+
+	observer := factory.Make()
+
+	for i := 0; i < 3; i++ {
+		observer.EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+	}
+
+	suite.Eventually(func() bool {
+		return factory.Dropped() == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *JSONObserverTestSuite) TestOverflow() {
+	suite.sink.block = make(chan struct{})
+
+	factory := events.NewJSONObserver(suite.sink, 2, 1, time.Minute)
+	observer := factory.Make()
+
+	for i := 0; i < 100; i++ {
+		observer.EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+	}
+
+	suite.GreaterOrEqual(factory.Dropped(), uint64(97))
+
+	close(suite.sink.block)
+	factory.Close()
+}
+
+func TestJSONObserver(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &JSONObserverTestSuite{})
+}
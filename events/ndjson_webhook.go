@@ -0,0 +1,62 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// JSONWebhookSink is a [JSONSink] which sends each batch of records as a
+// body of HTTP POST request. A body has application/x-ndjson content type.
+// Any response with non-2xx status code is considered as failure.
+type JSONWebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// Write sends a batch to a webhook.
+func (j *JSONWebhookSink) Write(batch []byte) error {
+	req, err := http.NewRequest(http.MethodPost, j.url, bytes.NewReader(batch))
+	if err != nil {
+		return fmt.Errorf("cannot build a request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send a request: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body) //nolint: errcheck
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// NewJSONWebhookSink creates a sink which sends records to a given http
+// or https URL. Each request has to finish within [JSONWebhookTimeout].
+func NewJSONWebhookSink(webhookURL string) (*JSONWebhookSink, error) {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect url %s: %w", webhookURL, err)
+	}
+
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("incorrect url %s", webhookURL)
+	}
+
+	return &JSONWebhookSink{
+		url: webhookURL,
+		client: &http.Client{
+			Timeout: JSONWebhookTimeout,
+		},
+	}, nil
+}
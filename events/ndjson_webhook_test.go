@@ -0,0 +1,75 @@
+package events_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/stretchr/testify/suite"
+)
+
+type JSONWebhookSinkTestSuite struct {
+	suite.Suite
+
+	server      *httptest.Server
+	statusCode  int
+	contentType string
+	body        string
+}
+
+func (suite *JSONWebhookSinkTestSuite) SetupTest() {
+	suite.statusCode = http.StatusNoContent
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		suite.contentType = r.Header.Get("Content-Type")
+		suite.body = string(body)
+
+		w.WriteHeader(suite.statusCode)
+	}))
+}
+
+func (suite *JSONWebhookSinkTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *JSONWebhookSinkTestSuite) TestIncorrectURL() {
+	for _, value := range []string{"", "ftp://example.com", "http://", "://"} {
+		_, err := events.NewJSONWebhookSink(value)
+		suite.Error(err, value)
+	}
+}
+
+func (suite *JSONWebhookSinkTestSuite) TestWrite() {
+	sink, err := events.NewJSONWebhookSink(suite.server.URL)
+	suite.Require().NoError(err)
+
+	suite.NoError(sink.Write([]byte("{\"a\":1}\n{\"b\":2}\n")))
+	suite.Equal("application/x-ndjson", suite.contentType)
+	suite.Equal("{\"a\":1}\n{\"b\":2}\n", suite.body)
+}
+
+func (suite *JSONWebhookSinkTestSuite) TestBadStatusCode() {
+	suite.statusCode = http.StatusServiceUnavailable
+
+	sink, err := events.NewJSONWebhookSink(suite.server.URL)
+	suite.Require().NoError(err)
+
+	suite.Error(sink.Write([]byte("{\"a\":1}\n")))
+}
+
+func (suite *JSONWebhookSinkTestSuite) TestUnavailable() {
+	sink, err := events.NewJSONWebhookSink(suite.server.URL)
+	suite.Require().NoError(err)
+
+	suite.server.Close()
+
+	suite.Error(sink.Write([]byte("{\"a\":1}\n")))
+}
+
+func TestJSONWebhookSink(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &JSONWebhookSinkTestSuite{})
+}
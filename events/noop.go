@@ -17,16 +17,40 @@ func NewNoopStream() mtglib.EventStream {
 
 type noopObserver struct{}
 
-func (n noopObserver) EventStart(_ mtglib.EventStart)                           {}
-func (n noopObserver) EventConnectedToDC(_ mtglib.EventConnectedToDC)           {}
-func (n noopObserver) EventDomainFronting(_ mtglib.EventDomainFronting)         {}
-func (n noopObserver) EventTraffic(_ mtglib.EventTraffic)                       {}
-func (n noopObserver) EventFinish(_ mtglib.EventFinish)                         {}
-func (n noopObserver) EventConcurrencyLimited(_ mtglib.EventConcurrencyLimited) {}
-func (n noopObserver) EventIPBlocklisted(_ mtglib.EventIPBlocklisted)           {}
-func (n noopObserver) EventReplayAttack(_ mtglib.EventReplayAttack)             {}
-func (n noopObserver) EventIPListSize(_ mtglib.EventIPListSize)                 {}
-func (n noopObserver) Shutdown()                                                {}
+func (n noopObserver) EventStart(_ mtglib.EventStart)                                         {}
+func (n noopObserver) EventConnectedToDC(_ mtglib.EventConnectedToDC)                         {}
+func (n noopObserver) EventDomainFronting(_ mtglib.EventDomainFronting)                       {}
+func (n noopObserver) EventTraffic(_ mtglib.EventTraffic)                                     {}
+func (n noopObserver) EventFinish(_ mtglib.EventFinish)                                       {}
+func (n noopObserver) EventConcurrencyLimited(_ mtglib.EventConcurrencyLimited)               {}
+func (n noopObserver) EventIPBlocklisted(_ mtglib.EventIPBlocklisted)                         {}
+func (n noopObserver) EventReplayAttack(_ mtglib.EventReplayAttack)                           {}
+func (n noopObserver) EventIPListSize(_ mtglib.EventIPListSize)                               {}
+func (n noopObserver) EventSecretMatched(_ mtglib.EventSecretMatched)                         {}
+func (n noopObserver) EventSecretsConfigured(_ mtglib.EventSecretsConfigured)                 {}
+func (n noopObserver) EventDNSQuery(_ mtglib.EventDNSQuery)                                   {}
+func (n noopObserver) EventDCEndpointFailover(_ mtglib.EventDCEndpointFailover)               {}
+func (n noopObserver) EventProbeTarpitted(_ mtglib.EventProbeTarpitted)                       {}
+func (n noopObserver) EventDNSCacheUpdated(_ mtglib.EventDNSCacheUpdated)                     {}
+func (n noopObserver) EventHandshakeTooLarge(_ mtglib.EventHandshakeTooLarge)                 {}
+func (n noopObserver) EventGeoIPLoadFailed(_ mtglib.EventGeoIPLoadFailed)                     {}
+func (n noopObserver) EventUpstreamMirrored(_ mtglib.EventUpstreamMirrored)                   {}
+func (n noopObserver) EventGeoIPUpdated(_ mtglib.EventGeoIPUpdated)                           {}
+func (n noopObserver) EventClientTraffic(_ mtglib.EventClientTraffic)                         {}
+func (n noopObserver) EventIPBanned(_ mtglib.EventIPBanned)                                   {}
+func (n noopObserver) EventIPUnbanned(_ mtglib.EventIPUnbanned)                               {}
+func (n noopObserver) EventMissingSNI(_ mtglib.EventMissingSNI)                               {}
+func (n noopObserver) EventDCConnsLimited(_ mtglib.EventDCConnsLimited)                       {}
+func (n noopObserver) EventIncompleteHandshake(_ mtglib.EventIncompleteHandshake)             {}
+func (n noopObserver) EventUpstreamProxyStateChanged(_ mtglib.EventUpstreamProxyStateChanged) {}
+func (n noopObserver) EventHandshakeFinished(_ mtglib.EventHandshakeFinished)                 {}
+func (n noopObserver) EventActiveStreams(_ mtglib.EventActiveStreams)                         {}
+func (n noopObserver) EventClientRateLimited(_ mtglib.EventClientRateLimited)                 {}
+func (n noopObserver) EventIdleTimeout(_ mtglib.EventIdleTimeout)                             {}
+func (n noopObserver) EventAntiReplayStats(_ mtglib.EventAntiReplayStats)                     {}
+func (n noopObserver) EventDCTraffic(_ mtglib.EventDCTraffic)                                 {}
+func (n noopObserver) EventTelegramDial(_ mtglib.EventTelegramDial)                           {}
+func (n noopObserver) Shutdown()                                                              {}
 
 // NewNoopObserver creates an observer which discards each message.
 func NewNoopObserver() Observer {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/IceCodeNew/mtg/events"
 	"github.com/IceCodeNew/mtg/mtglib"
@@ -19,15 +20,39 @@ type NoopTestSuite struct {
 
 func (suite *NoopTestSuite) SetupSuite() {
 	suite.testData = map[string]mtglib.Event{
-		"start":               mtglib.NewEventStart("connID", net.ParseIP("127.0.0.1")),
-		"connected-to-dc":     mtglib.NewEventConnectedToDC("connID", net.ParseIP("127.1.0.1"), 2),
-		"domain-fronting":     mtglib.NewEventDomainFronting("connID"),
-		"traffic":             mtglib.NewEventTraffic("connID", 1000, true),
-		"finish":              mtglib.NewEventFinish("connID"),
-		"concurrency-limited": mtglib.NewEventConcurrencyLimited(),
-		"ip-blacklisted":      mtglib.NewEventIPBlocklisted(net.ParseIP("10.0.0.10")),
-		"replay-attack":       mtglib.NewEventReplayAttack("connID"),
-		"ip-list-size":        mtglib.NewEventIPListSize(10, true),
+		"start":                mtglib.NewEventStart("connID", net.ParseIP("127.0.0.1")),
+		"connected-to-dc":      mtglib.NewEventConnectedToDC("connID", net.ParseIP("127.1.0.1"), 2),
+		"domain-fronting":      mtglib.NewEventDomainFronting("connID"),
+		"traffic":              mtglib.NewEventTraffic("connID", 1000, true),
+		"finish":               mtglib.NewEventFinish("connID"),
+		"concurrency-limited":  mtglib.NewEventConcurrencyLimited(),
+		"ip-blacklisted":       mtglib.NewEventIPBlocklisted(net.ParseIP("10.0.0.10")),
+		"replay-attack":        mtglib.NewEventReplayAttack("connID"),
+		"ip-list-size":         mtglib.NewEventIPListSize(10, true),
+		"secret-matched":       mtglib.NewEventSecretMatched("connID", "0011223344556677"),
+		"secrets-configured":   mtglib.NewEventSecretsConfigured(1),
+		"dns-query":            mtglib.NewEventDNSQuery("9.9.9.9", "google.com", time.Second, false, false),
+		"dc-endpoint-failover": mtglib.NewEventDCEndpointFailover("connID", 2, 1),
+		"probe-tarpitted":      mtglib.NewEventProbeTarpitted(net.ParseIP("10.0.0.10")),
+		"dns-cache-updated":    mtglib.NewEventDNSCacheUpdated(10, 1),
+		"handshake-too-large":  mtglib.NewEventHandshakeTooLarge("connID"),
+		"geoip-load-failed":    mtglib.NewEventGeoIPLoadFailed("country"),
+		"upstream-mirrored":    mtglib.NewEventUpstreamMirrored("127.0.0.1:443", time.Second, time.Second, false, false),
+		"geoip-updated":        mtglib.NewEventGeoIPUpdated("country", false),
+		"client-traffic":       mtglib.NewEventClientTraffic("connID", 1, true),
+		"ip-banned":            mtglib.NewEventIPBanned(net.ParseIP("10.0.0.10"), time.Minute),
+		"ip-unbanned":          mtglib.NewEventIPUnbanned(net.ParseIP("10.0.0.10")),
+		"missing-sni":          mtglib.NewEventMissingSNI("connID"),
+		"dc-conns-limited":     mtglib.NewEventDCConnsLimited("connID", 2, time.Second),
+		"incomplete-handshake": mtglib.NewEventIncompleteHandshake("connID"),
+		"upstream-proxy-state": mtglib.NewEventUpstreamProxyStateChanged("127.0.0.1:1080", true),
+		"handshake-finished":   mtglib.NewEventHandshakeFinished("connID", time.Second, false),
+		"active-streams":       mtglib.NewEventActiveStreams(1),
+		"idle-timeout":         mtglib.NewEventIdleTimeout("connID"),
+		"antireplay-stats":     mtglib.NewEventAntiReplayStats(0.5, 0.01),
+		"dc-traffic":           mtglib.NewEventDCTraffic(2, 100, 200),
+		"telegram-dial":        mtglib.NewEventTelegramDial("connID", 2, time.Second, false, true),
+		"client-rate-limited":  mtglib.NewEventClientRateLimited(net.ParseIP("10.0.0.10")),
 	}
 	suite.ctx = context.Background()
 }
@@ -68,6 +93,54 @@ func (suite *NoopTestSuite) TestObserver() {
 				observer.EventReplayAttack(typedEvt)
 			case mtglib.EventIPListSize:
 				observer.EventIPListSize(typedEvt)
+			case mtglib.EventSecretMatched:
+				observer.EventSecretMatched(typedEvt)
+			case mtglib.EventSecretsConfigured:
+				observer.EventSecretsConfigured(typedEvt)
+			case mtglib.EventDNSQuery:
+				observer.EventDNSQuery(typedEvt)
+			case mtglib.EventDCEndpointFailover:
+				observer.EventDCEndpointFailover(typedEvt)
+			case mtglib.EventProbeTarpitted:
+				observer.EventProbeTarpitted(typedEvt)
+			case mtglib.EventDNSCacheUpdated:
+				observer.EventDNSCacheUpdated(typedEvt)
+			case mtglib.EventHandshakeTooLarge:
+				observer.EventHandshakeTooLarge(typedEvt)
+			case mtglib.EventGeoIPLoadFailed:
+				observer.EventGeoIPLoadFailed(typedEvt)
+			case mtglib.EventUpstreamMirrored:
+				observer.EventUpstreamMirrored(typedEvt)
+			case mtglib.EventGeoIPUpdated:
+				observer.EventGeoIPUpdated(typedEvt)
+			case mtglib.EventClientTraffic:
+				observer.EventClientTraffic(typedEvt)
+			case mtglib.EventIPBanned:
+				observer.EventIPBanned(typedEvt)
+			case mtglib.EventIPUnbanned:
+				observer.EventIPUnbanned(typedEvt)
+			case mtglib.EventMissingSNI:
+				observer.EventMissingSNI(typedEvt)
+			case mtglib.EventDCConnsLimited:
+				observer.EventDCConnsLimited(typedEvt)
+			case mtglib.EventIncompleteHandshake:
+				observer.EventIncompleteHandshake(typedEvt)
+			case mtglib.EventIdleTimeout:
+				observer.EventIdleTimeout(typedEvt)
+			case mtglib.EventAntiReplayStats:
+				observer.EventAntiReplayStats(typedEvt)
+			case mtglib.EventDCTraffic:
+				observer.EventDCTraffic(typedEvt)
+			case mtglib.EventTelegramDial:
+				observer.EventTelegramDial(typedEvt)
+			case mtglib.EventUpstreamProxyStateChanged:
+				observer.EventUpstreamProxyStateChanged(typedEvt)
+			case mtglib.EventHandshakeFinished:
+				observer.EventHandshakeFinished(typedEvt)
+			case mtglib.EventActiveStreams:
+				observer.EventActiveStreams(typedEvt)
+			case mtglib.EventClientRateLimited:
+				observer.EventClientRateLimited(typedEvt)
 			}
 		})
 	}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// recentEventsSkipped are events which are not kept in a ring. Traffic
+// events are emitted for each read and write so they would push out
+// everything else in a matter of seconds.
+var recentEventsSkipped = map[string]bool{
+	"EventTraffic":       true,
+	"EventClientTraffic": true,
+}
+
+// RecentEventsFactory is a factory of observers which keep the last events
+// in a bounded in-memory ring. This is also an [http.Handler] which dumps
+// this ring as JSON array: the oldest event goes first.
+//
+// Format of each record is the same as for [UnixDatagramFactory]. 'dropped'
+// field is a number of events which were pushed out of the ring so far.
+// Traffic events are not kept.
+type RecentEventsFactory struct {
+	mutex   sync.Mutex
+	token   string
+	records []jsonRecord
+	head    int
+	dropped uint64
+}
+
+// Make builds a new observer.
+func (r *RecentEventsFactory) Make() Observer {
+	return jsonObserver{
+		send: r.add,
+	}
+}
+
+// Records returns a copy of the ring from the oldest event to the newest
+// one.
+func (r *RecentEventsFactory) Records() []json.RawMessage {
+	r.mutex.Lock()
+	records := make([]jsonRecord, 0, len(r.records))
+	records = append(records, r.records[r.head:]...)
+	records = append(records, r.records[:r.head]...)
+	r.mutex.Unlock()
+
+	rv := make([]json.RawMessage, 0, len(records))
+
+	for i := range records {
+		if encoded, err := json.Marshal(records[i]); err == nil {
+			rv = append(rv, encoded)
+		}
+	}
+
+	return rv
+}
+
+// ServeHTTP dumps recent events.
+//
+// If factory has a token, client has to pass it either as a bearer token
+// in Authorization header or as 'token' query parameter.
+func (r *RecentEventsFactory) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !isAuthorized(req, r.token) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	json.NewEncoder(w).Encode(r.Records()) //nolint: errcheck
+}
+
+func (r *RecentEventsFactory) add(eventType string, evt mtglib.Event) {
+	if recentEventsSkipped[eventType] {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	isFull := len(r.records) == cap(r.records)
+	if isFull {
+		r.dropped++
+	}
+
+	record := jsonRecord{
+		Type:      eventType,
+		StreamID:  evt.StreamID(),
+		Timestamp: evt.Timestamp().UnixMilli(),
+		Dropped:   r.dropped,
+		Event:     evt,
+	}
+
+	if !isFull {
+		r.records = append(r.records, record)
+
+		return
+	}
+
+	r.records[r.head] = record
+	r.head = (r.head + 1) % len(r.records)
+}
+
+// NewRecentEvents creates a factory of observers which keep up to size
+// last events in memory.
+//
+// token protects an endpoint, an empty token means no access control. 0
+// size means [DefaultRecentEventsSize].
+func NewRecentEvents(token string, size uint) *RecentEventsFactory {
+	if size == 0 {
+		size = DefaultRecentEventsSize
+	}
+
+	return &RecentEventsFactory{
+		token:   token,
+		records: make([]jsonRecord, 0, size),
+	}
+}
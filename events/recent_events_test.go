@@ -0,0 +1,83 @@
+package events_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type RecentEventsTestSuite struct {
+	suite.Suite
+
+	factory    *events.RecentEventsFactory
+	httpServer *httptest.Server
+}
+
+func (suite *RecentEventsTestSuite) SetupTest() {
+	suite.factory = events.NewRecentEvents("token", 2)
+	suite.httpServer = httptest.NewServer(suite.factory)
+}
+
+func (suite *RecentEventsTestSuite) TearDownTest() {
+	suite.httpServer.Close()
+}
+
+func (suite *RecentEventsTestSuite) Get(query string) (int, []map[string]interface{}) {
+	resp, err := suite.httpServer.Client().Get(suite.httpServer.URL + query)
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	suite.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	records := []map[string]interface{}{}
+	suite.NoError(json.NewDecoder(resp.Body).Decode(&records))
+
+	return resp.StatusCode, records
+}
+
+func (suite *RecentEventsTestSuite) TestUnauthorized() {
+	code, _ := suite.Get("?token=wrong")
+
+	suite.Equal(http.StatusUnauthorized, code)
+}
+
+func (suite *RecentEventsTestSuite) TestEmpty() {
+	code, records := suite.Get("?token=token")
+
+	suite.Equal(http.StatusOK, code)
+	suite.Empty(records)
+}
+
+func (suite *RecentEventsTestSuite) TestRing() {
+	observer := suite.factory.Make()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID", 100, true))
+	observer.EventIPBlocklisted(mtglib.NewEventIPBlocklisted(net.ParseIP("10.0.0.11")))
+	observer.EventFinish(mtglib.NewEventFinish("connID"))
+
+	_, records := suite.Get("?token=token")
+
+	suite.Len(records, 2)
+	suite.Equal("EventIPBlocklisted", records[0]["type"])
+	suite.EqualValues(0, records[0]["dropped"])
+	suite.Equal("EventFinish", records[1]["type"])
+	suite.Equal("connID", records[1]["streamId"])
+	suite.EqualValues(1, records[1]["dropped"])
+}
+
+func TestRecentEvents(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &RecentEventsTestSuite{})
+}
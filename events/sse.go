@@ -0,0 +1,196 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+type sseSubscriber struct {
+	queue chan []byte
+}
+
+// SSEFactory is a factory of observers which fan events out to HTTP clients
+// subscribed with Server-Sent Events. This is also an [http.Handler] of a
+// subscription endpoint.
+//
+// Each event is sent as SSE message with a name of the event type and a
+// JSON record as data. Format of the record is the same as for
+// [UnixDatagramFactory]. Each subscriber has its own queue: if subscriber
+// is too slow and its queue is full, new events are dropped for it. Other
+// subscribers and a proxy itself are never blocked.
+type SSEFactory struct {
+	// it has to be the first field for atomic operations on 32-bit platforms
+	dropped uint64
+
+	ctx         context.Context
+	ctxCancel   context.CancelFunc
+	token       string
+	queueSize   uint
+	mutex       sync.RWMutex
+	subscribers map[*sseSubscriber]struct{}
+}
+
+// Make builds a new observer.
+func (s *SSEFactory) Make() Observer {
+	return jsonObserver{
+		send: s.publish,
+	}
+}
+
+// Dropped returns a number of messages which were dropped because queues of
+// subscribers were full.
+func (s *SSEFactory) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Subscribers returns a number of connected subscribers.
+func (s *SSEFactory) Subscribers() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.subscribers)
+}
+
+// Close disconnects all subscribers.
+func (s *SSEFactory) Close() {
+	s.ctxCancel()
+}
+
+// ServeHTTP streams events to a client until it disconnects.
+//
+// If factory has a token, client has to pass it either as a bearer token
+// in Authorization header or as 'token' query parameter. The latter is for
+// browsers: EventSource cannot set headers.
+func (s *SSEFactory) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !s.isAuthorized(req) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+
+		return
+	}
+
+	subscriber := &sseSubscriber{
+		queue: make(chan []byte, s.queueSize),
+	}
+
+	s.mutex.Lock()
+	s.subscribers[subscriber] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.subscribers, subscriber)
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-req.Context().Done():
+			return
+		case message := <-subscriber.queue:
+			if _, err := w.Write(message); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *SSEFactory) isAuthorized(req *http.Request) bool {
+	return isAuthorized(req, s.token)
+}
+
+// isAuthorized checks a token of the request either in Authorization
+// header or in 'token' query parameter. An empty token allows everyone.
+func isAuthorized(req *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+
+	passed := req.URL.Query().Get("token")
+
+	if header := req.Header.Get("Authorization"); header != "" {
+		passed = strings.TrimPrefix(header, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(passed), []byte(token)) == 1
+}
+
+func (s *SSEFactory) publish(eventType string, evt mtglib.Event) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	record, err := json.Marshal(jsonRecord{
+		Type:      eventType,
+		StreamID:  evt.StreamID(),
+		Timestamp: evt.Timestamp().UnixMilli(),
+		Dropped:   s.Dropped(),
+		Event:     evt,
+	})
+	if err != nil {
+		atomic.AddUint64(&s.dropped, uint64(len(s.subscribers)))
+
+		return
+	}
+
+	message := &bytes.Buffer{}
+	fmt.Fprintf(message, "event: %s\ndata: %s\n\n", eventType, record)
+
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber.queue <- message.Bytes():
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// NewSSE creates a factory of observers which stream events to subscribed
+// HTTP clients.
+//
+// token protects an endpoint, an empty token means no access control.
+// queueSize is a max number of messages waiting to be sent to each
+// subscriber. 0 means [DefaultSSEQueueSize].
+func NewSSE(token string, queueSize uint) *SSEFactory {
+	if queueSize == 0 {
+		queueSize = DefaultSSEQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &SSEFactory{
+		ctx:         ctx,
+		ctxCancel:   cancel,
+		token:       token,
+		queueSize:   queueSize,
+		subscribers: map[*sseSubscriber]struct{}{},
+	}
+}
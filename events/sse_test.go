@@ -0,0 +1,153 @@
+package events_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type SSETestSuite struct {
+	suite.Suite
+
+	factory    *events.SSEFactory
+	httpServer *httptest.Server
+}
+
+func (suite *SSETestSuite) SetupTest() {
+	suite.factory = events.NewSSE("token", 1)
+	suite.httpServer = httptest.NewServer(suite.factory)
+}
+
+func (suite *SSETestSuite) TearDownTest() {
+	suite.factory.Close()
+	suite.httpServer.Close()
+}
+
+func (suite *SSETestSuite) Subscribe(query string, header http.Header) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, suite.httpServer.URL+query, nil)
+	suite.NoError(err)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := suite.httpServer.Client().Do(req)
+	suite.NoError(err)
+
+	return resp
+}
+
+func (suite *SSETestSuite) TestUnauthorized() {
+	testData := map[string]http.Header{
+		"no-token":     nil,
+		"wrong-header": {"Authorization": []string{"Bearer wrong"}},
+		"?token=wrong": nil,
+	}
+
+	for k, v := range testData {
+		query := ""
+		if strings.HasPrefix(k, "?") {
+			query = k
+		}
+
+		resp := suite.Subscribe(query, v)
+		resp.Body.Close()
+
+		suite.Equal(http.StatusUnauthorized, resp.StatusCode, k)
+	}
+
+	suite.Equal(0, suite.factory.Subscribers())
+}
+
+func (suite *SSETestSuite) TestStream() {
+	resp := suite.Subscribe("", http.Header{"Authorization": []string{"Bearer token"}})
+	defer resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	suite.Eventually(func() bool {
+		return suite.factory.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	suite.factory.Make().EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+
+	reader := bufio.NewReader(resp.Body)
+
+	line, err := reader.ReadString('\n')
+	suite.NoError(err)
+	suite.Equal("event: EventStart\n", line)
+
+	line, err = reader.ReadString('\n')
+	suite.NoError(err)
+	suite.True(strings.HasPrefix(line, "data: "))
+
+	record := map[string]interface{}{}
+	suite.NoError(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &record))
+	suite.Equal("EventStart", record["type"])
+	suite.Equal("connID", record["streamId"])
+
+	line, err = reader.ReadString('\n')
+	suite.NoError(err)
+	suite.Equal("\n", line)
+}
+
+func (suite *SSETestSuite) TestQueryToken() {
+	resp := suite.Subscribe("?token=token", nil)
+	resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *SSETestSuite) TestDisconnect() {
+	resp := suite.Subscribe("?token=token", nil)
+
+	suite.Eventually(func() bool {
+		return suite.factory.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	resp.Body.Close()
+
+	suite.Eventually(func() bool {
+		return suite.factory.Subscribers() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *SSETestSuite) TestSlowSubscriber() {
+	resp := suite.Subscribe("?token=token", nil)
+	defer resp.Body.Close()
+
+	suite.Eventually(func() bool {
+		return suite.factory.Subscribers() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	observer := suite.factory.Make()
+
+	suite.Eventually(func() bool {
+		for i := 0; i < 1000; i++ {
+			observer.EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+		}
+
+		return suite.factory.Dropped() > 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+func (suite *SSETestSuite) TestNoSubscribers() {
+	suite.factory.Make().EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+
+	suite.EqualValues(0, suite.factory.Dropped())
+}
+
+func TestSSE(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &SSETestSuite{})
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// UnixDatagramFactory is an [ObserverFactory] source which sends events
+// as JSON records to a local AF_UNIX datagram socket. Each event is a
+// separate datagram.
+//
+// It never blocks an event stream: records are queued and sent in a
+// background. If a queue is full or a socket is not available, records are
+// dropped. A total number of dropped records is added to each record so a
+// consumer can detect gaps.
+type UnixDatagramFactory struct {
+	// has to be the first field to be 64-bit aligned on 32-bit platforms.
+	dropped uint64
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	addr      *net.UnixAddr
+	queue     chan []byte
+	done      chan struct{}
+}
+
+// Make builds a new observer.
+func (u *UnixDatagramFactory) Make() Observer {
+	return jsonObserver{
+		send: u.send,
+	}
+}
+
+// Dropped returns a number of records which were dropped because a queue
+// was full or a socket was not available.
+func (u *UnixDatagramFactory) Dropped() uint64 {
+	return atomic.LoadUint64(&u.dropped)
+}
+
+// Close stops sending records.
+func (u *UnixDatagramFactory) Close() {
+	u.ctxCancel()
+	<-u.done
+}
+
+func (u *UnixDatagramFactory) send(eventType string, evt mtglib.Event) {
+	record, err := json.Marshal(jsonRecord{
+		Type:      eventType,
+		StreamID:  evt.StreamID(),
+		Timestamp: evt.Timestamp().UnixMilli(),
+		Dropped:   u.Dropped(),
+		Event:     evt,
+	})
+	if err != nil {
+		atomic.AddUint64(&u.dropped, 1)
+
+		return
+	}
+
+	select {
+	case u.queue <- record:
+	default:
+		atomic.AddUint64(&u.dropped, 1)
+	}
+}
+
+func (u *UnixDatagramFactory) run() {
+	defer close(u.done)
+
+	var conn *net.UnixConn
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case record := <-u.queue:
+			if conn == nil {
+				newConn, err := net.DialUnix("unixgram", nil, u.addr)
+				if err != nil {
+					atomic.AddUint64(&u.dropped, 1)
+
+					continue
+				}
+
+				conn = newConn
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(UnixDatagramWriteTimeout)) //nolint: errcheck
+
+			if _, err := conn.Write(record); err != nil {
+				atomic.AddUint64(&u.dropped, 1)
+
+				// a consumer could restart so we have to reconnect.
+				conn.Close()
+				conn = nil
+			}
+		}
+	}
+}
+
+// NewUnixDatagram creates a factory of observers which send events to
+// AF_UNIX datagram socket at a given path. A consumer is not required to
+// listen on it beforehand: mtg reconnects if socket is not available.
+//
+// queueSize is a max number of records waiting to be sent. 0 means
+// [DefaultUnixDatagramQueueSize].
+func NewUnixDatagram(path string, queueSize uint) (*UnixDatagramFactory, error) {
+	if path == "" {
+		return nil, fmt.Errorf("socket path is empty")
+	}
+
+	if queueSize == 0 {
+		queueSize = DefaultUnixDatagramQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	factory := &UnixDatagramFactory{
+		ctx:       ctx,
+		ctxCancel: cancel,
+		addr: &net.UnixAddr{
+			Name: path,
+			Net:  "unixgram",
+		},
+		queue: make(chan []byte, queueSize),
+		done:  make(chan struct{}),
+	}
+
+	go factory.run()
+
+	return factory, nil
+}
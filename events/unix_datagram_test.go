@@ -0,0 +1,126 @@
+package events_test
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/stretchr/testify/suite"
+)
+
+type unixDatagramTestRecord struct {
+	Type      string          `json:"type"`
+	StreamID  string          `json:"streamId"`
+	Timestamp int64           `json:"timestamp"`
+	Dropped   uint64          `json:"dropped"`
+	Failed    uint64          `json:"failed"`
+	Event     json.RawMessage `json:"event"`
+}
+
+type UnixDatagramTestSuite struct {
+	suite.Suite
+
+	path     string
+	consumer *net.UnixConn
+	factory  *events.UnixDatagramFactory
+}
+
+func (suite *UnixDatagramTestSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "events.sock")
+
+	factory, err := events.NewUnixDatagram(suite.path, 2)
+	suite.NoError(err)
+
+	suite.factory = factory
+}
+
+func (suite *UnixDatagramTestSuite) TearDownTest() {
+	suite.factory.Close()
+
+	if suite.consumer != nil {
+		suite.consumer.Close()
+		suite.consumer = nil
+	}
+}
+
+func (suite *UnixDatagramTestSuite) Listen() {
+	consumer, err := net.ListenUnixgram("unixgram", &net.UnixAddr{
+		Name: suite.path,
+		Net:  "unixgram",
+	})
+	suite.NoError(err)
+
+	suite.consumer = consumer
+}
+
+func (suite *UnixDatagramTestSuite) Read() unixDatagramTestRecord {
+	buf := make([]byte, 4096)
+
+	suite.NoError(suite.consumer.SetReadDeadline(time.Now().Add(time.Second)))
+
+	n, err := suite.consumer.Read(buf)
+	suite.NoError(err)
+
+	record := unixDatagramTestRecord{}
+	suite.NoError(json.Unmarshal(buf[:n], &record))
+
+	return record
+}
+
+func (suite *UnixDatagramTestSuite) TestEmptyPath() {
+	_, err := events.NewUnixDatagram("", 0)
+	suite.Error(err)
+}
+
+func (suite *UnixDatagramTestSuite) TestSend() {
+	suite.Listen()
+
+	evt := mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10"))
+	suite.factory.Make().EventStart(evt)
+
+	record := suite.Read()
+	suite.Equal("EventStart", record.Type)
+	suite.Equal("connID", record.StreamID)
+	suite.Equal(evt.Timestamp().UnixMilli(), record.Timestamp)
+	suite.EqualValues(0, record.Dropped)
+	suite.JSONEq(`{"RemoteIP": "10.0.0.10"}`, string(record.Event))
+}
+
+func (suite *UnixDatagramTestSuite) TestNoConsumer() {
+	observer := suite.factory.Make()
+
+	observer.EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+
+	suite.Eventually(func() bool {
+		return suite.factory.Dropped() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	suite.Listen()
+
+	observer.EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+
+	record := suite.Read()
+	suite.Equal("EventReplayAttack", record.Type)
+	suite.EqualValues(1, record.Dropped)
+}
+
+func (suite *UnixDatagramTestSuite) TestOverflow() {
+	observer := suite.factory.Make()
+
+	for i := 0; i < 100; i++ {
+		observer.EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+	}
+
+	suite.Eventually(func() bool {
+		return suite.factory.Dropped() == 100
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUnixDatagram(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &UnixDatagramTestSuite{})
+}
@@ -0,0 +1,218 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// LoadFailureCallback defines a signature of the callback that is executed
+// when some database cannot be loaded. database is either [DatabaseCountry]
+// or [DatabaseASN].
+type LoadFailureCallback func(ctx context.Context, database string, err error)
+
+// DB is a GeoIP database which consists of optional country and ASN
+// databases.
+//
+// Databases can be reloaded from disk. If some database cannot be loaded, a
+// previous version is kept. If there is no previous version, all lookups in
+// this database return unknown values.
+type DB struct {
+	ctx             context.Context
+	ctxCancel       context.CancelFunc
+	mutex           sync.RWMutex
+	countryPath     string
+	asnPath         string
+	country         *maxminddb.Reader
+	asn             *maxminddb.Reader
+	failureCallback LoadFailureCallback
+}
+
+// Lookup returns an origin of the given IP address. If IP address is not
+// found, an empty record is returned.
+func (d *DB) Lookup(ip net.IP) Record {
+	rv := Record{}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.country != nil {
+		record := countryRecord{}
+		if err := d.country.Lookup(ip, &record); err == nil {
+			rv.Country = record.Country.ISOCode
+		}
+	}
+
+	if d.asn != nil {
+		record := asnRecord{}
+		if err := d.asn.Lookup(ip, &record); err == nil {
+			rv.ASN = record.AutonomousSystemNumber
+			rv.ASOrganization = record.AutonomousSystemOrganization
+		}
+	}
+
+	return rv
+}
+
+// Reload opens databases from disk again. Each database is reloaded
+// independently: if it cannot be opened, its previous version stays active
+// and failure callback is executed. The first error is returned.
+func (d *DB) Reload() error {
+	var rv error
+
+	country, err := d.open(DatabaseCountry, d.countryPath)
+	if err != nil {
+		rv = err
+	}
+
+	asn, err := d.open(DatabaseASN, d.asnPath)
+	if err != nil && rv == nil {
+		rv = err
+	}
+
+	d.mutex.Lock()
+
+	oldCountry, oldASN := d.country, d.asn
+
+	if country != nil {
+		d.country = country
+	}
+
+	if asn != nil {
+		d.asn = asn
+	}
+
+	d.mutex.Unlock()
+
+	if country != nil && oldCountry != nil {
+		oldCountry.Close()
+	}
+
+	if asn != nil && oldASN != nil {
+		oldASN.Close()
+	}
+
+	return rv
+}
+
+// Run reloads databases periodically.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (d *DB) Run(updateEach time.Duration) {
+	if updateEach == 0 {
+		updateEach = DefaultUpdateEach
+	}
+
+	ticker := time.NewTicker(updateEach)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.Reload() //nolint: errcheck
+		}
+	}
+}
+
+func (d *DB) open(database, path string) (*maxminddb.Reader, error) {
+	if path == "" {
+		return nil, nil //nolint: nilnil
+	}
+
+	// mmap is not used intentionally: if a file is overwritten in place, a
+	// mapped database breaks even though it is considered as loaded.
+	content, err := os.ReadFile(path)
+	if err == nil {
+		var reader *maxminddb.Reader
+
+		if reader, err = maxminddb.FromBytes(content); err == nil {
+			return reader, nil
+		}
+	}
+
+	err = fmt.Errorf("cannot open %s database %s: %w", database, path, err)
+
+	if d.failureCallback != nil {
+		d.failureCallback(d.ctx, database, err)
+	}
+
+	return nil, err
+}
+
+// Close stops periodic reloads and closes underlying databases.
+func (d *DB) Close() error {
+	d.ctxCancel()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.country != nil {
+		if err := d.country.Close(); err != nil {
+			return fmt.Errorf("cannot close country database: %w", err)
+		}
+	}
+
+	if d.asn != nil {
+		if err := d.asn.Close(); err != nil {
+			return fmt.Errorf("cannot close asn database: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// NewDB opens MaxMind DB files. Both paths are optional but at least one
+// has to be set. Any failure to open a database is an error.
+func NewDB(countryPath, asnPath string) (*DB, error) {
+	db, err := NewDBWithFallback(countryPath, asnPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Reload(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewDBWithFallback creates a new database which tolerates load failures.
+// Both paths are optional but at least one has to be set.
+//
+// This method does not open databases so please execute Reload when it is
+// necessary. Until databases are loaded, all lookups return unknown values.
+func NewDBWithFallback(countryPath, asnPath string, failureCallback LoadFailureCallback) (*DB, error) {
+	if countryPath == "" && asnPath == "" {
+		return nil, ErrNoDatabases
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DB{
+		ctx:             ctx,
+		ctxCancel:       cancel,
+		countryPath:     countryPath,
+		asnPath:         asnPath,
+		failureCallback: failureCallback,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package geoip_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/stretchr/testify/suite"
+)
+
+type DBTestSuite struct {
+	suite.Suite
+
+	db *geoip.DB
+}
+
+func (suite *DBTestSuite) SetupTest() {
+	db, err := geoip.NewDB(
+		filepath.Join("testdata", "country.mmdb"),
+		filepath.Join("testdata", "asn.mmdb"))
+	suite.NoError(err)
+
+	suite.db = db
+}
+
+func (suite *DBTestSuite) TearDownTest() {
+	suite.NoError(suite.db.Close())
+}
+
+func (suite *DBTestSuite) TestNoDatabases() {
+	_, err := geoip.NewDB("", "")
+	suite.ErrorIs(err, geoip.ErrNoDatabases)
+}
+
+func (suite *DBTestSuite) TestAbsentDatabase() {
+	_, err := geoip.NewDB(filepath.Join("testdata", "absent.mmdb"), "")
+	suite.Error(err)
+}
+
+func (suite *DBTestSuite) TestLookupIPv4() {
+	suite.Equal(geoip.Record{
+		Country:        "GB",
+		ASN:            20712,
+		ASOrganization: "Andrews & Arnold Ltd",
+	}, suite.db.Lookup(net.ParseIP("81.2.69.142")))
+}
+
+func (suite *DBTestSuite) TestLookupIPv6() {
+	suite.Equal(geoip.Record{
+		Country:        "DE",
+		ASN:            64512,
+		ASOrganization: "Example",
+	}, suite.db.Lookup(net.ParseIP("2001:db8:1::1")))
+}
+
+func (suite *DBTestSuite) TestLookupUnknown() {
+	suite.Equal(geoip.Record{}, suite.db.Lookup(net.ParseIP("10.0.0.1")))
+}
+
+func (suite *DBTestSuite) TestCountryOnly() {
+	db, err := geoip.NewDB(filepath.Join("testdata", "country.mmdb"), "")
+	suite.NoError(err)
+
+	defer db.Close()
+
+	suite.Equal(geoip.Record{Country: "SE"}, db.Lookup(net.ParseIP("89.160.20.112")))
+}
+
+func (suite *DBTestSuite) TestFallbackAbsentDatabase() {
+	failures := []string{}
+	db, err := geoip.NewDBWithFallback(
+		filepath.Join("testdata", "absent.mmdb"),
+		filepath.Join("testdata", "asn.mmdb"),
+		func(_ context.Context, database string, _ error) {
+			failures = append(failures, database)
+		})
+	suite.NoError(err)
+
+	defer db.Close()
+
+	suite.Equal(geoip.Record{}, db.Lookup(net.ParseIP("81.2.69.142")))
+	suite.Error(db.Reload())
+	suite.Equal([]string{geoip.DatabaseCountry}, failures)
+	suite.Equal(geoip.Record{
+		ASN:            20712,
+		ASOrganization: "Andrews & Arnold Ltd",
+	}, db.Lookup(net.ParseIP("81.2.69.142")))
+}
+
+func (suite *DBTestSuite) TestReloadKeepsLastGood() {
+	path := filepath.Join(suite.T().TempDir(), "country.mmdb")
+	content, err := os.ReadFile(filepath.Join("testdata", "country.mmdb"))
+	suite.NoError(err)
+	suite.NoError(os.WriteFile(path, content, 0o600))
+
+	db, err := geoip.NewDB(path, "")
+	suite.NoError(err)
+
+	defer db.Close()
+
+	suite.NoError(os.WriteFile(path, []byte("corrupted"), 0o600))
+	suite.Error(db.Reload())
+	suite.Equal(geoip.Record{Country: "GB"}, db.Lookup(net.ParseIP("81.2.69.142")))
+
+	suite.NoError(os.WriteFile(path, content, 0o600))
+	suite.NoError(db.Reload())
+	suite.Equal(geoip.Record{Country: "SE"}, db.Lookup(net.ParseIP("89.160.20.112")))
+}
+
+func TestDB(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DBTestSuite{})
+}
@@ -0,0 +1,57 @@
+// Package geoip resolves an origin of IP addresses: a country and an
+// autonomous system. It reads MaxMind DB files (like GeoLite2-Country and
+// GeoLite2-ASN).
+//
+// Both databases are optional. If some database is not set, corresponding
+// fields of the [Record] are left empty.
+//
+// Databases are external files which are updated periodically so they can be
+// absent or corrupted. In that case lookups return unknown values and
+// consumers should treat such IPs safely: for example, a blocklist should not
+// block an IP with unknown origin.
+//
+// Databases can be downloaded from remote URLs with [Updater].
+package geoip
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DatabaseCountry is a name of the country database.
+	DatabaseCountry = "country"
+
+	// DatabaseASN is a name of the autonomous system database.
+	DatabaseASN = "asn"
+
+	// DefaultUpdateEach defines a default time period when databases are
+	// reloaded from disk.
+	DefaultUpdateEach = 24 * time.Hour
+
+	// DefaultDownloadAttempts defines a default max number of downloads of
+	// each database per update.
+	DefaultDownloadAttempts = 3
+
+	// DefaultDownloadBackoff defines a default pause between the first and
+	// the second download attempts. Each next pause is twice longer.
+	DefaultDownloadBackoff = 10 * time.Second
+)
+
+// ErrNoDatabases is returned if neither country nor ASN database is set.
+var ErrNoDatabases = errors.New("no geoip databases are set")
+
+// Record is a result of GeoIP lookup.
+type Record struct {
+	// Country is an ISO 3166-1 alpha-2 code of the country. It is empty if
+	// country is unknown.
+	Country string
+
+	// ASN is a number of the autonomous system. It is 0 if autonomous
+	// system is unknown.
+	ASN uint
+
+	// ASOrganization is a name of the organization which owns an
+	// autonomous system.
+	ASOrganization string
+}
@@ -0,0 +1,249 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// UpdateCallback defines a signature of the callback that is executed when
+// an update of some database is finished. err is nil if a new version was
+// downloaded.
+type UpdateCallback func(ctx context.Context, database string, err error)
+
+type updaterSource struct {
+	database string
+	url      string
+	path     string
+}
+
+// Updater downloads MaxMind DB files from remote URLs and reloads a [DB]
+// with them.
+//
+// A downloaded file is validated and then atomically replaces a file which
+// is used by a database, so the last good version always stays on disk. If
+// download fails, it is retried with exponential backoff. If all attempts
+// fail, database keeps using its previous version.
+type Updater struct {
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	logger    mtglib.Logger
+	http      *http.Client
+	db        *DB
+
+	sources        []updaterSource
+	attempts       uint
+	backoff        time.Duration
+	updateCallback UpdateCallback
+}
+
+// Shutdown stop a background update process.
+func (u *Updater) Shutdown() {
+	u.ctxCancel()
+}
+
+// Run starts a background update process.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (u *Updater) Run(updateEach time.Duration) {
+	if updateEach == 0 {
+		updateEach = DefaultUpdateEach
+	}
+
+	ticker := time.NewTicker(updateEach)
+
+	defer func() {
+		ticker.Stop()
+
+		select {
+		case <-ticker.C:
+		default:
+		}
+	}()
+
+	u.update()
+
+	for {
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-ticker.C:
+			u.update()
+		}
+	}
+}
+
+func (u *Updater) update() {
+	for _, v := range u.sources {
+		logger := u.logger.BindStr("database", v.database)
+		err := u.download(v)
+
+		if u.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			logger.WarningError("update has failed", err)
+		} else {
+			u.db.Reload() //nolint: errcheck
+			logger.Info("database was updated")
+		}
+
+		if u.updateCallback != nil {
+			u.updateCallback(u.ctx, v.database, err)
+		}
+	}
+}
+
+func (u *Updater) download(source updaterSource) error {
+	backoff := u.backoff
+
+	var err error
+
+	for attempt := uint(0); attempt < u.attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+
+			select {
+			case <-u.ctx.Done():
+				timer.Stop()
+
+				return fmt.Errorf("update was cancelled: %w", u.ctx.Err())
+			case <-timer.C:
+			}
+
+			backoff *= 2
+		}
+
+		if err = u.downloadOnce(source); err == nil {
+			return nil
+		}
+
+		u.logger.
+			BindStr("database", source.database).
+			BindInt("attempt", int(attempt)+1).
+			DebugError("cannot download database", err)
+	}
+
+	return fmt.Errorf("cannot download %s after %d attempts: %w", source.url, u.attempts, err)
+}
+
+func (u *Updater) downloadOnce(source updaterSource) error {
+	request, err := http.NewRequestWithContext(u.ctx, http.MethodGet, source.url, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	response, err := u.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("cannot get url %s: %w", source.url, err)
+	}
+
+	defer func() {
+		io.Copy(io.Discard, response.Body) //nolint: errcheck
+		response.Body.Close()
+	}()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("cannot read response: %w", err)
+	}
+
+	reader, err := maxminddb.FromBytes(content)
+	if err != nil {
+		return fmt.Errorf("incorrect database: %w", err)
+	}
+
+	reader.Close()
+
+	return writeFileAtomically(source.path, content)
+}
+
+func writeFileAtomically(path string, content []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create temporary file: %w", err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+
+		return fmt.Errorf("cannot write temporary file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot close temporary file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("cannot replace %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// NewUpdater creates a new updater for a given database. Both URLs are
+// optional but at least one has to be set. Each URL requires a path of the
+// corresponding database: downloaded files are stored there.
+//
+// attempts is a max number of downloads of each database per update,
+// backoff is a pause before the second attempt. Each next pause is twice
+// longer. 0 means default values.
+func NewUpdater(logger mtglib.Logger, network mtglib.Network, db *DB,
+	countryURL, asnURL string, attempts uint, backoff time.Duration,
+	updateCallback UpdateCallback,
+) (*Updater, error) {
+	sources := []updaterSource{}
+
+	for _, v := range []updaterSource{
+		{database: DatabaseCountry, url: countryURL, path: db.countryPath},
+		{database: DatabaseASN, url: asnURL, path: db.asnPath},
+	} {
+		switch {
+		case v.url == "":
+		case v.path == "":
+			return nil, fmt.Errorf("%s database has url but no path", v.database)
+		default:
+			sources = append(sources, v)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, ErrNoDatabases
+	}
+
+	if attempts == 0 {
+		attempts = DefaultDownloadAttempts
+	}
+
+	if backoff == 0 {
+		backoff = DefaultDownloadBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Updater{
+		ctx:            ctx,
+		ctxCancel:      cancel,
+		logger:         logger.Named("geoip-updater"),
+		http:           network.MakeHTTPClient(nil),
+		db:             db,
+		sources:        sources,
+		attempts:       attempts,
+		backoff:        backoff,
+		updateCallback: updateCallback,
+	}, nil
+}
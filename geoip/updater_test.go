@@ -0,0 +1,183 @@
+package geoip_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/IceCodeNew/mtg/internal/testlib"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type updaterResult struct {
+	database string
+	err      error
+}
+
+type UpdaterTestSuite struct {
+	suite.Suite
+
+	failures    int32
+	requests    int32
+	networkMock *testlib.MtglibNetworkMock
+	httpServer  *httptest.Server
+	dir         string
+	db          *geoip.DB
+
+	resultsMutex sync.Mutex
+	results      []updaterResult
+}
+
+func (suite *UpdaterTestSuite) SetupTest() {
+	atomic.StoreInt32(&suite.failures, 0)
+	atomic.StoreInt32(&suite.requests, 0)
+
+	suite.results = nil
+	suite.dir = suite.T().TempDir()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/country.mmdb", func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&suite.requests, 1) <= atomic.LoadInt32(&suite.failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		http.ServeFile(w, req, filepath.Join("testdata", "country.mmdb"))
+	})
+	mux.HandleFunc("/broken.mmdb", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("not a database")) //nolint: errcheck
+	})
+
+	suite.httpServer = httptest.NewServer(mux)
+	suite.networkMock = &testlib.MtglibNetworkMock{}
+	suite.networkMock.
+		On("MakeHTTPClient", mock.Anything).
+		Maybe().
+		Return(suite.httpServer.Client())
+
+	db, err := geoip.NewDBWithFallback(filepath.Join(suite.dir, "country.mmdb"), "", nil)
+	suite.NoError(err)
+
+	suite.db = db
+}
+
+func (suite *UpdaterTestSuite) TearDownTest() {
+	suite.db.Close()
+	suite.httpServer.Close()
+	suite.networkMock.AssertExpectations(suite.T())
+}
+
+func (suite *UpdaterTestSuite) MakeUpdater(countryURL string) *geoip.Updater {
+	updater, err := geoip.NewUpdater(logger.NewNoopLogger(), suite.networkMock, suite.db,
+		countryURL, "", 3, 10*time.Millisecond,
+		func(_ context.Context, database string, err error) {
+			suite.resultsMutex.Lock()
+			defer suite.resultsMutex.Unlock()
+
+			suite.results = append(suite.results, updaterResult{database: database, err: err})
+		})
+	suite.NoError(err)
+
+	return updater
+}
+
+func (suite *UpdaterTestSuite) Results() []updaterResult {
+	suite.resultsMutex.Lock()
+	defer suite.resultsMutex.Unlock()
+
+	return append([]updaterResult{}, suite.results...)
+}
+
+func (suite *UpdaterTestSuite) TestURLWithoutPath() {
+	_, err := geoip.NewUpdater(logger.NewNoopLogger(), suite.networkMock, suite.db,
+		"", suite.httpServer.URL+"/country.mmdb", 0, 0, nil)
+	suite.Error(err)
+}
+
+func (suite *UpdaterTestSuite) TestNoURLs() {
+	_, err := geoip.NewUpdater(logger.NewNoopLogger(), suite.networkMock, suite.db,
+		"", "", 0, 0, nil)
+	suite.ErrorIs(err, geoip.ErrNoDatabases)
+}
+
+func (suite *UpdaterTestSuite) TestRetry() {
+	atomic.StoreInt32(&suite.failures, 2)
+
+	updater := suite.MakeUpdater(suite.httpServer.URL + "/country.mmdb")
+
+	go updater.Run(time.Hour)
+
+	defer updater.Shutdown()
+
+	suite.Eventually(func() bool {
+		return len(suite.Results()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	suite.Equal([]updaterResult{{database: geoip.DatabaseCountry}}, suite.Results())
+	suite.EqualValues(3, atomic.LoadInt32(&suite.requests))
+	suite.Equal("GB", suite.db.Lookup(net.ParseIP("81.2.69.142")).Country)
+	suite.FileExists(filepath.Join(suite.dir, "country.mmdb"))
+}
+
+func (suite *UpdaterTestSuite) TestAllAttemptsFailed() {
+	atomic.StoreInt32(&suite.failures, 5)
+
+	updater := suite.MakeUpdater(suite.httpServer.URL + "/country.mmdb")
+
+	go updater.Run(time.Hour)
+
+	defer updater.Shutdown()
+
+	suite.Eventually(func() bool {
+		return len(suite.Results()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	suite.Error(suite.Results()[0].err)
+	suite.EqualValues(3, atomic.LoadInt32(&suite.requests))
+	suite.NoFileExists(filepath.Join(suite.dir, "country.mmdb"))
+}
+
+func (suite *UpdaterTestSuite) TestBrokenDatabaseKeepsLastGood() {
+	path := filepath.Join(suite.dir, "country.mmdb")
+	content, err := os.ReadFile(filepath.Join("testdata", "country.mmdb"))
+	suite.NoError(err)
+	suite.NoError(os.WriteFile(path, content, 0o600))
+	suite.NoError(suite.db.Reload())
+
+	updater := suite.MakeUpdater(suite.httpServer.URL + "/broken.mmdb")
+
+	go updater.Run(time.Hour)
+
+	defer updater.Shutdown()
+
+	suite.Eventually(func() bool {
+		return len(suite.Results()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	suite.Error(suite.Results()[0].err)
+
+	stored, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Equal(content, stored)
+	suite.Equal("GB", suite.db.Lookup(net.ParseIP("81.2.69.142")).Country)
+
+	entries, err := os.ReadDir(suite.dir)
+	suite.NoError(err)
+	suite.Len(entries, 1)
+}
+
+func TestUpdater(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &UpdaterTestSuite{})
+}
@@ -24,12 +24,13 @@ require (
 	github.com/stretchr/testify v1.9.0
 	github.com/tylertreat/BoomFilters v0.0.0-20210315201527-1a82519a3e43
 	golang.org/x/crypto v0.23.0
-	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/net v0.21.0
 	golang.org/x/sys v0.20.0
 	google.golang.org/protobuf v1.28.1 // indirect
 )
 
 require (
+	github.com/oschwald/maxminddb-golang v1.12.0
 	github.com/txthinking/socks5 v0.0.0-20230325130024-4230056ae301
 	github.com/yl2chen/cidranger v1.0.2
 )
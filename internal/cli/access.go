@@ -55,7 +55,7 @@ func (a *Access) Run(cli *CLI, version string) error {
 	resp.Secret.Base64 = conf.Secret.Base64()
 	resp.Secret.Hex = conf.Secret.Hex()
 
-	ntw, err := makeNetwork(conf, version)
+	ntw, err := makeNetwork(conf, version, networkOpts{})
 	if err != nil {
 		return fmt.Errorf("cannot init network: %w", err)
 	}
@@ -68,7 +68,7 @@ func (a *Access) Run(cli *CLI, version string) error {
 
 		ip := a.PublicIPv4
 		if ip == nil {
-			ip = a.getIP(ntw, "tcp4")
+			ip = getPublicIP(ntw, "tcp4")
 		}
 
 		if ip != nil {
@@ -83,7 +83,7 @@ func (a *Access) Run(cli *CLI, version string) error {
 
 		ip := a.PublicIPv6
 		if ip == nil {
-			ip = a.getIP(ntw, "tcp6")
+			ip = getPublicIP(ntw, "tcp6")
 		}
 
 		if ip != nil {
@@ -106,7 +106,9 @@ func (a *Access) Run(cli *CLI, version string) error {
 	return nil
 }
 
-func (a *Access) getIP(ntw mtglib.Network, protocol string) net.IP {
+// getPublicIP resolves a public IP address of a given protocol ('tcp4' or
+// 'tcp6') via remote website. It returns nil if address is unknown.
+func getPublicIP(ntw mtglib.Network, protocol string) net.IP {
 	client := ntw.MakeHTTPClient(func(ctx context.Context, network, address string) (essentials.Conn, error) {
 		return ntw.DialContext(ctx, protocol, address) //nolint: wrapcheck
 	})
@@ -150,35 +152,43 @@ func (a *Access) makeURLs(conf *config.Config, ip net.IP) *accessResponseURLs {
 		portNo = conf.BindTo.Port
 	}
 
-	values := url.Values{}
-	values.Set("server", ip.String())
-	values.Set("port", strconv.Itoa(int(portNo)))
-
+	secret := conf.Secret.Base64()
 	if a.Hex {
-		values.Set("secret", conf.Secret.Hex())
-	} else {
-		values.Set("secret", conf.Secret.Base64())
+		secret = conf.Secret.Hex()
 	}
 
-	urlQuery := values.Encode()
-
 	rv := &accessResponseURLs{
 		IP:   ip,
 		Port: portNo,
-		TgURL: (&url.URL{
-			Scheme:   "tg",
-			Host:     "proxy",
-			RawQuery: urlQuery,
-		}).String(),
-		TmeURL: (&url.URL{
-			Scheme:   "https",
-			Host:     "t.me",
-			Path:     "proxy",
-			RawQuery: urlQuery,
-		}).String(),
 	}
+	rv.TgURL, rv.TmeURL = makeSharingURLs(ip.String(), portNo, secret)
 	rv.TgQrCode = utils.MakeQRCodeURL(rv.TgURL)
 	rv.TmeQrCode = utils.MakeQRCodeURL(rv.TmeURL)
 
 	return rv
 }
+
+// makeSharingURLs returns tg:// and https://t.me links which add a proxy
+// to Telegram clients. A host could be either an IP address or a hostname.
+func makeSharingURLs(host string, port uint, secret string) (string, string) {
+	values := url.Values{}
+	values.Set("server", host)
+	values.Set("port", strconv.Itoa(int(port)))
+	values.Set("secret", secret)
+
+	urlQuery := values.Encode()
+
+	tgURL := &url.URL{
+		Scheme:   "tg",
+		Host:     "proxy",
+		RawQuery: urlQuery,
+	}
+	tmeURL := &url.URL{
+		Scheme:   "https",
+		Host:     "t.me",
+		Path:     "proxy",
+		RawQuery: urlQuery,
+	}
+
+	return tgURL.String(), tmeURL.String()
+}
@@ -7,5 +7,7 @@ type CLI struct {
 	Access         Access           `kong:"cmd,help='Print access information.'"`
 	Run            Run              `kong:"cmd,help='Run proxy.'"`
 	SimpleRun      SimpleRun        `kong:"cmd,help='Run proxy without config file.'"`
+	TestConfig     TestConfig       `kong:"cmd,help='Check configuration and reachability of upstreams without running proxy.'"`
+	ShowConfig     ShowConfig       `kong:"cmd,help='Print effective configuration with defaults and redacted secrets.'"`
 	Version        kong.VersionFlag `kong:"help='Print version.',short='v'"`
 }
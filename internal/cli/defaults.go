@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"net"
+
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/IceCodeNew/mtg/network"
+)
+
+// Defaults of options which have no default constant in a package which
+// uses them. Other defaults come from these packages, like
+// mtglib.DefaultConcurrency. Both runProxy and applyDefaults take them from
+// here so show-config always prints values a proxy is running with.
+const (
+	defaultAcceptLoops               = 1
+	defaultIPListDownloadConcurrency = 1
+	defaultIPListChainMode           = ipblocklist.ChainModeAny
+	defaultClientPreferIP            = config.TypePreferIPPreferIPv6
+	defaultDNSResolver               = config.TypeDNSResolverDOH
+	defaultDNSCachePolicy            = network.DNSCachePolicyLRU
+	defaultSocketOptionsPolicy       = utils.SocketOptionsPolicyReject
+	defaultPrometheusHTTPPath        = "/"
+	defaultLogOutput                 = config.TypeLogOutputStdout
+	defaultAccessLogFormat           = events.AccessLogFormatJSON
+)
+
+// defaultDOHIP is an IP address of DOH resolver if neither doh-ip nor
+// doh-url is set.
+var defaultDOHIP = net.ParseIP(network.DefaultDOHHostname)
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// errDryRunUnixSocket is returned if an address of unix socket listener
+// cannot be advertised.
+var errDryRunUnixSocket = errors.New("unix socket listener")
+
+// dryRunListener is a listener of a proxy with the secrets it serves.
+type dryRunListener struct {
+	bindTo  config.TypeBindTo
+	secrets []mtglib.Secret
+}
+
+// dryRun validates secrets of a configuration and prints sharing links of
+// each secret of each listener. Links are not redacted: they are meant to
+// be shared.
+func (r *Run) dryRun(writer io.Writer, conf *config.Config) error {
+	listeners := []dryRunListener{{
+		bindTo:  conf.BindTo,
+		secrets: append([]mtglib.Secret{conf.Secret}, conf.Secrets...),
+	}}
+
+	for i := range conf.Listeners {
+		listener := dryRunListener{
+			bindTo:  conf.Listeners[i].BindTo,
+			secrets: listeners[0].secrets,
+		}
+
+		if conf.Listeners[i].Secret.Valid() {
+			listener.secrets = []mtglib.Secret{conf.Listeners[i].Secret}
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	for _, listener := range listeners {
+		for _, secret := range listener.secrets {
+			if err := validateSecretHost(secret.Host); err != nil {
+				return fmt.Errorf("invalid secret of %s: %w", listener.bindTo.String(), err)
+			}
+		}
+	}
+
+	for i, listener := range listeners {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+
+		host, port, err := r.getPublicAddress(listener.bindTo, i == 0)
+
+		switch {
+		case errors.Is(err, errDryRunUnixSocket):
+			fmt.Fprintf(writer, "%s is skipped: its public address is defined by a load balancer in front of it\n",
+				listener.bindTo.String())
+
+			continue
+		case err != nil:
+			return err
+		}
+
+		fmt.Fprintf(writer, "%s is advertised as %s\n",
+			listener.bindTo.String(), net.JoinHostPort(host, fmt.Sprint(port)))
+
+		for _, secret := range listener.secrets {
+			tgURL, tmeURL := makeSharingURLs(host, port, secret.Base64())
+
+			fmt.Fprintf(writer, "  %s (%s)\n", secret.Host, secret.Hex())
+			fmt.Fprintf(writer, "    %s\n", tgURL)
+			fmt.Fprintf(writer, "    %s\n", tmeURL)
+		}
+	}
+
+	return nil
+}
+
+// getPublicAddress returns a host and a port which clients should connect
+// to. A public host replaces a host of each listener while a public port
+// replaces a port of the main bind-to only: other listeners have to be
+// distinguished by their ports. An unspecified IP cannot be advertised, so
+// it requires flags.
+//
+// A unix socket has no address at all. The main bind-to can still be
+// advertised with both flags, other unix sockets return errDryRunUnixSocket.
+func (r *Run) getPublicAddress(bindTo config.TypeBindTo, isMain bool) (string, uint, error) {
+	if bindTo.UnixPath != "" && !(isMain && r.PublicHost != "" && r.PublicPort != 0) {
+		if isMain {
+			return "", 0, fmt.Errorf("cannot advertise %s, please set --public-host and --public-port",
+				bindTo.String())
+		}
+
+		return "", 0, errDryRunUnixSocket
+	}
+
+	host, port := r.PublicHost, bindTo.Port
+
+	if host == "" {
+		if ip := net.ParseIP(bindTo.Host); bindTo.Host != "" && (ip == nil || !ip.IsUnspecified()) {
+			host = bindTo.Host
+		}
+	}
+
+	if isMain && r.PublicPort != 0 {
+		port = r.PublicPort
+	}
+
+	switch {
+	case host == "":
+		return "", 0, fmt.Errorf("cannot advertise a host of %s, please set --public-host", bindTo.String())
+	case port == 0:
+		return "", 0, fmt.Errorf("cannot advertise a port of %s, please set --public-port", bindTo.String())
+	}
+
+	return host, port, nil
+}
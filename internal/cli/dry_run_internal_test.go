@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetPublicAddressTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GetPublicAddressTestSuite) makeBindTo(value string) config.TypeBindTo {
+	rv := config.TypeBindTo{}
+
+	suite.NoError(rv.Set(value))
+
+	return rv
+}
+
+func (suite *GetPublicAddressTestSuite) makeUnixBindTo() config.TypeBindTo {
+	return config.TypeBindTo{
+		Value:    config.TypeBindToUnixPrefix + "/run/mtg.sock",
+		UnixPath: "/run/mtg.sock",
+	}
+}
+
+func (suite *GetPublicAddressTestSuite) TestOk() {
+	testData := []struct {
+		name   string
+		run    Run
+		bindTo config.TypeBindTo
+		isMain bool
+		host   string
+		port   uint
+	}{
+		{
+			name:   "bind-to",
+			bindTo: suite.makeBindTo("10.0.0.10:3128"),
+			isMain: true,
+			host:   "10.0.0.10",
+			port:   3128,
+		},
+		{
+			name:   "public host",
+			run:    Run{PublicHost: "proxy.example.com"},
+			bindTo: suite.makeBindTo("0.0.0.0:3128"),
+			isMain: true,
+			host:   "proxy.example.com",
+			port:   3128,
+		},
+		{
+			name:   "public port of main",
+			run:    Run{PublicPort: 443},
+			bindTo: suite.makeBindTo("10.0.0.10:3128"),
+			isMain: true,
+			host:   "10.0.0.10",
+			port:   443,
+		},
+		{
+			name:   "public port of listener",
+			run:    Run{PublicHost: "proxy.example.com", PublicPort: 443},
+			bindTo: suite.makeBindTo("0.0.0.0:3129"),
+			host:   "proxy.example.com",
+			port:   3129,
+		},
+		{
+			name:   "unix socket of main",
+			run:    Run{PublicHost: "proxy.example.com", PublicPort: 443},
+			bindTo: suite.makeUnixBindTo(),
+			isMain: true,
+			host:   "proxy.example.com",
+			port:   443,
+		},
+	}
+
+	for _, v := range testData {
+		value := v
+
+		suite.T().Run(value.name, func(t *testing.T) {
+			host, port, err := value.run.getPublicAddress(value.bindTo, value.isMain)
+
+			assert.NoError(t, err)
+			assert.Equal(t, value.host, host)
+			assert.Equal(t, value.port, port)
+		})
+	}
+}
+
+func (suite *GetPublicAddressTestSuite) TestFail() {
+	testData := []struct {
+		name   string
+		run    Run
+		bindTo config.TypeBindTo
+		isMain bool
+	}{
+		{
+			name:   "unspecified ipv4",
+			bindTo: suite.makeBindTo("0.0.0.0:3128"),
+			isMain: true,
+		},
+		{
+			name:   "unspecified ipv6",
+			bindTo: suite.makeBindTo("[::]:3128"),
+			isMain: true,
+		},
+		{
+			name:   "unix socket of main without port",
+			run:    Run{PublicHost: "proxy.example.com"},
+			bindTo: suite.makeUnixBindTo(),
+			isMain: true,
+		},
+		{
+			name:   "unix socket of main without host",
+			run:    Run{PublicPort: 443},
+			bindTo: suite.makeUnixBindTo(),
+			isMain: true,
+		},
+	}
+
+	for _, v := range testData {
+		value := v
+
+		suite.T().Run(value.name, func(t *testing.T) {
+			_, _, err := value.run.getPublicAddress(value.bindTo, value.isMain)
+
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, errDryRunUnixSocket)
+		})
+	}
+}
+
+func (suite *GetPublicAddressTestSuite) TestSkipUnixSocket() {
+	run := Run{PublicHost: "proxy.example.com", PublicPort: 443}
+
+	_, _, err := run.getPublicAddress(suite.makeUnixBindTo(), false)
+	suite.ErrorIs(err, errDryRunUnixSocket)
+}
+
+func TestGetPublicAddress(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &GetPublicAddressTestSuite{})
+}
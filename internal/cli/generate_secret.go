@@ -1,24 +1,230 @@
 package cli
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/IceCodeNew/mtg/internal/config"
 	"github.com/IceCodeNew/mtg/mtglib"
 )
 
+// generateSecretCheckTimeout is a max time to connect to a fronting domain
+// and to finish TLS handshake with it.
+const generateSecretCheckTimeout = 10 * time.Second
+
+// generateSecretPopularHosts are domains of large providers. Their
+// networks are well known, so an IP address of a proxy obviously does not
+// belong to them.
+var generateSecretPopularHosts = []string{ //nolint: gochecknoglobals
+	"amazon.com",
+	"apple.com",
+	"cloudflare.com",
+	"facebook.com",
+	"google.com",
+	"googleapis.com",
+	"microsoft.com",
+	"telegram.org",
+	"youtube.com",
+}
+
+type generateSecretResponse struct {
+	Secret struct {
+		Hex    string `json:"hex"`
+		Base64 string `json:"base64"`
+	} `json:"secret"`
+	Hostname string   `json:"hostname"`
+	TgURL    string   `json:"tg_url,omitempty"`  //nolint: tagliatelle
+	TmeURL   string   `json:"tme_url,omitempty"` //nolint: tagliatelle
+	Warnings []string `json:"warnings"`
+}
+
 type GenerateSecret struct {
-	HostName string `kong:"arg,required,help='Hostname to use for domain fronting.',name='hostname'"`
-	Hex      bool   `kong:"help='Print secret in hex encoding.',short='x'"`
+	HostName   string `kong:"arg,required,help='Hostname to use for domain fronting.',name='hostname'"`
+	Hex        bool   `kong:"help='Print secret in hex encoding.',short='x'"`
+	JSON       bool   `kong:"help='Print secret, sharing links and warnings as JSON.',name='json'"`                                                           //nolint: lll
+	SkipChecks bool   `kong:"help='Do not check that hostname resolves and serves TLS.',name='skip-checks'"`                                                  //nolint: lll
+	PublicHost string `kong:"help='Host of proxy for sharing links. By default it is a public IPv4 address resolved via remote website.',name='public-host'"` //nolint: lll
+	PublicPort uint   `kong:"default='443',help='Port of proxy for sharing links.',name='public-port'"`                                                       //nolint: lll
 }
 
-func (g *GenerateSecret) Run(cli *CLI, _ string) error {
-	secret := mtglib.GenerateSecret(cli.GenerateSecret.HostName)
+// Run generates a new secret for a given hostname. Unless checks are
+// skipped, it also connects to the hostname to make sure that it serves
+// TLS and can be used for domain fronting. Problems which do not prevent
+// a hostname from working, but make a proxy easier to detect, are
+// reported as warnings.
+//
+// Text output has only a secret in stdout, links and warnings go to
+// stderr. So a secret can still be captured in shell scripts.
+func (g *GenerateSecret) Run(cli *CLI, version string) error {
+	if err := validateSecretHost(g.HostName); err != nil {
+		return fmt.Errorf("cannot use hostname: %w", err)
+	}
 
-	if g.Hex {
-		fmt.Println(secret.Hex()) //nolint: forbidigo
+	secret := mtglib.GenerateSecret(g.HostName)
+	resp := &generateSecretResponse{
+		Hostname: g.HostName,
+		Warnings: g.checkPopularHost(),
+	}
+	resp.Secret.Hex = secret.Hex()
+	resp.Secret.Base64 = secret.Base64()
+	publicHost := g.PublicHost
+
+	if !g.SkipChecks {
+		ntw, err := makeNetwork(&config.Config{}, version, networkOpts{})
+		if err != nil {
+			return fmt.Errorf("cannot init network: %w", err)
+		}
+
+		warnings, err := g.checkHost(ntw)
+		if err != nil {
+			return fmt.Errorf("hostname %s cannot be used for domain fronting (use --skip-checks to ignore): %w",
+				g.HostName, err)
+		}
+
+		resp.Warnings = append(resp.Warnings, warnings...)
+
+		if publicHost == "" {
+			if ip := getPublicIP(ntw, "tcp4"); ip != nil {
+				publicHost = ip.String()
+			}
+		}
+	}
+
+	if publicHost == "" {
+		resp.Warnings = append(resp.Warnings, "public host is unknown, please set --public-host to get sharing links")
 	} else {
-		fmt.Println(secret.Base64()) //nolint: forbidigo
+		encodedSecret := resp.Secret.Base64
+		if g.Hex {
+			encodedSecret = resp.Secret.Hex
+		}
+
+		resp.TgURL, resp.TmeURL = makeSharingURLs(publicHost, g.PublicPort, encodedSecret)
+	}
+
+	if g.JSON {
+		return g.printJSON(os.Stdout, resp)
 	}
 
+	g.printText(os.Stdout, os.Stderr, resp)
+
 	return nil
 }
+
+func (g *GenerateSecret) checkPopularHost() []string {
+	host := strings.ToLower(g.HostName)
+
+	for _, v := range generateSecretPopularHosts {
+		if host == v || strings.HasSuffix(host, "."+v) {
+			return []string{fmt.Sprintf(
+				"%s belongs to a large provider with well-known networks, a proxy outside of them is easy to detect",
+				g.HostName)}
+		}
+	}
+
+	return []string{}
+}
+
+// checkHost connects to a hostname in the same way as domain fronting
+// does and makes TLS handshake. An error means that hostname is
+// unreachable or does not serve TLS at all.
+func (g *GenerateSecret) checkHost(ntw mtglib.Network) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), generateSecretCheckTimeout)
+	defer cancel()
+
+	conn, err := ntw.DialContext(ctx, "tcp", net.JoinHostPort(g.HostName, "443"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect: %w", err)
+	}
+
+	defer conn.Close()
+
+	warnings := []string{}
+
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		if !addr.IP.IsGlobalUnicast() || addr.IP.IsPrivate() {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s resolves to non-public address %s", g.HostName, addr.IP.String()))
+		}
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName: g.HostName,
+		NextProtos: []string{"h2", "http/1.1"},
+		MinVersion: tls.VersionTLS10,
+		// a certificate is verified below: untrusted one is a warning.
+		InsecureSkipVerify: true, //nolint: gosec
+	})
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("cannot perform tls handshake: %w", err)
+	}
+
+	state := tlsConn.ConnectionState()
+
+	if state.Version != tls.VersionTLS13 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%s does not support TLS 1.3 but fake-tls connections look like TLS 1.3 ones", g.HostName))
+	}
+
+	if err := g.verifyCertificates(state.PeerCertificates); err != nil {
+		warnings = append(warnings, fmt.Sprintf("certificate of %s is not trusted: %v", g.HostName, err))
+	}
+
+	return warnings, nil
+}
+
+func (g *GenerateSecret) verifyCertificates(certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+
+	for _, v := range certs[1:] {
+		intermediates.AddCert(v)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       g.HostName,
+		Intermediates: intermediates,
+	})
+
+	return err //nolint: wrapcheck
+}
+
+func (g *GenerateSecret) printJSON(writer io.Writer, resp *generateSecretResponse) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(resp); err != nil {
+		return fmt.Errorf("cannot dump secret json: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GenerateSecret) printText(writer, infoWriter io.Writer, resp *generateSecretResponse) {
+	if g.Hex {
+		fmt.Fprintln(writer, resp.Secret.Hex)
+	} else {
+		fmt.Fprintln(writer, resp.Secret.Base64)
+	}
+
+	if resp.TgURL != "" {
+		fmt.Fprintln(infoWriter, resp.TgURL)
+		fmt.Fprintln(infoWriter, resp.TmeURL)
+	}
+
+	for _, v := range resp.Warnings {
+		fmt.Fprintf(infoWriter, "WARNING: %s\n", v)
+	}
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// runLogLevelSwitch makes logs one step more verbose on each SIGUSR1 until
+// context is closed: warn, info, debug and then warn again. A level is
+// changed for all loggers at once, without restart.
+func runLogLevelSwitch(ctx context.Context, level *logger.AtomicLevel, log mtglib.Logger) {
+	signals := utils.LogLevelSignals()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			log.BindStr("log-level", level.Cycle().String()).Info("log level is changed")
+		}
+	}
+}
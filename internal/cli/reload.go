@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/IceCodeNew/mtg/network"
+)
+
+// reloadableNetworkOptions are options which are used only to build a
+// network. Other network options are used by listeners or by a proxy
+// itself so they require a restart.
+var reloadableNetworkOptions = []string{
+	"network.country-egress",
+	"network.dns-cache",
+	"network.doh-ip",
+	"network.doh-url",
+	"network.dot-server",
+	"network.mirror",
+	"network.proxies",
+	"network.resolver",
+	"network.timeout.http",
+	"network.timeout.tcp",
+}
+
+// ipListBuilder builds and starts a new IP list which downloads its files
+// with a given network. Given callbacks are called in addition to those
+// which are set by a builder itself.
+type ipListBuilder func(conf config.ListConfig, ntw mtglib.Network,
+	updateCallback ipblocklist.FireholUpdateCallback,
+	failureCallback ipblocklist.FireholFailureCallback,
+) (mtglib.IPBlocklist, error)
+
+// reloader applies a new configuration to a running proxy without
+// dropping active streams. Only a part of options can be changed live:
+// log level, IP lists, client rate limits, a network to upstreams and
+// secrets from secrets-dir. Other changes are reported as requiring a
+// restart.
+//
+// Reload is atomic: everything is built and checked first and swapped
+// only after that. If anything fails, a proxy keeps working with a
+// previous configuration.
+type reloader struct {
+	mutex sync.Mutex
+
+	configPaths []string
+	conf        *config.Config
+	logger      mtglib.Logger
+	logLevel    *logger.AtomicLevel
+
+	network     *network.Reloadable
+	baseNetwork mtglib.Network
+	blocklist   *ipblocklist.Reloadable
+	allowlist   *ipblocklist.Reloadable
+	proxies     []*mtglib.Proxy
+	proxyOpts   []mtglib.ProxyOpts
+	eventStream mtglib.EventStream
+	dirSecrets  []mtglib.Secret
+
+	makeNetwork   func(*config.Config) (mtglib.Network, error)
+	makeBlocklist ipListBuilder
+	makeAllowlist ipListBuilder
+}
+
+// Run reloads a configuration on each SIGHUP until context is closed.
+func (r *reloader) Run(ctx context.Context) {
+	signals := utils.ReloadSignals()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			r.logger.Info("configuration reload is requested")
+
+			if err := r.Reload(ctx); err != nil {
+				r.logger.WarningError("cannot reload configuration, previous one is kept", err)
+			}
+		}
+	}
+}
+
+// Reload reads configuration files again and applies changes.
+func (r *reloader) Reload(ctx context.Context) error { //nolint: cyclop
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	next, err := utils.ReadConfig(r.configPaths...)
+	if err != nil {
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+
+	applied := applyReloadableOptions(r.conf, next)
+
+	if err := applied.Validate(); err != nil {
+		return fmt.Errorf("cannot apply config: %w", err)
+	}
+
+	requireRestart := applied.Diff(next)
+	changes := r.conf.Diff(applied)
+
+	// a path of a directory requires a restart but its content is read
+	// again on each reload.
+	dirSecrets, err := readSecretsDir(r.conf, r.logger.Named("secrets"))
+	if err != nil {
+		return err
+	}
+
+	dirSecretsAreChanged := !secretsAreEqual(r.dirSecrets, dirSecrets)
+
+	if len(changes) == 0 && len(requireRestart) == 0 && !dirSecretsAreChanged {
+		r.logger.Info("configuration is not changed")
+
+		return nil
+	}
+
+	ntw := r.baseNetwork
+	networkIsChanged := config.HasChanges(changes, reloadableNetworkOptions...)
+
+	if networkIsChanged {
+		if ntw, err = r.makeNetwork(applied); err != nil {
+			return fmt.Errorf("cannot build network: %w", err)
+		}
+	}
+
+	blocklist, allowlist, err := r.makeIPLists(ctx, changes, applied, ntw, networkIsChanged)
+	if err != nil {
+		return err
+	}
+
+	r.network.Swap(ntw)
+	r.baseNetwork = ntw
+
+	if blocklist != nil {
+		r.blocklist.Swap(blocklist)
+	}
+
+	if allowlist != nil {
+		r.allowlist.Swap(allowlist)
+	}
+
+	if config.HasChanges(changes, "defense.client-rate-limit") {
+		rate, burst, maxIPs := makeClientRateLimit(applied)
+
+		for _, proxy := range r.proxies {
+			proxy.SetClientRateLimit(rate, burst, maxIPs)
+		}
+	}
+
+	// a level could be changed with SIGUSR1 so it is reset only if a
+	// config asks for it.
+	if config.HasChanges(changes, "debug") {
+		r.logLevel.SetLevel(makeLogLevel(applied))
+	}
+
+	if dirSecretsAreChanged {
+		if err := r.setDirSecrets(ctx, dirSecrets); err != nil {
+			return fmt.Errorf("cannot apply secrets: %w", err)
+		}
+
+		changes = append(changes, "secrets-dir")
+	}
+
+	r.conf = applied
+
+	if len(requireRestart) > 0 {
+		r.logger.
+			BindStr("options", strings.Join(requireRestart, ", ")).
+			Warning("some options cannot be changed live, restart is required to apply them")
+	}
+
+	r.logger.BindStr("options", strings.Join(changes, ", ")).Info("configuration is reloaded")
+
+	return nil
+}
+
+// setDirSecrets replaces secrets from secrets-dir of the main proxy and
+// listeners which do not have own secrets. Active streams keep secrets
+// they were authenticated with.
+func (r *reloader) setDirSecrets(ctx context.Context, dirSecrets []mtglib.Secret) error {
+	secrets := append(append([]mtglib.Secret{}, r.conf.Secrets...), dirSecrets...)
+
+	for i, proxy := range r.proxies {
+		// listeners are built after the main proxy in the same order as
+		// in a config, please see runProxy.
+		if i > 0 && r.conf.Listeners[i-1].Secret.Valid() {
+			continue
+		}
+
+		if err := proxy.SetSecrets(append([]mtglib.Secret{r.proxyOpts[i].Secret}, secrets...)); err != nil {
+			return fmt.Errorf("cannot set secrets: %w", err)
+		}
+
+		r.proxyOpts[i].Secrets = secrets
+	}
+
+	r.dirSecrets = dirSecrets
+
+	r.eventStream.Send(ctx, mtglib.NewEventSecretsConfigured(countSecrets(r.proxyOpts)))
+
+	return nil
+}
+
+// secretsAreEqual checks if both lists have the same secrets in the same
+// order.
+func secretsAreEqual(left, right []mtglib.Secret) bool {
+	if len(left) != len(right) {
+		return false
+	}
+
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// makeIPLists builds changed lists and waits until they are updated for
+// the first time so a list is never replaced with an empty one. If any
+// list cannot be updated, all new lists are stopped.
+//
+// Lists are downloaded with a given network before it is swapped in. A list
+// keeps its network, so if a network is changed, all lists are rebuilt as
+// well.
+func (r *reloader) makeIPLists(ctx context.Context, changes []string,
+	conf *config.Config, ntw mtglib.Network, networkIsChanged bool,
+) (mtglib.IPBlocklist, mtglib.IPBlocklist, error) {
+	var (
+		blocklist, allowlist mtglib.IPBlocklist
+		waiters              []*ipListWaiter
+		err                  error
+	)
+
+	if networkIsChanged || config.HasChanges(changes, "defense.blocklist") {
+		waiter := newIPListWaiter("blocklist", ipListIsUpdated(conf.Defense.Blocklist, false))
+		waiters = append(waiters, waiter)
+
+		blocklist, err = r.makeBlocklist(conf.Defense.Blocklist, ntw, waiter.UpdateCallback, waiter.FailureCallback)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot build ip blocklist: %w", err)
+		}
+	}
+
+	if networkIsChanged || config.HasChanges(changes, "defense.allowlist") {
+		waiter := newIPListWaiter("allowlist", ipListIsUpdated(conf.Defense.Allowlist, true))
+		waiters = append(waiters, waiter)
+
+		allowlist, err = r.makeAllowlist(conf.Defense.Allowlist, ntw, waiter.UpdateCallback, waiter.FailureCallback)
+		if err != nil {
+			if blocklist != nil {
+				blocklist.Shutdown()
+			}
+
+			return nil, nil, fmt.Errorf("cannot build ip allowlist: %w", err)
+		}
+	}
+
+	for _, waiter := range waiters {
+		if err = waiter.Wait(ctx); err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		for _, list := range []mtglib.IPBlocklist{blocklist, allowlist} {
+			if list != nil {
+				list.Shutdown()
+			}
+		}
+
+		return nil, nil, err
+	}
+
+	return blocklist, allowlist, nil
+}
+
+// ipListWaiter waits for the first update of a new list and collects an
+// error if any file has failed.
+type ipListWaiter struct {
+	name     string
+	once     sync.Once
+	done     chan struct{}
+	mutex    sync.Mutex
+	firstErr error
+}
+
+func (i *ipListWaiter) UpdateCallback(_ context.Context, _ int) {
+	i.once.Do(func() {
+		close(i.done)
+	})
+}
+
+func (i *ipListWaiter) FailureCallback(_ context.Context, err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.firstErr == nil {
+		i.firstErr = err
+	}
+}
+
+func (i *ipListWaiter) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s is not updated: %w", i.name, ctx.Err())
+	case <-i.done:
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.firstErr != nil {
+		return fmt.Errorf("cannot update %s: %w", i.name, i.firstErr)
+	}
+
+	return nil
+}
+
+// newIPListWaiter creates a waiter of a list. If a list has nothing to
+// download, it is ready at once.
+func newIPListWaiter(name string, isUpdated bool) *ipListWaiter {
+	rv := &ipListWaiter{
+		name: name,
+		done: make(chan struct{}),
+	}
+
+	if !isUpdated {
+		close(rv.done)
+	}
+
+	return rv
+}
+
+// ipListIsUpdated checks if a list built by makeIPBlocklist or
+// makeIPAllowlist has firehol files which call update callbacks. Disabled
+// allowlist is a firehol list of all addresses.
+func ipListIsUpdated(conf config.ListConfig, isAllowlist bool) bool {
+	if !conf.Enabled.Get(false) {
+		return isAllowlist
+	}
+
+	return len(conf.Countries) == 0 || len(conf.URLs) > 0 || len(conf.CIDRs) > 0
+}
+
+// chainIPListCallbacks returns callbacks which call both given callbacks.
+// Any of them can be nil.
+func chainIPListCallbacks(update1, update2 ipblocklist.FireholUpdateCallback,
+	failure1, failure2 ipblocklist.FireholFailureCallback,
+) (ipblocklist.FireholUpdateCallback, ipblocklist.FireholFailureCallback) {
+	update, failure := update1, failure1
+
+	if update2 != nil {
+		update = func(ctx context.Context, size int) {
+			if update1 != nil {
+				update1(ctx, size)
+			}
+
+			update2(ctx, size)
+		}
+	}
+
+	if failure2 != nil {
+		failure = func(ctx context.Context, err error) {
+			if failure1 != nil {
+				failure1(ctx, err)
+			}
+
+			failure2(ctx, err)
+		}
+	}
+
+	return update, failure
+}
+
+// applyReloadableOptions returns a copy of a current config where the
+// options which can be changed live are taken from a new one.
+func applyReloadableOptions(current, next *config.Config) *config.Config {
+	rv := *current
+
+	rv.Debug = next.Debug
+	rv.Defense.Blocklist = next.Defense.Blocklist
+	rv.Defense.Allowlist = next.Defense.Allowlist
+	rv.Defense.ClientRateLimit = next.Defense.ClientRateLimit
+	rv.Network.Timeout.TCP = next.Network.Timeout.TCP
+	rv.Network.Timeout.HTTP = next.Network.Timeout.HTTP
+	rv.Network.DOHIP = next.Network.DOHIP
+	rv.Network.DOHURL = next.Network.DOHURL
+	rv.Network.Resolver = next.Network.Resolver
+	rv.Network.DOTServer = next.Network.DOTServer
+	rv.Network.Proxies = next.Network.Proxies
+	rv.Network.DNSCache = next.Network.DNSCache
+	rv.Network.Mirror = next.Network.Mirror
+	rv.Network.CountryEgress = next.Network.CountryEgress
+
+	return &rv
+}
@@ -2,19 +2,27 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/IceCodeNew/mtg/internal/utils"
 )
 
 type Run struct {
-	ConfigPath string `kong:"arg,required,type='existingfile',help='Path to the configuration file.',name='config-path'"` //nolint: lll
+	ConfigPaths []string `kong:"arg,required,type='existingfile',help='Paths to the configuration files. They are merged in order, later wins.',name='config-path'"`                            //nolint: lll
+	DryRun      bool     `kong:"help='Validate configuration, print sharing links and exit without running proxy.',name='dry-run'"`                                                             //nolint: lll
+	PublicHost  string   `kong:"help='Host which is advertised in sharing links of dry run. By default it is taken from bind-to parameter.',name='public-host'"`                                //nolint: lll
+	PublicPort  uint     `kong:"help='Port which is advertised in sharing links of dry run instead of a port of bind-to parameter. Additional listeners keep their ports.',name='public-port'"` //nolint: lll
 }
 
 func (r *Run) Run(cli *CLI, version string) error {
-	conf, err := utils.ReadConfig(r.ConfigPath)
+	conf, err := utils.ReadConfig(r.ConfigPaths...)
 	if err != nil {
 		return fmt.Errorf("cannot init config: %w", err)
 	}
 
-	return runProxy(conf, version)
+	if r.DryRun {
+		return r.dryRun(os.Stdout, conf)
+	}
+
+	return runProxy(conf, version, r.ConfigPaths)
 }
@@ -2,13 +2,21 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/IceCodeNew/mtg/antireplay"
 	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/geoip"
 	"github.com/IceCodeNew/mtg/internal/config"
 	"github.com/IceCodeNew/mtg/internal/utils"
 	"github.com/IceCodeNew/mtg/ipblocklist"
@@ -16,45 +24,246 @@ import (
 	"github.com/IceCodeNew/mtg/logger"
 	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/IceCodeNew/mtg/network"
+	"github.com/IceCodeNew/mtg/profiling"
 	"github.com/IceCodeNew/mtg/stats"
 	"github.com/rs/zerolog"
 	"github.com/yl2chen/cidranger"
 )
 
-func makeLogger(conf *config.Config) mtglib.Logger {
+func makeLogger(conf *config.Config, writer io.Writer) (mtglib.Logger, *logger.AtomicLevel) {
+	level := logger.NewAtomicLevel(makeLogLevel(conf))
+
+	return makeLoggerWithLevel(conf, writer, level), level
+}
+
+// makeLogWriter returns a destination of application logs. All loggers
+// share the same writer so there is a single connection to syslog or
+// journald.
+func makeLogWriter(conf *config.Config) (io.Writer, error) {
+	tag := conf.Log.Tag.Get(logger.DefaultTag)
+
+	switch conf.Log.Output.Get(defaultLogOutput) {
+	case config.TypeLogOutputSyslog:
+		network, address := "", ""
+
+		if value := conf.Log.Syslog.URL.Get(""); value != "" {
+			parsed, _ := url.Parse(value)
+
+			network, address = parsed.Scheme, parsed.Host
+			if parsed.Host == "" {
+				address = parsed.Path
+			}
+		}
+
+		return logger.NewSyslog(network, address, tag) //nolint: wrapcheck
+	case config.TypeLogOutputJournald:
+		return logger.NewJournald(tag) //nolint: wrapcheck
+	}
+
+	return os.Stdout, nil
+}
+
+func makeLogLevel(conf *config.Config) zerolog.Level {
+	if conf.Debug.Get(false) {
+		return zerolog.DebugLevel
+	}
+
+	return zerolog.WarnLevel
+}
+
+// makeInfoLogger returns a logger for messages which are useful even if
+// debug mode is disabled.
+func makeInfoLogger(conf *config.Config, writer io.Writer) mtglib.Logger {
+	return makeLoggerWithLevel(conf, writer, logger.NewAtomicLevel(zerolog.InfoLevel))
+}
+
+func makeLoggerWithLevel(conf *config.Config, writer io.Writer, level *logger.AtomicLevel) mtglib.Logger {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs
 	zerolog.TimestampFieldName = "timestamp"
 	zerolog.LevelFieldName = "level"
 
-	if conf.Debug.Get(false) {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	// global level is a lower bound for all loggers so it is set to the
+	// most verbose level. An actual level is set per logger and can be
+	// changed in runtime.
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+
+	baseLogger := zerolog.New(writer).With().Timestamp().Logger()
+	dedupInterval := time.Duration(0)
+	if conf.Log.Dedup.Enabled.Get(false) {
+		dedupInterval = conf.Log.Dedup.Interval.Get(logger.DefaultDedupInterval)
+	}
+
+	opts := logger.ZeroLoggerOpts{
+		DedupInterval: dedupInterval,
+		Level:         level,
+	}
+
+	if conf.Log.Sampling.Enabled.Get(false) {
+		opts.Sampling = logger.SamplingOpts{
+			Interval:   conf.Log.Sampling.Interval.Get(logger.DefaultSamplingInterval),
+			First:      conf.Log.Sampling.First.Get(logger.DefaultSamplingFirst),
+			Thereafter: conf.Log.Sampling.Thereafter.Get(logger.DefaultSamplingThereafter),
+		}
 	}
 
-	baseLogger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	rv := logger.NewZeroLoggerWithOpts(baseLogger, opts)
+
+	if instanceName := getInstanceName(conf); instanceName != "" {
+		rv = rv.BindStr("instance-name", instanceName)
+	}
+
+	return rv
+}
+
+func getInstanceName(conf *config.Config) string {
+	hostname, _ := os.Hostname()
 
-	return logger.NewZeroLogger(baseLogger)
+	return conf.InstanceName.Get(hostname)
 }
 
-func makeNetwork(conf *config.Config, version string) (mtglib.Network, error) {
+// networkOpts are optional dependencies of a network made by makeNetwork.
+// All of them can be nil.
+type networkOpts struct {
+	// geoDB is required by per-country egress. Without it, all upstream
+	// connections use the default egress.
+	geoDB *geoip.DB
+
+	dnsCallback        network.DNSQueryCallback
+	dnsCacheCallback   network.DNSCacheCallback
+	mirrorCallback     network.MirrorCallback
+	proxyStateCallback network.ProxyStateCallback
+}
+
+func makeNetwork(conf *config.Config, version string, opts networkOpts) (mtglib.Network, error) {
 	tcpTimeout := conf.Network.Timeout.TCP.Get(network.DefaultTimeout)
 	httpTimeout := conf.Network.Timeout.HTTP.Get(network.DefaultHTTPTimeout)
-	dohIP := conf.Network.DOHIP.Get(net.ParseIP(network.DefaultDOHHostname)).String()
 	userAgent := "mtg/" + version
+	dnsCacheOpts := network.DNSCacheOpts{
+		Policy:      conf.Network.DNSCache.Policy.Get(defaultDNSCachePolicy),
+		Size:        conf.Network.DNSCache.Size.Get(network.DefaultDNSCacheSize),
+		Callback:    opts.dnsCacheCallback,
+		MinTTL:      conf.Network.DNSCache.MinTTL.Get(network.DefaultDNSCacheMinTTL),
+		MaxTTL:      conf.Network.DNSCache.MaxTTL.Get(network.DefaultDNSCacheMaxTTL),
+		NegativeTTL: conf.Network.DNSCache.NegativeTTL.Get(network.DefaultDNSCacheNegativeTTL),
+	}
 
-	baseDialer, err := network.NewDefaultDialer(tcpTimeout, 0)
+	baseDialer, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{
+		Timeout:    tcpTimeout,
+		DSCP:       conf.Network.DSCP.Get(0),
+		BufferSize: int(conf.Network.BufferSize.Get(0)),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("cannot build a default dialer: %w", err)
 	}
 
-	if len(conf.Network.Proxies) == 0 {
-		return network.NewNetwork(baseDialer, userAgent, dohIP, httpTimeout) //nolint: wrapcheck
+	baseDialer, err = network.NewPreferIPDialer(baseDialer, conf.PreferIP.Get(mtglib.DefaultPreferIP))
+	if err != nil {
+		return nil, fmt.Errorf("cannot build upstream dialer: %w", err)
+	}
+
+	dialer, err := makeProxyDialer(conf.Network.Proxies, baseDialer, opts.proxyStateCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Network.Mirror.Enabled.Get(false) {
+		mirrorDialer, err := network.NewSocks5Dialer(baseDialer, conf.Network.Mirror.Proxy.Get(nil))
+		if err != nil {
+			return nil, fmt.Errorf("cannot build mirror dialer: %w", err)
+		}
+
+		dialer, err = network.NewMirrorDialer(dialer, mirrorDialer,
+			conf.Network.Mirror.SampleRate.Get(network.DefaultMirrorSampleRate),
+			opts.mirrorCallback)
+		if err != nil {
+			return nil, fmt.Errorf("cannot build mirroring dialer: %w", err)
+		}
+	}
+
+	if opts.geoDB != nil && len(conf.Network.CountryEgress) > 0 {
+		dialer, err = makeCountryDialer(conf, baseDialer, dialer, opts.geoDB, opts.proxyStateCallback)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ntwOpts := network.Opts{
+		Dialer:      dialer,
+		UserAgent:   userAgent,
+		DOHHostname: makeDOHResolver(conf),
+		HTTPTimeout: httpTimeout,
+		DNSCallback: opts.dnsCallback,
+		DNSCache:    dnsCacheOpts,
+	}
+
+	if conf.Network.Resolver.Get(defaultDNSResolver) == config.TypeDNSResolverDOT {
+		dotResolver, err := network.NewDoTResolverWithDialer(
+			conf.Network.DOTServer.Get(network.DefaultDOTHostname),
+			&tls.Config{ServerName: conf.Network.DOTServer.ServerName}, //nolint: gosec
+			httpTimeout, dialer)
+		if err != nil {
+			return nil, fmt.Errorf("cannot build dot resolver: %w", err)
+		}
+
+		ntwOpts.DNSResolver = dotResolver
+	}
+
+	return network.NewNetworkWithOpts(ntwOpts) //nolint: wrapcheck
+}
+
+// makeDOHResolver returns either a full URL of DOH resolver or its IP
+// address. URL has a priority.
+func makeDOHResolver(conf *config.Config) string {
+	if dohURL := conf.Network.DOHURL.Get(""); dohURL != "" {
+		return dohURL
+	}
+
+	return conf.Network.DOHIP.Get(defaultDOHIP).String()
+}
+
+// makeDNSResolverName returns a name of DNS resolver for metrics: either
+// host:port of DOT resolver or the one of makeDOHResolver.
+func makeDNSResolverName(conf *config.Config) string {
+	if conf.Network.Resolver.Get(defaultDNSResolver) == config.TypeDNSResolverDOT {
+		return conf.Network.DOTServer.Get(network.DefaultDOTHostname)
+	}
+
+	return makeDOHResolver(conf)
+}
+
+func makeCountryDialer(conf *config.Config, baseDialer, defaultDialer network.Dialer,
+	geoDB *geoip.DB, proxyStateCallback network.ProxyStateCallback,
+) (network.Dialer, error) {
+	dialers := map[string]network.Dialer{}
+
+	for i := range conf.Network.CountryEgress {
+		rule := &conf.Network.CountryEgress[i]
+
+		dialer, err := makeProxyDialer(rule.Proxies, baseDialer, proxyStateCallback)
+		if err != nil {
+			return nil, fmt.Errorf("cannot build dialer of country egress %d: %w", i, err)
+		}
+
+		for _, v := range rule.Countries {
+			dialers[v.Get("")] = dialer
+		}
+	}
+
+	return network.NewCountryDialer(defaultDialer, dialers, func(ip net.IP) string {
+		return geoDB.Lookup(ip).Country
+	}), nil
+}
+
+func makeProxyDialer(proxies []config.TypeProxyURL, baseDialer network.Dialer,
+	proxyStateCallback network.ProxyStateCallback,
+) (network.Dialer, error) {
+	if len(proxies) == 0 {
+		return baseDialer, nil
 	}
 
-	proxyURLs := make([]*url.URL, 0, len(conf.Network.Proxies))
+	proxyURLs := make([]*url.URL, 0, len(proxies))
 
-	for _, v := range conf.Network.Proxies {
+	for _, v := range proxies {
 		if value := v.Get(nil); value != nil {
 			proxyURLs = append(proxyURLs, value)
 		}
@@ -66,37 +275,141 @@ func makeNetwork(conf *config.Config, version string) (mtglib.Network, error) {
 			return nil, fmt.Errorf("cannot build socks5 dialer: %w", err)
 		}
 
-		return network.NewNetwork(socksDialer, userAgent, dohIP, httpTimeout) //nolint: wrapcheck
+		return socksDialer, nil
 	}
 
-	socksDialer, err := network.NewLoadBalancedSocks5Dialer(baseDialer, proxyURLs)
+	socksDialer, err := network.NewLoadBalancedSocks5DialerWithCallback(baseDialer, proxyURLs, proxyStateCallback)
 	if err != nil {
 		return nil, fmt.Errorf("cannot build socks5 dialer: %w", err)
 	}
 
-	return network.NewNetwork(socksDialer, userAgent, dohIP, httpTimeout) //nolint: wrapcheck
+	return socksDialer, nil
 }
 
-func makeAntiReplayCache(conf *config.Config) mtglib.AntiReplayCache {
+func makeListenNetwork(conf *config.Config) string {
+	switch conf.ClientPreferIP.Get(defaultClientPreferIP) {
+	case config.TypePreferOnlyIPv4:
+		return "tcp4"
+	case config.TypePreferOnlyIPv6:
+		return "tcp6"
+	}
+
+	return "tcp"
+}
+
+func makeAntiReplayCache(conf *config.Config, logger mtglib.Logger) (mtglib.AntiReplayCache, error) {
 	if !conf.Defense.AntiReplay.Enabled.Get(false) {
-		return antireplay.NewNoop()
+		return antireplay.NewNoop(), nil
+	}
+
+	hashFunc, err := antireplay.NewHash(conf.Defense.AntiReplay.Hash.Get(antireplay.DefaultHash))
+	if err != nil {
+		return nil, fmt.Errorf("cannot build a hash function: %w", err)
 	}
 
-	return antireplay.NewStableBloomFilter(
+	if redis := &conf.Defense.AntiReplay.Redis; redis.Enabled.Get(false) {
+		client := antireplay.NewRedisClient(
+			redis.Address.Get(""),
+			redis.Password.Get(""),
+			int(redis.DB.Get(0)))
+
+		return antireplay.NewRedis(
+			logger.Named("anti-replay"),
+			client,
+			redis.KeyPrefix.Get(antireplay.DefaultRedisKeyPrefix),
+			redis.TTL.Get(antireplay.DefaultRedisTTL),
+			hashFunc,
+		), nil
+	}
+
+	if path := conf.Defense.AntiReplay.Path.Get(""); path != "" {
+		cache, err := antireplay.NewPersistentWithOpts(antireplay.PersistentOpts{
+			Path:      path,
+			MaxSize:   conf.Defense.AntiReplay.MaxSize.Get(antireplay.DefaultStableBloomFilterMaxSize),
+			ErrorRate: conf.Defense.AntiReplay.ErrorRate.Get(antireplay.DefaultStableBloomFilterErrorRate),
+			Hash:      hashFunc,
+			MaxAge:    conf.Defense.AntiReplay.MaxAge.Get(antireplay.DefaultPersistentMaxAge),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot open persistent cache: %w", err)
+		}
+
+		return cache, nil
+	}
+
+	return antireplay.NewStableBloomFilterWithHash(
 		conf.Defense.AntiReplay.MaxSize.Get(antireplay.DefaultStableBloomFilterMaxSize),
 		conf.Defense.AntiReplay.ErrorRate.Get(antireplay.DefaultStableBloomFilterErrorRate),
-	)
+		hashFunc,
+	), nil
 }
 
 func makeIPBlocklist(conf config.ListConfig,
 	logger mtglib.Logger,
 	ntw mtglib.Network,
+	geoDB *geoip.DB,
 	updateCallback ipblocklist.FireholUpdateCallback,
+	failureCallback ipblocklist.FireholFailureCallback,
+	downloadLimiter *ipblocklist.DownloadLimiter,
 ) (mtglib.IPBlocklist, error) {
 	if !conf.Enabled.Get(false) {
 		return ipblocklist.NewNoop(), nil
 	}
 
+	lists := []mtglib.IPBlocklist{}
+
+	if len(conf.Countries) == 0 || len(conf.URLs) > 0 || len(conf.CIDRs) > 0 {
+		firehol, err := makeFireholBlocklist(conf, logger, ntw, updateCallback, failureCallback, downloadLimiter)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, firehol)
+	}
+
+	if len(conf.Countries) > 0 {
+		countries := make([]string, 0, len(conf.Countries))
+
+		for _, v := range conf.Countries {
+			countries = append(countries, v.Get(""))
+		}
+
+		lists = append(lists, ipblocklist.NewGeoIPFromDB(geoDB, countries))
+	}
+
+	blocklist, err := ipblocklist.NewChain(
+		conf.ChainMode.Get(defaultIPListChainMode),
+		lists...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build a chain of lists: %w", err)
+	}
+
+	go blocklist.Run(conf.UpdateEach.Get(ipblocklist.DefaultFireholUpdateEach))
+
+	return blocklist, nil
+}
+
+// makeIPListCallbacks tracks updates of enabled list in a status if it is
+// set. Disabled allowlist allows everyone so its state is meaningless.
+func makeIPListCallbacks(status *ipblocklist.Status,
+	name string,
+	conf config.ListConfig,
+	updateCallback ipblocklist.FireholUpdateCallback,
+) (ipblocklist.FireholUpdateCallback, ipblocklist.FireholFailureCallback) {
+	if status == nil || !conf.Enabled.Get(false) {
+		return updateCallback, nil
+	}
+
+	return status.UpdateCallback(name, updateCallback), status.FailureCallback(name)
+}
+
+func makeFireholBlocklist(conf config.ListConfig,
+	logger mtglib.Logger,
+	ntw mtglib.Network,
+	updateCallback ipblocklist.FireholUpdateCallback,
+	failureCallback ipblocklist.FireholFailureCallback,
+	downloadLimiter *ipblocklist.DownloadLimiter,
+) (mtglib.IPBlocklist, error) {
 	remoteURLs := []string{}
 	localFiles := []string{}
 
@@ -108,17 +421,38 @@ func makeIPBlocklist(conf config.ListConfig,
 		}
 	}
 
-	blocklist, err := ipblocklist.NewFirehol(logger.Named("ipblockist"),
-		ntw,
-		conf.DownloadConcurrency.Get(1),
-		remoteURLs,
-		localFiles,
-		updateCallback)
+	if !conf.ResolveViaDOH.Get(false) {
+		ntw = network.NewSystemResolverNetwork(ntw)
+	}
+
+	blocklists, err := ipblocklist.NewFireholFiles(ntw, remoteURLs, localFiles)
 	if err != nil {
 		return nil, fmt.Errorf("incorrect parameters for firehol: %w", err)
 	}
 
-	go blocklist.Run(conf.UpdateEach.Get(ipblocklist.DefaultFireholUpdateEach))
+	if len(conf.CIDRs) > 0 {
+		networks := make([]*net.IPNet, 0, len(conf.CIDRs))
+
+		for _, v := range conf.CIDRs {
+			networks = append(networks, v.Get(nil))
+		}
+
+		blocklists = append(blocklists, files.NewMem(networks))
+	}
+
+	blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+		Logger:              logger.Named("ipblockist"),
+		Files:               blocklists,
+		DownloadConcurrency: conf.DownloadConcurrency.Get(defaultIPListDownloadConcurrency),
+		DownloadLimiter:     downloadLimiter,
+		DownloadAttempts:    conf.DownloadAttempts.Get(ipblocklist.DefaultFireholDownloadAttempts),
+		DownloadBackoff:     conf.DownloadBackoff.Get(ipblocklist.DefaultFireholDownloadBackoff),
+		UpdateCallback:      updateCallback,
+		FailureCallback:     failureCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("incorrect parameters for firehol: %w", err)
+	}
 
 	return blocklist, nil
 }
@@ -126,7 +460,10 @@ func makeIPBlocklist(conf config.ListConfig,
 func makeIPAllowlist(conf config.ListConfig,
 	logger mtglib.Logger,
 	ntw mtglib.Network,
+	geoDB *geoip.DB,
 	updateCallback ipblocklist.FireholUpdateCallback,
+	failureCallback ipblocklist.FireholFailureCallback,
+	downloadLimiter *ipblocklist.DownloadLimiter,
 ) (mtglib.IPBlocklist, error) {
 	var (
 		allowlist mtglib.IPBlocklist
@@ -152,7 +489,10 @@ func makeIPAllowlist(conf config.ListConfig,
 			conf,
 			logger,
 			ntw,
+			geoDB,
 			updateCallback,
+			failureCallback,
+			downloadLimiter,
 		)
 	}
 
@@ -163,116 +503,838 @@ func makeIPAllowlist(conf config.ListConfig,
 	return allowlist, nil
 }
 
-func makeEventStream(conf *config.Config, logger mtglib.Logger) (mtglib.EventStream, error) {
+func makeGeoIP(conf *config.Config, failureCallback geoip.LoadFailureCallback) (*geoip.DB, error) {
+	countryDB := conf.GeoIP.CountryDB.Get("")
+	asnDB := conf.GeoIP.ASNDB.Get("")
+
+	if countryDB == "" && asnDB == "" {
+		return nil, nil //nolint: nilnil
+	}
+
+	return geoip.NewDBWithFallback(countryDB, asnDB, failureCallback) //nolint: wrapcheck
+}
+
+func makeGeoIPUpdater(conf *config.Config,
+	logger mtglib.Logger,
+	ntw mtglib.Network,
+	geoDB *geoip.DB,
+	updateCallback geoip.UpdateCallback,
+) (*geoip.Updater, error) {
+	if geoDB == nil || !conf.GeoIP.Download.Enabled.Get(false) {
+		return nil, nil //nolint: nilnil
+	}
+
+	return geoip.NewUpdater( //nolint: wrapcheck
+		logger,
+		network.NewSystemResolverNetwork(ntw),
+		geoDB,
+		conf.GeoIP.Download.CountryURL.Get(""),
+		conf.GeoIP.Download.ASNURL.Get(""),
+		conf.GeoIP.Download.Attempts.Get(geoip.DefaultDownloadAttempts),
+		conf.GeoIP.Download.Backoff.Get(geoip.DefaultDownloadBackoff),
+		updateCallback)
+}
+
+func makeProfiler(conf *config.Config,
+	logger mtglib.Logger,
+	ntw mtglib.Network,
+) (*profiling.Pyroscope, error) {
+	if !conf.Profiling.Enabled.Get(false) {
+		return nil, nil //nolint: nilnil
+	}
+
+	return profiling.NewPyroscope( //nolint: wrapcheck
+		logger,
+		network.NewSystemResolverNetwork(ntw),
+		conf.Profiling.PyroscopeURL.Get(""),
+		conf.Profiling.AppName.Get(profiling.DefaultAppName),
+		getInstanceName(conf),
+		conf.Profiling.UploadEach.Get(profiling.DefaultUploadEach))
+}
+
+func makeOriginOpts(conf *config.Config, geoDB *geoip.DB) stats.OriginOpts {
+	if geoDB == nil || !conf.Stats.Origin.Enabled.Get(false) {
+		return stats.OriginOpts{}
+	}
+
+	opts := stats.OriginOpts{
+		Lookup:    geoDB,
+		Countries: make([]string, 0, len(conf.Stats.Origin.Countries)),
+		ASNs:      make([]uint, 0, len(conf.Stats.Origin.ASNs)),
+		MaxASNs:   conf.Stats.Origin.MaxASNs.Get(stats.DefaultOriginMaxASNs),
+	}
+
+	for _, v := range conf.Stats.Origin.Countries {
+		opts.Countries = append(opts.Countries, v.Get(""))
+	}
+
+	for _, v := range conf.Stats.Origin.ASNs {
+		opts.ASNs = append(opts.ASNs, v.Get(0))
+	}
+
+	return opts
+}
+
+// makePrometheusTLSConfig returns nil if prometheus endpoint is plain HTTP.
+func makePrometheusTLSConfig(conf *config.Config) (*tls.Config, error) {
+	tlsConf := &conf.Stats.Prometheus.TLS
+
+	if tlsConf.Cert.Get("") == "" {
+		return nil, nil
+	}
+
+	return stats.NewPrometheusTLSConfig( //nolint: wrapcheck
+		tlsConf.Cert.Get(""),
+		tlsConf.Key.Get(""),
+		tlsConf.ClientCA.Get(""))
+}
+
+// makeEventStream builds an event stream with all enabled observers. A
+// returned function closes observers which keep connections to external
+// services, it has to be called on shutdown.
+func makeEventStream(conf *config.Config, logger mtglib.Logger,
+	geoDB *geoip.DB, ipListStatus *ipblocklist.Status,
+) (mtglib.EventStream, func(), error) {
 	factories := make([]events.ObserverFactory, 0, 2) //nolint: gomnd
+	closers := []func(){}
+	closeAll := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+
+	originOpts := makeOriginOpts(conf, geoDB)
+	instanceName := getInstanceName(conf)
 
 	if conf.Stats.StatsD.Enabled.Get(false) {
-		statsdFactory, err := stats.NewStatsd(
-			conf.Stats.StatsD.Address.Get(""),
-			logger.Named("statsd"),
-			conf.Stats.StatsD.MetricPrefix.Get(stats.DefaultStatsdMetricPrefix),
-			conf.Stats.StatsD.TagFormat.Get(stats.DefaultStatsdTagFormat))
+		statsdFactory, err := stats.NewStatsdWithOpts(stats.StatsdOpts{
+			Address:      conf.Stats.StatsD.Address.Get(""),
+			Logger:       logger.Named("statsd"),
+			MetricPrefix: conf.Stats.StatsD.MetricPrefix.Get(stats.DefaultStatsdMetricPrefix),
+			TagFormat:    conf.Stats.StatsD.TagFormat.Get(stats.DefaultStatsdTagFormat),
+			InstanceName: instanceName,
+			Origin:       originOpts,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("cannot build statsd observer: %w", err)
+			return nil, nil, fmt.Errorf("cannot build statsd observer: %w", err)
 		}
 
 		factories = append(factories, statsdFactory.Make)
 	}
 
 	if conf.Stats.Prometheus.Enabled.Get(false) {
-		prometheus := stats.NewPrometheus(
-			conf.Stats.Prometheus.MetricPrefix.Get(stats.DefaultMetricPrefix),
-			conf.Stats.Prometheus.HTTPPath.Get("/"),
-		)
+		prometheus := stats.NewPrometheusWithOpts(stats.PrometheusOpts{
+			MetricPrefix: conf.Stats.Prometheus.MetricPrefix.Get(stats.DefaultMetricPrefix),
+			HTTPPath:     conf.Stats.Prometheus.HTTPPath.Get(defaultPrometheusHTTPPath),
+			InstanceName: instanceName,
+			Origin:       originOpts,
+		})
 
-		listener, err := net.Listen("tcp", conf.Stats.Prometheus.BindTo.Get(""))
-		if err != nil {
-			return nil, fmt.Errorf("cannot start a listener for prometheus: %w", err)
+		bindTo := conf.Stats.Prometheus.BindTo.Get("")
+		textfile := conf.Stats.Prometheus.Textfile.Path.Get("")
+
+		if bindTo != "" || textfile == "" {
+			tlsConfig, err := makePrometheusTLSConfig(conf)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot build tls config for prometheus: %w", err)
+			}
+
+			listener, err := net.Listen("tcp", bindTo)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot start a listener for prometheus: %w", err)
+			}
+
+			go prometheus.ServeTLS(listener, tlsConfig) //nolint: errcheck
 		}
 
-		go prometheus.Serve(listener) //nolint: errcheck
+		if textfile != "" {
+			go prometheus.ServeTextfile(textfile,
+				conf.Stats.Prometheus.Textfile.Interval.Get(stats.DefaultPrometheusTextfileInterval),
+				logger.Named("prometheus"))
+		}
 
 		factories = append(factories, prometheus.Make)
+
+		if conf.Stats.SSE.Enabled.Get(false) {
+			sse := events.NewSSE(conf.Stats.SSE.Token.Get(""),
+				conf.Stats.SSE.QueueSize.Get(events.DefaultSSEQueueSize))
+
+			prometheus.Handle(conf.Stats.SSE.HTTPPath.Get(""), sse)
+
+			factories = append(factories, sse.Make)
+		}
+
+		if conf.Stats.RecentEvents.Enabled.Get(false) {
+			recentEvents := events.NewRecentEvents(conf.Stats.RecentEvents.Token.Get(""),
+				conf.Stats.RecentEvents.Size.Get(events.DefaultRecentEventsSize))
+
+			prometheus.Handle(conf.Stats.RecentEvents.HTTPPath.Get(""), recentEvents)
+
+			factories = append(factories, recentEvents.Make)
+		}
+
+		if ipListStatus != nil {
+			prometheus.Handle(conf.Stats.IPLists.HTTPPath.Get(""), ipListStatus)
+		}
+	}
+
+	if conf.Stats.UnixDatagram.Enabled.Get(false) {
+		unixDatagram, err := events.NewUnixDatagram(
+			conf.Stats.UnixDatagram.Path.Get(""),
+			conf.Stats.UnixDatagram.QueueSize.Get(events.DefaultUnixDatagramQueueSize))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot build unix datagram observer: %w", err)
+		}
+
+		factories = append(factories, unixDatagram.Make)
+		closers = append(closers, unixDatagram.Close)
+	}
+
+	if conf.Stats.NATS.Enabled.Get(false) {
+		nats, err := events.NewNATS(
+			conf.Stats.NATS.Address.Get(""),
+			conf.Stats.NATS.Subject.Get(events.DefaultNATSSubject),
+			conf.Stats.NATS.Token.Get(""),
+			conf.Stats.NATS.QueueSize.Get(events.DefaultNATSQueueSize))
+		if err != nil {
+			closeAll()
+
+			return nil, nil, fmt.Errorf("cannot build nats observer: %w", err)
+		}
+
+		factories = append(factories, nats.Make)
+		closers = append(closers, nats.Close)
+	}
+
+	if conf.Stats.JSON.Enabled.Get(false) {
+		sink, err := makeJSONSink(conf)
+		if err != nil {
+			closeAll()
+
+			return nil, nil, fmt.Errorf("cannot build json observer: %w", err)
+		}
+
+		jsonObserver := events.NewJSONObserver(sink,
+			conf.Stats.JSON.QueueSize.Get(events.DefaultJSONQueueSize),
+			conf.Stats.JSON.BatchSize.Get(events.DefaultJSONBatchSize),
+			conf.Stats.JSON.FlushInterval.Get(events.DefaultJSONFlushInterval))
+
+		factories = append(factories, jsonObserver.Make)
+		closers = append(closers, jsonObserver.Close)
+
+		// queued records are written on close so a sink is closed after it.
+		if closer, ok := sink.(io.Closer); ok {
+			closers = append(closers, func() {
+				closer.Close()
+			})
+		}
+	}
+
+	if conf.Log.Access.Enabled.Get(false) {
+		accessLog, closeAccessLog, err := makeAccessLog(conf)
+		if err != nil {
+			closeAll()
+
+			return nil, nil, fmt.Errorf("cannot build access log observer: %w", err)
+		}
+
+		factories = append(factories, accessLog.Make)
+		closers = append(closers, closeAccessLog)
 	}
 
 	if len(factories) > 0 {
-		return events.NewEventStream(factories), nil
+		return events.NewEventStream(factories), closeAll, nil
+	}
+
+	return events.NewNoopStream(), closeAll, nil
+}
+
+func makeJSONSink(conf *config.Config) (events.JSONSink, error) {
+	if webhookURL := conf.Stats.JSON.WebhookURL.Get(""); webhookURL != "" {
+		return events.NewJSONWebhookSink(webhookURL) //nolint: wrapcheck
+	}
+
+	return events.NewJSONFileSink( //nolint: wrapcheck
+		conf.Stats.JSON.Path.Get(""),
+		int64(conf.Stats.JSON.MaxSize.Get(0)),
+		conf.Stats.JSON.MaxBackups.Get(events.DefaultJSONFileMaxBackups))
+}
+
+// makeAccessLog returns a factory of access log observers and a function
+// which syncs and closes a file of access log.
+func makeAccessLog(conf *config.Config) (*events.AccessLogFactory, func(), error) {
+	var writer io.Writer = os.Stdout
+
+	closeFile := func() {}
+
+	if path := conf.Log.Access.Path.Get(""); path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640) //nolint: gomnd
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot open %s: %w", path, err)
+		}
+
+		writer = file
+		closeFile = func() {
+			file.Sync() //nolint: errcheck
+			file.Close()
+		}
+	}
+
+	factory, err := events.NewAccessLog(writer, conf.Log.Access.Format.Get(defaultAccessLogFormat))
+	if err != nil {
+		closeFile()
+
+		return nil, nil, err //nolint: wrapcheck
+	}
+
+	return factory, closeFile, nil
+}
+
+type startupSummary struct {
+	Version    string `json:"version"`
+	BindTo     string `json:"bindTo"`
+	Listeners  int    `json:"listeners"`
+	Secrets    int    `json:"secrets"`
+	Blocklist  bool   `json:"blocklist"`
+	Allowlist  bool   `json:"allowlist"`
+	AntiReplay bool   `json:"antiReplay"`
+	Proxies    int    `json:"proxies"`
+	DOHIP      string `json:"dohIp,omitempty"`
+	DOHURL     string `json:"dohUrl,omitempty"`
+	Resolver   string `json:"resolver"`
+	DOTServer  string `json:"dotServer,omitempty"`
+}
+
+// logStartupSummary puts a short description of effective configuration at
+// info level even if debug mode is disabled. Unlike a full configuration,
+// it never contains secrets or credentials of proxies.
+func logStartupSummary(conf *config.Config, version string, writer io.Writer) {
+	summary := startupSummary{
+		Version:    version,
+		BindTo:     conf.BindTo.Get(""),
+		Listeners:  1 + len(conf.Listeners),
+		Secrets:    1 + len(conf.Secrets),
+		Blocklist:  conf.Defense.Blocklist.Enabled.Get(false),
+		Allowlist:  conf.Defense.Allowlist.Enabled.Get(false),
+		AntiReplay: conf.Defense.AntiReplay.Enabled.Get(false),
+		Proxies:    len(conf.Network.Proxies),
+		Resolver:   conf.Network.Resolver.Get(defaultDNSResolver),
+	}
+
+	// URL has a priority over IP, please see makeDOHResolver.
+	if dohURL := conf.Network.DOHURL.Get(""); dohURL != "" {
+		summary.DOHURL = config.RedactURL(dohURL)
+	} else {
+		summary.DOHIP = conf.Network.DOHIP.Get(defaultDOHIP).String()
+	}
+
+	if summary.Resolver == config.TypeDNSResolverDOT {
+		summary.DOTServer = conf.Network.DOTServer.Get(network.DefaultDOTHostname)
+	}
+
+	for i := range conf.Listeners {
+		if conf.Listeners[i].Secret.Valid() {
+			summary.Secrets++
+		}
 	}
 
-	return events.NewNoopStream(), nil
+	encoded, err := json.Marshal(summary)
+	if err != nil {
+		panic(err)
+	}
+
+	makeInfoLogger(conf, writer).
+		Named("startup").
+		BindJSON("summary", string(encoded)).
+		Info("proxy is starting")
 }
 
-func runProxy(conf *config.Config, version string) error { //nolint: funlen
-	logger := makeLogger(conf)
+// warnShortIdleTimeout warns if network.timeout.idle is shorter than
+// [mtglib.MinSafeIdleTimeout]. This option had no effect before and old
+// example configuration has set it to 1m, so such configs start to close
+// quiet streams after an upgrade.
+func warnShortIdleTimeout(conf *config.Config, logger mtglib.Logger) {
+	if idle := conf.Network.Timeout.Idle.Get(0); idle > 0 && idle < mtglib.MinSafeIdleTimeout {
+		logger.
+			Named("startup").
+			BindStr("idle", idle.String()).
+			BindStr("recommended", mtglib.MinSafeIdleTimeout.String()).
+			Warning("idle timeout is short, streams of quiet clients may be closed")
+	}
+}
+
+// runProxy starts a proxy and waits until it is stopped. If config paths
+// are given, a configuration is reloaded from them on SIGHUP.
+func runProxy(conf *config.Config, version string, configPaths []string) error { //nolint: funlen, cyclop
+	logWriter, err := makeLogWriter(conf)
+	if err != nil {
+		return fmt.Errorf("cannot build logger: %w", err)
+	}
+
+	logger, logLevel := makeLogger(conf, logWriter)
 
 	logger.BindJSON("configuration", conf.String()).Debug("configuration")
+	logStartupSummary(conf, version, logWriter)
+	warnShortIdleTimeout(conf, logger)
+
+	var eventStream mtglib.EventStream
 
-	eventStream, err := makeEventStream(conf, logger)
+	geoDB, err := makeGeoIP(conf, func(ctx context.Context, database string, err error) {
+		logger.BindStr("database", database).WarningError("cannot load geoip database", err)
+		eventStream.Send(ctx, mtglib.NewEventGeoIPLoadFailed(database))
+	})
+	if err != nil {
+		return fmt.Errorf("cannot open geoip databases: %w", err)
+	}
+
+	if geoDB != nil {
+		defer geoDB.Close()
+	}
+
+	var ipListStatus *ipblocklist.Status
+
+	if conf.Stats.IPLists.Enabled.Get(false) {
+		ipListStatus = ipblocklist.NewStatus()
+	}
+
+	eventStream, closeEventStream, err := makeEventStream(conf, logger, geoDB, ipListStatus)
 	if err != nil {
 		return fmt.Errorf("cannot build event stream: %w", err)
 	}
 
-	ntw, err := makeNetwork(conf, version)
+	defer closeEventStream()
+
+	if geoDB != nil {
+		geoDB.Reload() //nolint: errcheck
+
+		go geoDB.Run(conf.GeoIP.UpdateEach.Get(geoip.DefaultUpdateEach))
+	}
+
+	buildNetwork := func(conf *config.Config) (mtglib.Network, error) {
+		dnsResolver := makeDNSResolverName(conf)
+
+		return makeNetwork(conf, version, networkOpts{
+			geoDB: geoDB,
+			dnsCallback: func(ctx context.Context, hostname string, duration time.Duration,
+				isCached bool, err error,
+			) {
+				eventStream.Send(ctx,
+					mtglib.NewEventDNSQuery(dnsResolver, hostname, duration, isCached, err != nil))
+			},
+			dnsCacheCallback: func(size, evicted int) {
+				eventStream.Send(context.Background(), mtglib.NewEventDNSCacheUpdated(size, evicted))
+			},
+			mirrorCallback: func(address string, primary, mirror network.MirrorDialResult) {
+				eventStream.Send(context.Background(), mtglib.NewEventUpstreamMirrored(address,
+					primary.Duration, mirror.Duration, primary.Err != nil, mirror.Err != nil))
+			},
+			proxyStateCallback: func(proxyURL *url.URL, ejected bool) {
+				proxyLogger := logger.Named("upstream-proxy").BindStr("proxy", proxyURL.Host)
+
+				if ejected {
+					proxyLogger.Warning("proxy is ejected from rotation")
+				} else {
+					proxyLogger.Info("proxy is restored")
+				}
+
+				eventStream.Send(context.Background(),
+					mtglib.NewEventUpstreamProxyStateChanged(proxyURL.Host, ejected))
+			},
+		})
+	}
+
+	baseNetwork, err := buildNetwork(conf)
 	if err != nil {
 		return fmt.Errorf("cannot build network: %w", err)
 	}
 
-	blocklist, err := makeIPBlocklist(
-		conf.Defense.Blocklist,
-		logger.Named("blocklist"),
-		ntw,
-		func(ctx context.Context, size int) {
-			eventStream.Send(ctx, mtglib.NewEventIPListSize(size, true))
+	// network and lists are reloadable so a reload of a configuration
+	// affects new connections only.
+	ntw := network.NewReloadable(baseNetwork)
+
+	geoUpdater, err := makeGeoIPUpdater(conf, logger, ntw, geoDB,
+		func(ctx context.Context, database string, err error) {
+			eventStream.Send(ctx, mtglib.NewEventGeoIPUpdated(database, err != nil))
 		})
+	if err != nil {
+		return fmt.Errorf("cannot build geoip updater: %w", err)
+	}
+
+	if geoUpdater != nil {
+		defer geoUpdater.Shutdown()
+
+		go geoUpdater.Run(conf.GeoIP.Download.UpdateEach.Get(geoip.DefaultUpdateEach))
+	}
+
+	profiler, err := makeProfiler(conf, logger, ntw)
+	if err != nil {
+		return fmt.Errorf("cannot build profiler: %w", err)
+	}
+
+	if profiler != nil {
+		defer profiler.Shutdown()
+
+		go profiler.Run()
+	}
+
+	downloadLimiter := ipblocklist.NewDownloadLimiter(conf.Defense.MaxConcurrentDownloads.Get(0))
+
+	buildBlocklist := func(listConf config.ListConfig, ntw mtglib.Network,
+		updateCallback ipblocklist.FireholUpdateCallback,
+		failureCallback ipblocklist.FireholFailureCallback,
+	) (mtglib.IPBlocklist, error) {
+		blocklistUpdate, blocklistFailure := makeIPListCallbacks(ipListStatus, "blocklist",
+			listConf,
+			func(ctx context.Context, size int) {
+				eventStream.Send(ctx, mtglib.NewEventIPListSize(size, true))
+			})
+		blocklistUpdate, blocklistFailure = chainIPListCallbacks(blocklistUpdate, updateCallback,
+			blocklistFailure, failureCallback)
+
+		return makeIPBlocklist(
+			listConf,
+			logger.Named("blocklist"),
+			ntw,
+			geoDB,
+			blocklistUpdate,
+			blocklistFailure,
+			downloadLimiter)
+	}
+
+	blocklist, err := buildBlocklist(conf.Defense.Blocklist, baseNetwork, nil, nil)
 	if err != nil {
 		return fmt.Errorf("cannot build ip blocklist: %w", err)
 	}
 
-	allowlist, err := makeIPAllowlist(
-		conf.Defense.Allowlist,
-		logger.Named("allowlist"),
-		ntw,
-		func(ctx context.Context, size int) {
-			eventStream.Send(ctx, mtglib.NewEventIPListSize(size, false))
-		},
-	)
+	buildAllowlist := func(listConf config.ListConfig, ntw mtglib.Network,
+		updateCallback ipblocklist.FireholUpdateCallback,
+		failureCallback ipblocklist.FireholFailureCallback,
+	) (mtglib.IPBlocklist, error) {
+		allowlistUpdate, allowlistFailure := makeIPListCallbacks(ipListStatus, "allowlist",
+			listConf,
+			func(ctx context.Context, size int) {
+				eventStream.Send(ctx, mtglib.NewEventIPListSize(size, false))
+			})
+		allowlistUpdate, allowlistFailure = chainIPListCallbacks(allowlistUpdate, updateCallback,
+			allowlistFailure, failureCallback)
+
+		return makeIPAllowlist(
+			listConf,
+			logger.Named("allowlist"),
+			ntw,
+			geoDB,
+			allowlistUpdate,
+			allowlistFailure,
+			downloadLimiter,
+		)
+	}
+
+	allowlist, err := buildAllowlist(conf.Defense.Allowlist, baseNetwork, nil, nil)
 	if err != nil {
 		return fmt.Errorf("cannot build ip allowlist: %w", err)
 	}
 
+	reloadableBlocklist := ipblocklist.NewReloadable(blocklist)
+	reloadableAllowlist := ipblocklist.NewReloadable(allowlist)
+
+	// lists are shared by all proxies so they are stopped only after every
+	// proxy is shut down.
+	defer reloadableBlocklist.Shutdown()
+	defer reloadableAllowlist.Shutdown()
+
+	antiReplayCache, err := makeAntiReplayCache(conf, logger)
+	if err != nil {
+		return fmt.Errorf("cannot build anti-replay cache: %w", err)
+	}
+
+	// persistent cache flushes cells to a disk and redis cache closes
+	// pooled connections on shutdown.
+	if closer, ok := antiReplayCache.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	dirSecrets, err := readSecretsDir(conf, logger.Named("secrets"))
+	if err != nil {
+		return err
+	}
+
 	opts := mtglib.ProxyOpts{
 		Logger:          logger,
 		Network:         ntw,
-		AntiReplayCache: makeAntiReplayCache(conf),
-		IPBlocklist:     blocklist,
-		IPAllowlist:     allowlist,
+		AntiReplayCache: antiReplayCache,
+		IPBlocklist:     reloadableBlocklist,
+		IPAllowlist:     reloadableAllowlist,
 		EventStream:     eventStream,
 
+		// lists are shared by all proxies and secrets are reported once for
+		// all of them, please see below.
+		Shared: true,
+
 		Secret:             conf.Secret,
+		Secrets:            append(append([]mtglib.Secret{}, conf.Secrets...), dirSecrets...),
 		DomainFrontingPort: conf.DomainFrontingPort.Get(mtglib.DefaultDomainFrontingPort),
 		PreferIP:           conf.PreferIP.Get(mtglib.DefaultPreferIP),
+		DCAddresses:        makeDCAddresses(conf),
+
+		UnknownClientIPPolicy: conf.UnknownClientIPPolicy.Get(mtglib.DefaultUnknownClientIPPolicy),
+		IPListPrecedence:      conf.Defense.IPListPrecedence.Get(mtglib.DefaultIPListPrecedence),
+		OnMissingSNI:          conf.Defense.OnMissingSNI.Get(mtglib.DefaultMissingSNIPolicy),
+		FallbackClientIP:      conf.FallbackClientIP.Get(nil),
+
+		Concurrency:           conf.Concurrency.Get(mtglib.DefaultConcurrency),
+		AdmissionQueueSize:    conf.AdmissionQueueSize.Get(0),
+		AdmissionQueueTimeout: conf.AdmissionQueueTimeout.Get(mtglib.DefaultAdmissionQueueTimeout),
+
+		FileDescriptorsSoftLimit: conf.FileDescriptorsSoftLimit.Get(0),
+		MaxConnsPerDC:            int(conf.MaxConnsPerDC.Get(0)),
 
 		AllowFallbackOnUnknownDC: conf.AllowFallbackOnUnknownDC.Get(false),
+		AntiReplayPerClientIP:    conf.Defense.AntiReplay.PerClientIP.Get(false),
+		MaxHandshakeSize:         conf.Defense.MaxHandshakeSize.Get(0),
+		HandshakeJitter:          conf.Defense.HandshakeJitter.Get(0),
+		RelayTimeout:             conf.Network.Timeout.Relay.Get(0),
+		IdleTimeout:              conf.Network.Timeout.Idle.Get(0),
+		IdleSweepInterval:        conf.Network.Timeout.IdleSweepInterval.Get(mtglib.DefaultIdleSweepInterval),
 		TolerateTimeSkewness:     conf.TolerateTimeSkewness.Value,
 	}
 
-	proxy, err := mtglib.NewProxy(opts)
-	if err != nil {
-		return fmt.Errorf("cannot create a proxy: %w", err)
+	if conf.Defense.ProbeTarpit.Enabled.Get(false) {
+		opts.ProbeTarpitDuration = conf.Defense.ProbeTarpit.Duration.Get(mtglib.DefaultProbeTarpitDuration)
+		opts.ProbeTarpitMaxConnections = conf.Defense.ProbeTarpit.MaxConnections.Get(
+			mtglib.DefaultProbeTarpitMaxConnections)
 	}
 
-	listener, err := utils.NewListener(conf.BindTo.Get(""), 0)
-	if err != nil {
-		return fmt.Errorf("cannot start proxy: %w", err)
+	if conf.Defense.HandshakeFailureBan.Enabled.Get(false) {
+		opts.HandshakeFailureBanThreshold = conf.Defense.HandshakeFailureBan.MaxFailures.Get(
+			mtglib.DefaultHandshakeFailureBanThreshold)
+		opts.HandshakeFailureBanWindow = conf.Defense.HandshakeFailureBan.Window.Get(0)
+		opts.HandshakeFailureBanDuration = conf.Defense.HandshakeFailureBan.Duration.Get(0)
+		opts.HandshakeFailureBanMaxIPs = conf.Defense.HandshakeFailureBan.MaxIPs.Get(0)
+	}
+
+	opts.ClientRateLimit, opts.ClientRateLimitBurst, opts.ClientRateLimitMaxIPs = makeClientRateLimit(conf)
+
+	bindTo := []string{conf.BindTo.Get("")}
+	proxyOpts := []mtglib.ProxyOpts{opts}
+
+	for i := range conf.Listeners {
+		bindTo = append(bindTo, conf.Listeners[i].BindTo.Get(""))
+		proxyOpts = append(proxyOpts, makeListenerOpts(opts, &conf.Listeners[i]))
 	}
 
+	listeners := make([]net.Listener, 0, len(bindTo))
+	proxies := make([]*mtglib.Proxy, 0, len(bindTo))
+
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+
+		shutdownTimeout := conf.ShutdownTimeout.Get(0)
+		wg := &sync.WaitGroup{}
+
+		for _, proxy := range proxies {
+			wg.Add(1)
+
+			go func(proxy *mtglib.Proxy) {
+				defer wg.Done()
+
+				proxy.ShutdownWithTimeout(shutdownTimeout)
+			}(proxy)
+		}
+
+		wg.Wait()
+	}()
+
+	for i := range bindTo {
+		proxy, err := mtglib.NewProxy(proxyOpts[i])
+		if err != nil {
+			return fmt.Errorf("cannot create a proxy for %s: %w", bindTo[i], err)
+		}
+
+		proxies = append(proxies, proxy)
+
+		proxyListeners, err := makeListeners(conf, logger.Named("listener"), bindTo[i])
+		listeners = append(listeners, proxyListeners...)
+
+		if err != nil {
+			return fmt.Errorf("cannot start proxy: %w", err)
+		}
+
+		for _, listener := range proxyListeners {
+			go proxy.Serve(listener) //nolint: errcheck
+		}
+	}
+
+	eventStream.Send(context.Background(), mtglib.NewEventSecretsConfigured(countSecrets(proxyOpts)))
+
+	// signals are handled only after a proxy has started: before that a
+	// default handler stops the process at once.
 	ctx := utils.RootContext()
 
-	go proxy.Serve(listener) //nolint: errcheck
+	go runLogLevelSwitch(ctx, logLevel, makeInfoLogger(conf, logWriter).Named("log-level"))
+
+	if len(configPaths) > 0 {
+		reloader := &reloader{
+			configPaths:   configPaths,
+			conf:          conf,
+			logger:        makeInfoLogger(conf, logWriter).Named("reload"),
+			logLevel:      logLevel,
+			network:       ntw,
+			baseNetwork:   baseNetwork,
+			blocklist:     reloadableBlocklist,
+			allowlist:     reloadableAllowlist,
+			proxies:       proxies,
+			proxyOpts:     proxyOpts,
+			eventStream:   eventStream,
+			dirSecrets:    dirSecrets,
+			makeNetwork:   buildNetwork,
+			makeBlocklist: buildBlocklist,
+			makeAllowlist: buildAllowlist,
+		}
+
+		go reloader.Run(ctx)
+	}
 
 	<-ctx.Done()
-	listener.Close()
-	proxy.Shutdown()
 
 	return nil
 }
+
+// makeClientRateLimit returns a rate, a burst and a max number of tracked
+// IPs of a client rate limiter. 0 rate means that limiter is disabled.
+func makeClientRateLimit(conf *config.Config) (uint, uint, uint) {
+	if !conf.Defense.ClientRateLimit.Enabled.Get(false) {
+		return 0, 0, 0
+	}
+
+	return conf.Defense.ClientRateLimit.ConnectionsPerSecond.Get(mtglib.DefaultClientRateLimit),
+		conf.Defense.ClientRateLimit.Burst.Get(0),
+		conf.Defense.ClientRateLimit.MaxIPs.Get(0)
+}
+
+// readSecretsDir reads additional secrets of the main proxy from
+// secrets-dir if it is set. Files with incorrect secrets are skipped with
+// a warning so a single broken file does not stop a proxy.
+func readSecretsDir(conf *config.Config, logger mtglib.Logger) ([]mtglib.Secret, error) {
+	path := conf.SecretsDir.Get("")
+	if path == "" {
+		return nil, nil
+	}
+
+	secrets, err := config.ReadSecretsDir(path, func(name string, err error) {
+		logger.BindStr("file", name).WarningError("secret file is skipped", err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secrets from %s: %w", path, err)
+	}
+
+	return secrets, nil
+}
+
+// countSecrets returns a number of distinct secrets of all proxies.
+// Listeners without own secrets share them with the main one, so they do
+// not add anything.
+func countSecrets(proxyOpts []mtglib.ProxyOpts) int {
+	secrets := map[mtglib.Secret]struct{}{}
+
+	for i := range proxyOpts {
+		if proxyOpts[i].Secret.Valid() {
+			secrets[proxyOpts[i].Secret] = struct{}{}
+		}
+
+		for _, v := range proxyOpts[i].Secrets {
+			secrets[v] = struct{}{}
+		}
+	}
+
+	return len(secrets)
+}
+
+// makeDCAddresses converts network.dc-addresses into pinned addresses of
+// [mtglib.ProxyOpts]. It returns nil if nothing is pinned, so built-in
+// addresses of Telegram DCs are used.
+func makeDCAddresses(conf *config.Config) map[int][]net.IP {
+	if len(conf.Network.DCAddresses) == 0 {
+		return nil
+	}
+
+	rv := make(map[int][]net.IP, len(conf.Network.DCAddresses))
+
+	for dc, addresses := range conf.Network.DCAddresses {
+		for _, v := range addresses {
+			rv[dc] = append(rv[dc], v.Get(nil))
+		}
+	}
+
+	return rv
+}
+
+// makeListeners binds accept-loops listeners to a given address. If there
+// are many of them, they share the address with SO_REUSEPORT. On platforms
+// without it, a single listener is used. Unix sockets always have a single
+// listener. Listeners which were created before an error are returned as
+// well so a caller can close them.
+func makeListeners(conf *config.Config, logger mtglib.Logger, bindTo string) ([]net.Listener, error) {
+	if strings.HasPrefix(bindTo, config.TypeBindToUnixPrefix) {
+		listener, err := utils.NewUnixListener(
+			strings.TrimPrefix(bindTo, config.TypeBindToUnixPrefix),
+			conf.UnixSocketMode.Get(utils.DefaultUnixSocketMode),
+			logger,
+			conf.PreferProxyProtocol.Get(false))
+		if err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		return []net.Listener{listener}, nil
+	}
+
+	loops := int(conf.AcceptLoops.Get(defaultAcceptLoops))
+	opts := utils.ListenerOpts{
+		Network:             makeListenNetwork(conf),
+		BindTo:              bindTo,
+		DSCP:                conf.Network.DSCP.Get(0),
+		BufferSize:          int(conf.Network.BufferSize.Get(0)),
+		SocketOptionsPolicy: conf.Network.SocketOptionsPolicy.Get(defaultSocketOptionsPolicy),
+		Logger:              logger,
+		ProxyProtocol:       conf.PreferProxyProtocol.Get(false),
+		ReusePort:           loops > 1,
+	}
+
+	listeners := make([]net.Listener, 0, loops)
+
+	for len(listeners) < loops {
+		listener, err := utils.NewListener(opts)
+
+		switch {
+		case errors.Is(err, utils.ErrReusePortUnsupported):
+			logger.Warning("SO_REUSEPORT is not supported, fallback to a single accept loop")
+
+			loops = 1
+			opts.ReusePort = false
+		case err != nil:
+			return listeners, err
+		default:
+			listeners = append(listeners, listener)
+		}
+	}
+
+	return listeners, nil
+}
+
+// makeListenerOpts applies overrides of an additional listener to options
+// of the main proxy. Network, IP lists, anti-replay cache and event stream
+// are shared by all listeners.
+func makeListenerOpts(opts mtglib.ProxyOpts, conf *config.ListenerConfig) mtglib.ProxyOpts {
+	if conf.Secret.Valid() {
+		opts.Secret = conf.Secret
+		opts.Secrets = nil
+	}
+
+	opts.DomainFrontingPort = conf.DomainFrontingPort.Get(opts.DomainFrontingPort)
+	opts.Concurrency = conf.Concurrency.Get(opts.Concurrency)
+	opts.AdmissionQueueSize = conf.AdmissionQueueSize.Get(opts.AdmissionQueueSize)
+	opts.Logger = opts.Logger.BindStr("bind-to", conf.BindTo.Get(""))
+
+	return opts
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// maxHostnameLength is a max length of DNS name without a trailing dot.
+const maxHostnameLength = 253
+
+// maxHostnameLabelLength is a max length of each label of DNS name.
+const maxHostnameLabelLength = 63
+
+// validateSecretHost checks that a hostname of a secret can be used for
+// fake-TLS: clients send it as SNI so it has to be a fully qualified DNS
+// name. IP addresses are not allowed in SNI.
+func validateSecretHost(host string) error {
+	if net.ParseIP(host) != nil {
+		return fmt.Errorf("%s is an IP address but fake-tls requires a hostname", host)
+	}
+
+	if len(host) > maxHostnameLength {
+		return fmt.Errorf("hostname is longer than %d characters", maxHostnameLength)
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 { //nolint: gomnd
+		return fmt.Errorf("%s is not a fully qualified domain name", host)
+	}
+
+	for _, label := range labels {
+		if err := validateSecretHostLabel(label); err != nil {
+			return fmt.Errorf("incorrect hostname %s: %w", host, err)
+		}
+	}
+
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return fmt.Errorf("incorrect hostname %s: top-level domain is numeric", host)
+	}
+
+	return nil
+}
+
+func validateSecretHostLabel(label string) error {
+	switch {
+	case label == "":
+		return fmt.Errorf("empty label")
+	case len(label) > maxHostnameLabelLength:
+		return fmt.Errorf("label %s is longer than %d characters", label, maxHostnameLabelLength)
+	case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+		return fmt.Errorf("label %s starts or ends with a hyphen", label)
+	}
+
+	for _, char := range label {
+		isAllowed := char == '-' ||
+			(char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9')
+		if !isAllowed {
+			return fmt.Errorf("label %s has a forbidden character %q", label, char)
+		}
+	}
+
+	return nil
+}
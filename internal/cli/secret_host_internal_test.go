@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidateSecretHostTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ValidateSecretHostTestSuite) TestOk() {
+	testData := []string{
+		"example.com",
+		"storage.googleapis.com",
+		"xn--e1afmkfd.xn--p1ai",
+		"a-b.example.com",
+		"EXAMPLE.com",
+		"1.example.com",
+		strings.Repeat("a", 63) + ".com",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		suite.T().Run(value, func(t *testing.T) {
+			assert.NoError(t, validateSecretHost(value))
+		})
+	}
+}
+
+func (suite *ValidateSecretHostTestSuite) TestFail() {
+	testData := map[string]string{
+		"ipv4":             "10.0.0.10",
+		"ipv6":             "2001:db8::1",
+		"single label":     "localhost",
+		"empty label":      "example..com",
+		"trailing dot":     "example.com.",
+		"leading hyphen":   "-example.com",
+		"trailing hyphen":  "example-.com",
+		"underscore":       "exa_mple.com",
+		"numeric tld":      "example.123",
+		"too long label":   strings.Repeat("a", 64) + ".com",
+		"too long name":    strings.Repeat("a.", 127) + "com",
+		"forbidden symbol": "exa mple.com",
+	}
+
+	for name, v := range testData {
+		value := v
+
+		suite.T().Run(name, func(t *testing.T) {
+			assert.Error(t, validateSecretHost(value))
+		})
+	}
+}
+
+func TestValidateSecretHost(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ValidateSecretHostTestSuite{})
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/IceCodeNew/mtg/antireplay"
+	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/IceCodeNew/mtg/profiling"
+	"github.com/IceCodeNew/mtg/stats"
+	"github.com/alecthomas/units"
+)
+
+type ShowConfig struct {
+	ConfigPaths []string `kong:"arg,required,type='existingfile',help='Paths to the configuration files. They are merged in order, later wins.',name='config-path'"` //nolint: lll
+	Format      string   `kong:"help='Output format: toml or json.',enum='toml,json',default='toml',short='f'"`                                                      //nolint: lll
+}
+
+func (s *ShowConfig) Run(cli *CLI, version string) error {
+	conf, err := utils.ReadConfig(s.ConfigPaths...)
+	if err != nil {
+		return fmt.Errorf("cannot init config: %w", err)
+	}
+
+	applyDefaults(conf)
+
+	var data []byte
+
+	if s.Format == "json" {
+		data, err = conf.RedactedJSON()
+	} else {
+		data, err = conf.RedactedTOML()
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot dump config: %w", err)
+	}
+
+	os.Stdout.Write(data) //nolint: errcheck
+
+	return nil
+}
+
+// applyDefaults fills options which are not set with the values used by
+// runProxy. Options without default value, or where zero means 'disabled'
+// or 'unlimited', are kept as is.
+func applyDefaults(conf *config.Config) { //nolint: funlen
+	conf.InstanceName.Value = getInstanceName(conf)
+	conf.UnixSocketMode.Value = conf.UnixSocketMode.Get(utils.DefaultUnixSocketMode)
+	conf.PreferIP.Value = conf.PreferIP.Get(mtglib.DefaultPreferIP)
+	conf.ClientPreferIP.Value = conf.ClientPreferIP.Get(defaultClientPreferIP)
+	conf.UnknownClientIPPolicy.Value = conf.UnknownClientIPPolicy.Get(mtglib.DefaultUnknownClientIPPolicy)
+	conf.DomainFrontingPort.Value = conf.DomainFrontingPort.Get(mtglib.DefaultDomainFrontingPort)
+	conf.Concurrency.Value = conf.Concurrency.Get(mtglib.DefaultConcurrency)
+	conf.AcceptLoops.Value = conf.AcceptLoops.Get(defaultAcceptLoops)
+	conf.AdmissionQueueTimeout.Value = conf.AdmissionQueueTimeout.Get(mtglib.DefaultAdmissionQueueTimeout)
+
+	defense := &conf.Defense
+	defense.AntiReplay.MaxSize.Value = units.Base2Bytes(
+		defense.AntiReplay.MaxSize.Get(antireplay.DefaultStableBloomFilterMaxSize))
+	defense.AntiReplay.ErrorRate.Value = defense.AntiReplay.ErrorRate.Get(antireplay.DefaultStableBloomFilterErrorRate)
+	defense.AntiReplay.Hash.Value = defense.AntiReplay.Hash.Get(antireplay.DefaultHash)
+	defense.AntiReplay.MaxAge.Value = defense.AntiReplay.MaxAge.Get(antireplay.DefaultPersistentMaxAge)
+	defense.AntiReplay.Redis.KeyPrefix.Value = defense.AntiReplay.Redis.KeyPrefix.Get(antireplay.DefaultRedisKeyPrefix)
+	defense.AntiReplay.Redis.TTL.Value = defense.AntiReplay.Redis.TTL.Get(antireplay.DefaultRedisTTL)
+	defense.IPListPrecedence.Value = defense.IPListPrecedence.Get(mtglib.DefaultIPListPrecedence)
+	defense.ProbeTarpit.Duration.Value = defense.ProbeTarpit.Duration.Get(mtglib.DefaultProbeTarpitDuration)
+	defense.ProbeTarpit.MaxConnections.Value = defense.ProbeTarpit.MaxConnections.Get(
+		mtglib.DefaultProbeTarpitMaxConnections)
+	defense.HandshakeFailureBan.MaxFailures.Value = defense.HandshakeFailureBan.MaxFailures.Get(
+		mtglib.DefaultHandshakeFailureBanThreshold)
+	defense.ClientRateLimit.ConnectionsPerSecond.Value = defense.ClientRateLimit.ConnectionsPerSecond.Get(
+		mtglib.DefaultClientRateLimit)
+	defense.OnMissingSNI.Value = defense.OnMissingSNI.Get(mtglib.DefaultMissingSNIPolicy)
+
+	for _, list := range []*config.ListConfig{&defense.Blocklist, &defense.Allowlist} {
+		list.DownloadConcurrency.Value = list.DownloadConcurrency.Get(defaultIPListDownloadConcurrency)
+		list.DownloadAttempts.Value = list.DownloadAttempts.Get(ipblocklist.DefaultFireholDownloadAttempts)
+		list.DownloadBackoff.Value = list.DownloadBackoff.Get(ipblocklist.DefaultFireholDownloadBackoff)
+		list.ChainMode.Value = list.ChainMode.Get(defaultIPListChainMode)
+		list.UpdateEach.Value = list.UpdateEach.Get(ipblocklist.DefaultFireholUpdateEach)
+	}
+
+	ntw := &conf.Network
+	ntw.Timeout.TCP.Value = ntw.Timeout.TCP.Get(network.DefaultTimeout)
+	ntw.Timeout.HTTP.Value = ntw.Timeout.HTTP.Get(network.DefaultHTTPTimeout)
+	ntw.Timeout.IdleSweepInterval.Value = ntw.Timeout.IdleSweepInterval.Get(mtglib.DefaultIdleSweepInterval)
+	ntw.DOHIP.Value = ntw.DOHIP.Get(defaultDOHIP)
+	ntw.SocketOptionsPolicy.Value = ntw.SocketOptionsPolicy.Get(defaultSocketOptionsPolicy)
+	ntw.DNSCache.Policy.Value = ntw.DNSCache.Policy.Get(defaultDNSCachePolicy)
+	ntw.DNSCache.Size.Value = ntw.DNSCache.Size.Get(network.DefaultDNSCacheSize)
+	ntw.DNSCache.MinTTL.Value = ntw.DNSCache.MinTTL.Get(network.DefaultDNSCacheMinTTL)
+	ntw.DNSCache.MaxTTL.Value = ntw.DNSCache.MaxTTL.Get(network.DefaultDNSCacheMaxTTL)
+	ntw.DNSCache.NegativeTTL.Value = ntw.DNSCache.NegativeTTL.Get(network.DefaultDNSCacheNegativeTTL)
+	ntw.Mirror.SampleRate.Value = ntw.Mirror.SampleRate.Get(network.DefaultMirrorSampleRate)
+	ntw.Resolver.Value = ntw.Resolver.Get(defaultDNSResolver)
+
+	if ntw.Resolver.Value == config.TypeDNSResolverDOT && ntw.DOTServer.Value == "" {
+		ntw.DOTServer.Set(network.DefaultDOTHostname) //nolint: errcheck
+	}
+
+	st := &conf.Stats
+	st.StatsD.MetricPrefix.Value = st.StatsD.MetricPrefix.Get(stats.DefaultStatsdMetricPrefix)
+	st.StatsD.TagFormat.Value = st.StatsD.TagFormat.Get(stats.DefaultStatsdTagFormat)
+	st.Prometheus.HTTPPath.Value = st.Prometheus.HTTPPath.Get(defaultPrometheusHTTPPath)
+	st.Prometheus.MetricPrefix.Value = st.Prometheus.MetricPrefix.Get(stats.DefaultMetricPrefix)
+	st.Prometheus.Textfile.Interval.Value = st.Prometheus.Textfile.Interval.Get(stats.DefaultPrometheusTextfileInterval)
+	st.Origin.MaxASNs.Value = st.Origin.MaxASNs.Get(stats.DefaultOriginMaxASNs)
+	st.UnixDatagram.QueueSize.Value = st.UnixDatagram.QueueSize.Get(events.DefaultUnixDatagramQueueSize)
+	st.SSE.QueueSize.Value = st.SSE.QueueSize.Get(events.DefaultSSEQueueSize)
+	st.RecentEvents.Size.Value = st.RecentEvents.Size.Get(events.DefaultRecentEventsSize)
+	st.NATS.Subject.Value = st.NATS.Subject.Get(events.DefaultNATSSubject)
+	st.NATS.QueueSize.Value = st.NATS.QueueSize.Get(events.DefaultNATSQueueSize)
+	st.JSON.MaxBackups.Value = st.JSON.MaxBackups.Get(events.DefaultJSONFileMaxBackups)
+	st.JSON.QueueSize.Value = st.JSON.QueueSize.Get(events.DefaultJSONQueueSize)
+	st.JSON.BatchSize.Value = st.JSON.BatchSize.Get(events.DefaultJSONBatchSize)
+	st.JSON.FlushInterval.Value = st.JSON.FlushInterval.Get(events.DefaultJSONFlushInterval)
+
+	conf.GeoIP.UpdateEach.Value = conf.GeoIP.UpdateEach.Get(geoip.DefaultUpdateEach)
+	conf.GeoIP.Download.UpdateEach.Value = conf.GeoIP.Download.UpdateEach.Get(geoip.DefaultUpdateEach)
+	conf.GeoIP.Download.Attempts.Value = conf.GeoIP.Download.Attempts.Get(geoip.DefaultDownloadAttempts)
+	conf.GeoIP.Download.Backoff.Value = conf.GeoIP.Download.Backoff.Get(geoip.DefaultDownloadBackoff)
+
+	conf.Log.Output.Value = conf.Log.Output.Get(defaultLogOutput)
+	conf.Log.Tag.Value = conf.Log.Tag.Get(logger.DefaultTag)
+	conf.Log.Dedup.Interval.Value = conf.Log.Dedup.Interval.Get(logger.DefaultDedupInterval)
+	conf.Log.Sampling.Interval.Value = conf.Log.Sampling.Interval.Get(logger.DefaultSamplingInterval)
+	conf.Log.Sampling.First.Value = conf.Log.Sampling.First.Get(logger.DefaultSamplingFirst)
+	conf.Log.Sampling.Thereafter.Value = conf.Log.Sampling.Thereafter.Get(logger.DefaultSamplingThereafter)
+	conf.Log.Access.Format.Value = conf.Log.Access.Format.Get(defaultAccessLogFormat)
+
+	conf.Profiling.AppName.Value = conf.Profiling.AppName.Get(profiling.DefaultAppName)
+	conf.Profiling.UploadEach.Value = conf.Profiling.UploadEach.Get(profiling.DefaultUploadEach)
+
+	for i := range conf.Listeners {
+		listener := &conf.Listeners[i]
+
+		listener.DomainFrontingPort.Value = listener.DomainFrontingPort.Get(conf.DomainFrontingPort.Value)
+		listener.Concurrency.Value = listener.Concurrency.Get(conf.Concurrency.Value)
+		listener.AdmissionQueueSize.Value = listener.AdmissionQueueSize.Get(conf.AdmissionQueueSize.Value)
+	}
+}
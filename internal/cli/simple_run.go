@@ -59,10 +59,6 @@ func (s *SimpleRun) Run(cli *CLI, version string) error { //nolint: cyclop,funle
 		return fmt.Errorf("incorrect timeout: %w", err)
 	}
 
-	if err := conf.Network.Timeout.Idle.Set(s.Timeout.String()); err != nil {
-		return fmt.Errorf("incorrect timeout: %w", err)
-	}
-
 	if err := conf.Defense.AntiReplay.MaxSize.Set(s.AntiReplayCacheSize); err != nil {
 		return fmt.Errorf("incorrect antireplay-cache-size: %w", err)
 	}
@@ -85,5 +81,5 @@ func (s *SimpleRun) Run(cli *CLI, version string) error { //nolint: cyclop,funle
 		return fmt.Errorf("invalid result configuration: %w", err)
 	}
 
-	return runProxy(conf, version)
+	return runProxy(conf, version, nil)
 }
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// testConfigListTimeout is a max time to wait for a single file of ip
+// list.
+const testConfigListTimeout = 5 * time.Minute
+
+type testConfigCheck struct {
+	name    string
+	details string
+	err     error
+}
+
+type TestConfig struct {
+	ConfigPaths []string `kong:"arg,required,type='existingfile',help='Paths to the configuration files. They are merged in order, later wins.',name='config-path'"` //nolint: lll
+}
+
+// Run loads a configuration, builds a network and checks that Telegram
+// DCs, fronting domains and files of ip lists are reachable. A proxy is
+// not started. If any check has failed, an error is returned so mtg
+// exits with non-zero code.
+func (t *TestConfig) Run(cli *CLI, version string) error {
+	conf, err := utils.ReadConfig(t.ConfigPaths...)
+	if err != nil {
+		return fmt.Errorf("cannot init config: %w", err)
+	}
+
+	geoDB, err := makeGeoIP(conf, nil)
+	if err != nil {
+		return fmt.Errorf("cannot init geoip: %w", err)
+	}
+
+	checks := []testConfigCheck{}
+
+	if geoDB != nil {
+		defer geoDB.Close()
+
+		checks = append(checks, testConfigCheck{
+			name: "geoip databases",
+			err:  geoDB.Reload(),
+		})
+	}
+
+	ntw, err := makeNetwork(conf, version, networkOpts{geoDB: geoDB})
+	if err != nil {
+		return fmt.Errorf("cannot init network: %w", err)
+	}
+
+	checks = append(checks, t.checkTelegram(conf, ntw)...)
+	checks = append(checks, t.checkDomainFronting(conf, ntw)...)
+	checks = append(checks, t.checkIPList("blocklist", conf.Defense.Blocklist, ntw)...)
+	checks = append(checks, t.checkIPList("allowlist", conf.Defense.Allowlist, ntw)...)
+
+	if failed := t.printReport(os.Stdout, checks); failed > 0 {
+		return fmt.Errorf("%d of %d checks have failed", failed, len(checks))
+	}
+
+	return nil
+}
+
+func (t *TestConfig) checkTelegram(conf *config.Config, ntw mtglib.Network) []testConfigCheck {
+	opts := mtglib.ProxyOpts{
+		Network:     ntw,
+		PreferIP:    conf.PreferIP.Get(mtglib.DefaultPreferIP),
+		DCAddresses: makeDCAddresses(conf),
+	}
+	checks := make([]testConfigCheck, mtglib.MaxDC)
+	wg := &sync.WaitGroup{}
+
+	wg.Add(len(checks))
+
+	for i := range checks {
+		go func(check *testConfigCheck, dc int) {
+			defer wg.Done()
+
+			check.name = "telegram dc " + strconv.Itoa(dc)
+
+			conn, err := mtglib.DialTelegram(context.Background(), opts, dc)
+			if err != nil {
+				check.err = err
+
+				return
+			}
+
+			check.details = conn.RemoteAddr().String()
+
+			conn.Close()
+		}(&checks[i], i+1)
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+func (t *TestConfig) checkDomainFronting(conf *config.Config, ntw mtglib.Network) []testConfigCheck {
+	addresses := []string{}
+	seen := map[string]bool{}
+	addAddress := func(secret mtglib.Secret, port uint) {
+		address := net.JoinHostPort(secret.Host, strconv.Itoa(int(port)))
+
+		if secret.Valid() && !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	defaultPort := conf.DomainFrontingPort.Get(mtglib.DefaultDomainFrontingPort)
+
+	addAddress(conf.Secret, defaultPort)
+
+	for _, v := range conf.Secrets {
+		addAddress(v, defaultPort)
+	}
+
+	for i := range conf.Listeners {
+		addAddress(conf.Listeners[i].Secret, conf.Listeners[i].DomainFrontingPort.Get(defaultPort))
+	}
+
+	checks := make([]testConfigCheck, len(addresses))
+	wg := &sync.WaitGroup{}
+
+	wg.Add(len(checks))
+
+	for i := range checks {
+		go func(check *testConfigCheck, address string) {
+			defer wg.Done()
+
+			check.name = "domain fronting " + address
+
+			conn, err := ntw.DialContext(context.Background(), "tcp", address)
+			if err != nil {
+				check.err = err
+
+				return
+			}
+
+			check.details = conn.RemoteAddr().String()
+
+			conn.Close()
+		}(&checks[i], addresses[i])
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+// checkIPList downloads and parses each file of enabled ip list one by
+// one in the same way as a proxy does. Files are not retried.
+func (t *TestConfig) checkIPList(name string, conf config.ListConfig, ntw mtglib.Network) []testConfigCheck {
+	if !conf.Enabled.Get(false) {
+		return nil
+	}
+
+	checks := make([]testConfigCheck, len(conf.URLs))
+	wg := &sync.WaitGroup{}
+
+	wg.Add(len(checks))
+
+	for i := range checks {
+		fileConf := conf
+		fileConf.URLs = conf.URLs[i : i+1]
+		fileConf.CIDRs = nil
+		fileConf.DownloadAttempts = config.TypeConcurrency{Value: 1}
+
+		go func(check *testConfigCheck, fileConf config.ListConfig) {
+			defer wg.Done()
+
+			check.name = name + " " + fileConf.URLs[0].String()
+			check.details, check.err = t.checkIPListFile(fileConf, ntw)
+		}(&checks[i], fileConf)
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+func (t *TestConfig) checkIPListFile(conf config.ListConfig, ntw mtglib.Network) (string, error) {
+	sizes := make(chan int, 1)
+	errs := make(chan error, 1)
+
+	firehol, err := makeFireholBlocklist(conf, logger.NewNoopLogger(), ntw,
+		func(_ context.Context, size int) {
+			select {
+			case sizes <- size:
+			default:
+			}
+		},
+		func(_ context.Context, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+		nil)
+	if err != nil {
+		return "", err
+	}
+
+	go firehol.Run(time.Hour)
+
+	defer firehol.Shutdown()
+
+	select {
+	case size := <-sizes:
+		// failure callback is executed before update callback
+		select {
+		case err := <-errs:
+			return "", err
+		default:
+		}
+
+		return strconv.Itoa(size) + " networks", nil
+	case <-time.After(testConfigListTimeout):
+		return "", fmt.Errorf("file was not read in %s", testConfigListTimeout)
+	}
+}
+
+// printReport writes a line per each check and returns a number of
+// failed ones.
+func (t *TestConfig) printReport(writer io.Writer, checks []testConfigCheck) int {
+	failed := 0
+
+	for _, v := range checks {
+		switch {
+		case v.err != nil:
+			failed++
+
+			fmt.Fprintf(writer, "FAIL  %s: %v\n", v.name, v.err)
+		case v.details != "":
+			fmt.Fprintf(writer, "OK    %s (%s)\n", v.name, v.details)
+		default:
+			fmt.Fprintf(writer, "OK    %s\n", v.name)
+		}
+	}
+
+	return failed
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/IceCodeNew/mtg/mtglib"
 )
@@ -15,38 +16,133 @@ type Optional struct {
 type ListConfig struct {
 	Optional
 
-	DownloadConcurrency TypeConcurrency    `json:"downloadConcurrency"`
-	URLs                []TypeBlocklistURI `json:"urls"`
-	UpdateEach          TypeDuration       `json:"updateEach"`
+	DownloadConcurrency TypeConcurrency     `json:"downloadConcurrency"`
+	DownloadAttempts    TypeConcurrency     `json:"downloadAttempts"`
+	DownloadBackoff     TypeDuration        `json:"downloadBackoff"`
+	URLs                []TypeBlocklistURI  `json:"urls"`
+	CIDRs               []TypeCIDR          `json:"cidrs"`
+	Countries           []TypeCountryCode   `json:"countries"`
+	ChainMode           TypeIPListChainMode `json:"chainMode"`
+	UpdateEach          TypeDuration        `json:"updateEach"`
+	ResolveViaDOH       TypeBool            `json:"resolveViaDoh"`
+}
+
+type ListenerConfig struct {
+	BindTo             TypeBindTo      `json:"bindTo"`
+	Secret             mtglib.Secret   `json:"secret"`
+	DomainFrontingPort TypePort        `json:"domainFrontingPort"`
+	Concurrency        TypeConcurrency `json:"concurrency"`
+	AdmissionQueueSize TypeConcurrency `json:"admissionQueueSize"`
+}
+
+type CountryEgressConfig struct {
+	Countries []TypeCountryCode `json:"countries"`
+	Proxies   []TypeProxyURL    `json:"proxies"`
 }
 
 type Config struct {
-	Debug                    TypeBool        `json:"debug"`
-	AllowFallbackOnUnknownDC TypeBool        `json:"allowFallbackOnUnknownDc"`
-	Secret                   mtglib.Secret   `json:"secret"`
-	BindTo                   TypeHostPort    `json:"bindTo"`
-	PreferIP                 TypePreferIP    `json:"preferIp"`
-	DomainFrontingPort       TypePort        `json:"domainFrontingPort"`
-	TolerateTimeSkewness     TypeDuration    `json:"tolerateTimeSkewness"`
-	Concurrency              TypeConcurrency `json:"concurrency"`
+	Debug                    TypeBool                  `json:"debug"`
+	InstanceName             TypeInstanceName          `json:"instanceName"`
+	AllowFallbackOnUnknownDC TypeBool                  `json:"allowFallbackOnUnknownDc"`
+	Secret                   mtglib.Secret             `json:"secret"`
+	SecretFile               TypeFilePath              `json:"secretFile"`
+	SecretsDir               TypeDirPath               `json:"secretsDir"`
+	BindTo                   TypeBindTo                `json:"bindTo"`
+	UnixSocketMode           TypeFileMode              `json:"unixSocketMode"`
+	PreferIP                 TypePreferIP              `json:"preferIp"`
+	ClientPreferIP           TypePreferIP              `json:"clientPreferIp"`
+	PreferProxyProtocol      TypeBool                  `json:"preferProxyProtocol"`
+	UnknownClientIPPolicy    TypeUnknownClientIPPolicy `json:"unknownClientIpPolicy"`
+	FallbackClientIP         TypeIP                    `json:"fallbackClientIp"`
+	DomainFrontingPort       TypePort                  `json:"domainFrontingPort"`
+	TolerateTimeSkewness     TypeDuration              `json:"tolerateTimeSkewness"`
+	Concurrency              TypeConcurrency           `json:"concurrency"`
+	AcceptLoops              TypeConcurrency           `json:"acceptLoops"`
+	AdmissionQueueSize       TypeConcurrency           `json:"admissionQueueSize"`
+	AdmissionQueueTimeout    TypeDuration              `json:"admissionQueueTimeout"`
+	FileDescriptorsSoftLimit TypeConcurrency           `json:"fileDescriptorsSoftLimit"`
+	MaxConnsPerDC            TypeConcurrency           `json:"maxConnsPerDc"`
+	ShutdownTimeout          TypeDuration              `json:"shutdownTimeout"`
 	Defense                  struct {
 		AntiReplay struct {
 			Optional
 
-			MaxSize   TypeBytes     `json:"maxSize"`
-			ErrorRate TypeErrorRate `json:"errorRate"`
+			MaxSize     TypeBytes          `json:"maxSize"`
+			ErrorRate   TypeErrorRate      `json:"errorRate"`
+			PerClientIP TypeBool           `json:"perClientIp"`
+			Hash        TypeAntiReplayHash `json:"hash"`
+			Path        TypeOutputFilePath `json:"path"`
+			MaxAge      TypeDuration       `json:"maxAge"`
+			Redis       struct {
+				Optional
+
+				Address   TypeHostPort       `json:"address"`
+				Password  TypeAccessToken    `json:"password"`
+				DB        TypeConcurrency    `json:"db"`
+				KeyPrefix TypeRedisKeyPrefix `json:"keyPrefix"`
+				TTL       TypeDuration       `json:"ttl"`
+			} `json:"redis"`
 		} `json:"antiReplay"`
-		Blocklist ListConfig `json:"blocklist"`
-		Allowlist ListConfig `json:"allowlist"`
+		Blocklist        ListConfig           `json:"blocklist"`
+		Allowlist        ListConfig           `json:"allowlist"`
+		IPListPrecedence TypeIPListPrecedence `json:"ipListPrecedence"`
+		ProbeTarpit      struct {
+			Optional
+
+			Duration       TypeDuration    `json:"duration"`
+			MaxConnections TypeConcurrency `json:"maxConnections"`
+		} `json:"probeTarpit"`
+		HandshakeFailureBan struct {
+			Optional
+
+			MaxFailures TypeConcurrency `json:"maxFailures"`
+			Window      TypeDuration    `json:"window"`
+			Duration    TypeDuration    `json:"duration"`
+			MaxIPs      TypeConcurrency `json:"maxIps"`
+		} `json:"handshakeFailureBan"`
+		ClientRateLimit struct {
+			Optional
+
+			ConnectionsPerSecond TypeConcurrency `json:"connectionsPerSecond"`
+			Burst                TypeConcurrency `json:"burst"`
+			MaxIPs               TypeConcurrency `json:"maxIps"`
+		} `json:"clientRateLimit"`
+		MaxHandshakeSize       TypeBytes            `json:"maxHandshakeSize"`
+		HandshakeJitter        TypeDuration         `json:"handshakeJitter"`
+		OnMissingSNI           TypeMissingSNIPolicy `json:"onMissingSni"`
+		MaxConcurrentDownloads TypeConcurrency      `json:"maxConcurrentDownloads"`
 	} `json:"defense"`
 	Network struct {
 		Timeout struct {
-			TCP  TypeDuration `json:"tcp"`
-			HTTP TypeDuration `json:"http"`
-			Idle TypeDuration `json:"idle"`
+			TCP               TypeDuration `json:"tcp"`
+			HTTP              TypeDuration `json:"http"`
+			Idle              TypeDuration `json:"idle"`
+			IdleSweepInterval TypeDuration `json:"idleSweepInterval"`
+			Relay             TypeDuration `json:"relay"`
 		} `json:"timeout"`
-		DOHIP   TypeIP         `json:"dohIp"`
-		Proxies []TypeProxyURL `json:"proxies"`
+		DOHIP               TypeIP                  `json:"dohIp"`
+		Proxies             []TypeProxyURL          `json:"proxies"`
+		DSCP                TypeDSCP                `json:"dscp"`
+		SocketOptionsPolicy TypeSocketOptionsPolicy `json:"socketOptionsPolicy"`
+		BufferSize          TypeBytes               `json:"bufferSize"`
+		DNSCache            struct {
+			Policy      TypeDNSCachePolicy `json:"policy"`
+			Size        TypeConcurrency    `json:"size"`
+			MinTTL      TypeDuration       `json:"minTtl"`
+			MaxTTL      TypeDuration       `json:"maxTtl"`
+			NegativeTTL TypeDuration       `json:"negativeTtl"`
+		} `json:"dnsCache"`
+		Mirror struct {
+			Optional
+
+			Proxy      TypeProxyURL   `json:"proxy"`
+			SampleRate TypeSampleRate `json:"sampleRate"`
+		} `json:"mirror"`
+		CountryEgress []CountryEgressConfig `json:"countryEgress"`
+		DOHURL        TypeDOHURL            `json:"dohUrl"`
+		Resolver      TypeDNSResolver       `json:"resolver"`
+		DOTServer     TypeDOTServer         `json:"dotServer"`
+		DCAddresses   map[int][]TypeIP      `json:"dcAddresses"`
 	} `json:"network"`
 	Stats struct {
 		StatsD struct {
@@ -62,8 +158,116 @@ type Config struct {
 			BindTo       TypeHostPort     `json:"bindTo"`
 			HTTPPath     TypeHTTPPath     `json:"httpPath"`
 			MetricPrefix TypeMetricPrefix `json:"metricPrefix"`
+			Textfile     struct {
+				Path     TypeOutputFilePath `json:"path"`
+				Interval TypeDuration       `json:"interval"`
+			} `json:"textfile"`
+			TLS struct {
+				Cert     TypeFilePath `json:"cert"`
+				Key      TypeFilePath `json:"key"`
+				ClientCA TypeFilePath `json:"clientCa"`
+			} `json:"tls"`
 		} `json:"prometheus"`
+		Origin struct {
+			Optional
+
+			Countries []TypeCountryCode `json:"countries"`
+			ASNs      []TypeASN         `json:"asns"`
+			MaxASNs   TypeConcurrency   `json:"maxAsns"`
+		} `json:"origin"`
+		UnixDatagram struct {
+			Optional
+
+			Path      TypeOutputFilePath `json:"path"`
+			QueueSize TypeConcurrency    `json:"queueSize"`
+		} `json:"unixDatagram"`
+		SSE struct {
+			Optional
+
+			HTTPPath  TypeHTTPPath    `json:"httpPath"`
+			Token     TypeAccessToken `json:"token"`
+			QueueSize TypeConcurrency `json:"queueSize"`
+		} `json:"sse"`
+		RecentEvents struct {
+			Optional
+
+			HTTPPath TypeHTTPPath    `json:"httpPath"`
+			Token    TypeAccessToken `json:"token"`
+			Size     TypeConcurrency `json:"size"`
+		} `json:"recentEvents"`
+		IPLists struct {
+			Optional
+
+			HTTPPath TypeHTTPPath `json:"httpPath"`
+		} `json:"ipLists"`
+		NATS struct {
+			Optional
+
+			Address   TypeHostPort    `json:"address"`
+			Subject   TypeNATSSubject `json:"subject"`
+			Token     TypeAccessToken `json:"token"`
+			QueueSize TypeConcurrency `json:"queueSize"`
+		} `json:"nats"`
+		JSON struct {
+			Optional
+
+			Path          TypeOutputFilePath `json:"path"`
+			MaxSize       TypeBytes          `json:"maxSize"`
+			MaxBackups    TypeConcurrency    `json:"maxBackups"`
+			WebhookURL    TypeHTTPURL        `json:"webhookUrl"`
+			QueueSize     TypeConcurrency    `json:"queueSize"`
+			BatchSize     TypeConcurrency    `json:"batchSize"`
+			FlushInterval TypeDuration       `json:"flushInterval"`
+		} `json:"json"`
 	} `json:"stats"`
+	GeoIP struct {
+		CountryDB  TypeOutputFilePath `json:"countryDb"`
+		ASNDB      TypeOutputFilePath `json:"asnDb"`
+		UpdateEach TypeDuration       `json:"updateEach"`
+		Download   struct {
+			Optional
+
+			CountryURL TypeHTTPURL     `json:"countryUrl"`
+			ASNURL     TypeHTTPURL     `json:"asnUrl"`
+			UpdateEach TypeDuration    `json:"updateEach"`
+			Attempts   TypeConcurrency `json:"attempts"`
+			Backoff    TypeDuration    `json:"backoff"`
+		} `json:"download"`
+	} `json:"geoip"`
+	Log struct {
+		Output TypeLogOutput    `json:"output"`
+		Tag    TypeInstanceName `json:"tag"`
+		Syslog struct {
+			URL TypeSyslogURL `json:"url"`
+		} `json:"syslog"`
+		Dedup struct {
+			Optional
+
+			Interval TypeDuration `json:"interval"`
+		} `json:"dedup"`
+		Sampling struct {
+			Optional
+
+			Interval   TypeDuration    `json:"interval"`
+			First      TypeConcurrency `json:"first"`
+			Thereafter TypeConcurrency `json:"thereafter"`
+		} `json:"sampling"`
+		Access struct {
+			Optional
+
+			Path   TypeOutputFilePath  `json:"path"`
+			Format TypeAccessLogFormat `json:"format"`
+		} `json:"access"`
+	} `json:"log"`
+	Profiling struct {
+		Optional
+
+		PyroscopeURL TypeHTTPURL      `json:"pyroscopeUrl"`
+		AppName      TypeInstanceName `json:"appName"`
+		UploadEach   TypeDuration     `json:"uploadEach"`
+	} `json:"profiling"`
+	Secrets   []mtglib.Secret  `json:"secrets"`
+	Listeners []ListenerConfig `json:"listeners"`
 }
 
 func (c *Config) Validate() error {
@@ -75,18 +279,324 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("incorrect bind-to parameter %s", c.BindTo.String())
 	}
 
+	if err := c.validateListeners(); err != nil {
+		return err
+	}
+
+	if c.Stats.Origin.Enabled.Get(false) && c.GeoIP.CountryDB.Get("") == "" && c.GeoIP.ASNDB.Get("") == "" {
+		return fmt.Errorf("traffic by origin requires at least one geoip database")
+	}
+
+	if c.Profiling.Enabled.Get(false) && c.Profiling.PyroscopeURL.Get("") == "" {
+		return fmt.Errorf("continuous profiling requires pyroscope-url")
+	}
+
+	if err := c.validatePrometheusTLS(); err != nil {
+		return err
+	}
+
+	if err := c.validateSSE(); err != nil {
+		return err
+	}
+
+	if err := c.validateRecentEvents(); err != nil {
+		return err
+	}
+
+	if err := c.validateIPListsStatus(); err != nil {
+		return err
+	}
+
+	if c.Stats.UnixDatagram.Enabled.Get(false) && c.Stats.UnixDatagram.Path.Get("") == "" {
+		return fmt.Errorf("unix datagram events require a path of socket")
+	}
+
+	if c.Stats.NATS.Enabled.Get(false) && c.Stats.NATS.Address.Get("") == "" {
+		return fmt.Errorf("nats events require an address of server")
+	}
+
+	if c.Stats.JSON.Enabled.Get(false) &&
+		(c.Stats.JSON.Path.Get("") == "") == (c.Stats.JSON.WebhookURL.Get("") == "") {
+		return fmt.Errorf("json events require either a path or a webhook-url")
+	}
+
+	if c.Network.BufferSize.Get(0) > math.MaxInt32 {
+		return fmt.Errorf("network buffer-size should be < 2gib")
+	}
+
+	if c.Network.Mirror.Enabled.Get(false) && c.Network.Mirror.Proxy.Get(nil) == nil {
+		return fmt.Errorf("traffic mirroring requires a proxy")
+	}
+
+	if err := c.validateCountryEgress(); err != nil {
+		return err
+	}
+
+	for dc, addresses := range c.Network.DCAddresses {
+		switch {
+		case dc < 1 || dc > mtglib.MaxDC:
+			return fmt.Errorf("unknown dc %d in dc-addresses", dc)
+		case len(addresses) == 0:
+			return fmt.Errorf("dc-addresses of dc %d are empty", dc)
+		}
+	}
+
+	for _, list := range []*ListConfig{&c.Defense.Blocklist, &c.Defense.Allowlist} {
+		if list.Enabled.Get(false) && len(list.Countries) > 0 && c.GeoIP.CountryDB.Get("") == "" {
+			return fmt.Errorf("ip lists by country require geoip country database")
+		}
+	}
+
+	if err := c.validateUnixSockets(); err != nil {
+		return err
+	}
+
+	if c.UnknownClientIPPolicy.Get("") == TypeUnknownClientIPPolicyFallback && c.FallbackClientIP.Get(nil) == nil {
+		return fmt.Errorf("fallback policy for unknown client ip requires fallback-client-ip")
+	}
+
+	if c.Defense.IPListPrecedence.Get("") == TypeIPListPrecedenceAllowWins && !c.Defense.Allowlist.Enabled.Get(false) {
+		return fmt.Errorf("allow-wins precedence of ip lists requires enabled allowlist")
+	}
+
+	if err := c.validateAntiReplayRedis(); err != nil {
+		return err
+	}
+
+	if err := c.validateGeoIPDownload(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateUnixSockets checks that clients of unix sockets can be served.
+// They have no IP addresses so either PROXY protocol has to bring them or
+// a policy for unknown IPs has to allow such clients.
+func (c *Config) validateUnixSockets() error {
+	if c.PreferProxyProtocol.Get(false) ||
+		c.UnknownClientIPPolicy.Get(TypeUnknownClientIPPolicyReject) != TypeUnknownClientIPPolicyReject {
+		return nil
+	}
+
+	if c.BindTo.UnixPath != "" {
+		return fmt.Errorf("unix socket bind-to requires either prefer-proxy-protocol or unknown-client-ip-policy")
+	}
+
+	for i := range c.Listeners {
+		if c.Listeners[i].BindTo.UnixPath != "" {
+			return fmt.Errorf(
+				"listener %d: unix socket bind-to requires either prefer-proxy-protocol or unknown-client-ip-policy", i)
+		}
+	}
+
 	return nil
 }
 
+func (c *Config) validateListeners() error {
+	bindTo := map[string]bool{
+		c.BindTo.Get(""): true,
+	}
+
+	for i := range c.Listeners {
+		listener := &c.Listeners[i]
+		addr := listener.BindTo.Get("")
+
+		if addr == "" {
+			return fmt.Errorf("listener %d has incorrect bind-to parameter", i)
+		}
+
+		if bindTo[addr] {
+			return fmt.Errorf("listener %d uses the same bind-to %s as another one", i, addr)
+		}
+
+		bindTo[addr] = true
+	}
+
+	return nil
+}
+
+func (c *Config) validateCountryEgress() error {
+	if len(c.Network.CountryEgress) == 0 {
+		return nil
+	}
+
+	if c.GeoIP.CountryDB.Get("") == "" {
+		return fmt.Errorf("country egress requires geoip country database")
+	}
+
+	countries := map[string]bool{}
+
+	for i := range c.Network.CountryEgress {
+		rule := &c.Network.CountryEgress[i]
+
+		if len(rule.Countries) == 0 {
+			return fmt.Errorf("country egress %d has no countries", i)
+		}
+
+		if len(rule.Proxies) == 0 {
+			return fmt.Errorf("country egress %d has no proxies", i)
+		}
+
+		for _, v := range rule.Countries {
+			country := v.Get("")
+
+			if countries[country] {
+				return fmt.Errorf("country egress %d uses country %s which is already set", i, country)
+			}
+
+			countries[country] = true
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validatePrometheusTLS() error {
+	tls := &c.Stats.Prometheus.TLS
+
+	switch {
+	case (tls.Cert.Get("") == "") != (tls.Key.Get("") == ""):
+		return fmt.Errorf("prometheus tls requires both cert and key")
+	case tls.ClientCA.Get("") != "" && tls.Cert.Get("") == "":
+		return fmt.Errorf("prometheus tls client-ca requires cert and key")
+	}
+
+	return nil
+}
+
+func (c *Config) validateAntiReplayRedis() error {
+	redis := &c.Defense.AntiReplay.Redis
+
+	if !redis.Enabled.Get(false) {
+		return nil
+	}
+
+	if redis.Address.Get("") == "" {
+		return fmt.Errorf("redis anti-replay cache requires an address")
+	}
+
+	if c.Defense.AntiReplay.Path.Get("") != "" {
+		return fmt.Errorf("redis anti-replay cache cannot be stored in a file")
+	}
+
+	return nil
+}
+
+func (c *Config) validateGeoIPDownload() error {
+	download := &c.GeoIP.Download
+
+	if !download.Enabled.Get(false) {
+		return nil
+	}
+
+	countryURL := download.CountryURL.Get("")
+	asnURL := download.ASNURL.Get("")
+
+	switch {
+	case countryURL == "" && asnURL == "":
+		return fmt.Errorf("geoip download requires at least one url")
+	case countryURL != "" && c.GeoIP.CountryDB.Get("") == "":
+		return fmt.Errorf("geoip download of country database requires country-db path")
+	case asnURL != "" && c.GeoIP.ASNDB.Get("") == "":
+		return fmt.Errorf("geoip download of asn database requires asn-db path")
+	}
+
+	return nil
+}
+
+func (c *Config) validateSSE() error {
+	if !c.Stats.SSE.Enabled.Get(false) {
+		return nil
+	}
+
+	return c.validatePrometheusEndpoint("sse", c.Stats.SSE.HTTPPath.Get(""))
+}
+
+func (c *Config) validateRecentEvents() error {
+	recentEvents := &c.Stats.RecentEvents
+
+	if !recentEvents.Enabled.Get(false) {
+		return nil
+	}
+
+	if err := c.validatePrometheusEndpoint("recent-events", recentEvents.HTTPPath.Get("")); err != nil {
+		return err
+	}
+
+	if c.Stats.SSE.Enabled.Get(false) && c.Stats.SSE.HTTPPath.Get("") == recentEvents.HTTPPath.Get("") {
+		return fmt.Errorf("recent-events and sse endpoints cannot have the same http-path")
+	}
+
+	return nil
+}
+
+func (c *Config) validateIPListsStatus() error {
+	ipLists := &c.Stats.IPLists
+
+	if !ipLists.Enabled.Get(false) {
+		return nil
+	}
+
+	httpPath := ipLists.HTTPPath.Get("")
+
+	if err := c.validatePrometheusEndpoint("ip-lists", httpPath); err != nil {
+		return err
+	}
+
+	if (c.Stats.SSE.Enabled.Get(false) && c.Stats.SSE.HTTPPath.Get("") == httpPath) ||
+		(c.Stats.RecentEvents.Enabled.Get(false) && c.Stats.RecentEvents.HTTPPath.Get("") == httpPath) {
+		return fmt.Errorf("ip-lists endpoint cannot have the same http-path as sse or recent-events")
+	}
+
+	return nil
+}
+
+// validatePrometheusEndpoint checks an additional endpoint which is served
+// by HTTP server of prometheus.
+func (c *Config) validatePrometheusEndpoint(name, httpPath string) error {
+	prometheus := &c.Stats.Prometheus
+
+	switch {
+	case !prometheus.Enabled.Get(false),
+		prometheus.BindTo.Get("") == "" && prometheus.Textfile.Path.Get("") != "":
+		return fmt.Errorf("%s endpoint requires http server of prometheus", name)
+	case httpPath == "":
+		return fmt.Errorf("%s endpoint requires http-path", name)
+	case httpPath == prometheus.HTTPPath.Get("/"):
+		return fmt.Errorf("%s endpoint and prometheus cannot have the same http-path", name)
+	}
+
+	return nil
+}
+
+// String returns a config in JSON. Values are redacted in the same way as
+// for [Config.RedactedJSON], so it is safe to log it.
 func (c *Config) String() string {
 	buf := &bytes.Buffer{}
 	encoder := json.NewEncoder(buf)
 
 	encoder.SetEscapeHTML(false)
 
-	if err := encoder.Encode(c); err != nil {
+	if err := encoder.Encode(c.redacted()); err != nil {
 		panic(err)
 	}
 
 	return buf.String()
 }
+
+// tree returns a decoded JSON of the config with all values as is.
+func (c *Config) tree() map[string]interface{} {
+	data, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+
+	tree := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &tree); err != nil {
+		panic(err)
+	}
+
+	return tree
+}
@@ -1,9 +1,11 @@
 package config_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/IceCodeNew/mtg/internal/config"
 	"github.com/stretchr/testify/suite"
@@ -35,6 +37,70 @@ func (suite *ConfigTestSuite) TestParseOnlySecret() {
 	suite.Error(err)
 }
 
+func (suite *ConfigTestSuite) TestParseMalformedConfig() {
+	_, err := config.Parse(suite.ReadConfig("malformed.toml"))
+	suite.Error(err)
+
+	parseErr := &config.ParseError{}
+	suite.True(errors.As(err, &parseErr))
+	suite.Len(parseErr.Errors, 3)
+
+	suite.Equal("bind-to", parseErr.Errors[0].Path)
+	suite.Equal(2, parseErr.Errors[0].Line)
+	suite.Equal("defense.anti-replay.max-size", parseErr.Errors[1].Path)
+	suite.Equal(6, parseErr.Errors[1].Line)
+	suite.Equal("network.timeout.tcp", parseErr.Errors[2].Path)
+	suite.Equal(9, parseErr.Errors[2].Line)
+
+	suite.Contains(err.Error(), "bind-to (line 2)")
+	suite.Contains(err.Error(), "defense.anti-replay.max-size (line 6)")
+	suite.Contains(err.Error(), "network.timeout.tcp (line 9)")
+}
+
+func (suite *ConfigTestSuite) TestParseMissingField() {
+	_, err := config.Parse(suite.ReadConfig("only_secret.toml"))
+
+	parseErr := &config.ParseError{}
+	suite.True(errors.As(err, &parseErr))
+	suite.Len(parseErr.Errors, 1)
+	suite.Equal("bind-to", parseErr.Errors[0].Path)
+	suite.Equal(0, parseErr.Errors[0].Line)
+}
+
+func (suite *ConfigTestSuite) TestParseMerged() {
+	conf, err := config.Parse(suite.ReadConfig("base.toml"), suite.ReadConfig("overlay.toml"))
+	suite.NoError(err)
+	suite.Equal("7oe1GqLy6TBc38CV3jx7q09nb29nbGUuY29t", conf.Secret.Base64())
+	suite.Equal("127.0.0.1:443", conf.BindTo.Get(""))
+	suite.EqualValues(100, conf.Concurrency.Get(0))
+	suite.True(conf.Defense.AntiReplay.Enabled.Get(false))
+	suite.EqualValues(2*1024*1024, conf.Defense.AntiReplay.MaxSize.Get(0))
+	suite.True(conf.Defense.Blocklist.Enabled.Get(false))
+	suite.Empty(conf.Defense.Blocklist.URLs)
+}
+
+func (suite *ConfigTestSuite) TestParseMergedErrorLine() {
+	overlay := []byte(`
+concurrency = 10
+
+[network.timeout]
+tcp = "5 years"
+`)
+
+	_, err := config.Parse(suite.ReadConfig("base.toml"), overlay)
+
+	parseErr := &config.ParseError{}
+	suite.True(errors.As(err, &parseErr))
+	suite.Len(parseErr.Errors, 1)
+	suite.Equal("network.timeout.tcp", parseErr.Errors[0].Path)
+	suite.Equal(5, parseErr.Errors[0].Line)
+}
+
+func (suite *ConfigTestSuite) TestParseMergedBroken() {
+	_, err := config.Parse(suite.ReadConfig("base.toml"), suite.ReadConfig("broken.toml"))
+	suite.ErrorContains(err, "#2")
+}
+
 func (suite *ConfigTestSuite) TestParseMinimalConfig() {
 	conf, err := config.Parse(suite.ReadConfig("minimal.toml"))
 	suite.NoError(err)
@@ -42,10 +108,590 @@ func (suite *ConfigTestSuite) TestParseMinimalConfig() {
 	suite.Equal("0.0.0.0:3128", conf.BindTo.String())
 }
 
+func (suite *ConfigTestSuite) TestParseSecrets() {
+	conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(`
+secrets = ["ee473ce5d4958eb5f968c87680a23854a0736f6d652e6578616d706c652e636f6d"]
+`))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.Len(conf.Secrets, 1)
+	suite.Equal("some.example.com", conf.Secrets[0].Host)
+
+	_, err = config.Parse(suite.ReadConfig("minimal.toml"), []byte(`secrets = ["xxx"]`))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseListeners() {
+	conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(`
+[[listeners]]
+bind-to = "0.0.0.0:3129"
+concurrency = 10
+
+[[listeners]]
+bind-to = "0.0.0.0:3130"
+secret = "ee367a189aee18fa31c190054efd4a8e9573746f726167652e676f6f676c65617069732e636f6d"
+domain-fronting-port = 8443
+`))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.Len(conf.Listeners, 2)
+
+	suite.Equal("0.0.0.0:3129", conf.Listeners[0].BindTo.Get(""))
+	suite.EqualValues(10, conf.Listeners[0].Concurrency.Get(0))
+	suite.False(conf.Listeners[0].Secret.Valid())
+
+	suite.Equal("0.0.0.0:3130", conf.Listeners[1].BindTo.Get(""))
+	suite.Equal("storage.googleapis.com", conf.Listeners[1].Secret.Host)
+	suite.EqualValues(8443, conf.Listeners[1].DomainFrontingPort.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestParseListenersErrorPath() {
+	_, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(`
+[[listeners]]
+bind-to = "0.0.0.0:3129"
+
+[[listeners]]
+concurrency = "x"
+`))
+
+	parseErr := &config.ParseError{}
+	suite.True(errors.As(err, &parseErr))
+	suite.Len(parseErr.Errors, 2)
+	suite.Equal("listeners.1.bind-to", parseErr.Errors[0].Path)
+	suite.Equal("listeners.1.concurrency", parseErr.Errors[1].Path)
+	suite.Equal(6, parseErr.Errors[1].Line)
+}
+
+func (suite *ConfigTestSuite) TestValidateListenersSameBindTo() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[[listeners]]\nbind-to = \"0.0.0.0:3128\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "bind-to")
+}
+
+func (suite *ConfigTestSuite) TestValidateUnixSocket() {
+	path := filepath.Join(suite.T().TempDir(), "mtg.sock")
+	testData := map[string]bool{
+		"":                                       false,
+		"prefer-proxy-protocol = true\n":         true,
+		"unknown-client-ip-policy = \"allow\"\n": true,
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(
+			suite.ReadConfig("minimal.toml"),
+			[]byte(k+"bind-to = \"unix:"+path+"\"\nunix-socket-mode = \"0600\"\n"))
+		suite.NoError(err)
+		suite.Equal("unix:"+path, conf.BindTo.Get(""))
+		suite.Equal(path, conf.BindTo.UnixPath)
+		suite.Equal(os.FileMode(0o600), conf.UnixSocketMode.Get(0))
+
+		if v {
+			suite.NoError(conf.Validate())
+		} else {
+			suite.ErrorContains(conf.Validate(), "unix socket")
+		}
+	}
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[[listeners]]\nbind-to = \"unix:"+path+"\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "listener 0")
+}
+
+func (suite *ConfigTestSuite) TestValidateOriginWithoutGeoIP() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.origin]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "geoip")
+}
+
+func (suite *ConfigTestSuite) TestValidateMirrorWithoutProxy() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network.mirror]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "mirroring")
+}
+
+func (suite *ConfigTestSuite) TestValidateFallbackClientIPPolicyWithoutIP() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("unknown-client-ip-policy = \"fallback\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "fallback-client-ip")
+}
+
+func (suite *ConfigTestSuite) TestValidateGeoIPDownloadWithoutPath() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[geoip.download]\nenabled = true\nasn-url = \"https://example.com/asn.mmdb\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "asn-db")
+}
+
+func (suite *ConfigTestSuite) TestValidateUnixDatagramWithoutPath() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.unix-datagram]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "unix datagram")
+}
+
+func (suite *ConfigTestSuite) TestValidateNATSWithoutAddress() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.nats]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "nats")
+}
+
+func (suite *ConfigTestSuite) TestValidateJSONEvents() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.json]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "json events")
+
+	conf, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.json]\nenabled = true\npath = \"/tmp/events.json\"\n"+
+			"webhook-url = \"https://example.com/events\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "json events")
+}
+
+func (suite *ConfigTestSuite) TestParseJSONEvents() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.json]\nenabled = true\nwebhook-url = \"https://example.com/events\"\n"+
+			"max-size = \"10mb\"\nbatch-size = 50\nflush-interval = \"5s\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	suite.Equal("https://example.com/events", conf.Stats.JSON.WebhookURL.Get(""))
+	suite.EqualValues(10*1024*1024, conf.Stats.JSON.MaxSize.Get(0))
+	suite.EqualValues(50, conf.Stats.JSON.BatchSize.Get(0))
+	suite.Equal(5*time.Second, conf.Stats.JSON.FlushInterval.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestValidateAntiReplayRedisWithoutAddress() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.anti-replay.redis]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "redis")
+}
+
+func (suite *ConfigTestSuite) TestParseAntiReplayRedis() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.anti-replay.redis]\nenabled = true\naddress = \"127.0.0.1:6379\"\n"+
+			"db = 2\nkey-prefix = \"mtg:\"\nttl = \"5m\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	redis := conf.Defense.AntiReplay.Redis
+
+	suite.Equal("127.0.0.1:6379", redis.Address.Get(""))
+	suite.EqualValues(2, redis.DB.Get(0))
+	suite.Equal("mtg:", redis.KeyPrefix.Get(""))
+	suite.Equal(5*time.Minute, redis.TTL.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestParseAntiReplayPath() {
+	path := filepath.Join(suite.T().TempDir(), "anti-replay.bin")
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.anti-replay]\npath = \""+path+"\"\nmax-age = \"30m\"\n"))
+	suite.Require().NoError(err)
+	suite.NoError(conf.Validate())
+	suite.Equal(path, conf.Defense.AntiReplay.Path.Get(""))
+	suite.Equal(30*time.Minute, conf.Defense.AntiReplay.MaxAge.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestValidateAntiReplayRedisWithPath() {
+	path := filepath.Join(suite.T().TempDir(), "anti-replay.bin")
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.anti-replay]\npath = \""+path+"\"\n"+
+			"[defense.anti-replay.redis]\nenabled = true\naddress = \"127.0.0.1:6379\"\n"))
+	suite.Require().NoError(err)
+	suite.ErrorContains(conf.Validate(), "file")
+}
+
+func (suite *ConfigTestSuite) TestParseBlocklistCIDRs() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.blocklist]\ncidrs = [\"1.2.3.0/24\", \"2001:db8::/32\", \"10.0.0.1\"]\n"))
+	suite.Require().NoError(err)
+
+	cidrs := []string{}
+
+	for _, v := range conf.Defense.Blocklist.CIDRs {
+		cidrs = append(cidrs, v.String())
+	}
+
+	suite.Equal([]string{"1.2.3.0/24", "2001:db8::/32", "10.0.0.1/32"}, cidrs)
+}
+
+func (suite *ConfigTestSuite) TestValidateBlocklistCountriesWithoutGeoIP() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.blocklist]\nenabled = true\ncountries = [\"ru\", \"CN\"]\n"))
+	suite.Require().NoError(err)
+	suite.ErrorContains(conf.Validate(), "geoip")
+
+	suite.Equal("RU", conf.Defense.Blocklist.Countries[0].Get(""))
+	suite.Equal("CN", conf.Defense.Blocklist.Countries[1].Get(""))
+}
+
+func (suite *ConfigTestSuite) TestParseBlocklistDownloadRetries() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.blocklist]\ndownload-attempts = 5\ndownload-backoff = \"2s\"\n"))
+	suite.Require().NoError(err)
+	suite.EqualValues(5, conf.Defense.Blocklist.DownloadAttempts.Get(0))
+	suite.Equal(2*time.Second, conf.Defense.Blocklist.DownloadBackoff.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestParseBlocklistChainMode() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.blocklist]\nchain-mode = \"ALL\"\n"))
+	suite.Require().NoError(err)
+	suite.Equal(config.TypeIPListChainModeAll, conf.Defense.Blocklist.ChainMode.Get(""))
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.allowlist]\nchain-mode = \"xor\"\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseDOTResolver() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network]\nresolver = \"dot\"\ndot-server = \"google\"\n"))
+	suite.Require().NoError(err)
+	suite.Equal(config.TypeDNSResolverDOT, conf.Network.Resolver.Get(config.TypeDNSResolverDOH))
+	suite.Equal("8.8.8.8:853", conf.Network.DOTServer.Get(""))
+	suite.Equal("dns.google", conf.Network.DOTServer.ServerName)
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network]\nresolver = \"doq\"\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseIdleSweepInterval() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network.timeout]\nidle = \"5m\"\nidle-sweep-interval = \"30s\"\n"))
+	suite.Require().NoError(err)
+	suite.Equal(5*time.Minute, conf.Network.Timeout.Idle.Get(0))
+	suite.Equal(30*time.Second, conf.Network.Timeout.IdleSweepInterval.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestParseDCAddresses() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network.dc-addresses]\n2 = [\"149.154.167.51\", \"2001:67c:4e8:f002::a\"]\n4 = [\"149.154.167.91\"]\n"))
+	suite.Require().NoError(err)
+	suite.Len(conf.Network.DCAddresses, 2)
+	suite.Len(conf.Network.DCAddresses[2], 2)
+	suite.Equal("2001:67c:4e8:f002::a", conf.Network.DCAddresses[2][1].String())
+	suite.Equal("149.154.167.91", conf.Network.DCAddresses[4][0].String())
+	suite.NoError(conf.Validate())
+}
+
+func (suite *ConfigTestSuite) TestParseIncorrectDCAddresses() {
+	testData := map[string]string{
+		"ip":     "1 = [\"149.154.175.500\"]",
+		"dc":     "dc1 = [\"149.154.175.50\"]",
+		"type":   "1 = \"149.154.175.50\"",
+		"domain": "1 = [\"example.com\"]",
+	}
+
+	for k, v := range testData {
+		_, err := config.Parse(
+			suite.ReadConfig("minimal.toml"),
+			[]byte("[network.dc-addresses]\n"+v+"\n"))
+		suite.Error(err, k)
+	}
+}
+
+func (suite *ConfigTestSuite) TestValidateDCAddresses() {
+	testData := map[string]string{
+		"unknown dc": "6 = [\"149.154.175.50\"]",
+		"zero dc":    "0 = [\"149.154.175.50\"]",
+		"empty":      "1 = []",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(
+			suite.ReadConfig("minimal.toml"),
+			[]byte("[network.dc-addresses]\n"+v+"\n"))
+		suite.Require().NoError(err, k)
+		suite.Error(conf.Validate(), k)
+	}
+}
+
+func (suite *ConfigTestSuite) TestParseAllowlistIncorrectCIDR() {
+	_, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.allowlist]\ncidrs = [\"1.2.3.0/24\", \"1.2.3.0/33\"]\n"))
+	suite.ErrorContains(err, "1.2.3.0/33")
+}
+
+func (suite *ConfigTestSuite) TestParsePreferProxyProtocol() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("prefer-proxy-protocol = true\n"))
+	suite.NoError(err)
+	suite.True(conf.PreferProxyProtocol.Get(false))
+}
+
+func (suite *ConfigTestSuite) TestValidatePrometheusTLS() {
+	path := filepath.Join("testdata", "minimal.toml")
+	testData := map[string]string{
+		"cert = \"" + path + "\"\n":                         "both cert and key",
+		"key = \"" + path + "\"\n":                          "both cert and key",
+		"client-ca = \"" + path + "\"\n":                    "client-ca",
+		"cert = \"" + path + "\"\nkey = \"" + path + "\"\n": "",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(
+			suite.ReadConfig("minimal.toml"),
+			[]byte("[stats.prometheus.tls]\n"+k))
+		suite.NoError(err)
+
+		if v == "" {
+			suite.NoError(conf.Validate())
+		} else {
+			suite.ErrorContains(conf.Validate(), v)
+		}
+	}
+}
+
+func (suite *ConfigTestSuite) TestParseClientRateLimit() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense.client-rate-limit]\nenabled = true\nconnections-per-second = 5\nburst = 20\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	limit := conf.Defense.ClientRateLimit
+
+	suite.True(limit.Enabled.Get(false))
+	suite.EqualValues(5, limit.ConnectionsPerSecond.Get(0))
+	suite.EqualValues(20, limit.Burst.Get(0))
+	suite.EqualValues(100, limit.MaxIPs.Get(100))
+}
+
+func (suite *ConfigTestSuite) TestParseAcceptLoops() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("accept-loops = 4\n"))
+	suite.NoError(err)
+	suite.EqualValues(4, conf.AcceptLoops.Get(1))
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("accept-loops = 100000\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseNetworkBufferSize() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network]\nbuffer-size = \"256kib\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.EqualValues(256*1024, conf.Network.BufferSize.Get(0))
+
+	conf, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[network]\nbuffer-size = \"4gib\"\n"))
+	suite.NoError(err)
+	suite.Error(conf.Validate())
+}
+
+func (suite *ConfigTestSuite) TestParseAccessLog() {
+	path := filepath.Join(suite.T().TempDir(), "access.log")
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log.access]\nenabled = true\npath = \""+path+"\"\nformat = \"LOGFMT\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	suite.True(conf.Log.Access.Enabled.Get(false))
+	suite.Equal(path, conf.Log.Access.Path.Get(""))
+	suite.Equal(config.TypeAccessLogFormatLogfmt, conf.Log.Access.Format.Get(""))
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log.access]\nformat = \"xml\"\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseLogOutput() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log]\noutput = \"syslog\"\ntag = \"mtg-1\"\n[log.syslog]\nurl = \"udp://127.0.0.1:514\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	suite.Equal(config.TypeLogOutputSyslog, conf.Log.Output.Get(config.TypeLogOutputStdout))
+	suite.Equal("mtg-1", conf.Log.Tag.Get(""))
+	suite.Equal("udp://127.0.0.1:514", conf.Log.Syslog.URL.Get(""))
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log]\noutput = \"file\"\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestParseLogSampling() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log.sampling]\nenabled = true\ninterval = \"5s\"\nfirst = 3\nthereafter = 50\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+
+	suite.True(conf.Log.Sampling.Enabled.Get(false))
+	suite.Equal(5*time.Second, conf.Log.Sampling.Interval.Get(0))
+	suite.EqualValues(3, conf.Log.Sampling.First.Get(0))
+	suite.EqualValues(50, conf.Log.Sampling.Thereafter.Get(0))
+
+	_, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[log.sampling]\nfirst = -1\n"))
+	suite.Error(err)
+}
+
+func (suite *ConfigTestSuite) TestValidateProfilingWithoutURL() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[profiling]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "pyroscope-url")
+}
+
+func (suite *ConfigTestSuite) TestValidateAllowWinsWithoutAllowlist() {
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense]\nip-list-precedence = \"allow-wins\"\n"))
+	suite.NoError(err)
+	suite.ErrorContains(conf.Validate(), "allowlist")
+
+	conf, err = config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[defense]\nip-list-precedence = \"allow-wins\"\n[defense.allowlist]\nenabled = true\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+}
+
+func (suite *ConfigTestSuite) TestValidateSSE() {
+	testData := map[string]string{
+		"no prometheus": "[stats.prometheus]\nenabled = false\n[stats.sse]\nenabled = true\nhttp-path = \"/events\"\n",
+		"no path":       "[stats.prometheus]\nenabled = true\n[stats.sse]\nenabled = true\n",
+		"same path":     "[stats.prometheus]\nenabled = true\nhttp-path = \"/events\"\n[stats.sse]\nenabled = true\nhttp-path = \"/events\"\n",
+		"only textfile": "[stats.prometheus]\nenabled = true\n[stats.prometheus.textfile]\npath = \"mtg.prom\"\n[stats.sse]\nenabled = true\nhttp-path = \"/events\"\n",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(v))
+		suite.NoError(err, k)
+		suite.ErrorContains(conf.Validate(), "sse", k)
+	}
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.prometheus]\nenabled = true\n[stats.sse]\nenabled = true\nhttp-path = \"/events\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+}
+
+func (suite *ConfigTestSuite) TestValidateRecentEvents() {
+	testData := map[string]string{
+		"no prometheus": "[stats.prometheus]\nenabled = false\n[stats.recent-events]\nenabled = true\nhttp-path = \"/recent-events\"\n",
+		"no path":       "[stats.prometheus]\nenabled = true\n[stats.recent-events]\nenabled = true\n",
+		"same path":     "[stats.prometheus]\nenabled = true\nhttp-path = \"/recent-events\"\n[stats.recent-events]\nenabled = true\nhttp-path = \"/recent-events\"\n",
+		"same as sse":   "[stats.prometheus]\nenabled = true\n[stats.sse]\nenabled = true\nhttp-path = \"/events\"\n[stats.recent-events]\nenabled = true\nhttp-path = \"/events\"\n",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(v))
+		suite.NoError(err, k)
+		suite.ErrorContains(conf.Validate(), "recent-events", k)
+	}
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.prometheus]\nenabled = true\n[stats.recent-events]\nenabled = true\nhttp-path = \"/recent-events\"\nsize = 10\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.EqualValues(10, conf.Stats.RecentEvents.Size.Get(0))
+}
+
+func (suite *ConfigTestSuite) TestValidateIPListsStatus() {
+	testData := map[string]string{
+		"no prometheus":         "[stats.prometheus]\nenabled = false\n[stats.ip-lists]\nenabled = true\nhttp-path = \"/ip-lists\"\n",
+		"no path":               "[stats.prometheus]\nenabled = true\n[stats.ip-lists]\nenabled = true\n",
+		"same as recent-events": "[stats.prometheus]\nenabled = true\n[stats.recent-events]\nenabled = true\nhttp-path = \"/lists\"\n[stats.ip-lists]\nenabled = true\nhttp-path = \"/lists\"\n",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(v))
+		suite.NoError(err, k)
+		suite.ErrorContains(conf.Validate(), "ip-lists", k)
+	}
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte("[stats.prometheus]\nenabled = true\n[stats.ip-lists]\nenabled = true\nhttp-path = \"/ip-lists\"\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.Equal("/ip-lists", conf.Stats.IPLists.HTTPPath.Get(""))
+}
+
+func (suite *ConfigTestSuite) TestValidateCountryEgress() {
+	geoIP := "[geoip]\ncountry-db = \"country.mmdb\"\n"
+	testData := map[string]string{
+		"geoip":        "[[network.country-egress]]\ncountries = [\"de\"]\nproxies = [\"socks5://127.0.0.1:1080\"]\n",
+		"no countries": geoIP + "[[network.country-egress]]\nproxies = [\"socks5://127.0.0.1:1080\"]\n",
+		"no proxies":   geoIP + "[[network.country-egress]]\ncountries = [\"de\"]\n",
+		"already set":  geoIP + "[[network.country-egress]]\ncountries = [\"de\"]\nproxies = [\"socks5://127.0.0.1:1080\"]\n[[network.country-egress]]\ncountries = [\"DE\"]\nproxies = [\"socks5://127.0.0.1:1081\"]\n",
+	}
+
+	for k, v := range testData {
+		conf, err := config.Parse(suite.ReadConfig("minimal.toml"), []byte(v))
+		suite.NoError(err, k)
+		suite.ErrorContains(conf.Validate(), k, k)
+	}
+
+	conf, err := config.Parse(
+		suite.ReadConfig("minimal.toml"),
+		[]byte(geoIP+"[[network.country-egress]]\ncountries = [\"de\", \"fr\"]\nproxies = [\"socks5://127.0.0.1:1080\"]\n"))
+	suite.NoError(err)
+	suite.NoError(conf.Validate())
+	suite.Len(conf.Network.CountryEgress, 1)
+	suite.Equal("FR", conf.Network.CountryEgress[0].Countries[1].Get(""))
+	suite.Equal("127.0.0.1:1080", conf.Network.CountryEgress[0].Proxies[0].Get(nil).Host)
+}
+
 func (suite *ConfigTestSuite) TestString() {
 	conf, err := config.Parse(suite.ReadConfig("minimal.toml"))
 	suite.NoError(err)
 	suite.NotEmpty(conf.String())
+	suite.NotContains(conf.String(), conf.Secret.Base64())
 }
 
 func TestConfig(t *testing.T) {
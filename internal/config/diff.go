@@ -0,0 +1,90 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// Diff returns sorted paths of options which differ between configs.
+// Paths are written as in a config file, like 'defense.blocklist.urls'.
+// Arrays of tables, like listeners, are compared as a whole.
+func (c *Config) Diff(other *Config) []string {
+	left := c.flatten()
+	right := other.flatten()
+	rv := []string{}
+
+	for k, v := range left {
+		if value, ok := right[k]; !ok || !reflect.DeepEqual(v, value) {
+			rv = append(rv, k)
+		}
+	}
+
+	for k := range right {
+		if _, ok := left[k]; !ok {
+			rv = append(rv, k)
+		}
+	}
+
+	sort.Strings(rv)
+
+	return rv
+}
+
+func (c *Config) flatten() map[string]interface{} {
+	data, err := encodeTOML(c.tree())
+	if err != nil {
+		panic(err)
+	}
+
+	parsed, err := toml.LoadBytes(data)
+	if err != nil {
+		panic(err)
+	}
+
+	rv := map[string]interface{}{}
+
+	flattenTree(parsed, "", rv)
+
+	return rv
+}
+
+func flattenTree(tree *toml.Tree, prefix string, rv map[string]interface{}) {
+	for _, key := range tree.Keys() {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		switch value := tree.GetPath([]string{key}).(type) {
+		case *toml.Tree:
+			flattenTree(value, path, rv)
+		case []*toml.Tree:
+			tables := make([]map[string]interface{}, 0, len(value))
+
+			for _, v := range value {
+				tables = append(tables, v.ToMap())
+			}
+
+			rv[path] = tables
+		default:
+			rv[path] = value
+		}
+	}
+}
+
+// HasChanges checks if any of paths returned by [Config.Diff] is one of
+// given options or is nested into them.
+func HasChanges(changes []string, options ...string) bool {
+	for _, change := range changes {
+		for _, option := range options {
+			if change == option || strings.HasPrefix(change, option+".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -1,49 +1,137 @@
 package config
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/pelletier/go-toml"
 )
 
 type tomlConfig struct {
 	Debug                    bool   `toml:"debug" json:"debug,omitempty"`
+	InstanceName             string `toml:"instance-name" json:"instanceName,omitempty"`
 	AllowFallbackOnUnknownDC bool   `toml:"allow-fallback-on-unknown-dc" json:"allowFallbackOnUnknownDc,omitempty"`
-	Secret                   string `toml:"secret" json:"secret"`
+	Secret                   string `toml:"secret" json:"secret,omitempty"`
+	SecretFile               string `toml:"secret-file" json:"secretFile,omitempty"`
+	SecretsDir               string `toml:"secrets-dir" json:"secretsDir,omitempty"`
 	BindTo                   string `toml:"bind-to" json:"bindTo"`
+	UnixSocketMode           string `toml:"unix-socket-mode" json:"unixSocketMode,omitempty"`
 	PreferIP                 string `toml:"prefer-ip" json:"preferIp,omitempty"`
+	ClientPreferIP           string `toml:"client-prefer-ip" json:"clientPreferIp,omitempty"`
+	PreferProxyProtocol      bool   `toml:"prefer-proxy-protocol" json:"preferProxyProtocol,omitempty"`
+	UnknownClientIPPolicy    string `toml:"unknown-client-ip-policy" json:"unknownClientIpPolicy,omitempty"`
+	FallbackClientIP         string `toml:"fallback-client-ip" json:"fallbackClientIp,omitempty"`
 	DomainFrontingPort       uint   `toml:"domain-fronting-port" json:"domainFrontingPort,omitempty"`
 	TolerateTimeSkewness     string `toml:"tolerate-time-skewness" json:"tolerateTimeSkewness,omitempty"`
 	Concurrency              uint   `toml:"concurrency" json:"concurrency,omitempty"`
+	AcceptLoops              uint   `toml:"accept-loops" json:"acceptLoops,omitempty"`
+	AdmissionQueueSize       uint   `toml:"admission-queue-size" json:"admissionQueueSize,omitempty"`
+	AdmissionQueueTimeout    string `toml:"admission-queue-timeout" json:"admissionQueueTimeout,omitempty"`
+	FileDescriptorsSoftLimit uint   `toml:"file-descriptors-soft-limit" json:"fileDescriptorsSoftLimit,omitempty"`
+	MaxConnsPerDC            uint   `toml:"max-conns-per-dc" json:"maxConnsPerDc,omitempty"`
+	ShutdownTimeout          string `toml:"shutdown-timeout" json:"shutdownTimeout,omitempty"`
 	Defense                  struct {
 		AntiReplay struct {
-			Enabled   bool    `toml:"enabled" json:"enabled,omitempty"`
-			MaxSize   string  `toml:"max-size" json:"maxSize,omitempty"`
-			ErrorRate float64 `toml:"error-rate" json:"errorRate,omitempty"`
+			Enabled     bool    `toml:"enabled" json:"enabled,omitempty"`
+			MaxSize     string  `toml:"max-size" json:"maxSize,omitempty"`
+			ErrorRate   float64 `toml:"error-rate" json:"errorRate,omitempty"`
+			PerClientIP bool    `toml:"per-client-ip" json:"perClientIp,omitempty"`
+			Hash        string  `toml:"hash" json:"hash,omitempty"`
+			Path        string  `toml:"path" json:"path,omitempty"`
+			MaxAge      string  `toml:"max-age" json:"maxAge,omitempty"`
+			Redis       struct {
+				Enabled   bool   `toml:"enabled" json:"enabled,omitempty"`
+				Address   string `toml:"address" json:"address,omitempty"`
+				Password  string `toml:"password" json:"password,omitempty"`
+				DB        uint   `toml:"db" json:"db,omitempty"`
+				KeyPrefix string `toml:"key-prefix" json:"keyPrefix,omitempty"`
+				TTL       string `toml:"ttl" json:"ttl,omitempty"`
+			} `toml:"redis" json:"redis,omitempty"`
 		} `toml:"anti-replay" json:"antiReplay,omitempty"`
 		Blocklist struct {
 			Enabled             bool     `toml:"enabled" json:"enabled,omitempty"`
 			DownloadConcurrency uint     `toml:"download-concurrency" json:"downloadConcurrency,omitempty"`
+			DownloadAttempts    uint     `toml:"download-attempts" json:"downloadAttempts,omitempty"`
+			DownloadBackoff     string   `toml:"download-backoff" json:"downloadBackoff,omitempty"`
 			URLs                []string `toml:"urls" json:"urls,omitempty"`
+			CIDRs               []string `toml:"cidrs" json:"cidrs,omitempty"`
+			Countries           []string `toml:"countries" json:"countries,omitempty"`
+			ChainMode           string   `toml:"chain-mode" json:"chainMode,omitempty"`
 			UpdateEach          string   `toml:"update-each" json:"updateEach,omitempty"`
+			ResolveViaDOH       bool     `toml:"resolve-via-doh" json:"resolveViaDoh,omitempty"`
 		} `toml:"blocklist" json:"blocklist,omitempty"`
 		Allowlist struct {
 			Enabled             bool     `toml:"enabled" json:"enabled,omitempty"`
 			DownloadConcurrency uint     `toml:"download-concurrency" json:"downloadConcurrency,omitempty"`
+			DownloadAttempts    uint     `toml:"download-attempts" json:"downloadAttempts,omitempty"`
+			DownloadBackoff     string   `toml:"download-backoff" json:"downloadBackoff,omitempty"`
 			URLs                []string `toml:"urls" json:"urls,omitempty"`
+			CIDRs               []string `toml:"cidrs" json:"cidrs,omitempty"`
+			Countries           []string `toml:"countries" json:"countries,omitempty"`
+			ChainMode           string   `toml:"chain-mode" json:"chainMode,omitempty"`
 			UpdateEach          string   `toml:"update-each" json:"updateEach,omitempty"`
+			ResolveViaDOH       bool     `toml:"resolve-via-doh" json:"resolveViaDoh,omitempty"`
 		} `toml:"allowlist" json:"allowlist,omitempty"`
+		IPListPrecedence string `toml:"ip-list-precedence" json:"ipListPrecedence,omitempty"`
+		ProbeTarpit      struct {
+			Enabled        bool   `toml:"enabled" json:"enabled,omitempty"`
+			Duration       string `toml:"duration" json:"duration,omitempty"`
+			MaxConnections uint   `toml:"max-connections" json:"maxConnections,omitempty"`
+		} `toml:"probe-tarpit" json:"probeTarpit,omitempty"`
+		HandshakeFailureBan struct {
+			Enabled     bool   `toml:"enabled" json:"enabled,omitempty"`
+			MaxFailures uint   `toml:"max-failures" json:"maxFailures,omitempty"`
+			Window      string `toml:"window" json:"window,omitempty"`
+			Duration    string `toml:"duration" json:"duration,omitempty"`
+			MaxIPs      uint   `toml:"max-ips" json:"maxIps,omitempty"`
+		} `toml:"handshake-failure-ban" json:"handshakeFailureBan,omitempty"`
+		ClientRateLimit struct {
+			Enabled              bool `toml:"enabled" json:"enabled,omitempty"`
+			ConnectionsPerSecond uint `toml:"connections-per-second" json:"connectionsPerSecond,omitempty"`
+			Burst                uint `toml:"burst" json:"burst,omitempty"`
+			MaxIPs               uint `toml:"max-ips" json:"maxIps,omitempty"`
+		} `toml:"client-rate-limit" json:"clientRateLimit,omitempty"`
+		MaxHandshakeSize       string `toml:"max-handshake-size" json:"maxHandshakeSize,omitempty"`
+		HandshakeJitter        string `toml:"handshake-jitter" json:"handshakeJitter,omitempty"`
+		OnMissingSNI           string `toml:"on-missing-sni" json:"onMissingSni,omitempty"`
+		MaxConcurrentDownloads uint   `toml:"max-concurrent-downloads" json:"maxConcurrentDownloads,omitempty"`
 	} `toml:"defense" json:"defense,omitempty"`
 	Network struct {
 		Timeout struct {
-			TCP  string `toml:"tcp" json:"tcp,omitempty"`
-			HTTP string `toml:"http" json:"http,omitempty"`
-			Idle string `toml:"idle" json:"idle,omitempty"`
+			TCP               string `toml:"tcp" json:"tcp,omitempty"`
+			HTTP              string `toml:"http" json:"http,omitempty"`
+			Idle              string `toml:"idle" json:"idle,omitempty"`
+			IdleSweepInterval string `toml:"idle-sweep-interval" json:"idleSweepInterval,omitempty"`
+			Relay             string `toml:"relay" json:"relay,omitempty"`
 		} `toml:"timeout" json:"timeout,omitempty"`
-		DOHIP   string   `toml:"doh-ip" json:"dohIp,omitempty"`
-		Proxies []string `toml:"proxies" json:"proxies,omitempty"`
+		DOHIP               string   `toml:"doh-ip" json:"dohIp,omitempty"`
+		Proxies             []string `toml:"proxies" json:"proxies,omitempty"`
+		DSCP                uint     `toml:"dscp" json:"dscp,omitempty"`
+		SocketOptionsPolicy string   `toml:"socket-options-policy" json:"socketOptionsPolicy,omitempty"`
+		BufferSize          string   `toml:"buffer-size" json:"bufferSize,omitempty"`
+		DNSCache            struct {
+			Policy      string `toml:"policy" json:"policy,omitempty"`
+			Size        uint   `toml:"size" json:"size,omitempty"`
+			MinTTL      string `toml:"min-ttl" json:"minTtl,omitempty"`
+			MaxTTL      string `toml:"max-ttl" json:"maxTtl,omitempty"`
+			NegativeTTL string `toml:"negative-ttl" json:"negativeTtl,omitempty"`
+		} `toml:"dns-cache" json:"dnsCache,omitempty"`
+		Mirror struct {
+			Enabled    bool    `toml:"enabled" json:"enabled,omitempty"`
+			Proxy      string  `toml:"proxy" json:"proxy,omitempty"`
+			SampleRate float64 `toml:"sample-rate" json:"sampleRate,omitempty"`
+		} `toml:"mirror" json:"mirror,omitempty"`
+		CountryEgress []struct {
+			Countries []string `toml:"countries" json:"countries,omitempty"`
+			Proxies   []string `toml:"proxies" json:"proxies,omitempty"`
+		} `toml:"country-egress" json:"countryEgress,omitempty"`
+		DOHURL    string `toml:"doh-url" json:"dohUrl,omitempty"`
+		Resolver  string `toml:"resolver" json:"resolver,omitempty"`
+		DOTServer string `toml:"dot-server" json:"dotServer,omitempty"`
+		// keys are DC numbers, toml has no integer keys
+		DCAddresses map[string][]string `toml:"dc-addresses" json:"dcAddresses,omitempty"`
 	} `toml:"network" json:"network,omitempty"`
 	Stats struct {
 		StatsD struct {
@@ -57,30 +145,402 @@ type tomlConfig struct {
 			BindTo       string `toml:"bind-to" json:"bindTo,omitempty"`
 			HTTPPath     string `toml:"http-path" json:"httpPath,omitempty"`
 			MetricPrefix string `toml:"metric-prefix" json:"metricPrefix,omitempty"`
+			Textfile     struct {
+				Path     string `toml:"path" json:"path,omitempty"`
+				Interval string `toml:"interval" json:"interval,omitempty"`
+			} `toml:"textfile" json:"textfile,omitempty"`
+			TLS struct {
+				Cert     string `toml:"cert" json:"cert,omitempty"`
+				Key      string `toml:"key" json:"key,omitempty"`
+				ClientCA string `toml:"client-ca" json:"clientCa,omitempty"`
+			} `toml:"tls" json:"tls,omitempty"`
 		} `toml:"prometheus" json:"prometheus,omitempty"`
+		Origin struct {
+			Enabled   bool     `toml:"enabled" json:"enabled,omitempty"`
+			Countries []string `toml:"countries" json:"countries,omitempty"`
+			ASNs      []uint   `toml:"asns" json:"asns,omitempty"`
+			MaxASNs   uint     `toml:"max-asns" json:"maxAsns,omitempty"`
+		} `toml:"origin" json:"origin,omitempty"`
+		UnixDatagram struct {
+			Enabled   bool   `toml:"enabled" json:"enabled,omitempty"`
+			Path      string `toml:"path" json:"path,omitempty"`
+			QueueSize uint   `toml:"queue-size" json:"queueSize,omitempty"`
+		} `toml:"unix-datagram" json:"unixDatagram,omitempty"`
+		SSE struct {
+			Enabled   bool   `toml:"enabled" json:"enabled,omitempty"`
+			HTTPPath  string `toml:"http-path" json:"httpPath,omitempty"`
+			Token     string `toml:"token" json:"token,omitempty"`
+			QueueSize uint   `toml:"queue-size" json:"queueSize,omitempty"`
+		} `toml:"sse" json:"sse,omitempty"`
+		RecentEvents struct {
+			Enabled  bool   `toml:"enabled" json:"enabled,omitempty"`
+			HTTPPath string `toml:"http-path" json:"httpPath,omitempty"`
+			Token    string `toml:"token" json:"token,omitempty"`
+			Size     uint   `toml:"size" json:"size,omitempty"`
+		} `toml:"recent-events" json:"recentEvents,omitempty"`
+		IPLists struct {
+			Enabled  bool   `toml:"enabled" json:"enabled,omitempty"`
+			HTTPPath string `toml:"http-path" json:"httpPath,omitempty"`
+		} `toml:"ip-lists" json:"ipLists,omitempty"`
+		NATS struct {
+			Enabled   bool   `toml:"enabled" json:"enabled,omitempty"`
+			Address   string `toml:"address" json:"address,omitempty"`
+			Subject   string `toml:"subject" json:"subject,omitempty"`
+			Token     string `toml:"token" json:"token,omitempty"`
+			QueueSize uint   `toml:"queue-size" json:"queueSize,omitempty"`
+		} `toml:"nats" json:"nats,omitempty"`
+		JSON struct {
+			Enabled       bool   `toml:"enabled" json:"enabled,omitempty"`
+			Path          string `toml:"path" json:"path,omitempty"`
+			MaxSize       string `toml:"max-size" json:"maxSize,omitempty"`
+			MaxBackups    uint   `toml:"max-backups" json:"maxBackups,omitempty"`
+			WebhookURL    string `toml:"webhook-url" json:"webhookUrl,omitempty"`
+			QueueSize     uint   `toml:"queue-size" json:"queueSize,omitempty"`
+			BatchSize     uint   `toml:"batch-size" json:"batchSize,omitempty"`
+			FlushInterval string `toml:"flush-interval" json:"flushInterval,omitempty"`
+		} `toml:"json" json:"json,omitempty"`
 	} `toml:"stats" json:"stats,omitempty"`
+	GeoIP struct {
+		CountryDB  string `toml:"country-db" json:"countryDb,omitempty"`
+		ASNDB      string `toml:"asn-db" json:"asnDb,omitempty"`
+		UpdateEach string `toml:"update-each" json:"updateEach,omitempty"`
+		Download   struct {
+			Enabled    bool   `toml:"enabled" json:"enabled,omitempty"`
+			CountryURL string `toml:"country-url" json:"countryUrl,omitempty"`
+			ASNURL     string `toml:"asn-url" json:"asnUrl,omitempty"`
+			UpdateEach string `toml:"update-each" json:"updateEach,omitempty"`
+			Attempts   uint   `toml:"attempts" json:"attempts,omitempty"`
+			Backoff    string `toml:"backoff" json:"backoff,omitempty"`
+		} `toml:"download" json:"download,omitempty"`
+	} `toml:"geoip" json:"geoip,omitempty"`
+	Log struct {
+		Output string `toml:"output" json:"output,omitempty"`
+		Tag    string `toml:"tag" json:"tag,omitempty"`
+		Syslog struct {
+			URL string `toml:"url" json:"url,omitempty"`
+		} `toml:"syslog" json:"syslog,omitempty"`
+		Dedup struct {
+			Enabled  bool   `toml:"enabled" json:"enabled,omitempty"`
+			Interval string `toml:"interval" json:"interval,omitempty"`
+		} `toml:"dedup" json:"dedup,omitempty"`
+		Sampling struct {
+			Enabled    bool   `toml:"enabled" json:"enabled,omitempty"`
+			Interval   string `toml:"interval" json:"interval,omitempty"`
+			First      uint   `toml:"first" json:"first,omitempty"`
+			Thereafter uint   `toml:"thereafter" json:"thereafter,omitempty"`
+		} `toml:"sampling" json:"sampling,omitempty"`
+		Access struct {
+			Enabled bool   `toml:"enabled" json:"enabled,omitempty"`
+			Path    string `toml:"path" json:"path,omitempty"`
+			Format  string `toml:"format" json:"format,omitempty"`
+		} `toml:"access" json:"access,omitempty"`
+	} `toml:"log" json:"log,omitempty"`
+	Profiling struct {
+		Enabled      bool   `toml:"enabled" json:"enabled,omitempty"`
+		PyroscopeURL string `toml:"pyroscope-url" json:"pyroscopeUrl,omitempty"`
+		AppName      string `toml:"app-name" json:"appName,omitempty"`
+		UploadEach   string `toml:"upload-each" json:"uploadEach,omitempty"`
+	} `toml:"profiling" json:"profiling,omitempty"`
+	Secrets   []string `toml:"secrets" json:"secrets,omitempty"`
+	Listeners []struct {
+		BindTo             string `toml:"bind-to" json:"bindTo"`
+		Secret             string `toml:"secret" json:"secret,omitempty"`
+		DomainFrontingPort uint   `toml:"domain-fronting-port" json:"domainFrontingPort,omitempty"`
+		Concurrency        uint   `toml:"concurrency" json:"concurrency,omitempty"`
+		AdmissionQueueSize uint   `toml:"admission-queue-size" json:"admissionQueueSize,omitempty"`
+	} `toml:"listeners" json:"listeners,omitempty"`
 }
 
-func Parse(rawData []byte) (*Config, error) {
-	tomlConf := &tomlConfig{}
-	jsonBuf := &bytes.Buffer{}
-	conf := &Config{}
+// FieldError describes a problem with a single configuration field.
+type FieldError struct {
+	// Path is a dotted path to the field in TOML config. For example,
+	// 'defense.anti-replay.max-size'.
+	Path string
+
+	// Line is a line number of the field in TOML config. It is 0 if field
+	// is absent.
+	Line int
+
+	// Err is an original error.
+	Err error
+}
+
+func (f FieldError) Error() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %v", f.Path, f.Line, f.Err)
+	}
+
+	return fmt.Sprintf("%s: %v", f.Path, f.Err)
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+// ParseError is returned if some configuration fields are invalid. It
+// contains all found problems, not only the first one.
+type ParseError struct {
+	Errors []FieldError
+}
 
-	jsonEncoder := json.NewEncoder(jsonBuf)
-	jsonEncoder.SetEscapeHTML(false)
-	jsonEncoder.SetIndent("", "")
+func (p *ParseError) Error() string {
+	messages := make([]string, 0, len(p.Errors))
 
-	if err := toml.Unmarshal(rawData, tomlConf); err != nil {
-		return nil, fmt.Errorf("cannot parse toml config: %w", err)
+	for _, v := range p.Errors {
+		messages = append(messages, v.Error())
 	}
 
-	if err := jsonEncoder.Encode(tomlConf); err != nil {
+	return strings.Join(messages, "; ")
+}
+
+// Parse parses given TOML documents into a config. If there are several
+// documents, they are deep-merged in order: the value from the later document
+// wins.
+//
+// Tables are merged recursively so a key which is absent in a later document
+// keeps its value from the former ones. All other values, including arrays,
+// are replaced. Line numbers in errors point to the document which defined a
+// value.
+func Parse(rawData ...[]byte) (*Config, error) {
+	tree, err := toml.TreeFromMap(map[string]interface{}{})
+	if err != nil {
 		panic(err)
 	}
 
-	if err := json.NewDecoder(jsonBuf).Decode(conf); err != nil {
-		return nil, fmt.Errorf("cannot parse a config: %w", err)
+	for i, data := range rawData {
+		overlay, err := toml.LoadBytes(data)
+
+		switch {
+		case err != nil && len(rawData) == 1:
+			return nil, fmt.Errorf("cannot parse toml config: %w", err)
+		case err != nil:
+			return nil, fmt.Errorf("cannot parse toml config #%d: %w", i+1, err)
+		}
+
+		mergeTrees(tree, overlay, nil)
+	}
+
+	conf := &Config{}
+	parseErr := &ParseError{}
+
+	parseFields(tree, reflect.TypeOf(tomlConfig{}), reflect.ValueOf(conf).Elem(), nil, parseErr)
+
+	if len(parseErr.Errors) > 0 {
+		return nil, fmt.Errorf("cannot parse a config: %w", parseErr)
 	}
 
 	return conf, nil
 }
+
+func mergeTrees(base, overlay *toml.Tree, path []string) {
+	for _, key := range overlay.Keys() {
+		keyPath := []string{key}
+		fullPath := append(path[:len(path):len(path)], key)
+		value := overlay.GetPath(keyPath)
+
+		if subtree, ok := value.(*toml.Tree); ok {
+			if _, ok := base.GetPath(fullPath).(*toml.Tree); ok {
+				mergeTrees(base, subtree, fullPath)
+
+				continue
+			}
+		}
+
+		base.SetPath(fullPath, value)
+		base.SetPositionPath(fullPath, overlay.GetPositionPath(keyPath))
+	}
+}
+
+// parseFields walks over tomlConfig schema and fills a corresponding field of
+// Config for each of them. tomlConfig and Config are matched by json names.
+func parseFields(tree *toml.Tree, schema reflect.Type, target reflect.Value,
+	path []string, parseErr *ParseError,
+) {
+	for i := 0; i < schema.NumField(); i++ {
+		field := schema.Field(i)
+		jsonName, omitEmpty := parseJSONTag(field.Tag.Get("json"))
+		fieldPath := append(path[:len(path):len(path)], field.Tag.Get("toml"))
+		targetValue := lookupJSONField(target, jsonName)
+
+		if field.Type.Kind() == reflect.Struct {
+			parseFields(tree, field.Type, targetValue, fieldPath, parseErr)
+
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			parseTables(tree, field.Type.Elem(), targetValue, fieldPath, parseErr)
+
+			continue
+		}
+
+		if err := parseField(tree.GetPath(fieldPath), field.Type, targetValue, omitEmpty); err != nil {
+			parseErr.Errors = append(parseErr.Errors, FieldError{
+				Path: strings.Join(fieldPath, "."),
+				Line: tree.GetPositionPath(fieldPath).Line,
+				Err:  err,
+			})
+		}
+	}
+}
+
+// parseTables fills a slice of Config structures from an array of tables.
+// Errors have paths like 'listeners.1.bind-to'.
+func parseTables(tree *toml.Tree, schema reflect.Type, target reflect.Value,
+	path []string, parseErr *ParseError,
+) {
+	tomlValue := tree.GetPath(path)
+	if tomlValue == nil {
+		return
+	}
+
+	tables, ok := tomlValue.([]*toml.Tree)
+	if !ok {
+		parseErr.Errors = append(parseErr.Errors, FieldError{
+			Path: strings.Join(path, "."),
+			Line: tree.GetPositionPath(path).Line,
+			Err:  fmt.Errorf("unexpected type %T, expected array of tables", tomlValue),
+		})
+
+		return
+	}
+
+	slice := reflect.MakeSlice(target.Type(), len(tables), len(tables))
+
+	for i, table := range tables {
+		tableErr := &ParseError{}
+
+		parseFields(table, schema, slice.Index(i), nil, tableErr)
+
+		for _, err := range tableErr.Errors {
+			err.Path = fmt.Sprintf("%s.%d.%s", strings.Join(path, "."), i, err.Path)
+			parseErr.Errors = append(parseErr.Errors, err)
+		}
+	}
+
+	target.Set(slice)
+}
+
+func parseField(tomlValue interface{}, rawType reflect.Type,
+	target reflect.Value, omitEmpty bool,
+) error {
+	rawValue := reflect.New(rawType).Elem()
+
+	if tomlValue != nil {
+		if err := convertTOMLValue(tomlValue, rawValue); err != nil {
+			return err
+		}
+	}
+
+	if omitEmpty && (rawValue.IsZero() || (rawValue.Kind() == reflect.Slice && rawValue.Len() == 0)) {
+		return nil
+	}
+
+	data, err := json.Marshal(rawValue.Interface())
+	if err != nil {
+		panic(err)
+	}
+
+	return json.Unmarshal(data, target.Addr().Interface()) //nolint: wrapcheck
+}
+
+func convertTOMLValue(tomlValue interface{}, target reflect.Value) error { //nolint: cyclop
+	switch target.Kind() { //nolint: exhaustive
+	case reflect.Bool:
+		if value, ok := tomlValue.(bool); ok {
+			target.SetBool(value)
+
+			return nil
+		}
+	case reflect.String:
+		if value, ok := tomlValue.(string); ok {
+			target.SetString(value)
+
+			return nil
+		}
+	case reflect.Uint:
+		if value, ok := tomlValue.(int64); ok {
+			if value < 0 {
+				return fmt.Errorf("value should be >= 0 (%d)", value)
+			}
+
+			target.SetUint(uint64(value))
+
+			return nil
+		}
+	case reflect.Float64:
+		switch value := tomlValue.(type) {
+		case float64:
+			target.SetFloat(value)
+
+			return nil
+		case int64:
+			target.SetFloat(float64(value))
+
+			return nil
+		}
+	case reflect.Map:
+		if tree, ok := tomlValue.(*toml.Tree); ok {
+			keys := tree.Keys()
+			mapping := reflect.MakeMapWithSize(target.Type(), len(keys))
+
+			for _, key := range keys {
+				value := reflect.New(target.Type().Elem()).Elem()
+
+				if err := convertTOMLValue(tree.GetPath([]string{key}), value); err != nil {
+					return fmt.Errorf("incorrect item %s: %w", key, err)
+				}
+
+				mapping.SetMapIndex(reflect.ValueOf(key), value)
+			}
+
+			target.Set(mapping)
+
+			return nil
+		}
+	case reflect.Slice:
+		if values, ok := tomlValue.([]interface{}); ok {
+			slice := reflect.MakeSlice(target.Type(), len(values), len(values))
+
+			for i, v := range values {
+				if err := convertTOMLValue(v, slice.Index(i)); err != nil {
+					return fmt.Errorf("incorrect item %d: %w", i, err)
+				}
+			}
+
+			target.Set(slice)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unexpected type %T, expected %s", tomlValue, target.Kind())
+}
+
+func parseJSONTag(tag string) (string, bool) {
+	name, options, _ := strings.Cut(tag, ",")
+
+	return name, options == "omitempty"
+}
+
+func lookupJSONField(target reflect.Value, jsonName string) reflect.Value {
+	targetType := target.Type()
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+
+		if field.Anonymous {
+			if value := lookupJSONField(target.Field(i), jsonName); value.IsValid() {
+				return value
+			}
+
+			continue
+		}
+
+		if name, _ := parseJSONTag(field.Tag.Get("json")); name == jsonName {
+			return target.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/pelletier/go-toml"
+)
+
+// RedactedValue replaces sensitive values in a redacted dump of the
+// config.
+const RedactedValue = "redacted"
+
+// redactedKeys are json names of fields which are redacted completely
+// wherever they are.
+var redactedKeys = map[string]bool{
+	"password": true,
+	"token":    true,
+}
+
+// RedactedJSON returns a config in JSON where secrets, passwords, tokens
+// and credentials of URLs are redacted. So it is safe to share it.
+func (c *Config) RedactedJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(c.redacted()); err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RedactedTOML returns a config in TOML, in a format of a config file.
+// Values are redacted in the same way as for [Config.RedactedJSON].
+func (c *Config) RedactedTOML() ([]byte, error) {
+	return encodeTOML(c.redacted())
+}
+
+// encodeTOML converts a decoded JSON of the config back to a raw config
+// so keys are named as in a config file.
+func encodeTOML(tree map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	raw := tomlConfig{}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot convert config: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := toml.NewEncoder(buf).Encode(raw); err != nil {
+		return nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *Config) redacted() map[string]interface{} {
+	tree := c.tree()
+
+	redactTree(tree)
+
+	return tree
+}
+
+// redactTree redacts a decoded JSON in place. Secrets and other known
+// sensitive fields are replaced by their names. Any other string is
+// checked if it is a URL with credentials: an URL of blocklist, SOCKS5
+// proxy or webhook may contain a password in userinfo or a token in a
+// query.
+func redactTree(tree map[string]interface{}) {
+	for key, value := range tree {
+		switch {
+		case key == "secret":
+			tree[key] = redactSecret(value)
+		case key == "secrets":
+			if values, ok := value.([]interface{}); ok {
+				for i, v := range values {
+					values[i] = redactSecret(v)
+				}
+			}
+		case redactedKeys[key]:
+			if v, ok := value.(string); ok && v != "" {
+				tree[key] = RedactedValue
+			}
+		default:
+			tree[key] = redactValue(value)
+		}
+	}
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redactTree(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	case string:
+		return RedactURL(v)
+	}
+
+	return value
+}
+
+// redactSecret keeps a fingerprint of a secret. It is the same as the one
+// in logs and metrics, so a secret can be matched to them.
+func redactSecret(value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || text == "" {
+		return value
+	}
+
+	secret := mtglib.Secret{}
+
+	if err := secret.Set(text); err != nil {
+		return RedactedValue
+	}
+
+	return RedactedValue + ":" + secret.Fingerprint()
+}
+
+// RedactURL replaces credentials, query values and a fragment of URL with
+// RedactedValue. A scheme, a host and a path are kept as is. Values which
+// are not URLs are returned unchanged.
+func RedactURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return value
+	}
+
+	if parsed.User == nil && parsed.RawQuery == "" && parsed.Fragment == "" {
+		return value
+	}
+
+	if parsed.User != nil {
+		parsed.User = url.User(RedactedValue)
+	}
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+
+		for k := range query {
+			query[k] = []string{RedactedValue}
+		}
+
+		parsed.RawQuery = query.Encode()
+	}
+
+	if parsed.Fragment != "" {
+		parsed.Fragment = RedactedValue
+	}
+
+	return parsed.String()
+}
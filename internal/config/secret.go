@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// SecretEnvVar is a name of environment variable which may contain a
+// secret of the proxy.
+const SecretEnvVar = "MTG_SECRET"
+
+// ResolveSecret takes a secret from other sources than a config file, so
+// a config can be kept in source control. A value of [SecretEnvVar]
+// (passed as envValue) comes first, then a content of secret-file. Both
+// take precedence over an inline secret.
+func (c *Config) ResolveSecret(envValue string) error {
+	if envValue = strings.TrimSpace(envValue); envValue != "" {
+		if err := c.Secret.Set(envValue); err != nil {
+			return fmt.Errorf("incorrect secret in %s: %w", SecretEnvVar, err)
+		}
+
+		return nil
+	}
+
+	path := c.SecretFile.Get("")
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read secret file: %w", err)
+	}
+
+	if err := c.Secret.Set(strings.TrimSpace(string(data))); err != nil {
+		return fmt.Errorf("incorrect secret in file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ReadSecretsDir reads secrets from a directory, one secret per file.
+// Hidden files and subdirectories are skipped. A file with an incorrect
+// secret does not fail a whole directory: it is reported to onInvalid
+// and skipped. Secrets are returned in the order of file names.
+func ReadSecretsDir(path string, onInvalid func(name string, err error)) ([]mtglib.Secret, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read secrets directory: %w", err)
+	}
+
+	secrets := []mtglib.Secret{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			onInvalid(entry.Name(), fmt.Errorf("cannot read secret file: %w", err))
+
+			continue
+		}
+
+		secret, err := mtglib.ParseSecret(strings.TrimSpace(string(data)))
+
+		switch {
+		case err != nil:
+			onInvalid(entry.Name(), fmt.Errorf("incorrect secret: %w", err))
+
+			continue
+		case !secret.Valid():
+			onInvalid(entry.Name(), mtglib.ErrSecretInvalid)
+
+			continue
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,129 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	secretTestInline = "7oe1GqLy6TBc38CV3jx7q09nb29nbGUuY29t"
+	secretTestFile   = "ee367a189aee18fa31c190054efd4a8e9573746f726167652e676f6f676c65617069732e636f6d"
+	secretTestEnv    = "7qw1Xuop_iDOetaXW8uHYCZleGFtcGxlLmNvbQ"
+)
+
+type SecretTestSuite struct {
+	suite.Suite
+
+	secretPath string
+}
+
+func (suite *SecretTestSuite) SetupTest() {
+	suite.secretPath = filepath.Join(suite.T().TempDir(), "secret")
+	suite.NoError(os.WriteFile(suite.secretPath, []byte(secretTestFile+"\n"), 0o600))
+}
+
+func (suite *SecretTestSuite) parse(data string) *config.Config {
+	conf, err := config.Parse([]byte("bind-to = \"0.0.0.0:3128\"\n" + data))
+	suite.Require().NoError(err)
+
+	return conf
+}
+
+func (suite *SecretTestSuite) TestInline() {
+	conf := suite.parse(`secret = "` + secretTestInline + `"`)
+
+	suite.NoError(conf.ResolveSecret(""))
+	suite.Equal(secretTestInline, conf.Secret.Base64())
+}
+
+func (suite *SecretTestSuite) TestMissing() {
+	conf := suite.parse("")
+
+	suite.NoError(conf.ResolveSecret(""))
+	suite.Error(conf.Validate())
+}
+
+func (suite *SecretTestSuite) TestFile() {
+	conf := suite.parse(`secret = "` + secretTestInline + `"
+secret-file = "` + suite.secretPath + `"`)
+
+	suite.NoError(conf.ResolveSecret(""))
+	suite.Equal(secretTestFile, conf.Secret.Hex())
+	suite.Equal("storage.googleapis.com", conf.Secret.Host)
+}
+
+func (suite *SecretTestSuite) TestEnv() {
+	conf := suite.parse(`secret-file = "` + suite.secretPath + `"`)
+
+	suite.NoError(conf.ResolveSecret(" " + secretTestEnv + "\n"))
+	suite.Equal(secretTestEnv, conf.Secret.Base64())
+	suite.NoError(conf.Validate())
+}
+
+func (suite *SecretTestSuite) TestIncorrect() {
+	conf := suite.parse(`secret = "` + secretTestInline + `"`)
+	suite.Error(conf.ResolveSecret("aaaa"))
+
+	suite.NoError(os.WriteFile(suite.secretPath, []byte("aaaa"), 0o600))
+
+	conf = suite.parse(`secret-file = "` + suite.secretPath + `"`)
+	suite.Error(conf.ResolveSecret(""))
+}
+
+func (suite *SecretTestSuite) TestUnknownFile() {
+	_, err := config.Parse([]byte(`secret-file = "` + suite.secretPath + `.unknown"`))
+	suite.Error(err)
+}
+
+func (suite *SecretTestSuite) TestRedacted() {
+	conf := suite.parse(`secret-file = "` + suite.secretPath + `"`)
+	suite.NoError(conf.ResolveSecret(secretTestEnv))
+
+	suite.NotContains(conf.String(), secretTestEnv)
+	suite.Contains(conf.String(), config.RedactedValue+":"+conf.Secret.Fingerprint())
+	suite.Contains(conf.String(), suite.secretPath)
+}
+
+func (suite *SecretTestSuite) TestDir() {
+	dir := suite.T().TempDir()
+
+	suite.NoError(os.WriteFile(filepath.Join(dir, "b"), []byte(secretTestFile+"\n"), 0o600))
+	suite.NoError(os.WriteFile(filepath.Join(dir, "a"), []byte(secretTestEnv), 0o600))
+	suite.NoError(os.WriteFile(filepath.Join(dir, "c"), []byte("aaaa"), 0o600))
+	suite.NoError(os.WriteFile(filepath.Join(dir, ".hidden"), []byte("aaaa"), 0o600))
+	suite.NoError(os.Mkdir(filepath.Join(dir, "d"), 0o700))
+
+	invalid := []string{}
+	secrets, err := config.ReadSecretsDir(dir, func(name string, err error) {
+		suite.Error(err)
+
+		invalid = append(invalid, name)
+	})
+
+	suite.NoError(err)
+	suite.Equal([]string{"c"}, invalid)
+	suite.Len(secrets, 2)
+	suite.Equal(secretTestEnv, secrets[0].Base64())
+	suite.Equal(secretTestFile, secrets[1].Hex())
+}
+
+func (suite *SecretTestSuite) TestDirUnknown() {
+	_, err := config.ReadSecretsDir(filepath.Join(suite.T().TempDir(), "unknown"), func(string, error) {})
+	suite.Error(err)
+}
+
+func (suite *SecretTestSuite) TestDirParse() {
+	dir := suite.T().TempDir()
+	conf := suite.parse(`secrets-dir = "` + dir + `"`)
+
+	suite.Equal(dir, conf.SecretsDir.Get(""))
+}
+
+func TestSecret(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &SecretTestSuite{})
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeAccessLogFormatJSON defines an access log with a JSON object
+	// per line.
+	TypeAccessLogFormatJSON = "json"
+
+	// TypeAccessLogFormatLogfmt defines an access log with key=value
+	// pairs per line.
+	TypeAccessLogFormatLogfmt = "logfmt"
+)
+
+type TypeAccessLogFormat struct {
+	Value string
+}
+
+func (t *TypeAccessLogFormat) Set(value string) error {
+	lowercasedValue := strings.ToLower(value)
+
+	switch lowercasedValue {
+	case TypeAccessLogFormatJSON, TypeAccessLogFormatLogfmt:
+		t.Value = lowercasedValue
+
+		return nil
+	default:
+		return fmt.Errorf("unknown access log format %s", value)
+	}
+}
+
+func (t TypeAccessLogFormat) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeAccessLogFormat) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t *TypeAccessLogFormat) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *TypeAccessLogFormat) String() string {
+	return t.Value
+}
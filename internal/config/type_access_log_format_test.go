@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeAccessLogFormatTestStruct struct {
+	Value config.TypeAccessLogFormat `json:"value"`
+}
+
+type AccessLogFormatTestSuite struct {
+	suite.Suite
+}
+
+func (suite *AccessLogFormatTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"xml",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeAccessLogFormatTestStruct{}))
+		})
+	}
+}
+
+func (suite *AccessLogFormatTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeAccessLogFormatJSON,
+		config.TypeAccessLogFormatLogfmt,
+		strings.ToUpper(config.TypeAccessLogFormatJSON),
+		strings.ToUpper(config.TypeAccessLogFormatLogfmt),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeAccessLogFormatTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *AccessLogFormatTestSuite) TestMarshalOk() {
+	testData := []string{
+		config.TypeAccessLogFormatJSON,
+		config.TypeAccessLogFormatLogfmt,
+	}
+
+	for _, v := range testData {
+		value := v
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeAccessLogFormatTestStruct{
+				Value: config.TypeAccessLogFormat{
+					Value: value,
+				},
+			}
+
+			encodedJSON, err := json.Marshal(testStruct)
+			assert.NoError(t, err)
+
+			expectedJSON, err := json.Marshal(map[string]string{
+				"value": value,
+			})
+			assert.NoError(t, err)
+
+			assert.JSONEq(t, string(expectedJSON), string(encodedJSON))
+		})
+	}
+}
+
+func (suite *AccessLogFormatTestSuite) TestGet() {
+	value := config.TypeAccessLogFormat{}
+	suite.Equal(config.TypeAccessLogFormatJSON,
+		value.Get(config.TypeAccessLogFormatJSON))
+
+	suite.NoError(value.Set(config.TypeAccessLogFormatLogfmt))
+	suite.Equal(config.TypeAccessLogFormatLogfmt,
+		value.Get(config.TypeAccessLogFormatJSON))
+}
+
+func TestTypeAccessLogFormat(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &AccessLogFormatTestSuite{})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type TypeAccessToken struct {
+	Value string
+}
+
+func (t *TypeAccessToken) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("access token cannot be empty")
+	}
+
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("access token has to be printable and without spaces")
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeAccessToken) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeAccessToken) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeAccessToken) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeAccessToken) String() string {
+	return t.Value
+}
@@ -0,0 +1,87 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeAccessTokenTestStruct struct {
+	Value config.TypeAccessToken `json:"value"`
+}
+
+type TypeAccessTokenTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeAccessTokenTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"hello world",
+		"token\n",
+		"\ttoken",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeAccessTokenTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeAccessTokenTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"token",
+		"c2VjcmV0Cg==",
+		"a-b_c.d~e",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": value,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(value, func(t *testing.T) {
+			testStruct := &typeAccessTokenTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, value, testStruct.Value.Get("lalala"))
+		})
+	}
+}
+
+func (suite *TypeAccessTokenTestSuite) TestMarshalOk() {
+	testStruct := &typeAccessTokenTestStruct{
+		Value: config.TypeAccessToken{
+			Value: "token",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "token"}`, string(data))
+}
+
+func (suite *TypeAccessTokenTestSuite) TestGet() {
+	value := config.TypeAccessToken{}
+	suite.Equal("lalala", value.Get("lalala"))
+
+	value.Value = "token"
+	suite.Equal("token", value.Get("lalala"))
+}
+
+func TestTypeAccessToken(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeAccessTokenTestSuite{})
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeAntiReplayHashXXHash states that tokens of handshakes are
+	// computed with 64-bit xxhash.
+	TypeAntiReplayHashXXHash = "xxhash"
+
+	// TypeAntiReplayHashFNV states that tokens of handshakes are computed
+	// with 64-bit FNV-1a.
+	TypeAntiReplayHashFNV = "fnv"
+
+	// TypeAntiReplayHashSHA256 states that tokens of handshakes are
+	// computed with SHA-256.
+	TypeAntiReplayHashSHA256 = "sha256"
+)
+
+type TypeAntiReplayHash struct {
+	Value string
+}
+
+func (t *TypeAntiReplayHash) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeAntiReplayHashXXHash, TypeAntiReplayHashFNV, TypeAntiReplayHashSHA256:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported anti-replay hash: %s", value)
+	}
+}
+
+func (t *TypeAntiReplayHash) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeAntiReplayHash) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeAntiReplayHash) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeAntiReplayHash) String() string {
+	return t.Value
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeAntiReplayHashTestStruct struct {
+	Value config.TypeAntiReplayHash `json:"value"`
+}
+
+type TypeAntiReplayHashTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeAntiReplayHashTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"md5",
+		config.TypeAntiReplayHashXXHash + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeAntiReplayHashTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeAntiReplayHashTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeAntiReplayHashXXHash,
+		config.TypeAntiReplayHashFNV,
+		config.TypeAntiReplayHashSHA256,
+		strings.ToTitle(config.TypeAntiReplayHashXXHash),
+		strings.ToTitle(config.TypeAntiReplayHashSHA256),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeAntiReplayHashTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeAntiReplayHashTestSuite) TestMarshalOk() {
+	testStruct := &typeAntiReplayHashTestStruct{
+		Value: config.TypeAntiReplayHash{
+			Value: config.TypeAntiReplayHashSHA256,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"sha256"}`, string(data))
+}
+
+func (suite *TypeAntiReplayHashTestSuite) TestGet() {
+	value := config.TypeAntiReplayHash{}
+	suite.Equal(config.TypeAntiReplayHashXXHash,
+		value.Get(config.TypeAntiReplayHashXXHash))
+
+	suite.NoError(value.Set(config.TypeAntiReplayHashSHA256))
+	suite.Equal(config.TypeAntiReplayHashSHA256,
+		value.Get(config.TypeAntiReplayHashXXHash))
+}
+
+func TestTypeAntiReplayHash(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeAntiReplayHashTestSuite{})
+}
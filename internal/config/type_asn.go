@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TypeASN struct {
+	Value uint
+}
+
+func (t *TypeASN) Set(value string) error {
+	value = strings.TrimPrefix(strings.ToUpper(value), "AS")
+
+	asnValue, err := strconv.ParseUint(value, 10, 32) //nolint: gomnd
+	if err != nil {
+		return fmt.Errorf("incorrect autonomous system number (%s): %w", value, err)
+	}
+
+	if asnValue == 0 {
+		return fmt.Errorf("autonomous system number should be >0 (%s)", value)
+	}
+
+	t.Value = uint(asnValue)
+
+	return nil
+}
+
+func (t TypeASN) Get(defaultValue uint) uint {
+	if t.Value == 0 {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeASN) UnmarshalJSON(data []byte) error {
+	return t.Set(strings.Trim(string(data), `"`))
+}
+
+func (t TypeASN) MarshalJSON() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeASN) String() string {
+	return strconv.FormatUint(uint64(t.Value), 10) //nolint: gomnd
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeASNTestStruct struct {
+	Value config.TypeASN `json:"value"`
+}
+
+type TypeASNTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeASNTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		`""`,
+		`"asn"`,
+		`0`,
+		`-1`,
+		`1.5`,
+		`5000000000`,
+	}
+
+	for _, v := range testData {
+		data := []byte(`{"value": ` + v + `}`)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeASNTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeASNTestSuite) TestUnmarshalOk() {
+	testData := map[string]uint{
+		`20712`:    20712,
+		`"20712"`:  20712,
+		`"AS1299"`: 1299,
+		`"as1299"`: 1299,
+	}
+
+	for k, v := range testData {
+		data := []byte(`{"value": ` + k + `}`)
+		expected := v
+
+		suite.T().Run(k, func(t *testing.T) {
+			testStruct := &typeASNTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, expected, testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeASNTestSuite) TestMarshalOk() {
+	testStruct := &typeASNTestStruct{
+		Value: config.TypeASN{
+			Value: 20712,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":20712}`, string(data))
+}
+
+func (suite *TypeASNTestSuite) TestGet() {
+	value := config.TypeASN{}
+	suite.EqualValues(1, value.Get(1))
+
+	value.Value = 20712
+	suite.EqualValues(20712, value.Get(1))
+}
+
+func TestTypeASN(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeASNTestSuite{})
+}
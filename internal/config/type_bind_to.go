@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TypeBindToUnixPrefix is a prefix of bind-to values which define a path
+// of Unix domain socket instead of host:port pair.
+const TypeBindToUnixPrefix = "unix:"
+
+// TypeBindTo is either an IP:port pair like [TypeHostPort] or a path to
+// Unix domain socket with 'unix:' prefix.
+type TypeBindTo struct {
+	Value    string
+	Host     string
+	Port     uint
+	UnixPath string
+}
+
+func (t *TypeBindTo) Set(value string) error {
+	if !strings.HasPrefix(value, TypeBindToUnixPrefix) {
+		hostPort := TypeHostPort{}
+		if err := hostPort.Set(value); err != nil {
+			return err
+		}
+
+		t.Value = hostPort.Value
+		t.Host = hostPort.Host
+		t.Port = hostPort.Port
+		t.UnixPath = ""
+
+		return nil
+	}
+
+	path := strings.TrimPrefix(value, TypeBindToUnixPrefix)
+	if path == "" {
+		return fmt.Errorf("empty path of unix socket: %s", value)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("cannot resolve absolute path of unix socket (%s): %w", value, err)
+	}
+
+	stat, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("incorrect directory of unix socket (%s): %w", value, err)
+	}
+
+	if !stat.IsDir() {
+		return fmt.Errorf("parent of unix socket is not a directory (%s)", value)
+	}
+
+	t.Value = TypeBindToUnixPrefix + path
+	t.Host = ""
+	t.Port = 0
+	t.UnixPath = path
+
+	return nil
+}
+
+func (t TypeBindTo) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeBindTo) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeBindTo) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeBindTo) String() string {
+	return t.Value
+}
@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeBindToTestStruct struct {
+	Value config.TypeBindTo `json:"value"`
+}
+
+type TypeBindToTestSuite struct {
+	suite.Suite
+
+	directory string
+}
+
+func (suite *TypeBindToTestSuite) SetupSuite() {
+	dir, _ := os.Getwd()
+	suite.directory = dir
+}
+
+func (suite *TypeBindToTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		":800",
+		"localhost",
+		"unix:",
+		"unix:" + filepath.Join(suite.directory, "___", "mtg.sock"),
+		"unix:" + filepath.Join(suite.directory, "config.go", "mtg.sock"),
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeBindToTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeBindToTestSuite) TestUnmarshalHostPort() {
+	testStruct := &typeBindToTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": "127.0.0.1:3128"}`), testStruct))
+	suite.Equal("127.0.0.1:3128", testStruct.Value.Get(""))
+	suite.Equal("127.0.0.1", testStruct.Value.Host)
+	suite.EqualValues(3128, testStruct.Value.Port)
+	suite.Empty(testStruct.Value.UnixPath)
+}
+
+func (suite *TypeBindToTestSuite) TestUnmarshalUnix() {
+	testStruct := &typeBindToTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": "unix:mtg.sock"}`), testStruct))
+
+	path := filepath.Join(suite.directory, "mtg.sock")
+
+	suite.Equal("unix:"+path, testStruct.Value.Get(""))
+	suite.Equal(path, testStruct.Value.UnixPath)
+	suite.Empty(testStruct.Value.Host)
+	suite.Zero(testStruct.Value.Port)
+}
+
+func (suite *TypeBindToTestSuite) TestMarshalOk() {
+	testStruct := &typeBindToTestStruct{
+		Value: config.TypeBindTo{
+			Value: "unix:/run/mtg.sock",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"unix:/run/mtg.sock"}`, string(data))
+}
+
+func (suite *TypeBindToTestSuite) TestGet() {
+	value := config.TypeBindTo{}
+	suite.Equal("127.0.0.1:80", value.Get("127.0.0.1:80"))
+
+	suite.NoError(value.Set("10.0.0.10:3128"))
+	suite.Equal("10.0.0.10:3128", value.Get("127.0.0.1:80"))
+}
+
+func TestTypeBindTo(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeBindToTestSuite{})
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// TypeCIDR is a network in CIDR notation. A single IP address is a network
+// of this address only.
+type TypeCIDR struct {
+	Value *net.IPNet
+}
+
+func (t *TypeCIDR) Set(value string) error {
+	if ip := net.ParseIP(value); ip != nil {
+		bits := net.IPv6len * 8 //nolint: gomnd
+
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+			bits = net.IPv4len * 8 //nolint: gomnd
+		}
+
+		t.Value = &net.IPNet{
+			IP:   ip,
+			Mask: net.CIDRMask(bits, bits),
+		}
+
+		return nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return fmt.Errorf("incorrect cidr %s: %w", value, err)
+	}
+
+	t.Value = network
+
+	return nil
+}
+
+func (t *TypeCIDR) Get(defaultValue *net.IPNet) *net.IPNet {
+	if t.Value == nil {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeCIDR) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeCIDR) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeCIDR) String() string {
+	if t.Value == nil {
+		return ""
+	}
+
+	return t.Value.String()
+}
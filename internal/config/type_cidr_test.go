@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeCIDRTestStruct struct {
+	Value config.TypeCIDR `json:"value"`
+}
+
+type TypeCIDRTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeCIDRTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"1.2.3.0/",
+		"1.2.3.0/33",
+		"2001:db8::/129",
+		"300.2.3.0/24",
+		"example.com",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeCIDRTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeCIDRTestSuite) TestUnmarshalOk() {
+	testData := map[string]string{
+		"1.2.3.4/24":    "1.2.3.0/24",
+		"2001:db8::/32": "2001:db8::/32",
+		"10.0.0.1":      "10.0.0.1/32",
+		"2001:db8::1":   "2001:db8::1/128",
+	}
+
+	for k, v := range testData {
+		expected := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": k,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(k, func(t *testing.T) {
+			testStruct := &typeCIDRTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, expected, testStruct.Value.Get(nil).String())
+		})
+	}
+}
+
+func (suite *TypeCIDRTestSuite) TestMarshalOk() {
+	_, network, err := net.ParseCIDR("1.2.3.0/24")
+	suite.NoError(err)
+
+	encodedJSON, err := json.Marshal(&typeCIDRTestStruct{
+		Value: config.TypeCIDR{Value: network},
+	})
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "1.2.3.0/24"}`, string(encodedJSON))
+}
+
+func (suite *TypeCIDRTestSuite) TestGet() {
+	_, network, err := net.ParseCIDR("1.2.3.0/24")
+	suite.NoError(err)
+
+	value := config.TypeCIDR{}
+	suite.Equal("1.2.3.0/24", value.Get(network).String())
+
+	suite.NoError(value.Set("10.0.0.0/8"))
+	suite.Equal("10.0.0.0/8", value.Get(network).String())
+}
+
+func TestTypeCIDR(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeCIDRTestSuite{})
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type TypeCountryCode struct {
+	Value string
+}
+
+func (t *TypeCountryCode) Set(value string) error {
+	value = strings.ToUpper(value)
+
+	if len(value) != 2 { //nolint: gomnd // ISO 3166-1 alpha-2
+		return fmt.Errorf("country code should have 2 letters (%s)", value)
+	}
+
+	for _, v := range value {
+		if v < 'A' || v > 'Z' {
+			return fmt.Errorf("country code should have only latin letters (%s)", value)
+		}
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeCountryCode) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeCountryCode) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeCountryCode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeCountryCode) String() string {
+	return t.Value
+}
@@ -0,0 +1,70 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeCountryCodeTestStruct struct {
+	Value config.TypeCountryCode `json:"value"`
+}
+
+type TypeCountryCodeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeCountryCodeTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"G",
+		"GBR",
+		"G1",
+		"ЯЯ",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeCountryCodeTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeCountryCodeTestSuite) TestUnmarshalOk() {
+	testStruct := &typeCountryCodeTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": "gb"}`), testStruct))
+	suite.Equal("GB", testStruct.Value.Value)
+}
+
+func (suite *TypeCountryCodeTestSuite) TestMarshalOk() {
+	testStruct := &typeCountryCodeTestStruct{
+		Value: config.TypeCountryCode{
+			Value: "SE",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"SE"}`, string(data))
+}
+
+func (suite *TypeCountryCodeTestSuite) TestGet() {
+	value := config.TypeCountryCode{}
+	suite.Equal("GB", value.Get("GB"))
+
+	value.Value = "SE"
+	suite.Equal("SE", value.Get("GB"))
+}
+
+func TestTypeCountryCode(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeCountryCodeTestSuite{})
+}
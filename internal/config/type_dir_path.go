@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type TypeDirPath struct {
+	Value string
+}
+
+func (t *TypeDirPath) Set(value string) error {
+	stat, err := os.Stat(value)
+	if err != nil {
+		return fmt.Errorf("incorrect directory path (%s): %w", value, err)
+	}
+
+	switch {
+	case !stat.IsDir():
+		return fmt.Errorf("value is correct path but not a directory (%s)", value)
+	case stat.Mode().Perm()&0o500 != 0o500:
+		return fmt.Errorf("value is correct directory but not readable (%s)", value)
+	}
+
+	value, err = filepath.Abs(value)
+	if err != nil {
+		return fmt.Errorf("cannot resolve absolute directory path (%s): %w", value, err)
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeDirPath) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeDirPath) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDirPath) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDirPath) String() string {
+	return t.Value
+}
@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDirPathTestStruct struct {
+	Value config.TypeDirPath `json:"value"`
+}
+
+type TypeDirPathTestSuite struct {
+	suite.Suite
+
+	directory string
+}
+
+func (suite *TypeDirPathTestSuite) SetupSuite() {
+	dir, _ := os.Getwd()
+	suite.directory = dir
+}
+
+func (suite *TypeDirPathTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		filepath.Join(suite.directory, "___"),
+		filepath.Join(suite.directory, "config.go"),
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDirPathTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDirPathTestSuite) TestUnmarshalOk() {
+	testStruct := &typeDirPathTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": "testdata"}`), testStruct))
+	suite.Equal(filepath.Join(suite.directory, "testdata"), testStruct.Value.Get(""))
+}
+
+func (suite *TypeDirPathTestSuite) TestMarshalOk() {
+	testStruct := &typeDirPathTestStruct{
+		Value: config.TypeDirPath{
+			Value: "/run/secrets/mtg",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"/run/secrets/mtg"}`, string(data))
+}
+
+func (suite *TypeDirPathTestSuite) TestGet() {
+	value := config.TypeDirPath{}
+	suite.Equal("/path", value.Get("/path"))
+
+	value.Value = "/run/secrets/mtg"
+	suite.Equal("/run/secrets/mtg", value.Get("/path"))
+}
+
+func TestTypeDirPath(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDirPathTestSuite{})
+}
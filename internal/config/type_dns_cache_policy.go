@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeDNSCachePolicyLRU states that DNS cache keeps a limited number
+	// of entries and evicts the least recently used ones.
+	TypeDNSCachePolicyLRU = "lru"
+
+	// TypeDNSCachePolicyTTL states that DNS cache evicts only expired
+	// entries.
+	TypeDNSCachePolicyTTL = "ttl"
+)
+
+type TypeDNSCachePolicy struct {
+	Value string
+}
+
+func (t *TypeDNSCachePolicy) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeDNSCachePolicyLRU, TypeDNSCachePolicyTTL:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported dns cache policy: %s", value)
+	}
+}
+
+func (t *TypeDNSCachePolicy) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeDNSCachePolicy) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDNSCachePolicy) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDNSCachePolicy) String() string {
+	return t.Value
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDNSCachePolicyTestStruct struct {
+	Value config.TypeDNSCachePolicy `json:"value"`
+}
+
+type TypeDNSCachePolicyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeDNSCachePolicyTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"lfu",
+		config.TypeDNSCachePolicyLRU + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDNSCachePolicyTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDNSCachePolicyTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeDNSCachePolicyLRU,
+		config.TypeDNSCachePolicyTTL,
+		strings.ToTitle(config.TypeDNSCachePolicyLRU),
+		strings.ToTitle(config.TypeDNSCachePolicyTTL),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeDNSCachePolicyTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeDNSCachePolicyTestSuite) TestMarshalOk() {
+	testStruct := &typeDNSCachePolicyTestStruct{
+		Value: config.TypeDNSCachePolicy{
+			Value: config.TypeDNSCachePolicyTTL,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"ttl"}`, string(data))
+}
+
+func (suite *TypeDNSCachePolicyTestSuite) TestGet() {
+	value := config.TypeDNSCachePolicy{}
+	suite.Equal(config.TypeDNSCachePolicyLRU,
+		value.Get(config.TypeDNSCachePolicyLRU))
+
+	suite.NoError(value.Set(config.TypeDNSCachePolicyTTL))
+	suite.Equal(config.TypeDNSCachePolicyTTL,
+		value.Get(config.TypeDNSCachePolicyLRU))
+}
+
+func TestTypeDNSCachePolicy(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDNSCachePolicyTestSuite{})
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeDNSResolverDOH states that hostnames are resolved with
+	// DNS-over-HTTPS.
+	TypeDNSResolverDOH = "doh"
+
+	// TypeDNSResolverDOT states that hostnames are resolved with
+	// DNS-over-TLS.
+	TypeDNSResolverDOT = "dot"
+)
+
+type TypeDNSResolver struct {
+	Value string
+}
+
+func (t *TypeDNSResolver) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeDNSResolverDOH, TypeDNSResolverDOT:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported dns resolver: %s", value)
+	}
+}
+
+func (t *TypeDNSResolver) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeDNSResolver) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDNSResolver) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDNSResolver) String() string {
+	return t.Value
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDNSResolverTestStruct struct {
+	Value config.TypeDNSResolver `json:"value"`
+}
+
+type TypeDNSResolverTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeDNSResolverTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"doq",
+		config.TypeDNSResolverDOH + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDNSResolverTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDNSResolverTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeDNSResolverDOH,
+		config.TypeDNSResolverDOT,
+		strings.ToTitle(config.TypeDNSResolverDOH),
+		strings.ToTitle(config.TypeDNSResolverDOT),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeDNSResolverTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeDNSResolverTestSuite) TestMarshalOk() {
+	testStruct := &typeDNSResolverTestStruct{
+		Value: config.TypeDNSResolver{
+			Value: config.TypeDNSResolverDOT,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"dot"}`, string(data))
+}
+
+func (suite *TypeDNSResolverTestSuite) TestGet() {
+	value := config.TypeDNSResolver{}
+	suite.Equal(config.TypeDNSResolverDOH,
+		value.Get(config.TypeDNSResolverDOH))
+
+	suite.NoError(value.Set(config.TypeDNSResolverDOT))
+	suite.Equal(config.TypeDNSResolverDOT,
+		value.Get(config.TypeDNSResolverDOH))
+}
+
+func TestTypeDNSResolver(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDNSResolverTestSuite{})
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// well-known DOH resolvers. IP addresses are used so there is no need to
+// resolve a hostname of the resolver itself.
+var typeDOHURLProviders = map[string]string{
+	"cloudflare": "https://1.1.1.1/dns-query",
+	"google":     "https://8.8.8.8/dns-query",
+	"quad9":      "https://9.9.9.9/dns-query",
+}
+
+type TypeDOHURL struct {
+	Value string
+}
+
+func (t *TypeDOHURL) Set(value string) error {
+	if providerURL, ok := typeDOHURLProviders[value]; ok {
+		value = providerURL
+	}
+
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("incorrect url (%s): %w", value, err)
+	}
+
+	if parsedURL.Scheme != "https" {
+		return fmt.Errorf("unknown schema %s (%s)", parsedURL.Scheme, value)
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("incorrect url %s", value)
+	}
+
+	t.Value = parsedURL.String()
+
+	return nil
+}
+
+func (t TypeDOHURL) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeDOHURL) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDOHURL) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDOHURL) String() string {
+	return t.Value
+}
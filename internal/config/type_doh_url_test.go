@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDOHURLTestStruct struct {
+	Value config.TypeDOHURL `json:"value"`
+}
+
+type TypeDOHURLTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeDOHURLTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"http://1.1.1.1/dns-query",
+		"https:///dns-query",
+		"h:/=",
+		"cloudfront",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDOHURLTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDOHURLTestSuite) TestUnmarshalOk() {
+	testData := map[string]string{
+		"https://dns.google/dns-query":     "https://dns.google/dns-query",
+		"https://127.0.0.1:8443/resolve?a": "https://127.0.0.1:8443/resolve?a",
+		"cloudflare":                       "https://1.1.1.1/dns-query",
+		"google":                           "https://8.8.8.8/dns-query",
+		"quad9":                            "https://9.9.9.9/dns-query",
+	}
+
+	for k, v := range testData {
+		expected := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": k,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(k, func(t *testing.T) {
+			testStruct := &typeDOHURLTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.EqualValues(t, expected, testStruct.Value.Get(""))
+		})
+	}
+}
+
+func (suite *TypeDOHURLTestSuite) TestMarshalOk() {
+	testStruct := &typeDOHURLTestStruct{
+		Value: config.TypeDOHURL{
+			Value: "https://dns.google/dns-query",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "https://dns.google/dns-query"}`, string(data))
+}
+
+func (suite *TypeDOHURLTestSuite) TestGet() {
+	value := config.TypeDOHURL{}
+	suite.Equal("https://default", value.Get("https://default"))
+
+	suite.NoError(value.Set("quad9"))
+	suite.Equal("https://9.9.9.9/dns-query", value.Get("https://default"))
+	suite.Equal("https://9.9.9.9/dns-query", value.Get(""))
+}
+
+func TestTypeDOHURL(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDOHURLTestSuite{})
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// well-known DOT resolvers. IP addresses are used so there is no need to
+// resolve a hostname of the resolver itself, certificates are verified
+// against hostnames.
+var typeDOTServerProviders = map[string]struct {
+	address    string
+	serverName string
+}{
+	"cloudflare": {"1.1.1.1:853", "cloudflare-dns.com"},
+	"google":     {"8.8.8.8:853", "dns.google"},
+	"quad9":      {"9.9.9.9:853", "dns.quad9.net"},
+}
+
+var typeDOTServerHostname = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*\.?$`)
+
+type TypeDOTServer struct {
+	Value      string
+	Address    string
+	ServerName string
+}
+
+func (t *TypeDOTServer) Set(value string) error {
+	value = strings.ToLower(value)
+
+	if provider, ok := typeDOTServerProviders[value]; ok {
+		t.Value = value
+		t.Address = provider.address
+		t.ServerName = provider.serverName
+
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		host = strings.Trim(value, "[]")
+		port = "853"
+	}
+
+	if portValue, err := strconv.ParseUint(port, 10, 16); err != nil || portValue == 0 { //nolint: gomnd
+		return fmt.Errorf("incorrect port number (%s)", value)
+	}
+
+	switch {
+	case net.ParseIP(host) != nil:
+		t.ServerName = ""
+	case typeDOTServerHostname.MatchString(host):
+		t.ServerName = strings.TrimSuffix(host, ".")
+	default:
+		return fmt.Errorf("incorrect host of dot server (%s)", value)
+	}
+
+	t.Value = value
+	t.Address = net.JoinHostPort(host, port)
+
+	return nil
+}
+
+// Get returns host:port of the server.
+func (t TypeDOTServer) Get(defaultValue string) string {
+	if t.Address == "" {
+		return defaultValue
+	}
+
+	return t.Address
+}
+
+func (t *TypeDOTServer) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDOTServer) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDOTServer) String() string {
+	return t.Value
+}
@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDOTServerTestStruct struct {
+	Value config.TypeDOTServer `json:"value"`
+}
+
+type TypeDOTServerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeDOTServerTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"9.9.9.9:0",
+		"9.9.9.9:port",
+		"dns.google:70000",
+		"tls://dns.google",
+		"dns_google",
+		"cloudfront ",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDOTServerTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDOTServerTestSuite) TestUnmarshalOk() {
+	testData := map[string][2]string{
+		"9.9.9.9":            {"9.9.9.9:853", ""},
+		"9.9.9.9:8853":       {"9.9.9.9:8853", ""},
+		"2620:fe::fe":        {"[2620:fe::fe]:853", ""},
+		"[2620:fe::fe]:8853": {"[2620:fe::fe]:8853", ""},
+		"dns.google":         {"dns.google:853", "dns.google"},
+		"DNS.Google:8853":    {"dns.google:8853", "dns.google"},
+		"cloudflare":         {"1.1.1.1:853", "cloudflare-dns.com"},
+		"google":             {"8.8.8.8:853", "dns.google"},
+		"quad9":              {"9.9.9.9:853", "dns.quad9.net"},
+	}
+
+	for k, v := range testData {
+		expected := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": k,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(k, func(t *testing.T) {
+			testStruct := &typeDOTServerTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, expected[0], testStruct.Value.Get(""))
+			assert.Equal(t, expected[1], testStruct.Value.ServerName)
+		})
+	}
+}
+
+func (suite *TypeDOTServerTestSuite) TestMarshalOk() {
+	testStruct := &typeDOTServerTestStruct{}
+	suite.NoError(testStruct.Value.Set("quad9"))
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "quad9"}`, string(data))
+}
+
+func (suite *TypeDOTServerTestSuite) TestGet() {
+	value := config.TypeDOTServer{}
+	suite.Equal("1.1.1.1:853", value.Get("1.1.1.1:853"))
+
+	suite.NoError(value.Set("quad9"))
+	suite.Equal("9.9.9.9:853", value.Get("1.1.1.1:853"))
+	suite.Equal("9.9.9.9:853", value.Get(""))
+}
+
+func TestTypeDOTServer(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDOTServerTestSuite{})
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/IceCodeNew/mtg/network"
+)
+
+type TypeDSCP struct {
+	Value uint
+}
+
+func (t *TypeDSCP) Set(value string) error {
+	dscpValue, err := strconv.ParseUint(value, 10, 8) //nolint: gomnd
+	if err != nil {
+		return fmt.Errorf("value is not uint (%s): %w", value, err)
+	}
+
+	if dscpValue > network.MaxDSCP {
+		return fmt.Errorf("dscp should be <= %d (%s)", network.MaxDSCP, value)
+	}
+
+	t.Value = uint(dscpValue)
+
+	return nil
+}
+
+func (t TypeDSCP) Get(defaultValue uint) uint {
+	if t.Value == 0 {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeDSCP) UnmarshalJSON(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeDSCP) MarshalJSON() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeDSCP) String() string {
+	return strconv.FormatUint(uint64(t.Value), 10) //nolint: gomnd
+}
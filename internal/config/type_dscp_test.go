@@ -0,0 +1,71 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeDSCPTestStruct struct {
+	Value config.TypeDSCP `json:"value"`
+}
+
+type TypeDSCPTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeDSCPTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"dscp",
+		"-1",
+		"1.5",
+		"64",
+		"300",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeDSCPTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeDSCPTestSuite) TestUnmarshalOk() {
+	testStruct := &typeDSCPTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": 46}`), testStruct))
+	suite.EqualValues(46, testStruct.Value.Value)
+}
+
+func (suite *TypeDSCPTestSuite) TestMarshalOk() {
+	testStruct := &typeDSCPTestStruct{
+		Value: config.TypeDSCP{
+			Value: 10,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":10}`, string(data))
+}
+
+func (suite *TypeDSCPTestSuite) TestGet() {
+	value := config.TypeDSCP{}
+	suite.EqualValues(10, value.Get(10))
+
+	value.Value = 46
+	suite.EqualValues(46, value.Get(10))
+}
+
+func TestTypeDSCP(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeDSCPTestSuite{})
+}
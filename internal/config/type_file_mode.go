@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type TypeFileMode struct {
+	Value os.FileMode
+}
+
+func (t *TypeFileMode) Set(value string) error {
+	modeValue, err := strconv.ParseUint(value, 8, 32) //nolint: gomnd
+	if err != nil {
+		return fmt.Errorf("value is not an octal number (%s): %w", value, err)
+	}
+
+	if modeValue == 0 || modeValue > uint64(os.ModePerm) {
+		return fmt.Errorf("file mode should be in 0001-0777 (%s)", value)
+	}
+
+	t.Value = os.FileMode(modeValue)
+
+	return nil
+}
+
+func (t TypeFileMode) Get(defaultValue os.FileMode) os.FileMode {
+	if t.Value == 0 {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeFileMode) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeFileMode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeFileMode) String() string {
+	return fmt.Sprintf("%04o", uint32(t.Value))
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeFileModeTestStruct struct {
+	Value config.TypeFileMode `json:"value"`
+}
+
+type TypeFileModeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeFileModeTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"0",
+		"0888",
+		"1777",
+		"rw-rw----",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeFileModeTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeFileModeTestSuite) TestUnmarshalOk() {
+	testData := map[string]os.FileMode{
+		"0660": 0o660,
+		"660":  0o660,
+		"0777": 0o777,
+		"0600": 0o600,
+	}
+
+	for k, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": k,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(k, func(t *testing.T) {
+			testStruct := &typeFileModeTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, value, testStruct.Value.Get(0))
+		})
+	}
+}
+
+func (suite *TypeFileModeTestSuite) TestMarshalOk() {
+	testStruct := &typeFileModeTestStruct{
+		Value: config.TypeFileMode{
+			Value: 0o660,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"0660"}`, string(data))
+}
+
+func (suite *TypeFileModeTestSuite) TestGet() {
+	value := config.TypeFileMode{}
+	suite.Equal(os.FileMode(0o600), value.Get(0o600))
+
+	suite.NoError(value.Set("0660"))
+	suite.Equal(os.FileMode(0o660), value.Get(0o600))
+}
+
+func TestTypeFileMode(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeFileModeTestSuite{})
+}
@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeFilePathTestStruct struct {
+	Value config.TypeFilePath `json:"value"`
+}
+
+type TypeFilePathTestSuite struct {
+	suite.Suite
+
+	directory string
+}
+
+func (suite *TypeFilePathTestSuite) SetupSuite() {
+	dir, _ := os.Getwd()
+	suite.directory = dir
+}
+
+func (suite *TypeFilePathTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		filepath.Join(suite.directory, "___"),
+		suite.directory,
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeFilePathTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeFilePathTestSuite) TestUnmarshalOk() {
+	testStruct := &typeFilePathTestStruct{}
+	suite.NoError(json.Unmarshal([]byte(`{"value": "config.go"}`), testStruct))
+	suite.Equal(filepath.Join(suite.directory, "config.go"), testStruct.Value.Get(""))
+}
+
+func (suite *TypeFilePathTestSuite) TestMarshalOk() {
+	testStruct := &typeFilePathTestStruct{
+		Value: config.TypeFilePath{
+			Value: "/etc/mtg.mmdb",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"/etc/mtg.mmdb"}`, string(data))
+}
+
+func (suite *TypeFilePathTestSuite) TestGet() {
+	value := config.TypeFilePath{}
+	suite.Equal("/path", value.Get("/path"))
+
+	value.Value = "/etc/mtg.mmdb"
+	suite.Equal("/etc/mtg.mmdb", value.Get("/path"))
+}
+
+func TestTypeFilePath(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeFilePathTestSuite{})
+}
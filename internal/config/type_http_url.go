@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+type TypeHTTPURL struct {
+	Value string
+}
+
+func (t *TypeHTTPURL) Set(value string) error {
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("incorrect url (%s): %w", value, err)
+	}
+
+	switch parsedURL.Scheme {
+	case "http", "https":
+	default:
+		return fmt.Errorf("unknown schema %s (%s)", parsedURL.Scheme, value)
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("incorrect url %s", value)
+	}
+
+	t.Value = parsedURL.String()
+
+	return nil
+}
+
+func (t TypeHTTPURL) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeHTTPURL) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeHTTPURL) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeHTTPURL) String() string {
+	return t.Value
+}
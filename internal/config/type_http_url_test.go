@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeHTTPURLTestStruct struct {
+	Value config.TypeHTTPURL `json:"value"`
+}
+
+type TypeHTTPURLTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeHTTPURLTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"gopher://lalala",
+		"https:///paths",
+		"h:/=",
+		"/var/lib/mtg/GeoLite2-ASN.mmdb",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeHTTPURLTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeHTTPURLTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"http://lalala",
+		"https://lalala",
+		"https://lalala/path?query=1",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeHTTPURLTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.EqualValues(t, value, testStruct.Value.Get(""))
+		})
+	}
+}
+
+func (suite *TypeHTTPURLTestSuite) TestMarshalOk() {
+	testStruct := &typeHTTPURLTestStruct{
+		Value: config.TypeHTTPURL{
+			Value: "http://some.url/with/path",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "http://some.url/with/path"}`, string(data))
+}
+
+func (suite *TypeHTTPURLTestSuite) TestGet() {
+	value := config.TypeHTTPURL{}
+	suite.Equal("http://default", value.Get("http://default"))
+
+	suite.NoError(value.Set("http://lalala.ru"))
+	suite.Equal("http://lalala.ru", value.Get("http://default"))
+	suite.Equal("http://lalala.ru", value.Get(""))
+}
+
+func TestTypeHTTPURL(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeHTTPURLTestSuite{})
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var typeInstanceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type TypeInstanceName struct {
+	Value string
+}
+
+func (t *TypeInstanceName) Set(value string) error {
+	if !typeInstanceNameRegexp.MatchString(value) {
+		return fmt.Errorf("incorrect instance name %s", value)
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeInstanceName) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeInstanceName) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeInstanceName) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeInstanceName) String() string {
+	return t.Value
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeInstanceNameTestStruct struct {
+	Value config.TypeInstanceName `json:"value"`
+}
+
+type TypeInstanceNameTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeInstanceNameTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"-mtg",
+		".mtg",
+		"hello world",
+		"hello/world",
+		"mtg:1",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeInstanceNameTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeInstanceNameTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"mtg",
+		"MTG-1",
+		"proxy01.example.com",
+		"eu_west_1",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": value,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(value, func(t *testing.T) {
+			testStruct := &typeInstanceNameTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, value, testStruct.Value.Get("lalala"))
+		})
+	}
+}
+
+func (suite *TypeInstanceNameTestSuite) TestMarshalOk() {
+	testStruct := &typeInstanceNameTestStruct{
+		Value: config.TypeInstanceName{
+			Value: "mtg-1",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "mtg-1"}`, string(data))
+}
+
+func (suite *TypeInstanceNameTestSuite) TestGet() {
+	value := config.TypeInstanceName{}
+	suite.Equal("lalala", value.Get("lalala"))
+
+	value.Value = "mtg-1"
+	suite.Equal("mtg-1", value.Get("lalala"))
+}
+
+func TestTypeInstanceName(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeInstanceNameTestSuite{})
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeIPListChainModeAny states that IP belongs to a list if any of
+	// its sources contains it.
+	TypeIPListChainModeAny = "any"
+
+	// TypeIPListChainModeAll states that IP belongs to a list only if all
+	// its sources contain it.
+	TypeIPListChainModeAll = "all"
+)
+
+type TypeIPListChainMode struct {
+	Value string
+}
+
+func (t *TypeIPListChainMode) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeIPListChainModeAny, TypeIPListChainModeAll:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported ip list chain mode: %s", value)
+	}
+}
+
+func (t *TypeIPListChainMode) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeIPListChainMode) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeIPListChainMode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeIPListChainMode) String() string {
+	return t.Value
+}
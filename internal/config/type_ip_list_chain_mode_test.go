@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeIPListChainModeTestStruct struct {
+	Value config.TypeIPListChainMode `json:"value"`
+}
+
+type TypeIPListChainModeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeIPListChainModeTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"every",
+		config.TypeIPListChainModeAny + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeIPListChainModeTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeIPListChainModeTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeIPListChainModeAny,
+		config.TypeIPListChainModeAll,
+		strings.ToTitle(config.TypeIPListChainModeAny),
+		strings.ToTitle(config.TypeIPListChainModeAll),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeIPListChainModeTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeIPListChainModeTestSuite) TestMarshalOk() {
+	testStruct := &typeIPListChainModeTestStruct{
+		Value: config.TypeIPListChainMode{
+			Value: config.TypeIPListChainModeAll,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"all"}`, string(data))
+}
+
+func (suite *TypeIPListChainModeTestSuite) TestGet() {
+	value := config.TypeIPListChainMode{}
+	suite.Equal(config.TypeIPListChainModeAny,
+		value.Get(config.TypeIPListChainModeAny))
+
+	suite.NoError(value.Set(config.TypeIPListChainModeAll))
+	suite.Equal(config.TypeIPListChainModeAll,
+		value.Get(config.TypeIPListChainModeAny))
+}
+
+func TestTypeIPListChainMode(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeIPListChainModeTestSuite{})
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeIPListPrecedenceBlockWins states that IP is served only if it is
+	// in allowlist and not in blocklist.
+	TypeIPListPrecedenceBlockWins = "block-wins"
+
+	// TypeIPListPrecedenceAllowWins states that IP is rejected only if it
+	// is in blocklist and not in allowlist.
+	TypeIPListPrecedenceAllowWins = "allow-wins"
+)
+
+type TypeIPListPrecedence struct {
+	Value string
+}
+
+func (t *TypeIPListPrecedence) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeIPListPrecedenceBlockWins, TypeIPListPrecedenceAllowWins:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported ip list precedence: %s", value)
+	}
+}
+
+func (t *TypeIPListPrecedence) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeIPListPrecedence) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeIPListPrecedence) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeIPListPrecedence) String() string {
+	return t.Value
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeIPListPrecedenceTestStruct struct {
+	Value config.TypeIPListPrecedence `json:"value"`
+}
+
+type TypeIPListPrecedenceTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeIPListPrecedenceTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"allow",
+		config.TypeIPListPrecedenceBlockWins + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeIPListPrecedenceTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeIPListPrecedenceTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeIPListPrecedenceBlockWins,
+		config.TypeIPListPrecedenceAllowWins,
+		strings.ToTitle(config.TypeIPListPrecedenceBlockWins),
+		strings.ToTitle(config.TypeIPListPrecedenceAllowWins),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeIPListPrecedenceTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeIPListPrecedenceTestSuite) TestMarshalOk() {
+	testStruct := &typeIPListPrecedenceTestStruct{
+		Value: config.TypeIPListPrecedence{
+			Value: config.TypeIPListPrecedenceAllowWins,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"allow-wins"}`, string(data))
+}
+
+func (suite *TypeIPListPrecedenceTestSuite) TestGet() {
+	value := config.TypeIPListPrecedence{}
+	suite.Equal(config.TypeIPListPrecedenceBlockWins,
+		value.Get(config.TypeIPListPrecedenceBlockWins))
+
+	suite.NoError(value.Set(config.TypeIPListPrecedenceAllowWins))
+	suite.Equal(config.TypeIPListPrecedenceAllowWins,
+		value.Get(config.TypeIPListPrecedenceBlockWins))
+}
+
+func TestTypeIPListPrecedence(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeIPListPrecedenceTestSuite{})
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeLogOutputStdout states that logs are written to stdout.
+	TypeLogOutputStdout = "stdout"
+
+	// TypeLogOutputSyslog states that logs are sent to syslog.
+	TypeLogOutputSyslog = "syslog"
+
+	// TypeLogOutputJournald states that logs are sent to systemd-journald.
+	TypeLogOutputJournald = "journald"
+)
+
+type TypeLogOutput struct {
+	Value string
+}
+
+func (t *TypeLogOutput) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeLogOutputStdout, TypeLogOutputSyslog, TypeLogOutputJournald:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported log output: %s", value)
+	}
+}
+
+func (t *TypeLogOutput) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeLogOutput) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeLogOutput) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeLogOutput) String() string {
+	return t.Value
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeLogOutputTestStruct struct {
+	Value config.TypeLogOutput `json:"value"`
+}
+
+type TypeLogOutputTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeLogOutputTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"file",
+		config.TypeLogOutputStdout + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeLogOutputTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeLogOutputTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeLogOutputStdout,
+		config.TypeLogOutputSyslog,
+		config.TypeLogOutputJournald,
+		strings.ToTitle(config.TypeLogOutputStdout),
+		strings.ToTitle(config.TypeLogOutputJournald),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeLogOutputTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeLogOutputTestSuite) TestMarshalOk() {
+	testStruct := &typeLogOutputTestStruct{
+		Value: config.TypeLogOutput{
+			Value: config.TypeLogOutputJournald,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"journald"}`, string(data))
+}
+
+func (suite *TypeLogOutputTestSuite) TestGet() {
+	value := config.TypeLogOutput{}
+	suite.Equal(config.TypeLogOutputStdout,
+		value.Get(config.TypeLogOutputStdout))
+
+	suite.NoError(value.Set(config.TypeLogOutputJournald))
+	suite.Equal(config.TypeLogOutputJournald,
+		value.Get(config.TypeLogOutputStdout))
+}
+
+func TestTypeLogOutput(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeLogOutputTestSuite{})
+}
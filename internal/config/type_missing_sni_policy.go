@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeMissingSNIPolicyAllow states that clients without SNI proceed to
+	// handshake.
+	TypeMissingSNIPolicyAllow = "allow"
+
+	// TypeMissingSNIPolicyReject states that connections of clients without
+	// SNI are closed.
+	TypeMissingSNIPolicyReject = "reject"
+
+	// TypeMissingSNIPolicyFront states that clients without SNI are routed
+	// to a fronting domain.
+	TypeMissingSNIPolicyFront = "front"
+)
+
+type TypeMissingSNIPolicy struct {
+	Value string
+}
+
+func (t *TypeMissingSNIPolicy) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeMissingSNIPolicyAllow, TypeMissingSNIPolicyReject, TypeMissingSNIPolicyFront:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported missing sni policy: %s", value)
+	}
+}
+
+func (t *TypeMissingSNIPolicy) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeMissingSNIPolicy) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeMissingSNIPolicy) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeMissingSNIPolicy) String() string {
+	return t.Value
+}
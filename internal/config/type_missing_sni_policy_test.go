@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeMissingSNIPolicyTestStruct struct {
+	Value config.TypeMissingSNIPolicy `json:"value"`
+}
+
+type TypeMissingSNIPolicyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeMissingSNIPolicyTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"fronting",
+		config.TypeMissingSNIPolicyAllow + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeMissingSNIPolicyTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeMissingSNIPolicyTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeMissingSNIPolicyAllow,
+		config.TypeMissingSNIPolicyReject,
+		config.TypeMissingSNIPolicyFront,
+		strings.ToTitle(config.TypeMissingSNIPolicyAllow),
+		strings.ToTitle(config.TypeMissingSNIPolicyReject),
+		strings.ToTitle(config.TypeMissingSNIPolicyFront),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeMissingSNIPolicyTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeMissingSNIPolicyTestSuite) TestMarshalOk() {
+	testStruct := &typeMissingSNIPolicyTestStruct{
+		Value: config.TypeMissingSNIPolicy{
+			Value: config.TypeMissingSNIPolicyReject,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"reject"}`, string(data))
+}
+
+func (suite *TypeMissingSNIPolicyTestSuite) TestGet() {
+	value := config.TypeMissingSNIPolicy{}
+	suite.Equal(config.TypeMissingSNIPolicyAllow,
+		value.Get(config.TypeMissingSNIPolicyAllow))
+
+	suite.NoError(value.Set(config.TypeMissingSNIPolicyReject))
+	suite.Equal(config.TypeMissingSNIPolicyReject,
+		value.Get(config.TypeMissingSNIPolicyAllow))
+}
+
+func TestTypeMissingSNIPolicy(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeMissingSNIPolicyTestSuite{})
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type TypeNATSSubject struct {
+	Value string
+}
+
+func (t *TypeNATSSubject) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("subject cannot be empty")
+	}
+
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("subject has to be printable and without spaces: %s", value)
+	}
+
+	for _, token := range strings.Split(value, ".") {
+		switch token {
+		case "":
+			return fmt.Errorf("subject has an empty token: %s", value)
+		case "*", ">":
+			return fmt.Errorf("subject cannot have wildcards: %s", value)
+		}
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeNATSSubject) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeNATSSubject) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeNATSSubject) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeNATSSubject) String() string {
+	return t.Value
+}
@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeNATSSubjectTestStruct struct {
+	Value config.TypeNATSSubject `json:"value"`
+}
+
+type TypeNATSSubjectTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeNATSSubjectTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"hello world",
+		"mtg.\tevents",
+		"mtg..events",
+		".mtg",
+		"mtg.",
+		"mtg.*",
+		"mtg.>",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeNATSSubjectTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeNATSSubjectTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"mtg",
+		"mtg.events",
+		"mtg-1.events_2",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": value,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(value, func(t *testing.T) {
+			testStruct := &typeNATSSubjectTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, value, testStruct.Value.Get("lalala"))
+		})
+	}
+}
+
+func (suite *TypeNATSSubjectTestSuite) TestMarshalOk() {
+	testStruct := &typeNATSSubjectTestStruct{
+		Value: config.TypeNATSSubject{
+			Value: "mtg.events",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "mtg.events"}`, string(data))
+}
+
+func (suite *TypeNATSSubjectTestSuite) TestGet() {
+	value := config.TypeNATSSubject{}
+	suite.Equal("lalala", value.Get("lalala"))
+
+	value.Value = "mtg.events"
+	suite.Equal("mtg.events", value.Get("lalala"))
+}
+
+func TestTypeNATSSubject(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeNATSSubjectTestSuite{})
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type TypeOutputFilePath struct {
+	Value string
+}
+
+func (t *TypeOutputFilePath) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("filepath cannot be empty")
+	}
+
+	value, err := filepath.Abs(value)
+	if err != nil {
+		return fmt.Errorf("cannot resolve absolute filepath (%s): %w", value, err)
+	}
+
+	if stat, err := os.Stat(value); err == nil && stat.IsDir() {
+		return fmt.Errorf("value is correct filepath but directory (%s)", value)
+	}
+
+	stat, err := os.Stat(filepath.Dir(value))
+	if err != nil {
+		return fmt.Errorf("incorrect directory of filepath (%s): %w", value, err)
+	}
+
+	if !stat.IsDir() {
+		return fmt.Errorf("parent of filepath is not a directory (%s)", value)
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeOutputFilePath) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeOutputFilePath) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeOutputFilePath) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeOutputFilePath) String() string {
+	return t.Value
+}
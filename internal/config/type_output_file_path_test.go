@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeOutputFilePathTestStruct struct {
+	Value config.TypeOutputFilePath `json:"value"`
+}
+
+type TypeOutputFilePathTestSuite struct {
+	suite.Suite
+
+	directory string
+}
+
+func (suite *TypeOutputFilePathTestSuite) SetupSuite() {
+	dir, _ := os.Getwd()
+	suite.directory = dir
+}
+
+func (suite *TypeOutputFilePathTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		suite.directory,
+		filepath.Join(suite.directory, "___", "mtg.prom"),
+		filepath.Join(suite.directory, "config.go", "mtg.prom"),
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeOutputFilePathTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeOutputFilePathTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"config.go",
+		"mtg.prom",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		suite.T().Run(value, func(t *testing.T) {
+			testStruct := &typeOutputFilePathTestStruct{}
+			data, _ := json.Marshal(map[string]string{"value": value})
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, filepath.Join(suite.directory, value), testStruct.Value.Get(""))
+		})
+	}
+}
+
+func (suite *TypeOutputFilePathTestSuite) TestMarshalOk() {
+	testStruct := &typeOutputFilePathTestStruct{
+		Value: config.TypeOutputFilePath{
+			Value: "/var/lib/mtg.prom",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"/var/lib/mtg.prom"}`, string(data))
+}
+
+func (suite *TypeOutputFilePathTestSuite) TestGet() {
+	value := config.TypeOutputFilePath{}
+	suite.Equal("/path", value.Get("/path"))
+
+	value.Value = "/var/lib/mtg.prom"
+	suite.Equal("/var/lib/mtg.prom", value.Get("/path"))
+}
+
+func TestTypeOutputFilePath(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeOutputFilePathTestSuite{})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type TypeRedisKeyPrefix struct {
+	Value string
+}
+
+func (t *TypeRedisKeyPrefix) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("redis key prefix cannot be empty")
+	}
+
+	if strings.IndexFunc(value, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) >= 0 {
+		return fmt.Errorf("redis key prefix has to be printable and without spaces")
+	}
+
+	t.Value = value
+
+	return nil
+}
+
+func (t TypeRedisKeyPrefix) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeRedisKeyPrefix) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeRedisKeyPrefix) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeRedisKeyPrefix) String() string {
+	return t.Value
+}
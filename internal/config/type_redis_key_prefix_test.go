@@ -0,0 +1,87 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeRedisKeyPrefixTestStruct struct {
+	Value config.TypeRedisKeyPrefix `json:"value"`
+}
+
+type TypeRedisKeyPrefixTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeRedisKeyPrefixTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"hello world",
+		"mtg:\n",
+		"\tmtg:",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeRedisKeyPrefixTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeRedisKeyPrefixTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"mtg:",
+		"mtg:antireplay:",
+		"a-b_c.d~e",
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": value,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(value, func(t *testing.T) {
+			testStruct := &typeRedisKeyPrefixTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, value, testStruct.Value.Get("lalala"))
+		})
+	}
+}
+
+func (suite *TypeRedisKeyPrefixTestSuite) TestMarshalOk() {
+	testStruct := &typeRedisKeyPrefixTestStruct{
+		Value: config.TypeRedisKeyPrefix{
+			Value: "mtg:",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "mtg:"}`, string(data))
+}
+
+func (suite *TypeRedisKeyPrefixTestSuite) TestGet() {
+	value := config.TypeRedisKeyPrefix{}
+	suite.Equal("lalala", value.Get("lalala"))
+
+	value.Value = "mtg:"
+	suite.Equal("mtg:", value.Get("lalala"))
+}
+
+func TestTypeRedisKeyPrefix(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeRedisKeyPrefixTestSuite{})
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type TypeSampleRate struct {
+	Value float64
+}
+
+func (t *TypeSampleRate) Set(value string) error {
+	parsedValue, err := strconv.ParseFloat(value, 64) //nolint: gomnd
+	if err != nil {
+		return fmt.Errorf("value is not a float (%s): %w", value, err)
+	}
+
+	if parsedValue <= 0.0 || parsedValue > 1.0 {
+		return fmt.Errorf("value should be 0 < x <= 1 (%s)", value)
+	}
+
+	t.Value = parsedValue
+
+	return nil
+}
+
+func (t TypeSampleRate) Get(defaultValue float64) float64 {
+	if t.Value == 0 {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeSampleRate) UnmarshalJSON(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeSampleRate) MarshalJSON() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeSampleRate) String() string {
+	return strconv.FormatFloat(t.Value, 'f', -1, 64) //nolint: gomnd
+}
@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeSampleRateTestStruct struct {
+	Value config.TypeSampleRate `json:"value"`
+}
+
+type TypeSampleRateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeSampleRateTestSuite) TestUnmarshalFail() {
+	testData := []interface{}{
+		"",
+		"0.5",
+		"some word",
+		0,
+		-0.1,
+		1.01,
+		10,
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]interface{}{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(string(data), func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeSampleRateTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeSampleRateTestSuite) TestUnmarshalOk() {
+	for _, v := range []float64{0.001, 0.5, 1} {
+		data, err := json.Marshal(map[string]float64{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		testStruct := &typeSampleRateTestStruct{}
+		suite.NoError(json.Unmarshal(data, testStruct))
+		suite.InEpsilon(v, testStruct.Value.Value, 1e-10)
+	}
+}
+
+func (suite *TypeSampleRateTestSuite) TestMarshalOk() {
+	testStruct := typeSampleRateTestStruct{
+		Value: config.TypeSampleRate{
+			Value: 0.05,
+		},
+	}
+
+	encodedJSON, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": 0.05}`, string(encodedJSON))
+}
+
+func (suite *TypeSampleRateTestSuite) TestGet() {
+	value := config.TypeSampleRate{}
+	suite.InEpsilon(0.1, value.Get(0.1), 1e-10)
+
+	value.Value = 0.5
+	suite.InEpsilon(0.5, value.Get(0.1), 1e-10)
+}
+
+func TestTypeSampleRate(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeSampleRateTestSuite{})
+}
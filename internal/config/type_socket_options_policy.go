@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeSocketOptionsPolicyReject states that a client connection is closed
+	// if socket options cannot be set on it.
+	TypeSocketOptionsPolicyReject = "reject"
+
+	// TypeSocketOptionsPolicyProceed states that a client connection is
+	// processed even if socket options cannot be set on it.
+	TypeSocketOptionsPolicyProceed = "proceed"
+)
+
+type TypeSocketOptionsPolicy struct {
+	Value string
+}
+
+func (t *TypeSocketOptionsPolicy) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeSocketOptionsPolicyReject, TypeSocketOptionsPolicyProceed:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported socket options policy: %s", value)
+	}
+}
+
+func (t *TypeSocketOptionsPolicy) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeSocketOptionsPolicy) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeSocketOptionsPolicy) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeSocketOptionsPolicy) String() string {
+	return t.Value
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeSocketOptionsPolicyTestStruct struct {
+	Value config.TypeSocketOptionsPolicy `json:"value"`
+}
+
+type TypeSocketOptionsPolicyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeSocketOptionsPolicyTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"ignore",
+		config.TypeSocketOptionsPolicyReject + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeSocketOptionsPolicyTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeSocketOptionsPolicyTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeSocketOptionsPolicyReject,
+		config.TypeSocketOptionsPolicyProceed,
+		strings.ToTitle(config.TypeSocketOptionsPolicyReject),
+		strings.ToTitle(config.TypeSocketOptionsPolicyProceed),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeSocketOptionsPolicyTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeSocketOptionsPolicyTestSuite) TestMarshalOk() {
+	testStruct := &typeSocketOptionsPolicyTestStruct{
+		Value: config.TypeSocketOptionsPolicy{
+			Value: config.TypeSocketOptionsPolicyProceed,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"proceed"}`, string(data))
+}
+
+func (suite *TypeSocketOptionsPolicyTestSuite) TestGet() {
+	value := config.TypeSocketOptionsPolicy{}
+	suite.Equal(config.TypeSocketOptionsPolicyReject,
+		value.Get(config.TypeSocketOptionsPolicyReject))
+
+	suite.NoError(value.Set(config.TypeSocketOptionsPolicyProceed))
+	suite.Equal(config.TypeSocketOptionsPolicyProceed,
+		value.Get(config.TypeSocketOptionsPolicyReject))
+}
+
+func TestTypeSocketOptionsPolicy(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeSocketOptionsPolicyTestSuite{})
+}
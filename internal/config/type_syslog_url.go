@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// TypeSyslogURL is an address of a syslog server written as URL, like
+// udp://127.0.0.1:514 or unix:///dev/log.
+type TypeSyslogURL struct {
+	Value string
+}
+
+func (t *TypeSyslogURL) Set(value string) error {
+	parsedURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("incorrect url (%s): %w", value, err)
+	}
+
+	switch parsedURL.Scheme {
+	case "udp", "tcp":
+		if _, _, err := net.SplitHostPort(parsedURL.Host); err != nil {
+			return fmt.Errorf("incorrect address %s: %w", value, err)
+		}
+	case "unix", "unixgram":
+		if parsedURL.Host != "" || parsedURL.Path == "" {
+			return fmt.Errorf("incorrect socket path %s", value)
+		}
+	default:
+		return fmt.Errorf("unknown schema %s (%s)", parsedURL.Scheme, value)
+	}
+
+	t.Value = parsedURL.String()
+
+	return nil
+}
+
+func (t TypeSyslogURL) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeSyslogURL) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeSyslogURL) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeSyslogURL) String() string {
+	return t.Value
+}
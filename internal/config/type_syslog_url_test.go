@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeSyslogURLTestStruct struct {
+	Value config.TypeSyslogURL `json:"value"`
+}
+
+type TypeSyslogURLTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeSyslogURLTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"127.0.0.1:514",
+		"http://127.0.0.1:514",
+		"udp://127.0.0.1",
+		"tcp://",
+		"unix://",
+		"unix://host/dev/log",
+		"h:/=",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeSyslogURLTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeSyslogURLTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		"udp://127.0.0.1:514",
+		"tcp://syslog.example.com:601",
+		"udp://[::1]:514",
+		"unix:///dev/log",
+		"unixgram:///var/run/syslog",
+	}
+
+	for _, v := range testData {
+		expected := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeSyslogURLTestStruct{}
+
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, expected, testStruct.Value.Get(""))
+		})
+	}
+}
+
+func (suite *TypeSyslogURLTestSuite) TestMarshalOk() {
+	testStruct := &typeSyslogURLTestStruct{
+		Value: config.TypeSyslogURL{
+			Value: "udp://127.0.0.1:514",
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value": "udp://127.0.0.1:514"}`, string(data))
+}
+
+func (suite *TypeSyslogURLTestSuite) TestGet() {
+	value := config.TypeSyslogURL{}
+	suite.Equal("udp://127.0.0.1:514", value.Get("udp://127.0.0.1:514"))
+
+	suite.NoError(value.Set("unix:///dev/log"))
+	suite.Equal("unix:///dev/log", value.Get("udp://127.0.0.1:514"))
+	suite.Equal("unix:///dev/log", value.Get(""))
+}
+
+func TestTypeSyslogURL(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeSyslogURLTestSuite{})
+}
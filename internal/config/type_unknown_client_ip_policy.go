@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TypeUnknownClientIPPolicyReject states that a connection is closed if IP
+	// address of the client cannot be determined.
+	TypeUnknownClientIPPolicyReject = "reject"
+
+	// TypeUnknownClientIPPolicyAllow states that a connection with unknown
+	// client IP address is served without IP checks.
+	TypeUnknownClientIPPolicyAllow = "allow"
+
+	// TypeUnknownClientIPPolicyFallback states that a fallback IP address is
+	// used for clients with unknown IP address.
+	TypeUnknownClientIPPolicyFallback = "fallback"
+)
+
+type TypeUnknownClientIPPolicy struct {
+	Value string
+}
+
+func (t *TypeUnknownClientIPPolicy) Set(value string) error {
+	value = strings.ToLower(value)
+
+	switch value {
+	case TypeUnknownClientIPPolicyReject, TypeUnknownClientIPPolicyAllow, TypeUnknownClientIPPolicyFallback:
+		t.Value = value
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported unknown client ip policy: %s", value)
+	}
+}
+
+func (t *TypeUnknownClientIPPolicy) Get(defaultValue string) string {
+	if t.Value == "" {
+		return defaultValue
+	}
+
+	return t.Value
+}
+
+func (t *TypeUnknownClientIPPolicy) UnmarshalText(data []byte) error {
+	return t.Set(string(data))
+}
+
+func (t TypeUnknownClientIPPolicy) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t TypeUnknownClientIPPolicy) String() string {
+	return t.Value
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type typeUnknownClientIPPolicyTestStruct struct {
+	Value config.TypeUnknownClientIPPolicy `json:"value"`
+}
+
+type TypeUnknownClientIPPolicyTestSuite struct {
+	suite.Suite
+}
+
+func (suite *TypeUnknownClientIPPolicyTestSuite) TestUnmarshalFail() {
+	testData := []string{
+		"",
+		"ignore",
+		config.TypeUnknownClientIPPolicyReject + "_",
+	}
+
+	for _, v := range testData {
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			assert.Error(t, json.Unmarshal(data, &typeUnknownClientIPPolicyTestStruct{}))
+		})
+	}
+}
+
+func (suite *TypeUnknownClientIPPolicyTestSuite) TestUnmarshalOk() {
+	testData := []string{
+		config.TypeUnknownClientIPPolicyReject,
+		config.TypeUnknownClientIPPolicyAllow,
+		config.TypeUnknownClientIPPolicyFallback,
+		strings.ToTitle(config.TypeUnknownClientIPPolicyReject),
+		strings.ToTitle(config.TypeUnknownClientIPPolicyAllow),
+	}
+
+	for _, v := range testData {
+		value := v
+
+		data, err := json.Marshal(map[string]string{
+			"value": v,
+		})
+		suite.NoError(err)
+
+		suite.T().Run(v, func(t *testing.T) {
+			testStruct := &typeUnknownClientIPPolicyTestStruct{}
+			assert.NoError(t, json.Unmarshal(data, testStruct))
+			assert.Equal(t, strings.ToLower(value), testStruct.Value.Value)
+		})
+	}
+}
+
+func (suite *TypeUnknownClientIPPolicyTestSuite) TestMarshalOk() {
+	testStruct := &typeUnknownClientIPPolicyTestStruct{
+		Value: config.TypeUnknownClientIPPolicy{
+			Value: config.TypeUnknownClientIPPolicyAllow,
+		},
+	}
+
+	data, err := json.Marshal(testStruct)
+	suite.NoError(err)
+	suite.JSONEq(`{"value":"allow"}`, string(data))
+}
+
+func (suite *TypeUnknownClientIPPolicyTestSuite) TestGet() {
+	value := config.TypeUnknownClientIPPolicy{}
+	suite.Equal(config.TypeUnknownClientIPPolicyReject,
+		value.Get(config.TypeUnknownClientIPPolicyReject))
+
+	suite.NoError(value.Set(config.TypeUnknownClientIPPolicyAllow))
+	suite.Equal(config.TypeUnknownClientIPPolicyAllow,
+		value.Get(config.TypeUnknownClientIPPolicyReject))
+}
+
+func TestTypeUnknownClientIPPolicy(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &TypeUnknownClientIPPolicyTestSuite{})
+}
@@ -39,8 +39,12 @@ func (n *EssentialsConnMock) LocalAddr() net.Addr {
 	return n.Called().Get(0).(net.Addr) //nolint: forcetypeassert
 }
 
+// RemoteAddr returns a mocked address. It can be nil: this is what unix
+// sockets return for unnamed peers.
 func (n *EssentialsConnMock) RemoteAddr() net.Addr {
-	return n.Called().Get(0).(net.Addr) //nolint: forcetypeassert
+	addr, _ := n.Called().Get(0).(net.Addr)
+
+	return addr
 }
 
 func (n *EssentialsConnMock) SetDeadline(t time.Time) error {
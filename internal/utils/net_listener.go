@@ -1,38 +1,248 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"os"
 
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/IceCodeNew/mtg/network"
 )
 
+const (
+	// SocketOptionsPolicyReject closes client connections if socket
+	// options cannot be set on them.
+	SocketOptionsPolicyReject = "reject"
+
+	// SocketOptionsPolicyProceed serves client connections even if socket
+	// options cannot be set on them.
+	SocketOptionsPolicyProceed = "proceed"
+
+	// DefaultUnixSocketMode is a default file mode of unix socket a proxy
+	// listens on: only an owner and a group can connect.
+	DefaultUnixSocketMode = 0o660
+)
+
+// ErrReusePortUnsupported is returned by [NewListener] with ReusePort on
+// platforms without SO_REUSEPORT.
+var ErrReusePortUnsupported = errors.New("SO_REUSEPORT is not supported")
+
 type Listener struct {
 	net.Listener
+
+	DSCP                uint
+	BufferSize          int
+	SocketOptionsPolicy string
+	Logger              mtglib.Logger
 }
 
+// Accept returns a next client connection with tuned socket.
+//
+// A failure to tune a socket is never returned as an error because it
+// stops a proxy. Depending on a policy, such connection is either closed
+// and the next one is accepted or returned as is.
 func (l Listener) Accept() (net.Conn, error) {
-	conn, err := l.Listener.Accept()
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err //nolint: wrapcheck
+		}
+
+		if err = l.setSocketOptions(conn); err == nil {
+			return conn, nil
+		}
+
+		logger := l.getLogger(conn)
+
+		if l.SocketOptionsPolicy == SocketOptionsPolicyProceed {
+			logger.WarningError("cannot set socket options, proceed with defaults", err)
+
+			return conn, nil
+		}
+
+		logger.WarningError("cannot set socket options, reject connection", err)
+
+		if err := conn.Close(); err != nil {
+			logger.DebugError("cannot close rejected connection", err)
+		}
+	}
+}
+
+func (l Listener) setSocketOptions(conn net.Conn) error {
+	if err := network.SetClientSocketOptions(conn, l.BufferSize); err != nil {
+		return fmt.Errorf("cannot set TCP options: %w", err)
+	}
+
+	if err := network.SetSocketDSCP(conn, l.DSCP); err != nil {
+		return fmt.Errorf("cannot set DSCP: %w", err)
+	}
+
+	return nil
+}
+
+func (l Listener) getLogger(conn net.Conn) mtglib.Logger {
+	log := l.Logger
+	if log == nil {
+		log = logger.NewNoopLogger()
+	}
+
+	if addr := conn.RemoteAddr(); addr != nil {
+		log = log.BindStr("ip", addr.String())
+	}
+
+	return log
+}
+
+// ListenerOpts defines settings of a listener made by [NewListener].
+type ListenerOpts struct {
+	// Network is a network to listen on: tcp, tcp4 or tcp6.
+	//
+	// This is an optional setting. Default is tcp.
+	Network string
+
+	// BindTo is an address to listen on.
+	//
+	// This is a mandatory setting.
+	BindTo string
+
+	// DSCP is a value of DSCP field which is set on each accepted
+	// connection.
+	//
+	// This is an optional setting. 0 means that a field is not changed.
+	DSCP uint
+
+	// BufferSize is a size of SO_SNDBUF and SO_RCVBUF of each accepted
+	// connection in bytes.
+	//
+	// This is an optional setting. 0 means that operating system defaults
+	// are kept.
+	BufferSize int
+
+	// SocketOptionsPolicy defines what to do with connections which cannot
+	// be tuned. It is either SocketOptionsPolicyReject or
+	// SocketOptionsPolicyProceed.
+	//
+	// This is an optional setting. Default is SocketOptionsPolicyReject.
+	SocketOptionsPolicy string
+
+	// Logger is used to log connections which cannot be tuned.
+	//
+	// This is an optional setting.
+	Logger mtglib.Logger
+
+	// ProxyProtocol enables reading PROXY protocol header (v1 or v2) from
+	// each accepted connection. In that case RemoteAddr of the connection
+	// is an address of the real client and connections without a valid
+	// header are rejected.
+	//
+	// This is an optional setting.
+	ProxyProtocol bool
+
+	// ReusePort sets SO_REUSEPORT on a listening socket. So many such
+	// listeners can be bound to the same address and operating system
+	// spreads incoming connections between them. Each listener can have
+	// its own accept loop.
+	//
+	// If platform does not support SO_REUSEPORT, [ErrReusePortUnsupported]
+	// is returned.
+	//
+	// This is an optional setting.
+	ReusePort bool
+}
+
+func (l ListenerOpts) getNetwork() string {
+	if l.Network == "" {
+		return "tcp"
+	}
+
+	return l.Network
+}
+
+func (l ListenerOpts) getSocketOptionsPolicy() string {
+	if l.SocketOptionsPolicy == "" {
+		return SocketOptionsPolicyReject
+	}
+
+	return l.SocketOptionsPolicy
+}
+
+// NewListener creates a listener which tunes sockets of accepted
+// connections.
+func NewListener(opts ListenerOpts) (net.Listener, error) {
+	policy := opts.getSocketOptionsPolicy()
+
+	switch policy {
+	case SocketOptionsPolicyReject, SocketOptionsPolicyProceed:
+	default:
+		return nil, fmt.Errorf("unsupported socket options policy: %s", policy)
+	}
+
+	if opts.BufferSize < 0 {
+		return nil, fmt.Errorf("incorrect buffer size %d", opts.BufferSize)
+	}
+
+	listen := net.Listen
+	if opts.ReusePort {
+		listen = listenReusePort
+	}
+
+	base, err := listen(opts.getNetwork(), opts.BindTo)
 	if err != nil {
-		return nil, err //nolint: wrapcheck
+		return nil, fmt.Errorf("cannot build a base listener: %w", err)
 	}
 
-	if err := network.SetClientSocketOptions(conn, 0); err != nil {
-		conn.Close()
+	listener := Listener{
+		Listener:            base,
+		DSCP:                opts.DSCP,
+		BufferSize:          opts.BufferSize,
+		SocketOptionsPolicy: policy,
+		Logger:              opts.Logger,
+	}
 
-		return nil, fmt.Errorf("cannot set TCP options: %w", err)
+	if opts.ProxyProtocol {
+		return newProxyProtocolListener(listener, opts.Logger, ProxyProtocolMaxPendingHeaders), nil
 	}
 
-	return conn, nil
+	return listener, nil
 }
 
-func NewListener(bindTo string, bufferSize int) (net.Listener, error) {
-	base, err := net.Listen("tcp", bindTo)
+// NewUnixListener creates a listener of Unix domain socket at a given
+// path. A stale socket file (for example, after a crash) is removed
+// before. mode is a file mode of the socket, it defines who can connect to
+// it.
+//
+// Unix connections have no client IP. So either proxyProtocol has to be
+// set or a proxy has to be configured to accept clients with unknown IP.
+// Socket options, DSCP and buffer sizes are not applicable to them.
+func NewUnixListener(path string, mode os.FileMode, logger mtglib.Logger,
+	proxyProtocol bool,
+) (net.Listener, error) {
+	if stat, err := os.Lstat(path); err == nil {
+		if stat.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and it is not a socket", path)
+		}
+
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("cannot remove stale socket %s: %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot build a base listener: %w", err)
 	}
 
-	return Listener{
-		Listener: base,
-	}, nil
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+
+		return nil, fmt.Errorf("cannot change mode of socket %s: %w", path, err)
+	}
+
+	if proxyProtocol {
+		return newProxyProtocolListener(listener, logger, ProxyProtocolMaxPendingHeaders), nil
+	}
+
+	return listener, nil
 }
@@ -0,0 +1,202 @@
+package utils_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type netListenerMock struct {
+	net.Listener
+
+	conns []net.Conn
+}
+
+func (n *netListenerMock) Accept() (net.Conn, error) {
+	if len(n.conns) == 0 {
+		return nil, net.ErrClosed
+	}
+
+	conn := n.conns[0]
+	n.conns = n.conns[1:]
+
+	return conn, nil
+}
+
+type failedCloseConn struct {
+	net.Conn
+
+	closed bool
+}
+
+func (f *failedCloseConn) Close() error {
+	f.closed = true
+
+	return errors.New("cannot close")
+}
+
+type NetListenerTestSuite struct {
+	suite.Suite
+
+	base     net.Listener
+	tcpConns []net.Conn
+}
+
+func (suite *NetListenerTestSuite) SetupTest() {
+	base, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.NoError(err)
+
+	suite.base = base
+}
+
+func (suite *NetListenerTestSuite) TearDownTest() {
+	for _, v := range suite.tcpConns {
+		v.Close()
+	}
+
+	suite.tcpConns = nil
+
+	suite.base.Close()
+}
+
+func (suite *NetListenerTestSuite) MakeTCPConn() net.Conn {
+	client, err := net.Dial("tcp", suite.base.Addr().String())
+	suite.NoError(err)
+
+	server, err := suite.base.Accept()
+	suite.NoError(err)
+
+	suite.tcpConns = append(suite.tcpConns, client, server)
+
+	return server
+}
+
+func (suite *NetListenerTestSuite) MakePipeConn() *failedCloseConn {
+	client, server := net.Pipe()
+
+	suite.tcpConns = append(suite.tcpConns, client, server)
+
+	return &failedCloseConn{Conn: server}
+}
+
+func (suite *NetListenerTestSuite) TestRejectSkipsConnection() {
+	pipeConn := suite.MakePipeConn()
+	tcpConn := suite.MakeTCPConn()
+	listener := utils.Listener{
+		Listener: &netListenerMock{
+			conns: []net.Conn{pipeConn, tcpConn},
+		},
+		SocketOptionsPolicy: utils.SocketOptionsPolicyReject,
+	}
+
+	conn, err := listener.Accept()
+	suite.NoError(err)
+	suite.Equal(tcpConn, conn)
+	suite.True(pipeConn.closed)
+
+	_, err = listener.Accept()
+	suite.ErrorIs(err, net.ErrClosed)
+}
+
+func (suite *NetListenerTestSuite) TestDefaultPolicyRejects() {
+	pipeConn := suite.MakePipeConn()
+	listener := utils.Listener{
+		Listener: &netListenerMock{
+			conns: []net.Conn{pipeConn},
+		},
+	}
+
+	_, err := listener.Accept()
+	suite.ErrorIs(err, net.ErrClosed)
+	suite.True(pipeConn.closed)
+}
+
+func (suite *NetListenerTestSuite) TestProceed() {
+	pipeConn := suite.MakePipeConn()
+	listener := utils.Listener{
+		Listener: &netListenerMock{
+			conns: []net.Conn{pipeConn},
+		},
+		SocketOptionsPolicy: utils.SocketOptionsPolicyProceed,
+	}
+
+	conn, err := listener.Accept()
+	suite.NoError(err)
+	suite.Equal(pipeConn, conn)
+	suite.False(pipeConn.closed)
+}
+
+func (suite *NetListenerTestSuite) TestUnknownPolicy() {
+	_, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:              "127.0.0.1:0",
+		SocketOptionsPolicy: "ignore",
+	})
+	suite.Error(err)
+}
+
+func (suite *NetListenerTestSuite) TestListen() {
+	listener, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:              "127.0.0.1:0",
+		SocketOptionsPolicy: utils.SocketOptionsPolicyProceed,
+	})
+	suite.NoError(err)
+
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	suite.NoError(err)
+
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	suite.NoError(err)
+	suite.NoError(conn.Close())
+}
+
+func (suite *NetListenerTestSuite) TestUnix() {
+	path := filepath.Join(suite.T().TempDir(), "mtg.sock")
+
+	stale, err := net.Listen("unix", path)
+	suite.NoError(err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := utils.NewUnixListener(path, 0o660, nil, false)
+	suite.NoError(err)
+
+	defer listener.Close()
+
+	stat, err := os.Stat(path)
+	suite.NoError(err)
+	suite.Equal(os.FileMode(0o660), stat.Mode().Perm())
+
+	client, err := net.Dial("unix", path)
+	suite.NoError(err)
+
+	defer client.Close()
+
+	conn, err := listener.Accept()
+	suite.NoError(err)
+	suite.NoError(conn.Close())
+}
+
+func (suite *NetListenerTestSuite) TestUnixNotASocket() {
+	path := filepath.Join(suite.T().TempDir(), "mtg.sock")
+	suite.NoError(os.WriteFile(path, []byte{1}, 0o600))
+
+	_, err := utils.NewUnixListener(path, 0o660, nil, false)
+	suite.Error(err)
+
+	_, err = os.Stat(path)
+	suite.NoError(err)
+}
+
+func TestNetListener(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &NetListenerTestSuite{})
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func listenReusePort(network, bindTo string) (net.Listener, error) {
+	listenConfig := net.ListenConfig{
+		Control: func(_, _ string, conn syscall.RawConn) error {
+			var err error
+
+			controlErr := conn.Control(func(fd uintptr) {
+				err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1) //nolint: nosnakecase
+			})
+			if controlErr != nil {
+				return fmt.Errorf("cannot get access to socket: %w", controlErr)
+			}
+
+			if err != nil {
+				return fmt.Errorf("cannot set SO_REUSEPORT: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	return listenConfig.Listen(context.Background(), network, bindTo) //nolint: wrapcheck
+}
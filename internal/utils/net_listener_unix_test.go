@@ -0,0 +1,161 @@
+//go:build !windows
+// +build !windows
+
+package utils_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/sys/unix"
+)
+
+type NetListenerBufferSizeTestSuite struct {
+	suite.Suite
+}
+
+func (suite *NetListenerBufferSizeTestSuite) GetBuffers(conn net.Conn) (int, int) {
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	suite.NoError(err)
+
+	var sndErr, rcvErr error
+
+	var sndBuf, rcvBuf int
+
+	rawConn.Control(func(fd uintptr) { //nolint: errcheck
+		sndBuf, sndErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+		rcvBuf, rcvErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUF)
+	})
+	suite.NoError(sndErr)
+	suite.NoError(rcvErr)
+
+	return sndBuf, rcvBuf
+}
+
+// Accept returns a pair of connected sockets: an accepted one and a
+// client one.
+func (suite *NetListenerBufferSizeTestSuite) Accept(bufferSize int) (net.Conn, net.Conn) {
+	listener, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:     "127.0.0.1:0",
+		BufferSize: bufferSize,
+	})
+	suite.Require().NoError(err)
+
+	defer listener.Close()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	suite.Require().NoError(err)
+
+	conn, err := listener.Accept()
+	suite.Require().NoError(err)
+
+	return conn, client
+}
+
+func (suite *NetListenerBufferSizeTestSuite) TestSet() {
+	conn, client := suite.Accept(4096)
+
+	defer client.Close()
+	defer conn.Close()
+
+	// some platforms double a value, some keep it as is.
+	sndBuf, rcvBuf := suite.GetBuffers(conn)
+	suite.Contains([]int{4096, 8192}, sndBuf)
+	suite.Contains([]int{4096, 8192}, rcvBuf)
+
+	// a client socket is not touched.
+	clientSndBuf, clientRcvBuf := suite.GetBuffers(client)
+	suite.NotEqual(sndBuf, clientSndBuf)
+	suite.NotEqual(rcvBuf, clientRcvBuf)
+}
+
+func (suite *NetListenerBufferSizeTestSuite) TestDefault() {
+	conn, client := suite.Accept(0)
+
+	defer client.Close()
+	defer conn.Close()
+
+	sndBuf, rcvBuf := suite.GetBuffers(conn)
+	clientSndBuf, clientRcvBuf := suite.GetBuffers(client)
+
+	suite.Equal(clientSndBuf, sndBuf)
+	suite.Equal(clientRcvBuf, rcvBuf)
+}
+
+func (suite *NetListenerBufferSizeTestSuite) TestNegative() {
+	_, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:     "127.0.0.1:0",
+		BufferSize: -1,
+	})
+	suite.Error(err)
+}
+
+func TestNetListenerBufferSize(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &NetListenerBufferSizeTestSuite{})
+}
+
+type ReusePortListenerTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ReusePortListenerTestSuite) Listen(bindTo string) net.Listener {
+	listener, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:    bindTo,
+		ReusePort: true,
+	})
+	suite.Require().NoError(err)
+
+	return listener
+}
+
+func (suite *ReusePortListenerTestSuite) TestSameAddress() {
+	first := suite.Listen("127.0.0.1:0")
+	defer first.Close()
+
+	second := suite.Listen(first.Addr().String())
+	defer second.Close()
+
+	suite.Equal(first.Addr().String(), second.Addr().String())
+
+	accepted := make(chan struct{}, 2)
+
+	for _, listener := range []net.Listener{first, second} {
+		go func(listener net.Listener) {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				conn.Close()
+				accepted <- struct{}{}
+			}
+		}(listener)
+	}
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", first.Addr().String())
+		suite.Require().NoError(err)
+		conn.Close()
+
+		<-accepted
+	}
+}
+
+func (suite *ReusePortListenerTestSuite) TestWithoutReusePort() {
+	first := suite.Listen("127.0.0.1:0")
+	defer first.Close()
+
+	_, err := utils.NewListener(utils.ListenerOpts{
+		BindTo: first.Addr().String(),
+	})
+	suite.Error(err)
+}
+
+func TestReusePortListener(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ReusePortListenerTestSuite{})
+}
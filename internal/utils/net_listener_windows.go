@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import "net"
+
+func listenReusePort(_, _ string) (net.Listener, error) {
+	return nil, ErrReusePortUnsupported
+}
@@ -0,0 +1,335 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// ProxyProtocolHeaderTimeout is a max time period load balancer has to
+// send PROXY protocol header within. Connections which have not sent it
+// are closed.
+const ProxyProtocolHeaderTimeout = 5 * time.Second
+
+// ProxyProtocolMaxPendingHeaders is a max number of connections which can
+// wait for PROXY protocol header at the same time. Connections which are
+// accepted when this limit is reached are closed.
+const ProxyProtocolMaxPendingHeaders = 1024
+
+const (
+	proxyProtocolV1MaxLength = 107
+	proxyProtocolV2HeaderLen = 16
+
+	proxyProtocolV2CommandLocal = 0x0
+	proxyProtocolV2CommandProxy = 0x1
+
+	proxyProtocolV2FamilyUnspec = 0x0
+	proxyProtocolV2FamilyInet   = 0x1
+	proxyProtocolV2FamilyInet6  = 0x2
+)
+
+var (
+	proxyProtocolV1Signature = []byte("PROXY ")
+	proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errProxyProtocolNoHeader = errors.New("no PROXY protocol header")
+)
+
+type proxyProtocolConn struct {
+	essentials.Conn
+
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// Read returns data which was buffered while header was read and then
+// reads from the connection directly.
+func (p *proxyProtocolConn) Read(b []byte) (int, error) {
+	if p.reader != nil {
+		if p.reader.Buffered() > 0 {
+			return p.reader.Read(b) //nolint: wrapcheck
+		}
+
+		p.reader = nil
+	}
+
+	return p.Conn.Read(b) //nolint: wrapcheck
+}
+
+// RemoteAddr returns an address of the client taken from PROXY protocol
+// header. If header has no address (health checks of load balancer, for
+// example), this is an address of the peer.
+func (p *proxyProtocolConn) RemoteAddr() net.Addr {
+	return p.remoteAddr
+}
+
+// readProxyProtocolHeader reads PROXY protocol header of v1 or v2 from conn.
+func readProxyProtocolHeader(conn essentials.Conn) (*proxyProtocolConn, error) {
+	reader := bufio.NewReader(conn)
+
+	signature, err := reader.Peek(len(proxyProtocolV2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read a signature: %w", err)
+	}
+
+	rv := &proxyProtocolConn{
+		Conn:       conn,
+		reader:     reader,
+		remoteAddr: conn.RemoteAddr(),
+	}
+
+	var addr net.Addr
+
+	switch {
+	case bytes.Equal(signature, proxyProtocolV2Signature):
+		addr, err = readProxyProtocolV2(reader)
+	case bytes.HasPrefix(signature, proxyProtocolV1Signature):
+		addr, err = readProxyProtocolV1(reader)
+	default:
+		return nil, errProxyProtocolNoHeader
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addr != nil {
+		rv.remoteAddr = addr
+	}
+
+	return rv, nil
+}
+
+func readProxyProtocolV1(reader *bufio.Reader) (net.Addr, error) {
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("cannot read v1 header: %w", err)
+	}
+
+	if len(line) > proxyProtocolV1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("incorrect v1 header")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 { //nolint: gomnd
+		return nil, fmt.Errorf("incorrect number of fields in v1 header: %d", len(fields))
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, fmt.Errorf("incorrect source address %q", fields[2])
+	}
+
+	switch {
+	case fields[1] == "TCP4" && ip.To4() != nil:
+	case fields[1] == "TCP6" && ip.To4() == nil:
+	default:
+		return nil, fmt.Errorf("incorrect protocol %q of %s", fields[1], ip)
+	}
+
+	port, err := strconv.ParseUint(fields[4], 10, 16) //nolint: gomnd
+	if err != nil {
+		return nil, fmt.Errorf("incorrect source port %q: %w", fields[4], err)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readProxyProtocolV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyProtocolV2HeaderLen)
+
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("cannot read v2 header: %w", err)
+	}
+
+	if version := header[12] >> 4; version != 2 { //nolint: gomnd
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("cannot read v2 addresses: %w", err)
+	}
+
+	switch command := header[12] & 0x0f; command { //nolint: gomnd
+	case proxyProtocolV2CommandLocal:
+		return nil, nil
+	case proxyProtocolV2CommandProxy:
+	default:
+		return nil, fmt.Errorf("unsupported command %d", command)
+	}
+
+	var ipLength int
+
+	switch family := header[13] >> 4; family { //nolint: gomnd
+	case proxyProtocolV2FamilyUnspec:
+		return nil, nil
+	case proxyProtocolV2FamilyInet:
+		ipLength = net.IPv4len
+	case proxyProtocolV2FamilyInet6:
+		ipLength = net.IPv6len
+	default:
+		// unix sockets have no meaningful address for us
+		return nil, nil
+	}
+
+	// source address, destination address, source port, destination port
+	if len(payload) < 2*ipLength+4 {
+		return nil, fmt.Errorf("too short address block: %d", len(payload))
+	}
+
+	ip := make(net.IP, ipLength)
+	copy(ip, payload[:ipLength])
+
+	return &net.TCPAddr{
+		IP:   ip,
+		Port: int(binary.BigEndian.Uint16(payload[2*ipLength:])),
+	}, nil
+}
+
+// proxyProtocolListener reads PROXY protocol headers of accepted
+// connections. Headers are read concurrently, so a slow peer cannot block
+// other connections. Connections without a valid header are closed. A
+// number of pending headers is limited by a size of pending channel.
+type proxyProtocolListener struct {
+	net.Listener
+
+	logger    mtglib.Logger
+	pending   chan struct{}
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	closeOnce *sync.Once
+}
+
+func (p proxyProtocolListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-p.conns:
+		return conn, nil
+	case err := <-p.errs:
+		return nil, err
+	case <-p.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (p proxyProtocolListener) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+
+	return p.Listener.Close() //nolint: wrapcheck
+}
+
+func (p proxyProtocolListener) run() {
+	for {
+		conn, err := p.Listener.Accept()
+		if err != nil {
+			select {
+			case p.errs <- err:
+			case <-p.done:
+			}
+
+			return
+		}
+
+		select {
+		case p.pending <- struct{}{}:
+			go p.handle(conn)
+		default:
+			p.getLogger(conn).Info("too many pending PROXY protocol headers, reject connection")
+			conn.Close()
+		}
+	}
+}
+
+func (p proxyProtocolListener) handle(conn net.Conn) {
+	defer func() {
+		<-p.pending
+	}()
+
+	log := p.getLogger(conn)
+
+	wrapped, err := p.readHeader(conn)
+	if err != nil {
+		log.InfoError("cannot read PROXY protocol header, reject connection", err)
+		conn.Close()
+
+		return
+	}
+
+	select {
+	case p.conns <- wrapped:
+	case <-p.done:
+		conn.Close()
+	}
+}
+
+func (p proxyProtocolListener) getLogger(conn net.Conn) mtglib.Logger {
+	// unix sockets could have no address of a peer.
+	if addr := conn.RemoteAddr(); addr != nil {
+		return p.logger.BindStr("ip", addr.String())
+	}
+
+	return p.logger
+}
+
+func (p proxyProtocolListener) readHeader(conn net.Conn) (net.Conn, error) {
+	essentialsConn, ok := conn.(essentials.Conn)
+	if !ok {
+		return nil, fmt.Errorf("unsupported connection type %T", conn)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(ProxyProtocolHeaderTimeout)); err != nil {
+		return nil, fmt.Errorf("cannot set read deadline: %w", err)
+	}
+
+	wrapped, err := readProxyProtocolHeader(essentialsConn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("cannot reset read deadline: %w", err)
+	}
+
+	return wrapped, nil
+}
+
+func newProxyProtocolListener(base net.Listener, log mtglib.Logger, maxPending int) net.Listener {
+	if log == nil {
+		log = logger.NewNoopLogger()
+	}
+
+	listener := proxyProtocolListener{
+		Listener:  base,
+		logger:    log,
+		pending:   make(chan struct{}, maxPending),
+		conns:     make(chan net.Conn),
+		errs:      make(chan error, 1),
+		done:      make(chan struct{}),
+		closeOnce: &sync.Once{},
+	}
+
+	go listener.run()
+
+	return listener
+}
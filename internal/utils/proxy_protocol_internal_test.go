@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxyProtocolListenerTestSuite struct {
+	suite.Suite
+
+	listener net.Listener
+}
+
+func (suite *ProxyProtocolListenerTestSuite) SetupTest() {
+	base, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.NoError(err)
+
+	suite.listener = newProxyProtocolListener(base, nil, 1)
+}
+
+func (suite *ProxyProtocolListenerTestSuite) TearDownTest() {
+	suite.listener.Close()
+}
+
+func (suite *ProxyProtocolListenerTestSuite) TestMaxPending() {
+	pending, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer pending.Close()
+
+	rejected, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer rejected.Close()
+
+	rejected.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err = rejected.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+
+	pending.SetReadDeadline(time.Now().Add(100 * time.Millisecond)) //nolint: errcheck
+
+	_, err = pending.Read(make([]byte, 1))
+	suite.ErrorIs(err, os.ErrDeadlineExceeded)
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyProtocolListenerTestSuite{})
+}
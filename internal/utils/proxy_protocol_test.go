@@ -0,0 +1,175 @@
+package utils_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/internal/utils"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxyProtocolTestSuite struct {
+	suite.Suite
+
+	listener net.Listener
+}
+
+func (suite *ProxyProtocolTestSuite) SetupTest() {
+	listener, err := utils.NewListener(utils.ListenerOpts{
+		BindTo:              "127.0.0.1:0",
+		SocketOptionsPolicy: utils.SocketOptionsPolicyProceed,
+		ProxyProtocol:       true,
+	})
+	suite.NoError(err)
+
+	suite.listener = listener
+}
+
+func (suite *ProxyProtocolTestSuite) TearDownTest() {
+	suite.listener.Close()
+}
+
+func (suite *ProxyProtocolTestSuite) Send(data []byte) net.Conn {
+	client, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	_, err = client.Write(data)
+	suite.NoError(err)
+
+	return client
+}
+
+func (suite *ProxyProtocolTestSuite) Accept() net.Conn {
+	accepted := make(chan net.Conn, 1)
+
+	go func() {
+		conn, err := suite.listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	select {
+	case conn := <-accepted:
+		return conn
+	case <-time.After(time.Second):
+		suite.FailNow("connection was not accepted")
+	}
+
+	return nil
+}
+
+func (suite *ProxyProtocolTestSuite) AssertPayload(conn net.Conn) {
+	data := make([]byte, 5)
+
+	_, err := io.ReadFull(conn, data)
+	suite.NoError(err)
+	suite.Equal("hello", string(data))
+}
+
+func (suite *ProxyProtocolTestSuite) TestV1() {
+	testData := map[string]string{
+		"PROXY TCP4 10.0.0.10 10.0.0.1 56324 443\r\n":      "10.0.0.10:56324",
+		"PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n": "[2001:db8::1]:56324",
+	}
+
+	for header, addr := range testData {
+		client := suite.Send([]byte(header + "hello"))
+		defer client.Close()
+
+		conn := suite.Accept()
+		defer conn.Close()
+
+		suite.Equal(addr, conn.RemoteAddr().String())
+		suite.AssertPayload(conn)
+	}
+}
+
+func (suite *ProxyProtocolTestSuite) TestV1Unknown() {
+	client := suite.Send([]byte("PROXY UNKNOWN\r\nhello"))
+	defer client.Close()
+
+	conn := suite.Accept()
+	defer conn.Close()
+
+	suite.Equal(client.LocalAddr().String(), conn.RemoteAddr().String())
+	suite.AssertPayload(conn)
+}
+
+func (suite *ProxyProtocolTestSuite) TestV2() {
+	header := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	header = append(header, 0x21, 0x11, 0, 12)
+	header = append(header, 10, 0, 0, 10, 10, 0, 0, 1)
+
+	ports := make([]byte, 4)
+	binary.BigEndian.PutUint16(ports, 56324)
+	binary.BigEndian.PutUint16(ports[2:], 443)
+
+	client := suite.Send(append(append(header, ports...), "hello"...))
+	defer client.Close()
+
+	conn := suite.Accept()
+	defer conn.Close()
+
+	_, ok := conn.(essentials.Conn)
+	suite.True(ok)
+	suite.Equal("10.0.0.10:56324", conn.RemoteAddr().String())
+	suite.AssertPayload(conn)
+}
+
+func (suite *ProxyProtocolTestSuite) TestV2Local() {
+	header := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	header = append(header, 0x20, 0x00, 0, 0)
+
+	client := suite.Send(append(header, "hello"...))
+	defer client.Close()
+
+	conn := suite.Accept()
+	defer conn.Close()
+
+	suite.Equal(client.LocalAddr().String(), conn.RemoteAddr().String())
+	suite.AssertPayload(conn)
+}
+
+func (suite *ProxyProtocolTestSuite) TestRejected() {
+	testData := []string{
+		"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		"PROXY TCP4 10.0.0.10\r\n\r\n",
+		"PROXY TCP6 10.0.0.10 10.0.0.1 56324 443\r\n",
+		"PROXY TCP4 10.0.0.10 10.0.0.1 port 443\r\n",
+	}
+
+	for _, v := range testData {
+		client := suite.Send([]byte(v))
+		defer client.Close()
+
+		client.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+		_, err := client.Read(make([]byte, 1))
+		suite.ErrorIs(err, io.EOF, v)
+	}
+
+	client := suite.Send([]byte("PROXY UNKNOWN\r\nhello"))
+	defer client.Close()
+
+	conn := suite.Accept()
+	defer conn.Close()
+
+	suite.AssertPayload(conn)
+}
+
+func (suite *ProxyProtocolTestSuite) TestClose() {
+	suite.NoError(suite.listener.Close())
+
+	_, err := suite.listener.Accept()
+	suite.ErrorIs(err, net.ErrClosed)
+}
+
+func TestProxyProtocol(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyProtocolTestSuite{})
+}
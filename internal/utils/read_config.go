@@ -7,17 +7,27 @@ import (
 	"github.com/IceCodeNew/mtg/internal/config"
 )
 
-func ReadConfig(path string) (*config.Config, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read config file: %w", err)
+func ReadConfig(paths ...string) (*config.Config, error) {
+	contents := make([][]byte, 0, len(paths))
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config file %s: %w", path, err)
+		}
+
+		contents = append(contents, content)
 	}
 
-	conf, err := config.Parse(content)
+	conf, err := config.Parse(contents...)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse config: %w", err)
 	}
 
+	if err := conf.ResolveSecret(os.Getenv(config.SecretEnvVar)); err != nil {
+		return nil, fmt.Errorf("cannot resolve secret: %w", err)
+	}
+
 	if err := conf.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
@@ -24,6 +24,15 @@ func (suite *ReadConfigTestSuite) TestReadMinimal() {
 	suite.Equal("7mqFMMq3P2Tvvt_rPx5qhmFnb29nbGUuY29t", conf.Secret.Base64())
 }
 
+func (suite *ReadConfigTestSuite) TestReadMerged() {
+	conf, err := utils.ReadConfig(
+		suite.GetConfigPath("missed-bindto.toml"),
+		suite.GetConfigPath("missed-secret.toml"))
+	suite.NoError(err)
+	suite.Equal("0.0.0.0:80", conf.BindTo.Get(""))
+	suite.Equal("7mqFMMq3P2Tvvt_rPx5qhmFnb29nbGUuY29t", conf.Secret.Base64())
+}
+
 func (suite *ReadConfigTestSuite) TestReadAbsentFile() {
 	_, err := utils.ReadConfig(suite.GetConfigPath("unknown.file"))
 	suite.Error(err)
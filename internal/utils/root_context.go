@@ -24,3 +24,23 @@ func RootContext() context.Context {
 
 	return ctx
 }
+
+// ReloadSignals returns a channel which receives SIGHUP. This signal asks
+// to reload a configuration.
+func ReloadSignals() <-chan os.Signal {
+	sigChan := make(chan os.Signal, 1)
+
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	return sigChan
+}
+
+// LogLevelSignals returns a channel which receives SIGUSR1. This signal
+// asks to change a log level.
+func LogLevelSignals() <-chan os.Signal {
+	sigChan := make(chan os.Signal, 1)
+
+	signal.Notify(sigChan, syscall.SIGUSR1)
+
+	return sigChan
+}
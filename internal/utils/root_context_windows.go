@@ -22,3 +22,15 @@ func RootContext() context.Context {
 
 	return ctx
 }
+
+// ReloadSignals returns a channel which never receives anything: there is
+// no SIGHUP on Windows.
+func ReloadSignals() <-chan os.Signal {
+	return make(chan os.Signal)
+}
+
+// LogLevelSignals returns a channel which never receives anything: there
+// is no SIGUSR1 on Windows.
+func LogLevelSignals() <-chan os.Signal {
+	return make(chan os.Signal)
+}
@@ -0,0 +1,93 @@
+package ipblocklist
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+const (
+	// ChainModeAny states that a chain contains an IP address if any of
+	// its lists contains it.
+	ChainModeAny = "any"
+
+	// ChainModeAll states that a chain contains an IP address only if all
+	// its lists contain it.
+	ChainModeAll = "all"
+)
+
+// Chain is IP blocklist which combines other lists: it is either a union or
+// an intersection of them. A chain without lists contains nothing.
+type Chain struct {
+	mode  string
+	lists []mtglib.IPBlocklist
+}
+
+// Contains checks if an IP address belongs to lists of the chain.
+func (c *Chain) Contains(ip net.IP) bool {
+	if len(c.lists) == 0 {
+		return false
+	}
+
+	if c.mode == ChainModeAll {
+		for _, v := range c.lists {
+			if !v.Contains(ip) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, v := range c.lists {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run starts update procedures of all lists and waits until all of them
+// are finished.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (c *Chain) Run(updateEach time.Duration) {
+	wg := &sync.WaitGroup{}
+	wg.Add(len(c.lists))
+
+	for _, v := range c.lists {
+		go func(list mtglib.IPBlocklist) {
+			defer wg.Done()
+
+			list.Run(updateEach)
+		}(v)
+	}
+
+	wg.Wait()
+}
+
+// Shutdown stops all lists of the chain.
+func (c *Chain) Shutdown() {
+	for _, v := range c.lists {
+		v.Shutdown()
+	}
+}
+
+// NewChain creates a new chain of lists. mode is either [ChainModeAny] or
+// [ChainModeAll].
+func NewChain(mode string, lists ...mtglib.IPBlocklist) (*Chain, error) {
+	switch mode {
+	case ChainModeAny, ChainModeAll:
+	default:
+		return nil, fmt.Errorf("unknown chain mode %q", mode)
+	}
+
+	return &Chain{
+		mode:  mode,
+		lists: lists,
+	}, nil
+}
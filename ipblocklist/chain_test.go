@@ -0,0 +1,120 @@
+package ipblocklist_test
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/stretchr/testify/suite"
+)
+
+type chainTestList struct {
+	network  *net.IPNet
+	runs     int32
+	shutdown chan struct{}
+}
+
+func (c *chainTestList) Contains(ip net.IP) bool {
+	return c.network.Contains(ip)
+}
+
+func (c *chainTestList) Run(updateEach time.Duration) {
+	atomic.AddInt32(&c.runs, 1)
+	<-c.shutdown
+}
+
+func (c *chainTestList) Shutdown() {
+	close(c.shutdown)
+}
+
+func newChainTestList(cidr string) *chainTestList {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+
+	return &chainTestList{
+		network:  network,
+		shutdown: make(chan struct{}),
+	}
+}
+
+type ChainTestSuite struct {
+	suite.Suite
+
+	wide   *chainTestList
+	narrow *chainTestList
+}
+
+func (suite *ChainTestSuite) SetupTest() {
+	suite.wide = newChainTestList("10.0.0.0/8")
+	suite.narrow = newChainTestList("10.1.0.0/16")
+}
+
+func (suite *ChainTestSuite) TestUnknownMode() {
+	_, err := ipblocklist.NewChain("xor", suite.wide)
+	suite.Error(err)
+}
+
+func (suite *ChainTestSuite) TestAny() {
+	chain, err := ipblocklist.NewChain(ipblocklist.ChainModeAny, suite.wide, suite.narrow)
+	suite.Require().NoError(err)
+
+	suite.True(chain.Contains(net.ParseIP("10.1.0.1")))
+	suite.True(chain.Contains(net.ParseIP("10.2.0.1")))
+	suite.False(chain.Contains(net.ParseIP("127.0.0.1")))
+}
+
+func (suite *ChainTestSuite) TestAll() {
+	chain, err := ipblocklist.NewChain(ipblocklist.ChainModeAll, suite.wide, suite.narrow)
+	suite.Require().NoError(err)
+
+	suite.True(chain.Contains(net.ParseIP("10.1.0.1")))
+	suite.False(chain.Contains(net.ParseIP("10.2.0.1")))
+	suite.False(chain.Contains(net.ParseIP("127.0.0.1")))
+}
+
+func (suite *ChainTestSuite) TestEmpty() {
+	for _, mode := range []string{ipblocklist.ChainModeAny, ipblocklist.ChainModeAll} {
+		chain, err := ipblocklist.NewChain(mode)
+		suite.Require().NoError(err)
+
+		suite.False(chain.Contains(net.ParseIP("10.1.0.1")))
+		chain.Run(time.Hour)
+		chain.Shutdown()
+	}
+}
+
+func (suite *ChainTestSuite) TestRunShutdown() {
+	chain, err := ipblocklist.NewChain(ipblocklist.ChainModeAny, suite.wide, suite.narrow)
+	suite.Require().NoError(err)
+
+	done := make(chan struct{})
+
+	go func() {
+		chain.Run(time.Hour)
+		close(done)
+	}()
+
+	suite.Eventually(func() bool {
+		return atomic.LoadInt32(&suite.wide.runs) == 1 && atomic.LoadInt32(&suite.narrow.runs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	chain.Shutdown()
+
+	suite.Eventually(func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ChainTestSuite{})
+}
@@ -0,0 +1,54 @@
+package ipblocklist
+
+import (
+	"context"
+	"fmt"
+)
+
+// DownloadLimiter limits a total number of simultaneous downloads across
+// all Firehol instances which share it.
+//
+// Each Firehol has its own download concurrency but if you have several
+// lists, they still update at the same time, on startup for example. A
+// shared limiter prevents such download storms.
+//
+// A nil limiter does not limit anything.
+type DownloadLimiter struct {
+	slots chan struct{}
+}
+
+// Acquire blocks until a download slot is available or a given context is
+// closed.
+func (d *DownloadLimiter) Acquire(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("cannot acquire download slot: %w", ctx.Err())
+	case d.slots <- struct{}{}:
+		return nil
+	}
+}
+
+// Release returns a slot taken by Acquire.
+func (d *DownloadLimiter) Release() {
+	if d == nil {
+		return
+	}
+
+	<-d.slots
+}
+
+// NewDownloadLimiter creates a new limiter which allows up to limit
+// simultaneous downloads. 0 means no limits and nil is returned.
+func NewDownloadLimiter(limit uint) *DownloadLimiter {
+	if limit == 0 {
+		return nil
+	}
+
+	return &DownloadLimiter{
+		slots: make(chan struct{}, limit),
+	}
+}
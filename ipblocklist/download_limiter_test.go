@@ -0,0 +1,46 @@
+package ipblocklist_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/stretchr/testify/suite"
+)
+
+type DownloadLimiterTestSuite struct {
+	suite.Suite
+}
+
+func (suite *DownloadLimiterTestSuite) TestNoLimit() {
+	limiter := ipblocklist.NewDownloadLimiter(0)
+
+	suite.Nil(limiter)
+
+	for i := 0; i < 10; i++ {
+		suite.NoError(limiter.Acquire(context.Background()))
+	}
+
+	limiter.Release()
+}
+
+func (suite *DownloadLimiterTestSuite) TestLimit() {
+	limiter := ipblocklist.NewDownloadLimiter(2)
+
+	suite.NoError(limiter.Acquire(context.Background()))
+	suite.NoError(limiter.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	suite.ErrorIs(limiter.Acquire(ctx), context.DeadlineExceeded)
+
+	limiter.Release()
+	suite.NoError(limiter.Acquire(context.Background()))
+}
+
+func TestDownloadLimiter(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DownloadLimiterTestSuite{})
+}
@@ -0,0 +1,87 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type decompressedReader struct {
+	io.Reader
+
+	closers []io.Closer
+}
+
+func (d decompressedReader) Close() error {
+	var rv error
+
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if err := d.closers[i].Close(); err != nil && rv == nil {
+			rv = err
+		}
+	}
+
+	return rv
+}
+
+// decompress wraps a reader of file content: it is decoded with a given
+// HTTP content encoding (empty for local files) and then it is
+// decompressed if it starts with gzip magic bytes. So, .gz files are
+// decompressed regardless of headers. Otherwise, content is read as is.
+func decompress(content io.ReadCloser, contentEncoding string) (io.ReadCloser, error) {
+	rv := decompressedReader{
+		Reader:  content,
+		closers: []io.Closer{content},
+	}
+
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(content)
+		if err != nil {
+			content.Close()
+
+			return nil, fmt.Errorf("cannot decode gzip content: %w", err)
+		}
+
+		rv.Reader = reader
+		rv.closers = append(rv.closers, reader)
+	case "deflate":
+		reader, err := zlib.NewReader(content)
+		if err != nil {
+			content.Close()
+
+			return nil, fmt.Errorf("cannot decode deflate content: %w", err)
+		}
+
+		rv.Reader = reader
+		rv.closers = append(rv.closers, reader)
+	default:
+		content.Close()
+
+		return nil, fmt.Errorf("unsupported content encoding %s", contentEncoding)
+	}
+
+	buffered := bufio.NewReader(rv.Reader)
+	rv.Reader = buffered
+
+	if magic, _ := buffered.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			rv.Close()
+
+			return nil, fmt.Errorf("cannot decompress gzip file: %w", err)
+		}
+
+		rv.Reader = reader
+		rv.closers = append(rv.closers, reader)
+	}
+
+	return rv, nil
+}
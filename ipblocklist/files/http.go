@@ -2,23 +2,47 @@ package files
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
 )
 
 type httpFile struct {
 	http *http.Client
 	url  string
+
+	mutex        sync.Mutex
+	etag         string
+	lastModified string
 }
 
-func (h httpFile) Open(ctx context.Context) (io.ReadCloser, error) {
+// Open downloads a file. A content which is compressed with gzip or
+// deflate encoding is decoded, gzipped files are decompressed.
+//
+// ETag and Last-Modified of a response are sent back as conditional
+// headers next time, but only if a response body was read until EOF. If
+// a server responds with 304, ErrNotModified is returned.
+func (h *httpFile) Open(ctx context.Context) (io.ReadCloser, error) {
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
 	if err != nil {
 		panic(err)
 	}
 
+	h.mutex.Lock()
+
+	if h.etag != "" {
+		request.Header.Set("If-None-Match", h.etag)
+	}
+
+	if h.lastModified != "" {
+		request.Header.Set("If-Modified-Since", h.lastModified)
+	}
+
+	h.mutex.Unlock()
+
 	response, err := h.http.Do(request)
 	if err != nil {
 		if response != nil {
@@ -29,17 +53,62 @@ func (h httpFile) Open(ctx context.Context) (io.ReadCloser, error) {
 		return nil, fmt.Errorf("cannot get url %s: %w", h.url, err)
 	}
 
+	if response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+
+		return nil, fmt.Errorf("url %s: %w", h.url, ErrNotModified)
+	}
+
 	if response.StatusCode >= http.StatusBadRequest {
+		io.Copy(io.Discard, response.Body) //nolint: errcheck
+		response.Body.Close()
+
 		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
 	}
 
-	return response.Body, nil
+	content, err := decompress(response.Body, response.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpBody{
+		ReadCloser:   content,
+		file:         h,
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}, nil
 }
 
-func (h httpFile) String() string {
+func (h *httpFile) String() string {
 	return h.url
 }
 
+// httpBody remembers validators of a response in a file when a body is
+// read completely. So, an interrupted download is never considered as
+// a cached one.
+type httpBody struct {
+	io.ReadCloser
+
+	file         *httpFile
+	etag         string
+	lastModified string
+}
+
+func (h *httpBody) Read(p []byte) (int, error) {
+	n, err := h.ReadCloser.Read(p)
+
+	if errors.Is(err, io.EOF) && h.file != nil {
+		h.file.mutex.Lock()
+		h.file.etag = h.etag
+		h.file.lastModified = h.lastModified
+		h.file.mutex.Unlock()
+
+		h.file = nil
+	}
+
+	return n, err //nolint: wrapcheck
+}
+
 // NewHTTP returns a file abstraction for HTTP/HTTPS endpoint. You also need to
 // provide a valid instance of [http.Client] to access it.
 func NewHTTP(client *http.Client, endpoint string) (File, error) {
@@ -58,7 +127,7 @@ func NewHTTP(client *http.Client, endpoint string) (File, error) {
 		return nil, fmt.Errorf("unsupported url %s", endpoint)
 	}
 
-	return httpFile{
+	return &httpFile{
 		http: client,
 		url:  endpoint,
 	}, nil
@@ -1,6 +1,8 @@
 package files_test
 
 import (
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"io"
 	"net/http"
@@ -9,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/IceCodeNew/mtg/ipblocklist/files"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -29,6 +32,39 @@ func (suite *HTTPTestSuite) SetupSuite() {
 	mux := http.NewServeMux()
 
 	mux.Handle("/", http.FileServer(http.Dir("testdata")))
+	mux.HandleFunc("/etag", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		io.WriteString(w, "Hooray!\n") //nolint: errcheck
+	})
+	mux.HandleFunc("/encoded/", func(w http.ResponseWriter, req *http.Request) {
+		var writer io.WriteCloser
+
+		encoding := strings.TrimPrefix(req.URL.Path, "/encoded/")
+
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(w)
+		case "deflate":
+			writer = zlib.NewWriter(w)
+		default:
+			http.NotFound(w, req)
+
+			return
+		}
+
+		w.Header().Set("Content-Encoding", encoding)
+
+		defer writer.Close()
+
+		io.WriteString(writer, "Hooray!\n") //nolint: errcheck
+	})
 
 	suite.httpServer = httptest.NewServer(mux)
 	suite.httpClient = suite.httpServer.Client()
@@ -84,6 +120,105 @@ func (suite *HTTPTestSuite) TestOk() {
 	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
 }
 
+func (suite *HTTPTestSuite) TestGzippedFile() {
+	file, err := suite.makeFile("readable.gz")
+	suite.NoError(err)
+
+	readCloser, err := file.Open(suite.ctx)
+	suite.NoError(err)
+
+	defer readCloser.Close()
+
+	data, err := io.ReadAll(readCloser)
+	suite.NoError(err)
+	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
+}
+
+func (suite *HTTPTestSuite) TestContentEncoding() {
+	clients := map[string]*http.Client{
+		"default": suite.httpClient,
+		"no-transport-decompression": {
+			Transport: &http.Transport{
+				DisableCompression: true,
+			},
+		},
+	}
+
+	for clientName, cl := range clients {
+		client := cl
+
+		for _, v := range []string{"gzip", "deflate"} {
+			encoding := v
+
+			suite.T().Run(clientName+"/"+encoding, func(t *testing.T) {
+				file, err := files.NewHTTP(client, suite.httpServer.URL+"/encoded/"+encoding)
+				assert.NoError(t, err)
+
+				readCloser, err := file.Open(suite.ctx)
+				assert.NoError(t, err)
+
+				defer readCloser.Close()
+
+				data, err := io.ReadAll(readCloser)
+				assert.NoError(t, err)
+				assert.Equal(t, "Hooray!", strings.TrimSpace(string(data)))
+			})
+		}
+	}
+}
+
+func (suite *HTTPTestSuite) TestETag() {
+	file, err := suite.makeFile("etag")
+	suite.NoError(err)
+
+	readCloser, err := file.Open(suite.ctx)
+	suite.NoError(err)
+
+	data, err := io.ReadAll(readCloser)
+	suite.NoError(err)
+	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
+	suite.NoError(readCloser.Close())
+
+	_, err = file.Open(suite.ctx)
+	suite.ErrorIs(err, files.ErrNotModified)
+}
+
+func (suite *HTTPTestSuite) TestLastModified() {
+	file, err := suite.makeFile("readable")
+	suite.NoError(err)
+
+	readCloser, err := file.Open(suite.ctx)
+	suite.NoError(err)
+
+	_, err = io.ReadAll(readCloser)
+	suite.NoError(err)
+	suite.NoError(readCloser.Close())
+
+	_, err = file.Open(suite.ctx)
+	suite.ErrorIs(err, files.ErrNotModified)
+}
+
+func (suite *HTTPTestSuite) TestPartialReadIsNotCached() {
+	file, err := suite.makeFile("etag")
+	suite.NoError(err)
+
+	readCloser, err := file.Open(suite.ctx)
+	suite.NoError(err)
+
+	_, err = readCloser.Read(make([]byte, 1))
+	suite.NoError(err)
+	suite.NoError(readCloser.Close())
+
+	readCloser, err = file.Open(suite.ctx)
+	suite.NoError(err)
+
+	defer readCloser.Close()
+
+	data, err := io.ReadAll(readCloser)
+	suite.NoError(err)
+	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
+}
+
 func TestHTTP(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &HTTPTestSuite{})
@@ -10,6 +10,11 @@ import (
 // incorrectly.
 var ErrBadHTTPClient = errors.New("incorrect http client")
 
+// ErrNotModified is returned by [File.Open] if a file has not been changed
+// since the last time it was read completely. A caller should use content
+// it has read before.
+var ErrNotModified = errors.New("file is not modified")
+
 // File is an abstraction for a entity that can be opened in some context.
 type File interface {
 	// Open returns an readable entity for a file. It is important to not forget
@@ -11,8 +11,14 @@ type localFile struct {
 	path string
 }
 
+// Open opens a file. Gzipped files are decompressed.
 func (l localFile) Open(ctx context.Context) (io.ReadCloser, error) {
-	return os.Open(l.path) //nolint: wrapcheck
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	return decompress(file, "")
 }
 
 func (l localFile) String() string {
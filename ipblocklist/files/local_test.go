@@ -49,6 +49,21 @@ func (suite *LocalTestSuite) TestOk() {
 	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
 }
 
+func (suite *LocalTestSuite) TestGzipped() {
+	file, err := files.NewLocal(suite.getLocalFile("readable.gz"))
+	suite.NoError(err)
+
+	reader, err := file.Open(context.Background())
+	suite.NoError(err)
+
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	suite.NoError(err)
+
+	suite.Equal("Hooray!", strings.TrimSpace(string(data)))
+}
+
 func TestLocal(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &LocalTestSuite{})
@@ -0,0 +1,126 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnixSocketTimeout is a max time period to read a list from a unix socket
+// if a context of the caller has no deadline.
+const UnixSocketTimeout = 10 * time.Second
+
+type unixSocketFile struct {
+	path string
+
+	mutex    sync.Mutex
+	received bool
+	lastGood []byte
+}
+
+func (u *unixSocketFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	data, err := u.read(ctx)
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		u.received = true
+		u.lastGood = data
+	case !u.received:
+		return nil, fmt.Errorf("cannot read a list from %s: %w", u.path, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(u.lastGood)), nil
+}
+
+func (u *unixSocketFile) read(ctx context.Context) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, UnixSocketTimeout)
+		defer cancel()
+	}
+
+	dialer := net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, "unix", u.path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial: %w", err)
+	}
+
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline) //nolint: errcheck
+
+	buf := &bytes.Buffer{}
+	scanner := bufio.NewScanner(conn)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// an empty line ends a list: a sender may keep connection open
+		if line == "" {
+			return buf.Bytes(), nil
+		}
+
+		if err := validateUnixSocketLine(line); err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (u *unixSocketFile) String() string {
+	return "unix:" + u.path
+}
+
+func validateUnixSocketLine(line string) error {
+	if idx := strings.IndexByte(line, '#'); idx >= 0 {
+		line = strings.TrimSpace(line[:idx])
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	if _, _, err := net.ParseCIDR(line); err == nil {
+		return nil
+	}
+
+	if net.ParseIP(line) != nil {
+		return nil
+	}
+
+	return fmt.Errorf("incorrect network %s", line)
+}
+
+// NewUnixSocket returns an openable File which reads a list from a unix
+// stream socket. It is intended for sidecars which compute a list
+// dynamically.
+//
+// Each Open connects to a socket and reads newline-delimited IPv4 or IPv6
+// networks (or single addresses) until EOF or an empty line. If socket is
+// unavailable or sends an invalid list, the last good list is returned
+// instead. Only if nothing has been received so far, Open returns an error.
+func NewUnixSocket(path string) File {
+	return &unixSocketFile{
+		path: path,
+	}
+}
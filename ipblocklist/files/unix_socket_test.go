@@ -0,0 +1,117 @@
+package files_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/ipblocklist/files"
+	"github.com/stretchr/testify/suite"
+)
+
+type UnixSocketTestSuite struct {
+	suite.Suite
+
+	path     string
+	listener net.Listener
+	payload  atomic.Value
+}
+
+func (suite *UnixSocketTestSuite) SetupTest() {
+	suite.path = filepath.Join(suite.T().TempDir(), "list.sock")
+	suite.payload.Store("10.0.0.0/8\n2001:db8::/32 # comment\n192.168.1.1\n")
+
+	listener, err := net.Listen("unix", suite.path)
+	suite.NoError(err)
+
+	suite.listener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Write([]byte(suite.payload.Load().(string))) //nolint: errcheck
+			conn.Close()
+		}
+	}()
+}
+
+func (suite *UnixSocketTestSuite) TearDownTest() {
+	suite.listener.Close()
+}
+
+func (suite *UnixSocketTestSuite) Read(file files.File) (string, error) {
+	reader, err := file.Open(context.Background())
+	if err != nil {
+		return "", err //nolint: wrapcheck
+	}
+
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	suite.NoError(err)
+
+	return string(data), nil
+}
+
+func (suite *UnixSocketTestSuite) TestOk() {
+	data, err := suite.Read(files.NewUnixSocket(suite.path))
+	suite.NoError(err)
+	suite.Equal(suite.payload.Load(), data)
+}
+
+func (suite *UnixSocketTestSuite) TestEmptyLineEndsList() {
+	suite.payload.Store("10.0.0.0/8\n\n2001:db8::/32\n")
+
+	data, err := suite.Read(files.NewUnixSocket(suite.path))
+	suite.NoError(err)
+	suite.Equal("10.0.0.0/8\n", data)
+}
+
+func (suite *UnixSocketTestSuite) TestNeverAvailable() {
+	_, err := suite.Read(files.NewUnixSocket(suite.path + ".absent"))
+	suite.Error(err)
+}
+
+func (suite *UnixSocketTestSuite) TestLastGoodIfUnavailable() {
+	file := files.NewUnixSocket(suite.path)
+
+	_, err := suite.Read(file)
+	suite.NoError(err)
+
+	suite.listener.Close()
+
+	data, err := suite.Read(file)
+	suite.NoError(err)
+	suite.Equal(suite.payload.Load(), data)
+}
+
+func (suite *UnixSocketTestSuite) TestLastGoodIfInvalid() {
+	file := files.NewUnixSocket(suite.path)
+	expected := suite.payload.Load()
+
+	_, err := suite.Read(file)
+	suite.NoError(err)
+
+	suite.payload.Store("10.0.0.0/8\nnot-a-network\n")
+
+	data, err := suite.Read(file)
+	suite.NoError(err)
+	suite.Equal(expected, data)
+}
+
+func (suite *UnixSocketTestSuite) TestString() {
+	suite.True(strings.HasPrefix(files.NewUnixSocket(suite.path).String(), "unix:"))
+}
+
+func TestUnixSocket(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &UnixSocketTestSuite{})
+}
@@ -3,11 +3,14 @@ package ipblocklist
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IceCodeNew/mtg/ipblocklist/files"
@@ -27,6 +30,12 @@ var (
 // execute when ip list is updated.
 type FireholUpdateCallback func(context.Context, int)
 
+// FireholFailureCallback defines a signature of the callback that has to be
+// executed when some files of ip list cannot be read during update. It is
+// executed before [FireholUpdateCallback] of the same update with the
+// first error.
+type FireholFailureCallback func(context.Context, error)
+
 // Firehol is [mtglib.IPBlocklist] which uses lists from FireHOL:
 // https://iplists.firehol.org/
 //
@@ -40,18 +49,29 @@ type FireholUpdateCallback func(context.Context, int)
 //	# to ignore
 //	127.0.0.1   # you can specify an IP
 //	10.0.0.0/8  # or cidr
+//
+// Reloads are atomic. A new list is built aside from the active one and
+// replaces it only when all files are processed. Each Contains call works
+// with a single complete snapshot: a call which has started before a swap
+// is checked against the old list, any call after that is checked against
+// the new one. Contains never sees a list which is being built.
 type Firehol struct {
-	ctx         context.Context
-	ctxCancel   context.CancelFunc
-	logger      mtglib.Logger
-	updateMutex sync.RWMutex
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	logger    mtglib.Logger
 
-	updateCallback FireholUpdateCallback
-	ranger         cidranger.Ranger
+	updateCallback  FireholUpdateCallback
+	failureCallback FireholFailureCallback
+	ranger          atomic.Value
 
 	blocklists []files.File
+	lastGood   [][]net.IPNet
+
+	downloadAttempts uint
+	downloadBackoff  time.Duration
 
-	workerPool *ants.Pool
+	workerPool      *ants.Pool
+	downloadLimiter *DownloadLimiter
 }
 
 // Shutdown stop a background update process.
@@ -65,10 +85,9 @@ func (f *Firehol) Contains(ip net.IP) bool {
 		return true
 	}
 
-	f.updateMutex.RLock()
-	defer f.updateMutex.RUnlock()
+	ranger := f.ranger.Load().(cidranger.Ranger) //nolint: forcetypeassert
 
-	ok, err := f.ranger.Contains(ip)
+	ok, err := ranger.Contains(ip)
 	if err != nil {
 		f.logger.BindStr("ip", ip.String()).DebugError("Cannot check if ip is present", err)
 	}
@@ -117,33 +136,57 @@ func (f *Firehol) update() {
 	mutex := &sync.Mutex{}
 	ranger := cidranger.NewPCTrieRanger()
 
-	for _, v := range f.blocklists {
-		go func(file files.File) {
+	var firstErr error
+
+	for i, v := range f.blocklists {
+		go func(idx int, file files.File) {
 			defer wg.Done()
 
 			logger := f.logger.BindStr("filename", file.String())
 
-			fileContent, err := file.Open(ctx)
-			if err != nil {
-				logger.WarningError("update has failed", err)
+			networks, err := f.updateFile(ctx, logger, file)
+
+			switch {
+			case err == nil:
+				f.lastGood[idx] = networks
+			case errors.Is(err, files.ErrNotModified):
+				networks = f.lastGood[idx]
+				err = nil
 
-				return
+				logger.BindInt("size", len(networks)).Debug("file is not modified, previous version is used")
+			default:
+				networks = f.lastGood[idx]
+
+				logger.BindInt("size", len(networks)).WarningError("update has failed, previous version is used", err)
 			}
 
-			defer fileContent.Close()
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("cannot update from %s: %w", file.String(), err)
+			}
 
-			if err := f.updateFromFile(mutex, ranger, bufio.NewScanner(fileContent)); err != nil {
-				logger.WarningError("update has failed", err)
+			for _, ipnet := range networks {
+				if err := ranger.Insert(cidranger.NewBasicRangerEntry(ipnet)); err != nil {
+					logger.BindStr("network", ipnet.String()).WarningError("cannot insert a network", err)
+				}
 			}
-		}(v)
+		}(i, v)
 	}
 
 	wg.Wait()
 
-	f.updateMutex.Lock()
-	defer f.updateMutex.Unlock()
+	if ctx.Err() != nil {
+		// update was cancelled by shutdown: some files could be not read.
+		return
+	}
+
+	f.ranger.Store(ranger)
 
-	f.ranger = ranger
+	if firstErr != nil && f.failureCallback != nil {
+		f.failureCallback(ctx, firstErr)
+	}
 
 	if f.updateCallback != nil {
 		f.updateCallback(ctx, ranger.Len())
@@ -152,10 +195,65 @@ func (f *Firehol) update() {
 	f.logger.Info("ip list was updated")
 }
 
-func (f *Firehol) updateFromFile(mutex sync.Locker,
-	ranger cidranger.Ranger,
-	scanner *bufio.Scanner,
-) error {
+// updateFile reads a file with retries. Each next pause between attempts
+// is twice longer than a previous one.
+func (f *Firehol) updateFile(ctx context.Context, logger mtglib.Logger, file files.File) ([]net.IPNet, error) {
+	backoff := f.downloadBackoff
+
+	var err error
+
+	for attempt := uint(0); attempt < f.downloadAttempts; attempt++ {
+		if attempt > 0 {
+			logger.
+				BindInt("attempt", int(attempt)+1).
+				BindStr("backoff", backoff.String()).
+				InfoError("cannot read a file, retry", err)
+
+			timer := time.NewTimer(backoff)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return nil, fmt.Errorf("update was cancelled: %w", ctx.Err())
+			case <-timer.C:
+			}
+
+			backoff *= 2
+		}
+
+		var networks []net.IPNet
+
+		networks, err = f.readFile(ctx, file)
+
+		switch {
+		case err == nil:
+			return networks, nil
+		case errors.Is(err, files.ErrNotModified):
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("cannot read a file after %d attempts: %w", f.downloadAttempts, err)
+}
+
+func (f *Firehol) readFile(ctx context.Context, file files.File) ([]net.IPNet, error) {
+	if err := f.downloadLimiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	defer f.downloadLimiter.Release()
+
+	fileContent, err := file.Open(ctx)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	defer fileContent.Close()
+
+	networks := []net.IPNet{}
+	scanner := bufio.NewScanner(fileContent)
+
 	for scanner.Scan() {
 		text := scanner.Text()
 		text = fireholRegexpComment.ReplaceAllLiteralString(text, "")
@@ -167,23 +265,17 @@ func (f *Firehol) updateFromFile(mutex sync.Locker,
 
 		ipnet, err := f.updateParseLine(text)
 		if err != nil {
-			return fmt.Errorf("cannot parse a line: %w", err)
+			return nil, fmt.Errorf("cannot parse a line: %w", err)
 		}
 
-		mutex.Lock()
-		err = ranger.Insert(cidranger.NewBasicRangerEntry(*ipnet))
-		mutex.Unlock()
-
-		if err != nil {
-			return fmt.Errorf("cannot insert %v into ranger: %w", ipnet, err)
-		}
+		networks = append(networks, *ipnet)
 	}
 
 	if scanner.Err() != nil {
-		return fmt.Errorf("cannot parse a file: %w", scanner.Err())
+		return nil, fmt.Errorf("cannot parse a file: %w", scanner.Err())
 	}
 
-	return nil
+	return networks, nil
 }
 
 func (f *Firehol) updateParseLine(text string) (*net.IPNet, error) {
@@ -208,6 +300,85 @@ func (f *Firehol) updateParseLine(text string) (*net.IPNet, error) {
 	}, nil
 }
 
+// FireholOpts defines settings of a FireHOL IP blocklist made by
+// [NewFireholWithOpts].
+type FireholOpts struct {
+	// Logger is used to report updates and errors.
+	//
+	// This is a mandatory setting.
+	Logger mtglib.Logger
+
+	// Files are sources of a list. Files for URLs and local paths can be
+	// made with [NewFireholFiles] and extended with other sources.
+	//
+	// This is a mandatory setting.
+	Files []files.File
+
+	// DownloadConcurrency is a max number of files which are read in
+	// parallel during a single update.
+	//
+	// This is an optional setting. Default is
+	// [DefaultFireholDownloadConcurrency].
+	DownloadConcurrency uint
+
+	// DownloadLimiter limits downloads and can be shared with other
+	// instances.
+	//
+	// This is an optional setting. Default is no limits.
+	DownloadLimiter *DownloadLimiter
+
+	// DownloadAttempts is a max number of reads of each file per update.
+	// If a file cannot be read in all attempts, its networks from the last
+	// successful update are used. So, a list is never emptied by a
+	// temporary failure of upstream.
+	//
+	// This is an optional setting. Default is
+	// [DefaultFireholDownloadAttempts].
+	DownloadAttempts uint
+
+	// DownloadBackoff is a pause between the first and the second attempts
+	// to read a file. Each next pause is twice longer.
+	//
+	// This is an optional setting. Default is
+	// [DefaultFireholDownloadBackoff].
+	DownloadBackoff time.Duration
+
+	// UpdateCallback is executed after each update of a list.
+	//
+	// This is an optional setting.
+	UpdateCallback FireholUpdateCallback
+
+	// FailureCallback is executed if some files cannot be read during
+	// update.
+	//
+	// This is an optional setting.
+	FailureCallback FireholFailureCallback
+}
+
+func (f FireholOpts) getDownloadConcurrency() uint {
+	if f.DownloadConcurrency == 0 {
+		return DefaultFireholDownloadConcurrency
+	}
+
+	return f.DownloadConcurrency
+}
+
+func (f FireholOpts) getDownloadAttempts() uint {
+	if f.DownloadAttempts == 0 {
+		return DefaultFireholDownloadAttempts
+	}
+
+	return f.DownloadAttempts
+}
+
+func (f FireholOpts) getDownloadBackoff() time.Duration {
+	if f.DownloadBackoff == 0 {
+		return DefaultFireholDownloadBackoff
+	}
+
+	return f.DownloadBackoff
+}
+
 // NewFirehol creates a new instance of FireHOL IP blocklist.
 //
 // This method does not start an update process so please execute Run when it
@@ -218,9 +389,28 @@ func NewFirehol(logger mtglib.Logger, network mtglib.Network,
 	localFiles []string,
 	updateCallback FireholUpdateCallback,
 ) (*Firehol, error) {
+	blocklists, err := NewFireholFiles(network, urls, localFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFireholFromFiles(logger, downloadConcurrency, blocklists, updateCallback)
+}
+
+// NewFireholFiles returns files of blocklist for given URLs and local
+// files. Local files which are unix sockets are read with
+// [files.NewUnixSocket]. These files can be extended with other sources
+// and passed to [NewFireholWithOpts].
+func NewFireholFiles(network mtglib.Network, urls, localFiles []string) ([]files.File, error) {
 	blocklists := []files.File{}
 
 	for _, v := range localFiles {
+		if stat, err := os.Stat(v); err == nil && stat.Mode()&os.ModeSocket != 0 {
+			blocklists = append(blocklists, files.NewUnixSocket(v))
+
+			continue
+		}
+
 		file, err := files.NewLocal(v)
 		if err != nil {
 			return nil, fmt.Errorf("cannot create a local file %s: %w", v, err)
@@ -240,7 +430,7 @@ func NewFirehol(logger mtglib.Logger, network mtglib.Network,
 		blocklists = append(blocklists, file)
 	}
 
-	return NewFireholFromFiles(logger, downloadConcurrency, blocklists, updateCallback)
+	return blocklists, nil
 }
 
 // NewFirehol creates a new instance of FireHOL IP blocklist.
@@ -251,20 +441,38 @@ func NewFireholFromFiles(logger mtglib.Logger,
 	blocklists []files.File,
 	updateCallback FireholUpdateCallback,
 ) (*Firehol, error) {
-	if downloadConcurrency == 0 {
-		downloadConcurrency = DefaultFireholDownloadConcurrency
-	}
+	return NewFireholWithOpts(FireholOpts{
+		Logger:              logger,
+		Files:               blocklists,
+		DownloadConcurrency: downloadConcurrency,
+		UpdateCallback:      updateCallback,
+	})
+}
 
-	workerPool, _ := ants.NewPool(int(downloadConcurrency))
+// NewFireholWithOpts creates a new instance of FireHOL IP blocklist with
+// given settings.
+//
+// This method does not start an update process so please execute Run when it
+// is necessary.
+func NewFireholWithOpts(opts FireholOpts) (*Firehol, error) {
+	workerPool, _ := ants.NewPool(int(opts.getDownloadConcurrency()))
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Firehol{
-		ctx:            ctx,
-		ctxCancel:      cancel,
-		logger:         logger.Named("firehol"),
-		ranger:         cidranger.NewPCTrieRanger(),
-		workerPool:     workerPool,
-		blocklists:     blocklists,
-		updateCallback: updateCallback,
-	}, nil
+	firehol := &Firehol{
+		ctx:              ctx,
+		ctxCancel:        cancel,
+		logger:           opts.Logger.Named("firehol"),
+		workerPool:       workerPool,
+		blocklists:       opts.Files,
+		lastGood:         make([][]net.IPNet, len(opts.Files)),
+		updateCallback:   opts.UpdateCallback,
+		failureCallback:  opts.FailureCallback,
+		downloadLimiter:  opts.DownloadLimiter,
+		downloadAttempts: opts.getDownloadAttempts(),
+		downloadBackoff:  opts.getDownloadBackoff(),
+	}
+
+	firehol.ranger.Store(cidranger.NewPCTrieRanger())
+
+	return firehol, nil
 }
@@ -1,17 +1,22 @@
 package ipblocklist_test
 
 import (
+	"context"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/IceCodeNew/mtg/internal/testlib"
 	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/IceCodeNew/mtg/ipblocklist/files"
 	"github.com/IceCodeNew/mtg/logger"
 	"github.com/IceCodeNew/mtg/network"
 	"github.com/jarcoal/httpmock"
@@ -19,6 +24,102 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
+type fireholSlowFile struct {
+	active    *int32
+	maxActive *int32
+}
+
+func (f fireholSlowFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	current := atomic.AddInt32(f.active, 1)
+	defer atomic.AddInt32(f.active, -1)
+
+	for {
+		seen := atomic.LoadInt32(f.maxActive)
+		if current <= seen || atomic.CompareAndSwapInt32(f.maxActive, seen, current) {
+			break
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	return io.NopCloser(strings.NewReader("10.0.0.0/8")), nil
+}
+
+func (f fireholSlowFile) String() string {
+	return "slow"
+}
+
+type fireholGatedReader struct {
+	parts   []string
+	reached chan struct{}
+	release chan struct{}
+}
+
+func (f *fireholGatedReader) Read(p []byte) (int, error) {
+	if len(f.parts) == 0 {
+		return 0, io.EOF
+	}
+
+	if len(f.parts) == 1 && f.reached != nil {
+		close(f.reached)
+		f.reached = nil
+		<-f.release
+	}
+
+	n := copy(p, f.parts[0])
+	f.parts = f.parts[1:]
+
+	return n, nil
+}
+
+type fireholGatedFile struct {
+	reader *fireholGatedReader
+}
+
+func (f fireholGatedFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(f.reader), nil
+}
+
+func (f fireholGatedFile) String() string {
+	return "gated"
+}
+
+// fireholFlakyFile fails while its failures are left.
+type fireholFlakyFile struct {
+	failures *int32
+	opens    *int32
+}
+
+func (f fireholFlakyFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	atomic.AddInt32(f.opens, 1)
+
+	if atomic.AddInt32(f.failures, -1) >= 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return io.NopCloser(strings.NewReader("10.0.0.0/8")), nil
+}
+
+func (f fireholFlakyFile) String() string {
+	return "flaky"
+}
+
+type fireholNotModifiedFile struct {
+	opens *int32
+}
+
+func (f fireholNotModifiedFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	if atomic.AddInt32(f.opens, 1) > 1 {
+		return nil, files.ErrNotModified
+	}
+
+	return io.NopCloser(strings.NewReader("10.0.0.0/8")), nil
+}
+
+func (f fireholNotModifiedFile) String() string {
+	return "not-modified"
+}
+
 type FireholTestSuite struct {
 	suite.Suite
 
@@ -102,6 +203,236 @@ func (suite *FireholTestSuite) TestLocalOk() {
 	time.Sleep(500 * time.Millisecond)
 }
 
+func (suite *FireholTestSuite) TestLocalGzipped() {
+	plain, err := ipblocklist.NewFirehol(logger.NewNoopLogger(),
+		suite.networkMock, 2,
+		nil, []string{filepath.Join("testdata", "good_ipset.ipset")},
+		nil)
+	suite.NoError(err)
+
+	gzipped, err := ipblocklist.NewFirehol(logger.NewNoopLogger(),
+		suite.networkMock, 2,
+		nil, []string{filepath.Join("testdata", "good_ipset.ipset.gz")},
+		nil)
+	suite.NoError(err)
+
+	go plain.Run(time.Hour)
+	go gzipped.Run(time.Hour)
+
+	time.Sleep(500 * time.Millisecond)
+
+	addresses := []string{
+		"10.0.0.10",
+		"10.0.0.11",
+		"10.1.0.1",
+		"10.1.1.1",
+		"2001:0db8:85a3:0000:0000:8a2e:0370:7334",
+		"2001:0db8:85a3:0000:0000:8a2e:0370:7335",
+		"127.0.0.1",
+	}
+
+	for _, v := range addresses {
+		ip := net.ParseIP(v)
+
+		suite.Equal(plain.Contains(ip), gzipped.Contains(ip), v)
+	}
+
+	suite.True(gzipped.Contains(net.ParseIP("10.1.0.1")))
+
+	plain.Shutdown()
+	gzipped.Shutdown()
+	time.Sleep(500 * time.Millisecond)
+}
+
+func (suite *FireholTestSuite) TestFilesMergedWithMem() {
+	blocklists, err := ipblocklist.NewFireholFiles(suite.networkMock,
+		nil, []string{filepath.Join("testdata", "good_ipset.ipset")})
+	suite.NoError(err)
+	suite.Len(blocklists, 1)
+
+	_, network, err := net.ParseCIDR("192.168.0.0/16")
+	suite.NoError(err)
+
+	blocklist, err := ipblocklist.NewFireholFromFiles(logger.NewNoopLogger(), 2,
+		append(blocklists, files.NewMem([]*net.IPNet{network})), nil)
+	suite.NoError(err)
+
+	go blocklist.Run(time.Hour)
+
+	time.Sleep(500 * time.Millisecond)
+
+	suite.True(blocklist.Contains(net.ParseIP("10.0.0.10")))
+	suite.True(blocklist.Contains(net.ParseIP("192.168.1.1")))
+	suite.False(blocklist.Contains(net.ParseIP("127.0.0.1")))
+
+	blocklist.Shutdown()
+	time.Sleep(500 * time.Millisecond)
+}
+
+func (suite *FireholTestSuite) TestFailureCallback() {
+	failures := make(chan error, 1)
+	sizes := make(chan int, 1)
+
+	blocklists, err := ipblocklist.NewFireholFiles(suite.networkMock, nil, []string{
+		filepath.Join("testdata", "good_ipset.ipset"),
+		filepath.Join("testdata", "broken_ipset.ipset"),
+	})
+	suite.NoError(err)
+
+	blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+		Logger:              logger.NewNoopLogger(),
+		Files:               blocklists,
+		DownloadConcurrency: 2,
+		DownloadAttempts:    1,
+		UpdateCallback: func(ctx context.Context, size int) {
+			sizes <- size
+		},
+		FailureCallback: func(ctx context.Context, err error) {
+			failures <- err
+		},
+	})
+	suite.NoError(err)
+
+	go blocklist.Run(time.Hour)
+
+	defer blocklist.Shutdown()
+
+	select {
+	case err := <-failures:
+		suite.ErrorContains(err, "broken_ipset.ipset")
+	case <-time.After(time.Second):
+		suite.FailNow("failure callback was not executed")
+	}
+
+	select {
+	case size := <-sizes:
+		suite.Positive(size)
+	case <-time.After(time.Second):
+		suite.FailNow("update callback was not executed")
+	}
+}
+
+func (suite *FireholTestSuite) TestRetry() {
+	failures, opens := int32(2), int32(0)
+	sizes := make(chan int, 1)
+
+	blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+		Logger:              logger.NewNoopLogger(),
+		Files:               []files.File{fireholFlakyFile{failures: &failures, opens: &opens}},
+		DownloadConcurrency: 1,
+		DownloadAttempts:    3,
+		DownloadBackoff:     10 * time.Millisecond,
+		UpdateCallback: func(ctx context.Context, size int) {
+			sizes <- size
+		},
+		FailureCallback: func(ctx context.Context, err error) {
+			suite.Fail("unexpected failure", err)
+		},
+	})
+	suite.NoError(err)
+
+	go blocklist.Run(time.Hour)
+
+	defer blocklist.Shutdown()
+
+	select {
+	case size := <-sizes:
+		suite.Equal(1, size)
+	case <-time.After(time.Second):
+		suite.FailNow("update callback was not executed")
+	}
+
+	suite.EqualValues(3, atomic.LoadInt32(&opens))
+	suite.True(blocklist.Contains(net.ParseIP("10.2.2.2")))
+}
+
+func (suite *FireholTestSuite) TestKeepPreviousOnFailure() {
+	failures, opens := int32(0), int32(0)
+	updates := make(chan struct{}, 1)
+	errs := make(chan error, 1)
+
+	blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+		Logger:              logger.NewNoopLogger(),
+		Files:               []files.File{fireholFlakyFile{failures: &failures, opens: &opens}},
+		DownloadConcurrency: 1,
+		DownloadAttempts:    2,
+		DownloadBackoff:     time.Millisecond,
+		UpdateCallback: func(ctx context.Context, size int) {
+			updates <- struct{}{}
+		},
+		FailureCallback: func(ctx context.Context, err error) {
+			errs <- err
+		},
+	})
+	suite.NoError(err)
+
+	go blocklist.Run(50 * time.Millisecond)
+
+	defer blocklist.Shutdown()
+
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		suite.FailNow("update callback was not executed")
+	}
+
+	suite.True(blocklist.Contains(net.ParseIP("10.2.2.2")))
+
+	atomic.StoreInt32(&failures, 1000)
+
+	select {
+	case err := <-errs:
+		suite.ErrorContains(err, "2 attempts")
+	case <-time.After(time.Second):
+		suite.FailNow("failure callback was not executed")
+	}
+
+	<-updates
+
+	suite.True(blocklist.Contains(net.ParseIP("10.2.2.2")))
+}
+
+func (suite *FireholTestSuite) TestNotModified() {
+	opens := int32(0)
+	sizes := make(chan int, 1)
+	errs := make(chan error, 1)
+
+	blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+		Logger:              logger.NewNoopLogger(),
+		Files:               []files.File{fireholNotModifiedFile{opens: &opens}},
+		DownloadConcurrency: 1,
+		DownloadAttempts:    3,
+		DownloadBackoff:     time.Millisecond,
+		UpdateCallback: func(ctx context.Context, size int) {
+			sizes <- size
+		},
+		FailureCallback: func(ctx context.Context, err error) {
+			errs <- err
+		},
+	})
+	suite.NoError(err)
+
+	go blocklist.Run(50 * time.Millisecond)
+
+	defer blocklist.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case size := <-sizes:
+			suite.Equal(1, size)
+		case err := <-errs:
+			suite.FailNow("unexpected failure", err.Error())
+		case <-time.After(time.Second):
+			suite.FailNow("update callback was not executed")
+		}
+
+		suite.True(blocklist.Contains(net.ParseIP("10.2.2.2")))
+	}
+
+	// not modified file is not retried
+	suite.LessOrEqual(atomic.LoadInt32(&opens), int32(4))
+}
+
 func (suite *FireholTestSuite) TestRemoteFail() {
 	blocklist, err := ipblocklist.NewFirehol(logger.NewNoopLogger(),
 		suite.networkMock, 2,
@@ -120,8 +451,12 @@ func (suite *FireholTestSuite) TestRemoteFail() {
 }
 
 func (suite *FireholTestSuite) TestMixed() {
-	dialer, _ := network.NewDefaultDialer(0, 0)
-	ntw, _ := network.NewNetwork(dialer, "mtg", "1.1.1.1", 0)
+	dialer, _ := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	ntw, _ := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      dialer,
+		UserAgent:   "mtg",
+		DOHHostname: "1.1.1.1",
+	})
 
 	blocklist, err := ipblocklist.NewFirehol(logger.NewNoopLogger(),
 		ntw, 2,
@@ -144,6 +479,131 @@ func (suite *FireholTestSuite) TestMixed() {
 	time.Sleep(500 * time.Millisecond)
 }
 
+func (suite *FireholTestSuite) TestSharedDownloadLimiter() {
+	var active, maxActive int32
+
+	limiter := ipblocklist.NewDownloadLimiter(1)
+	lists := []*ipblocklist.Firehol{}
+
+	for i := 0; i < 2; i++ {
+		blocklist, err := ipblocklist.NewFireholWithOpts(ipblocklist.FireholOpts{
+			Logger:              logger.NewNoopLogger(),
+			DownloadConcurrency: 2,
+			DownloadLimiter:     limiter,
+			Files: []files.File{
+				fireholSlowFile{active: &active, maxActive: &maxActive},
+				fireholSlowFile{active: &active, maxActive: &maxActive},
+			},
+		})
+		suite.NoError(err)
+
+		lists = append(lists, blocklist)
+
+		go blocklist.Run(time.Hour)
+	}
+
+	suite.Eventually(func() bool {
+		return lists[0].Contains(net.ParseIP("10.0.0.10")) &&
+			lists[1].Contains(net.ParseIP("10.0.0.10"))
+	}, 2*time.Second, 10*time.Millisecond)
+
+	suite.EqualValues(1, atomic.LoadInt32(&maxActive))
+
+	for _, v := range lists {
+		v.Shutdown()
+	}
+}
+
+func (suite *FireholTestSuite) TestReloadIsAtomic() {
+	reached := make(chan struct{})
+	release := make(chan struct{})
+	reader := &fireholGatedReader{
+		parts:   []string{"10.0.0.0/8\n", "192.168.0.0/16\n"},
+		reached: reached,
+		release: release,
+	}
+
+	blocklist, err := ipblocklist.NewFireholFromFiles(logger.NewNoopLogger(), 1,
+		[]files.File{fireholGatedFile{reader: reader}}, nil)
+	suite.NoError(err)
+
+	go blocklist.Run(time.Hour)
+
+	defer blocklist.Shutdown()
+
+	<-reached
+
+	var seen int32
+
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				if blocklist.Contains(net.ParseIP("10.0.0.10")) {
+					atomic.AddInt32(&seen, 1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	suite.EqualValues(0, atomic.LoadInt32(&seen))
+
+	close(release)
+
+	suite.Eventually(func() bool {
+		return blocklist.Contains(net.ParseIP("192.168.0.10"))
+	}, 2*time.Second, 10*time.Millisecond)
+	suite.True(blocklist.Contains(net.ParseIP("10.0.0.10")))
+}
+
+func (suite *FireholTestSuite) TestConcurrentContainsDuringReloads() {
+	blocklist, err := ipblocklist.NewFireholFromFiles(logger.NewNoopLogger(), 2,
+		[]files.File{
+			files.NewMem([]*net.IPNet{
+				{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)},
+				{IP: net.ParseIP("192.168.0.0"), Mask: net.CIDRMask(16, 32)},
+			}),
+		}, nil)
+	suite.NoError(err)
+
+	go blocklist.Run(time.Millisecond)
+
+	defer blocklist.Shutdown()
+
+	suite.Eventually(func() bool {
+		return blocklist.Contains(net.ParseIP("10.0.0.10"))
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var missed int32
+
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 5000; j++ {
+				if !blocklist.Contains(net.ParseIP("192.168.0.10")) {
+					atomic.AddInt32(&missed, 1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	suite.EqualValues(0, atomic.LoadInt32(&missed))
+}
+
 func TestFirehol(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &FireholTestSuite{})
@@ -0,0 +1,83 @@
+package ipblocklist
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/IceCodeNew/mtg/geoip"
+)
+
+// GeoIP is IP blocklist which contains all IP addresses of given countries.
+// Countries are resolved with a GeoIP country database.
+//
+// IP addresses of unknown origin are never contained in this list: if a
+// database is not loaded yet or has no record, a blocklist does not block
+// and an allowlist does not allow.
+type GeoIP struct {
+	db        *geoip.DB
+	countries map[string]struct{}
+	ownDB     bool
+}
+
+// Contains checks if a country of IP address is one of the list.
+func (g *GeoIP) Contains(ip net.IP) bool {
+	country := g.db.Lookup(ip).Country
+	if country == "" {
+		return false
+	}
+
+	_, ok := g.countries[country]
+
+	return ok
+}
+
+// Run reloads a database from disk periodically. If a database is shared
+// with other consumers (see [NewGeoIPFromDB]), it is reloaded by its owner
+// and this method does nothing.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (g *GeoIP) Run(updateEach time.Duration) {
+	if g.ownDB {
+		g.db.Run(updateEach)
+	}
+}
+
+// Shutdown stops periodic reloads and closes a database if it is owned by
+// this list.
+func (g *GeoIP) Shutdown() {
+	if g.ownDB {
+		g.db.Close()
+	}
+}
+
+// NewGeoIP creates a new country blocklist from a MaxMind DB file, like
+// GeoLite2-Country. countries are ISO 3166-1 alpha-2 codes. A file which
+// cannot be loaded is an error.
+func NewGeoIP(path string, countries []string) (*GeoIP, error) {
+	db, err := geoip.NewDB(path, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open geoip database: %w", err)
+	}
+
+	rv := NewGeoIPFromDB(db, countries)
+	rv.ownDB = true
+
+	return rv, nil
+}
+
+// NewGeoIPFromDB creates a new country blocklist which uses a given
+// database. This database is neither reloaded nor closed by a blocklist.
+func NewGeoIPFromDB(db *geoip.DB, countries []string) *GeoIP {
+	rv := &GeoIP{
+		db:        db,
+		countries: make(map[string]struct{}, len(countries)),
+	}
+
+	for _, v := range countries {
+		rv.countries[strings.ToUpper(v)] = struct{}{}
+	}
+
+	return rv
+}
@@ -0,0 +1,51 @@
+package ipblocklist_test
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/stretchr/testify/suite"
+)
+
+type GeoIPTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GeoIPTestSuite) TestContains() {
+	blocklist, err := ipblocklist.NewGeoIP(filepath.Join("testdata", "country.mmdb"), []string{"gb", "SE"})
+	suite.Require().NoError(err)
+
+	defer blocklist.Shutdown()
+
+	suite.True(blocklist.Contains(net.ParseIP("81.2.69.142")))
+	suite.True(blocklist.Contains(net.ParseIP("89.160.20.112")))
+	suite.False(blocklist.Contains(net.ParseIP("2001:db8:1::1")))
+	suite.False(blocklist.Contains(net.ParseIP("10.0.0.1")))
+}
+
+func (suite *GeoIPTestSuite) TestMissingDatabase() {
+	_, err := ipblocklist.NewGeoIP(filepath.Join("testdata", "missing.mmdb"), []string{"GB"})
+	suite.Error(err)
+}
+
+func (suite *GeoIPTestSuite) TestSharedDatabase() {
+	db, err := geoip.NewDB(filepath.Join("testdata", "country.mmdb"), "")
+	suite.Require().NoError(err)
+
+	defer db.Close()
+
+	blocklist := ipblocklist.NewGeoIPFromDB(db, []string{"DE"})
+	blocklist.Run(0)
+	blocklist.Shutdown()
+
+	suite.True(blocklist.Contains(net.ParseIP("2001:db8:1::1")))
+	suite.Equal("DE", db.Lookup(net.ParseIP("2001:db8:1::1")).Country)
+}
+
+func TestGeoIP(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &GeoIPTestSuite{})
+}
@@ -15,4 +15,12 @@ const (
 	// DefaultFireholUpdateEach defines a default time period when Firehol
 	// requests updates of the blocklists.
 	DefaultFireholUpdateEach = 6 * time.Hour
+
+	// DefaultFireholDownloadAttempts defines a default max number of reads
+	// of each file per update.
+	DefaultFireholDownloadAttempts = 3
+
+	// DefaultFireholDownloadBackoff defines a default pause between the
+	// first and the second read attempts. Each next pause is twice longer.
+	DefaultFireholDownloadBackoff = 10 * time.Second
 )
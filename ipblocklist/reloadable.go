@@ -0,0 +1,56 @@
+package ipblocklist
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// Reloadable is IP blocklist which delegates to another list. This list
+// can be replaced in runtime, for example, on reload of a configuration.
+//
+// Reloadable does not run lists: a list has to be started by its owner
+// before it is given to Reloadable.
+type Reloadable struct {
+	mutex sync.RWMutex
+	list  mtglib.IPBlocklist
+}
+
+// Contains checks if an IP address belongs to a current list.
+func (r *Reloadable) Contains(ip net.IP) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.list.Contains(ip)
+}
+
+// Run does nothing: lists are started by their owners.
+func (r *Reloadable) Run(updateEach time.Duration) {}
+
+// Shutdown stops a current list.
+func (r *Reloadable) Shutdown() {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	r.list.Shutdown()
+}
+
+// Swap replaces a current list with a given one and stops a previous list.
+// Any call of Contains after a swap is checked against a new list.
+func (r *Reloadable) Swap(list mtglib.IPBlocklist) {
+	r.mutex.Lock()
+	previous := r.list
+	r.list = list
+	r.mutex.Unlock()
+
+	previous.Shutdown()
+}
+
+// NewReloadable creates a new list which delegates to a given one.
+func NewReloadable(list mtglib.IPBlocklist) *Reloadable {
+	return &Reloadable{
+		list: list,
+	}
+}
@@ -0,0 +1,70 @@
+package ipblocklist_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloadableTestSuite struct {
+	suite.Suite
+
+	first  *chainTestList
+	second *chainTestList
+	list   *ipblocklist.Reloadable
+}
+
+func (suite *ReloadableTestSuite) SetupTest() {
+	suite.first = newChainTestList("10.0.0.0/8")
+	suite.second = newChainTestList("192.168.0.0/16")
+	suite.list = ipblocklist.NewReloadable(suite.first)
+}
+
+func (suite *ReloadableTestSuite) TestContains() {
+	suite.True(suite.list.Contains(net.ParseIP("10.1.1.1")))
+	suite.False(suite.list.Contains(net.ParseIP("192.168.1.1")))
+}
+
+func (suite *ReloadableTestSuite) TestSwap() {
+	suite.list.Swap(suite.second)
+
+	suite.False(suite.list.Contains(net.ParseIP("10.1.1.1")))
+	suite.True(suite.list.Contains(net.ParseIP("192.168.1.1")))
+
+	select {
+	case <-suite.first.shutdown:
+	default:
+		suite.Fail("previous list is not stopped")
+	}
+
+	select {
+	case <-suite.second.shutdown:
+		suite.Fail("current list is stopped")
+	default:
+	}
+}
+
+func (suite *ReloadableTestSuite) TestRunDoesNothing() {
+	suite.list.Run(time.Second)
+
+	suite.EqualValues(0, suite.first.runs)
+}
+
+func (suite *ReloadableTestSuite) TestShutdown() {
+	suite.list.Swap(suite.second)
+	suite.list.Shutdown()
+
+	select {
+	case <-suite.second.shutdown:
+	default:
+		suite.Fail("current list is not stopped")
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ReloadableTestSuite{})
+}
@@ -0,0 +1,138 @@
+package ipblocklist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StatusRecord is a state of a single IP list.
+type StatusRecord struct {
+	// Size is a number of networks in the list after the last update.
+	Size int `json:"size"`
+
+	// UpdatedAt is a time of the last update, even a partial one.
+	UpdatedAt *time.Time `json:"updatedAt"`
+
+	// SucceededAt is a time of the last update where all files were read.
+	SucceededAt *time.Time `json:"succeededAt"`
+
+	// LastError is an error of the last failed update.
+	LastError string `json:"lastError,omitempty"`
+
+	// FailedAt is a time of the last failed update.
+	FailedAt *time.Time `json:"failedAt,omitempty"`
+}
+
+type statusList struct {
+	record StatusRecord
+	failed bool
+}
+
+// Status tracks updates of IP lists with callbacks of [Firehol]. This is
+// also an [http.Handler] which dumps a state of each list as JSON object:
+// a key is a name of the list.
+//
+// Partial updates are applied: if some file cannot be read, other files
+// are still used. So, a list with LastError has a size of files which
+// were read successfully. It is a good idea to alert if SucceededAt is too
+// old.
+type Status struct {
+	mutex sync.Mutex
+	lists map[string]*statusList
+}
+
+// Register adds a list which has not been updated yet.
+func (s *Status) Register(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.get(name)
+}
+
+// UpdateCallback returns a callback which tracks updates of a list of a
+// given name and then executes next one. next can be nil.
+func (s *Status) UpdateCallback(name string, next FireholUpdateCallback) FireholUpdateCallback {
+	s.Register(name)
+
+	return func(ctx context.Context, size int) {
+		now := time.Now()
+
+		s.mutex.Lock()
+
+		list := s.get(name)
+		list.record.Size = size
+		list.record.UpdatedAt = &now
+
+		if !list.failed {
+			list.record.SucceededAt = &now
+		}
+
+		list.failed = false
+
+		s.mutex.Unlock()
+
+		if next != nil {
+			next(ctx, size)
+		}
+	}
+}
+
+// FailureCallback returns a callback which tracks failures of a list of a
+// given name.
+func (s *Status) FailureCallback(name string) FireholFailureCallback {
+	s.Register(name)
+
+	return func(ctx context.Context, err error) {
+		now := time.Now()
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		list := s.get(name)
+		list.record.LastError = err.Error()
+		list.record.FailedAt = &now
+		list.failed = true
+	}
+}
+
+// Records returns a copy of states of all lists.
+func (s *Status) Records() map[string]StatusRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	rv := make(map[string]StatusRecord, len(s.lists))
+
+	for k, v := range s.lists {
+		rv[k] = v.record
+	}
+
+	return rv
+}
+
+// ServeHTTP dumps states of all lists.
+func (s *Status) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	json.NewEncoder(w).Encode(s.Records()) //nolint: errcheck
+}
+
+func (s *Status) get(name string) *statusList {
+	list, ok := s.lists[name]
+	if !ok {
+		list = &statusList{}
+		s.lists[name] = list
+	}
+
+	return list
+}
+
+// NewStatus creates a new tracker of IP lists.
+func NewStatus() *Status {
+	return &Status{
+		lists: map[string]*statusList{},
+	}
+}
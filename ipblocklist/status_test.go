@@ -0,0 +1,100 @@
+package ipblocklist_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/ipblocklist"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatusTestSuite struct {
+	suite.Suite
+
+	status *ipblocklist.Status
+}
+
+func (suite *StatusTestSuite) SetupTest() {
+	suite.status = ipblocklist.NewStatus()
+}
+
+func (suite *StatusTestSuite) TestRegister() {
+	suite.status.Register("blocklist")
+
+	record := suite.status.Records()["blocklist"]
+
+	suite.Zero(record.Size)
+	suite.Nil(record.UpdatedAt)
+	suite.Nil(record.SucceededAt)
+}
+
+func (suite *StatusTestSuite) TestUpdate() {
+	sizes := []int{}
+	callback := suite.status.UpdateCallback("blocklist", func(ctx context.Context, size int) {
+		sizes = append(sizes, size)
+	})
+
+	callback(context.Background(), 10)
+
+	record := suite.status.Records()["blocklist"]
+
+	suite.Equal([]int{10}, sizes)
+	suite.Equal(10, record.Size)
+	suite.NotNil(record.UpdatedAt)
+	suite.Equal(record.UpdatedAt, record.SucceededAt)
+	suite.Empty(record.LastError)
+}
+
+func (suite *StatusTestSuite) TestFailure() {
+	update := suite.status.UpdateCallback("allowlist", nil)
+	failure := suite.status.FailureCallback("allowlist")
+
+	update(context.Background(), 10)
+
+	succeededAt := suite.status.Records()["allowlist"].SucceededAt
+
+	failure(context.Background(), errors.New("cannot download"))
+	update(context.Background(), 5)
+
+	record := suite.status.Records()["allowlist"]
+
+	suite.Equal(5, record.Size)
+	suite.Equal(succeededAt, record.SucceededAt)
+	suite.NotEqual(record.UpdatedAt, record.SucceededAt)
+	suite.Equal("cannot download", record.LastError)
+	suite.NotNil(record.FailedAt)
+
+	update(context.Background(), 10)
+
+	record = suite.status.Records()["allowlist"]
+
+	suite.Equal(record.UpdatedAt, record.SucceededAt)
+	suite.Equal("cannot download", record.LastError)
+}
+
+func (suite *StatusTestSuite) TestServeHTTP() {
+	suite.status.UpdateCallback("blocklist", nil)(context.Background(), 3)
+	suite.status.Register("allowlist")
+
+	recorder := httptest.NewRecorder()
+	suite.status.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ip-lists", nil))
+
+	suite.Equal(http.StatusOK, recorder.Code)
+	suite.Equal("application/json", recorder.Header().Get("Content-Type"))
+
+	response := map[string]map[string]interface{}{}
+
+	suite.NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+	suite.EqualValues(3, response["blocklist"]["size"])
+	suite.NotNil(response["blocklist"]["updatedAt"])
+	suite.Nil(response["allowlist"]["updatedAt"])
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &StatusTestSuite{})
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const repeatedFieldName = "repeated"
+
+// dedupKey identifies a message. Bound context variables are not the part of
+// the key: identical messages usually differ only in stream ids or IP
+// addresses so they would never match otherwise.
+type dedupKey struct {
+	level zerolog.Level
+	name  string
+	msg   string
+	err   string
+}
+
+type dedupEntry struct {
+	emittedAt  time.Time
+	suppressed int
+}
+
+type dedupSummary struct {
+	key        dedupKey
+	suppressed int
+}
+
+type deduplicator struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	entries  map[dedupKey]*dedupEntry
+	sweptAt  time.Time
+}
+
+// check decides if a message has to be emitted now. If yes, it also returns
+// how many times this message was suppressed since the last emission.
+//
+// Messages which were suppressed but have not been seen again within an
+// interval are returned as summaries: nobody else is going to report them.
+func (d *deduplicator) check(key dedupKey, now time.Time) (bool, int, []dedupSummary) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	summaries := d.sweep(key, now)
+
+	entry, ok := d.entries[key]
+	if !ok {
+		d.entries[key] = &dedupEntry{emittedAt: now}
+
+		return true, 0, summaries
+	}
+
+	if now.Sub(entry.emittedAt) < d.interval {
+		entry.suppressed++
+
+		return false, 0, summaries
+	}
+
+	suppressed := entry.suppressed
+	entry.emittedAt = now
+	entry.suppressed = 0
+
+	return true, suppressed, summaries
+}
+
+func (d *deduplicator) sweep(current dedupKey, now time.Time) []dedupSummary {
+	if now.Sub(d.sweptAt) < d.interval {
+		return nil
+	}
+
+	d.sweptAt = now
+
+	var summaries []dedupSummary
+
+	for key, entry := range d.entries {
+		if key == current || now.Sub(entry.emittedAt) < d.interval {
+			continue
+		}
+
+		if entry.suppressed > 0 {
+			summaries = append(summaries, dedupSummary{
+				key:        key,
+				suppressed: entry.suppressed,
+			})
+		}
+
+		delete(d.entries, key)
+	}
+
+	return summaries
+}
+
+func newDeduplicator(interval time.Duration) *deduplicator {
+	return &deduplicator{
+		interval: interval,
+		entries:  map[dedupKey]*dedupEntry{},
+	}
+}
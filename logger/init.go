@@ -4,6 +4,17 @@
 // used by mtglib.
 package logger
 
+import "time"
+
+const (
+	// DefaultDedupInterval is a default min time period between identical
+	// messages if deduplication is enabled.
+	DefaultDedupInterval = 10 * time.Second
+
+	// DefaultTag is a default name of application in syslog and journald.
+	DefaultTag = "mtg"
+)
+
 // StdLikeLogger is an interface which is close to [log.Logger]. This is
 // commonly used by many 3pp tools. While mtglib itself does not need it, it is
 // always a good idea to support it and have a transient end to end logging.
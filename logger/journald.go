@@ -0,0 +1,162 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// JournaldSocket is a path to a socket of systemd-journald which accepts
+// messages in a native protocol.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+type journaldWriter struct {
+	conn net.Conn
+	tag  string
+}
+
+func (j journaldWriter) Write(p []byte) (int, error) {
+	return j.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel sends a message as a journal entry. Each field of JSON
+// message becomes a journal field with a name in upper case, like
+// LOGGER or STREAM_ID.
+func (j journaldWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	fields := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return 0, fmt.Errorf("cannot decode message: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+
+	writeJournaldField(buf, "PRIORITY", strconv.Itoa(int(journaldPriority(level))))
+	writeJournaldField(buf, "SYSLOG_IDENTIFIER", j.tag)
+	writeJournaldField(buf, "MESSAGE", journaldValue(fields[zerolog.MessageFieldName]))
+
+	keys := make([]string, 0, len(fields))
+
+	for k := range fields {
+		if k != zerolog.MessageFieldName && k != zerolog.LevelFieldName {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		writeJournaldField(buf, journaldFieldName(k), journaldValue(fields[k]))
+	}
+
+	if _, err := j.conn.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("cannot send message to journald: %w", err)
+	}
+
+	return len(p), nil
+}
+
+// journaldPriority maps zerolog levels to syslog severities.
+func journaldPriority(level zerolog.Level) syslog.Priority {
+	switch level { //nolint: exhaustive
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return syslog.LOG_DEBUG
+	case zerolog.WarnLevel:
+		return syslog.LOG_WARNING
+	case zerolog.ErrorLevel:
+		return syslog.LOG_ERR
+	case zerolog.FatalLevel:
+		return syslog.LOG_CRIT
+	case zerolog.PanicLevel:
+		return syslog.LOG_EMERG
+	default:
+		return syslog.LOG_INFO
+	}
+}
+
+// journaldFieldName converts a name of JSON field to a valid name of
+// journal field: only upper case letters, digits and underscores, not
+// starting with an underscore or a digit.
+func journaldFieldName(name string) string {
+	rv := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	if rv == "" || rv[0] == '_' || (rv[0] >= '0' && rv[0] <= '9') {
+		rv = "F" + rv
+	}
+
+	return rv
+}
+
+// journaldValue returns strings as is and any other JSON value as its
+// text.
+func journaldValue(value json.RawMessage) string {
+	text := ""
+
+	if err := json.Unmarshal(value, &text); err == nil {
+		return text
+	}
+
+	return string(value)
+}
+
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	// multiline values are written with their length in front.
+	size := [8]byte{}
+
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// NewJournald returns a writer which sends zerolog messages to a local
+// systemd-journald with its native protocol. Unlike stdout, each field of
+// a message is indexed by journal and can be used in filters of
+// journalctl, and levels are mapped to priorities.
+func NewJournald(tag string) (zerolog.LevelWriter, error) {
+	return newJournald(JournaldSocket, tag)
+}
+
+func newJournald(socketPath, tag string) (zerolog.LevelWriter, error) {
+	conn, err := net.Dial("unixgram", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to journald: %w", err)
+	}
+
+	return journaldWriter{
+		conn: conn,
+		tag:  tag,
+	}, nil
+}
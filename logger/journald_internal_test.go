@@ -0,0 +1,106 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type JournaldTestSuite struct {
+	suite.Suite
+
+	conn *net.UnixConn
+	log  zerolog.Logger
+}
+
+func (suite *JournaldTestSuite) SetupTest() {
+	socketPath := filepath.Join(suite.T().TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	suite.Require().NoError(err)
+
+	suite.conn = conn
+
+	writer, err := newJournald(socketPath, "mtg")
+	suite.Require().NoError(err)
+
+	suite.log = zerolog.New(writer)
+}
+
+func (suite *JournaldTestSuite) TearDownTest() {
+	suite.conn.Close()
+}
+
+func (suite *JournaldTestSuite) Read() string {
+	buf := make([]byte, 65536)
+
+	n, err := suite.conn.Read(buf)
+	suite.Require().NoError(err)
+
+	return string(buf[:n])
+}
+
+func (suite *JournaldTestSuite) TestFields() {
+	suite.log.Warn().Str("stream-id", "xxx").Int("size", 10).Msg("hello")
+
+	suite.Equal(strings.Join([]string{
+		"PRIORITY=4",
+		"SYSLOG_IDENTIFIER=mtg",
+		"MESSAGE=hello",
+		"SIZE=10",
+		"STREAM_ID=xxx",
+		"",
+	}, "\n"), suite.Read())
+}
+
+func (suite *JournaldTestSuite) TestPriorities() {
+	testData := map[zerolog.Level]string{
+		zerolog.DebugLevel: "PRIORITY=7\n",
+		zerolog.InfoLevel:  "PRIORITY=6\n",
+		zerolog.WarnLevel:  "PRIORITY=4\n",
+		zerolog.ErrorLevel: "PRIORITY=3\n",
+		zerolog.NoLevel:    "PRIORITY=6\n",
+	}
+
+	for level, priority := range testData {
+		suite.log.WithLevel(level).Msg("message")
+		suite.True(strings.HasPrefix(suite.Read(), priority), level.String())
+	}
+}
+
+func (suite *JournaldTestSuite) TestMultiline() {
+	suite.log.Info().Msg("hello\nworld")
+
+	size := [8]byte{}
+	binary.LittleEndian.PutUint64(size[:], uint64(len("hello\nworld")))
+
+	suite.Contains(suite.Read(), "\nMESSAGE\n"+string(size[:])+"hello\nworld\n")
+}
+
+func (suite *JournaldTestSuite) TestFieldName() {
+	testData := map[string]string{
+		"logger":      "LOGGER",
+		"stream-id":   "STREAM_ID",
+		"clientIP":    "CLIENTIP",
+		"_hidden":     "F_HIDDEN",
+		"1st":         "F1ST",
+		"instance.ok": "INSTANCE_OK",
+	}
+
+	for name, expected := range testData {
+		suite.Equal(expected, journaldFieldName(name), name)
+	}
+}
+
+func TestJournald(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &JournaldTestSuite{})
+}
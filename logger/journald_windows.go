@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+// NewJournald is not supported on Windows.
+func NewJournald(tag string) (zerolog.LevelWriter, error) {
+	return nil, errors.New("journald is not supported on windows")
+}
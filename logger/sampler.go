@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultSamplingInterval is a default time period of sampling.
+	DefaultSamplingInterval = time.Second
+
+	// DefaultSamplingFirst is a default number of messages of each kind
+	// which are emitted per interval before sampling starts.
+	DefaultSamplingFirst = 10
+
+	// DefaultSamplingThereafter is a default rate of sampling: each Nth
+	// message is emitted after the first ones.
+	DefaultSamplingThereafter = 100
+)
+
+// SamplingOpts defines how repeated messages are sampled.
+//
+// Within an interval, first messages of each kind are emitted as is.
+// After that, only each Thereafter message is emitted. Messages are of the
+// same kind if they have the same level, logger name and text; errors and
+// bound context variables are ignored. Warnings are never sampled: these
+// are the most important messages mtg has.
+type SamplingOpts struct {
+	// Interval is a time period after which counters are reset. 0 interval
+	// disables sampling.
+	Interval time.Duration
+
+	// First is a number of messages of each kind which are emitted per
+	// interval before sampling starts.
+	First uint
+
+	// Thereafter is a rate of sampling after the first messages. 0 means
+	// that nothing is emitted until the end of an interval.
+	Thereafter uint
+}
+
+type samplerKey struct {
+	level zerolog.Level
+	name  string
+	msg   string
+}
+
+type samplerEntry struct {
+	startedAt time.Time
+	count     uint
+}
+
+type sampler struct {
+	mutex   sync.Mutex
+	opts    SamplingOpts
+	entries map[samplerKey]*samplerEntry
+	sweptAt time.Time
+}
+
+// allow decides if a message has to be emitted.
+func (s *sampler) allow(key samplerKey, now time.Time) bool {
+	if key.level >= zerolog.WarnLevel {
+		return true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+
+	switch {
+	case !ok:
+		entry = &samplerEntry{startedAt: now}
+		s.entries[key] = entry
+	case now.Sub(entry.startedAt) >= s.opts.Interval:
+		entry.startedAt = now
+		entry.count = 0
+	}
+
+	entry.count++
+
+	if entry.count <= s.opts.First {
+		return true
+	}
+
+	if s.opts.Thereafter == 0 {
+		return false
+	}
+
+	return (entry.count-s.opts.First)%s.opts.Thereafter == 0
+}
+
+// sweep removes entries of finished intervals so messages which are
+// not seen anymore do not hold memory.
+func (s *sampler) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.opts.Interval {
+		return
+	}
+
+	s.sweptAt = now
+
+	for key, entry := range s.entries {
+		if now.Sub(entry.startedAt) >= s.opts.Interval {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func newSampler(opts SamplingOpts) *sampler {
+	return &sampler{
+		opts:    opts,
+		entries: map[samplerKey]*samplerEntry{},
+	}
+}
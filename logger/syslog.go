@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+
+	"github.com/rs/zerolog"
+)
+
+// NewSyslog returns a writer which sends zerolog messages to a syslog
+// server. Empty network and address mean a local syslog daemon. Network
+// is one of udp, tcp, unix or unixgram.
+//
+// zerolog levels are mapped to syslog severities: debug, info, warning
+// and err. A message itself is the same JSON as for stdout.
+func NewSyslog(network, address, tag string) (zerolog.LevelWriter, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to syslog: %w", err)
+	}
+
+	return zerolog.SyslogLevelWriter(writer), nil
+}
@@ -0,0 +1,87 @@
+//go:build !windows
+// +build !windows
+
+package logger_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+)
+
+type SyslogTestSuite struct {
+	suite.Suite
+
+	conn net.PacketConn
+	log  zerolog.Logger
+}
+
+func (suite *SyslogTestSuite) SetupTest() {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	suite.Require().NoError(err)
+
+	suite.conn = conn
+	suite.log = zerolog.New(suite.writer())
+}
+
+func (suite *SyslogTestSuite) TearDownTest() {
+	suite.conn.Close()
+}
+
+func (suite *SyslogTestSuite) Read() string {
+	buf := make([]byte, 65536)
+
+	n, _, err := suite.conn.ReadFrom(buf)
+	suite.Require().NoError(err)
+
+	return string(buf[:n])
+}
+
+func (suite *SyslogTestSuite) TestSeverities() {
+	// facility is daemon (3), so priority is 3 * 8 + severity.
+	testData := map[zerolog.Level]string{
+		zerolog.DebugLevel: "<31>",
+		zerolog.InfoLevel:  "<30>",
+		zerolog.WarnLevel:  "<28>",
+		zerolog.ErrorLevel: "<27>",
+	}
+
+	for level, priority := range testData {
+		suite.log.WithLevel(level).Str("logger", "name").Msg("message")
+
+		message := suite.Read()
+
+		suite.Contains(message, priority, level.String())
+		suite.Contains(message, "mtg")
+		suite.Contains(message, `"logger":"name"`)
+		suite.Contains(message, `"message":"message"`)
+	}
+}
+
+func (suite *SyslogTestSuite) TestMtglibLogger() {
+	log := logger.NewZeroLogger(zerolog.New(suite.writer())).Named("proxy").BindStr("ip", "127.0.0.1")
+
+	log.InfoError("cannot connect", net.ErrClosed)
+
+	message := suite.Read()
+
+	suite.Contains(message, "<30>")
+	suite.Contains(message, `"logger":"proxy"`)
+	suite.Contains(message, `"ip":"127.0.0.1"`)
+	suite.Contains(message, `"error":"use of closed network connection"`)
+}
+
+func (suite *SyslogTestSuite) writer() zerolog.LevelWriter {
+	writer, err := logger.NewSyslog("udp", suite.conn.LocalAddr().String(), "mtg")
+	suite.Require().NoError(err)
+
+	return writer
+}
+
+func TestSyslog(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &SyslogTestSuite{})
+}
@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+// NewSyslog is not supported on Windows.
+func NewSyslog(network, address, tag string) (zerolog.LevelWriter, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/rs/zerolog"
@@ -18,9 +20,60 @@ const (
 	zeroLogContextVarTypeJSON
 )
 
+// AtomicLevel is a min level of messages which can be changed in runtime.
+// It is safe to change it concurrently with logging: each message is
+// checked against either a previous or a new level.
+type AtomicLevel struct {
+	level int32
+}
+
+// Level returns a current level.
+func (a *AtomicLevel) Level() zerolog.Level {
+	return zerolog.Level(atomic.LoadInt32(&a.level))
+}
+
+// SetLevel changes a current level.
+func (a *AtomicLevel) SetLevel(level zerolog.Level) {
+	atomic.StoreInt32(&a.level, int32(level))
+}
+
+// Cycle makes a current level one step more verbose: warn, info, debug
+// and then warn again. It returns a new level.
+func (a *AtomicLevel) Cycle() zerolog.Level {
+	for {
+		current := atomic.LoadInt32(&a.level)
+		next := int32(nextCycleLevel(zerolog.Level(current)))
+
+		if atomic.CompareAndSwapInt32(&a.level, current, next) {
+			return zerolog.Level(next)
+		}
+	}
+}
+
+func nextCycleLevel(level zerolog.Level) zerolog.Level {
+	switch {
+	case level > zerolog.InfoLevel:
+		return zerolog.InfoLevel
+	case level > zerolog.DebugLevel:
+		return zerolog.DebugLevel
+	default:
+		return zerolog.WarnLevel
+	}
+}
+
+// NewAtomicLevel creates a new level with a given initial value.
+func NewAtomicLevel(level zerolog.Level) *AtomicLevel {
+	return &AtomicLevel{
+		level: int32(level),
+	}
+}
+
 type zeroLogContext struct {
-	name string
-	log  *zerolog.Logger
+	name    string
+	log     *zerolog.Logger
+	dedup   *deduplicator
+	sampler *sampler
+	level   *AtomicLevel
 
 	ctxVarType zeroLogContextVarType
 	ctxVarName string
@@ -39,9 +92,12 @@ func (z *zeroLogContext) Named(name string) mtglib.Logger {
 	}
 
 	return &zeroLogContext{
-		name:   loggerName,
-		log:    z.log,
-		parent: z,
+		name:    loggerName,
+		log:     z.log,
+		dedup:   z.dedup,
+		sampler: z.sampler,
+		level:   z.level,
+		parent:  z,
 	}
 }
 
@@ -49,6 +105,9 @@ func (z *zeroLogContext) BindInt(name string, value int) mtglib.Logger {
 	return &zeroLogContext{
 		name:       z.name,
 		log:        z.log,
+		dedup:      z.dedup,
+		sampler:    z.sampler,
+		level:      z.level,
 		ctxVarType: zeroLogContextVarTypeInt,
 		ctxVarInt:  value,
 		ctxVarName: name,
@@ -60,6 +119,9 @@ func (z *zeroLogContext) BindStr(name, value string) mtglib.Logger {
 	return &zeroLogContext{
 		name:       z.name,
 		log:        z.log,
+		dedup:      z.dedup,
+		sampler:    z.sampler,
+		level:      z.level,
 		ctxVarType: zeroLogContextVarTypeStr,
 		ctxVarStr:  value,
 		ctxVarName: name,
@@ -71,6 +133,9 @@ func (z *zeroLogContext) BindJSON(name, value string) mtglib.Logger {
 	return &zeroLogContext{
 		name:       z.name,
 		log:        z.log,
+		dedup:      z.dedup,
+		sampler:    z.sampler,
+		level:      z.level,
 		ctxVarType: zeroLogContextVarTypeJSON,
 		ctxVarName: name,
 		ctxVarStr:  value,
@@ -95,18 +160,61 @@ func (z *zeroLogContext) Debug(msg string) {
 }
 
 func (z *zeroLogContext) InfoError(msg string, err error) {
-	z.emitLog(z.log.Info(), msg, err)
+	z.emitLog(zerolog.InfoLevel, msg, err)
 }
 
 func (z *zeroLogContext) WarningError(msg string, err error) {
-	z.emitLog(z.log.Warn(), msg, err)
+	z.emitLog(zerolog.WarnLevel, msg, err)
 }
 
 func (z *zeroLogContext) DebugError(msg string, err error) {
-	z.emitLog(z.log.Debug(), msg, err)
+	z.emitLog(zerolog.DebugLevel, msg, err)
 }
 
-func (z *zeroLogContext) emitLog(evt *zerolog.Event, msg string, err error) {
+func (z *zeroLogContext) emitLog(level zerolog.Level, msg string, err error) {
+	if z.level != nil && level < z.level.Level() {
+		return
+	}
+
+	evt := z.log.WithLevel(level)
+	if !evt.Enabled() {
+		return
+	}
+
+	if z.sampler != nil && !z.sampler.allow(samplerKey{level: level, name: z.name, msg: msg}, time.Now()) {
+		evt.Discard()
+
+		return
+	}
+
+	if z.dedup != nil {
+		key := dedupKey{
+			level: level,
+			name:  z.name,
+			msg:   msg,
+		}
+
+		if err != nil {
+			key.err = err.Error()
+		}
+
+		emit, suppressed, summaries := z.dedup.check(key, time.Now())
+
+		for _, v := range summaries {
+			z.emitSummary(v)
+		}
+
+		if !emit {
+			evt.Discard()
+
+			return
+		}
+
+		if suppressed > 0 {
+			evt.Int(repeatedFieldName, suppressed)
+		}
+	}
+
 	z.attachCtx(evt)
 
 	for current := z.parent; current != nil; current = current.parent {
@@ -116,6 +224,18 @@ func (z *zeroLogContext) emitLog(evt *zerolog.Event, msg string, err error) {
 	evt.Str(loggerFieldName, z.name).Err(err).Msg(msg)
 }
 
+func (z *zeroLogContext) emitSummary(summary dedupSummary) {
+	evt := z.log.WithLevel(summary.key.level).
+		Str(loggerFieldName, summary.key.name).
+		Int(repeatedFieldName, summary.suppressed)
+
+	if summary.key.err != "" {
+		evt.Str(zerolog.ErrorFieldName, summary.key.err)
+	}
+
+	evt.Msg(summary.key.msg)
+}
+
 func (z *zeroLogContext) attachCtx(evt *zerolog.Event) {
 	switch z.ctxVarType {
 	case zeroLogContextVarTypeStr:
@@ -130,7 +250,49 @@ func (z *zeroLogContext) attachCtx(evt *zerolog.Event) {
 
 // NewZeroLogger returns a logger which is using rs/zerolog library.
 func NewZeroLogger(log zerolog.Logger) mtglib.Logger {
-	return &zeroLogContext{
-		log: &log,
+	return NewZeroLoggerWithOpts(log, ZeroLoggerOpts{})
+}
+
+// ZeroLoggerOpts is a set of options of a logger made by
+// [NewZeroLoggerWithOpts]. Zero value means that all features are
+// disabled.
+type ZeroLoggerOpts struct {
+	// DedupInterval is a min time period between identical messages.
+	//
+	// Messages are identical if they have the same level, logger name,
+	// text and error. Bound context variables are ignored. Such message is
+	// emitted at most once per interval, the next emission has a
+	// 'repeated' field with a number of suppressed messages. If message is
+	// not seen again, this number is reported with a separate message
+	// later. 0 disables deduplication.
+	DedupInterval time.Duration
+
+	// Level is a min level of messages. It can be changed in runtime and
+	// it applies to all derived loggers at once. A level of a given
+	// zerolog logger is respected as well. nil disables this check.
+	Level *AtomicLevel
+
+	// Sampling limits a number of repeated messages. Sampling is applied
+	// before deduplication so only sampled messages are counted as
+	// repeated.
+	Sampling SamplingOpts
+}
+
+// NewZeroLoggerWithOpts returns a logger which is using rs/zerolog
+// library with given options. All derived loggers share the same state.
+func NewZeroLoggerWithOpts(log zerolog.Logger, opts ZeroLoggerOpts) mtglib.Logger {
+	rv := &zeroLogContext{
+		log:   &log,
+		level: opts.Level,
+	}
+
+	if opts.DedupInterval > 0 {
+		rv.dedup = newDeduplicator(opts.DedupInterval)
 	}
+
+	if opts.Sampling.Interval > 0 {
+		rv.sampler = newSampler(opts.Sampling)
+	}
+
+	return rv
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +26,15 @@ type zeroLoggerLogMessage struct {
 	Message   string `json:"message"`
 }
 
+type zeroLoggerDedupMessage struct {
+	Level    string `json:"level"`
+	StrParam string `json:"strparam"`
+	Logger   string `json:"logger"`
+	Error    string `json:"error"`
+	Message  string `json:"message"`
+	Repeated int    `json:"repeated"`
+}
+
 type ZeroLoggerTestSuite struct {
 	suite.Suite
 }
@@ -116,6 +126,233 @@ func (suite *ZeroLoggerTestSuite) TestIndependence() {
 	suite.NotContains("lalala", log12Output)
 }
 
+func (suite *ZeroLoggerTestSuite) ReadDedupMessages(buf *bytes.Buffer) []zeroLoggerDedupMessage {
+	messages := []zeroLoggerDedupMessage{}
+	decoder := json.NewDecoder(buf)
+
+	for decoder.More() {
+		msg := zeroLoggerDedupMessage{}
+		suite.NoError(decoder.Decode(&msg))
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+func (suite *ZeroLoggerTestSuite) TestDedup() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		DedupInterval: 100 * time.Millisecond,
+	})
+	named := log.Named("name")
+
+	named.BindStr("strparam", "1").WarningError("hello", io.EOF)
+	named.BindStr("strparam", "2").WarningError("hello", io.EOF)
+	log.Named("name").WarningError("hello", io.EOF)
+	named.WarningError("hello", io.ErrUnexpectedEOF)
+	named.Info("hello")
+
+	messages := suite.ReadDedupMessages(buf)
+	suite.Len(messages, 3)
+	suite.Equal("1", messages[0].StrParam)
+	suite.Equal(0, messages[0].Repeated)
+	suite.Equal(io.ErrUnexpectedEOF.Error(), messages[1].Error)
+	suite.Equal("info", messages[2].Level)
+
+	time.Sleep(150 * time.Millisecond)
+
+	named.BindStr("strparam", "3").WarningError("hello", io.EOF)
+
+	messages = suite.ReadDedupMessages(buf)
+	suite.Len(messages, 1)
+	suite.Equal("3", messages[0].StrParam)
+	suite.Equal(2, messages[0].Repeated)
+}
+
+func (suite *ZeroLoggerTestSuite) TestDedupSummary() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		DedupInterval: 100 * time.Millisecond,
+	}).Named("name")
+
+	log.Warning("hello")
+	log.Warning("hello")
+	log.Warning("hello")
+
+	time.Sleep(150 * time.Millisecond)
+
+	log.Warning("world")
+
+	messages := suite.ReadDedupMessages(buf)
+	suite.Len(messages, 3)
+	suite.Equal(zeroLoggerDedupMessage{
+		Level:   "warn",
+		Logger:  "name",
+		Message: "hello",
+	}, messages[0])
+	suite.Equal(zeroLoggerDedupMessage{
+		Level:    "warn",
+		Logger:   "name",
+		Message:  "hello",
+		Repeated: 2,
+	}, messages[1])
+	suite.Equal("world", messages[2].Message)
+}
+
+func (suite *ZeroLoggerTestSuite) TestNoDedup() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{})
+
+	log.Warning("hello")
+	log.Warning("hello")
+
+	suite.Len(suite.ReadDedupMessages(buf), 2)
+}
+
+func (suite *ZeroLoggerTestSuite) TestSampling() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		Sampling: logger.SamplingOpts{
+			Interval:   time.Hour,
+			First:      2,
+			Thereafter: 3,
+		},
+	}).Named("name")
+
+	for i := 0; i < 10; i++ {
+		log.BindInt("intparam", i).InfoError("handshake is failed", io.EOF)
+		log.Debug("other")
+	}
+
+	messages := suite.ReadDedupMessages(buf)
+
+	// 2 first messages and then 5th and 8th of each kind.
+	suite.Len(messages, 8)
+	suite.Equal("handshake is failed", messages[0].Message)
+	suite.Equal("other", messages[1].Message)
+	suite.Equal("handshake is failed", messages[6].Message)
+	suite.Equal("other", messages[7].Message)
+}
+
+func (suite *ZeroLoggerTestSuite) TestSamplingSkipsWarnings() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		Sampling: logger.SamplingOpts{
+			Interval: time.Hour,
+			First:    1,
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		log.WarningError("cannot connect", io.EOF)
+		log.Info("connected")
+	}
+
+	levels := map[string]int{}
+
+	for _, v := range suite.ReadDedupMessages(buf) {
+		levels[v.Level]++
+	}
+
+	suite.Equal(map[string]int{"warn": 5, "info": 1}, levels)
+}
+
+func (suite *ZeroLoggerTestSuite) TestSamplingInterval() {
+	buf := &bytes.Buffer{}
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		Sampling: logger.SamplingOpts{
+			Interval: 100 * time.Millisecond,
+			First:    1,
+		},
+	})
+
+	log.Info("hello")
+	log.Info("hello")
+
+	time.Sleep(150 * time.Millisecond)
+
+	log.Info("hello")
+	log.Info("hello")
+
+	suite.Len(suite.ReadDedupMessages(buf), 2)
+}
+
+func (suite *ZeroLoggerTestSuite) TestLevel() {
+	buf := &bytes.Buffer{}
+	level := logger.NewAtomicLevel(zerolog.WarnLevel)
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(buf), logger.ZeroLoggerOpts{
+		Level: level,
+	}).Named("name")
+
+	log.Debug("debug")
+	log.Info("info")
+	log.Warning("warn")
+
+	messages := suite.ReadDedupMessages(buf)
+	suite.Len(messages, 1)
+	suite.Equal("warn", messages[0].Message)
+
+	level.SetLevel(zerolog.DebugLevel)
+	suite.Equal(zerolog.DebugLevel, level.Level())
+
+	log.BindStr("strparam", "value").Debug("debug")
+
+	messages = suite.ReadDedupMessages(buf)
+	suite.Len(messages, 1)
+	suite.Equal("debug", messages[0].Message)
+	suite.Equal("value", messages[0].StrParam)
+}
+
+func (suite *ZeroLoggerTestSuite) TestCycleLevel() {
+	level := logger.NewAtomicLevel(zerolog.WarnLevel)
+
+	suite.Equal(zerolog.InfoLevel, level.Cycle())
+	suite.Equal(zerolog.DebugLevel, level.Cycle())
+	suite.Equal(zerolog.WarnLevel, level.Cycle())
+	suite.Equal(zerolog.WarnLevel, level.Level())
+
+	level.SetLevel(zerolog.ErrorLevel)
+	suite.Equal(zerolog.InfoLevel, level.Cycle())
+
+	level.SetLevel(zerolog.TraceLevel)
+	suite.Equal(zerolog.WarnLevel, level.Cycle())
+}
+
+func (suite *ZeroLoggerTestSuite) TestCycleLevelConcurrently() {
+	level := logger.NewAtomicLevel(zerolog.WarnLevel)
+	log := logger.NewZeroLoggerWithOpts(zerolog.New(io.Discard), logger.ZeroLoggerOpts{
+		Level: level,
+	})
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				log.Debug("debug")
+				log.Warning("warn")
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 30; j++ {
+				level.Cycle()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// 300 cycles in total is a multiple of 3, so a level is back to warn.
+	suite.Equal(zerolog.WarnLevel, level.Level())
+}
+
 func TestZeroLogger(t *testing.T) { //nolint: paralleltest
 	suite.Run(t, &ZeroLoggerTestSuite{})
 }
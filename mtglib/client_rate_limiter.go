@@ -0,0 +1,89 @@
+package mtglib
+
+import (
+	"container/list"
+	"net"
+	"sync"
+	"time"
+)
+
+type clientRateLimiterEntry struct {
+	key       string
+	tokens    float64
+	updatedAt time.Time
+}
+
+// clientRateLimiter limits a rate of new connections per client IP with
+// token buckets. Each bucket has a capacity of burst tokens and is refilled
+// with rate tokens per second. Each connection takes a token.
+//
+// A number of tracked IPs is bounded: if there is no space for a new IP,
+// the least recently seen one is forgotten. Forgotten IP gets a full bucket
+// again but this is the same as if it was idle for long enough.
+type clientRateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	maxIPs  int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// allow takes a token of IP. It returns false if there are no tokens and
+// connection has to be rejected.
+func (c *clientRateLimiter) allow(ip net.IP, now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := string(ip.To16())
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.evict()
+
+		elem = c.order.PushFront(&clientRateLimiterEntry{
+			key:       key,
+			tokens:    c.burst,
+			updatedAt: now,
+		})
+		c.entries[key] = elem
+	}
+
+	c.order.MoveToFront(elem)
+
+	entry := elem.Value.(*clientRateLimiterEntry) //nolint: forcetypeassert
+
+	if elapsed := now.Sub(entry.updatedAt); elapsed > 0 {
+		entry.tokens += elapsed.Seconds() * c.rate
+		entry.updatedAt = now
+
+		if entry.tokens > c.burst {
+			entry.tokens = c.burst
+		}
+	}
+
+	if entry.tokens < 1 {
+		return false
+	}
+
+	entry.tokens--
+
+	return true
+}
+
+func (c *clientRateLimiter) evict() {
+	for len(c.entries) >= c.maxIPs {
+		entry := c.order.Remove(c.order.Back()).(*clientRateLimiterEntry) //nolint: forcetypeassert
+		delete(c.entries, entry.key)
+	}
+}
+
+func newClientRateLimiter(rate, burst uint, maxIPs int) *clientRateLimiter {
+	return &clientRateLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		maxIPs:  maxIPs,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
@@ -0,0 +1,73 @@
+package mtglib
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ClientRateLimiterTestSuite struct {
+	suite.Suite
+
+	limiter *clientRateLimiter
+	now     time.Time
+	ip      net.IP
+}
+
+func (suite *ClientRateLimiterTestSuite) SetupTest() {
+	suite.limiter = newClientRateLimiter(2, 3, 2)
+	suite.now = time.Now()
+	suite.ip = net.ParseIP("10.0.0.10")
+}
+
+func (suite *ClientRateLimiterTestSuite) TestBurst() {
+	for i := 0; i < 3; i++ {
+		suite.True(suite.limiter.allow(suite.ip, suite.now))
+	}
+
+	suite.False(suite.limiter.allow(suite.ip, suite.now))
+	suite.True(suite.limiter.allow(net.ParseIP("10.0.0.11"), suite.now))
+}
+
+func (suite *ClientRateLimiterTestSuite) TestRefill() {
+	for i := 0; i < 3; i++ {
+		suite.limiter.allow(suite.ip, suite.now)
+	}
+
+	suite.False(suite.limiter.allow(suite.ip, suite.now.Add(100*time.Millisecond)))
+	suite.True(suite.limiter.allow(suite.ip, suite.now.Add(600*time.Millisecond)))
+	suite.False(suite.limiter.allow(suite.ip, suite.now.Add(600*time.Millisecond)))
+
+	for i := 0; i < 3; i++ {
+		suite.True(suite.limiter.allow(suite.ip, suite.now.Add(time.Hour)))
+	}
+
+	suite.False(suite.limiter.allow(suite.ip, suite.now.Add(time.Hour)))
+}
+
+func (suite *ClientRateLimiterTestSuite) TestIPv4IsMappedToIPv6() {
+	for i := 0; i < 3; i++ {
+		suite.limiter.allow(suite.ip.To4(), suite.now)
+	}
+
+	suite.False(suite.limiter.allow(suite.ip.To16(), suite.now))
+}
+
+func (suite *ClientRateLimiterTestSuite) TestEvict() {
+	for i := 0; i < 3; i++ {
+		suite.limiter.allow(suite.ip, suite.now)
+	}
+
+	suite.limiter.allow(net.ParseIP("10.0.0.11"), suite.now)
+	suite.limiter.allow(net.ParseIP("10.0.0.12"), suite.now)
+	suite.Len(suite.limiter.entries, 2)
+
+	suite.True(suite.limiter.allow(suite.ip, suite.now))
+}
+
+func TestClientRateLimiter(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ClientRateLimiterTestSuite{})
+}
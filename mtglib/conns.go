@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 
 	"github.com/IceCodeNew/mtg/essentials"
 )
@@ -15,12 +16,17 @@ type connTraffic struct {
 	streamID string
 	stream   EventStream
 	ctx      context.Context
+	counter  *dcTrafficCounter
 }
 
 func (c connTraffic) Read(b []byte) (int, error) {
 	n, err := c.Conn.Read(b)
 
 	if n > 0 {
+		if c.counter != nil {
+			atomic.AddUint64(&c.counter.downstream, uint64(n))
+		}
+
 		c.stream.Send(c.ctx, NewEventTraffic(c.streamID, uint(n), true))
 	}
 
@@ -31,12 +37,44 @@ func (c connTraffic) Write(b []byte) (int, error) {
 	n, err := c.Conn.Write(b)
 
 	if n > 0 {
+		if c.counter != nil {
+			atomic.AddUint64(&c.counter.upstream, uint64(n))
+		}
+
 		c.stream.Send(c.ctx, NewEventTraffic(c.streamID, uint(n), false))
 	}
 
 	return n, err //nolint: wrapcheck
 }
 
+type connClientTraffic struct {
+	essentials.Conn
+
+	streamID string
+	stream   EventStream
+	ctx      context.Context
+}
+
+func (c connClientTraffic) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+
+	if n > 0 {
+		c.stream.Send(c.ctx, NewEventClientTraffic(c.streamID, uint(n), true))
+	}
+
+	return n, err //nolint: wrapcheck
+}
+
+func (c connClientTraffic) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+
+	if n > 0 {
+		c.stream.Send(c.ctx, NewEventClientTraffic(c.streamID, uint(n), false))
+	}
+
+	return n, err //nolint: wrapcheck
+}
+
 type connRewind struct {
 	essentials.Conn
 
@@ -30,17 +30,20 @@ type ConnTrafficTestSuite struct {
 
 	eventStreamMock *EventStreamMock
 	connMock        *testlib.EssentialsConnMock
+	counter         *dcTrafficCounter
 	conn            io.ReadWriter
 }
 
 func (suite *ConnTrafficTestSuite) SetupTest() {
 	suite.eventStreamMock = &EventStreamMock{}
 	suite.connMock = &testlib.EssentialsConnMock{}
+	suite.counter = &dcTrafficCounter{}
 	suite.conn = connTraffic{
 		Conn:     suite.connMock,
 		streamID: "CONNID",
 		ctx:      context.Background(),
 		stream:   suite.eventStreamMock,
+		counter:  suite.counter,
 	}
 }
 
@@ -67,6 +70,8 @@ func (suite *ConnTrafficTestSuite) TestReadOk() {
 	n, err := suite.conn.Read(make([]byte, 10))
 	suite.NoError(err)
 	suite.Equal(10, n)
+	suite.EqualValues(10, suite.counter.downstream)
+	suite.Zero(suite.counter.upstream)
 }
 
 func (suite *ConnTrafficTestSuite) TestReadErr() { //nolint: dupl
@@ -123,6 +128,8 @@ func (suite *ConnTrafficTestSuite) TestWriteOk() {
 	n, err := suite.conn.Write(make([]byte, 10))
 	suite.NoError(err)
 	suite.Equal(10, n)
+	suite.EqualValues(10, suite.counter.upstream)
+	suite.Zero(suite.counter.downstream)
 }
 
 func (suite *ConnTrafficTestSuite) TestWriteErr() { //nolint: dupl
@@ -0,0 +1,48 @@
+package mtglib
+
+import (
+	"context"
+	"sync"
+)
+
+// dcLimiter bounds a number of concurrent streams connected to each
+// Telegram DC. Slots of DC are allocated on the first use.
+type dcLimiter struct {
+	mutex sync.Mutex
+	limit int
+	slots map[int]chan struct{}
+}
+
+func (d *dcLimiter) getSlots(dc int) chan struct{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	slots, ok := d.slots[dc]
+	if !ok {
+		slots = make(chan struct{}, d.limit)
+		d.slots[dc] = slots
+	}
+
+	return slots
+}
+
+// acquire blocks until DC has a free slot or context is closed.
+func (d *dcLimiter) acquire(ctx context.Context, dc int) error {
+	select {
+	case d.getSlots(dc) <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint: wrapcheck
+	}
+}
+
+func (d *dcLimiter) release(dc int) {
+	<-d.getSlots(dc)
+}
+
+func newDCLimiter(limit int) *dcLimiter {
+	return &dcLimiter{
+		limit: limit,
+		slots: map[int]chan struct{}{},
+	}
+}
@@ -0,0 +1,52 @@
+package mtglib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DCLimiterTestSuite struct {
+	suite.Suite
+
+	limiter *dcLimiter
+}
+
+func (suite *DCLimiterTestSuite) SetupTest() {
+	suite.limiter = newDCLimiter(1)
+}
+
+func (suite *DCLimiterTestSuite) TestDifferentDCs() {
+	suite.NoError(suite.limiter.acquire(context.Background(), 1))
+	suite.NoError(suite.limiter.acquire(context.Background(), 2))
+}
+
+func (suite *DCLimiterTestSuite) TestWaitForRelease() {
+	suite.NoError(suite.limiter.acquire(context.Background(), 1))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		suite.limiter.release(1)
+	}()
+
+	startedAt := time.Now()
+
+	suite.NoError(suite.limiter.acquire(context.Background(), 1))
+	suite.GreaterOrEqual(time.Since(startedAt), 50*time.Millisecond)
+}
+
+func (suite *DCLimiterTestSuite) TestContextIsClosed() {
+	suite.NoError(suite.limiter.acquire(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	suite.ErrorIs(suite.limiter.acquire(ctx, 1), context.DeadlineExceeded)
+}
+
+func TestDCLimiter(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DCLimiterTestSuite{})
+}
@@ -0,0 +1,73 @@
+package mtglib
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// dcTrafficCounter accumulates bytes of all streams of a single DC.
+type dcTrafficCounter struct {
+	// these fields have to be the first ones for atomic operations on
+	// 32-bit platforms
+	upstream   uint64
+	downstream uint64
+}
+
+// dcTraffic keeps counters of all DCs. Copy loops update them with atomic
+// operations only, a proxy periodically takes collected values and emits
+// them as [EventDCTraffic]. So there is no event per read or write.
+type dcTraffic struct {
+	mutex    sync.RWMutex
+	counters map[int]*dcTrafficCounter
+}
+
+func (d *dcTraffic) counter(dc int) *dcTrafficCounter {
+	d.mutex.RLock()
+	counter, ok := d.counters[dc]
+	d.mutex.RUnlock()
+
+	if ok {
+		return counter
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if counter, ok = d.counters[dc]; !ok {
+		counter = &dcTrafficCounter{}
+		d.counters[dc] = counter
+	}
+
+	return counter
+}
+
+// collect returns events with bytes which were counted since the previous
+// call. DCs without traffic are skipped.
+func (d *dcTraffic) collect() []EventDCTraffic {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	rv := make([]EventDCTraffic, 0, len(d.counters))
+
+	for dc, counter := range d.counters {
+		upstream := atomic.SwapUint64(&counter.upstream, 0)
+		downstream := atomic.SwapUint64(&counter.downstream, 0)
+
+		if upstream > 0 || downstream > 0 {
+			rv = append(rv, NewEventDCTraffic(dc, upstream, downstream))
+		}
+	}
+
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].DC < rv[j].DC
+	})
+
+	return rv
+}
+
+func newDCTraffic() *dcTraffic {
+	return &dcTraffic{
+		counters: map[int]*dcTrafficCounter{},
+	}
+}
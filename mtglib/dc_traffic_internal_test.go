@@ -0,0 +1,86 @@
+package mtglib
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DCTrafficTestSuite struct {
+	suite.Suite
+
+	traffic *dcTraffic
+}
+
+func (suite *DCTrafficTestSuite) SetupTest() {
+	suite.traffic = newDCTraffic()
+}
+
+func (suite *DCTrafficTestSuite) TestSameCounter() {
+	suite.Same(suite.traffic.counter(2), suite.traffic.counter(2))
+	suite.NotSame(suite.traffic.counter(2), suite.traffic.counter(-2))
+}
+
+func (suite *DCTrafficTestSuite) TestCollect() {
+	atomic.AddUint64(&suite.traffic.counter(4).downstream, 50)
+	atomic.AddUint64(&suite.traffic.counter(2).upstream, 100)
+	atomic.AddUint64(&suite.traffic.counter(2).downstream, 200)
+	suite.traffic.counter(3)
+
+	collected := suite.traffic.collect()
+	suite.Len(collected, 2)
+
+	suite.Equal(2, collected[0].DC)
+	suite.EqualValues(100, collected[0].Upstream)
+	suite.EqualValues(200, collected[0].Downstream)
+
+	suite.Equal(4, collected[1].DC)
+	suite.EqualValues(0, collected[1].Upstream)
+	suite.EqualValues(50, collected[1].Downstream)
+
+	suite.Empty(suite.traffic.collect())
+
+	atomic.AddUint64(&suite.traffic.counter(2).upstream, 10)
+
+	collected = suite.traffic.collect()
+	suite.Len(collected, 1)
+	suite.EqualValues(10, collected[0].Upstream)
+}
+
+func (suite *DCTrafficTestSuite) TestConcurrently() {
+	wg := &sync.WaitGroup{}
+	total := uint64(0)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				atomic.AddUint64(&suite.traffic.counter(j%5).upstream, 1)
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		for _, evt := range suite.traffic.collect() {
+			total += evt.Upstream
+		}
+	}
+
+	wg.Wait()
+
+	for _, evt := range suite.traffic.collect() {
+		total += evt.Upstream
+	}
+
+	suite.EqualValues(10000, total)
+}
+
+func TestDCTraffic(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DCTrafficTestSuite{})
+}
@@ -64,6 +64,9 @@ type EventTraffic struct {
 // EventFinish is emitted when we stop to manage a connection.
 type EventFinish struct {
 	eventBase
+
+	// Reason is a reason why the stream was closed.
+	Reason CloseReason
 }
 
 // EventDomainFronting is emitted when we connect to a front domain instead of
@@ -102,6 +105,313 @@ type EventIPListSize struct {
 	IsBlockList bool
 }
 
+// EventSecretMatched is emitted when client has passed a faketls handshake with
+// some secret.
+type EventSecretMatched struct {
+	eventBase
+
+	// SecretFingerprint is a fingerprint of the matched secret. Please see
+	// [Secret.Fingerprint] for details.
+	SecretFingerprint string
+
+	// Host is a hostname which client has sent in SNI. It is empty if
+	// client has not sent SNI. Clients with any other hostname than a
+	// hostname of the secret never pass a handshake so this set is bounded
+	// by configured secrets.
+	Host string
+
+	// SecretIndex is an index of the matched secret among secrets of a
+	// proxy. Please see [ProxyOpts.Secrets] for details.
+	SecretIndex int
+}
+
+// EventSecretsConfigured is emitted when proxy gets a new set of secrets it
+// has to serve. Shared proxies do not emit it, please see
+// [ProxyOpts.Shared].
+type EventSecretsConfigured struct {
+	eventBase
+
+	// Count is a number of configured secrets.
+	Count int
+}
+
+// EventDNSQuery is emitted when mtg resolves a hostname with DNS-over-HTTPS
+// resolver.
+type EventDNSQuery struct {
+	eventBase
+
+	// Resolver is an address of DNS-over-HTTPS resolver.
+	Resolver string
+
+	// Hostname is a hostname which was resolved.
+	Hostname string
+
+	// Duration is a time spent on a query. If answer was taken from the
+	// cache, it is 0.
+	Duration time.Duration
+
+	// IsCached is true if answer was taken from the cache.
+	IsCached bool
+
+	// IsFailed is true if query has failed.
+	IsFailed bool
+}
+
+// EventDCEndpointFailover is emitted when proxy could not connect to the
+// primary endpoint of Telegram DC and had to use a secondary one.
+type EventDCEndpointFailover struct {
+	eventBase
+
+	// DC is an index of the datacenter.
+	DC int
+
+	// FailedEndpoints is a number of endpoints which were tried before the
+	// successful one.
+	FailedEndpoints int
+}
+
+// EventProbeTarpitted is emitted when connection from blocklisted or not
+// allowlisted IP address is held in tarpit instead of immediate closing.
+type EventProbeTarpitted struct {
+	eventBase
+
+	// RemoteIP is an IP address of the client.
+	RemoteIP net.IP
+}
+
+// EventDNSCacheUpdated is emitted when DNS cache gets a new entry. It
+// reports a current size of the cache and a number of evicted entries.
+type EventDNSCacheUpdated struct {
+	eventBase
+
+	// Size is a number of entries in the cache.
+	Size int
+
+	// Evicted is a number of entries evicted during this update.
+	Evicted int
+}
+
+// EventHandshakeTooLarge is emitted when client declares a client hello
+// which is larger than allowed. Such connections are closed before the
+// payload is read.
+type EventHandshakeTooLarge struct {
+	eventBase
+}
+
+// EventGeoIPLoadFailed is emitted when GeoIP database cannot be loaded or
+// reloaded. A previous version of the database is kept if it exists.
+type EventGeoIPLoadFailed struct {
+	eventBase
+
+	// Database is a name of the database: 'country' or 'asn'.
+	Database string
+}
+
+// EventUpstreamMirrored is emitted when a connection to upstream was also
+// made with a mirror dialer. It is used to compare a new egress path with a
+// primary one.
+type EventUpstreamMirrored struct {
+	eventBase
+
+	// Address is a host:port of the upstream.
+	Address string
+
+	// PrimaryDuration is a time spent on a dial with a primary dialer.
+	PrimaryDuration time.Duration
+
+	// MirrorDuration is a time spent on a dial with a mirror dialer.
+	MirrorDuration time.Duration
+
+	// PrimaryFailed is true if primary dialer could not connect.
+	PrimaryFailed bool
+
+	// MirrorFailed is true if mirror dialer could not connect.
+	MirrorFailed bool
+}
+
+// EventGeoIPUpdated is emitted when an update of GeoIP database from a
+// remote URL is finished.
+type EventGeoIPUpdated struct {
+	eventBase
+
+	// Database is a name of the database: 'country' or 'asn'.
+	Database string
+
+	// Failed is true if a new version of the database cannot be
+	// downloaded. A previous version is kept in that case.
+	Failed bool
+}
+
+// EventClientTraffic is emitted when some bytes are transmitted over a client
+// connection. Unlike [EventTraffic], it counts raw bytes on the wire
+// including all framing and handshakes. It is a way to compare a volume of
+// client traffic with a volume of payload.
+//
+// Payload is encrypted end to end between a client and Telegram so there
+// is no way to check if it is compressed.
+type EventClientTraffic struct {
+	eventBase
+
+	// Traffic is a count of bytes which were transmitted.
+	Traffic uint
+
+	// IsRead defines if we _read_ from a client connection (so bytes are
+	// going from a client) or _write_ to it.
+	IsRead bool
+}
+
+// EventIPBanned is emitted when client IP address is banned because of too
+// many failed handshakes. Connections from this address are rejected
+// before handshake until the ban is lifted.
+type EventIPBanned struct {
+	eventBase
+
+	// RemoteIP is an IP address of the client.
+	RemoteIP net.IP
+
+	// Duration is a time period this IP address is banned for.
+	Duration time.Duration
+}
+
+// EventIPUnbanned is emitted when a ban of client IP address is lifted.
+type EventIPUnbanned struct {
+	eventBase
+
+	// RemoteIP is an IP address of the client.
+	RemoteIP net.IP
+}
+
+// EventMissingSNI is emitted when client hello has a valid secret but no
+// SNI. It is emitted regardless of a policy for such clients.
+type EventMissingSNI struct {
+	eventBase
+}
+
+// EventDCConnsLimited is emitted when stream had to wait for a free
+// connection slot of Telegram DC longer than
+// [DCConnsLimitedWaitThreshold].
+type EventDCConnsLimited struct {
+	eventBase
+
+	// DC is an index of the datacenter.
+	DC int
+
+	// Waited is a time period stream was waiting for a slot.
+	Waited time.Duration
+}
+
+// EventIncompleteHandshake is emitted when client connection is closed
+// before a handshake is completed. This is what scanners and broken
+// clients usually do. Connections which are explicitly rejected, like
+// replay attacks or invalid client hellos, do not emit this event.
+type EventIncompleteHandshake struct {
+	eventBase
+}
+
+// EventUpstreamProxyStateChanged is emitted when a load balanced upstream
+// proxy is ejected from rotation because of failed dials or restored
+// after a successful probe.
+type EventUpstreamProxyStateChanged struct {
+	eventBase
+
+	// Proxy is a host:port of the proxy.
+	Proxy string
+
+	// Ejected is true if proxy was ejected and false if it was restored.
+	Ejected bool
+}
+
+// EventHandshakeFinished is emitted when a client handshake is either
+// completed or failed. A handshake starts when a client connection is
+// accepted and includes both FakeTLS and obfuscated2 parts.
+type EventHandshakeFinished struct {
+	eventBase
+
+	// Duration is a time spent on a handshake.
+	Duration time.Duration
+
+	// IsFailed is true if handshake has failed. Domain fronted
+	// connections are failed handshakes too.
+	IsFailed bool
+}
+
+// EventActiveStreams is emitted when a number of concurrent streams is
+// changed: a stream is started or finished. Events are timestamped in the
+// same order as the number is changed, so observers which may get them
+// reordered can use timestamps to pick the latest value.
+type EventActiveStreams struct {
+	eventBase
+
+	// Count is a number of concurrent streams.
+	Count int
+}
+
+// EventClientRateLimited is emitted when a connection is rejected because
+// its client IP opens new connections too often.
+type EventClientRateLimited struct {
+	eventBase
+
+	// RemoteIP is an IP address of the client.
+	RemoteIP net.IP
+}
+
+// EventIdleTimeout is emitted when a stream is closed because no data
+// was transmitted in any direction within an idle timeout. Usually this
+// means that a peer has disappeared without closing a connection.
+type EventIdleTimeout struct {
+	eventBase
+}
+
+// EventAntiReplayStats is emitted periodically if anti-replay cache
+// reports its stats. Please see [AntiReplayCacheStats].
+type EventAntiReplayStats struct {
+	eventBase
+
+	// FillRatio is an approximate share of the cache which is in use.
+	FillRatio float64
+
+	// FalsePositiveRate is an estimated probability that a new handshake
+	// is reported as a replay attack.
+	FalsePositiveRate float64
+}
+
+// EventDCTraffic is emitted periodically with a number of bytes which were
+// transferred to and from a Telegram DC since the previous event. DCs
+// without any traffic are not reported.
+type EventDCTraffic struct {
+	eventBase
+
+	// DC is a number of Telegram DC.
+	DC int
+
+	// Upstream is a number of bytes which were sent to the DC.
+	Upstream uint64
+
+	// Downstream is a number of bytes which were received from the DC.
+	Downstream uint64
+}
+
+// EventTelegramDial is emitted after a proxy has tried to connect to a
+// Telegram DC for a stream. It is emitted for both successful and failed
+// dials.
+type EventTelegramDial struct {
+	eventBase
+
+	// DC is a number of Telegram DC.
+	DC int
+
+	// Duration is a time spent on a dial, including attempts of all
+	// endpoints of the DC.
+	Duration time.Duration
+
+	// IsIPv6 is true if a connection is established over IPv6. If a dial
+	// has failed, both families could be tried so it is meaningless.
+	IsIPv6 bool
+
+	// IsFailed is true if proxy could not connect to any endpoint.
+	IsFailed bool
+}
+
 // NewEventStart creates a new EventStart event.
 func NewEventStart(streamID string, remoteIP net.IP) EventStart {
 	return EventStart{
@@ -137,13 +447,20 @@ func NewEventTraffic(streamID string, traffic uint, isRead bool) EventTraffic {
 	}
 }
 
-// NewEventFinish creates a new EventFinish event.
+// NewEventFinish creates a new EventFinish event with unknown close reason.
 func NewEventFinish(streamID string) EventFinish {
+	return NewEventFinishWithReason(streamID, CloseReasonUnknown)
+}
+
+// NewEventFinishWithReason creates a new EventFinish event with a given
+// close reason.
+func NewEventFinishWithReason(streamID string, reason CloseReason) EventFinish {
 	return EventFinish{
 		eventBase: eventBase{
 			timestamp: time.Now(),
 			streamID:  streamID,
 		},
+		Reason: reason,
 	}
 }
 
@@ -210,3 +527,311 @@ func NewEventIPListSize(size int, isBlockList bool) EventIPListSize {
 		IsBlockList: isBlockList,
 	}
 }
+
+// EventSecretMatchedOpts defines fields of an event made by
+// [NewEventSecretMatchedWithOpts]. Please see [EventSecretMatched] for
+// their meaning.
+type EventSecretMatchedOpts struct {
+	// SecretFingerprint is a fingerprint of the matched secret.
+	//
+	// This is a mandatory setting.
+	SecretFingerprint string
+
+	// Host is a hostname from SNI.
+	//
+	// This is an optional setting. Default is no SNI.
+	Host string
+
+	// SecretIndex is an index of the matched secret.
+	//
+	// This is an optional setting. Default is the first secret.
+	SecretIndex int
+}
+
+// NewEventSecretMatched creates a new EventSecretMatched event.
+func NewEventSecretMatched(streamID, secretFingerprint string) EventSecretMatched {
+	return NewEventSecretMatchedWithOpts(streamID, EventSecretMatchedOpts{
+		SecretFingerprint: secretFingerprint,
+	})
+}
+
+// NewEventSecretMatchedWithOpts creates a new EventSecretMatched event with
+// given fields.
+func NewEventSecretMatchedWithOpts(streamID string, opts EventSecretMatchedOpts) EventSecretMatched {
+	return EventSecretMatched{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		SecretFingerprint: opts.SecretFingerprint,
+		Host:              opts.Host,
+		SecretIndex:       opts.SecretIndex,
+	}
+}
+
+// NewEventSecretsConfigured creates a new EventSecretsConfigured event.
+func NewEventSecretsConfigured(count int) EventSecretsConfigured {
+	return EventSecretsConfigured{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Count: count,
+	}
+}
+
+// NewEventDNSQuery creates a new EventDNSQuery event.
+func NewEventDNSQuery(resolver, hostname string, duration time.Duration,
+	isCached, isFailed bool,
+) EventDNSQuery {
+	return EventDNSQuery{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Resolver: resolver,
+		Hostname: hostname,
+		Duration: duration,
+		IsCached: isCached,
+		IsFailed: isFailed,
+	}
+}
+
+// NewEventDCEndpointFailover creates a new EventDCEndpointFailover event.
+func NewEventDCEndpointFailover(streamID string, dc, failedEndpoints int) EventDCEndpointFailover {
+	return EventDCEndpointFailover{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		DC:              dc,
+		FailedEndpoints: failedEndpoints,
+	}
+}
+
+// NewEventProbeTarpitted creates a new EventProbeTarpitted event.
+func NewEventProbeTarpitted(remoteIP net.IP) EventProbeTarpitted {
+	return EventProbeTarpitted{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		RemoteIP: remoteIP,
+	}
+}
+
+// NewEventDNSCacheUpdated creates a new EventDNSCacheUpdated event.
+func NewEventDNSCacheUpdated(size, evicted int) EventDNSCacheUpdated {
+	return EventDNSCacheUpdated{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Size:    size,
+		Evicted: evicted,
+	}
+}
+
+// NewEventHandshakeTooLarge creates a new EventHandshakeTooLarge event.
+func NewEventHandshakeTooLarge(streamID string) EventHandshakeTooLarge {
+	return EventHandshakeTooLarge{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+	}
+}
+
+// NewEventGeoIPLoadFailed creates a new EventGeoIPLoadFailed event.
+func NewEventGeoIPLoadFailed(database string) EventGeoIPLoadFailed {
+	return EventGeoIPLoadFailed{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Database: database,
+	}
+}
+
+// NewEventUpstreamMirrored creates a new EventUpstreamMirrored event.
+func NewEventUpstreamMirrored(address string,
+	primaryDuration, mirrorDuration time.Duration,
+	primaryFailed, mirrorFailed bool,
+) EventUpstreamMirrored {
+	return EventUpstreamMirrored{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Address:         address,
+		PrimaryDuration: primaryDuration,
+		MirrorDuration:  mirrorDuration,
+		PrimaryFailed:   primaryFailed,
+		MirrorFailed:    mirrorFailed,
+	}
+}
+
+// NewEventGeoIPUpdated creates a new EventGeoIPUpdated event.
+func NewEventGeoIPUpdated(database string, failed bool) EventGeoIPUpdated {
+	return EventGeoIPUpdated{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Database: database,
+		Failed:   failed,
+	}
+}
+
+// NewEventClientTraffic creates a new EventClientTraffic event.
+func NewEventClientTraffic(streamID string, traffic uint, isRead bool) EventClientTraffic {
+	return EventClientTraffic{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		Traffic: traffic,
+		IsRead:  isRead,
+	}
+}
+
+// NewEventIPBanned creates a new EventIPBanned event.
+func NewEventIPBanned(remoteIP net.IP, duration time.Duration) EventIPBanned {
+	return EventIPBanned{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		RemoteIP: remoteIP,
+		Duration: duration,
+	}
+}
+
+// NewEventIPUnbanned creates a new EventIPUnbanned event.
+func NewEventIPUnbanned(remoteIP net.IP) EventIPUnbanned {
+	return EventIPUnbanned{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		RemoteIP: remoteIP,
+	}
+}
+
+// NewEventMissingSNI creates a new EventMissingSNI event.
+func NewEventMissingSNI(streamID string) EventMissingSNI {
+	return EventMissingSNI{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+	}
+}
+
+// NewEventDCConnsLimited creates a new EventDCConnsLimited event.
+func NewEventDCConnsLimited(streamID string, dc int, waited time.Duration) EventDCConnsLimited {
+	return EventDCConnsLimited{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		DC:     dc,
+		Waited: waited,
+	}
+}
+
+// NewEventIncompleteHandshake creates a new EventIncompleteHandshake event.
+func NewEventIncompleteHandshake(streamID string) EventIncompleteHandshake {
+	return EventIncompleteHandshake{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+	}
+}
+
+// NewEventUpstreamProxyStateChanged creates a new
+// EventUpstreamProxyStateChanged event.
+func NewEventUpstreamProxyStateChanged(proxy string, ejected bool) EventUpstreamProxyStateChanged {
+	return EventUpstreamProxyStateChanged{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Proxy:   proxy,
+		Ejected: ejected,
+	}
+}
+
+// NewEventHandshakeFinished creates a new EventHandshakeFinished event.
+func NewEventHandshakeFinished(streamID string, duration time.Duration,
+	isFailed bool,
+) EventHandshakeFinished {
+	return EventHandshakeFinished{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		Duration: duration,
+		IsFailed: isFailed,
+	}
+}
+
+// NewEventActiveStreams creates a new EventActiveStreams event.
+func NewEventActiveStreams(count int) EventActiveStreams {
+	return EventActiveStreams{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		Count: count,
+	}
+}
+
+// NewEventClientRateLimited creates a new EventClientRateLimited event.
+func NewEventClientRateLimited(remoteIP net.IP) EventClientRateLimited {
+	return EventClientRateLimited{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		RemoteIP: remoteIP,
+	}
+}
+
+// NewEventIdleTimeout creates a new EventIdleTimeout event.
+func NewEventIdleTimeout(streamID string) EventIdleTimeout {
+	return EventIdleTimeout{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+	}
+}
+
+// NewEventAntiReplayStats creates a new EventAntiReplayStats event.
+func NewEventAntiReplayStats(fillRatio, falsePositiveRate float64) EventAntiReplayStats {
+	return EventAntiReplayStats{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		FillRatio:         fillRatio,
+		FalsePositiveRate: falsePositiveRate,
+	}
+}
+
+// NewEventDCTraffic creates a new EventDCTraffic event.
+func NewEventDCTraffic(dc int, upstream, downstream uint64) EventDCTraffic {
+	return EventDCTraffic{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+		},
+		DC:         dc,
+		Upstream:   upstream,
+		Downstream: downstream,
+	}
+}
+
+// NewEventTelegramDial creates a new EventTelegramDial event.
+func NewEventTelegramDial(streamID string, dc int, duration time.Duration,
+	isIPv6, isFailed bool,
+) EventTelegramDial {
+	return EventTelegramDial{
+		eventBase: eventBase{
+			timestamp: time.Now(),
+			streamID:  streamID,
+		},
+		DC:       dc,
+		Duration: duration,
+		IsIPv6:   isIPv6,
+		IsFailed: isFailed,
+	}
+}
@@ -25,6 +25,15 @@ func (suite *EventsTestSuite) TestEventFinish() {
 
 	suite.Equal("CONNID", evt.StreamID())
 	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(mtglib.CloseReasonUnknown, evt.Reason)
+}
+
+func (suite *EventsTestSuite) TestEventFinishWithReason() {
+	evt := mtglib.NewEventFinishWithReason("CONNID", mtglib.CloseReasonIdleTimeout)
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(mtglib.CloseReasonIdleTimeout, evt.Reason)
 }
 
 func (suite *EventsTestSuite) TestEventConnectedToDC() {
@@ -87,6 +96,231 @@ func (suite *EventsTestSuite) TestEventIPListSize() {
 	suite.False(evt.IsBlockList)
 }
 
+func (suite *EventsTestSuite) TestEventSecretMatched() {
+	evt := mtglib.NewEventSecretMatched("CONNID", "0011223344556677")
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("0011223344556677", evt.SecretFingerprint)
+	suite.Empty(evt.Host)
+}
+
+func (suite *EventsTestSuite) TestEventSecretMatchedWithOpts() {
+	evt := mtglib.NewEventSecretMatchedWithOpts("CONNID", mtglib.EventSecretMatchedOpts{
+		SecretFingerprint: "0011223344556677",
+		Host:              "example.com",
+		SecretIndex:       2,
+	})
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("0011223344556677", evt.SecretFingerprint)
+	suite.Equal("example.com", evt.Host)
+	suite.Equal(2, evt.SecretIndex)
+}
+
+func (suite *EventsTestSuite) TestEventSecretsConfigured() {
+	evt := mtglib.NewEventSecretsConfigured(2)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(2, evt.Count)
+}
+
+func (suite *EventsTestSuite) TestEventDNSQuery() {
+	evt := mtglib.NewEventDNSQuery("9.9.9.9", "google.com", time.Second, false, true)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("9.9.9.9", evt.Resolver)
+	suite.Equal("google.com", evt.Hostname)
+	suite.Equal(time.Second, evt.Duration)
+	suite.False(evt.IsCached)
+	suite.True(evt.IsFailed)
+}
+
+func (suite *EventsTestSuite) TestEventDCEndpointFailover() {
+	evt := mtglib.NewEventDCEndpointFailover("CONNID", 2, 1)
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(2, evt.DC)
+	suite.Equal(1, evt.FailedEndpoints)
+}
+
+func (suite *EventsTestSuite) TestEventProbeTarpitted() {
+	evt := mtglib.NewEventProbeTarpitted(net.ParseIP("10.0.0.10"))
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("10.0.0.10", evt.RemoteIP.String())
+}
+
+func (suite *EventsTestSuite) TestEventIPBanned() {
+	evt := mtglib.NewEventIPBanned(net.ParseIP("10.0.0.10"), time.Minute)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("10.0.0.10", evt.RemoteIP.String())
+	suite.Equal(time.Minute, evt.Duration)
+}
+
+func (suite *EventsTestSuite) TestEventIPUnbanned() {
+	evt := mtglib.NewEventIPUnbanned(net.ParseIP("10.0.0.10"))
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("10.0.0.10", evt.RemoteIP.String())
+}
+
+func (suite *EventsTestSuite) TestEventMissingSNI() {
+	evt := mtglib.NewEventMissingSNI("connID")
+
+	suite.Equal("connID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+}
+
+func (suite *EventsTestSuite) TestEventDCConnsLimited() {
+	evt := mtglib.NewEventDCConnsLimited("connID", 2, time.Second)
+
+	suite.Equal("connID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(2, evt.DC)
+	suite.Equal(time.Second, evt.Waited)
+}
+
+func (suite *EventsTestSuite) TestEventIncompleteHandshake() {
+	evt := mtglib.NewEventIncompleteHandshake("connID")
+
+	suite.Equal("connID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+}
+
+func (suite *EventsTestSuite) TestEventUpstreamProxyStateChanged() {
+	evt := mtglib.NewEventUpstreamProxyStateChanged("127.0.0.1:1080", true)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("127.0.0.1:1080", evt.Proxy)
+	suite.True(evt.Ejected)
+}
+
+func (suite *EventsTestSuite) TestEventHandshakeFinished() {
+	evt := mtglib.NewEventHandshakeFinished("connID", time.Second, true)
+
+	suite.Equal("connID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(time.Second, evt.Duration)
+	suite.True(evt.IsFailed)
+}
+
+func (suite *EventsTestSuite) TestEventActiveStreams() {
+	evt := mtglib.NewEventActiveStreams(10)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(10, evt.Count)
+}
+
+func (suite *EventsTestSuite) TestEventClientRateLimited() {
+	evt := mtglib.NewEventClientRateLimited(net.ParseIP("10.0.0.10"))
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("10.0.0.10", evt.RemoteIP.String())
+}
+
+func (suite *EventsTestSuite) TestEventIdleTimeout() {
+	evt := mtglib.NewEventIdleTimeout("connID")
+
+	suite.Equal("connID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+}
+
+func (suite *EventsTestSuite) TestEventAntiReplayStats() {
+	evt := mtglib.NewEventAntiReplayStats(0.5, 0.01)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(0.5, evt.FillRatio)
+	suite.Equal(0.01, evt.FalsePositiveRate)
+}
+
+func (suite *EventsTestSuite) TestEventDNSCacheUpdated() {
+	evt := mtglib.NewEventDNSCacheUpdated(10, 1)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(10, evt.Size)
+	suite.Equal(1, evt.Evicted)
+}
+
+func (suite *EventsTestSuite) TestEventHandshakeTooLarge() {
+	evt := mtglib.NewEventHandshakeTooLarge("CONNID")
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+}
+
+func (suite *EventsTestSuite) TestEventGeoIPLoadFailed() {
+	evt := mtglib.NewEventGeoIPLoadFailed("country")
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("country", evt.Database)
+}
+
+func (suite *EventsTestSuite) TestEventUpstreamMirrored() {
+	evt := mtglib.NewEventUpstreamMirrored("127.0.0.1:443", time.Second, 2*time.Second, false, true)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("127.0.0.1:443", evt.Address)
+	suite.Equal(time.Second, evt.PrimaryDuration)
+	suite.Equal(2*time.Second, evt.MirrorDuration)
+	suite.False(evt.PrimaryFailed)
+	suite.True(evt.MirrorFailed)
+}
+
+func (suite *EventsTestSuite) TestEventGeoIPUpdated() {
+	evt := mtglib.NewEventGeoIPUpdated("asn", true)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal("asn", evt.Database)
+	suite.True(evt.Failed)
+}
+
+func (suite *EventsTestSuite) TestEventClientTraffic() {
+	evt := mtglib.NewEventClientTraffic("CONNID", 1024, true)
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.EqualValues(1024, evt.Traffic)
+	suite.True(evt.IsRead)
+}
+
+func (suite *EventsTestSuite) TestEventDCTraffic() {
+	evt := mtglib.NewEventDCTraffic(2, 100, 200)
+
+	suite.Empty(evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(2, evt.DC)
+	suite.EqualValues(100, evt.Upstream)
+	suite.EqualValues(200, evt.Downstream)
+}
+
+func (suite *EventsTestSuite) TestEventTelegramDial() {
+	evt := mtglib.NewEventTelegramDial("CONNID", 2, time.Second, true, false)
+
+	suite.Equal("CONNID", evt.StreamID())
+	suite.WithinDuration(time.Now(), evt.Timestamp(), 10*time.Millisecond)
+	suite.Equal(2, evt.DC)
+	suite.Equal(time.Second, evt.Duration)
+	suite.True(evt.IsIPv6)
+	suite.False(evt.IsFailed)
+}
+
 func TestEvents(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &EventsTestSuite{})
@@ -0,0 +1,170 @@
+package mtglib
+
+import (
+	"container/list"
+	"net"
+	"sync"
+	"time"
+)
+
+type handshakeBanEntry struct {
+	ip          net.IP
+	key         string
+	windowStart time.Time
+	failures    uint
+	bannedUntil time.Time
+}
+
+func (h *handshakeBanEntry) isBanned() bool {
+	return !h.bannedUntil.IsZero()
+}
+
+// handshakeBans counts failed handshakes per client IP and bans IPs which
+// fail too often. Failures are counted within fixed windows: a window is
+// started by the first failure and counter is reset when it is over.
+//
+// A number of tracked IPs is bounded: if there is no space for a new IP,
+// the least recently seen one is forgotten. This applies to banned IPs as
+// well, otherwise a flood of bans could grow memory without any limit.
+type handshakeBans struct {
+	mutex       sync.Mutex
+	maxFailures uint
+	window      time.Duration
+	duration    time.Duration
+	maxIPs      int
+	entries     map[string]*list.Element
+	order       *list.List
+}
+
+// isBanned checks if IP is banned now. If ban of IP is expired, it is
+// lifted and unbanned is true.
+func (h *handshakeBans) isBanned(ip net.IP, now time.Time) (banned, unbanned bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	elem, ok := h.entries[string(ip.To16())]
+	if !ok {
+		return false, false
+	}
+
+	entry := elem.Value.(*handshakeBanEntry) //nolint: forcetypeassert
+
+	if !entry.isBanned() {
+		return false, false
+	}
+
+	if now.Before(entry.bannedUntil) {
+		h.order.MoveToFront(elem)
+
+		return true, false
+	}
+
+	h.remove(elem)
+
+	return false, true
+}
+
+// addFailure records a failed handshake. It returns true if IP has to be
+// banned because of this failure. It also returns banned IPs which were
+// forgotten to free space for this one.
+func (h *handshakeBans) addFailure(ip net.IP, now time.Time) (bool, []net.IP) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := string(ip.To16())
+
+	var evicted []net.IP
+
+	elem, ok := h.entries[key]
+	if !ok {
+		evicted = h.evict()
+		elem = h.order.PushFront(&handshakeBanEntry{
+			ip:          ip,
+			key:         key,
+			windowStart: now,
+		})
+		h.entries[key] = elem
+	}
+
+	h.order.MoveToFront(elem)
+
+	entry := elem.Value.(*handshakeBanEntry) //nolint: forcetypeassert
+
+	if entry.isBanned() {
+		return false, evicted
+	}
+
+	if now.Sub(entry.windowStart) >= h.window {
+		entry.windowStart = now
+		entry.failures = 0
+	}
+
+	entry.failures++
+
+	if entry.failures < h.maxFailures {
+		return false, evicted
+	}
+
+	entry.bannedUntil = now.Add(h.duration)
+
+	return true, evicted
+}
+
+// sweep lifts all expired bans and returns IPs which were unbanned.
+func (h *handshakeBans) sweep(now time.Time) []net.IP {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var unbanned []net.IP
+
+	for elem := h.order.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*handshakeBanEntry) //nolint: forcetypeassert
+
+		switch {
+		case entry.isBanned() && !now.Before(entry.bannedUntil):
+			unbanned = append(unbanned, entry.ip)
+
+			h.remove(elem)
+		case !entry.isBanned() && now.Sub(entry.windowStart) >= h.window:
+			h.remove(elem)
+		}
+
+		elem = next
+	}
+
+	return unbanned
+}
+
+func (h *handshakeBans) evict() []net.IP {
+	var evicted []net.IP
+
+	for len(h.entries) >= h.maxIPs {
+		elem := h.order.Back()
+		entry := elem.Value.(*handshakeBanEntry) //nolint: forcetypeassert
+
+		if entry.isBanned() {
+			evicted = append(evicted, entry.ip)
+		}
+
+		h.remove(elem)
+	}
+
+	return evicted
+}
+
+func (h *handshakeBans) remove(elem *list.Element) {
+	entry := h.order.Remove(elem).(*handshakeBanEntry) //nolint: forcetypeassert
+	delete(h.entries, entry.key)
+}
+
+func newHandshakeBans(maxFailures uint, window, duration time.Duration, maxIPs int) *handshakeBans {
+	return &handshakeBans{
+		maxFailures: maxFailures,
+		window:      window,
+		duration:    duration,
+		maxIPs:      maxIPs,
+		entries:     map[string]*list.Element{},
+		order:       list.New(),
+	}
+}
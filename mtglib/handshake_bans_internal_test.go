@@ -0,0 +1,100 @@
+package mtglib
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HandshakeBansTestSuite struct {
+	suite.Suite
+
+	bans *handshakeBans
+	now  time.Time
+	ip   net.IP
+}
+
+func (suite *HandshakeBansTestSuite) SetupTest() {
+	suite.bans = newHandshakeBans(3, time.Minute, 10*time.Minute, 2)
+	suite.now = time.Now()
+	suite.ip = net.ParseIP("10.0.0.10")
+}
+
+func (suite *HandshakeBansTestSuite) TestBan() {
+	for i := 0; i < 2; i++ {
+		banned, _ := suite.bans.addFailure(suite.ip, suite.now)
+		suite.False(banned)
+	}
+
+	banned, _ := suite.bans.isBanned(suite.ip, suite.now)
+	suite.False(banned)
+
+	banned, _ = suite.bans.addFailure(suite.ip, suite.now)
+	suite.True(banned)
+
+	banned, unbanned := suite.bans.isBanned(suite.ip, suite.now.Add(time.Minute))
+	suite.True(banned)
+	suite.False(unbanned)
+
+	banned, unbanned = suite.bans.isBanned(suite.ip, suite.now.Add(10*time.Minute))
+	suite.False(banned)
+	suite.True(unbanned)
+
+	banned, unbanned = suite.bans.isBanned(suite.ip, suite.now.Add(10*time.Minute))
+	suite.False(banned)
+	suite.False(unbanned)
+}
+
+func (suite *HandshakeBansTestSuite) TestWindowIsOver() {
+	suite.bans.addFailure(suite.ip, suite.now)
+	suite.bans.addFailure(suite.ip, suite.now)
+
+	banned, _ := suite.bans.addFailure(suite.ip, suite.now.Add(time.Minute))
+	suite.False(banned)
+}
+
+func (suite *HandshakeBansTestSuite) TestIPv4IsMappedToIPv6() {
+	suite.bans.addFailure(suite.ip, suite.now)
+	suite.bans.addFailure(suite.ip.To16(), suite.now)
+
+	banned, _ := suite.bans.addFailure(suite.ip.To4(), suite.now)
+	suite.True(banned)
+}
+
+func (suite *HandshakeBansTestSuite) TestEvict() {
+	for i := 0; i < 3; i++ {
+		suite.bans.addFailure(suite.ip, suite.now)
+	}
+
+	suite.bans.addFailure(net.ParseIP("10.0.0.11"), suite.now)
+
+	_, evicted := suite.bans.addFailure(net.ParseIP("10.0.0.12"), suite.now)
+	suite.Len(evicted, 1)
+	suite.Equal("10.0.0.10", evicted[0].String())
+
+	banned, _ := suite.bans.isBanned(suite.ip, suite.now)
+	suite.False(banned)
+}
+
+func (suite *HandshakeBansTestSuite) TestSweep() {
+	for i := 0; i < 3; i++ {
+		suite.bans.addFailure(suite.ip, suite.now)
+	}
+
+	suite.bans.addFailure(net.ParseIP("10.0.0.11"), suite.now)
+
+	suite.Empty(suite.bans.sweep(suite.now.Add(time.Minute)))
+	suite.Len(suite.bans.entries, 1)
+
+	unbanned := suite.bans.sweep(suite.now.Add(10 * time.Minute))
+	suite.Len(unbanned, 1)
+	suite.Equal("10.0.0.10", unbanned[0].String())
+	suite.Empty(suite.bans.entries)
+}
+
+func TestHandshakeBans(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &HandshakeBansTestSuite{})
+}
@@ -58,6 +58,97 @@ var (
 	// ErrLoggerIsNotDefined is returned if you are trying to create a proxy but
 	// logger is not defined.
 	ErrLoggerIsNotDefined = errors.New("logger is not defined")
+
+	// ErrUnknownClientIPPolicyInvalid is returned if you are trying to create
+	// a proxy with unsupported policy for unknown client IPs or with fallback
+	// policy but without fallback IP.
+	ErrUnknownClientIPPolicyInvalid = errors.New("unknown client ip policy is invalid")
+
+	// ErrIPListPrecedenceInvalid is returned if you are trying to create a
+	// proxy with unsupported precedence of IP allowlist and blocklist.
+	ErrIPListPrecedenceInvalid = errors.New("ip list precedence is invalid")
+
+	// ErrMissingSNIPolicyInvalid is returned if you are trying to create a
+	// proxy with unsupported policy for clients without SNI.
+	ErrMissingSNIPolicyInvalid = errors.New("missing sni policy is invalid")
+)
+
+// ContextKey is a type of keys of the values mtg stores in stream contexts.
+// These contexts are passed to [EventStream.Send] so custom event streams can
+// attribute events without parsing them.
+type ContextKey string
+
+// CloseReason describes why a stream was closed. It is passed with
+// [EventFinish].
+//
+// Connections rejected before a stream is started (by blocklists,
+// concurrency limits or rate limits) have no close reason: they emit their
+// own events instead.
+type CloseReason string
+
+const (
+	// CloseReasonUnknown means that a stream was closed because of
+	// something mtg has not tracked.
+	CloseReasonUnknown CloseReason = "unknown"
+
+	// CloseReasonClientClosed means that a client has finished a relayed
+	// stream first: it has closed a connection or it was broken.
+	CloseReasonClientClosed CloseReason = "client-closed"
+
+	// CloseReasonTelegramClosed means that Telegram has finished a relayed
+	// stream first.
+	CloseReasonTelegramClosed CloseReason = "telegram-closed"
+
+	// CloseReasonIdleTimeout means that nothing was transmitted within an
+	// idle timeout. Please see [ProxyOpts.IdleTimeout].
+	CloseReasonIdleTimeout CloseReason = "idle-timeout"
+
+	// CloseReasonTelegramUnavailable means that mtg has failed to connect
+	// to Telegram.
+	CloseReasonTelegramUnavailable CloseReason = "telegram-unavailable"
+
+	// CloseReasonHandshakeFailed means that a client has not passed a
+	// handshake: for example, a client hello is invalid or it has no SNI.
+	CloseReasonHandshakeFailed CloseReason = "handshake-failed"
+
+	// CloseReasonHandshakeTooLarge means that a client has sent a
+	// handshake record which exceeds a limit.
+	CloseReasonHandshakeTooLarge CloseReason = "handshake-too-large"
+
+	// CloseReasonIncompleteHandshake means that a client has disconnected
+	// or timed out in the middle of a handshake.
+	CloseReasonIncompleteHandshake CloseReason = "incomplete-handshake"
+
+	// CloseReasonReplayAttack means that a client has sent a handshake
+	// which was seen before.
+	CloseReasonReplayAttack CloseReason = "replay-attack"
+
+	// CloseReasonDomainFronting means that a stream was relayed to a
+	// fronting domain.
+	CloseReasonDomainFronting CloseReason = "domain-fronting"
+
+	// CloseReasonShutdown means that a stream was closed because proxy
+	// was shutting down.
+	CloseReasonShutdown CloseReason = "shutdown"
+)
+
+const (
+	// ContextKeySecretFingerprint is a key of a string value with a
+	// fingerprint of the secret matched by a stream. Please see
+	// [Secret.Fingerprint] for details. It is set after a successful faketls
+	// handshake.
+	ContextKeySecretFingerprint ContextKey = "secret-fingerprint"
+
+	// ContextKeySecretTag is a key of a string value with a tag of the
+	// matched secret. It is set after a successful faketls handshake if the
+	// secret has a tag.
+	ContextKeySecretTag ContextKey = "secret-tag"
+
+	// ContextKeyClientIP is a key of a [net.IP] value with an IP address of
+	// the client. It is set if IP address of the client is known. This
+	// context is also passed to [Network.DialContext] so a network can
+	// choose an egress path based on a client.
+	ContextKeyClientIP ContextKey = "client-ip"
 )
 
 const (
@@ -65,6 +156,47 @@ const (
 	// clients.
 	DefaultConcurrency = 4096
 
+	// DefaultAdmissionQueueTimeout is a default max time period connection
+	// may wait in admission queue for a free worker.
+	DefaultAdmissionQueueTimeout = time.Second
+
+	// DefaultProbeTarpitDuration is a default time period connection is held
+	// in tarpit.
+	DefaultProbeTarpitDuration = 30 * time.Second
+
+	// DefaultProbeTarpitMaxConnections is a default max count of connections
+	// which can be held in tarpit simultaneously.
+	DefaultProbeTarpitMaxConnections = 128
+
+	// DefaultHandshakeFailureBanThreshold is a default number of failed
+	// handshakes after which IP address is banned if bans are enabled.
+	DefaultHandshakeFailureBanThreshold = 10
+
+	// DefaultHandshakeFailureBanWindow is a default time period failed
+	// handshakes are counted within.
+	DefaultHandshakeFailureBanWindow = time.Minute
+
+	// DefaultHandshakeFailureBanDuration is a default time period IP
+	// address is banned for.
+	DefaultHandshakeFailureBanDuration = 10 * time.Minute
+
+	// DefaultHandshakeFailureBanMaxIPs is a default max count of IP
+	// addresses failed handshakes are tracked for.
+	DefaultHandshakeFailureBanMaxIPs = 16384
+
+	// DefaultClientRateLimit is a default number of new connections per
+	// second allowed from the same client IP if rate limiting is enabled.
+	DefaultClientRateLimit = 10
+
+	// DefaultClientRateLimitMaxIPs is a default max count of IP addresses
+	// connection rates are tracked for.
+	DefaultClientRateLimitMaxIPs = 16384
+
+	// DCConnsLimitedWaitThreshold is a time period a stream may wait for a
+	// free slot of DC silently. If it waits longer, EventDCConnsLimited is
+	// emitted.
+	DCConnsLimitedWaitThreshold = 100 * time.Millisecond
+
 	// DefaultBufferSize is a default size of a copy buffer.
 	//
 	// Deprecated: this setting no longer makes any effect.
@@ -74,12 +206,34 @@ const (
 	// of probe-resistance activity.
 	DefaultDomainFrontingPort = 443
 
+	// AntiReplayStatsInterval defines how often proxy reports stats of
+	// anti-replay cache. Please see [AntiReplayCacheStats].
+	AntiReplayStatsInterval = time.Minute
+
+	// DCTrafficReportInterval defines how often proxy reports traffic of
+	// Telegram DCs. Please see [EventDCTraffic].
+	DCTrafficReportInterval = 10 * time.Second
+
 	// DefaultIdleTimeout is a default timeout for closing a connection in case of
 	// idling.
 	//
 	// Deprecated: no longer in use because of changed TCP relay algorithm.
 	DefaultIdleTimeout = time.Minute
 
+	// MinSafeIdleTimeout is the shortest idle timeout which is considered
+	// safe. Shorter timeouts may close streams of clients which are alive
+	// but have nothing to send for a while. Please see
+	// [ProxyOpts.IdleTimeout].
+	MinSafeIdleTimeout = 5 * time.Minute
+
+	// DefaultIdleSweepInterval is a default time period between checks of
+	// idle streams. Please see [ProxyOpts.IdleSweepInterval].
+	DefaultIdleSweepInterval = 10 * time.Second
+
+	// MaxHandshakeJitter is a max delay which can be added to a faketls
+	// handshake response.
+	MaxHandshakeJitter = 500 * time.Millisecond
+
 	// DefaultTolerateTimeSkewness is a default timeout for time skewness on a
 	// faketls timeout verification.
 	DefaultTolerateTimeSkewness = 3 * time.Second
@@ -87,6 +241,56 @@ const (
 	// DefaultPreferIP is a default value for Telegram IP connectivity preference.
 	DefaultPreferIP = "prefer-ipv6"
 
+	// MaxDC is the largest number of production Telegram DC.
+	MaxDC = 5
+
+	// UnknownClientIPPolicyReject closes connections if IP address of the
+	// client cannot be determined.
+	UnknownClientIPPolicyReject = "reject"
+
+	// UnknownClientIPPolicyAllow serves connections with unknown client IP
+	// address but skips IP allowlist and blocklist checks for them.
+	UnknownClientIPPolicyAllow = "allow"
+
+	// UnknownClientIPPolicyFallback uses a fallback IP address for the
+	// clients with unknown IP address. All IP checks are applied to it.
+	UnknownClientIPPolicyFallback = "fallback"
+
+	// DefaultUnknownClientIPPolicy is a default policy for connections with
+	// unknown client IP address.
+	DefaultUnknownClientIPPolicy = UnknownClientIPPolicyReject
+
+	// IPListPrecedenceBlockWins serves a connection only if client IP is
+	// in allowlist and is not in blocklist. If IP is in both lists, it is
+	// rejected. IPs which are in neither list are rejected.
+	IPListPrecedenceBlockWins = "block-wins"
+
+	// IPListPrecedenceAllowWins rejects a connection only if client IP is
+	// in blocklist and is not in allowlist. If IP is in both lists, it is
+	// served. IPs which are in neither list are served: allowlist is a
+	// list of exceptions from blocklist.
+	IPListPrecedenceAllowWins = "allow-wins"
+
+	// DefaultIPListPrecedence is a default precedence of IP allowlist and
+	// blocklist.
+	DefaultIPListPrecedence = IPListPrecedenceBlockWins
+
+	// MissingSNIPolicyAllow lets clients which send no SNI in client
+	// hello to proceed to handshake. Secret still has to match.
+	MissingSNIPolicyAllow = "allow"
+
+	// MissingSNIPolicyReject closes connections of clients which send no
+	// SNI in client hello.
+	MissingSNIPolicyReject = "reject"
+
+	// MissingSNIPolicyFront routes clients which send no SNI in client
+	// hello to a fronting domain as if their secret was wrong.
+	MissingSNIPolicyFront = "front"
+
+	// DefaultMissingSNIPolicy is a default policy for clients which send
+	// no SNI in client hello.
+	DefaultMissingSNIPolicy = MissingSNIPolicyAllow
+
 	// SecretKeyLength defines a length of the secret bytes used by Telegram and a
 	// proxy.
 	SecretKeyLength = 16
@@ -162,6 +366,19 @@ type AntiReplayCache interface {
 	SeenBefore(data []byte) bool
 }
 
+// AntiReplayCacheStats is an optional interface of [AntiReplayCache] which
+// can report how full it is. If a cache implements it, proxy emits
+// [EventAntiReplayStats] each [AntiReplayStatsInterval].
+type AntiReplayCacheStats interface {
+	// FillRatio returns an approximate share of the cache which is in use,
+	// from 0 to 1.
+	FillRatio() float64
+
+	// FalsePositiveRate returns an estimated probability that a new
+	// handshake is reported as seen before.
+	FalsePositiveRate() float64
+}
+
 // IPBlocklist filters requests based on IP address.
 //
 // If this filter has an IP address, then mtg closes a request without reading
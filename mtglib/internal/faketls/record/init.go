@@ -1,9 +1,16 @@
 package record
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 const TLSMaxRecordSize = 65535 // max uint16
 
+// ErrRecordTooLarge is returned if a payload length of the record exceeds a
+// given limit.
+var ErrRecordTooLarge = errors.New("record is too large")
+
 type Type uint8
 
 const (
@@ -26,6 +26,12 @@ func (r *Record) Reset() {
 }
 
 func (r *Record) Read(reader io.Reader) error {
+	return r.ReadLimit(reader, TLSMaxRecordSize)
+}
+
+// ReadLimit is the same as Read but it rejects records with payload larger
+// than maxPayloadSize bytes. A payload of such records is not read at all.
+func (r *Record) ReadLimit(reader io.Reader, maxPayloadSize int) error {
 	r.Reset()
 
 	buf := [2]byte{}
@@ -53,6 +59,10 @@ func (r *Record) Read(reader io.Reader) error {
 	}
 
 	length := int64(binary.BigEndian.Uint16(buf[:]))
+	if length > int64(maxPayloadSize) {
+		return fmt.Errorf("payload length %d exceeds %d: %w", length, maxPayloadSize, ErrRecordTooLarge)
+	}
+
 	if _, err := io.CopyN(&r.Payload, reader, length); err != nil {
 		return fmt.Errorf("cannot read payload: %w", err)
 	}
@@ -65,6 +65,19 @@ func (suite *RecordTestSuite) TestIdempotent() {
 	suite.Equal([]byte{1, 2, 3}, suite.r.Payload.Bytes())
 }
 
+func (suite *RecordTestSuite) TestReadLimit() {
+	suite.r.Type = record.TypeHandshake
+	suite.r.Version = record.Version10
+
+	suite.r.Payload.Write([]byte{1, 2, 3})
+	suite.NoError(suite.r.Dump(suite.buf))
+
+	suite.r.Reset()
+	suite.ErrorIs(suite.r.ReadLimit(suite.buf, 2), record.ErrRecordTooLarge)
+	suite.Equal(3, suite.buf.Len())
+	suite.Equal(0, suite.r.Payload.Len())
+}
+
 func (suite *RecordTestSuite) TestString() {
 	_ = suite.r.String()
 }
@@ -0,0 +1,32 @@
+package relay
+
+import (
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+)
+
+// deadlineConn sets a rolling deadline before each read and write. Read and
+// write deadlines are independent: one pump reads from a connection while
+// another one writes to it.
+type deadlineConn struct {
+	essentials.Conn
+
+	timeout time.Duration
+}
+
+func (d deadlineConn) Read(p []byte) (int, error) {
+	if err := d.Conn.SetReadDeadline(time.Now().Add(d.timeout)); err != nil {
+		return 0, err //nolint: wrapcheck
+	}
+
+	return d.Conn.Read(p) //nolint: wrapcheck
+}
+
+func (d deadlineConn) Write(p []byte) (int, error) {
+	if err := d.Conn.SetWriteDeadline(time.Now().Add(d.timeout)); err != nil {
+		return 0, err //nolint: wrapcheck
+	}
+
+	return d.Conn.Write(p) //nolint: wrapcheck
+}
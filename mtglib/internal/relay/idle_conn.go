@@ -0,0 +1,49 @@
+package relay
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+)
+
+// idleTracker keeps a time of the last read or write of a stream. It is
+// shared between both connections: a stream is idle only if nothing is
+// happening in both directions.
+type idleTracker struct {
+	lastActivity int64
+}
+
+func (i *idleTracker) touch() {
+	atomic.StoreInt64(&i.lastActivity, time.Now().UnixNano())
+}
+
+func (i *idleTracker) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&i.lastActivity)))
+}
+
+// idleConn marks a stream as active on each read or write which has
+// transmitted any byte.
+type idleConn struct {
+	essentials.Conn
+
+	tracker *idleTracker
+}
+
+func (i idleConn) Read(p []byte) (int, error) {
+	n, err := i.Conn.Read(p)
+	if n > 0 {
+		i.tracker.touch()
+	}
+
+	return n, err //nolint: wrapcheck
+}
+
+func (i idleConn) Write(p []byte) (int, error) {
+	n, err := i.Conn.Write(p)
+	if n > 0 {
+		i.tracker.touch()
+	}
+
+	return n, err //nolint: wrapcheck
+}
@@ -0,0 +1,73 @@
+package relay
+
+import (
+	"sync"
+	"time"
+)
+
+// IdleSweeper closes streams which have been idle for too long. All
+// streams of a proxy are registered in a single sweeper which checks them
+// on each Sweep, so there is no timer or goroutine per stream. A caller
+// runs Sweep periodically: a stream is closed with a delay up to a sweep
+// interval, so a longer interval trades precision for a lower overhead.
+type IdleSweeper struct {
+	timeout time.Duration
+	mutex   sync.Mutex
+	streams map[*idleTracker]func()
+}
+
+// Sweep closes all registered streams which have been idle for a timeout
+// and unregisters them. It returns a number of closed streams.
+func (s *IdleSweeper) Sweep() int {
+	idle := []func(){}
+
+	s.mutex.Lock()
+
+	for tracker, onIdle := range s.streams {
+		if tracker.idle() >= s.timeout {
+			idle = append(idle, onIdle)
+
+			delete(s.streams, tracker)
+		}
+	}
+
+	s.mutex.Unlock()
+
+	// callbacks close connections so they are called without a lock.
+	for _, onIdle := range idle {
+		onIdle()
+	}
+
+	return len(idle)
+}
+
+// Len returns a number of registered streams.
+func (s *IdleSweeper) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.streams)
+}
+
+func (s *IdleSweeper) register(tracker *idleTracker, onIdle func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.streams[tracker] = onIdle
+}
+
+func (s *IdleSweeper) unregister(tracker *idleTracker) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.streams, tracker)
+}
+
+// NewIdleSweeper creates a sweeper which closes streams idle for a given
+// timeout.
+func NewIdleSweeper(timeout time.Duration) *IdleSweeper {
+	return &IdleSweeper{
+		timeout: timeout,
+		streams: map[*idleTracker]func(){},
+	}
+}
@@ -4,6 +4,27 @@ const (
 	copyBufferSize = 64 * 1024
 )
 
+// Result describes why a relay has been finished.
+type Result int32
+
+const (
+	// ResultUnknown means that a relay was finished because of a closed
+	// context.
+	ResultUnknown Result = iota
+
+	// ResultClientClosed means that a client connection was finished
+	// first: a client has closed it or it was broken.
+	ResultClientClosed
+
+	// ResultTelegramClosed means that a telegram connection was finished
+	// first.
+	ResultTelegramClosed
+
+	// ResultIdleTimeout means that a stream was closed because no data was
+	// transmitted in any direction within an idle timeout.
+	ResultIdleTimeout
+)
+
 type Logger interface {
 	Printf(msg string, args ...interface{})
 }
@@ -4,17 +4,68 @@ import (
 	"context"
 	"errors"
 	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/IceCodeNew/mtg/essentials"
 )
 
-func Relay(ctx context.Context, log Logger, telegramConn, clientConn essentials.Conn) {
+// Relay pumps data between connections until any of them is closed.
+//
+// If timeout is not 0, each single read or write has to finish within
+// it, otherwise a stream is aborted. This catches stuck peers which are not
+// idle but make no progress.
+func Relay(ctx context.Context, log Logger, telegramConn, clientConn essentials.Conn, timeout time.Duration) {
+	RelayWithIdleSweeper(ctx, log, telegramConn, clientConn, timeout, nil)
+}
+
+// RelayWithIdleSweeper is the same as Relay but also registers a stream in
+// a given sweeper which closes it if no data was transmitted in any
+// direction within its timeout. Any transmitted byte marks a stream as
+// active. This catches half-open streams: a peer which disappeared
+// without closing a connection never sends anything.
+//
+// It returns a side which has finished a stream first or ResultIdleTimeout
+// if a stream was closed because of idling. nil sweeper disables idle
+// timeout.
+func RelayWithIdleSweeper(
+	ctx context.Context,
+	log Logger,
+	telegramConn, clientConn essentials.Conn,
+	timeout time.Duration,
+	sweeper *IdleSweeper,
+) Result {
 	defer telegramConn.Close()
 	defer clientConn.Close()
 
+	if timeout > 0 {
+		telegramConn = deadlineConn{Conn: telegramConn, timeout: timeout}
+		clientConn = deadlineConn{Conn: clientConn, timeout: timeout}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// the first finished pump or idle sweeper defines a result.
+	result := int32(ResultUnknown)
+	setResult := func(value Result) {
+		atomic.CompareAndSwapInt32(&result, int32(ResultUnknown), int32(value))
+	}
+
+	if sweeper != nil {
+		tracker := &idleTracker{}
+		tracker.touch()
+
+		telegramConn = idleConn{Conn: telegramConn, tracker: tracker}
+		clientConn = idleConn{Conn: clientConn, tracker: tracker}
+
+		sweeper.register(tracker, func() {
+			setResult(ResultIdleTimeout)
+			cancel()
+		})
+		defer sweeper.unregister(tracker)
+	}
+
 	go func() {
 		<-ctx.Done()
 		telegramConn.Close()
@@ -27,11 +78,19 @@ func Relay(ctx context.Context, log Logger, telegramConn, clientConn essentials.
 		defer close(closeChan)
 
 		pump(log, telegramConn, clientConn, "client -> telegram")
+		setResult(ResultClientClosed)
 	}()
 
 	pump(log, clientConn, telegramConn, "telegram -> client")
+	setResult(ResultTelegramClosed)
 
 	<-closeChan
+
+	if ctx.Err() != nil && Result(atomic.LoadInt32(&result)) != ResultIdleTimeout {
+		return ResultUnknown
+	}
+
+	return Result(atomic.LoadInt32(&result))
 }
 
 func pump(log Logger, src, dst essentials.Conn, direction string) {
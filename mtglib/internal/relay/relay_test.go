@@ -3,8 +3,11 @@ package relay_test
 import (
 	"context"
 	"io"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/IceCodeNew/mtg/essentials"
 	"github.com/IceCodeNew/mtg/internal/testlib"
 	"github.com/IceCodeNew/mtg/mtglib/internal/relay"
 	"github.com/stretchr/testify/mock"
@@ -49,7 +52,157 @@ func (suite *RelayTestSuite) TestExit() {
 	suite.clientConnMock.On("CloseRead").Return(nil).Once()
 	suite.clientConnMock.On("CloseWrite").Return(nil).Once()
 
-	relay.Relay(suite.ctx, suite.loggerMock, suite.telegramConnMock, suite.clientConnMock)
+	relay.Relay(suite.ctx, suite.loggerMock, suite.telegramConnMock, suite.clientConnMock, 0)
+}
+
+func (suite *RelayTestSuite) TestTimeout() {
+	suite.telegramConnMock.On("Close").Return(nil)
+	suite.telegramConnMock.On("CloseRead").Return(nil).Once()
+	suite.telegramConnMock.On("CloseWrite").Return(nil).Once()
+	suite.telegramConnMock.On("SetReadDeadline", mock.Anything).Return(nil).Once()
+	suite.telegramConnMock.On("SetWriteDeadline", mock.Anything).Return(nil).Maybe()
+	suite.telegramConnMock.On("Read", mock.Anything).Return(10, io.EOF).Once()
+	suite.telegramConnMock.On("Write", mock.Anything).Return(10, io.EOF).Maybe()
+
+	suite.clientConnMock.On("SetReadDeadline", mock.Anything).Return(nil).Once()
+	suite.clientConnMock.On("SetWriteDeadline", mock.Anything).Return(nil).Maybe()
+	suite.clientConnMock.On("Read", mock.Anything).Return(0, io.EOF).Once()
+	suite.clientConnMock.On("Write", mock.Anything).Return(10, io.EOF).Maybe()
+	suite.clientConnMock.On("Close").Return(nil)
+	suite.clientConnMock.On("CloseRead").Return(nil).Once()
+	suite.clientConnMock.On("CloseWrite").Return(nil).Once()
+
+	relay.Relay(suite.ctx, suite.loggerMock, suite.telegramConnMock, suite.clientConnMock, time.Second)
+}
+
+func (suite *RelayTestSuite) makeConnPair() (essentials.Conn, essentials.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+
+	defer listener.Close()
+
+	dialed, err := net.Dial("tcp", listener.Addr().String())
+	suite.Require().NoError(err)
+
+	accepted, err := listener.Accept()
+	suite.Require().NoError(err)
+
+	suite.T().Cleanup(func() {
+		dialed.Close()
+		accepted.Close()
+	})
+
+	return dialed.(essentials.Conn), accepted.(essentials.Conn)
+}
+
+// makeSweeper creates a sweeper which is swept each 10ms until a test is
+// finished.
+func (suite *RelayTestSuite) makeSweeper(timeout time.Duration) *relay.IdleSweeper {
+	sweeper := relay.NewIdleSweeper(timeout)
+	ctx := suite.ctx
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sweeper.Sweep()
+			}
+		}
+	}()
+
+	return sweeper
+}
+
+func (suite *RelayTestSuite) TestIdleTimeout() {
+	telegramConn, telegramPeer := suite.makeConnPair()
+	clientConn, _ := suite.makeConnPair()
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(50 * time.Millisecond)
+			telegramPeer.Write([]byte{1}) //nolint: errcheck
+		}
+	}()
+
+	sweeper := suite.makeSweeper(150 * time.Millisecond)
+	startedAt := time.Now()
+	result := relay.RelayWithIdleSweeper(suite.ctx, suite.loggerMock,
+		telegramConn, clientConn, 0, sweeper)
+
+	suite.Equal(relay.ResultIdleTimeout, result)
+	suite.GreaterOrEqual(time.Since(startedAt), 400*time.Millisecond)
+	suite.Equal(0, sweeper.Len())
+}
+
+func (suite *RelayTestSuite) TestIdleTimeoutContextClosed() {
+	telegramConn, _ := suite.makeConnPair()
+	clientConn, _ := suite.makeConnPair()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		suite.ctxCancel()
+	}()
+
+	sweeper := suite.makeSweeper(time.Minute)
+
+	suite.Equal(relay.ResultUnknown, relay.RelayWithIdleSweeper(suite.ctx, suite.loggerMock,
+		telegramConn, clientConn, 0, sweeper))
+	suite.Equal(0, sweeper.Len())
+}
+
+func (suite *RelayTestSuite) TestClientClosed() {
+	telegramConn, _ := suite.makeConnPair()
+	clientConn, clientPeer := suite.makeConnPair()
+
+	clientPeer.Close()
+
+	sweeper := suite.makeSweeper(time.Minute)
+
+	suite.Equal(relay.ResultClientClosed, relay.RelayWithIdleSweeper(suite.ctx, suite.loggerMock,
+		telegramConn, clientConn, 0, sweeper))
+	suite.Equal(0, sweeper.Len())
+}
+
+func (suite *RelayTestSuite) TestTelegramClosed() {
+	telegramConn, telegramPeer := suite.makeConnPair()
+	clientConn, _ := suite.makeConnPair()
+
+	telegramPeer.Close()
+
+	suite.Equal(relay.ResultTelegramClosed, relay.RelayWithIdleSweeper(suite.ctx, suite.loggerMock,
+		telegramConn, clientConn, 0, suite.makeSweeper(time.Minute)))
+}
+
+func (suite *RelayTestSuite) TestSweepManyStreams() {
+	sweeper := relay.NewIdleSweeper(50 * time.Millisecond)
+	results := make(chan relay.Result)
+
+	for i := 0; i < 10; i++ {
+		telegramConn, _ := suite.makeConnPair()
+		clientConn, _ := suite.makeConnPair()
+
+		go func() {
+			results <- relay.RelayWithIdleSweeper(suite.ctx, suite.loggerMock,
+				telegramConn, clientConn, 0, sweeper)
+		}()
+	}
+
+	suite.Eventually(func() bool {
+		return sweeper.Len() == 10
+	}, time.Second, 10*time.Millisecond)
+	suite.Equal(0, sweeper.Sweep())
+
+	time.Sleep(100 * time.Millisecond)
+	suite.Equal(10, sweeper.Sweep())
+
+	for i := 0; i < 10; i++ {
+		suite.Equal(relay.ResultIdleTimeout, <-results)
+	}
 }
 
 func TestRelay(t *testing.T) {
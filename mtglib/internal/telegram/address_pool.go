@@ -1,6 +1,11 @@
 package telegram
 
-import "math/rand"
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+)
 
 type addressPool struct {
 	v4 [][]tgAddr
@@ -39,3 +44,38 @@ func (a addressPool) get(addresses [][]tgAddr, dc int) []tgAddr {
 
 	return rv
 }
+
+// pin returns a copy of the pool where addresses of given DCs are
+// replaced.
+func (a addressPool) pin(dcAddresses map[int][]net.IP) (addressPool, error) {
+	rv := addressPool{
+		v4: append([][]tgAddr{}, a.v4...),
+		v6: append([][]tgAddr{}, a.v6...),
+	}
+
+	for dc, ips := range dcAddresses {
+		if !a.isValidDC(dc) {
+			return addressPool{}, fmt.Errorf("cannot pin addresses of unknown dc %d", dc)
+		}
+
+		v4, v6 := []tgAddr{}, []tgAddr{}
+
+		for _, ip := range ips {
+			address := net.JoinHostPort(ip.String(), strconv.Itoa(telegramPort))
+
+			switch {
+			case ip.To4() != nil:
+				v4 = append(v4, tgAddr{network: "tcp4", address: address})
+			case ip.To16() != nil:
+				v6 = append(v6, tgAddr{network: "tcp6", address: address})
+			default:
+				return addressPool{}, fmt.Errorf("incorrect ip address of dc %d", dc)
+			}
+		}
+
+		rv.v4[dc-1] = v4
+		rv.v6[dc-1] = v6
+	}
+
+	return rv, nil
+}
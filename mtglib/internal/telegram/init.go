@@ -3,10 +3,18 @@ package telegram
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/IceCodeNew/mtg/essentials"
 )
 
+// happyEyeballsDelay is a head start of the preferred address family. RFC
+// 8305 recommends 250ms as a sensible default.
+const happyEyeballsDelay = 250 * time.Millisecond
+
+// telegramPort is a port of all Telegram DCs.
+const telegramPort = 443
+
 var errNoAddresses = errors.New("no addresses")
 
 type preferIP uint8
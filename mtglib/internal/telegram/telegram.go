@@ -3,7 +3,9 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/IceCodeNew/mtg/essentials"
 )
@@ -14,32 +16,133 @@ type Telegram struct {
 	pool     addressPool
 }
 
-func (t Telegram) Dial(ctx context.Context, dc int) (essentials.Conn, error) {
-	var addresses []tgAddr
+// Dial connects to a given DC. Each DC has several endpoints, they are tried
+// in order of IP preference. Apart from a connection, it returns a number of
+// endpoints which have failed before a successful one. So, if it is 0, then
+// the primary endpoint is used.
+//
+// If both IPv4 and IPv6 are allowed, address families are raced in happy
+// eyeballs style (RFC 8305): endpoints of the preferred family are dialed
+// first and the other family joins after [happyEyeballsDelay] or as soon
+// as the preferred family fails. The first established connection wins,
+// a dial of the other family is cancelled.
+func (t Telegram) Dial(ctx context.Context, dc int) (essentials.Conn, int, error) {
+	var primary, secondary []tgAddr
 
 	switch t.preferIP {
 	case preferIPOnlyIPv4:
-		addresses = t.pool.getV4(dc)
+		primary = t.pool.getV4(dc)
 	case preferIPOnlyIPv6:
-		addresses = t.pool.getV6(dc)
+		primary = t.pool.getV6(dc)
 	case preferIPPreferIPv4:
-		addresses = append(t.pool.getV4(dc), t.pool.getV6(dc)...)
+		primary, secondary = t.pool.getV4(dc), t.pool.getV6(dc)
 	case preferIPPreferIPv6:
-		addresses = append(t.pool.getV6(dc), t.pool.getV4(dc)...)
+		primary, secondary = t.pool.getV6(dc), t.pool.getV4(dc)
 	}
 
-	var conn essentials.Conn
+	var (
+		conn  essentials.Conn
+		index int
+		err   error
+	)
 
+	if len(primary) == 0 || len(secondary) == 0 {
+		conn, index, err = t.dialAddresses(ctx, append(primary, secondary...))
+	} else {
+		conn, index, err = t.race(ctx, primary, secondary)
+	}
+
+	if err != nil {
+		return nil, index, fmt.Errorf("cannot dial to %d dc: %w", dc, err)
+	}
+
+	return conn, index, nil
+}
+
+// dialAddresses tries addresses one by one. It returns an index of the
+// address which was connected or a number of addresses on fail.
+func (t Telegram) dialAddresses(ctx context.Context, addresses []tgAddr) (essentials.Conn, int, error) {
 	err := errNoAddresses
 
-	for _, v := range addresses {
-		conn, err = t.dialer.DialContext(ctx, v.network, v.address)
-		if err == nil {
-			return conn, nil
+	for i, v := range addresses {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, len(addresses), ctxErr //nolint: wrapcheck
+		}
+
+		var conn essentials.Conn
+
+		if conn, err = t.dialer.DialContext(ctx, v.network, v.address); err == nil {
+			return conn, i, nil
+		}
+	}
+
+	return nil, len(addresses), err
+}
+
+func (t Telegram) race(ctx context.Context, primary, secondary []tgAddr) (essentials.Conn, int, error) {
+	type result struct {
+		conn  essentials.Conn
+		index int
+		err   error
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, 2) //nolint: gomnd
+	primaryFailed := make(chan struct{})
+
+	go func() {
+		conn, index, err := t.dialAddresses(ctx, primary)
+		if err != nil {
+			close(primaryFailed)
 		}
+
+		results <- result{conn: conn, index: index, err: err}
+	}()
+
+	go func() {
+		timer := time.NewTimer(happyEyeballsDelay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			results <- result{err: ctx.Err()}
+
+			return
+		case <-primaryFailed:
+		case <-timer.C:
+		}
+
+		conn, index, err := t.dialAddresses(ctx, secondary)
+		results <- result{conn: conn, index: len(primary) + index, err: err}
+	}()
+
+	var err error
+
+	for pending := 2; pending > 0; pending-- {
+		res := <-results
+		if res.err != nil {
+			err = res.err
+
+			continue
+		}
+
+		cancel()
+
+		if pending > 1 {
+			// a loser can connect after all, its connection has to be closed
+			go func() {
+				if rv := <-results; rv.conn != nil {
+					rv.conn.Close()
+				}
+			}()
+		}
+
+		return res.conn, res.index, nil
 	}
 
-	return nil, fmt.Errorf("cannot dial to %d dc: %w", dc, err)
+	return nil, len(primary) + len(secondary), err
 }
 
 func (t Telegram) IsKnownDC(dc int) bool {
@@ -50,7 +153,12 @@ func (t Telegram) GetFallbackDC() int {
 	return t.pool.getRandomDC()
 }
 
-func New(dialer Dialer, ipPreference string, useTestDCs bool) (*Telegram, error) {
+// New returns a dialer of Telegram DCs. dcAddresses replace built-in
+// addresses of given DCs: addresses of both families are replaced even
+// if only IPv4 or IPv6 ones are pinned.
+func New(dialer Dialer, ipPreference string, useTestDCs bool,
+	dcAddresses map[int][]net.IP,
+) (*Telegram, error) {
 	var pref preferIP
 
 	switch strings.ToLower(ipPreference) {
@@ -75,6 +183,14 @@ func New(dialer Dialer, ipPreference string, useTestDCs bool) (*Telegram, error)
 		pool.v6 = testV6Addresses
 	}
 
+	if len(dcAddresses) > 0 {
+		var err error
+
+		if pool, err = pool.pin(dcAddresses); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Telegram{
 		dialer:   dialer,
 		preferIP: pref,
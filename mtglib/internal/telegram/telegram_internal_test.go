@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/IceCodeNew/mtg/internal/testlib"
 	"github.com/stretchr/testify/assert"
@@ -23,7 +24,7 @@ type TelegramTestSuite struct {
 
 func (suite *TelegramTestSuite) SetupTest() {
 	suite.dialerMock = &testlib.MtglibNetworkMock{}
-	suite.t, _ = New(suite.dialerMock, "prefer-ipv4", false)
+	suite.t, _ = New(suite.dialerMock, "prefer-ipv4", false, nil)
 }
 
 func (suite *TelegramTestSuite) TearDownTest() {
@@ -42,7 +43,7 @@ func (suite *TelegramTestSuite) TestUnknownDC() {
 		value := v
 
 		suite.T().Run(strconv.Itoa(value), func(t *testing.T) {
-			_, err := suite.t.Dial(context.Background(), value)
+			_, _, err := suite.t.Dial(context.Background(), value)
 			assert.Error(t, err)
 			assert.False(t, suite.t.IsKnownDC(value))
 		})
@@ -70,7 +71,7 @@ func (suite *TelegramTestSuite) TestDialToCorrectIPs() {
 					Return((*net.TCPConn)(nil), io.EOF)
 			}
 
-			_, err := suite.t.Dial(context.Background(), idx)
+			_, _, err := suite.t.Dial(context.Background(), idx)
 			assert.True(t, errors.Is(err, io.EOF))
 			assert.True(t, suite.t.IsKnownDC(idx))
 		})
@@ -97,8 +98,8 @@ func (suite *TelegramTestSuite) TestDialPreferIPRange() {
 					Return((*net.TCPConn)(nil), io.EOF)
 			}
 
-			tg, _ := New(suite.dialerMock, name, true)
-			_, err := tg.Dial(context.Background(), 1)
+			tg, _ := New(suite.dialerMock, name, true, nil)
+			_, _, err := tg.Dial(context.Background(), 1)
 
 			assert.True(t, errors.Is(err, io.EOF))
 		})
@@ -123,17 +124,150 @@ func (suite *TelegramTestSuite) TestDialPreferIPPriority() {
 				Once().
 				Return(conn, nil)
 
-			tg, _ := New(suite.dialerMock, name, false)
+			tg, _ := New(suite.dialerMock, name, false, nil)
 
-			res, err := tg.Dial(context.Background(), 1)
+			res, failedEndpoints, err := tg.Dial(context.Background(), 1)
 			assert.NoError(t, err)
 			assert.Equal(t, conn, res)
+			assert.Equal(t, 0, failedEndpoints)
 		})
 	}
 }
 
+func (suite *TelegramTestSuite) TestDialFailover() {
+	conn := &net.TCPConn{}
+	primary := testV4Addresses[0][0]
+	secondary := testV6Addresses[0][0]
+
+	suite.dialerMock.
+		On("DialContext", mock.Anything, primary.network, primary.address).
+		Once().
+		Return((*net.TCPConn)(nil), io.EOF)
+	suite.dialerMock.
+		On("DialContext", mock.Anything, secondary.network, secondary.address).
+		Once().
+		Return(conn, nil)
+
+	tg, _ := New(suite.dialerMock, "prefer-ipv4", true, nil)
+
+	res, failedEndpoints, err := tg.Dial(context.Background(), 1)
+	suite.NoError(err)
+	suite.Equal(conn, res)
+	suite.Equal(1, failedEndpoints)
+}
+
+func (suite *TelegramTestSuite) TestDialRace() {
+	conn := &net.TCPConn{}
+	primary := testV6Addresses[0][0]
+	secondary := testV4Addresses[0][0]
+	cancelled := make(chan struct{})
+
+	suite.dialerMock.
+		On("DialContext", mock.Anything, primary.network, primary.address).
+		Once().
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+			close(cancelled)
+		}).
+		Return((*net.TCPConn)(nil), context.Canceled)
+	suite.dialerMock.
+		On("DialContext", mock.Anything, secondary.network, secondary.address).
+		Once().
+		Return(conn, nil)
+
+	tg, _ := New(suite.dialerMock, "prefer-ipv6", true, nil)
+
+	started := time.Now()
+	res, failedEndpoints, err := tg.Dial(context.Background(), 1)
+
+	suite.NoError(err)
+	suite.Equal(conn, res)
+	suite.Equal(1, failedEndpoints)
+	suite.GreaterOrEqual(time.Since(started), happyEyeballsDelay)
+	suite.Less(time.Since(started), 2*happyEyeballsDelay)
+	suite.Eventually(func() bool {
+		select {
+		case <-cancelled:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *TelegramTestSuite) TestDialHeadStart() {
+	conn := &net.TCPConn{}
+	primary := testV4Addresses[0][0]
+
+	suite.dialerMock.
+		On("DialContext", mock.Anything, primary.network, primary.address).
+		Once().
+		After(happyEyeballsDelay/2).
+		Return(conn, nil)
+
+	tg, _ := New(suite.dialerMock, "prefer-ipv4", true, nil)
+
+	res, failedEndpoints, err := tg.Dial(context.Background(), 1)
+	suite.NoError(err)
+	suite.Equal(conn, res)
+	suite.Equal(0, failedEndpoints)
+
+	time.Sleep(happyEyeballsDelay)
+}
+
 func (suite *TelegramTestSuite) TestUnknownPreferIP() {
-	_, err := New(suite.dialerMock, "xxx", false)
+	_, err := New(suite.dialerMock, "xxx", false, nil)
+	suite.Error(err)
+}
+
+func (suite *TelegramTestSuite) TestPinnedAddresses() {
+	testData := map[string]tgAddr{
+		"prefer-ipv6": {network: "tcp6", address: "[2001:db8::1]:443"},
+		"only-ipv4":   {network: "tcp4", address: "10.0.0.1:443"},
+	}
+
+	pinned := map[int][]net.IP{
+		2: {net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")},
+	}
+
+	for k, v := range testData {
+		name := k
+		addr := v
+
+		suite.T().Run(name, func(t *testing.T) {
+			conn := &net.TCPConn{}
+
+			suite.dialerMock.
+				On("DialContext", mock.Anything, addr.network, addr.address).
+				Once().
+				Return(conn, nil)
+
+			tg, err := New(suite.dialerMock, name, false, pinned)
+			assert.NoError(t, err)
+
+			res, _, err := tg.Dial(context.Background(), 2)
+			assert.NoError(t, err)
+			assert.Equal(t, conn, res)
+		})
+	}
+
+	suite.Equal("149.154.167.51:443", productionV4Addresses[1][0].address)
+}
+
+func (suite *TelegramTestSuite) TestPinnedAddressesOfOtherFamily() {
+	tg, err := New(suite.dialerMock, "only-ipv6", false, map[int][]net.IP{
+		3: {net.ParseIP("10.0.0.1")},
+	})
+	suite.NoError(err)
+
+	_, _, err = tg.Dial(context.Background(), 3)
+	suite.Error(err)
+}
+
+func (suite *TelegramTestSuite) TestPinnedAddressesOfUnknownDC() {
+	_, err := New(suite.dialerMock, "prefer-ipv4", true, map[int][]net.IP{
+		4: {net.ParseIP("10.0.0.1")},
+	})
 	suite.Error(err)
 }
 
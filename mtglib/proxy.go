@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IceCodeNew/mtg/essentials"
@@ -18,30 +21,146 @@ import (
 	"github.com/panjf2000/ants/v2"
 )
 
+// Reasons of a DC selection which are logged for each stream.
+const (
+	// dcSelectionHandshake means that DC from client handshake is used.
+	dcSelectionHandshake = "handshake"
+
+	// dcSelectionFallback means that DC from client handshake is unknown
+	// so a random one is used instead.
+	dcSelectionFallback = "fallback"
+
+	// dcSelectionUnknown means that DC from client handshake is unknown
+	// and fallback is disabled. Such streams fail to dial.
+	dcSelectionUnknown = "unknown"
+)
+
+// handshakeBansSweepEach defines how often expired handshake failure bans
+// are lifted. Banned IP which connects again is unbanned immediately.
+const handshakeBansSweepEach = 10 * time.Second
+
+// fileDescriptorsPerStream is a number of file descriptors used by each
+// stream: a client connection and a telegram connection.
+const fileDescriptorsPerStream = 2
+
 // Proxy is an MTPROTO proxy structure.
 type Proxy struct {
-	ctx             context.Context
-	ctxCancel       context.CancelFunc
-	streamWaitGroup sync.WaitGroup
+	// it has to be the first field for atomic operations on 32-bit platforms
+	fileDescriptors int64
+
+	ctx              context.Context
+	ctxCancel        context.CancelFunc
+	streamsCtx       context.Context
+	streamsCtxCancel context.CancelFunc
+	streamWaitGroup  sync.WaitGroup
+
+	activeStreamsMutex sync.Mutex
+	activeStreams      int
 
 	allowFallbackOnUnknownDC bool
+	antiReplayPerClientIP    bool
 	tolerateTimeSkewness     time.Duration
 	domainFrontingPort       int
 	workerPool               *ants.PoolWithFunc
+	workerSlots              chan struct{}
+	admissionQueue           chan struct{}
+	admissionQueueTimeout    time.Duration
+	fileDescriptorsSoftLimit int64
+	probeTarpit              chan struct{}
+	probeTarpitDuration      time.Duration
+	maxHandshakeSize         int
+	handshakeJitter          time.Duration
+	relayTimeout             time.Duration
+	unknownClientIPPolicy    string
+	fallbackClientIP         net.IP
+	ipListPrecedence         string
+	onMissingSNI             string
+	handshakeBans            *handshakeBans
+	idleSweeper              *relay.IdleSweeper
+	clientRateLimiter        atomic.Value
+	dcLimiter                *dcLimiter
+	dcTraffic                *dcTraffic
 	telegram                 *telegram.Telegram
 
-	secret          Secret
+	secrets         atomic.Value
+	secretTag       string
 	network         Network
 	antiReplayCache AntiReplayCache
 	blocklist       IPBlocklist
 	allowlist       IPBlocklist
+	shared          bool
 	eventStream     EventStream
 	logger          Logger
 }
 
-// DomainFrontingAddress returns a host:port pair for a fronting domain.
+// DomainFrontingAddress returns a host:port pair for a fronting domain. If
+// proxy has many secrets, this is a fronting domain of the first one.
 func (p *Proxy) DomainFrontingAddress() string {
-	return net.JoinHostPort(p.secret.Host, strconv.Itoa(p.domainFrontingPort))
+	return p.domainFrontingAddress(p.getSecrets()[0])
+}
+
+func (p *Proxy) domainFrontingAddress(secret Secret) string {
+	return net.JoinHostPort(secret.Host, strconv.Itoa(p.domainFrontingPort))
+}
+
+// SetSecrets replaces secrets of a running proxy. Secrets have the same
+// meaning as [ProxyOpts.Secrets]: a client hello is matched against them
+// in a given order and a fronting domain of the first one is used when
+// nothing matches.
+//
+// Active streams are not affected: they keep a secret they were matched
+// with.
+func (p *Proxy) SetSecrets(secrets []Secret) error {
+	if len(secrets) == 0 {
+		return ErrSecretInvalid
+	}
+
+	for _, v := range secrets {
+		if !v.Valid() {
+			return ErrSecretInvalid
+		}
+	}
+
+	p.secrets.Store(append([]Secret{}, secrets...))
+
+	if !p.shared {
+		p.eventStream.Send(p.ctx, NewEventSecretsConfigured(len(secrets)))
+	}
+
+	return nil
+}
+
+func (p *Proxy) getSecrets() []Secret {
+	return p.secrets.Load().([]Secret) //nolint: forcetypeassert
+}
+
+// SetClientRateLimit changes rate limits of new connections per client IP
+// of a running proxy. Parameters have the same meaning as
+// [ProxyOpts.ClientRateLimit], [ProxyOpts.ClientRateLimitBurst] and
+// [ProxyOpts.ClientRateLimitMaxIPs]; 0 rate disables rate limiting.
+//
+// Active streams are not affected. Buckets of clients are not carried
+// over: each client starts with a full one.
+func (p *Proxy) SetClientRateLimit(rate, burst, maxIPs uint) {
+	if rate == 0 {
+		p.clientRateLimiter.Store((*clientRateLimiter)(nil))
+
+		return
+	}
+
+	opts := ProxyOpts{
+		ClientRateLimit:       rate,
+		ClientRateLimitBurst:  burst,
+		ClientRateLimitMaxIPs: maxIPs,
+	}
+
+	p.clientRateLimiter.Store(newClientRateLimiter(rate,
+		opts.getClientRateLimitBurst(),
+		opts.getClientRateLimitMaxIPs()))
+}
+
+func (p *Proxy) getClientRateLimiter() *clientRateLimiter {
+	return p.clientRateLimiter.Load().(*clientRateLimiter) //nolint: forcetypeassert
 }
 
 // ServeConn serves a connection. We do not check IP blocklist and concurrency
@@ -50,9 +169,26 @@ func (p *Proxy) ServeConn(conn essentials.Conn) {
 	p.streamWaitGroup.Add(1)
 	defer p.streamWaitGroup.Done()
 
-	ctx := newStreamContext(p.ctx, p.logger, conn)
+	atomic.AddInt64(&p.fileDescriptors, fileDescriptorsPerStream)
+	defer atomic.AddInt64(&p.fileDescriptors, -fileDescriptorsPerStream)
+
+	p.changeActiveStreams(1)
+	defer p.changeActiveStreams(-1)
+
+	ctx := newStreamContext(p.streamsCtx, p.logger, conn, p.getClientIP(conn))
 	defer ctx.Close()
 
+	// a fronting domain of the first secret is used until a client hello
+	// is matched.
+	ctx.secret = p.getSecrets()[0]
+
+	ctx.clientConn = connClientTraffic{
+		Conn:     ctx.clientConn,
+		streamID: ctx.streamID,
+		stream:   p.eventStream,
+		ctx:      ctx,
+	}
+
 	go func() {
 		<-ctx.Done()
 		ctx.Close()
@@ -62,32 +198,64 @@ func (p *Proxy) ServeConn(conn essentials.Conn) {
 	ctx.logger.Info("Stream has been started")
 
 	defer func() {
-		p.eventStream.Send(ctx, NewEventFinish(ctx.streamID))
-		ctx.logger.Info("Stream has been finished")
+		if p.streamsCtx.Err() != nil {
+			ctx.setCloseReason(CloseReasonShutdown)
+		}
+
+		ctx.setCloseReason(CloseReasonUnknown)
+		p.eventStream.Send(ctx, NewEventFinishWithReason(ctx.streamID, ctx.closeReason))
+		ctx.logger.BindStr("close-reason", string(ctx.closeReason)).Info("Stream has been finished")
 	}()
 
 	if !p.doFakeTLSHandshake(ctx) {
+		p.finishHandshake(ctx, true)
+
 		return
 	}
 
 	if err := p.doObfuscated2Handshake(ctx); err != nil {
 		p.logger.InfoError("obfuscated2 handshake is failed", err)
+		p.finishHandshake(ctx, true)
+		p.addHandshakeFailure(ctx)
+
+		if isIncompleteHandshake(err) {
+			ctx.setCloseReason(CloseReasonIncompleteHandshake)
+			p.eventStream.Send(ctx, NewEventIncompleteHandshake(ctx.streamID))
+		}
+
+		ctx.setCloseReason(CloseReasonHandshakeFailed)
 
 		return
 	}
 
+	p.finishHandshake(ctx, false)
+
 	if err := p.doTelegramCall(ctx); err != nil {
 		p.logger.WarningError("cannot dial to telegram", err)
+		ctx.setCloseReason(CloseReasonTelegramUnavailable)
 
 		return
 	}
 
-	relay.Relay(
+	result := relay.RelayWithIdleSweeper(
 		ctx,
 		ctx.logger.Named("relay"),
 		ctx.telegramConn,
 		ctx.clientConn,
+		p.relayTimeout,
+		p.idleSweeper,
 	)
+
+	switch result {
+	case relay.ResultClientClosed:
+		ctx.setCloseReason(CloseReasonClientClosed)
+	case relay.ResultTelegramClosed:
+		ctx.setCloseReason(CloseReasonTelegramClosed)
+	case relay.ResultIdleTimeout:
+		ctx.logger.Info("Stream has been closed because it was idle for too long")
+		ctx.setCloseReason(CloseReasonIdleTimeout)
+		p.eventStream.Send(ctx, NewEventIdleTimeout(ctx.streamID))
+	}
 }
 
 // Serve starts a proxy on a given listener.
@@ -106,47 +274,361 @@ func (p *Proxy) Serve(listener net.Listener) error {
 			}
 		}
 
-		ipAddr := conn.RemoteAddr().(*net.TCPAddr).IP //nolint: forcetypeassert
+		if p.ctx.Err() != nil {
+			conn.Close()
+
+			return nil
+		}
+
+		ipAddr := p.getClientIP(conn)
 		logger := p.logger.BindStr("ip", ipAddr.String())
 
-		if !p.allowlist.Contains(ipAddr) {
+		if p.isFileDescriptorsSoftLimitReached() {
 			conn.Close()
-			logger.Info("ip was rejected by allowlist")
-			p.eventStream.Send(p.ctx, NewEventIPAllowlisted(ipAddr))
+			logger.Warning("file descriptors soft limit is reached, connection was rejected")
+			p.eventStream.Send(p.ctx, NewEventConcurrencyLimited())
 
 			continue
 		}
 
-		if p.blocklist.Contains(ipAddr) {
+		switch {
+		case ipAddr != nil:
+		case p.unknownClientIPPolicy == UnknownClientIPPolicyAllow:
+			logger.Debug("client ip is unknown, skip ip checks")
+		default:
 			conn.Close()
-			logger.Info("ip was blacklisted")
-			p.eventStream.Send(p.ctx, NewEventIPBlocklisted(ipAddr))
+			logger.Info("client ip is unknown, connection was rejected")
 
 			continue
 		}
 
-		err = p.workerPool.Invoke(conn)
+		if ipAddr != nil {
+			isAllowed := p.allowlist.Contains(ipAddr)
 
-		switch {
-		case err == nil:
-		case errors.Is(err, ants.ErrPoolClosed):
-			return nil
-		case errors.Is(err, ants.ErrPoolOverload):
+			switch {
+			case isAllowed && p.ipListPrecedence == IPListPrecedenceAllowWins:
+			case !isAllowed && p.ipListPrecedence == IPListPrecedenceBlockWins:
+				p.rejectProbe(conn, ipAddr, logger)
+				logger.Info("ip was rejected by allowlist")
+				p.eventStream.Send(p.ctx, NewEventIPAllowlisted(ipAddr))
+
+				continue
+			case p.blocklist.Contains(ipAddr):
+				p.rejectProbe(conn, ipAddr, logger)
+				logger.Info("ip was blacklisted")
+				p.eventStream.Send(p.ctx, NewEventIPBlocklisted(ipAddr))
+
+				continue
+			}
+
+			if p.isHandshakeBanned(ipAddr) {
+				p.rejectProbe(conn, ipAddr, logger)
+				logger.Info("ip was banned because of failed handshakes")
+
+				continue
+			}
+
+			if limiter := p.getClientRateLimiter(); limiter != nil && !limiter.allow(ipAddr, time.Now()) {
+				conn.Close()
+				logger.Debug("connection was rate limited")
+				p.eventStream.Send(p.ctx, NewEventClientRateLimited(ipAddr))
+
+				continue
+			}
+		}
+
+		select {
+		case p.workerSlots <- struct{}{}:
+			if err := p.invoke(conn); errors.Is(err, ants.ErrPoolClosed) {
+				return nil
+			}
+
+			continue
+		default:
+		}
+
+		select {
+		case p.admissionQueue <- struct{}{}:
+			logger.Debug("connection was put into admission queue")
+
+			p.streamWaitGroup.Add(1)
+
+			go p.waitForAdmission(conn, logger)
+		default:
+			conn.Close()
 			logger.Info("connection was concurrency limited")
 			p.eventStream.Send(p.ctx, NewEventConcurrencyLimited())
 		}
 	}
 }
 
+// invoke serves a connection in a worker pool. A caller has to take a slot
+// of workerSlots before, it is given back when the connection is served.
+func (p *Proxy) invoke(conn net.Conn) error {
+	if err := p.workerPool.Invoke(conn); err != nil {
+		<-p.workerSlots
+
+		return err //nolint: wrapcheck
+	}
+
+	return nil
+}
+
+// isFileDescriptorsSoftLimitReached checks if a new stream would exceed a
+// soft limit of file descriptors. Connections which wait in admission
+// queue hold their client descriptors so they are counted as well.
+func (p *Proxy) isFileDescriptorsSoftLimitReached() bool {
+	if p.fileDescriptorsSoftLimit == 0 {
+		return false
+	}
+
+	used := atomic.LoadInt64(&p.fileDescriptors) + int64(len(p.admissionQueue))
+
+	return used+fileDescriptorsPerStream > p.fileDescriptorsSoftLimit
+}
+
+func (p *Proxy) isHandshakeBanned(ipAddr net.IP) bool {
+	if p.handshakeBans == nil {
+		return false
+	}
+
+	banned, unbanned := p.handshakeBans.isBanned(ipAddr, time.Now())
+	if unbanned {
+		p.eventStream.Send(p.ctx, NewEventIPUnbanned(ipAddr))
+	}
+
+	return banned
+}
+
+// changeActiveStreams updates a number of concurrent streams and reports
+// it. An event is created under the lock, so timestamps of events follow
+// the order of changes. It is sent regardless of proxy context: streams
+// which are drained on shutdown have to be reported as well.
+func (p *Proxy) changeActiveStreams(delta int) {
+	p.activeStreamsMutex.Lock()
+	p.activeStreams += delta
+	evt := NewEventActiveStreams(p.activeStreams)
+	p.activeStreamsMutex.Unlock()
+
+	p.eventStream.Send(context.Background(), evt)
+}
+
+// finishHandshake reports a duration of client handshake. Only the first
+// call per stream counts: domain fronting reports a failure before it
+// starts, so time spent on fronting is not counted as handshake time.
+func (p *Proxy) finishHandshake(ctx *streamContext, isFailed bool) {
+	if ctx.handshakeFinished {
+		return
+	}
+
+	ctx.handshakeFinished = true
+
+	p.eventStream.Send(ctx,
+		NewEventHandshakeFinished(ctx.streamID, time.Since(ctx.acceptedAt), isFailed))
+}
+
+// addHandshakeFailure records a failed handshake of the stream and bans
+// its client IP if it fails too often.
+func (p *Proxy) addHandshakeFailure(ctx *streamContext) {
+	ipAddr := ctx.ClientIP()
+	if p.handshakeBans == nil || ipAddr == nil {
+		return
+	}
+
+	banned, evicted := p.handshakeBans.addFailure(ipAddr, time.Now())
+
+	for _, v := range evicted {
+		p.eventStream.Send(p.ctx, NewEventIPUnbanned(v))
+	}
+
+	if banned {
+		ctx.logger.Warning("ip was banned because of too many failed handshakes")
+		p.eventStream.Send(p.ctx, NewEventIPBanned(ipAddr, p.handshakeBans.duration))
+	}
+}
+
+func (p *Proxy) sweepHandshakeBans() {
+	defer p.streamWaitGroup.Done()
+
+	ticker := time.NewTicker(handshakeBansSweepEach)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, v := range p.handshakeBans.sweep(now) {
+				p.eventStream.Send(p.ctx, NewEventIPUnbanned(v))
+			}
+		}
+	}
+}
+
+// sweepIdleStreams periodically closes streams which are idle for longer
+// than an idle timeout. Please see [ProxyOpts.IdleSweepInterval].
+func (p *Proxy) sweepIdleStreams(interval time.Duration) {
+	defer p.streamWaitGroup.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.idleSweeper.Sweep()
+		}
+	}
+}
+
+// reportAntiReplayStats periodically emits stats of anti-replay cache. It
+// runs only if a cache implements AntiReplayCacheStats.
+func (p *Proxy) reportAntiReplayStats(cache AntiReplayCacheStats) {
+	defer p.streamWaitGroup.Done()
+
+	ticker := time.NewTicker(AntiReplayStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.eventStream.Send(p.ctx,
+				NewEventAntiReplayStats(cache.FillRatio(), cache.FalsePositiveRate()))
+		}
+	}
+}
+
+// reportDCTraffic periodically emits bytes which were transferred to and
+// from each Telegram DC.
+func (p *Proxy) reportDCTraffic() {
+	defer p.streamWaitGroup.Done()
+
+	ticker := time.NewTicker(DCTrafficReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.sendDCTraffic(p.ctx)
+		}
+	}
+}
+
+func (p *Proxy) sendDCTraffic(ctx context.Context) {
+	for _, evt := range p.dcTraffic.collect() {
+		p.eventStream.Send(ctx, evt)
+	}
+}
+
+// rejectProbe closes a connection which is not allowed to access a proxy. If
+// tarpit is enabled and has a free slot, a connection is held open for a
+// while before closing.
+func (p *Proxy) rejectProbe(conn net.Conn, ipAddr net.IP, logger Logger) {
+	select {
+	case p.probeTarpit <- struct{}{}:
+	default:
+		conn.Close()
+
+		return
+	}
+
+	logger.Debug("connection was put into tarpit")
+	p.eventStream.Send(p.ctx, NewEventProbeTarpitted(ipAddr))
+	p.streamWaitGroup.Add(1)
+
+	go func() {
+		defer func() {
+			conn.Close()
+			<-p.probeTarpit
+			p.streamWaitGroup.Done()
+		}()
+
+		timer := time.NewTimer(p.probeTarpitDuration)
+		defer timer.Stop()
+
+		select {
+		case <-p.ctx.Done():
+		case <-timer.C:
+		}
+	}()
+}
+
+func (p *Proxy) waitForAdmission(conn net.Conn, logger Logger) {
+	defer func() {
+		<-p.admissionQueue
+		p.streamWaitGroup.Done()
+	}()
+
+	timer := time.NewTimer(p.admissionQueueTimeout)
+	defer timer.Stop()
+
+	// a send is blocked until a worker gives its slot back, so a waiter
+	// wakes up as soon as a slot is free.
+	select {
+	case p.workerSlots <- struct{}{}:
+		if err := p.invoke(conn); err != nil {
+			conn.Close()
+		}
+	case <-p.ctx.Done():
+		conn.Close()
+	case <-timer.C:
+		conn.Close()
+		logger.Info("connection was concurrency limited after waiting in admission queue")
+		p.eventStream.Send(p.ctx, NewEventConcurrencyLimited())
+	}
+}
+
 // Shutdown 'gracefully' shutdowns all connections. Please remember that it
 // does not close an underlying listener.
 func (p *Proxy) Shutdown() {
+	p.ShutdownWithTimeout(0)
+}
+
+// ShutdownWithTimeout stops accepting new connections and lets active
+// streams finish within a given timeout. Streams which are still active
+// when timeout is elapsed are closed. 0 timeout means no draining at all.
+//
+// Please remember that it does not close an underlying listener. IP lists
+// are stopped unless a proxy is shared, please see [ProxyOpts.Shared].
+func (p *Proxy) ShutdownWithTimeout(timeout time.Duration) {
 	p.ctxCancel()
+
+	if timeout > 0 {
+		drained := make(chan struct{})
+
+		go func() {
+			p.streamWaitGroup.Wait()
+			close(drained)
+		}()
+
+		timer := time.NewTimer(timeout)
+
+		select {
+		case <-drained:
+		case <-timer.C:
+			p.logger.Info("drain timeout is elapsed, close remaining streams")
+		}
+
+		timer.Stop()
+	}
+
+	p.streamsCtxCancel()
 	p.streamWaitGroup.Wait()
 	p.workerPool.Release()
 
-	p.allowlist.Shutdown()
-	p.blocklist.Shutdown()
+	// streams are closed so traffic which is not reported yet is final.
+	p.sendDCTraffic(context.Background())
+
+	if !p.shared {
+		p.allowlist.Shutdown()
+		p.blocklist.Shutdown()
+	}
 }
 
 func (p *Proxy) doFakeTLSHandshake(ctx *streamContext) bool {
@@ -155,41 +637,96 @@ func (p *Proxy) doFakeTLSHandshake(ctx *streamContext) bool {
 
 	rewind := newConnRewind(ctx.clientConn)
 
-	if err := rec.Read(rewind); err != nil {
+	if err := rec.ReadLimit(rewind, p.maxHandshakeSize); err != nil {
+		if errors.Is(err, record.ErrRecordTooLarge) {
+			p.logger.InfoError("client hello is too large", err)
+			ctx.setCloseReason(CloseReasonHandshakeTooLarge)
+			p.eventStream.Send(ctx, NewEventHandshakeTooLarge(ctx.streamID))
+			p.addHandshakeFailure(ctx)
+
+			return false
+		}
+
 		p.logger.InfoError("cannot read client hello", err)
+		p.addHandshakeFailure(ctx)
+
+		if isIncompleteHandshake(err) {
+			ctx.setCloseReason(CloseReasonIncompleteHandshake)
+			p.eventStream.Send(ctx, NewEventIncompleteHandshake(ctx.streamID))
+		}
+
+		ctx.setCloseReason(CloseReasonHandshakeFailed)
+
 		p.doDomainFronting(ctx, rewind)
 
 		return false
 	}
 
-	hello, err := faketls.ParseClientHello(p.secret.Key[:], rec.Payload.Bytes())
+	secrets := p.getSecrets()
+
+	secretIndex, hello, err := matchSecret(secrets, rec.Payload.Bytes())
 	if err != nil {
 		p.logger.InfoError("cannot parse client hello", err)
+		ctx.setCloseReason(CloseReasonHandshakeFailed)
+		p.addHandshakeFailure(ctx)
 		p.doDomainFronting(ctx, rewind)
 
 		return false
 	}
 
-	if err := hello.Valid(p.secret.Host, p.tolerateTimeSkewness); err != nil {
+	secret := secrets[secretIndex]
+	ctx.secret = secret
+
+	if err := hello.Valid(secret.Host, p.tolerateTimeSkewness); err != nil {
 		p.logger.
 			BindStr("hostname", hello.Host).
 			BindStr("hello-time", hello.Time.String()).
 			InfoError("invalid faketls client hello", err)
+		ctx.setCloseReason(CloseReasonHandshakeFailed)
+		p.addHandshakeFailure(ctx)
 		p.doDomainFronting(ctx, rewind)
 
 		return false
 	}
 
-	if p.antiReplayCache.SeenBefore(hello.SessionID) {
+	if hello.Host == "" {
+		p.eventStream.Send(ctx, NewEventMissingSNI(ctx.streamID))
+
+		switch p.onMissingSNI {
+		case MissingSNIPolicyReject:
+			p.logger.Info("client hello has no sni, connection is rejected")
+			ctx.setCloseReason(CloseReasonHandshakeFailed)
+
+			return false
+		case MissingSNIPolicyFront:
+			p.logger.Info("client hello has no sni, connection is fronted")
+			ctx.setCloseReason(CloseReasonHandshakeFailed)
+			p.doDomainFronting(ctx, rewind)
+
+			return false
+		}
+	}
+
+	antiReplayKey := makeAntiReplayKey(ctx.ClientIP(), hello.SessionID, p.antiReplayPerClientIP)
+
+	if p.antiReplayCache.SeenBefore(antiReplayKey) {
 		p.logger.Warning("replay attack has been detected!")
-		p.eventStream.Send(p.ctx, NewEventReplayAttack(ctx.streamID))
+		ctx.setCloseReason(CloseReasonReplayAttack)
+		p.eventStream.Send(ctx, NewEventReplayAttack(ctx.streamID))
+		p.addHandshakeFailure(ctx)
 		p.doDomainFronting(ctx, rewind)
 
 		return false
 	}
 
-	if err := faketls.SendWelcomePacket(rewind, p.secret.Key[:], hello); err != nil {
+	if !p.waitHandshakeJitter(ctx) {
+		return false
+	}
+
+	if err := faketls.SendWelcomePacket(rewind, secret.Key[:], hello); err != nil {
 		p.logger.InfoError("cannot send welcome packet", err)
+		ctx.setCloseReason(CloseReasonIncompleteHandshake)
+		p.eventStream.Send(ctx, NewEventIncompleteHandshake(ctx.streamID))
 
 		return false
 	}
@@ -198,11 +735,64 @@ func (p *Proxy) doFakeTLSHandshake(ctx *streamContext) bool {
 		Conn: ctx.clientConn,
 	}
 
+	ctx.secretFingerprint = secret.Fingerprint()
+	ctx.secretTag = p.secretTag
+
+	p.eventStream.Send(ctx, NewEventSecretMatchedWithOpts(ctx.streamID, EventSecretMatchedOpts{
+		SecretFingerprint: ctx.secretFingerprint,
+		Host:              hello.Host,
+		SecretIndex:       secretIndex,
+	}))
+
 	return true
 }
 
+// matchSecret finds a secret client hello is signed with. It returns an
+// index of this secret and a parsed client hello. If no secret matches, an
+// error of the last one is returned.
+func matchSecret(secrets []Secret, handshake []byte) (int, faketls.ClientHello, error) {
+	var err error
+
+	for i := range secrets {
+		data := handshake
+
+		// client hello is modified by parser so it has to be copied if it
+		// is not the last attempt.
+		if i < len(secrets)-1 {
+			data = append([]byte(nil), handshake...)
+		}
+
+		hello, parseErr := faketls.ParseClientHello(secrets[i].Key[:], data)
+		if parseErr == nil {
+			return i, hello, nil
+		}
+
+		err = parseErr
+	}
+
+	return 0, faketls.ClientHello{}, err
+}
+
+// waitHandshakeJitter sleeps for a random duration up to handshake jitter.
+// It returns false if stream was closed meanwhile.
+func (p *Proxy) waitHandshakeJitter(ctx *streamContext) bool {
+	if p.handshakeJitter <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(p.handshakeJitter))))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 func (p *Proxy) doObfuscated2Handshake(ctx *streamContext) error {
-	dc, encryptor, decryptor, err := obfuscated2.ClientHandshake(p.secret.Key[:], ctx.clientConn)
+	dc, encryptor, decryptor, err := obfuscated2.ClientHandshake(ctx.secret.Key[:], ctx.clientConn)
 	if err != nil {
 		return fmt.Errorf("cannot process client handshake: %w", err)
 	}
@@ -218,21 +808,61 @@ func (p *Proxy) doObfuscated2Handshake(ctx *streamContext) error {
 	return nil
 }
 
+// selectDC returns a DC a stream has to be connected to and a reason why it
+// was chosen.
+func (p *Proxy) selectDC(requestedDC int) (int, string) {
+	switch {
+	case p.telegram.IsKnownDC(requestedDC):
+		return requestedDC, dcSelectionHandshake
+	case p.allowFallbackOnUnknownDC:
+		return p.telegram.GetFallbackDC(), dcSelectionFallback
+	}
+
+	return requestedDC, dcSelectionUnknown
+}
+
 func (p *Proxy) doTelegramCall(ctx *streamContext) error {
-	dc := ctx.dc
+	dc, dcSelection := p.selectDC(ctx.dc)
 
-	if p.allowFallbackOnUnknownDC && !p.telegram.IsKnownDC(dc) {
-		dc = p.telegram.GetFallbackDC()
+	if dcSelection == dcSelectionFallback {
 		ctx.logger = ctx.logger.BindInt("fallback_dc", dc)
 
 		ctx.logger.Warning("unknown DC, fallbacks")
 	}
 
-	conn, err := p.telegram.Dial(ctx, dc)
+	ctx.logger.
+		BindInt("selected_dc", dc).
+		BindStr("dc_selection", dcSelection).
+		Debug("telegram dc is selected")
+
+	if err := p.acquireDC(ctx, dc); err != nil {
+		return err
+	}
+
+	dialStartedAt := time.Now()
+	conn, failedEndpoints, err := p.telegram.Dial(ctx, dc)
+	dialDuration := time.Since(dialStartedAt)
+
 	if err != nil {
+		p.eventStream.Send(ctx,
+			NewEventTelegramDial(ctx.streamID, dc, dialDuration, false, true))
+
 		return fmt.Errorf("cannot dial to Telegram: %w", err)
 	}
 
+	telegramIP := conn.RemoteAddr().(*net.TCPAddr).IP //nolint: forcetypeassert
+
+	p.eventStream.Send(ctx,
+		NewEventTelegramDial(ctx.streamID, dc, dialDuration, telegramIP.To4() == nil, false))
+
+	if failedEndpoints > 0 {
+		ctx.logger.
+			BindInt("dc", dc).
+			BindInt("failed_endpoints", failedEndpoints).
+			Warning("primary DC endpoint is unavailable, fallback endpoint is used")
+		p.eventStream.Send(ctx, NewEventDCEndpointFailover(ctx.streamID, dc, failedEndpoints))
+	}
+
 	encryptor, decryptor, err := obfuscated2.ServerHandshake(conn)
 	if err != nil {
 		conn.Close()
@@ -246,25 +876,54 @@ func (p *Proxy) doTelegramCall(ctx *streamContext) error {
 			streamID: ctx.streamID,
 			stream:   p.eventStream,
 			ctx:      ctx,
+			counter:  p.dcTraffic.counter(dc),
 		},
 		Encryptor: encryptor,
 		Decryptor: decryptor,
 	}
 
-	p.eventStream.Send(ctx,
-		NewEventConnectedToDC(ctx.streamID,
-			conn.RemoteAddr().(*net.TCPAddr).IP, //nolint: forcetypeassert
-			ctx.dc),
-	)
+	p.eventStream.Send(ctx, NewEventConnectedToDC(ctx.streamID, telegramIP, ctx.dc))
+
+	return nil
+}
+
+// acquireDC takes a slot of DC if connections to DCs are limited. A slot
+// is released when stream is closed.
+func (p *Proxy) acquireDC(ctx *streamContext, dc int) error {
+	if p.dcLimiter == nil {
+		return nil
+	}
+
+	startedAt := time.Now()
+	err := p.dcLimiter.acquire(ctx, dc)
+
+	if waited := time.Since(startedAt); waited > DCConnsLimitedWaitThreshold {
+		ctx.logger.
+			BindInt("dc", dc).
+			BindStr("waited", waited.String()).
+			Info("stream waited for a free connection slot of dc")
+		p.eventStream.Send(ctx, NewEventDCConnsLimited(ctx.streamID, dc, waited))
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot get a free connection slot of dc %d: %w", dc, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		p.dcLimiter.release(dc)
+	}()
 
 	return nil
 }
 
 func (p *Proxy) doDomainFronting(ctx *streamContext, conn *connRewind) {
-	p.eventStream.Send(p.ctx, NewEventDomainFronting(ctx.streamID))
+	p.finishHandshake(ctx, true)
+	ctx.setCloseReason(CloseReasonDomainFronting)
+	p.eventStream.Send(ctx, NewEventDomainFronting(ctx.streamID))
 	conn.Rewind()
 
-	frontConn, err := p.network.DialContext(ctx, "tcp", p.DomainFrontingAddress())
+	frontConn, err := p.network.DialContext(ctx, "tcp", p.domainFrontingAddress(ctx.secret))
 	if err != nil {
 		p.logger.WarningError("cannot dial to the fronting domain", err)
 
@@ -283,39 +942,106 @@ func (p *Proxy) doDomainFronting(ctx *streamContext, conn *connRewind) {
 		ctx.logger.Named("domain-fronting"),
 		frontConn,
 		conn,
+		p.relayTimeout,
 	)
 }
 
+// getClientIP returns IP address of the client taking unknown client IP
+// policy into account. It returns nil only if IP address is unknown and no
+// fallback is configured.
+func (p *Proxy) getClientIP(conn net.Conn) net.IP {
+	if ip := getClientIP(conn); ip != nil {
+		return ip
+	}
+
+	if p.unknownClientIPPolicy == UnknownClientIPPolicyFallback {
+		return p.fallbackClientIP
+	}
+
+	return nil
+}
+
+// isIncompleteHandshake checks if handshake has failed because a client
+// has gone away or stopped sending data, not because it has sent
+// something wrong.
+func isIncompleteHandshake(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// makeAntiReplayKey returns a key which is checked against anti-replay cache.
+// If perClientIP is set, then a session id is prefixed with a client IP so the
+// same handshake from different addresses is not considered as a replay.
+func makeAntiReplayKey(clientIP net.IP, sessionID []byte, perClientIP bool) []byte {
+	if !perClientIP {
+		return sessionID
+	}
+
+	key := make([]byte, 0, net.IPv6len+len(sessionID))
+	key = append(key, clientIP.To16()...)
+
+	return append(key, sessionID...)
+}
+
 // NewProxy makes a new proxy instance.
 func NewProxy(opts ProxyOpts) (*Proxy, error) {
 	if err := opts.valid(); err != nil {
 		return nil, fmt.Errorf("invalid settings: %w", err)
 	}
 
-	tg, err := telegram.New(opts.Network, opts.getPreferIP(), opts.UseTestDCs)
+	tg, err := telegram.New(opts.Network, opts.getPreferIP(), opts.UseTestDCs, opts.DCAddresses)
 	if err != nil {
 		return nil, fmt.Errorf("cannot build telegram dialer: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	streamsCtx, streamsCancel := context.WithCancel(context.Background())
 	proxy := &Proxy{
 		ctx:                      ctx,
 		ctxCancel:                cancel,
-		secret:                   opts.Secret,
+		streamsCtx:               streamsCtx,
+		streamsCtxCancel:         streamsCancel,
+		secretTag:                opts.SecretTag,
 		network:                  opts.Network,
 		antiReplayCache:          opts.AntiReplayCache,
 		blocklist:                opts.IPBlocklist,
 		allowlist:                opts.IPAllowlist,
+		shared:                   opts.Shared,
 		eventStream:              opts.EventStream,
 		logger:                   opts.getLogger("proxy"),
 		domainFrontingPort:       opts.getDomainFrontingPort(),
 		tolerateTimeSkewness:     opts.getTolerateTimeSkewness(),
 		allowFallbackOnUnknownDC: opts.AllowFallbackOnUnknownDC,
+		antiReplayPerClientIP:    opts.AntiReplayPerClientIP,
+		workerSlots:              make(chan struct{}, opts.getConcurrency()),
+		admissionQueue:           make(chan struct{}, opts.getAdmissionQueueSize()),
+		admissionQueueTimeout:    opts.getAdmissionQueueTimeout(),
+		fileDescriptorsSoftLimit: int64(opts.FileDescriptorsSoftLimit),
+		probeTarpit:              make(chan struct{}, opts.getProbeTarpitMaxConnections()),
+		probeTarpitDuration:      opts.ProbeTarpitDuration,
+		maxHandshakeSize:         opts.getMaxHandshakeSize(),
+		handshakeJitter:          opts.getHandshakeJitter(),
+		relayTimeout:             opts.RelayTimeout,
+		unknownClientIPPolicy:    opts.getUnknownClientIPPolicy(),
+		fallbackClientIP:         opts.FallbackClientIP,
+		ipListPrecedence:         opts.getIPListPrecedence(),
+		onMissingSNI:             opts.getOnMissingSNI(),
 		telegram:                 tg,
+		dcTraffic:                newDCTraffic(),
 	}
 
-	pool, err := ants.NewPoolWithFunc(opts.getConcurrency(),
+	// a pool only reuses goroutines, concurrency is limited by workerSlots:
+	// a worker of the pool becomes free a bit later than a slot, so waiters
+	// of admission queue could not rely on it.
+	pool, err := ants.NewPoolWithFunc(-1,
 		func(arg interface{}) {
+			defer func() {
+				<-proxy.workerSlots
+			}()
+
 			proxy.ServeConn(arg.(essentials.Conn)) //nolint: forcetypeassert
 		},
 		ants.WithLogger(opts.getLogger("ants")),
@@ -326,5 +1052,71 @@ func NewProxy(opts ProxyOpts) (*Proxy, error) {
 
 	proxy.workerPool = pool
 
+	if opts.MaxConnsPerDC > 0 {
+		proxy.dcLimiter = newDCLimiter(opts.MaxConnsPerDC)
+	}
+
+	proxy.SetClientRateLimit(opts.ClientRateLimit, opts.ClientRateLimitBurst, opts.ClientRateLimitMaxIPs)
+
+	if opts.HandshakeFailureBanThreshold > 0 {
+		proxy.handshakeBans = newHandshakeBans(opts.HandshakeFailureBanThreshold,
+			opts.getHandshakeFailureBanWindow(),
+			opts.getHandshakeFailureBanDuration(),
+			opts.getHandshakeFailureBanMaxIPs())
+
+		proxy.streamWaitGroup.Add(1)
+
+		go proxy.sweepHandshakeBans()
+	}
+
+	if opts.IdleTimeout > 0 {
+		proxy.idleSweeper = relay.NewIdleSweeper(opts.IdleTimeout)
+		proxy.streamWaitGroup.Add(1)
+
+		go proxy.sweepIdleStreams(opts.getIdleSweepInterval())
+	}
+
+	if cache, ok := opts.AntiReplayCache.(AntiReplayCacheStats); ok {
+		proxy.streamWaitGroup.Add(1)
+
+		go proxy.reportAntiReplayStats(cache)
+	}
+
+	proxy.streamWaitGroup.Add(1)
+
+	go proxy.reportDCTraffic()
+
+	proxy.secrets.Store(opts.getSecrets())
+
+	if !proxy.shared {
+		proxy.eventStream.Send(proxy.ctx, NewEventSecretsConfigured(len(opts.getSecrets())))
+	}
+
 	return proxy, nil
 }
+
+// DialTelegram connects to a given Telegram DC in the same way as a proxy
+// with given options does: with its network, IP preference, test DCs and
+// pinned addresses. Other options are ignored. This is useful to check
+// connectivity without running a proxy.
+func DialTelegram(ctx context.Context, opts ProxyOpts, dc int) (essentials.Conn, error) {
+	if opts.Network == nil {
+		return nil, ErrNetworkIsNotDefined
+	}
+
+	tg, err := telegram.New(opts.Network, opts.getPreferIP(), opts.UseTestDCs, opts.DCAddresses)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build telegram dialer: %w", err)
+	}
+
+	if !tg.IsKnownDC(dc) {
+		return nil, fmt.Errorf("unknown dc %d", dc)
+	}
+
+	conn, _, err := tg.Dial(ctx, dc)
+	if err != nil {
+		return nil, err //nolint: wrapcheck
+	}
+
+	return conn, nil
+}
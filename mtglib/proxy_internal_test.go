@@ -0,0 +1,159 @@
+package mtglib
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/internal/testlib"
+	"github.com/IceCodeNew/mtg/mtglib/internal/faketls/record"
+	"github.com/IceCodeNew/mtg/mtglib/internal/telegram"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxyInternalTestSuite struct {
+	suite.Suite
+}
+
+// makeClientHello builds a minimal client hello signed with a given key.
+func (suite *ProxyInternalTestSuite) makeClientHello(key []byte) []byte {
+	handshake := []byte{
+		0x01, 0x00, 0x00, 0x2b, // handshake type and length
+		0x03, 0x03, // version
+	}
+	handshake = append(handshake, make([]byte, 32)...) // random
+	handshake = append(handshake,
+		0x00,                   // session id
+		0x00, 0x02, 0x13, 0x01, // cipher suites
+		0x01, 0x00, // compression methods
+		0x00, 0x00) // extensions
+
+	rec := record.AcquireRecord()
+	defer record.ReleaseRecord(rec)
+
+	rec.Type = record.TypeHandshake
+	rec.Version = record.Version10
+	rec.Payload.Write(handshake)
+
+	mac := hmac.New(sha256.New, key)
+	suite.NoError(rec.Dump(mac))
+
+	random := mac.Sum(nil)
+	timestamp := binary.LittleEndian.Uint32(random[28:]) ^ uint32(time.Now().Unix())
+	binary.LittleEndian.PutUint32(random[28:], timestamp)
+	copy(handshake[6:], random)
+
+	return handshake
+}
+
+func (suite *ProxyInternalTestSuite) TestMatchSecret() {
+	secrets := []Secret{
+		GenerateSecret("example.com"),
+		GenerateSecret("example.org"),
+	}
+
+	for i, v := range secrets {
+		index, hello, err := matchSecret(secrets, suite.makeClientHello(v.Key[:]))
+		suite.NoError(err)
+		suite.Equal(i, index)
+		suite.WithinDuration(time.Now(), hello.Time, 2*time.Second)
+	}
+
+	unknown := GenerateSecret("example.net")
+
+	_, _, err := matchSecret(secrets, suite.makeClientHello(unknown.Key[:]))
+	suite.Error(err)
+}
+
+func (suite *ProxyInternalTestSuite) TestAntiReplayKeyGlobal() {
+	sessionID := []byte{1, 2, 3}
+
+	suite.Equal(sessionID, makeAntiReplayKey(net.ParseIP("10.0.0.1"), sessionID, false))
+	suite.Equal(
+		makeAntiReplayKey(net.ParseIP("10.0.0.1"), sessionID, false),
+		makeAntiReplayKey(net.ParseIP("10.0.0.2"), sessionID, false))
+}
+
+func (suite *ProxyInternalTestSuite) TestAntiReplayKeyPerClientIP() {
+	sessionID := []byte{1, 2, 3}
+
+	key1 := makeAntiReplayKey(net.ParseIP("10.0.0.1"), sessionID, true)
+	key2 := makeAntiReplayKey(net.ParseIP("10.0.0.2"), sessionID, true)
+
+	suite.NotEqual(key1, key2)
+	suite.Equal(key1, makeAntiReplayKey(net.ParseIP("10.0.0.1").To4(), sessionID, true))
+	suite.Equal(append([]byte(net.ParseIP("10.0.0.1").To16()), sessionID...), key1)
+	suite.Equal([]byte{1, 2, 3}, sessionID)
+}
+
+func (suite *ProxyInternalTestSuite) TestClientIPPolicy() {
+	connMock := &testlib.EssentialsConnMock{}
+	connMock.On("RemoteAddr").Return(&net.UnixAddr{Name: "/tmp/mtg.sock", Net: "unix"})
+
+	proxy := &Proxy{unknownClientIPPolicy: UnknownClientIPPolicyAllow}
+	suite.Nil(proxy.getClientIP(connMock))
+
+	proxy = &Proxy{
+		unknownClientIPPolicy: UnknownClientIPPolicyFallback,
+		fallbackClientIP:      net.ParseIP("10.0.0.1"),
+	}
+	suite.Equal("10.0.0.1", proxy.getClientIP(connMock).String())
+
+	connMock = &testlib.EssentialsConnMock{}
+	connMock.On("RemoteAddr").Return(nil)
+
+	suite.Equal("10.0.0.1", proxy.getClientIP(connMock).String())
+}
+
+func (suite *ProxyInternalTestSuite) TestHandshakeJitter() {
+	suite.Equal(time.Duration(0), ProxyOpts{}.getHandshakeJitter())
+	suite.Equal(MaxHandshakeJitter, ProxyOpts{HandshakeJitter: time.Hour}.getHandshakeJitter())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	streamCtx := &streamContext{ctx: ctx, ctxCancel: cancel}
+	proxy := &Proxy{handshakeJitter: 50 * time.Millisecond}
+
+	startedAt := time.Now()
+
+	suite.True(proxy.waitHandshakeJitter(streamCtx))
+	suite.Less(time.Since(startedAt), 100*time.Millisecond)
+
+	cancel()
+
+	proxy.handshakeJitter = time.Hour
+	suite.False(proxy.waitHandshakeJitter(streamCtx))
+}
+
+func (suite *ProxyInternalTestSuite) TestSelectDC() {
+	tg, err := telegram.New(nil, "prefer-ipv4", false, nil)
+	suite.NoError(err)
+
+	proxy := &Proxy{telegram: tg}
+
+	dc, selection := proxy.selectDC(2)
+	suite.Equal(2, dc)
+	suite.Equal(dcSelectionHandshake, selection)
+
+	dc, selection = proxy.selectDC(100)
+	suite.Equal(100, dc)
+	suite.Equal(dcSelectionUnknown, selection)
+
+	proxy.allowFallbackOnUnknownDC = true
+
+	dc, selection = proxy.selectDC(100)
+	suite.True(tg.IsKnownDC(dc))
+	suite.Equal(dcSelectionFallback, selection)
+
+	dc, selection = proxy.selectDC(2)
+	suite.Equal(2, dc)
+	suite.Equal(dcSelectionHandshake, selection)
+}
+
+func TestProxyInternal(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyInternalTestSuite{})
+}
@@ -1,6 +1,11 @@
 package mtglib
 
-import "time"
+import (
+	"net"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib/internal/faketls/record"
+)
 
 // ProxyOpts is a structure with settings to mtg proxy.
 //
@@ -9,9 +14,26 @@ import "time"
 type ProxyOpts struct {
 	// Secret defines a secret which should be used by a proxy.
 	//
-	// This is a mandatory setting.
+	// This is a mandatory setting if Secrets are not set.
 	Secret Secret
 
+	// Secrets defines additional secrets which should be used by a proxy.
+	// A client may pass a handshake with any of them. If Secret is set, it
+	// goes first so it has index 0 in [EventSecretMatched]. Each secret has
+	// its own fronting domain.
+	//
+	// Secrets should have different keys: if a few secrets share the same
+	// key, only the first one is matched.
+	//
+	// This is an optional setting.
+	Secrets []Secret
+
+	// SecretTag is a free-form label of the secret. Custom event streams can
+	// get it from a stream context by [ContextKeySecretTag] key.
+	//
+	// This is an optional setting.
+	SecretTag string
+
 	// Network defines a network instance which should be used for all network
 	// communications made by proxies.
 	//
@@ -33,6 +55,19 @@ type ProxyOpts struct {
 	// This is an optional setting, ignored by default (no restrictions).
 	IPAllowlist IPBlocklist
 
+	// Shared tells that a proxy is one of many proxies which are run
+	// together, for example, proxies of SO_REUSEPORT or additional
+	// listeners. They share IP lists and an event stream which are owned by
+	// a caller:
+	//   - a proxy does not stop IPBlocklist and IPAllowlist on shutdown, a
+	//     caller stops them after all proxies are shut down;
+	//   - a proxy does not send EventSecretsConfigured, a caller sends a
+	//     single event with a number of secrets of all proxies.
+	//
+	// This is an optional setting. By default, a proxy owns its IP lists
+	// and reports its own secrets.
+	Shared bool
+
 	// EventStream defines an instance of event stream.
 	//
 	// This ia a mandatory setting.
@@ -56,20 +91,172 @@ type ProxyOpts struct {
 	// Concurrency is a size of the worker pool for connection management.
 	//
 	// If we have more connections than this number, they are going to be
-	// rejected.
+	// rejected or put into admission queue.
 	//
 	// This is an optional setting.
 	Concurrency uint
 
+	// AdmissionQueueSize is a max number of connections which may wait for a
+	// free worker if proxy has reached its concurrency limit.
+	//
+	// If this number is 0, then connections above a concurrency limit are
+	// rejected immediately.
+	//
+	// This is an optional setting.
+	AdmissionQueueSize uint
+
+	// MaxConnsPerDC is a max number of concurrent streams connected to the
+	// same Telegram DC. If DC has no free slots, a new stream waits until
+	// some other stream is finished or a client goes away. Telegram may
+	// rate limit a proxy which opens too many connections to one DC.
+	//
+	// This is an optional setting. 0 means no limit.
+	MaxConnsPerDC int
+
+	// AdmissionQueueTimeout is a max time period connection may spend in
+	// admission queue. If no worker is freed during this period, a connection
+	// is rejected.
+	//
+	// This is an optional setting.
+	AdmissionQueueTimeout time.Duration
+
+	// FileDescriptorsSoftLimit is a max number of file descriptors which
+	// client streams may use. Each stream is accounted as 2 descriptors:
+	// one for a client connection and one for a telegram connection.
+	//
+	// If accepting a new connection would exceed this limit, it is closed
+	// immediately. This keeps a proxy below a hard limit of the operating
+	// system so it never starts to fail on accept. 0 means no limit.
+	//
+	// This is an optional setting.
+	FileDescriptorsSoftLimit uint
+
 	// IdleTimeout is a timeout for relay when we have to break a stream.
 	//
 	// This is a timeout for any activity. So, if we have any message which will
 	// pass to either direction, a timer is reset. If we have no any reads or
-	// writes for this timeout, a connection will be aborted.
+	// writes for this timeout, a connection will be aborted and
+	// EventIdleTimeout is emitted. This closes half-open streams of
+	// clients which have disappeared without closing a connection. Values
+	// below [MinSafeIdleTimeout] may close streams of quiet clients.
 	//
-	// This is an optional setting.
+	// This is an optional setting. 0 disables idle timeout.
 	IdleTimeout time.Duration
 
+	// IdleSweepInterval is a time period between checks of idle streams.
+	//
+	// A proxy has a single sweeper for all streams instead of a timer per
+	// stream, so an idle stream is closed with a delay up to this interval.
+	// Shorter intervals are more precise but cost more CPU with many
+	// streams. It is used only if IdleTimeout is set.
+	//
+	// This is an optional setting. Default is [DefaultIdleSweepInterval].
+	IdleSweepInterval time.Duration
+
+	// RelayTimeout is a max time period a single read or write of a relay
+	// may block. A deadline is reset before each operation so unlike an
+	// idle timeout it also catches peers which are not idle but make no
+	// progress: for example, a peer which acknowledges packets but never
+	// reads them.
+	//
+	// This is an optional setting. 0 disables it.
+	RelayTimeout time.Duration
+
+	// ProbeTarpitDuration is a time period connection from blocklisted (or not
+	// allowlisted) IP address is held open before it is closed. A goal is to
+	// slow down mass scanners: instead of a fast rejection they have to wait.
+	//
+	// If this setting is 0, then such connections are closed immediately.
+	//
+	// This is an optional setting.
+	ProbeTarpitDuration time.Duration
+
+	// ProbeTarpitMaxConnections is a max number of connections that can be
+	// held in tarpit simultaneously. If tarpit is full, connections are closed
+	// immediately. This protects proxy from exhausting its own resources.
+	//
+	// This is an optional setting.
+	ProbeTarpitMaxConnections uint
+
+	// HandshakeFailureBanThreshold is a number of failed handshakes from
+	// the same IP address after which this address is banned. Connections
+	// from banned addresses are rejected before handshake in the same way
+	// as blocklisted ones.
+	//
+	// Failed handshakes are those which are routed to a fronting domain,
+	// replays and handshakes which are too large or cannot be read.
+	//
+	// This is an optional setting. 0 disables bans.
+	HandshakeFailureBanThreshold uint
+
+	// HandshakeFailureBanWindow is a time period failed handshakes are
+	// counted within. A window starts with the first failure, counter is
+	// reset when it is over.
+	//
+	// This is an optional setting.
+	HandshakeFailureBanWindow time.Duration
+
+	// HandshakeFailureBanDuration is a time period IP address is banned
+	// for.
+	//
+	// This is an optional setting.
+	HandshakeFailureBanDuration time.Duration
+
+	// HandshakeFailureBanMaxIPs is a max number of IP addresses failed
+	// handshakes are tracked for. If there is no space for a new address,
+	// the least recently seen one is forgotten, even if it is banned.
+	//
+	// This is an optional setting.
+	HandshakeFailureBanMaxIPs uint
+
+	// ClientRateLimit is a number of new connections per second allowed
+	// from the same client IP address. Connections over the limit are
+	// closed before handshake, even before they are given to a worker.
+	// This is a token bucket: a client can make [ClientRateLimitBurst]
+	// connections at once and then bucket is refilled with this rate.
+	//
+	// This is an optional setting. 0 disables rate limiting.
+	ClientRateLimit uint
+
+	// ClientRateLimitBurst is a max number of connections client IP can
+	// make at once.
+	//
+	// This is an optional setting. Default is [ClientRateLimit].
+	ClientRateLimitBurst uint
+
+	// ClientRateLimitMaxIPs is a max number of IP addresses rate limits are
+	// tracked for. If there is no space for a new address, the least
+	// recently seen one is forgotten.
+	//
+	// This is an optional setting.
+	ClientRateLimitMaxIPs uint
+
+	// MaxHandshakeSize defines a max size of the payload of the first TLS
+	// record (client hello) in bytes. If client declares a bigger record,
+	// connection is closed before the payload is read. This caps the memory
+	// a single unauthenticated connection can force proxy to buffer.
+	//
+	// Please be aware that such connections are not routed to a fronting
+	// domain, so a value which is too small can make proxy distinguishable
+	// from a real TLS server. Real client hellos rarely exceed 2-3
+	// kilobytes.
+	//
+	// This is an optional setting. Default is the max size of the TLS
+	// record.
+	MaxHandshakeSize uint
+
+	// HandshakeJitter is a max random delay which is added before proxy
+	// responds to a valid client hello. Each response is delayed by a
+	// random duration in [0, HandshakeJitter). This blurs timing of the
+	// response which otherwise can be used to fingerprint a proxy.
+	//
+	// Please remember that this is a tradeoff: each new connection becomes
+	// slower on average by a half of this value. Values larger than
+	// MaxHandshakeJitter are capped.
+	//
+	// This is an optional setting. Default is no jitter.
+	HandshakeJitter time.Duration
+
 	// TolerateTimeSkewness is a time boundary that defines a time range where
 	// faketls timestamp is acceptable.
 	//
@@ -85,6 +272,14 @@ type ProxyOpts struct {
 	// This is an optional setting.
 	PreferIP string
 
+	// DCAddresses pins IP addresses of Telegram DCs: a key is a number of
+	// DC, a value is a list of its IPv4 and IPv6 addresses which replace
+	// built-in ones. Port is always 443. PreferIP still chooses an address
+	// family. DCs which are not mentioned here use built-in addresses.
+	//
+	// This is an optional setting.
+	DCAddresses map[int][]net.IP
+
 	// DomainFrontingPort is a port we use to connect to a fronting domain.
 	//
 	// This is required because secret does not specify a port. It specifies a
@@ -103,6 +298,58 @@ type ProxyOpts struct {
 	// This is an optional setting.
 	AllowFallbackOnUnknownDC bool
 
+	// AntiReplayPerClientIP defines a scope of replay detection. By default,
+	// a handshake is a replay if it was observed before from any client. If
+	// this setting is true, then it is a replay only if it was observed before
+	// from the same IP address.
+	//
+	// This reduces false positives when the cache is getting full and
+	// unrelated clients collide there. But this comes with a security cost: an
+	// attacker who captured a handshake can replay it from any other IP
+	// address and proxy won't notice that.
+	//
+	// This is an optional setting.
+	AntiReplayPerClientIP bool
+
+	// UnknownClientIPPolicy defines what to do if IP address of the client
+	// cannot be determined. For example, if a listener works with Unix
+	// sockets or remote address is unspecified. Valid values are:
+	// 'reject', 'allow' and 'fallback'. Please see [UnknownClientIPPolicyReject],
+	// [UnknownClientIPPolicyAllow] and [UnknownClientIPPolicyFallback] for
+	// details.
+	//
+	// This is an optional setting. Default is 'reject'.
+	UnknownClientIPPolicy string
+
+	// FallbackClientIP is an IP address which is used for the clients with
+	// unknown IP address if UnknownClientIPPolicy is 'fallback'.
+	//
+	// This setting is mandatory for 'fallback' policy.
+	FallbackClientIP net.IP
+
+	// IPListPrecedence defines what to do if client IP is in both
+	// IPAllowlist and IPBlocklist. Valid values are 'block-wins' and
+	// 'allow-wins'. Please see [IPListPrecedenceBlockWins] and
+	// [IPListPrecedenceAllowWins] for details.
+	//
+	// Please pay attention that 'allow-wins' makes sense only with a real
+	// allowlist: if allowlist has all IP addresses, blocklist has no effect.
+	//
+	// This is an optional setting. Default is 'block-wins'.
+	IPListPrecedence string
+
+	// OnMissingSNI defines what to do if client hello has a valid secret
+	// but no SNI. Some old clients do not send it, but a real TLS client
+	// always does. Valid values are 'allow', 'reject' and 'front'. Please
+	// see [MissingSNIPolicyAllow], [MissingSNIPolicyReject] and
+	// [MissingSNIPolicyFront] for details.
+	//
+	// Client hello with SNI which does not match a secret is always routed
+	// to a fronting domain.
+	//
+	// This is an optional setting. Default is 'allow'.
+	OnMissingSNI string
+
 	// UseTestDCs defines if we have to connect to production or to staging DCs of
 	// Telegram.
 	//
@@ -127,10 +374,41 @@ func (p ProxyOpts) valid() error {
 		return ErrEventStreamIsNotDefined
 	case p.Logger == nil:
 		return ErrLoggerIsNotDefined
-	case !p.Secret.Valid():
+	}
+
+	secrets := p.getSecrets()
+	if len(secrets) == 0 {
 		return ErrSecretInvalid
 	}
 
+	for _, v := range secrets {
+		if !v.Valid() {
+			return ErrSecretInvalid
+		}
+	}
+
+	switch p.getUnknownClientIPPolicy() {
+	case UnknownClientIPPolicyReject, UnknownClientIPPolicyAllow:
+	case UnknownClientIPPolicyFallback:
+		if p.FallbackClientIP == nil {
+			return ErrUnknownClientIPPolicyInvalid
+		}
+	default:
+		return ErrUnknownClientIPPolicyInvalid
+	}
+
+	switch p.getIPListPrecedence() {
+	case IPListPrecedenceBlockWins, IPListPrecedenceAllowWins:
+	default:
+		return ErrIPListPrecedenceInvalid
+	}
+
+	switch p.getOnMissingSNI() {
+	case MissingSNIPolicyAllow, MissingSNIPolicyReject, MissingSNIPolicyFront:
+	default:
+		return ErrMissingSNIPolicyInvalid
+	}
+
 	return nil
 }
 
@@ -142,6 +420,102 @@ func (p ProxyOpts) getConcurrency() int {
 	return int(p.Concurrency)
 }
 
+func (p ProxyOpts) getAdmissionQueueSize() int {
+	return int(p.AdmissionQueueSize)
+}
+
+func (p ProxyOpts) getSecrets() []Secret {
+	if p.Secret == (Secret{}) {
+		return p.Secrets
+	}
+
+	return append([]Secret{p.Secret}, p.Secrets...)
+}
+
+func (p ProxyOpts) getAdmissionQueueTimeout() time.Duration {
+	if p.AdmissionQueueTimeout == 0 {
+		return DefaultAdmissionQueueTimeout
+	}
+
+	return p.AdmissionQueueTimeout
+}
+
+func (p ProxyOpts) getProbeTarpitMaxConnections() int {
+	if p.ProbeTarpitDuration == 0 {
+		return 0
+	}
+
+	if p.ProbeTarpitMaxConnections == 0 {
+		return DefaultProbeTarpitMaxConnections
+	}
+
+	return int(p.ProbeTarpitMaxConnections)
+}
+
+func (p ProxyOpts) getClientRateLimitBurst() uint {
+	if p.ClientRateLimitBurst == 0 {
+		return p.ClientRateLimit
+	}
+
+	return p.ClientRateLimitBurst
+}
+
+func (p ProxyOpts) getClientRateLimitMaxIPs() int {
+	if p.ClientRateLimitMaxIPs == 0 {
+		return DefaultClientRateLimitMaxIPs
+	}
+
+	return int(p.ClientRateLimitMaxIPs)
+}
+
+func (p ProxyOpts) getIdleSweepInterval() time.Duration {
+	if p.IdleSweepInterval == 0 {
+		return DefaultIdleSweepInterval
+	}
+
+	return p.IdleSweepInterval
+}
+
+func (p ProxyOpts) getHandshakeFailureBanWindow() time.Duration {
+	if p.HandshakeFailureBanWindow == 0 {
+		return DefaultHandshakeFailureBanWindow
+	}
+
+	return p.HandshakeFailureBanWindow
+}
+
+func (p ProxyOpts) getHandshakeFailureBanDuration() time.Duration {
+	if p.HandshakeFailureBanDuration == 0 {
+		return DefaultHandshakeFailureBanDuration
+	}
+
+	return p.HandshakeFailureBanDuration
+}
+
+func (p ProxyOpts) getHandshakeFailureBanMaxIPs() int {
+	if p.HandshakeFailureBanMaxIPs == 0 {
+		return DefaultHandshakeFailureBanMaxIPs
+	}
+
+	return int(p.HandshakeFailureBanMaxIPs)
+}
+
+func (p ProxyOpts) getMaxHandshakeSize() int {
+	if p.MaxHandshakeSize == 0 || p.MaxHandshakeSize > record.TLSMaxRecordSize {
+		return record.TLSMaxRecordSize
+	}
+
+	return int(p.MaxHandshakeSize)
+}
+
+func (p ProxyOpts) getHandshakeJitter() time.Duration {
+	if p.HandshakeJitter > MaxHandshakeJitter {
+		return MaxHandshakeJitter
+	}
+
+	return p.HandshakeJitter
+}
+
 func (p ProxyOpts) getDomainFrontingPort() int {
 	if p.DomainFrontingPort == 0 {
 		return DefaultDomainFrontingPort
@@ -166,6 +540,30 @@ func (p ProxyOpts) getPreferIP() string {
 	return p.PreferIP
 }
 
+func (p ProxyOpts) getUnknownClientIPPolicy() string {
+	if p.UnknownClientIPPolicy == "" {
+		return DefaultUnknownClientIPPolicy
+	}
+
+	return p.UnknownClientIPPolicy
+}
+
+func (p ProxyOpts) getIPListPrecedence() string {
+	if p.IPListPrecedence == "" {
+		return DefaultIPListPrecedence
+	}
+
+	return p.IPListPrecedence
+}
+
+func (p ProxyOpts) getOnMissingSNI() string {
+	if p.OnMissingSNI == "" {
+		return DefaultMissingSNIPolicy
+	}
+
+	return p.OnMissingSNI
+}
+
 func (p ProxyOpts) getLogger(name string) Logger {
 	return p.Logger.Named(name)
 }
@@ -5,15 +5,19 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/IceCodeNew/mtg/antireplay"
 	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/internal/testlib"
 	"github.com/IceCodeNew/mtg/ipblocklist"
 	"github.com/IceCodeNew/mtg/ipblocklist/files"
 	"github.com/IceCodeNew/mtg/logger"
@@ -22,6 +26,7 @@ import (
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/dcs"
 	"github.com/gotd/td/tg"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/yl2chen/cidranger"
 )
@@ -45,10 +50,14 @@ func (suite *ProxyTestSuite) ProxySecret() string {
 }
 
 func (suite *ProxyTestSuite) SetupSuite() {
-	dialer, err := network.NewDefaultDialer(0, 0)
+	dialer, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
 	suite.NoError(err)
 
-	ntw, err := network.NewNetwork(dialer, "mtgtest", "1.1.1.1", 0)
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      dialer,
+		UserAgent:   "mtgtest",
+		DOHHostname: "1.1.1.1",
+	})
 	suite.NoError(err)
 
 	allowlist, _ := ipblocklist.NewFireholFromFiles(
@@ -107,6 +116,26 @@ func (suite *ProxyTestSuite) TestCannotInitNoSecret() {
 	suite.Error(err)
 }
 
+func (suite *ProxyTestSuite) TestCannotInitInvalidSecrets() {
+	opts := *suite.opts
+	opts.Secrets = []mtglib.Secret{{}}
+
+	_, err := mtglib.NewProxy(opts)
+	suite.ErrorIs(err, mtglib.ErrSecretInvalid)
+}
+
+func (suite *ProxyTestSuite) TestInitOnlySecrets() {
+	opts := *suite.opts
+	opts.Secret = mtglib.Secret{}
+	opts.Secrets = []mtglib.Secret{mtglib.GenerateSecret("example.com")}
+
+	proxy, err := mtglib.NewProxy(opts)
+	suite.NoError(err)
+	suite.Equal("example.com:443", proxy.DomainFrontingAddress())
+
+	proxy.Shutdown()
+}
+
 func (suite *ProxyTestSuite) TestCannotInitNoNetwork() {
 	opts := *suite.opts
 	opts.Network = nil
@@ -163,6 +192,38 @@ func (suite *ProxyTestSuite) TestCannotInitIncorrectPreferIP() {
 	suite.Error(err)
 }
 
+func (suite *ProxyTestSuite) TestCannotInitIncorrectUnknownClientIPPolicy() {
+	opts := *suite.opts
+	opts.UnknownClientIPPolicy = "xxx"
+
+	_, err := mtglib.NewProxy(opts)
+	suite.ErrorIs(err, mtglib.ErrUnknownClientIPPolicyInvalid)
+}
+
+func (suite *ProxyTestSuite) TestCannotInitFallbackWithoutIP() {
+	opts := *suite.opts
+	opts.UnknownClientIPPolicy = mtglib.UnknownClientIPPolicyFallback
+
+	_, err := mtglib.NewProxy(opts)
+	suite.ErrorIs(err, mtglib.ErrUnknownClientIPPolicyInvalid)
+}
+
+func (suite *ProxyTestSuite) TestCannotInitIncorrectIPListPrecedence() {
+	opts := *suite.opts
+	opts.IPListPrecedence = "xxx"
+
+	_, err := mtglib.NewProxy(opts)
+	suite.ErrorIs(err, mtglib.ErrIPListPrecedenceInvalid)
+}
+
+func (suite *ProxyTestSuite) TestCannotInitIncorrectMissingSNIPolicy() {
+	opts := *suite.opts
+	opts.OnMissingSNI = "xxx"
+
+	_, err := mtglib.NewProxy(opts)
+	suite.ErrorIs(err, mtglib.ErrMissingSNIPolicyInvalid)
+}
+
 func (suite *ProxyTestSuite) TestDomainFrontingAddress() {
 	suite.Equal("httpbin.org:443", suite.p.DomainFrontingAddress())
 }
@@ -229,3 +290,885 @@ func TestProxy(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &ProxyTestSuite{})
 }
+
+type proxyEventCounter struct {
+	started            int32
+	concurrencyLimited int32
+	probeTarpitted     int32
+	handshakeTooLarge  int32
+	ipBanned           int32
+	incomplete         int32
+	handshakeFailed    int32
+	activeStreams      int32
+	maxActiveStreams   int32
+	rateLimited        int32
+	secretsConfigured  int32
+	closeReason        atomic.Value
+}
+
+func (p *proxyEventCounter) Send(_ context.Context, evt mtglib.Event) {
+	switch evt.(type) {
+	case mtglib.EventStart:
+		atomic.AddInt32(&p.started, 1)
+	case mtglib.EventConcurrencyLimited:
+		atomic.AddInt32(&p.concurrencyLimited, 1)
+	case mtglib.EventProbeTarpitted:
+		atomic.AddInt32(&p.probeTarpitted, 1)
+	case mtglib.EventHandshakeTooLarge:
+		atomic.AddInt32(&p.handshakeTooLarge, 1)
+	case mtglib.EventIPBanned:
+		atomic.AddInt32(&p.ipBanned, 1)
+	case mtglib.EventClientRateLimited:
+		atomic.AddInt32(&p.rateLimited, 1)
+	case mtglib.EventSecretsConfigured:
+		atomic.AddInt32(&p.secretsConfigured, 1)
+	case mtglib.EventIncompleteHandshake:
+		atomic.AddInt32(&p.incomplete, 1)
+	case mtglib.EventFinish:
+		p.closeReason.Store(evt.(mtglib.EventFinish).Reason) //nolint: forcetypeassert
+	case mtglib.EventActiveStreams:
+		count := int32(evt.(mtglib.EventActiveStreams).Count) //nolint: forcetypeassert
+
+		atomic.StoreInt32(&p.activeStreams, count)
+
+		if count > atomic.LoadInt32(&p.maxActiveStreams) {
+			atomic.StoreInt32(&p.maxActiveStreams, count)
+		}
+	case mtglib.EventHandshakeFinished:
+		if evt.(mtglib.EventHandshakeFinished).IsFailed { //nolint: forcetypeassert
+			atomic.AddInt32(&p.handshakeFailed, 1)
+		}
+	}
+}
+
+// proxyOfflineTestSuite runs a proxy which never reaches network.
+type proxyOfflineTestSuite struct {
+	suite.Suite
+
+	eventStream *proxyEventCounter
+	p           *mtglib.Proxy
+	listener    net.Listener
+}
+
+func (suite *proxyOfflineTestSuite) MakeAllIPsList() mtglib.IPBlocklist {
+	list, _ := ipblocklist.NewFireholFromFiles(
+		logger.NewNoopLogger(),
+		1,
+		[]files.File{
+			files.NewMem([]*net.IPNet{
+				cidranger.AllIPv4,
+				cidranger.AllIPv6,
+			}),
+		},
+		nil,
+	)
+
+	go list.Run(time.Second)
+
+	suite.Eventually(func() bool {
+		return list.Contains(net.ParseIP("127.0.0.1"))
+	}, time.Second, 10*time.Millisecond)
+
+	return list
+}
+
+func (suite *proxyOfflineTestSuite) StartProxy(opts mtglib.ProxyOpts) {
+	networkMock := &testlib.MtglibNetworkMock{}
+	networkMock.
+		On("DialContext", mock.Anything, mock.Anything, mock.Anything).
+		Maybe().
+		Return(&testlib.EssentialsConnMock{}, io.EOF)
+
+	suite.eventStream = &proxyEventCounter{}
+
+	opts.Secret = mtglib.GenerateSecret("example.com")
+	opts.Network = networkMock
+	opts.AntiReplayCache = antireplay.NewNoop()
+	opts.EventStream = suite.eventStream
+	opts.Logger = logger.NewNoopLogger()
+
+	if opts.IPBlocklist == nil {
+		opts.IPBlocklist = ipblocklist.NewNoop()
+	}
+
+	if opts.IPAllowlist == nil {
+		opts.IPAllowlist = suite.MakeAllIPsList()
+	}
+
+	proxy, err := mtglib.NewProxy(opts)
+	suite.NoError(err)
+
+	suite.p = proxy
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.NoError(err)
+
+	suite.listener = listener
+
+	go suite.p.Serve(suite.listener) //nolint: errcheck
+}
+
+func (suite *proxyOfflineTestSuite) Dial() net.Conn {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	time.Sleep(50 * time.Millisecond)
+
+	return conn
+}
+
+func (suite *proxyOfflineTestSuite) Started() int32 {
+	return atomic.LoadInt32(&suite.eventStream.started)
+}
+
+func (suite *proxyOfflineTestSuite) ConcurrencyLimited() int32 {
+	return atomic.LoadInt32(&suite.eventStream.concurrencyLimited)
+}
+
+func (suite *proxyOfflineTestSuite) ProbeTarpitted() int32 {
+	return atomic.LoadInt32(&suite.eventStream.probeTarpitted)
+}
+
+func (suite *proxyOfflineTestSuite) HandshakeTooLarge() int32 {
+	return atomic.LoadInt32(&suite.eventStream.handshakeTooLarge)
+}
+
+func (suite *proxyOfflineTestSuite) IPBanned() int32 {
+	return atomic.LoadInt32(&suite.eventStream.ipBanned)
+}
+
+func (suite *proxyOfflineTestSuite) RateLimited() int32 {
+	return atomic.LoadInt32(&suite.eventStream.rateLimited)
+}
+
+func (suite *proxyOfflineTestSuite) SecretsConfigured() int32 {
+	return atomic.LoadInt32(&suite.eventStream.secretsConfigured)
+}
+
+func (suite *proxyOfflineTestSuite) IncompleteHandshakes() int32 {
+	return atomic.LoadInt32(&suite.eventStream.incomplete)
+}
+
+func (suite *proxyOfflineTestSuite) FailedHandshakes() int32 {
+	return atomic.LoadInt32(&suite.eventStream.handshakeFailed)
+}
+
+func (suite *proxyOfflineTestSuite) CloseReason() mtglib.CloseReason {
+	reason, _ := suite.eventStream.closeReason.Load().(mtglib.CloseReason)
+
+	return reason
+}
+
+func (suite *proxyOfflineTestSuite) ActiveStreams() (int32, int32) {
+	return atomic.LoadInt32(&suite.eventStream.activeStreams),
+		atomic.LoadInt32(&suite.eventStream.maxActiveStreams)
+}
+
+func (suite *proxyOfflineTestSuite) TearDownTest() {
+	if suite.listener != nil {
+		suite.listener.Close()
+	}
+
+	if suite.p != nil {
+		suite.p.Shutdown()
+	}
+}
+
+type ProxyAdmissionQueueTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyAdmissionQueueTestSuite) StartProxy(queueSize uint, timeout time.Duration) {
+	suite.proxyOfflineTestSuite.StartProxy(mtglib.ProxyOpts{
+		Concurrency:           1,
+		AdmissionQueueSize:    queueSize,
+		AdmissionQueueTimeout: timeout,
+	})
+}
+
+func (suite *ProxyAdmissionQueueTestSuite) TestNoQueue() {
+	suite.StartProxy(0, 0)
+
+	conn1 := suite.Dial()
+	defer conn1.Close()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.EqualValues(1, suite.Started())
+	suite.EqualValues(1, suite.ConcurrencyLimited())
+
+	conn2.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err := conn2.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+}
+
+func (suite *ProxyAdmissionQueueTestSuite) TestAdmitted() {
+	suite.StartProxy(1, time.Second)
+
+	conn1 := suite.Dial()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.EqualValues(1, suite.Started())
+
+	conn1.Close()
+
+	suite.Eventually(func() bool {
+		return suite.Started() == 2
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(0, suite.ConcurrencyLimited())
+}
+
+func (suite *ProxyAdmissionQueueTestSuite) TestTimeout() {
+	suite.StartProxy(1, 100*time.Millisecond)
+
+	conn1 := suite.Dial()
+	defer conn1.Close()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.EqualValues(0, suite.ConcurrencyLimited())
+
+	suite.Eventually(func() bool {
+		return suite.ConcurrencyLimited() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(1, suite.Started())
+
+	conn2.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err := conn2.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+}
+
+func (suite *ProxyAdmissionQueueTestSuite) TestQueueIsFull() {
+	suite.StartProxy(1, time.Second)
+
+	conn1 := suite.Dial()
+	defer conn1.Close()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	conn3 := suite.Dial()
+	defer conn3.Close()
+
+	suite.EqualValues(1, suite.Started())
+	suite.EqualValues(1, suite.ConcurrencyLimited())
+}
+
+func TestProxyAdmissionQueue(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyAdmissionQueueTestSuite{})
+}
+
+type ProxyFileDescriptorsTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyFileDescriptorsTestSuite) TestSoftLimit() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		FileDescriptorsSoftLimit: 3,
+	})
+
+	conn1 := suite.Dial()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.EqualValues(1, suite.Started())
+	suite.EqualValues(1, suite.ConcurrencyLimited())
+
+	conn2.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err := conn2.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+
+	conn1.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	conn3 := suite.Dial()
+	defer conn3.Close()
+
+	suite.EqualValues(2, suite.Started())
+	suite.EqualValues(1, suite.ConcurrencyLimited())
+}
+
+func (suite *ProxyFileDescriptorsTestSuite) TestNoLimit() {
+	suite.StartProxy(mtglib.ProxyOpts{})
+
+	conn1 := suite.Dial()
+	defer conn1.Close()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.EqualValues(2, suite.Started())
+	suite.EqualValues(0, suite.ConcurrencyLimited())
+}
+
+func TestProxyFileDescriptors(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyFileDescriptorsTestSuite{})
+}
+
+type ProxyIPListPrecedenceTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyIPListPrecedenceTestSuite) TestBlockWins() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist: suite.MakeAllIPsList(),
+		IPAllowlist: suite.MakeAllIPsList(),
+	})
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(0, suite.Started())
+}
+
+func (suite *ProxyIPListPrecedenceTestSuite) TestAllowWins() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist:      suite.MakeAllIPsList(),
+		IPAllowlist:      suite.MakeAllIPsList(),
+		IPListPrecedence: mtglib.IPListPrecedenceAllowWins,
+	})
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(1, suite.Started())
+}
+
+func (suite *ProxyIPListPrecedenceTestSuite) TestAllowWinsBlocklisted() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist:      suite.MakeAllIPsList(),
+		IPAllowlist:      ipblocklist.NewNoop(),
+		IPListPrecedence: mtglib.IPListPrecedenceAllowWins,
+	})
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(0, suite.Started())
+}
+
+func (suite *ProxyIPListPrecedenceTestSuite) TestAllowWinsNotListed() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPAllowlist:      ipblocklist.NewNoop(),
+		IPListPrecedence: mtglib.IPListPrecedenceAllowWins,
+	})
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(1, suite.Started())
+}
+
+func TestProxyIPListPrecedence(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyIPListPrecedenceTestSuite{})
+}
+
+type ProxyProbeTarpitTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyProbeTarpitTestSuite) StartProxy(duration time.Duration, maxConnections uint) {
+	suite.proxyOfflineTestSuite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist:               suite.MakeAllIPsList(),
+		ProbeTarpitDuration:       duration,
+		ProbeTarpitMaxConnections: maxConnections,
+	})
+}
+
+func (suite *ProxyProbeTarpitTestSuite) WaitForClose(conn net.Conn) time.Duration {
+	startedAt := time.Now()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint: errcheck
+
+	_, err := conn.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+
+	return time.Since(startedAt)
+}
+
+func (suite *ProxyProbeTarpitTestSuite) TestDisabled() {
+	suite.StartProxy(0, 0)
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.Less(suite.WaitForClose(conn), 100*time.Millisecond)
+	suite.EqualValues(0, suite.ProbeTarpitted())
+	suite.EqualValues(0, suite.Started())
+}
+
+func (suite *ProxyProbeTarpitTestSuite) TestTarpitted() {
+	suite.StartProxy(300*time.Millisecond, 0)
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.Greater(suite.WaitForClose(conn), 150*time.Millisecond)
+	suite.EqualValues(1, suite.ProbeTarpitted())
+	suite.EqualValues(0, suite.Started())
+}
+
+func (suite *ProxyProbeTarpitTestSuite) TestTarpitIsFull() {
+	suite.StartProxy(time.Second, 1)
+
+	conn1 := suite.Dial()
+	defer conn1.Close()
+
+	conn2 := suite.Dial()
+	defer conn2.Close()
+
+	suite.Less(suite.WaitForClose(conn2), 100*time.Millisecond)
+	suite.EqualValues(1, suite.ProbeTarpitted())
+}
+
+func TestProxyProbeTarpit(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyProbeTarpitTestSuite{})
+}
+
+type ProxyMaxHandshakeSizeTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyMaxHandshakeSizeTestSuite) SetupTest() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		MaxHandshakeSize: 1024,
+	})
+}
+
+func (suite *ProxyMaxHandshakeSizeTestSuite) TestTooLarge() {
+	conn := suite.Dial()
+	defer conn.Close()
+
+	// handshake record of TLS 1.0 with 2000 bytes of payload
+	_, err := conn.Write([]byte{0x16, 0x03, 0x01, 0x07, 0xd0})
+	suite.NoError(err)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err = conn.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+
+	suite.Eventually(func() bool {
+		return suite.HandshakeTooLarge() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.Eventually(func() bool {
+		return suite.CloseReason() == mtglib.CloseReasonHandshakeTooLarge
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *ProxyMaxHandshakeSizeTestSuite) TestIncomplete() {
+	conn := suite.Dial()
+
+	// a beginning of handshake record header
+	_, err := conn.Write([]byte{0x16, 0x03})
+	suite.NoError(err)
+
+	conn.Close()
+
+	suite.Eventually(func() bool {
+		return suite.IncompleteHandshakes() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.Eventually(func() bool {
+		return suite.FailedHandshakes() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.Eventually(func() bool {
+		return suite.CloseReason() == mtglib.CloseReasonIncompleteHandshake
+	}, time.Second, 10*time.Millisecond)
+	suite.Eventually(func() bool {
+		active, maxActive := suite.ActiveStreams()
+
+		return active == 0 && maxActive == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *ProxyMaxHandshakeSizeTestSuite) TestRejectedIsNotIncomplete() {
+	conn := suite.Dial()
+	defer conn.Close()
+
+	_, err := conn.Write([]byte{0x16, 0x03, 0x01, 0x07, 0xd0})
+	suite.NoError(err)
+
+	suite.Eventually(func() bool {
+		return suite.HandshakeTooLarge() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	suite.EqualValues(0, suite.IncompleteHandshakes())
+}
+
+func TestProxyMaxHandshakeSize(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyMaxHandshakeSizeTestSuite{})
+}
+
+type ProxyHandshakeFailureBanTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyHandshakeFailureBanTestSuite) SetupTest() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		MaxHandshakeSize:             1024,
+		HandshakeFailureBanThreshold: 2,
+		HandshakeFailureBanDuration:  300 * time.Millisecond,
+	})
+}
+
+func (suite *ProxyHandshakeFailureBanTestSuite) FailHandshake() {
+	conn := suite.Dial()
+	defer conn.Close()
+
+	// handshake record of TLS 1.0 with 2000 bytes of payload
+	conn.Write([]byte{0x16, 0x03, 0x01, 0x07, 0xd0})  //nolint: errcheck
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err := conn.Read(make([]byte, 1))
+	suite.ErrorIs(err, io.EOF)
+}
+
+func (suite *ProxyHandshakeFailureBanTestSuite) TestBanned() {
+	suite.FailHandshake()
+	suite.FailHandshake()
+
+	suite.Eventually(func() bool {
+		return suite.IPBanned() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(2, suite.Started())
+}
+
+func (suite *ProxyHandshakeFailureBanTestSuite) TestBanIsLifted() {
+	suite.FailHandshake()
+	suite.FailHandshake()
+
+	time.Sleep(400 * time.Millisecond)
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.Eventually(func() bool {
+		return suite.Started() == 3
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestProxyHandshakeFailureBan(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyHandshakeFailureBanTestSuite{})
+}
+
+type ProxyClientRateLimitTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyClientRateLimitTestSuite) SetupTest() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		ClientRateLimit:      2,
+		ClientRateLimitBurst: 2,
+	})
+}
+
+func (suite *ProxyClientRateLimitTestSuite) TestRateLimited() {
+	for i := 0; i < 3; i++ {
+		conn := suite.Dial()
+		defer conn.Close()
+	}
+
+	suite.Eventually(func() bool {
+		return suite.RateLimited() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(2, suite.Started())
+
+	time.Sleep(500 * time.Millisecond)
+
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.Eventually(func() bool {
+		return suite.Started() == 3
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(1, suite.RateLimited())
+}
+
+func (suite *ProxyClientRateLimitTestSuite) TestSetClientRateLimit() {
+	suite.p.SetClientRateLimit(1, 1, 0)
+
+	for i := 0; i < 2; i++ {
+		conn := suite.Dial()
+		defer conn.Close()
+	}
+
+	suite.Eventually(func() bool {
+		return suite.RateLimited() == 1
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(1, suite.Started())
+}
+
+func (suite *ProxyClientRateLimitTestSuite) TestDisableClientRateLimit() {
+	suite.p.SetClientRateLimit(0, 0, 0)
+
+	for i := 0; i < 3; i++ {
+		conn := suite.Dial()
+		defer conn.Close()
+	}
+
+	suite.Eventually(func() bool {
+		return suite.Started() == 3
+	}, time.Second, 10*time.Millisecond)
+	suite.EqualValues(0, suite.RateLimited())
+}
+
+func TestProxyClientRateLimit(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyClientRateLimitTestSuite{})
+}
+
+type ProxyShutdownTestSuite struct {
+	proxyOfflineTestSuite
+}
+
+func (suite *ProxyShutdownTestSuite) SetupTest() {
+	suite.StartProxy(mtglib.ProxyOpts{})
+}
+
+func (suite *ProxyShutdownTestSuite) Shutdown(timeout time.Duration) chan struct{} {
+	proxy := suite.p
+	suite.p = nil
+
+	done := make(chan struct{})
+
+	go func() {
+		proxy.ShutdownWithTimeout(timeout)
+		close(done)
+	}()
+
+	return done
+}
+
+func (suite *ProxyShutdownTestSuite) TestDrainStreams() {
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.EqualValues(1, suite.Started())
+
+	done := suite.Shutdown(500 * time.Millisecond)
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)) //nolint: errcheck
+
+	_, err := conn.Read(make([]byte, 1))
+	suite.True(errors.Is(err, os.ErrDeadlineExceeded))
+
+	rejected := suite.Dial()
+	defer rejected.Close()
+
+	rejected.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err = rejected.Read(make([]byte, 1))
+	suite.True(errors.Is(err, io.EOF))
+	suite.EqualValues(1, suite.Started())
+
+	suite.Eventually(func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+
+	conn.SetReadDeadline(time.Now().Add(time.Second)) //nolint: errcheck
+
+	_, err = conn.Read(make([]byte, 1))
+	suite.True(errors.Is(err, io.EOF))
+}
+
+func (suite *ProxyShutdownTestSuite) TestNoTimeout() {
+	conn := suite.Dial()
+	defer conn.Close()
+
+	suite.listener.Close()
+
+	select {
+	case <-suite.Shutdown(0):
+	case <-time.After(time.Second):
+		suite.FailNow("proxy is not shutdown")
+	}
+}
+
+func TestProxyShutdown(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxyShutdownTestSuite{})
+}
+
+type proxyStoppedIPList struct {
+	stopped int32
+}
+
+func (p *proxyStoppedIPList) Contains(_ net.IP) bool {
+	return false
+}
+
+func (p *proxyStoppedIPList) Run(_ time.Duration) {}
+
+func (p *proxyStoppedIPList) Shutdown() {
+	atomic.AddInt32(&p.stopped, 1)
+}
+
+func (p *proxyStoppedIPList) Stopped() int32 {
+	return atomic.LoadInt32(&p.stopped)
+}
+
+type ProxySharedTestSuite struct {
+	proxyOfflineTestSuite
+
+	blocklist *proxyStoppedIPList
+	allowlist *proxyStoppedIPList
+}
+
+func (suite *ProxySharedTestSuite) SetupTest() {
+	suite.blocklist = &proxyStoppedIPList{}
+	suite.allowlist = &proxyStoppedIPList{}
+}
+
+func (suite *ProxySharedTestSuite) Shutdown() {
+	suite.listener.Close()
+	suite.p.Shutdown()
+	suite.p = nil
+}
+
+func (suite *ProxySharedTestSuite) TestNotShared() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist: suite.blocklist,
+		IPAllowlist: suite.allowlist,
+	})
+	suite.EqualValues(1, suite.SecretsConfigured())
+
+	suite.Shutdown()
+	suite.EqualValues(1, suite.blocklist.Stopped())
+	suite.EqualValues(1, suite.allowlist.Stopped())
+}
+
+func (suite *ProxySharedTestSuite) TestShared() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		IPBlocklist: suite.blocklist,
+		IPAllowlist: suite.allowlist,
+		Shared:      true,
+	})
+	suite.EqualValues(0, suite.SecretsConfigured())
+
+	suite.Shutdown()
+	suite.EqualValues(0, suite.blocklist.Stopped())
+	suite.EqualValues(0, suite.allowlist.Stopped())
+}
+
+func (suite *ProxySharedTestSuite) TestSetSecrets() {
+	suite.StartProxy(mtglib.ProxyOpts{})
+	defer suite.Shutdown()
+
+	secret := mtglib.GenerateSecret("google.com")
+
+	suite.ErrorIs(suite.p.SetSecrets(nil), mtglib.ErrSecretInvalid)
+	suite.ErrorIs(suite.p.SetSecrets([]mtglib.Secret{secret, {}}), mtglib.ErrSecretInvalid)
+	suite.Equal("example.com:443", suite.p.DomainFrontingAddress())
+	suite.EqualValues(1, suite.SecretsConfigured())
+
+	suite.NoError(suite.p.SetSecrets([]mtglib.Secret{secret, mtglib.GenerateSecret("example.com")}))
+	suite.Equal("google.com:443", suite.p.DomainFrontingAddress())
+	suite.EqualValues(2, suite.SecretsConfigured())
+}
+
+func (suite *ProxySharedTestSuite) TestSetSecretsShared() {
+	suite.StartProxy(mtglib.ProxyOpts{
+		Shared: true,
+	})
+	defer suite.Shutdown()
+
+	suite.NoError(suite.p.SetSecrets([]mtglib.Secret{mtglib.GenerateSecret("google.com")}))
+	suite.EqualValues(0, suite.SecretsConfigured())
+}
+
+func TestProxyShared(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ProxySharedTestSuite{})
+}
+
+type DialTelegramTestSuite struct {
+	suite.Suite
+
+	networkMock *testlib.MtglibNetworkMock
+}
+
+func (suite *DialTelegramTestSuite) SetupTest() {
+	suite.networkMock = &testlib.MtglibNetworkMock{}
+}
+
+func (suite *DialTelegramTestSuite) TearDownTest() {
+	suite.networkMock.AssertExpectations(suite.T())
+}
+
+func (suite *DialTelegramTestSuite) TestNoNetwork() {
+	_, err := mtglib.DialTelegram(context.Background(), mtglib.ProxyOpts{}, 2)
+	suite.ErrorIs(err, mtglib.ErrNetworkIsNotDefined)
+}
+
+func (suite *DialTelegramTestSuite) TestUnknownDC() {
+	_, err := mtglib.DialTelegram(context.Background(), mtglib.ProxyOpts{
+		Network: suite.networkMock,
+	}, mtglib.MaxDC+1)
+	suite.Error(err)
+}
+
+func (suite *DialTelegramTestSuite) TestPinnedAddress() {
+	conn := &net.TCPConn{}
+
+	suite.networkMock.
+		On("DialContext", mock.Anything, "tcp4", "10.0.0.1:443").
+		Once().
+		Return(conn, nil)
+
+	rv, err := mtglib.DialTelegram(context.Background(), mtglib.ProxyOpts{
+		Network:  suite.networkMock,
+		PreferIP: "only-ipv4",
+		DCAddresses: map[int][]net.IP{
+			2: {net.ParseIP("10.0.0.1")},
+		},
+	}, 2)
+	suite.NoError(err)
+	suite.Equal(conn, rv)
+}
+
+func (suite *DialTelegramTestSuite) TestFailed() {
+	suite.networkMock.
+		On("DialContext", mock.Anything, "tcp4", "10.0.0.1:443").
+		Once().
+		Return((*net.TCPConn)(nil), io.EOF)
+
+	_, err := mtglib.DialTelegram(context.Background(), mtglib.ProxyOpts{
+		Network:  suite.networkMock,
+		PreferIP: "prefer-ipv4",
+		DCAddresses: map[int][]net.IP{
+			1: {net.ParseIP("10.0.0.1")},
+		},
+	}, 1)
+	suite.ErrorIs(err, io.EOF)
+}
+
+func TestDialTelegram(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DialTelegramTestSuite{})
+}
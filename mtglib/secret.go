@@ -2,12 +2,17 @@ package mtglib
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 )
 
-const secretFakeTLSFirstByte byte = 0xee
+const (
+	secretFakeTLSFirstByte byte = 0xee
+
+	secretFingerprintLength = 8
+)
 
 var secretEmptyKey [SecretKeyLength]byte
 
@@ -117,6 +122,14 @@ func (s Secret) Hex() string {
 	return hex.EncodeToString(s.makeBytes())
 }
 
+// Fingerprint returns a short hex-encoded hash of the secret. It identifies
+// a secret but does not disclose it so it is safe to use in logs and metrics.
+func (s Secret) Fingerprint() string {
+	digest := sha256.Sum256(s.makeBytes())
+
+	return hex.EncodeToString(digest[:secretFingerprintLength])
+}
+
 func (s *Secret) makeBytes() []byte {
 	data := append([]byte{secretFakeTLSFirstByte}, s.Key[:]...)
 	data = append(data, s.Host...)
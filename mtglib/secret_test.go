@@ -121,6 +121,21 @@ func (suite *SecretTestSuite) TestValid() {
 	suite.True(s.Valid())
 }
 
+func (suite *SecretTestSuite) TestFingerprint() {
+	s1 := mtglib.GenerateSecret("google.com")
+	s2 := mtglib.GenerateSecret("google.com")
+
+	suite.Len(s1.Fingerprint(), 16)
+	suite.Equal(s1.Fingerprint(), s1.Fingerprint())
+	suite.NotEqual(s1.Fingerprint(), s2.Fingerprint())
+	suite.NotContains(s1.Hex(), s1.Fingerprint())
+
+	s3 := s1
+	s3.Host = "example.com"
+
+	suite.NotEqual(s1.Fingerprint(), s3.Fingerprint())
+}
+
 func TestSecret(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &SecretTestSuite{})
@@ -15,9 +15,18 @@ type streamContext struct {
 	ctxCancel    context.CancelFunc
 	clientConn   essentials.Conn
 	telegramConn essentials.Conn
+	clientIP     net.IP
 	streamID     string
 	dc           int
 	logger       Logger
+	acceptedAt   time.Time
+
+	handshakeFinished bool
+	closeReason       CloseReason
+
+	secret            Secret
+	secretFingerprint string
+	secretTag         string
 }
 
 func (s *streamContext) Deadline() (time.Time, bool) {
@@ -33,6 +42,21 @@ func (s *streamContext) Err() error {
 }
 
 func (s *streamContext) Value(key interface{}) interface{} {
+	switch key {
+	case ContextKeySecretFingerprint:
+		if s.secretFingerprint != "" {
+			return s.secretFingerprint
+		}
+	case ContextKeySecretTag:
+		if s.secretTag != "" {
+			return s.secretTag
+		}
+	case ContextKeyClientIP:
+		if s.clientIP != nil {
+			return s.clientIP
+		}
+	}
+
 	return s.ctx.Value(key)
 }
 
@@ -49,10 +73,41 @@ func (s *streamContext) Close() {
 }
 
 func (s *streamContext) ClientIP() net.IP {
-	return s.clientConn.RemoteAddr().(*net.TCPAddr).IP //nolint: forcetypeassert
+	return s.clientIP
+}
+
+// setCloseReason keeps the first reason of the stream. A stream usually
+// goes through several failures in a row: a replay attack, for example,
+// is also relayed to a fronting domain.
+func (s *streamContext) setCloseReason(reason CloseReason) {
+	if s.closeReason == "" {
+		s.closeReason = reason
+	}
+}
+
+// getClientIP is the only place where mtg decides which IP address belongs to
+// a client. Blocklists, allowlists, logs and events have to use it (or
+// streamContext.ClientIP which is populated by it) so they never disagree.
+//
+// It returns nil if IP address cannot be determined: for example, if a
+// connection is not TCP, has no remote address or it is unspecified.
+// IPv4-mapped IPv6 addresses are returned as IPv4 ones.
+func getClientIP(conn net.Conn) net.IP {
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok || addr.IP == nil || addr.IP.IsUnspecified() {
+		return nil
+	}
+
+	if ipv4 := addr.IP.To4(); ipv4 != nil {
+		return ipv4
+	}
+
+	return addr.IP
 }
 
-func newStreamContext(ctx context.Context, logger Logger, clientConn essentials.Conn) *streamContext {
+func newStreamContext(ctx context.Context, logger Logger, clientConn essentials.Conn,
+	clientIP net.IP,
+) *streamContext {
 	connIDBytes := make([]byte, ConnectionIDBytesLength)
 
 	if _, err := rand.Read(connIDBytes); err != nil {
@@ -64,7 +119,9 @@ func newStreamContext(ctx context.Context, logger Logger, clientConn essentials.
 		ctx:        ctx,
 		ctxCancel:  cancel,
 		clientConn: clientConn,
+		clientIP:   clientIP,
 		streamID:   base64.RawURLEncoding.EncodeToString(connIDBytes),
+		acceptedAt: time.Now(),
 	}
 	streamCtx.logger = logger.
 		BindStr("stream-id", streamCtx.streamID).
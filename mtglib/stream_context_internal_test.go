@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/IceCodeNew/mtg/internal/testlib"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -35,7 +36,7 @@ func (suite *StreamContextTestSuite) SetupTest() {
 	}
 	suite.connMock.On("RemoteAddr").Return(addr)
 
-	suite.ctx = newStreamContext(ctx, suite.logger, suite.connMock)
+	suite.ctx = newStreamContext(ctx, suite.logger, suite.connMock, getClientIP(suite.connMock))
 }
 
 func (suite *StreamContextTestSuite) TearDownTest() {
@@ -70,6 +71,80 @@ func (suite *StreamContextTestSuite) TestClientIP() {
 	suite.Equal("10.0.0.10", suite.ctx.ClientIP().String())
 }
 
+func (suite *StreamContextTestSuite) TestClientIPIsCanonical() {
+	suite.True(getClientIP(suite.connMock).Equal(suite.ctx.ClientIP()))
+}
+
+func (suite *StreamContextTestSuite) TestClientIPUnknown() {
+	testData := map[string]net.Addr{
+		"unix":        &net.UnixAddr{Name: "/tmp/mtg.sock", Net: "unix"},
+		"unspecified": &net.TCPAddr{IP: net.IPv6unspecified, Port: 6676},
+		"nil":         &net.TCPAddr{Port: 6676},
+		"no address":  nil,
+	}
+
+	for name, v := range testData {
+		addr := v
+
+		suite.T().Run(name, func(t *testing.T) {
+			connMock := &testlib.EssentialsConnMock{}
+			connMock.On("RemoteAddr").Return(addr)
+
+			assert.Nil(t, getClientIP(connMock))
+		})
+	}
+}
+
+func (suite *StreamContextTestSuite) TestClientIPNotTCP() {
+	connMock := &testlib.EssentialsConnMock{}
+	connMock.On("RemoteAddr").Return(&net.UnixAddr{Name: "/tmp/mtg.sock", Net: "unix"})
+
+	suite.NotPanics(func() {
+		ctx := newStreamContext(context.Background(), suite.logger, connMock, getClientIP(connMock))
+		defer ctx.ctxCancel()
+
+		suite.Nil(ctx.ClientIP())
+		suite.Nil(ctx.Value(ContextKeyClientIP))
+	})
+}
+
+func (suite *StreamContextTestSuite) TestClientIPMapped() {
+	connMock := &testlib.EssentialsConnMock{}
+	connMock.On("RemoteAddr").Return(&net.TCPAddr{
+		IP:   net.ParseIP("::ffff:10.0.0.10"),
+		Port: 6676,
+	})
+
+	suite.Equal(net.IP{10, 0, 0, 10}, getClientIP(connMock))
+}
+
+func (suite *StreamContextTestSuite) TestSecretValues() {
+	suite.Nil(suite.ctx.Value(ContextKeySecretFingerprint))
+	suite.Nil(suite.ctx.Value(ContextKeySecretTag))
+
+	suite.ctx.secretFingerprint = "0123456789abcdef"
+	suite.ctx.secretTag = "tag"
+
+	suite.Equal("0123456789abcdef", suite.ctx.Value(ContextKeySecretFingerprint))
+	suite.Equal("tag", suite.ctx.Value(ContextKeySecretTag))
+	suite.Equal("value", suite.ctx.Value("key"))
+}
+
+func (suite *StreamContextTestSuite) TestClientIPValue() {
+	suite.Equal(net.IP{10, 0, 0, 10}, suite.ctx.Value(ContextKeyClientIP))
+
+	suite.ctx.clientIP = nil
+
+	suite.Nil(suite.ctx.Value(ContextKeyClientIP))
+}
+
+func (suite *StreamContextTestSuite) TestCloseReason() {
+	suite.ctx.setCloseReason(CloseReasonReplayAttack)
+	suite.ctx.setCloseReason(CloseReasonDomainFronting)
+
+	suite.Equal(CloseReasonReplayAttack, suite.ctx.closeReason)
+}
+
 func (suite *StreamContextTestSuite) TestClose() {
 	suite.connMock.On("Close").Once().Return(nil)
 
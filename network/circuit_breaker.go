@@ -29,6 +29,10 @@ type circuitBreakerDialer struct {
 	openThreshold        uint32
 	halfOpenTimeout      time.Duration
 	resetFailuresTimeout time.Duration
+
+	// stateCallback is executed when breaker is opened after closed state
+	// and when it is closed again.
+	stateCallback func(opened bool)
 }
 
 func (c *circuitBreakerDialer) Dial(network, address string) (essentials.Conn, error) {
@@ -117,6 +121,15 @@ func (c *circuitBreakerDialer) doHalfOpened(ctx context.Context,
 }
 
 func (c *circuitBreakerDialer) switchState(state uint32) {
+	if c.stateCallback != nil {
+		switch previous := c.state; {
+		case previous == circuitBreakerStateClosed && state == circuitBreakerStateOpened:
+			c.stateCallback(true)
+		case previous != circuitBreakerStateClosed && state == circuitBreakerStateClosed:
+			c.stateCallback(false)
+		}
+	}
+
 	switch state {
 	case circuitBreakerStateClosed:
 		c.stopTimer(&c.halfOpenTimer)
@@ -186,7 +199,7 @@ func (c *circuitBreakerDialer) ensureTimer(timerRef **time.Timer,
 
 func newCircuitBreakerDialer(baseDialer Dialer,
 	openThreshold uint32, halfOpenTimeout, resetFailuresTimeout time.Duration,
-) Dialer {
+) *circuitBreakerDialer {
 	cb := &circuitBreakerDialer{
 		Dialer:               baseDialer,
 		stateMutexChan:       make(chan bool, 1),
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// CountryResolver returns ISO 3166-1 alpha-2 code of the country of the
+// given IP address. It should return an empty string if country is
+// unknown.
+type CountryResolver func(ip net.IP) string
+
+type countryDialer struct {
+	Dialer
+
+	dialers  map[string]Dialer
+	resolver CountryResolver
+}
+
+func (c countryDialer) Dial(network, address string) (essentials.Conn, error) {
+	return c.DialContext(context.Background(), network, address)
+}
+
+func (c countryDialer) DialContext(ctx context.Context, network, address string) (essentials.Conn, error) {
+	return c.getDialer(ctx).DialContext(ctx, network, address) //nolint: wrapcheck
+}
+
+func (c countryDialer) getDialer(ctx context.Context) Dialer {
+	clientIP, ok := ctx.Value(mtglib.ContextKeyClientIP).(net.IP)
+	if !ok {
+		return c.Dialer
+	}
+
+	if dialer, ok := c.dialers[strings.ToUpper(c.resolver(clientIP))]; ok {
+		return dialer
+	}
+
+	return c.Dialer
+}
+
+// NewCountryDialer makes a dialer which chooses an egress path based on a
+// country of the client. A client IP address is taken from a context with
+// [mtglib.ContextKeyClientIP] key.
+//
+// dialers is a mapping of country codes to dialers. If client IP is unknown
+// or its country has no dialer, a default dialer is used.
+func NewCountryDialer(defaultDialer Dialer, dialers map[string]Dialer, resolver CountryResolver) Dialer {
+	normalized := make(map[string]Dialer, len(dialers))
+
+	for k, v := range dialers {
+		normalized[strings.ToUpper(k)] = v
+	}
+
+	return countryDialer{
+		Dialer:   defaultDialer,
+		dialers:  normalized,
+		resolver: resolver,
+	}
+}
@@ -0,0 +1,94 @@
+package network_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/mtglib"
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type CountryDialerTestSuite struct {
+	suite.Suite
+
+	defaultMock *DialerMock
+	countryMock *DialerMock
+	dialer      network.Dialer
+}
+
+func (suite *CountryDialerTestSuite) SetupTest() {
+	suite.defaultMock = &DialerMock{}
+	suite.countryMock = &DialerMock{}
+	suite.dialer = network.NewCountryDialer(suite.defaultMock, map[string]network.Dialer{
+		"de": suite.countryMock,
+	}, func(ip net.IP) string {
+		if ip.Equal(net.ParseIP("10.0.0.10")) {
+			return "DE"
+		}
+
+		return ""
+	})
+}
+
+func (suite *CountryDialerTestSuite) TearDownTest() {
+	suite.defaultMock.AssertExpectations(suite.T())
+	suite.countryMock.AssertExpectations(suite.T())
+}
+
+func (suite *CountryDialerTestSuite) Dial(clientIP net.IP) (essentials.Conn, error) {
+	ctx := context.Background()
+
+	if clientIP != nil {
+		ctx = context.WithValue(ctx, mtglib.ContextKeyClientIP, clientIP)
+	}
+
+	return suite.dialer.DialContext(ctx, "tcp", "127.0.0.1:443") //nolint: wrapcheck
+}
+
+func (suite *CountryDialerTestSuite) TestMatchedCountry() {
+	var conn essentials.Conn = &net.TCPConn{}
+
+	suite.countryMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return(conn, nil)
+
+	dialed, err := suite.Dial(net.ParseIP("10.0.0.10"))
+	suite.NoError(err)
+	suite.Equal(conn, dialed)
+}
+
+func (suite *CountryDialerTestSuite) TestUnknownCountry() {
+	var conn essentials.Conn = &net.TCPConn{}
+
+	suite.defaultMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return(conn, nil)
+
+	dialed, err := suite.Dial(net.ParseIP("10.0.0.11"))
+	suite.NoError(err)
+	suite.Equal(conn, dialed)
+}
+
+func (suite *CountryDialerTestSuite) TestUnknownClientIP() {
+	var conn essentials.Conn = &net.TCPConn{}
+
+	suite.defaultMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return(conn, nil)
+
+	dialed, err := suite.Dial(nil)
+	suite.NoError(err)
+	suite.Equal(conn, dialed)
+}
+
+func TestCountryDialer(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &CountryDialerTestSuite{})
+}
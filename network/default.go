@@ -11,6 +11,9 @@ import (
 
 type defaultDialer struct {
 	net.Dialer
+
+	dscp       uint
+	bufferSize int
 }
 
 func (d *defaultDialer) Dial(network, address string) (essentials.Conn, error) {
@@ -30,24 +33,66 @@ func (d *defaultDialer) DialContext(ctx context.Context, network, address string
 	}
 
 	// we do not need to call to end user. End users call us.
-	if err := SetServerSocketOptions(conn, 0); err != nil {
+	if err := SetServerSocketOptions(conn, d.bufferSize); err != nil {
 		conn.Close()
 
 		return nil, fmt.Errorf("cannot set socket options: %w", err)
 	}
 
+	if err := SetSocketDSCP(conn, d.dscp); err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("cannot set socket dscp: %w", err)
+	}
+
 	return conn.(essentials.Conn), nil //nolint: forcetypeassert
 }
 
+// DefaultDialerOpts defines settings of a dialer made by
+// [NewDefaultDialerWithOpts].
+type DefaultDialerOpts struct {
+	// Timeout is a timeout of establishing a connection.
+	//
+	// This is an optional setting. Default is DefaultTimeout.
+	Timeout time.Duration
+
+	// DSCP is a value of DSCP field which is set on each upstream
+	// connection. It should be <= MaxDSCP.
+	//
+	// This is an optional setting. 0 means that operating system defaults
+	// are used.
+	DSCP uint
+
+	// BufferSize is a size of SO_SNDBUF and SO_RCVBUF of each upstream
+	// connection in bytes.
+	//
+	// This is an optional setting. 0 means that operating system defaults
+	// are used.
+	BufferSize int
+}
+
 // NewDefaultDialer build a new dialer which dials bypassing proxies
 // etc.
 //
-// The most default one you can imagine. But it has tunes TCP
-// connections and setups SO_REUSEPORT.
+// bufferSize is a size of SO_SNDBUF and SO_RCVBUF of each connection. 0
+// means that operating system defaults are used.
 //
-// bufferSize is deprecated and ignored. It is kept here for backward
-// compatibility.
+// Deprecated: please use [NewDefaultDialerWithOpts].
 func NewDefaultDialer(timeout time.Duration, bufferSize int) (Dialer, error) {
+	return NewDefaultDialerWithOpts(DefaultDialerOpts{
+		Timeout:    timeout,
+		BufferSize: bufferSize,
+	})
+}
+
+// NewDefaultDialerWithOpts build a new dialer which dials bypassing
+// proxies etc.
+//
+// The most default one you can imagine. But it has tunes TCP
+// connections and setups SO_REUSEPORT.
+func NewDefaultDialerWithOpts(opts DefaultDialerOpts) (Dialer, error) {
+	timeout := opts.Timeout
+
 	switch {
 	case timeout < 0:
 		return nil, fmt.Errorf("timeout %v should be positive number", timeout)
@@ -55,9 +100,19 @@ func NewDefaultDialer(timeout time.Duration, bufferSize int) (Dialer, error) {
 		timeout = DefaultTimeout
 	}
 
+	if opts.DSCP > MaxDSCP {
+		return nil, fmt.Errorf("dscp %d should be <= %d", opts.DSCP, MaxDSCP)
+	}
+
+	if opts.BufferSize < 0 {
+		return nil, fmt.Errorf("buffer size %d should be >= 0", opts.BufferSize)
+	}
+
 	return &defaultDialer{
 		Dialer: net.Dialer{
 			Timeout: timeout,
 		},
+		dscp:       opts.DSCP,
+		bufferSize: opts.BufferSize,
 	}, nil
 }
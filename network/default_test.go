@@ -19,17 +19,61 @@ type DefaultDialerTestSuite struct {
 func (suite *DefaultDialerTestSuite) SetupSuite() {
 	suite.HTTPServerTestSuite.SetupSuite()
 
-	d, err := network.NewDefaultDialer(0, 0)
+	d, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
 	suite.NoError(err)
 
 	suite.d = d
 }
 
 func (suite *DefaultDialerTestSuite) TestNegativeTimeout() {
-	_, err := network.NewDefaultDialer(-1, 0)
+	_, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{Timeout: -1})
 	suite.Error(err)
 }
 
+func (suite *DefaultDialerTestSuite) TestDeprecatedConstructor() {
+	_, err := network.NewDefaultDialer(0, 0)
+	suite.NoError(err)
+
+	_, err = network.NewDefaultDialer(-1, 0)
+	suite.Error(err)
+}
+
+func (suite *DefaultDialerTestSuite) TestIncorrectDSCP() {
+	_, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{DSCP: network.MaxDSCP + 1})
+	suite.Error(err)
+}
+
+func (suite *DefaultDialerTestSuite) TestConnectWithDSCP() {
+	d, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{DSCP: 46})
+	suite.NoError(err)
+
+	conn, err := d.DialContext(context.Background(),
+		"tcp",
+		suite.HTTPServerAddress())
+	suite.NoError(err)
+	suite.NotNil(conn)
+
+	conn.Close()
+}
+
+func (suite *DefaultDialerTestSuite) TestIncorrectBufferSize() {
+	_, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{BufferSize: -1})
+	suite.Error(err)
+}
+
+func (suite *DefaultDialerTestSuite) TestConnectWithBufferSize() {
+	d, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{BufferSize: 65536})
+	suite.NoError(err)
+
+	conn, err := d.DialContext(context.Background(),
+		"tcp",
+		suite.HTTPServerAddress())
+	suite.NoError(err)
+	suite.NotNil(conn)
+
+	conn.Close()
+}
+
 func (suite *DefaultDialerTestSuite) TestUnsupportedProtocol() {
 	_, err := suite.d.DialContext(context.Background(),
 		"udp",
@@ -0,0 +1,162 @@
+package network
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// dnsCacheSweepInterval defines how often expired entries are evicted.
+const dnsCacheSweepInterval = 10 * time.Minute
+
+type dnsCacheItem struct {
+	key   string
+	entry dnsResolverCacheEntry
+}
+
+// dnsCache is a DNS cache which can be bound by a number of entries. If
+// it is bound, the least recently used entries are evicted. Otherwise,
+// entries are evicted only when they are expired.
+//
+// Each entry is kept for TTL of DNS answer clamped to [minTTL, maxTTL].
+// Negative answers are kept for negativeTTL.
+type dnsCache struct {
+	mutex       sync.Mutex
+	items       map[string]*list.Element
+	order       *list.List
+	maxSize     int
+	minTTL      time.Duration
+	maxTTL      time.Duration
+	negativeTTL time.Duration
+	lastSweep   time.Time
+	callback    DNSCacheCallback
+}
+
+func (c *dnsCache) Get(key string) ([]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := elem.Value.(*dnsCacheItem) //nolint: forcetypeassert
+	if !item.entry.Ok() {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return item.entry.ips, true
+}
+
+func (c *dnsCache) Set(key string, ips []string, ttl time.Duration) {
+	switch {
+	case ttl < c.minTTL:
+		ttl = c.minTTL
+	case ttl > c.maxTTL:
+		ttl = c.maxTTL
+	}
+
+	c.set(key, ips, ttl)
+}
+
+// SetNegative stores an absence of records for a given key.
+func (c *dnsCache) SetNegative(key string) {
+	c.set(key, nil, c.negativeTTL)
+}
+
+func (c *dnsCache) set(key string, ips []string, ttl time.Duration) {
+	c.mutex.Lock()
+
+	entry := dnsResolverCacheEntry{
+		ips:       ips,
+		expiresAt: time.Now().Add(ttl),
+	}
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*dnsCacheItem).entry = entry //nolint: forcetypeassert
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&dnsCacheItem{
+			key:   key,
+			entry: entry,
+		})
+	}
+
+	evicted := c.evictExpired() + c.evictOverflow()
+	size := c.order.Len()
+
+	c.mutex.Unlock()
+
+	if c.callback != nil {
+		c.callback(size, evicted)
+	}
+}
+
+func (c *dnsCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *dnsCache) evictExpired() int {
+	if time.Since(c.lastSweep) < dnsCacheSweepInterval {
+		return 0
+	}
+
+	c.lastSweep = time.Now()
+	evicted := 0
+
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+
+		if item := elem.Value.(*dnsCacheItem); !item.entry.Ok() { //nolint: forcetypeassert
+			c.remove(elem)
+
+			evicted++
+		}
+
+		elem = prev
+	}
+
+	return evicted
+}
+
+func (c *dnsCache) evictOverflow() int {
+	evicted := 0
+
+	for c.maxSize > 0 && c.order.Len() > c.maxSize {
+		c.remove(c.order.Back())
+
+		evicted++
+	}
+
+	return evicted
+}
+
+func (c *dnsCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*dnsCacheItem).key) //nolint: forcetypeassert
+}
+
+func newDNSCache(opts DNSCacheOpts) *dnsCache {
+	maxSize := 0
+
+	if opts.Policy != DNSCachePolicyTTL {
+		maxSize = int(opts.getSize())
+	}
+
+	return &dnsCache{
+		items:       map[string]*list.Element{},
+		order:       list.New(),
+		maxSize:     maxSize,
+		minTTL:      opts.getMinTTL(),
+		maxTTL:      opts.getMaxTTL(),
+		negativeTTL: opts.getNegativeTTL(),
+		lastSweep:   time.Now(),
+		callback:    opts.Callback,
+	}
+}
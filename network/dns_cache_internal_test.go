@@ -0,0 +1,157 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type dnsCacheCallbackCall struct {
+	size    int
+	evicted int
+}
+
+type DNSCacheTestSuite struct {
+	suite.Suite
+
+	calls []dnsCacheCallbackCall
+}
+
+func (suite *DNSCacheTestSuite) SetupTest() {
+	suite.calls = nil
+}
+
+func (suite *DNSCacheTestSuite) MakeCache(policy string, size uint) *dnsCache {
+	return newDNSCache(DNSCacheOpts{
+		Policy: policy,
+		Size:   size,
+		Callback: func(size, evicted int) {
+			suite.calls = append(suite.calls, dnsCacheCallbackCall{
+				size:    size,
+				evicted: evicted,
+			})
+		},
+	})
+}
+
+func (suite *DNSCacheTestSuite) TestGetAbsent() {
+	cache := suite.MakeCache(DNSCachePolicyLRU, 2)
+
+	_, ok := cache.Get("key")
+	suite.False(ok)
+}
+
+func (suite *DNSCacheTestSuite) TestGetExpired() {
+	cache := suite.MakeCache(DNSCachePolicyLRU, 2)
+	cache.Set("key", []string{"10.0.0.1"}, time.Minute)
+	cache.items["key"].Value.(*dnsCacheItem).entry.expiresAt = time.Now().Add(-time.Second)
+
+	_, ok := cache.Get("key")
+	suite.False(ok)
+}
+
+func (suite *DNSCacheTestSuite) TestLRU() {
+	cache := suite.MakeCache(DNSCachePolicyLRU, 2)
+
+	cache.Set("key1", []string{"10.0.0.1"}, time.Minute)
+	cache.Set("key2", []string{"10.0.0.2"}, time.Minute)
+
+	_, ok := cache.Get("key1")
+	suite.True(ok)
+
+	cache.Set("key3", []string{"10.0.0.3"}, time.Minute)
+	suite.Equal(2, cache.Len())
+
+	ips, ok := cache.Get("key1")
+	suite.True(ok)
+	suite.Equal([]string{"10.0.0.1"}, ips)
+
+	_, ok = cache.Get("key2")
+	suite.False(ok)
+
+	_, ok = cache.Get("key3")
+	suite.True(ok)
+
+	suite.Equal([]dnsCacheCallbackCall{
+		{size: 1, evicted: 0},
+		{size: 2, evicted: 0},
+		{size: 2, evicted: 1},
+	}, suite.calls)
+}
+
+func (suite *DNSCacheTestSuite) TestUpdate() {
+	cache := suite.MakeCache(DNSCachePolicyLRU, 2)
+
+	cache.Set("key", []string{"10.0.0.1"}, time.Minute)
+	cache.Set("key", []string{"10.0.0.2"}, time.Minute)
+
+	ips, ok := cache.Get("key")
+	suite.True(ok)
+	suite.Equal([]string{"10.0.0.2"}, ips)
+	suite.Equal(1, cache.Len())
+}
+
+func (suite *DNSCacheTestSuite) TestTTLOnly() {
+	cache := suite.MakeCache(DNSCachePolicyTTL, 2)
+
+	cache.Set("key1", []string{"10.0.0.1"}, time.Minute)
+	cache.Set("key2", []string{"10.0.0.2"}, time.Minute)
+	cache.Set("key3", []string{"10.0.0.3"}, time.Minute)
+	suite.Equal(3, cache.Len())
+
+	cache.items["key1"].Value.(*dnsCacheItem).entry.expiresAt = time.Now().Add(-time.Second)
+	cache.lastSweep = time.Now().Add(-2 * dnsCacheSweepInterval)
+
+	cache.Set("key4", []string{"10.0.0.4"}, time.Minute)
+	suite.Equal(3, cache.Len())
+
+	_, ok := cache.Get("key1")
+	suite.False(ok)
+
+	suite.Equal(dnsCacheCallbackCall{size: 3, evicted: 1}, suite.calls[len(suite.calls)-1])
+}
+
+func (suite *DNSCacheTestSuite) TestClampTTL() {
+	cache := newDNSCache(DNSCacheOpts{
+		MinTTL:      time.Minute,
+		MaxTTL:      time.Hour,
+		NegativeTTL: time.Second,
+	})
+
+	testData := map[time.Duration]time.Duration{
+		0:                time.Minute,
+		time.Second:      time.Minute,
+		10 * time.Minute: 10 * time.Minute,
+		24 * time.Hour:   time.Hour,
+	}
+
+	for ttl, expected := range testData {
+		cache.Set("key", []string{"10.0.0.1"}, ttl)
+
+		expiresAt := cache.items["key"].Value.(*dnsCacheItem).entry.expiresAt //nolint: forcetypeassert
+		suite.WithinDuration(time.Now().Add(expected), expiresAt, time.Second, ttl.String())
+	}
+}
+
+func (suite *DNSCacheTestSuite) TestNegative() {
+	cache := newDNSCache(DNSCacheOpts{
+		NegativeTTL: 50 * time.Millisecond,
+	})
+
+	cache.SetNegative("key")
+
+	ips, ok := cache.Get("key")
+	suite.True(ok)
+	suite.Empty(ips)
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, ok = cache.Get("key")
+	suite.False(ok)
+}
+
+func TestDNSCache(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DNSCacheTestSuite{})
+}
@@ -1,102 +1,249 @@
 package network
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
-	"sync"
+	"net/url"
 	"time"
 
 	doh "github.com/babolivier/go-doh-client"
 )
 
-const dnsResolverKeepTime = 10 * time.Minute
-
 type dnsResolverCacheEntry struct {
 	ips       []string
-	createdAt time.Time
+	expiresAt time.Time
 }
 
 func (c dnsResolverCacheEntry) Ok() bool {
-	return time.Since(c.createdAt) < dnsResolverKeepTime
+	return time.Now().Before(c.expiresAt)
+}
+
+// DNSResolver is a transport which sends DNS queries to some upstream
+// server. Network caches its answers, so implementations should not.
+type DNSResolver interface {
+	// LookupA returns IPv4 addresses of a hostname and TTLs of answer
+	// records. If a name does not exist, ErrDNSNameError is returned.
+	LookupA(ctx context.Context, hostname string) ([]string, []uint32, error)
+
+	// LookupAAAA returns IPv6 addresses of a hostname and TTLs of answer
+	// records. If a name does not exist, ErrDNSNameError is returned.
+	LookupAAAA(ctx context.Context, hostname string) ([]string, []uint32, error)
+}
+
+// dohResolver is DNS-over-HTTPS transport.
+type dohResolver struct {
+	resolver doh.Resolver
+}
+
+func (d dohResolver) LookupA(_ context.Context, hostname string) ([]string, []uint32, error) {
+	recs, ttls, err := d.resolver.LookupA(hostname)
+	if err != nil {
+		return nil, nil, wrapDOHError(err)
+	}
+
+	ips := make([]string, 0, len(recs))
+
+	for _, v := range recs {
+		ips = append(ips, v.IP4)
+	}
+
+	return ips, ttls, nil
+}
+
+func (d dohResolver) LookupAAAA(_ context.Context, hostname string) ([]string, []uint32, error) {
+	recs, ttls, err := d.resolver.LookupAAAA(hostname)
+	if err != nil {
+		return nil, nil, wrapDOHError(err)
+	}
+
+	ips := make([]string, 0, len(recs))
+
+	for _, v := range recs {
+		ips = append(ips, v.IP6)
+	}
+
+	return ips, ttls, nil
+}
+
+func wrapDOHError(err error) error {
+	if errors.Is(err, doh.ErrNameError) {
+		return ErrDNSNameError
+	}
+
+	return err //nolint: wrapcheck
 }
 
 type dnsResolver struct {
-	resolver   doh.Resolver
-	cache      map[string]dnsResolverCacheEntry
-	cacheMutex sync.RWMutex
+	resolver DNSResolver
+	cache    *dnsCache
+	callback DNSQueryCallback
 }
 
-func (d *dnsResolver) LookupA(hostname string) []string {
+func (d *dnsResolver) LookupA(ctx context.Context, hostname string) []string {
 	key := "\x00" + hostname
 
-	d.cacheMutex.RLock()
-	entry, ok := d.cache[key]
-	d.cacheMutex.RUnlock()
+	if ips, ok := d.cache.Get(key); ok {
+		d.notify(ctx, hostname, 0, true, nil)
 
-	if ok && entry.Ok() {
-		return entry.ips
+		return ips
 	}
 
-	var ips []string
+	startedAt := time.Now()
+	ips, ttls, err := d.resolver.LookupA(ctx, hostname)
 
-	if recs, _, err := d.resolver.LookupA(hostname); err == nil {
-		for _, v := range recs {
-			ips = append(ips, v.IP4)
-		}
+	d.notify(ctx, hostname, time.Since(startedAt), false, err)
 
-		d.cacheMutex.Lock()
-		d.cache[key] = dnsResolverCacheEntry{
-			ips:       ips,
-			createdAt: time.Now(),
-		}
-		d.cacheMutex.Unlock()
+	switch {
+	case err == nil && len(ips) > 0:
+		d.cache.Set(key, ips, getAnswerTTL(ttls))
+	case err == nil || errors.Is(err, ErrDNSNameError):
+		ips = nil
+
+		d.cache.SetNegative(key)
+	default:
+		ips = nil
 	}
 
 	return ips
 }
 
-func (d *dnsResolver) LookupAAAA(hostname string) []string {
+func (d *dnsResolver) LookupAAAA(ctx context.Context, hostname string) []string {
 	key := "\x01" + hostname
 
-	d.cacheMutex.RLock()
-	entry, ok := d.cache[key]
-	d.cacheMutex.RUnlock()
+	if ips, ok := d.cache.Get(key); ok {
+		d.notify(ctx, hostname, 0, true, nil)
 
-	if ok && entry.Ok() {
-		return entry.ips
+		return ips
 	}
 
-	var ips []string
+	startedAt := time.Now()
+	ips, ttls, err := d.resolver.LookupAAAA(ctx, hostname)
+
+	d.notify(ctx, hostname, time.Since(startedAt), false, err)
+
+	switch {
+	case err == nil && len(ips) > 0:
+		d.cache.Set(key, ips, getAnswerTTL(ttls))
+	case err == nil || errors.Is(err, ErrDNSNameError):
+		ips = nil
+
+		d.cache.SetNegative(key)
+	default:
+		ips = nil
+	}
 
-	if recs, _, err := d.resolver.LookupAAAA(hostname); err == nil {
-		for _, v := range recs {
-			ips = append(ips, v.IP6)
+	return ips
+}
+
+// getAnswerTTL returns the smallest TTL of answer records.
+func getAnswerTTL(ttls []uint32) time.Duration {
+	var rv uint32
+
+	for i, v := range ttls {
+		if i == 0 || v < rv {
+			rv = v
 		}
+	}
+
+	return time.Duration(rv) * time.Second
+}
+
+func (d *dnsResolver) notify(ctx context.Context, hostname string,
+	duration time.Duration, isCached bool, err error,
+) {
+	if d.callback != nil {
+		d.callback(ctx, hostname, duration, isCached, err)
+	}
+}
+
+// dohURLTransport sends all requests to a given DOH endpoint. DOH client
+// always requests https://host/dns-query, so this is a way to support
+// endpoints with custom paths.
+type dohURLTransport struct {
+	url  *url.URL
+	next http.RoundTripper
+}
+
+func (d dohURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := *d.url
+
+	req = req.Clone(req.Context())
+	req.URL = &endpoint
+	req.Host = endpoint.Host
+
+	return d.next.RoundTrip(req) //nolint: wrapcheck
+}
 
-		d.cacheMutex.Lock()
-		d.cache[key] = dnsResolverCacheEntry{
-			ips:       ips,
-			createdAt: time.Now(),
+// parseDOHURL parses either IP address of DOH resolver or a full URL of its
+// endpoint. For IP addresses a standard /dns-query path is used.
+func parseDOHURL(value string) (*url.URL, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		host := ip.String()
+		if ip.To4() == nil {
+			// the hostname is an IPv6 address
+			host = fmt.Sprintf("[%s]", host)
 		}
-		d.cacheMutex.Unlock()
+
+		return &url.URL{
+			Scheme: "https",
+			Host:   host,
+			Path:   "/dns-query",
+		}, nil
 	}
 
-	return ips
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect url: %w", err)
+	}
+
+	switch {
+	case parsed.Scheme != "https":
+		return nil, fmt.Errorf("url %s should have https scheme", value)
+	case parsed.Hostname() == "":
+		return nil, fmt.Errorf("url %s should have a host", value)
+	case parsed.User != nil:
+		return nil, fmt.Errorf("url %s should not have credentials", value)
+	}
+
+	if parsed.Path == "" {
+		parsed.Path = "/dns-query"
+	}
+
+	return parsed, nil
 }
 
-func newDNSResolver(hostname string, httpClient *http.Client) *dnsResolver {
-	if net.ParseIP(hostname).To4() == nil {
-		// the hostname is an IPv6 address
-		hostname = fmt.Sprintf("[%s]", hostname)
+func newDNSResolver(dohURL *url.URL, httpClient *http.Client,
+	callback DNSQueryCallback, cache *dnsCache,
+) *dnsResolver {
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
 	}
 
-	return &dnsResolver{
+	client := *httpClient
+	client.Transport = dohURLTransport{
+		url:  dohURL,
+		next: next,
+	}
+
+	return newDNSResolverWithTransport(dohResolver{
 		resolver: doh.Resolver{
-			Host:       hostname,
+			Host:       dohURL.Host,
 			Class:      doh.IN,
-			HTTPClient: httpClient,
+			HTTPClient: &client,
 		},
-		cache: map[string]dnsResolverCacheEntry{},
+	}, callback, cache)
+}
+
+func newDNSResolverWithTransport(resolver DNSResolver,
+	callback DNSQueryCallback, cache *dnsCache,
+) *dnsResolver {
+	return &dnsResolver{
+		resolver: resolver,
+		cache:    cache,
+		callback: callback,
 	}
 }
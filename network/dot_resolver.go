@@ -0,0 +1,265 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DoTResolver is DNS-over-TLS (RFC 7858) transport. Each query is sent in
+// its own TLS connection: answers are cached by a network anyway.
+type DoTResolver struct {
+	address   string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	dialer    Dialer
+}
+
+// LookupA returns IPv4 addresses of a hostname and TTLs of answer
+// records.
+func (d *DoTResolver) LookupA(ctx context.Context, hostname string) ([]string, []uint32, error) {
+	return d.lookup(ctx, hostname, dnsmessage.TypeA)
+}
+
+// LookupAAAA returns IPv6 addresses of a hostname and TTLs of answer
+// records.
+func (d *DoTResolver) LookupAAAA(ctx context.Context, hostname string) ([]string, []uint32, error) {
+	return d.lookup(ctx, hostname, dnsmessage.TypeAAAA)
+}
+
+// String returns an address of the resolver.
+func (d *DoTResolver) String() string {
+	return d.address
+}
+
+func (d *DoTResolver) lookup(ctx context.Context, hostname string,
+	qtype dnsmessage.Type,
+) ([]string, []uint32, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	queryID := uint16(rand.Uint32()) //nolint: gosec
+
+	query, err := makeDOTQuery(queryID, hostname, qtype)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	answer, err := d.exchange(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot query %s: %w", d.address, err)
+	}
+
+	return parseDOTAnswer(queryID, qtype, answer)
+}
+
+func (d *DoTResolver) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	rawConn, err := d.dialer.DialContext(ctx, "tcp", d.address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial: %w", err)
+	}
+
+	conn := tls.Client(rawConn, d.tlsConfig)
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline) //nolint: errcheck
+	}
+
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("tls handshake has failed: %w", err)
+	}
+
+	// each message in TCP stream is prefixed by its length.
+	message := make([]byte, 2+len(query)) //nolint: gomnd
+	binary.BigEndian.PutUint16(message, uint16(len(query)))
+	copy(message[2:], query)
+
+	if _, err := conn.Write(message); err != nil {
+		return nil, fmt.Errorf("cannot send a query: %w", err)
+	}
+
+	length := make([]byte, 2) //nolint: gomnd
+
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return nil, fmt.Errorf("cannot read a length of answer: %w", err)
+	}
+
+	answer := make([]byte, binary.BigEndian.Uint16(length))
+
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, fmt.Errorf("cannot read an answer: %w", err)
+	}
+
+	return answer, nil
+}
+
+func makeDOTQuery(queryID uint16, hostname string, qtype dnsmessage.Type) ([]byte, error) {
+	if !strings.HasSuffix(hostname, ".") {
+		hostname += "."
+	}
+
+	name, err := dnsmessage.NewName(hostname)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect hostname %s: %w", hostname, err)
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               queryID,
+		RecursionDesired: true,
+	})
+
+	builder.StartQuestions() //nolint: errcheck
+
+	if err := builder.Question(dnsmessage.Question{
+		Name:  name,
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, fmt.Errorf("cannot build a question: %w", err)
+	}
+
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build a query: %w", err)
+	}
+
+	return query, nil
+}
+
+func parseDOTAnswer(queryID uint16, qtype dnsmessage.Type, answer []byte) ([]string, []uint32, error) {
+	parser := dnsmessage.Parser{}
+
+	header, err := parser.Start(answer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse an answer: %w", err)
+	}
+
+	switch {
+	case header.ID != queryID || !header.Response:
+		return nil, nil, fmt.Errorf("unexpected answer with id %d", header.ID)
+	case header.RCode == dnsmessage.RCodeNameError:
+		return nil, nil, ErrDNSNameError
+	case header.RCode != dnsmessage.RCodeSuccess:
+		return nil, nil, fmt.Errorf("resolver has responded with %s", header.RCode)
+	}
+
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, nil, fmt.Errorf("cannot parse questions: %w", err)
+	}
+
+	ips := []string{}
+	ttls := []uint32{}
+
+	for {
+		resHeader, err := parser.AnswerHeader()
+
+		switch {
+		case errors.Is(err, dnsmessage.ErrSectionDone):
+			return ips, ttls, nil
+		case err != nil:
+			return nil, nil, fmt.Errorf("cannot parse an answer record: %w", err)
+		}
+
+		switch {
+		case resHeader.Class != dnsmessage.ClassINET || resHeader.Type != qtype:
+			err = parser.SkipAnswer()
+		case qtype == dnsmessage.TypeA:
+			var res dnsmessage.AResource
+
+			if res, err = parser.AResource(); err == nil {
+				ips = append(ips, net.IP(res.A[:]).String())
+				ttls = append(ttls, resHeader.TTL)
+			}
+		default:
+			var res dnsmessage.AAAAResource
+
+			if res, err = parser.AAAAResource(); err == nil {
+				ips = append(ips, net.IP(res.AAAA[:]).String())
+				ttls = append(ttls, resHeader.TTL)
+			}
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse an answer record: %w", err)
+		}
+	}
+}
+
+// NewDoTResolver returns DNS-over-TLS resolver. address is either a host
+// or host:port, default port is DefaultDOTPort. A certificate of the
+// server is verified against ServerName of tlsConfig; if it is empty,
+// against a host of address. nil tlsConfig means default settings.
+//
+// Each query, including a connection and TLS handshake, is limited by
+// timeout. 0 means DefaultHTTPTimeout, so it is the same limit as for
+// DNS-over-HTTPS.
+//
+// Connections are established directly. Please use
+// [NewDoTResolverWithDialer] if they have to go through proxies.
+func NewDoTResolver(address string, tlsConfig *tls.Config, timeout time.Duration) (*DoTResolver, error) {
+	dialer, err := NewDefaultDialerWithOpts(DefaultDialerOpts{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("cannot build a dialer: %w", err)
+	}
+
+	return NewDoTResolverWithDialer(address, tlsConfig, timeout, dialer)
+}
+
+// NewDoTResolverWithDialer is the same as [NewDoTResolver] but connects
+// to the resolver with a given dialer.
+func NewDoTResolverWithDialer(address string, tlsConfig *tls.Config,
+	timeout time.Duration, dialer Dialer,
+) (*DoTResolver, error) {
+	switch {
+	case timeout < 0:
+		return nil, fmt.Errorf("timeout should be positive number %s", timeout)
+	case timeout == 0:
+		timeout = DefaultHTTPTimeout
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host = strings.Trim(address, "[]")
+		port = strconv.Itoa(DefaultDOTPort)
+	}
+
+	if host == "" {
+		return nil, fmt.Errorf("incorrect address %s", address)
+	}
+
+	if value, err := strconv.ParseUint(port, 10, 16); err != nil || value == 0 { //nolint: gomnd
+		return nil, fmt.Errorf("incorrect port of address %s", address)
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{} //nolint: gosec
+	}
+
+	tlsConfig = tlsConfig.Clone()
+
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+
+	return &DoTResolver{
+		address:   net.JoinHostPort(host, port),
+		tlsConfig: tlsConfig,
+		timeout:   timeout,
+		dialer:    dialer,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package network_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dotFakeServer answers A queries of example.com with 10.0.0.1. Other
+// names do not exist. If silent is set, queries are never answered.
+type dotFakeServer struct {
+	listener net.Listener
+	roots    *x509.CertPool
+	silent   bool
+}
+
+func (d *dotFakeServer) Addr() string {
+	return d.listener.Addr().String()
+}
+
+func (d *dotFakeServer) Close() {
+	d.listener.Close()
+}
+
+func (d *dotFakeServer) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go d.handle(conn)
+	}
+}
+
+func (d *dotFakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	length := make([]byte, 2)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return
+	}
+
+	query := make([]byte, binary.BigEndian.Uint16(length))
+	if _, err := io.ReadFull(conn, query); err != nil {
+		return
+	}
+
+	if d.silent {
+		io.Copy(io.Discard, conn) //nolint: errcheck
+
+		return
+	}
+
+	parser := dnsmessage.Parser{}
+
+	header, err := parser.Start(query)
+	if err != nil {
+		return
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return
+	}
+
+	header.Response = true
+	header.RecursionAvailable = true
+
+	if question.Name.String() != "example.com." {
+		header.RCode = dnsmessage.RCodeNameError
+	}
+
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.StartQuestions()   //nolint: errcheck
+	builder.Question(question) //nolint: errcheck
+	builder.StartAnswers()     //nolint: errcheck
+
+	if header.RCode == dnsmessage.RCodeSuccess && question.Type == dnsmessage.TypeA {
+		builder.AResource(dnsmessage.ResourceHeader{ //nolint: errcheck
+			Name:  question.Name,
+			Class: dnsmessage.ClassINET,
+			TTL:   120,
+		}, dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}})
+	}
+
+	answer, _ := builder.Finish()
+
+	binary.BigEndian.PutUint16(length, uint16(len(answer)))
+	conn.Write(append(length, answer...)) //nolint: errcheck
+}
+
+func newDOTFakeServer(silent bool) *dotFakeServer {
+	// httptest has a certificate for 127.0.0.1 which is trusted by its
+	// client.
+	httpServer := httptest.NewUnstartedServer(nil)
+	httpServer.StartTLS()
+
+	certificates := httpServer.TLS.Certificates
+	roots := httpServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs //nolint: forcetypeassert
+
+	httpServer.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint: gosec
+		Certificates: certificates,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	server := &dotFakeServer{
+		listener: listener,
+		roots:    roots,
+		silent:   silent,
+	}
+
+	go server.serve()
+
+	return server
+}
+
+type DoTResolverTestSuite struct {
+	suite.Suite
+
+	server *dotFakeServer
+}
+
+func (suite *DoTResolverTestSuite) SetupTest() {
+	suite.server = newDOTFakeServer(false)
+}
+
+func (suite *DoTResolverTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *DoTResolverTestSuite) makeResolver(serverName string, timeout time.Duration) *network.DoTResolver {
+	resolver, err := network.NewDoTResolver(suite.server.Addr(), &tls.Config{ //nolint: gosec
+		RootCAs:    suite.server.roots,
+		ServerName: serverName,
+	}, timeout)
+	suite.NoError(err)
+
+	return resolver
+}
+
+func (suite *DoTResolverTestSuite) TestIncorrectParams() {
+	_, err := network.NewDoTResolver("127.0.0.1:853", nil, -time.Second)
+	suite.Error(err)
+
+	_, err = network.NewDoTResolver("127.0.0.1:0", nil, 0)
+	suite.Error(err)
+
+	_, err = network.NewDoTResolver(":853", nil, 0)
+	suite.Error(err)
+}
+
+func (suite *DoTResolverTestSuite) TestDefaultPort() {
+	resolver, err := network.NewDoTResolver("9.9.9.9", nil, 0)
+	suite.NoError(err)
+	suite.Equal("9.9.9.9:853", resolver.String())
+
+	resolver, err = network.NewDoTResolver("2620:fe::fe", nil, 0)
+	suite.NoError(err)
+	suite.Equal("[2620:fe::fe]:853", resolver.String())
+}
+
+func (suite *DoTResolverTestSuite) TestLookupA() {
+	ips, ttls, err := suite.makeResolver("", 0).LookupA(context.Background(), "example.com")
+	suite.NoError(err)
+	suite.Equal([]string{"10.0.0.1"}, ips)
+	suite.Equal([]uint32{120}, ttls)
+}
+
+func (suite *DoTResolverTestSuite) TestLookupAAAA() {
+	ips, _, err := suite.makeResolver("", 0).LookupAAAA(context.Background(), "example.com")
+	suite.NoError(err)
+	suite.Empty(ips)
+}
+
+func (suite *DoTResolverTestSuite) TestNameError() {
+	_, _, err := suite.makeResolver("", 0).LookupA(context.Background(), "unknown.com")
+	suite.ErrorIs(err, network.ErrDNSNameError)
+}
+
+func (suite *DoTResolverTestSuite) TestUnexpectedHostname() {
+	_, _, err := suite.makeResolver("dns.example.org", 0).LookupA(context.Background(), "example.com")
+	suite.ErrorContains(err, "tls handshake has failed")
+}
+
+func (suite *DoTResolverTestSuite) TestUntrustedCertificate() {
+	resolver, err := network.NewDoTResolver(suite.server.Addr(), nil, 0)
+	suite.NoError(err)
+
+	_, _, err = resolver.LookupA(context.Background(), "example.com")
+	suite.ErrorContains(err, "tls handshake has failed")
+}
+
+func (suite *DoTResolverTestSuite) TestTimeout() {
+	suite.server.Close()
+	suite.server = newDOTFakeServer(true)
+
+	startedAt := time.Now()
+	_, _, err := suite.makeResolver("", 100*time.Millisecond).LookupA(context.Background(), "example.com")
+
+	suite.Error(err)
+	suite.Less(time.Since(startedAt), time.Second)
+}
+
+func TestDoTResolver(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &DoTResolverTestSuite{})
+}
@@ -65,9 +65,52 @@ const (
 	// embedded.
 	DefaultDOHHostname = "9.9.9.9"
 
+	// DefaultDOTHostname defines a default IP address for DNS-over-TLS
+	// resolver. This is Quad9 as for DOH.
+	DefaultDOTHostname = "9.9.9.9"
+
+	// DefaultDOTPort defines a default port of DNS-over-TLS resolver.
+	DefaultDOTPort = 853
+
 	// DNSTimeout defines a timeout for DNS queries.
+	//
+	// Deprecated: DNS queries are limited by HTTP timeout of the network.
 	DNSTimeout = 5 * time.Second
 
+	// DefaultMirrorSampleRate defines a default fraction of connections
+	// which are mirrored by mirror dialer.
+	DefaultMirrorSampleRate = 0.01
+
+	// DefaultDNSCacheSize defines a default max number of entries in DNS
+	// cache with LRU eviction policy.
+	DefaultDNSCacheSize = 1024
+
+	// DefaultDNSCacheMinTTL defines a default min time to keep DNS answer
+	// in the cache. Answers with smaller TTL are kept for this time.
+	DefaultDNSCacheMinTTL = 30 * time.Second
+
+	// DefaultDNSCacheMaxTTL defines a default max time to keep DNS answer
+	// in the cache. Answers with larger TTL are kept for this time.
+	DefaultDNSCacheMaxTTL = 10 * time.Minute
+
+	// DefaultDNSCacheNegativeTTL defines a default time to keep negative
+	// DNS answers in the cache: NXDOMAIN or answers without records.
+	DefaultDNSCacheNegativeTTL = 30 * time.Second
+
+	// DNSCachePolicyLRU defines DNS cache eviction policy which keeps
+	// a limited number of entries and evicts the least recently used
+	// ones. Expired entries are evicted as well.
+	DNSCachePolicyLRU = "lru"
+
+	// DNSCachePolicyTTL defines DNS cache eviction policy which evicts
+	// only expired entries. A size of the cache is not limited.
+	DNSCachePolicyTTL = "ttl"
+
+	// MaxDSCP is the biggest DSCP value which could be set on a socket.
+	// DSCP is a 6-bit field, the other 2 bits of IP_TOS/IPV6_TCLASS byte are
+	// used by ECN.
+	MaxDSCP = 63
+
 	// tcpLingerTimeout defines a number of seconds to wait for sending
 	// unacknowledged data.
 	tcpLingerTimeout = 1
@@ -81,6 +124,18 @@ var (
 	// ErrCannotDialWithAllProxies is returned when load balancing client is
 	// trying to access proxies but all of them are failed.
 	ErrCannotDialWithAllProxies = errors.New("cannot dial with all proxies")
+
+	// ErrNotTCPConnection is returned when socket options are applied to a
+	// connection which is not TCP.
+	ErrNotTCPConnection = errors.New("not a TCP connection")
+
+	// ErrSocks5AuthenticationRequired is returned when SOCKS5 proxy
+	// requires authentication but its URL has no credentials.
+	ErrSocks5AuthenticationRequired = errors.New("proxy requires authentication")
+
+	// ErrDNSNameError is returned by DNSResolver if a queried name does
+	// not exist (NXDOMAIN).
+	ErrDNSNameError = errors.New("dns name does not exist")
 )
 
 // Dialer defines an interface which is required to bootstrap a network
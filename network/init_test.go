@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/IceCodeNew/mtg/essentials"
 	"github.com/IceCodeNew/mtg/network"
@@ -88,3 +89,37 @@ func (suite *Socks5ServerTestSuite) MakeSocks5URL(user, password string) *url.UR
 		Host:   suite.socks5Listener.Addr().String(),
 	}
 }
+
+type socks5CountingRules struct {
+	allowed uint32
+}
+
+func (s *socks5CountingRules) Allow(ctx context.Context, _ *socks5.Request) (context.Context, bool) {
+	atomic.AddUint32(&s.allowed, 1)
+
+	return ctx, true
+}
+
+func (s *socks5CountingRules) Allowed() uint32 {
+	return atomic.LoadUint32(&s.allowed)
+}
+
+// startSocks5Server runs SOCKS5 server which counts requests passed
+// authentication. nil credentials mean no authentication.
+func startSocks5Server(credentials socks5.StaticCredentials) (net.Listener, *socks5CountingRules) {
+	rules := &socks5CountingRules{}
+	conf := &socks5.Config{
+		Rules: rules,
+	}
+
+	if credentials != nil {
+		conf.Credentials = credentials
+	}
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	server, _ := socks5.New(conf)
+
+	go server.Serve(listener) //nolint: errcheck
+
+	return listener, rules
+}
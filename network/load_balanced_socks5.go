@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
+	"strconv"
 
 	"github.com/IceCodeNew/mtg/essentials"
 )
 
 type loadBalancedSocks5Dialer struct {
-	dialers []Dialer
+	dialers     []Dialer
+	weights     []int
+	totalWeight int
 }
 
 func (l loadBalancedSocks5Dialer) Dial(network, address string) (essentials.Conn, error) {
@@ -19,7 +22,7 @@ func (l loadBalancedSocks5Dialer) Dial(network, address string) (essentials.Conn
 
 func (l loadBalancedSocks5Dialer) DialContext(ctx context.Context, network, address string) (essentials.Conn, error) {
 	length := len(l.dialers)
-	start := rand.Intn(length)
+	start := l.pickStart()
 	moved := false
 
 	for i := start; i != start || !moved; i = (i + 1) % length {
@@ -33,6 +36,30 @@ func (l loadBalancedSocks5Dialer) DialContext(ctx context.Context, network, addr
 	return nil, ErrCannotDialWithAllProxies
 }
 
+// pickStart randomly chooses a proxy to start with. A chance of each proxy
+// is proportional to its weight.
+func (l loadBalancedSocks5Dialer) pickStart() int {
+	point := rand.Intn(l.totalWeight)
+
+	for i, v := range l.weights {
+		if point < v {
+			return i
+		}
+
+		point -= v
+	}
+
+	return len(l.weights) - 1
+}
+
+// ProxyStateCallback defines a signature of the callback that has to be
+// executed when load balancing dialer ejects a proxy from rotation or
+// restores it. It gets a URL of the proxy and a flag if it was ejected.
+//
+// It is executed synchronously with dials of this proxy so it should not
+// block.
+type ProxyStateCallback func(proxyURL *url.URL, ejected bool)
+
 // NewLoadBalancedSocks5Dialer builds a new load balancing SOCKS5 dialer.
 //
 // The main difference from one which is made by NewSocks5Dialer is that we
@@ -41,19 +68,78 @@ func (l loadBalancedSocks5Dialer) DialContext(ctx context.Context, network, addr
 //
 // So, it is mostly useful if you have some routes with proxies which are not
 // always online or having buggy network.
+//
+// Each proxy URL may have a 'weight' query parameter, a positive integer
+// which is 1 by default. A proxy is picked first with a chance
+// proportional to its weight, so a proxy with weight 3 gets roughly 3
+// times more dials than a proxy with weight 1.
 func NewLoadBalancedSocks5Dialer(baseDialer Dialer, proxyURLs []*url.URL) (Dialer, error) {
-	dialers := make([]Dialer, 0, len(proxyURLs))
+	return NewLoadBalancedSocks5DialerWithCallback(baseDialer, proxyURLs, nil)
+}
+
+// NewLoadBalancedSocks5DialerWithCallback is the same as
+// [NewLoadBalancedSocks5Dialer] but also executes a given callback when a
+// proxy is ejected or restored.
+//
+// A proxy is ejected from rotation after open_threshold consecutive
+// failed dials: these are both network errors and failures of SOCKS5
+// handshake. After half_open_timeout a next dial is used as a probe. If
+// it succeeds, the proxy is restored. Otherwise, it is ejected again.
+// These parameters are taken from a query of proxy URL, please see
+// [ProxyDialerOpenThreshold] and [ProxyDialerHalfOpenTimeout] for
+// defaults.
+func NewLoadBalancedSocks5DialerWithCallback(baseDialer Dialer,
+	proxyURLs []*url.URL,
+	callback ProxyStateCallback,
+) (Dialer, error) {
+	rv := loadBalancedSocks5Dialer{
+		dialers: make([]Dialer, 0, len(proxyURLs)),
+		weights: make([]int, 0, len(proxyURLs)),
+	}
 
 	for _, u := range proxyURLs {
-		dialer, err := NewSocks5Dialer(newProxyDialer(baseDialer, u), u)
+		weight, err := parseProxyWeight(u)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect weight of %s: %w", u.Redacted(), err)
+		}
+
+		dialer, err := NewSocks5Dialer(baseDialer, u)
 		if err != nil {
 			return nil, fmt.Errorf("cannot build dialer for %s: %w", u.String(), err)
 		}
 
-		dialers = append(dialers, dialer)
+		rv.dialers = append(rv.dialers, newProxyDialer(dialer, u, makeProxyStateCallback(u, callback)))
+		rv.weights = append(rv.weights, weight)
+		rv.totalWeight += weight
+	}
+
+	return rv, nil
+}
+
+func parseProxyWeight(proxyURL *url.URL) (int, error) {
+	param := proxyURL.Query().Get("weight")
+	if param == "" {
+		return 1, nil
+	}
+
+	weight, err := strconv.ParseUint(param, 10, 16) //nolint: gomnd
+	if err != nil {
+		return 0, fmt.Errorf("value is not uint (%s): %w", param, err)
 	}
 
-	return loadBalancedSocks5Dialer{
-		dialers: dialers,
-	}, nil
+	if weight == 0 {
+		return 0, fmt.Errorf("value should be >0 (%s)", param)
+	}
+
+	return int(weight), nil
+}
+
+func makeProxyStateCallback(proxyURL *url.URL, callback ProxyStateCallback) func(bool) {
+	if callback == nil {
+		return nil
+	}
+
+	return func(opened bool) {
+		callback(proxyURL, opened)
+	}
 }
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/IceCodeNew/mtg/network"
+	socks5 "github.com/armon/go-socks5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -27,7 +29,7 @@ func (suite *LoadBalancedSocks5TestSuite) SetupSuite() {
 }
 
 func (suite *LoadBalancedSocks5TestSuite) SetupTest() {
-	baseDialer, _ := network.NewDefaultDialer(0, 0)
+	baseDialer, _ := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
 	lbDialer, err := network.NewLoadBalancedSocks5Dialer(baseDialer, []*url.URL{
 		suite.MakeSocks5URL("user", "password"),
 		suite.MakeSocks5URL("user2", "password"),
@@ -82,6 +84,126 @@ func (suite *LoadBalancedSocks5TestSuite) TestDialOk() {
 	suite.Equal(http.StatusOK, resp.StatusCode)
 }
 
+func (suite *LoadBalancedSocks5TestSuite) TestMixedAuth() {
+	authed1, rules1 := startSocks5Server(map[string]string{"user1": "password1"})
+	defer authed1.Close()
+
+	authed2, rules2 := startSocks5Server(map[string]string{"user2": "password2"})
+	defer authed2.Close()
+
+	unauthed, rules3 := startSocks5Server(nil)
+	defer unauthed.Close()
+
+	baseDialer, _ := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	lbDialer, err := network.NewLoadBalancedSocks5Dialer(baseDialer, []*url.URL{
+		{Scheme: "socks5", Host: authed1.Addr().String()},
+		{Scheme: "socks5", User: url.UserPassword("user1", "password1"), Host: authed1.Addr().String()},
+		{Scheme: "socks5", User: url.UserPassword("user2", "password2"), Host: authed2.Addr().String()},
+		{Scheme: "socks5", Host: unauthed.Addr().String()},
+	})
+	suite.NoError(err)
+
+	for i := 0; i < 100; i++ {
+		conn, err := lbDialer.Dial("tcp", suite.HTTPServerAddress())
+		suite.NoError(err)
+
+		conn.Close()
+	}
+
+	suite.EqualValues(100, rules1.Allowed()+rules2.Allowed()+rules3.Allowed())
+	suite.NotZero(rules1.Allowed())
+	suite.NotZero(rules2.Allowed())
+	suite.NotZero(rules3.Allowed())
+}
+
+func (suite *LoadBalancedSocks5TestSuite) TestIncorrectWeight() {
+	for _, v := range []string{"0", "-1", "a", "1.5"} {
+		_, err := network.NewLoadBalancedSocks5Dialer(&DialerMock{}, []*url.URL{
+			{Scheme: "socks5", Host: "127.0.0.1:1080", RawQuery: "weight=" + v},
+		})
+		suite.Error(err, v)
+	}
+}
+
+func (suite *LoadBalancedSocks5TestSuite) TestWeighted() {
+	heavy, heavyRules := startSocks5Server(nil)
+	defer heavy.Close()
+
+	light, lightRules := startSocks5Server(nil)
+	defer light.Close()
+
+	baseDialer, _ := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	lbDialer, err := network.NewLoadBalancedSocks5Dialer(baseDialer, []*url.URL{
+		{Scheme: "socks5", Host: heavy.Addr().String(), RawQuery: "weight=3"},
+		{Scheme: "socks5", Host: light.Addr().String()},
+	})
+	suite.NoError(err)
+
+	for i := 0; i < 400; i++ {
+		conn, err := lbDialer.Dial("tcp", suite.HTTPServerAddress())
+		suite.NoError(err)
+
+		conn.Close()
+	}
+
+	suite.EqualValues(400, heavyRules.Allowed()+lightRules.Allowed())
+	suite.InDelta(300, heavyRules.Allowed(), 60)
+}
+
+func (suite *LoadBalancedSocks5TestSuite) TestEjectAndRestore() {
+	down, _ := startSocks5Server(nil)
+	downAddress := down.Addr().String()
+	down.Close()
+
+	up, _ := startSocks5Server(nil)
+	defer up.Close()
+
+	ejected := make(chan bool, 10)
+
+	baseDialer, _ := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	lbDialer, err := network.NewLoadBalancedSocks5DialerWithCallback(baseDialer, []*url.URL{
+		{Scheme: "socks5", Host: downAddress, RawQuery: "open_threshold=2&half_open_timeout=50ms"},
+		{Scheme: "socks5", Host: up.Addr().String()},
+	}, func(proxyURL *url.URL, isEjected bool) {
+		suite.Equal(downAddress, proxyURL.Host)
+
+		ejected <- isEjected
+	})
+	suite.NoError(err)
+
+	for i := 0; i < 20; i++ {
+		conn, err := lbDialer.Dial("tcp", suite.HTTPServerAddress())
+		suite.NoError(err)
+
+		conn.Close()
+	}
+
+	suite.True(<-ejected)
+
+	listener, err := net.Listen("tcp", downAddress)
+	suite.NoError(err)
+
+	defer listener.Close()
+
+	server, _ := socks5.New(&socks5.Config{})
+
+	go server.Serve(listener) //nolint: errcheck
+
+	suite.Eventually(func() bool {
+		conn, err := lbDialer.Dial("tcp", suite.HTTPServerAddress())
+		if err == nil {
+			conn.Close()
+		}
+
+		select {
+		case isEjected := <-ejected:
+			return !isEjected
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 func TestLoadBalancedSocks5(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &LoadBalancedSocks5TestSuite{})
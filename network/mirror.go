@@ -0,0 +1,97 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+)
+
+// MirrorDialResult is a result of a single dial made by mirror dialer.
+type MirrorDialResult struct {
+	// Duration is a time spent on establishing a connection.
+	Duration time.Duration
+
+	// Err is an error of the dial. It is nil if connection was established.
+	Err error
+}
+
+// MirrorCallback defines a signature of the callback that is executed when
+// both primary and mirrored dials to the same address are finished.
+type MirrorCallback func(address string, primary, mirror MirrorDialResult)
+
+type mirrorDialer struct {
+	Dialer
+
+	mirror     Dialer
+	sampleRate float64
+	callback   MirrorCallback
+}
+
+func (m mirrorDialer) Dial(network, address string) (essentials.Conn, error) {
+	return m.DialContext(context.Background(), network, address)
+}
+
+func (m mirrorDialer) DialContext(ctx context.Context, network, address string) (essentials.Conn, error) {
+	if rand.Float64() >= m.sampleRate { //nolint: gosec
+		return m.Dialer.DialContext(ctx, network, address) //nolint: wrapcheck
+	}
+
+	primaryDone := make(chan MirrorDialResult, 1)
+
+	go m.dialMirror(network, address, primaryDone)
+
+	started := time.Now()
+	conn, err := m.Dialer.DialContext(ctx, network, address)
+
+	primaryDone <- MirrorDialResult{
+		Duration: time.Since(started),
+		Err:      err,
+	}
+
+	return conn, err //nolint: wrapcheck
+}
+
+func (m mirrorDialer) dialMirror(network, address string, primaryDone <-chan MirrorDialResult) {
+	// mirrored dial should never affect a client so it does not respect a
+	// context of the primary one: it is bound only by a timeout of the
+	// mirror dialer.
+	started := time.Now()
+	conn, err := m.mirror.DialContext(context.Background(), network, address)
+	result := MirrorDialResult{
+		Duration: time.Since(started),
+		Err:      err,
+	}
+
+	if err == nil {
+		conn.Close()
+	}
+
+	m.callback(address, <-primaryDone, result)
+}
+
+// NewMirrorDialer wraps a primary dialer so a sampled fraction of its dials
+// is also made with a mirror dialer. Mirrored connections are closed
+// immediately, clients always get connections of the primary dialer. A
+// callback gets results of both dials so they can be compared.
+//
+// This is a diagnostic tool to evaluate a new egress path (for example, a
+// new proxy) before switching to it. sampleRate should be 0 < x <= 1.
+func NewMirrorDialer(primary, mirror Dialer, sampleRate float64, callback MirrorCallback) (Dialer, error) {
+	if sampleRate <= 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("sample rate should be 0 < x <= 1 (%v)", sampleRate)
+	}
+
+	if callback == nil {
+		callback = func(_ string, _, _ MirrorDialResult) {}
+	}
+
+	return mirrorDialer{
+		Dialer:     primary,
+		mirror:     mirror,
+		sampleRate: sampleRate,
+		callback:   callback,
+	}, nil
+}
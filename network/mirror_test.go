@@ -0,0 +1,106 @@
+package network_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MirrorDialerTestSuite struct {
+	suite.Suite
+
+	primaryMock *DialerMock
+	mirrorMock  *DialerMock
+	results     chan [2]network.MirrorDialResult
+}
+
+func (suite *MirrorDialerTestSuite) SetupTest() {
+	suite.primaryMock = &DialerMock{}
+	suite.mirrorMock = &DialerMock{}
+	suite.results = make(chan [2]network.MirrorDialResult, 1)
+}
+
+func (suite *MirrorDialerTestSuite) TearDownTest() {
+	suite.primaryMock.AssertExpectations(suite.T())
+	suite.mirrorMock.AssertExpectations(suite.T())
+}
+
+func (suite *MirrorDialerTestSuite) makeDialer(sampleRate float64) network.Dialer {
+	dialer, err := network.NewMirrorDialer(suite.primaryMock, suite.mirrorMock, sampleRate,
+		func(address string, primary, mirror network.MirrorDialResult) {
+			suite.Equal("127.0.0.1:443", address)
+
+			suite.results <- [2]network.MirrorDialResult{primary, mirror}
+		})
+	suite.NoError(err)
+
+	return dialer
+}
+
+func (suite *MirrorDialerTestSuite) TestIncorrectSampleRate() {
+	for _, v := range []float64{-0.1, 0, 1.1} {
+		_, err := network.NewMirrorDialer(suite.primaryMock, suite.mirrorMock, v, nil)
+		suite.Error(err)
+	}
+}
+
+func (suite *MirrorDialerTestSuite) TestMirrored() {
+	var conn essentials.Conn = &net.TCPConn{}
+
+	suite.primaryMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return(conn, nil)
+	suite.mirrorMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		After(10*time.Millisecond).
+		Return((*net.TCPConn)(nil), errors.New("failed"))
+
+	dialed, err := suite.makeDialer(1).DialContext(context.Background(), "tcp", "127.0.0.1:443")
+	suite.NoError(err)
+	suite.Equal(conn, dialed)
+
+	select {
+	case results := <-suite.results:
+		suite.NoError(results[0].Err)
+		suite.Error(results[1].Err)
+		suite.GreaterOrEqual(results[1].Duration, 10*time.Millisecond)
+	case <-time.After(time.Second):
+		suite.FailNow("mirror callback was not called")
+	}
+}
+
+func (suite *MirrorDialerTestSuite) TestPrimaryErrorIsReturned() {
+	suite.primaryMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return((*net.TCPConn)(nil), errors.New("failed"))
+	suite.mirrorMock.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:443").
+		Once().
+		Return(&net.TCPConn{}, nil)
+
+	_, err := suite.makeDialer(1).Dial("tcp", "127.0.0.1:443")
+	suite.Error(err)
+
+	select {
+	case results := <-suite.results:
+		suite.Error(results[0].Err)
+		suite.NoError(results[1].Err)
+	case <-time.After(time.Second):
+		suite.FailNow("mirror callback was not called")
+	}
+}
+
+func TestMirrorDialer(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &MirrorDialerTestSuite{})
+}
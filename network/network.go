@@ -38,7 +38,7 @@ func (n *network) Dial(protocol, address string) (essentials.Conn, error) {
 func (n *network) DialContext(ctx context.Context, protocol, address string) (essentials.Conn, error) {
 	host, port, _ := net.SplitHostPort(address)
 
-	ips, err := n.dnsResolve(protocol, host)
+	ips, err := n.dnsResolve(ctx, protocol, host)
 	if err != nil {
 		return nil, fmt.Errorf("cannot resolve dns names: %w", err)
 	}
@@ -70,7 +70,7 @@ func (n *network) MakeHTTPClient(dialFunc func(ctx context.Context,
 	return makeHTTPClient(n.userAgent, n.httpTimeout, dialFunc)
 }
 
-func (n *network) dnsResolve(protocol, address string) ([]string, error) {
+func (n *network) dnsResolve(ctx context.Context, protocol, address string) ([]string, error) {
 	if net.ParseIP(address) != nil {
 		return []string{address}, nil
 	}
@@ -86,7 +86,7 @@ func (n *network) dnsResolve(protocol, address string) ([]string, error) {
 		go func() {
 			defer wg.Done()
 
-			resolved := n.dns.LookupA(address)
+			resolved := n.dns.LookupA(ctx, address)
 
 			mutex.Lock()
 			ips = append(ips, resolved...)
@@ -101,7 +101,7 @@ func (n *network) dnsResolve(protocol, address string) ([]string, error) {
 		go func() {
 			defer wg.Done()
 
-			resolved := n.dns.LookupAAAA(address)
+			resolved := n.dns.LookupAAAA(ctx, address)
 
 			mutex.Lock()
 			ips = append(ips, resolved...)
@@ -118,32 +118,234 @@ func (n *network) dnsResolve(protocol, address string) ([]string, error) {
 	return ips, nil
 }
 
+// DNSQueryCallback defines a signature of the callback that has to be
+// executed after each DNS lookup. It gets a hostname, a time spent on a query,
+// a flag if an answer was taken from the cache and an error if query has
+// failed.
+type DNSQueryCallback func(ctx context.Context, hostname string,
+	duration time.Duration, isCached bool, err error)
+
+// DNSCacheCallback defines a signature of the callback that has to be
+// executed after each update of DNS cache. It gets a current number of
+// entries in the cache and a number of entries evicted during this update.
+type DNSCacheCallback func(size, evicted int)
+
+// DNSCacheOpts defines settings of DNS cache.
+type DNSCacheOpts struct {
+	// Policy defines an eviction policy of the cache. It is either
+	// DNSCachePolicyLRU or DNSCachePolicyTTL.
+	//
+	// This is an optional setting. Default is DNSCachePolicyLRU.
+	Policy string
+
+	// Size defines a max number of entries in the cache. It is used only
+	// by DNSCachePolicyLRU.
+	//
+	// This is an optional setting. Default is DefaultDNSCacheSize.
+	Size uint
+
+	// Callback is executed after each update of the cache.
+	//
+	// This is an optional setting.
+	Callback DNSCacheCallback
+
+	// MinTTL defines a min time to keep an answer. If TTL of DNS records
+	// is smaller, it is raised to this value.
+	//
+	// This is an optional setting. Default is DefaultDNSCacheMinTTL.
+	MinTTL time.Duration
+
+	// MaxTTL defines a max time to keep an answer. If TTL of DNS records
+	// is larger, it is lowered to this value.
+	//
+	// This is an optional setting. Default is DefaultDNSCacheMaxTTL.
+	MaxTTL time.Duration
+
+	// NegativeTTL defines a time to keep NXDOMAIN or an answer without
+	// records. Network errors are never cached.
+	//
+	// This is an optional setting. Default is DefaultDNSCacheNegativeTTL.
+	NegativeTTL time.Duration
+}
+
+func (d DNSCacheOpts) getSize() uint {
+	if d.Size == 0 {
+		return DefaultDNSCacheSize
+	}
+
+	return d.Size
+}
+
+func (d DNSCacheOpts) getMinTTL() time.Duration {
+	if d.MinTTL == 0 {
+		return DefaultDNSCacheMinTTL
+	}
+
+	return d.MinTTL
+}
+
+func (d DNSCacheOpts) getMaxTTL() time.Duration {
+	if d.MaxTTL == 0 {
+		return DefaultDNSCacheMaxTTL
+	}
+
+	return d.MaxTTL
+}
+
+func (d DNSCacheOpts) getNegativeTTL() time.Duration {
+	if d.NegativeTTL == 0 {
+		return DefaultDNSCacheNegativeTTL
+	}
+
+	return d.NegativeTTL
+}
+
+// Opts defines settings of a network made by [NewNetworkWithOpts].
+type Opts struct {
+	// Dialer is used to establish connections.
+	//
+	// This is a mandatory setting.
+	Dialer Dialer
+
+	// UserAgent is a value of User-Agent header of HTTP requests.
+	//
+	// This is an optional setting.
+	UserAgent string
+
+	// DOHHostname is either IP address of DOH resolver which is accessed
+	// with a standard /dns-query endpoint or a full URL of its endpoint
+	// like https://dns.google/dns-query. Only https scheme is allowed. If
+	// URL has no path, /dns-query is used.
+	//
+	// Please remember that a hostname of the resolver is resolved with a
+	// system resolver. Use IP address in URL to avoid that.
+	//
+	// This is an optional setting. Default is DefaultDOHHostname. It is
+	// ignored if DNSResolver is set.
+	DOHHostname string
+
+	// DNSResolver resolves hostnames instead of DNS-over-HTTPS, for
+	// example, [DoTResolver]. Answers are cached in the same way.
+	//
+	// This is an optional setting.
+	DNSResolver DNSResolver
+
+	// HTTPTimeout limits HTTP requests, including DNS-over-HTTPS queries.
+	//
+	// This is an optional setting. Default is DefaultHTTPTimeout.
+	HTTPTimeout time.Duration
+
+	// DNSCallback is executed after each DNS lookup. It is useful if you
+	// want to monitor how healthy your DNS resolver is.
+	//
+	// This is an optional setting.
+	DNSCallback DNSQueryCallback
+
+	// DNSCache tunes DNS cache: its size, eviction policy and a callback
+	// to monitor it.
+	//
+	// This is an optional setting.
+	DNSCache DNSCacheOpts
+}
+
+func (o Opts) getDOHHostname() string {
+	if o.DOHHostname == "" {
+		return DefaultDOHHostname
+	}
+
+	return o.DOHHostname
+}
+
+func (o Opts) getHTTPTimeout() time.Duration {
+	if o.HTTPTimeout == 0 {
+		return DefaultHTTPTimeout
+	}
+
+	return o.HTTPTimeout
+}
+
 // NewNetwork assembles an mtglib.Network compatible structure based on a
 // dialer and given params.
 //
 // It brings simple DNS cache and DNS-Over-HTTPS when necessary.
+//
+// Deprecated: please use [NewNetworkWithOpts].
 func NewNetwork(dialer Dialer,
 	userAgent, dohHostname string,
 	httpTimeout time.Duration,
 ) (mtglib.Network, error) {
-	switch {
-	case httpTimeout < 0:
-		return nil, fmt.Errorf("timeout should be positive number %s", httpTimeout)
-	case httpTimeout == 0:
-		httpTimeout = DefaultHTTPTimeout
+	return NewNetworkWithOpts(Opts{
+		Dialer:      dialer,
+		UserAgent:   userAgent,
+		DOHHostname: dohHostname,
+		HTTPTimeout: httpTimeout,
+	})
+}
+
+// NewNetworkWithOpts assembles an mtglib.Network compatible structure
+// based on given options.
+//
+// It brings simple DNS cache and DNS-Over-HTTPS when necessary.
+func NewNetworkWithOpts(opts Opts) (mtglib.Network, error) {
+	if opts.Dialer == nil {
+		return nil, fmt.Errorf("dialer is not defined")
+	}
+
+	if opts.HTTPTimeout < 0 {
+		return nil, fmt.Errorf("timeout should be positive number %s", opts.HTTPTimeout)
 	}
 
-	if net.ParseIP(dohHostname) == nil {
-		return nil, fmt.Errorf("hostname %s should be IP address", dohHostname)
+	cacheOpts := opts.DNSCache
+	if err := validateDNSCacheOpts(&cacheOpts); err != nil {
+		return nil, err
 	}
 
-	return &network{
-		dialer:      dialer,
+	httpTimeout := opts.getHTTPTimeout()
+	rv := &network{
+		dialer:      opts.Dialer,
 		httpTimeout: httpTimeout,
-		userAgent:   userAgent,
-		dns: newDNSResolver(dohHostname,
-			makeHTTPClient(userAgent, DNSTimeout, dialer.DialContext)),
-	}, nil
+		userAgent:   opts.UserAgent,
+	}
+
+	if opts.DNSResolver != nil {
+		rv.dns = newDNSResolverWithTransport(opts.DNSResolver, opts.DNSCallback, newDNSCache(cacheOpts))
+
+		return rv, nil
+	}
+
+	dohHostname := opts.getDOHHostname()
+
+	dohURL, err := parseDOHURL(dohHostname)
+	if err != nil {
+		return nil, fmt.Errorf("hostname %s should be IP address or https URL: %w", dohHostname, err)
+	}
+
+	rv.dns = newDNSResolver(dohURL,
+		makeHTTPClient(opts.UserAgent, httpTimeout, opts.Dialer.DialContext),
+		opts.DNSCallback,
+		newDNSCache(cacheOpts))
+
+	return rv, nil
+}
+
+func validateDNSCacheOpts(cacheOpts *DNSCacheOpts) error {
+	switch cacheOpts.Policy {
+	case "":
+		cacheOpts.Policy = DNSCachePolicyLRU
+	case DNSCachePolicyLRU, DNSCachePolicyTTL:
+	default:
+		return fmt.Errorf("unsupported dns cache policy %s", cacheOpts.Policy)
+	}
+
+	switch {
+	case cacheOpts.MinTTL < 0 || cacheOpts.MaxTTL < 0 || cacheOpts.NegativeTTL < 0:
+		return fmt.Errorf("dns cache ttls should be positive")
+	case cacheOpts.getMinTTL() > cacheOpts.getMaxTTL():
+		return fmt.Errorf("min ttl %s of dns cache is larger than max ttl %s",
+			cacheOpts.getMinTTL(), cacheOpts.getMaxTTL())
+	}
+
+	return nil
 }
 
 func makeHTTPClient(userAgent string,
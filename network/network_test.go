@@ -1,9 +1,11 @@
 package network_test
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -11,6 +13,20 @@ import (
 	"github.com/stretchr/testify/suite"
 )
 
+type networkFakeDNSResolver struct{}
+
+func (n networkFakeDNSResolver) LookupA(_ context.Context, hostname string) ([]string, []uint32, error) {
+	if hostname != "mtg.example" {
+		return nil, nil, network.ErrDNSNameError
+	}
+
+	return []string{"127.0.0.1"}, []uint32{60}, nil
+}
+
+func (n networkFakeDNSResolver) LookupAAAA(_ context.Context, _ string) ([]string, []uint32, error) {
+	return nil, nil, nil
+}
+
 type NetworkTestSuite struct {
 	suite.Suite
 	HTTPServerTestSuite
@@ -19,14 +35,18 @@ type NetworkTestSuite struct {
 }
 
 func (suite *NetworkTestSuite) SetupTest() {
-	dialer, err := network.NewDefaultDialer(0, 0)
+	dialer, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
 	suite.NoError(err)
 
 	suite.dialer = dialer
 }
 
 func (suite *NetworkTestSuite) TestLocalHTTPRequest() {
-	ntw, err := network.NewNetwork(suite.dialer, "itsme", "1.1.1.1", 0)
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+	})
 	suite.NoError(err)
 
 	client := ntw.MakeHTTPClient(nil)
@@ -51,7 +71,11 @@ func (suite *NetworkTestSuite) TestLocalHTTPRequest() {
 }
 
 func (suite *NetworkTestSuite) TestRealHTTPRequest() {
-	ntw, err := network.NewNetwork(suite.dialer, "itsme", "1.1.1.1", 0)
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+	})
 	suite.NoError(err)
 
 	client := ntw.MakeHTTPClient(nil)
@@ -75,16 +99,116 @@ func (suite *NetworkTestSuite) TestRealHTTPRequest() {
 	suite.Equal("itsme", jsonStruct.Headers.UserAgent)
 }
 
+func (suite *NetworkTestSuite) TestDeprecatedConstructor() {
+	_, err := network.NewNetwork(suite.dialer, "itsme", "1.1.1.1", 0)
+	suite.NoError(err)
+
+	_, err = network.NewNetwork(suite.dialer, "itsme", "1.1.1.1", -time.Second)
+	suite.Error(err)
+}
+
 func (suite *NetworkTestSuite) TestIncorrectTimeout() {
-	_, err := network.NewNetwork(suite.dialer, "itsme", "1.1.1.1", -time.Second)
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+		HTTPTimeout: -time.Second,
+	})
+	suite.Error(err)
+}
+
+func (suite *NetworkTestSuite) TestIncorrectDNSCachePolicy() {
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+		DNSCache:    network.DNSCacheOpts{Policy: "lfu"},
+	})
+	suite.Error(err)
+}
+
+func (suite *NetworkTestSuite) TestIncorrectDNSCacheTTL() {
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+		DNSCache:    network.DNSCacheOpts{MinTTL: time.Hour, MaxTTL: time.Minute},
+	})
+	suite.Error(err)
+
+	_, err = network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+		DNSCache:    network.DNSCacheOpts{NegativeTTL: -time.Second},
+	})
 	suite.Error(err)
 }
 
 func (suite *NetworkTestSuite) TestIncorrectDOHHostname() {
-	_, err := network.NewNetwork(suite.dialer, "itsme", "doh.com", 0)
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "doh.com",
+	})
+	suite.Error(err)
+}
+
+func (suite *NetworkTestSuite) TestDOHURL() {
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "https://dns.google/dns-query",
+	})
+	suite.NoError(err)
+
+	for _, v := range []string{"http://dns.google/dns-query", "dns.google"} {
+		_, err := network.NewNetworkWithOpts(network.Opts{
+			Dialer:      suite.dialer,
+			UserAgent:   "itsme",
+			DOHHostname: v,
+		})
+		suite.Error(err, v)
+	}
+}
+
+func (suite *NetworkTestSuite) TestDNSResolver() {
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      suite.dialer,
+		UserAgent:   "itsme",
+		DNSResolver: networkFakeDNSResolver{},
+	})
+	suite.NoError(err)
+
+	parsed, err := url.Parse(suite.httpServer.URL)
+	suite.NoError(err)
+
+	resp, err := ntw.MakeHTTPClient(nil).Get("http://mtg.example:" + parsed.Port() + "/headers") //nolint: noctx
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+
+	_, err = ntw.Dial("tcp", "unknown.example:80")
+	suite.Error(err)
+}
+
+func (suite *NetworkTestSuite) TestNilDialer() {
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		UserAgent:   "itsme",
+		DOHHostname: "1.1.1.1",
+	})
 	suite.Error(err)
 }
 
+func (suite *NetworkTestSuite) TestDefaultDOHHostname() {
+	_, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer: suite.dialer,
+	})
+	suite.NoError(err)
+}
+
 func TestNetwork(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &NetworkTestSuite{})
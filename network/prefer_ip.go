@@ -0,0 +1,55 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/IceCodeNew/mtg/essentials"
+)
+
+type preferIPDialer struct {
+	Dialer
+
+	network string
+}
+
+func (p preferIPDialer) Dial(network, address string) (essentials.Conn, error) {
+	return p.DialContext(context.Background(), network, address)
+}
+
+func (p preferIPDialer) DialContext(ctx context.Context, network, address string) (essentials.Conn, error) {
+	if network == "tcp" {
+		network = p.network
+	}
+
+	return p.Dialer.DialContext(ctx, network, address) //nolint: wrapcheck
+}
+
+// NewPreferIPDialer wraps a dialer so it reaches upstreams only with a given
+// IP family. Valid values are 'prefer-ipv4', 'prefer-ipv6', 'only-ipv4' and
+// 'only-ipv6'.
+//
+// Only 'only-*' values make any effect here: generic tcp dials are converted
+// into tcp4 or tcp6 ones. If caller explicitly asks for tcp4 or tcp6, this
+// request is respected. 'prefer-*' values keep dialer as is because a
+// preference is about ordering of addresses and this is done by a caller
+// which knows all of them (for example, Telegram dialer in mtglib).
+func NewPreferIPDialer(baseDialer Dialer, preferIP string) (Dialer, error) {
+	switch strings.ToLower(preferIP) {
+	case "prefer-ipv4", "prefer-ipv6":
+		return baseDialer, nil
+	case "only-ipv4":
+		return preferIPDialer{
+			Dialer:  baseDialer,
+			network: "tcp4",
+		}, nil
+	case "only-ipv6":
+		return preferIPDialer{
+			Dialer:  baseDialer,
+			network: "tcp6",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown ip preference %s", preferIP)
+}
@@ -0,0 +1,82 @@
+package network_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PreferIPDialerTestSuite struct {
+	suite.Suite
+
+	dialerMock *DialerMock
+}
+
+func (suite *PreferIPDialerTestSuite) SetupTest() {
+	suite.dialerMock = &DialerMock{}
+}
+
+func (suite *PreferIPDialerTestSuite) TearDownTest() {
+	suite.dialerMock.AssertExpectations(suite.T())
+}
+
+func (suite *PreferIPDialerTestSuite) TestUnknownPreference() {
+	_, err := network.NewPreferIPDialer(suite.dialerMock, "ipv4")
+	suite.Error(err)
+}
+
+func (suite *PreferIPDialerTestSuite) TestPreferKeepsNetwork() {
+	for _, v := range []string{"prefer-ipv4", "prefer-ipv6"} {
+		dialer, err := network.NewPreferIPDialer(suite.dialerMock, v)
+		suite.NoError(err)
+		suite.Equal(suite.dialerMock, dialer)
+	}
+}
+
+func (suite *PreferIPDialerTestSuite) TestOnlyIPv4() {
+	suite.dialerMock.
+		On("DialContext", mock.Anything, "tcp4", "127.0.0.1:443").
+		Once().
+		Return(&net.TCPConn{}, nil)
+
+	dialer, err := network.NewPreferIPDialer(suite.dialerMock, "only-ipv4")
+	suite.NoError(err)
+
+	_, err = dialer.DialContext(context.Background(), "tcp", "127.0.0.1:443")
+	suite.NoError(err)
+}
+
+func (suite *PreferIPDialerTestSuite) TestOnlyIPv6() {
+	suite.dialerMock.
+		On("DialContext", mock.Anything, "tcp6", "[::1]:443").
+		Once().
+		Return(&net.TCPConn{}, nil)
+
+	dialer, err := network.NewPreferIPDialer(suite.dialerMock, "only-ipv6")
+	suite.NoError(err)
+
+	_, err = dialer.Dial("tcp", "[::1]:443")
+	suite.NoError(err)
+}
+
+func (suite *PreferIPDialerTestSuite) TestExplicitNetworkIsRespected() {
+	suite.dialerMock.
+		On("DialContext", mock.Anything, "tcp4", "127.0.0.1:443").
+		Once().
+		Return(&net.TCPConn{}, nil)
+
+	dialer, err := network.NewPreferIPDialer(suite.dialerMock, "only-ipv6")
+	suite.NoError(err)
+
+	_, err = dialer.DialContext(context.Background(), "tcp4", "127.0.0.1:443")
+	suite.NoError(err)
+}
+
+func TestPreferIPDialer(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &PreferIPDialerTestSuite{})
+}
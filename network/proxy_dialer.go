@@ -6,7 +6,7 @@ import (
 	"time"
 )
 
-func newProxyDialer(baseDialer Dialer, proxyURL *url.URL) Dialer {
+func newProxyDialer(baseDialer Dialer, proxyURL *url.URL, stateCallback func(opened bool)) Dialer {
 	params := proxyURL.Query()
 
 	var (
@@ -33,5 +33,8 @@ func newProxyDialer(baseDialer Dialer, proxyURL *url.URL) Dialer {
 		}
 	}
 
-	return newCircuitBreakerDialer(baseDialer, openThreshold, halfOpenTimeout, resetFailuresTimeout)
+	dialer := newCircuitBreakerDialer(baseDialer, openThreshold, halfOpenTimeout, resetFailuresTimeout)
+	dialer.stateCallback = stateCallback
+
+	return dialer
 }
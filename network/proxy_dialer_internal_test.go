@@ -21,7 +21,7 @@ func (suite *ProxyDialerTestSuite) SetupSuite() {
 }
 
 func (suite *ProxyDialerTestSuite) TestSetupDefaults() {
-	d := newProxyDialer(&DialerMock{}, suite.u).(*circuitBreakerDialer) //nolint: forcetypeassert
+	d := newProxyDialer(&DialerMock{}, suite.u, nil).(*circuitBreakerDialer) //nolint: forcetypeassert
 	suite.EqualValues(ProxyDialerOpenThreshold, d.openThreshold)
 	suite.EqualValues(ProxyDialerHalfOpenTimeout, d.halfOpenTimeout)
 	suite.EqualValues(ProxyDialerResetFailuresTimeout, d.resetFailuresTimeout)
@@ -34,7 +34,7 @@ func (suite *ProxyDialerTestSuite) TestSetupValuesAllOk() {
 	query.Set("half_open_timeout", "2s")
 	suite.u.RawQuery = query.Encode()
 
-	d := newProxyDialer(&DialerMock{}, suite.u).(*circuitBreakerDialer) //nolint: forcetypeassert
+	d := newProxyDialer(&DialerMock{}, suite.u, nil).(*circuitBreakerDialer) //nolint: forcetypeassert
 	suite.EqualValues(30, d.openThreshold)
 	suite.EqualValues(2*time.Second, d.halfOpenTimeout)
 	suite.EqualValues(time.Second, d.resetFailuresTimeout)
@@ -50,7 +50,7 @@ func (suite *ProxyDialerTestSuite) TestOpenThreshold() {
 			query.Set("open_threshold", param)
 			suite.u.RawQuery = query.Encode()
 
-			d := newProxyDialer(&DialerMock{}, suite.u).(*circuitBreakerDialer) //nolint: forcetypeassert
+			d := newProxyDialer(&DialerMock{}, suite.u, nil).(*circuitBreakerDialer) //nolint: forcetypeassert
 			assert.EqualValues(t, ProxyDialerOpenThreshold, d.openThreshold)
 		})
 	}
@@ -66,7 +66,7 @@ func (suite *ProxyDialerTestSuite) TestHalfOpenTimeout() {
 			query.Set("half_open_timeout", param)
 			suite.u.RawQuery = query.Encode()
 
-			d := newProxyDialer(&DialerMock{}, suite.u).(*circuitBreakerDialer) //nolint: forcetypeassert
+			d := newProxyDialer(&DialerMock{}, suite.u, nil).(*circuitBreakerDialer) //nolint: forcetypeassert
 			assert.EqualValues(t, ProxyDialerHalfOpenTimeout, d.halfOpenTimeout)
 		})
 	}
@@ -82,7 +82,7 @@ func (suite *ProxyDialerTestSuite) TestResetFailuresTimeout() {
 			query.Set("reset_failures_timeout", param)
 			suite.u.RawQuery = query.Encode()
 
-			d := newProxyDialer(&DialerMock{}, suite.u).(*circuitBreakerDialer) //nolint: forcetypeassert
+			d := newProxyDialer(&DialerMock{}, suite.u, nil).(*circuitBreakerDialer) //nolint: forcetypeassert
 			assert.EqualValues(t, ProxyDialerHalfOpenTimeout, d.halfOpenTimeout)
 		})
 	}
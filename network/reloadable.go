@@ -0,0 +1,65 @@
+package network
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// Reloadable is a network which delegates to another one. This network
+// can be replaced in runtime, for example, on reload of a configuration.
+//
+// Connections which were established before a swap are kept as is. HTTP
+// clients made by Reloadable dial with a current network so they follow
+// swaps as well.
+type Reloadable struct {
+	mutex   sync.RWMutex
+	network mtglib.Network
+}
+
+// Dial establishes a connection with a current network.
+func (r *Reloadable) Dial(protocol, address string) (essentials.Conn, error) {
+	return r.get().Dial(protocol, address) //nolint: wrapcheck
+}
+
+// DialContext establishes a connection with a current network.
+func (r *Reloadable) DialContext(ctx context.Context, protocol, address string) (essentials.Conn, error) {
+	return r.get().DialContext(ctx, protocol, address) //nolint: wrapcheck
+}
+
+// MakeHTTPClient makes a client with a current network. If dialFunc is
+// nil, a client dials with [Reloadable.DialContext].
+func (r *Reloadable) MakeHTTPClient(dialFunc func(ctx context.Context,
+	network, address string) (essentials.Conn, error),
+) *http.Client {
+	if dialFunc == nil {
+		dialFunc = r.DialContext
+	}
+
+	return r.get().MakeHTTPClient(dialFunc)
+}
+
+// Swap replaces a current network with a given one.
+func (r *Reloadable) Swap(ntw mtglib.Network) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.network = ntw
+}
+
+func (r *Reloadable) get() mtglib.Network {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.network
+}
+
+// NewReloadable creates a new network which delegates to a given one.
+func NewReloadable(ntw mtglib.Network) *Reloadable {
+	return &Reloadable{
+		network: ntw,
+	}
+}
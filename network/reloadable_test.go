@@ -0,0 +1,86 @@
+package network_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/internal/testlib"
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloadableTestSuite struct {
+	suite.Suite
+
+	first  *testlib.MtglibNetworkMock
+	second *testlib.MtglibNetworkMock
+	ntw    *network.Reloadable
+}
+
+func (suite *ReloadableTestSuite) SetupTest() {
+	suite.first = &testlib.MtglibNetworkMock{}
+	suite.second = &testlib.MtglibNetworkMock{}
+	suite.ntw = network.NewReloadable(suite.first)
+}
+
+func (suite *ReloadableTestSuite) TearDownTest() {
+	suite.first.AssertExpectations(suite.T())
+	suite.second.AssertExpectations(suite.T())
+}
+
+func (suite *ReloadableTestSuite) TestDial() {
+	conn := &testlib.EssentialsConnMock{}
+
+	suite.first.On("Dial", "tcp", "127.0.0.1:80").Once().Return(conn, nil)
+
+	rv, err := suite.ntw.Dial("tcp", "127.0.0.1:80")
+	suite.NoError(err)
+	suite.Equal(conn, rv)
+}
+
+func (suite *ReloadableTestSuite) TestSwap() {
+	conn := &testlib.EssentialsConnMock{}
+
+	suite.second.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:80").
+		Once().
+		Return(conn, nil)
+
+	suite.ntw.Swap(suite.second)
+
+	rv, err := suite.ntw.DialContext(context.Background(), "tcp", "127.0.0.1:80")
+	suite.NoError(err)
+	suite.Equal(conn, rv)
+}
+
+func (suite *ReloadableTestSuite) TestHTTPClientFollowsSwap() {
+	conn := &testlib.EssentialsConnMock{}
+
+	suite.first.
+		On("MakeHTTPClient", mock.Anything).
+		Once().
+		Run(func(args mock.Arguments) {
+			dialFunc := args.Get(0).(func(context.Context, string, string) (essentials.Conn, error))
+
+			suite.ntw.Swap(suite.second)
+
+			rv, err := dialFunc(context.Background(), "tcp", "127.0.0.1:80")
+			suite.NoError(err)
+			suite.Equal(conn, rv)
+		}).
+		Return(&http.Client{})
+	suite.second.
+		On("DialContext", mock.Anything, "tcp", "127.0.0.1:80").
+		Once().
+		Return(conn, nil)
+
+	suite.NotNil(suite.ntw.MakeHTTPClient(nil))
+}
+
+func TestReloadable(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &ReloadableTestSuite{})
+}
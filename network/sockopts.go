@@ -8,18 +8,59 @@ import (
 // SetClientSocketOptions tunes a TCP socket that represents a connection to
 // end user (not Telegram service or fronting domain).
 //
-// bufferSize setting is deprecated and ignored.
+// bufferSize is a size of both SO_SNDBUF and SO_RCVBUF in bytes. 0 means
+// that operating system defaults are used. Please see [SetSocketBuffers]
+// for details.
 func SetClientSocketOptions(conn net.Conn, bufferSize int) error {
-	return setCommonSocketOptions(conn.(*net.TCPConn)) //nolint: forcetypeassert
+	return setCommonSocketOptions(conn, bufferSize)
 }
 
 // SetServerSocketOptions tunes a TCP socket that represents a connection to
 // remote server like Telegram or fronting domain (but not end user).
+//
+// bufferSize has the same meaning as for [SetClientSocketOptions].
 func SetServerSocketOptions(conn net.Conn, bufferSize int) error {
-	return setCommonSocketOptions(conn.(*net.TCPConn)) //nolint: forcetypeassert
+	return setCommonSocketOptions(conn, bufferSize)
+}
+
+// SetSocketBuffers sets both SO_SNDBUF and SO_RCVBUF of a given TCP socket.
+// 0 means that nothing is changed and operating system defaults are used.
+//
+// Operating system treats this value as a hint. Linux doubles it to have
+// a space for bookkeeping and silently caps it with net.core.wmem_max and
+// net.core.rmem_max sysctls. Also, Linux stops autotuning of buffers of
+// such socket so a value that is too small can decrease throughput.
+func SetSocketBuffers(conn net.Conn, bufferSize int) error {
+	if bufferSize < 0 {
+		return fmt.Errorf("incorrect buffer size %d, should be >= 0", bufferSize)
+	}
+
+	if bufferSize == 0 {
+		return nil
+	}
+
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return ErrNotTCPConnection
+	}
+
+	if err := tcpConn.SetReadBuffer(bufferSize); err != nil {
+		return fmt.Errorf("cannot set SO_RCVBUF: %w", err)
+	}
+
+	if err := tcpConn.SetWriteBuffer(bufferSize); err != nil {
+		return fmt.Errorf("cannot set SO_SNDBUF: %w", err)
+	}
+
+	return nil
 }
 
-func setCommonSocketOptions(conn *net.TCPConn) error {
+func setCommonSocketOptions(baseConn net.Conn, bufferSize int) error {
+	conn, ok := baseConn.(*net.TCPConn)
+	if !ok {
+		return ErrNotTCPConnection
+	}
+
 	if err := conn.SetKeepAlivePeriod(DefaultTCPKeepAlivePeriod); err != nil {
 		return fmt.Errorf("cannot set time period of TCP keepalive probes: %w", err)
 	}
@@ -37,5 +78,41 @@ func setCommonSocketOptions(conn *net.TCPConn) error {
 		return fmt.Errorf("cannot setup SO_REUSEADDR/PORT: %w", err)
 	}
 
+	if err := SetSocketBuffers(conn, bufferSize); err != nil {
+		return fmt.Errorf("cannot setup socket buffers: %w", err)
+	}
+
+	return nil
+}
+
+// SetSocketDSCP sets a DSCP value of a traffic class for a given TCP socket.
+// It uses IP_TOS for IPv4 and IPV6_TCLASS for IPv6 sockets. 0 means that
+// nothing is changed and operating system defaults are used.
+//
+// This is not supported on all platforms. If platform does not support it,
+// this function does nothing.
+func SetSocketDSCP(conn net.Conn, dscp uint) error {
+	if dscp > MaxDSCP {
+		return fmt.Errorf("incorrect dscp value %d, should be <= %d", dscp, MaxDSCP)
+	}
+
+	if dscp == 0 {
+		return nil
+	}
+
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return ErrNotTCPConnection
+	}
+
+	rawConn, err := tcpConn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("cannot get underlying raw connection: %w", err)
+	}
+
+	if err := setSocketTrafficClass(rawConn, int(dscp<<2)); err != nil { //nolint: gomnd
+		return fmt.Errorf("cannot setup traffic class: %w", err)
+	}
+
 	return nil
 }
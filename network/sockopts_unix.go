@@ -29,3 +29,41 @@ func setSocketReuseAddrPort(conn syscall.RawConn) error {
 
 	return err
 }
+
+func setSocketTrafficClass(conn syscall.RawConn, trafficClass int) error {
+	var err error
+
+	conn.Control(func(fd uintptr) { //nolint: errcheck
+		var sockAddr unix.Sockaddr
+
+		sockAddr, err = unix.Getsockname(int(fd))
+		if err != nil {
+			err = fmt.Errorf("cannot get socket address: %w", err)
+
+			return
+		}
+
+		if _, ok := sockAddr.(*unix.SockaddrInet6); !ok {
+			err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, trafficClass) //nolint: nosnakecase
+			if err != nil {
+				err = fmt.Errorf("cannot set IP_TOS: %w", err)
+			}
+
+			return
+		}
+
+		err = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_TCLASS, trafficClass) //nolint: nosnakecase
+		if err != nil {
+			err = fmt.Errorf("cannot set IPV6_TCLASS: %w", err)
+
+			return
+		}
+
+		// dual-stack sockets could carry IPv4 traffic. Some platforms take
+		// a traffic class of such packets from IP_TOS, some do not support
+		// it for IPv6 sockets at all so this is a best effort.
+		unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS, trafficClass) //nolint: errcheck, nosnakecase
+	})
+
+	return err
+}
@@ -0,0 +1,144 @@
+//go:build !windows
+// +build !windows
+
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/sys/unix"
+)
+
+type SockoptsTestSuite struct {
+	suite.Suite
+
+	listener net.Listener
+}
+
+func (suite *SockoptsTestSuite) SetupTest() {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	suite.NoError(err)
+
+	suite.listener = listener
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+		}
+	}()
+}
+
+func (suite *SockoptsTestSuite) TearDownTest() {
+	suite.listener.Close()
+}
+
+func (suite *SockoptsTestSuite) GetTOS(conn net.Conn) int {
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	suite.NoError(err)
+
+	var value int
+
+	rawConn.Control(func(fd uintptr) { //nolint: errcheck
+		value, err = unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_TOS)
+	})
+	suite.NoError(err)
+
+	return value
+}
+
+func (suite *SockoptsTestSuite) GetBuffers(conn net.Conn) (int, int) {
+	rawConn, err := conn.(*net.TCPConn).SyscallConn()
+	suite.NoError(err)
+
+	var sndErr, rcvErr error
+
+	var sndBuf, rcvBuf int
+
+	rawConn.Control(func(fd uintptr) { //nolint: errcheck
+		sndBuf, sndErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF)
+		rcvBuf, rcvErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUF)
+	})
+	suite.NoError(sndErr)
+	suite.NoError(rcvErr)
+
+	return sndBuf, rcvBuf
+}
+
+func (suite *SockoptsTestSuite) TestIncorrectValue() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	suite.Error(SetSocketDSCP(conn, MaxDSCP+1))
+}
+
+func (suite *SockoptsTestSuite) TestUnset() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	suite.NoError(SetSocketDSCP(conn, 0))
+	suite.Equal(0, suite.GetTOS(conn))
+}
+
+func (suite *SockoptsTestSuite) TestSet() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	suite.NoError(SetSocketDSCP(conn, 46))
+	suite.Equal(46<<2, suite.GetTOS(conn))
+}
+
+func (suite *SockoptsTestSuite) TestBuffersIncorrectValue() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	suite.Error(SetSocketBuffers(conn, -1))
+}
+
+func (suite *SockoptsTestSuite) TestBuffersUnset() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	sndBuf, rcvBuf := suite.GetBuffers(conn)
+
+	suite.NoError(SetClientSocketOptions(conn, 0))
+
+	newSndBuf, newRcvBuf := suite.GetBuffers(conn)
+	suite.Equal(sndBuf, newSndBuf)
+	suite.Equal(rcvBuf, newRcvBuf)
+}
+
+func (suite *SockoptsTestSuite) TestBuffersSet() {
+	conn, err := net.Dial("tcp", suite.listener.Addr().String())
+	suite.NoError(err)
+
+	defer conn.Close()
+
+	suite.NoError(SetServerSocketOptions(conn, 4096))
+
+	// some platforms double a value, some keep it as is.
+	sndBuf, rcvBuf := suite.GetBuffers(conn)
+	suite.Contains([]int{4096, 8192}, sndBuf)
+	suite.Contains([]int{4096, 8192}, rcvBuf)
+}
+
+func TestSockopts(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &SockoptsTestSuite{})
+}
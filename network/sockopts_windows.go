@@ -8,3 +8,7 @@ import "syscall"
 func setSocketReuseAddrPort(conn syscall.RawConn) error {
 	return nil
 }
+
+func setSocketTrafficClass(conn syscall.RawConn, trafficClass int) error {
+	return nil
+}
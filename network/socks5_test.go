@@ -1,8 +1,13 @@
 package network_test
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/IceCodeNew/mtg/network"
 	"github.com/stretchr/testify/suite"
@@ -20,7 +25,7 @@ func (suite *Socks5TestSuite) SetupSuite() {
 	suite.HTTPServerTestSuite.SetupSuite()
 	suite.Socks5ServerTestSuite.SetupSuite()
 
-	suite.d, _ = network.NewDefaultDialer(0, 0)
+	suite.d, _ = network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
 }
 
 func (suite *Socks5TestSuite) TearDownSuite() {
@@ -55,6 +60,82 @@ func (suite *Socks5TestSuite) TestRequestOk() {
 	suite.Equal(http.StatusOK, resp.StatusCode)
 }
 
+func (suite *Socks5TestSuite) TestAuthRequired() {
+	dialer, _ := network.NewSocks5Dialer(suite.d, &url.URL{
+		Scheme: "socks5",
+		Host:   suite.socks5Listener.Addr().String(),
+	})
+
+	_, err := dialer.Dial("tcp", suite.HTTPServerAddress())
+	suite.True(errors.Is(err, network.ErrSocks5AuthenticationRequired))
+}
+
+func (suite *Socks5TestSuite) TestNoAuth() {
+	listener, rules := startSocks5Server(nil)
+	defer listener.Close()
+
+	dialer, _ := network.NewSocks5Dialer(suite.d, &url.URL{
+		Scheme: "socks5",
+		Host:   listener.Addr().String(),
+	})
+
+	conn, err := dialer.Dial("tcp", suite.HTTPServerAddress())
+	suite.NoError(err)
+
+	conn.Close()
+
+	suite.EqualValues(1, rules.Allowed())
+}
+
+func (suite *Socks5TestSuite) TestUsernameOnly() {
+	listener, rules := startSocks5Server(map[string]string{"user": ""})
+	defer listener.Close()
+
+	dialer, _ := network.NewSocks5Dialer(suite.d, &url.URL{
+		Scheme: "socks5",
+		User:   url.User("user"),
+		Host:   listener.Addr().String(),
+	})
+
+	conn, err := dialer.Dial("tcp", suite.HTTPServerAddress())
+	suite.NoError(err)
+
+	conn.Close()
+
+	suite.EqualValues(1, rules.Allowed())
+}
+
+func (suite *Socks5TestSuite) TestProxyDoesNotRespond() {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+		}
+	}()
+
+	dialer, _ := network.NewSocks5Dialer(suite.d, &url.URL{
+		Scheme: "socks5",
+		User:   url.UserPassword("user", "password"),
+		Host:   listener.Addr().String(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := dialer.DialContext(ctx, "tcp", suite.HTTPServerAddress())
+
+	suite.Error(err)
+	suite.Less(time.Since(started), time.Second)
+}
+
 func TestSocks5(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &Socks5TestSuite{})
@@ -0,0 +1,66 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/IceCodeNew/mtg/essentials"
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+type systemResolverNetwork struct {
+	mtglib.Network
+
+	resolver *net.Resolver
+}
+
+func (s systemResolverNetwork) MakeHTTPClient(dialFunc func(ctx context.Context,
+	network, address string) (essentials.Conn, error),
+) *http.Client {
+	if dialFunc == nil {
+		dialFunc = s.dialContext
+	}
+
+	return s.Network.MakeHTTPClient(dialFunc)
+}
+
+func (s systemResolverNetwork) dialContext(ctx context.Context,
+	protocol, address string,
+) (essentials.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect address %s: %w", address, err)
+	}
+
+	ips, err := s.resolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", host, err)
+	}
+
+	var conn essentials.Conn
+
+	for _, v := range ips {
+		conn, err = s.Network.DialContext(ctx, protocol, net.JoinHostPort(v.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot dial to %s:%s: %w", protocol, address, err)
+}
+
+// NewSystemResolverNetwork wraps a given network so HTTP clients made by
+// it resolve hostnames with a system resolver instead of DNS-over-HTTPS.
+// Connections are still established with a given network so proxies and
+// socket options are respected.
+//
+// This is useful for the traffic which is not related to Telegram and
+// should not load DOH resolver.
+func NewSystemResolverNetwork(ntw mtglib.Network) mtglib.Network {
+	return systemResolverNetwork{
+		Network:  ntw,
+		resolver: net.DefaultResolver,
+	}
+}
@@ -0,0 +1,62 @@
+package network_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/network"
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemResolverNetworkTestSuite struct {
+	suite.Suite
+	HTTPServerTestSuite
+}
+
+func (suite *SystemResolverNetworkTestSuite) makeURL() string {
+	return strings.Replace(suite.MakeURL("/get"), "127.0.0.1", "localhost", 1)
+}
+
+func (suite *SystemResolverNetworkTestSuite) TestResolveHostname() {
+	dialer, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	suite.NoError(err)
+
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "127.0.0.1",
+	})
+	suite.NoError(err)
+
+	client := network.NewSystemResolverNetwork(ntw).MakeHTTPClient(nil)
+
+	resp, err := client.Get(suite.makeURL()) //nolint: noctx
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *SystemResolverNetworkTestSuite) TestUnknownHostname() {
+	dialer, err := network.NewDefaultDialerWithOpts(network.DefaultDialerOpts{})
+	suite.NoError(err)
+
+	ntw, err := network.NewNetworkWithOpts(network.Opts{
+		Dialer:      dialer,
+		UserAgent:   "itsme",
+		DOHHostname: "127.0.0.1",
+	})
+	suite.NoError(err)
+
+	client := network.NewSystemResolverNetwork(ntw).MakeHTTPClient(nil)
+
+	_, err = client.Get("http://unknown.invalid/get") //nolint: noctx
+	suite.Error(err)
+}
+
+func TestSystemResolverNetwork(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &SystemResolverNetworkTestSuite{})
+}
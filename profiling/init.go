@@ -0,0 +1,26 @@
+// Profiling package has integrations with continuous profiling systems.
+//
+// Unlike on-demand profiling, continuous profiling captures profiles of a
+// running process all the time and ships them to a remote storage. This
+// helps to spot slow performance regressions over time.
+package profiling
+
+import "time"
+
+const (
+	// DefaultAppName is a default name of application which is used to
+	// group profiles.
+	DefaultAppName = "mtg"
+
+	// DefaultUploadEach is a default duration of each CPU profile. Heap
+	// profile is captured and uploaded with the same period.
+	DefaultUploadEach = 15 * time.Second
+
+	// PyroscopeSampleRate is a sampling rate of CPU profiler in Hz. This is
+	// a default rate of Go runtime.
+	PyroscopeSampleRate = 100
+
+	// PyroscopeSpyName is a name of profiler which is reported to
+	// Pyroscope.
+	PyroscopeSpyName = "gospy"
+)
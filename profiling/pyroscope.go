@@ -0,0 +1,181 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IceCodeNew/mtg/mtglib"
+)
+
+// Pyroscope captures CPU and heap profiles and pushes them to Pyroscope
+// server with its HTTP ingestion API.
+//
+// CPU profile is collected continuously: each profile covers a whole
+// upload period. Heap profile is a snapshot taken at the end of each
+// period. If profile cannot be captured or uploaded, it is skipped: there
+// are no retries.
+type Pyroscope struct {
+	ctx        context.Context
+	ctxCancel  context.CancelFunc
+	logger     mtglib.Logger
+	http       *http.Client
+	ingestURL  string
+	name       string
+	uploadEach time.Duration
+}
+
+// Shutdown stops profiling.
+func (p *Pyroscope) Shutdown() {
+	p.ctxCancel()
+}
+
+// Run starts profiling.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (p *Pyroscope) Run() {
+	for {
+		from := time.Now()
+		cpuProfile, err := p.collectCPUProfile()
+		until := time.Now()
+
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			p.logger.WarningError("cannot collect cpu profile", err)
+		} else if err := p.upload(cpuProfile, from, until); err != nil {
+			p.logger.WarningError("cannot upload cpu profile", err)
+		}
+
+		heapProfile := &bytes.Buffer{}
+
+		if err := pprof.Lookup("heap").WriteTo(heapProfile, 0); err != nil {
+			p.logger.WarningError("cannot collect heap profile", err)
+		} else if err := p.upload(heapProfile.Bytes(), until, until); err != nil {
+			p.logger.WarningError("cannot upload heap profile", err)
+		}
+	}
+}
+
+func (p *Pyroscope) collectCPUProfile() ([]byte, error) {
+	timer := time.NewTimer(p.uploadEach)
+	defer timer.Stop()
+
+	profile := &bytes.Buffer{}
+	// only one CPU profile can be active in the process. If somebody else
+	// holds it, we still wait for a period to avoid busy loop.
+	err := pprof.StartCPUProfile(profile)
+
+	select {
+	case <-p.ctx.Done():
+	case <-timer.C:
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot start cpu profile: %w", err)
+	}
+
+	pprof.StopCPUProfile()
+
+	return profile.Bytes(), nil
+}
+
+func (p *Pyroscope) upload(profile []byte, from, until time.Time) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return fmt.Errorf("cannot create form file: %w", err)
+	}
+
+	part.Write(profile) //nolint: errcheck
+	writer.Close()
+
+	query := url.Values{}
+	query.Set("name", p.name)
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	query.Set("until", strconv.FormatInt(until.Unix(), 10))
+	query.Set("spyName", PyroscopeSpyName)
+	query.Set("sampleRate", strconv.Itoa(PyroscopeSampleRate))
+
+	request, err := http.NewRequestWithContext(p.ctx, http.MethodPost,
+		p.ingestURL+"?"+query.Encode(), body)
+	if err != nil {
+		panic(err)
+	}
+
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	response, err := p.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("cannot send profile: %w", err)
+	}
+
+	defer func() {
+		io.Copy(io.Discard, response.Body) //nolint: errcheck
+		response.Body.Close()
+	}()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// NewPyroscope creates a new profiler which pushes profiles to Pyroscope
+// server at a given base URL. Basic auth credentials can be set as
+// userinfo part of this URL.
+//
+// Profiles are grouped by appName. If instanceName is not empty, it is
+// attached as 'instance' tag. 0 uploadEach means a default value.
+func NewPyroscope(logger mtglib.Logger, network mtglib.Network,
+	serverURL, appName, instanceName string, uploadEach time.Duration,
+) (*Pyroscope, error) {
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect url %s: %w", serverURL, err)
+	}
+
+	if parsedURL.Host == "" {
+		return nil, fmt.Errorf("incorrect url %s", serverURL)
+	}
+
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/") + "/ingest"
+	parsedURL.RawQuery = ""
+
+	if appName == "" {
+		appName = DefaultAppName
+	}
+
+	if instanceName != "" {
+		appName += "{instance=" + instanceName + "}"
+	}
+
+	if uploadEach == 0 {
+		uploadEach = DefaultUploadEach
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Pyroscope{
+		ctx:        ctx,
+		ctxCancel:  cancel,
+		logger:     logger.Named("pyroscope"),
+		http:       network.MakeHTTPClient(nil),
+		ingestURL:  parsedURL.String(),
+		name:       appName,
+		uploadEach: uploadEach,
+	}, nil
+}
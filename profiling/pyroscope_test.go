@@ -0,0 +1,111 @@
+package profiling_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/internal/testlib"
+	"github.com/IceCodeNew/mtg/logger"
+	"github.com/IceCodeNew/mtg/profiling"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type pyroscopeUpload struct {
+	path    string
+	query   url.Values
+	profile []byte
+}
+
+type PyroscopeTestSuite struct {
+	suite.Suite
+
+	networkMock *testlib.MtglibNetworkMock
+	httpServer  *httptest.Server
+
+	uploadsMutex sync.Mutex
+	uploads      []pyroscopeUpload
+}
+
+func (suite *PyroscopeTestSuite) SetupTest() {
+	suite.uploads = nil
+	suite.httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		file, _, err := req.FormFile("profile")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		defer file.Close()
+
+		content, _ := io.ReadAll(file)
+
+		suite.uploadsMutex.Lock()
+		defer suite.uploadsMutex.Unlock()
+
+		suite.uploads = append(suite.uploads, pyroscopeUpload{
+			path:    req.URL.Path,
+			query:   req.URL.Query(),
+			profile: content,
+		})
+	}))
+
+	suite.networkMock = &testlib.MtglibNetworkMock{}
+	suite.networkMock.
+		On("MakeHTTPClient", mock.Anything).
+		Maybe().
+		Return(suite.httpServer.Client())
+}
+
+func (suite *PyroscopeTestSuite) TearDownTest() {
+	suite.httpServer.Close()
+	suite.networkMock.AssertExpectations(suite.T())
+}
+
+func (suite *PyroscopeTestSuite) Uploads() []pyroscopeUpload {
+	suite.uploadsMutex.Lock()
+	defer suite.uploadsMutex.Unlock()
+
+	return append([]pyroscopeUpload{}, suite.uploads...)
+}
+
+func (suite *PyroscopeTestSuite) TestIncorrectURL() {
+	_, err := profiling.NewPyroscope(logger.NewNoopLogger(), suite.networkMock,
+		"/ingest", "", "", 0)
+	suite.Error(err)
+}
+
+func (suite *PyroscopeTestSuite) TestUpload() {
+	profiler, err := profiling.NewPyroscope(logger.NewNoopLogger(), suite.networkMock,
+		suite.httpServer.URL+"/pyroscope/", "", "instance", 50*time.Millisecond)
+	suite.NoError(err)
+
+	go profiler.Run()
+
+	defer profiler.Shutdown()
+
+	suite.Eventually(func() bool {
+		return len(suite.Uploads()) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, v := range suite.Uploads()[:2] {
+		suite.Equal("/pyroscope/ingest", v.path)
+		suite.Equal("mtg{instance=instance}", v.query.Get("name"))
+		suite.Equal(profiling.PyroscopeSpyName, v.query.Get("spyName"))
+		suite.Equal("100", v.query.Get("sampleRate"))
+		suite.NotEmpty(v.query.Get("from"))
+		suite.NotEmpty(v.query.Get("until"))
+		suite.NotEmpty(v.profile)
+	}
+}
+
+func TestPyroscope(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &PyroscopeTestSuite{})
+}
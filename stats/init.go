@@ -6,6 +6,8 @@
 // different monitoring system or time series databases.
 package stats
 
+import "time"
+
 const (
 	// DefaultMetricPrefix defines a base prefix for all metrics.
 	DefaultMetricPrefix = "mtg"
@@ -18,6 +20,18 @@ const (
 	// observer.
 	DefaultStatsdTagFormat = "datadog"
 
+	// DefaultPrometheusTextfileInterval defines a default time period
+	// between writes of Prometheus textfile.
+	DefaultPrometheusTextfileInterval = 15 * time.Second
+
+	// StatsdSendErrorsReportEach defines how often statsd observer reports
+	// its own send errors.
+	StatsdSendErrorsReportEach = 10 * time.Second
+
+	// DefaultOriginMaxASNs defines a default max number of autonomous
+	// systems which are tracked in traffic metrics.
+	DefaultOriginMaxASNs = 100
+
 	// MetricClientConnections defines a metric which is responsible for a
 	// number of currently active connections established by client.
 	//
@@ -55,6 +69,18 @@ const (
 	//                   | 'to_client' and 'from_client'
 	MetricTelegramTraffic = "telegram_traffic"
 
+	// MetricDCTraffic defines a metric for traffic (in bytes) that is
+	// proxied to and from each Telegram DC. Unlike telegram_traffic, it
+	// is collected by a proxy and reported periodically so it does not
+	// depend on telegram IP addresses.
+	//
+	//     Type: counter
+	//     Tags:
+	//       dc        | Index of the datacenter
+	//       direction | Direction of the traffc flow. Values are
+	//                 | 'to_client' and 'from_client'
+	MetricDCTraffic = "traffic_bytes_total"
+
 	// MetricDomainFrontingTraffic defines a metric for traffic (in bytes)
 	// that is sent to and from fronting domain.
 	//
@@ -64,6 +90,16 @@ const (
 	//                   | 'to_client' and 'from_client'
 	MetricDomainFrontingTraffic = "domain_fronting_traffic"
 
+	// MetricClientTraffic defines a metric for raw traffic (in bytes) that
+	// is sent to and from clients, including all framing and handshakes.
+	// Compare it with telegram_traffic to get an overhead of transport.
+	//
+	//     Type: counter
+	//     Tags:
+	//       direction   | Direction of the traffc flow. Values are
+	//                   | 'to_client' and 'from_client'
+	MetricClientTraffic = "client_traffic"
+
 	// MetricDomainFronting defines a metric for a number of domain
 	// fronting routing events.
 	//
@@ -96,6 +132,255 @@ const (
 	//       ip_list | 'allowlist' or 'blocklist'
 	MetricIPListSize = "iplist_size"
 
+	// MetricConfiguredSecrets defines a metric for a number of secrets
+	// proxy currently serves.
+	//
+	//     Type: gauge
+	MetricConfiguredSecrets = "configured_secrets"
+
+	// MetricBannedIPs defines a metric for a number of client IP addresses
+	// which are currently banned because of too many failed handshakes.
+	//
+	//     Type: gauge
+	MetricBannedIPs = "banned_ips"
+
+	// MetricActiveConnections defines a metric for a number of active
+	// client connections which have passed a handshake with some secret.
+	//
+	//     Type: gauge
+	//     Tags:
+	//       secret_fp | A fingerprint of the secret.
+	MetricActiveConnections = "active_connections"
+
+	// MetricDNSQueries defines a metric for a count of DNS-over-HTTPS
+	// queries. Answers taken from the cache are not counted there.
+	//
+	//     Type: counter
+	//     Tags:
+	//       doh_resolver | An address of DOH or DOT resolver.
+	//       dns_result   | 'ok' or 'failed'
+	MetricDNSQueries = "dns_queries"
+
+	// MetricDNSQueryDuration defines a metric for a time spent on
+	// DNS-over-HTTPS queries.
+	//
+	//     Type: histogram (timing for statsd)
+	//     Tags:
+	//       doh_resolver | An address of DOH or DOT resolver.
+	MetricDNSQueryDuration = "dns_query_duration"
+
+	// MetricDNSCache defines a metric for a count of DNS lookups which were
+	// served from the cache or not. It can be used to calculate a cache
+	// hit ratio.
+	//
+	//     Type: counter
+	//     Tags:
+	//       dns_cache | 'hit' or 'miss'
+	MetricDNSCache = "dns_cache"
+
+	// MetricDCEndpointFailover defines a metric for a count of events, when
+	// mtg could not connect to the primary endpoint of Telegram DC and had
+	// to use a secondary one. A rising count signals that a primary IP is
+	// blocked or degraded.
+	//
+	//     Type: counter
+	//     Tags:
+	//       dc | Index of the datacenter.
+	MetricDCEndpointFailover = "dc_endpoint_failover"
+
+	// MetricDCConnsLimited defines a metric for a count of streams which
+	// had to wait for a free connection slot of Telegram DC.
+	//
+	//     Type: counter
+	//     Tags:
+	//       dc | Index of the datacenter.
+	MetricDCConnsLimited = "dc_conns_limited"
+
+	// MetricProbeTarpitted defines a metric for a count of connections from
+	// blocklisted or not allowlisted IP addresses which were held in
+	// tarpit.
+	//
+	//     Type: counter
+	MetricProbeTarpitted = "probe_tarpitted"
+
+	// MetricMissingSNI defines a metric for a count of client hellos with
+	// a valid secret but without SNI.
+	//
+	//     Type: counter
+	MetricMissingSNI = "missing_sni"
+
+	// MetricIncompleteHandshakes defines a metric for a count of client
+	// connections which were closed before a handshake was completed.
+	// Explicitly rejected connections are not counted here.
+	//
+	//     Type: counter
+	MetricIncompleteHandshakes = "incomplete_handshakes"
+
+	// MetricIdleTimeouts defines a metric for a count of streams which
+	// were closed because no data was transmitted within an idle timeout.
+	//
+	//     Type: counter
+	MetricIdleTimeouts = "idle_timeouts"
+
+	// MetricCountryTraffic defines a metric for a count of bytes
+	// transmitted to/from clients grouped by a country of the client. It is
+	// reported only if GeoIP databases are configured.
+	//
+	//     Type: counter
+	//     Tags:
+	//       country   | ISO code of the country, 'other' or 'unknown'.
+	//       direction | A direction of the traffic flow.
+	MetricCountryTraffic = "country_traffic"
+
+	// MetricASNTraffic defines a metric for a count of bytes transmitted
+	// to/from clients grouped by an autonomous system of the client. It is
+	// reported only if GeoIP databases are configured.
+	//
+	//     Type: counter
+	//     Tags:
+	//       asn       | A number of autonomous system, 'other' or 'unknown'.
+	//       direction | A direction of the traffic flow.
+	MetricASNTraffic = "asn_traffic"
+
+	// MetricHandshakeTooLarge defines a metric for a count of connections
+	// which were closed because client hello was larger than allowed.
+	//
+	//     Type: counter
+	MetricHandshakeTooLarge = "handshake_too_large"
+
+	// MetricGeoIPLoadFailures defines a metric for a count of failed
+	// attempts to load or reload GeoIP databases.
+	//
+	//     Type: counter
+	//     Tags:
+	//       geoip_db | A name of the database: 'country' or 'asn'.
+	MetricGeoIPLoadFailures = "geoip_load_failures"
+
+	// MetricGeoIPUpdates defines a metric for a count of GeoIP database
+	// downloads from remote URLs.
+	//
+	//     Type: counter
+	//     Tags:
+	//       geoip_db      | A name of the database: 'country' or 'asn'.
+	//       update_result | 'ok' or 'failed'
+	MetricGeoIPUpdates = "geoip_updates"
+
+	// MetricUpstreamMirrorDials defines a metric for a count of dials
+	// made by traffic mirroring. Each mirrored connection produces 2
+	// dials: with primary and mirror dialers.
+	//
+	//     Type: counter
+	//     Tags:
+	//       upstream    | 'primary' or 'mirror'
+	//       dial_result | 'ok' or 'failed'
+	MetricUpstreamMirrorDials = "upstream_mirror_dials"
+
+	// MetricUpstreamMirrorDialDuration defines a metric for a time spent on
+	// dials made by traffic mirroring.
+	//
+	//     Type: histogram (timing for statsd)
+	//     Tags:
+	//       upstream | 'primary' or 'mirror'
+	MetricUpstreamMirrorDialDuration = "upstream_mirror_dial_duration"
+
+	// MetricTelegramDialDuration defines a metric for a time spent on
+	// dials to Telegram DCs. A dial is measured as a whole: if a primary
+	// endpoint of the DC fails, time spent on fallback endpoints is
+	// included.
+	//
+	//     Type: histogram (timing for statsd)
+	//     Tags:
+	//       dc          | Index of the datacenter
+	//       ip_family   | 'ipv4' or 'ipv6'. Failed dials are reported
+	//                   | as 'unknown'.
+	//       dial_result | 'ok' or 'failed'
+	MetricTelegramDialDuration = "telegram_dial_duration"
+
+	// MetricUpstreamProxyEjected defines a metric which is 1 if load
+	// balanced upstream proxy is ejected from rotation and 0 if it is
+	// healthy.
+	//
+	//     Type: gauge
+	//     Tags:
+	//       proxy | host:port of the proxy.
+	MetricUpstreamProxyEjected = "upstream_proxy_ejected"
+
+	// MetricSNIConnections defines a metric for a count of client
+	// connections which have passed a handshake grouped by a hostname from
+	// SNI. This hostname is always a hostname of some configured secret.
+	//
+	//     Type: counter
+	//     Tags:
+	//       sni | A hostname or 'unknown' if client has not sent SNI.
+	MetricSNIConnections = "sni_connections"
+
+	// MetricClosedStreams defines a metric for a count of finished
+	// streams grouped by a reason why they were closed.
+	//
+	//     Type: counter
+	//     Tags:
+	//       close_reason | A value of mtglib.CloseReason.
+	MetricClosedStreams = "closed_streams"
+
+	// MetricStatsdSendErrors defines a metric for a count of metrics
+	// which statsd client failed to send. This metric is reported only to
+	// statsd itself.
+	//
+	//     Type: counter
+	//     Tags:
+	//       statsd_error | 'write' if UDP write has failed, 'overflow' if
+	//                      send queue was full.
+	MetricStatsdSendErrors = "statsd_send_errors"
+
+	// MetricDNSCacheSize defines a metric for a number of entries in DNS
+	// cache.
+	//
+	//     Type: gauge
+	MetricDNSCacheSize = "dns_cache_size"
+
+	// MetricAntiReplayFillRatio defines a metric for an approximate share
+	// of anti-replay cache which is in use, from 0 to 1.
+	//
+	//     Type: gauge
+	MetricAntiReplayFillRatio = "antireplay_fill_ratio"
+
+	// MetricAntiReplayFalsePositiveRate defines a metric for an estimated
+	// probability that a new handshake is reported as a replay attack.
+	//
+	//     Type: gauge
+	MetricAntiReplayFalsePositiveRate = "antireplay_false_positive_rate"
+
+	// MetricDNSCacheEvictions defines a metric for a count of entries
+	// evicted from DNS cache either because they are expired or because
+	// the cache is full.
+	//
+	//     Type: counter
+	MetricDNSCacheEvictions = "dns_cache_evictions"
+
+	// MetricActiveStreams defines a metric for a number of concurrent
+	// streams maintained by the proxy itself. This metric is reported only
+	// to statsd: Prometheus has client_connections for that.
+	//
+	//     Type: gauge
+	MetricActiveStreams = "streams.active"
+
+	// MetricClientRateLimited defines a metric for a count of connections
+	// which were rejected because their client IP addresses open new
+	// connections too often.
+	//
+	//     Type: counter
+	MetricClientRateLimited = "client_rate_limited"
+
+	// MetricHandshakeDuration defines a metric for a time spent on client
+	// handshakes: from accepting a connection to a completed or failed
+	// FakeTLS and obfuscated2 handshake. Slow handshakes usually signal
+	// some DPI interference.
+	//
+	//     Type: histogram (timing for statsd)
+	//     Tags:
+	//       handshake_result | 'ok' or 'failed'
+	MetricHandshakeDuration = "handshake_duration"
+
 	// TagIPFamily defines a name of the 'ip_family' tag and all values.
 	TagIPFamily = "ip_family"
 
@@ -105,6 +390,10 @@ const (
 	// TagIPFamilyIPv6 defines a value of 'ip_family' of IPv6.
 	TagIPFamilyIPv6 = "ipv6"
 
+	// TagIPFamilyUnknown defines a value of 'ip_family' if a family is not
+	// known, for example, of a failed dial.
+	TagIPFamilyUnknown = "unknown"
+
 	// TagTelegramIP defines a name of the 'telegram_ip' tag.
 	TagTelegramIP = "telegram_ip"
 
@@ -122,6 +411,45 @@ const (
 	// Telegram.
 	TagDirectionFromClient = "from_client"
 
+	// TagSecretFingerprint defines a name of the 'secret_fp' tag.
+	TagSecretFingerprint = "secret_fp"
+
+	// TagDOHResolver defines a name of the 'doh_resolver' tag.
+	TagDOHResolver = "doh_resolver"
+
+	// TagDNSResult defines a name of the 'dns_result' tag and all values.
+	TagDNSResult = "dns_result"
+
+	// TagDNSResultOK defines a value of 'dns_result' of successful query.
+	TagDNSResultOK = "ok"
+
+	// TagDNSResultFailed defines a value of 'dns_result' of failed query.
+	TagDNSResultFailed = "failed"
+
+	// TagDNSCache defines a name of the 'dns_cache' tag and all values.
+	TagDNSCache = "dns_cache"
+
+	// TagDNSCacheHit defines a value of 'dns_cache' if answer was taken from
+	// the cache.
+	TagDNSCacheHit = "hit"
+
+	// TagDNSCacheMiss defines a value of 'dns_cache' if real query was made.
+	TagDNSCacheMiss = "miss"
+
+	// TagCountry defines a name of the 'country' tag.
+	TagCountry = "country"
+
+	// TagASN defines a name of the 'asn' tag.
+	TagASN = "asn"
+
+	// TagOriginOther defines a value of 'country' and 'asn' tags if origin
+	// of the client is known but it is not tracked.
+	TagOriginOther = "other"
+
+	// TagOriginUnknown defines a value of 'country' and 'asn' tags if
+	// origin of the client is unknown.
+	TagOriginUnknown = "unknown"
+
 	// TagIPList defines a name of the 'ip_list' and all values.
 	TagIPList = "ip_list"
 
@@ -130,4 +458,76 @@ const (
 
 	// TagIPListBlock defines a value of 'ip_list' of blocklist.
 	TagIPListBlock = "blocklist"
+
+	// TagGeoIPDatabase defines a name of the 'geoip_db' tag.
+	TagGeoIPDatabase = "geoip_db"
+
+	// TagUpstream defines a name of the 'upstream' tag and all values.
+	TagUpstream = "upstream"
+
+	// TagUpstreamPrimary defines a value of 'upstream' of primary dialer.
+	TagUpstreamPrimary = "primary"
+
+	// TagUpstreamMirror defines a value of 'upstream' of mirror dialer.
+	TagUpstreamMirror = "mirror"
+
+	// TagDialResult defines a name of the 'dial_result' tag and all values.
+	TagDialResult = "dial_result"
+
+	// TagDialResultOK defines a value of 'dial_result' of successful dial.
+	TagDialResultOK = "ok"
+
+	// TagDialResultFailed defines a value of 'dial_result' of failed dial.
+	TagDialResultFailed = "failed"
+
+	// TagHandshakeResult defines a name of the 'handshake_result' tag and
+	// all values.
+	TagHandshakeResult = "handshake_result"
+
+	// TagHandshakeResultOK defines a value of 'handshake_result' of
+	// completed handshake.
+	TagHandshakeResultOK = "ok"
+
+	// TagHandshakeResultFailed defines a value of 'handshake_result' of
+	// failed handshake.
+	TagHandshakeResultFailed = "failed"
+
+	// TagUpdateResult defines a name of the 'update_result' tag and all
+	// values.
+	TagUpdateResult = "update_result"
+
+	// TagUpdateResultOK defines a value of 'update_result' of successful
+	// update.
+	TagUpdateResultOK = "ok"
+
+	// TagUpdateResultFailed defines a value of 'update_result' of failed
+	// update.
+	TagUpdateResultFailed = "failed"
+
+	// TagProxy defines a name of the 'proxy' tag.
+	TagProxy = "proxy"
+
+	// TagSNI defines a name of the 'sni' tag.
+	TagSNI = "sni"
+
+	// TagSNIUnknown defines a value of 'sni' if client has not sent SNI.
+	TagSNIUnknown = "unknown"
+
+	// TagCloseReason defines a name of the 'close_reason' tag.
+	TagCloseReason = "close_reason"
+
+	// TagStatsdError defines a name of the 'statsd_error' tag.
+	TagStatsdError = "statsd_error"
+
+	// TagStatsdErrorWrite defines a value of 'statsd_error' if a packet
+	// could not be written to UDP socket.
+	TagStatsdErrorWrite = "write"
+
+	// TagStatsdErrorOverflow defines a value of 'statsd_error' if a packet
+	// was dropped because send queue was full.
+	TagStatsdErrorOverflow = "overflow"
+
+	// TagInstanceName defines a name of the 'instance_name' tag. This tag
+	// is added to all metrics if instance name is set.
+	TagInstanceName = "instance_name"
 )
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/IceCodeNew/mtg/geoip"
+)
+
+// OriginLookup resolves an origin of the IP address. [geoip.DB] implements
+// this interface.
+type OriginLookup interface {
+	Lookup(ip net.IP) geoip.Record
+}
+
+// OriginOpts defines how client traffic is grouped by its origin: a country
+// and an autonomous system of the client.
+//
+// Each tracked country and ASN produces a new time series so this
+// structure defines how cardinality of such metrics is bound. Everything
+// which is not tracked is accounted as 'other'.
+type OriginOpts struct {
+	// Lookup resolves an origin of the client IP address. If it is not
+	// set, traffic is not grouped by origin at all.
+	Lookup OriginLookup
+
+	// Countries is a list of ISO codes of tracked countries. If it is
+	// empty, all countries are tracked.
+	Countries []string
+
+	// ASNs is a list of tracked autonomous systems. If it is empty, first
+	// MaxASNs distinct autonomous systems are tracked.
+	ASNs []uint
+
+	// MaxASNs defines a max number of tracked autonomous systems if ASNs
+	// list is empty. Default is DefaultOriginMaxASNs.
+	MaxASNs uint
+}
+
+type originTracker struct {
+	lookup    OriginLookup
+	countries map[string]struct{}
+	asns      map[uint]struct{}
+	maxASNs   int
+	isFixed   bool
+	mutex     sync.Mutex
+}
+
+func (o *originTracker) Resolve(ip net.IP) (string, string) {
+	record := o.lookup.Lookup(ip)
+
+	return o.resolveCountry(record.Country), o.resolveASN(record.ASN)
+}
+
+func (o *originTracker) resolveCountry(country string) string {
+	if country == "" {
+		return TagOriginUnknown
+	}
+
+	if len(o.countries) == 0 {
+		return country
+	}
+
+	if _, ok := o.countries[country]; ok {
+		return country
+	}
+
+	return TagOriginOther
+}
+
+func (o *originTracker) resolveASN(asn uint) string {
+	if asn == 0 {
+		return TagOriginUnknown
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if _, ok := o.asns[asn]; !ok {
+		if o.isFixed || len(o.asns) >= o.maxASNs {
+			return TagOriginOther
+		}
+
+		o.asns[asn] = struct{}{}
+	}
+
+	return strconv.FormatUint(uint64(asn), 10) //nolint: gomnd
+}
+
+func newOriginTracker(opts OriginOpts) *originTracker {
+	if opts.Lookup == nil {
+		return nil
+	}
+
+	tracker := &originTracker{
+		lookup:    opts.Lookup,
+		countries: make(map[string]struct{}, len(opts.Countries)),
+		asns:      make(map[uint]struct{}, len(opts.ASNs)),
+		maxASNs:   DefaultOriginMaxASNs,
+		isFixed:   len(opts.ASNs) > 0,
+	}
+
+	if opts.MaxASNs > 0 {
+		tracker.maxASNs = int(opts.MaxASNs)
+	}
+
+	for _, v := range opts.Countries {
+		tracker.countries[strings.ToUpper(v)] = struct{}{}
+	}
+
+	for _, v := range opts.ASNs {
+		tracker.asns[v] = struct{}{}
+	}
+
+	return tracker
+}
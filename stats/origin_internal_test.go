@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"net"
+	"testing"
+
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/stretchr/testify/suite"
+)
+
+type originLookupMock map[string]geoip.Record
+
+func (o originLookupMock) Lookup(ip net.IP) geoip.Record {
+	return o[ip.String()]
+}
+
+type OriginTrackerTestSuite struct {
+	suite.Suite
+
+	lookup originLookupMock
+}
+
+func (suite *OriginTrackerTestSuite) SetupSuite() {
+	suite.lookup = originLookupMock{
+		"10.0.0.1": {Country: "GB", ASN: 1},
+		"10.0.0.2": {Country: "SE", ASN: 2},
+		"10.0.0.3": {Country: "DE", ASN: 3},
+	}
+}
+
+func (suite *OriginTrackerTestSuite) Resolve(tracker *originTracker, ip string) []string {
+	country, asn := tracker.Resolve(net.ParseIP(ip))
+
+	return []string{country, asn}
+}
+
+func (suite *OriginTrackerTestSuite) TestDisabled() {
+	suite.Nil(newOriginTracker(OriginOpts{}))
+}
+
+func (suite *OriginTrackerTestSuite) TestUnknown() {
+	tracker := newOriginTracker(OriginOpts{Lookup: suite.lookup})
+
+	suite.Equal([]string{TagOriginUnknown, TagOriginUnknown},
+		suite.Resolve(tracker, "127.0.0.1"))
+}
+
+func (suite *OriginTrackerTestSuite) TestAll() {
+	tracker := newOriginTracker(OriginOpts{Lookup: suite.lookup})
+
+	suite.Equal([]string{"GB", "1"}, suite.Resolve(tracker, "10.0.0.1"))
+	suite.Equal([]string{"SE", "2"}, suite.Resolve(tracker, "10.0.0.2"))
+	suite.Equal([]string{"DE", "3"}, suite.Resolve(tracker, "10.0.0.3"))
+}
+
+func (suite *OriginTrackerTestSuite) TestCountries() {
+	tracker := newOriginTracker(OriginOpts{
+		Lookup:    suite.lookup,
+		Countries: []string{"gb", "SE"},
+	})
+
+	suite.Equal("GB", suite.Resolve(tracker, "10.0.0.1")[0])
+	suite.Equal("SE", suite.Resolve(tracker, "10.0.0.2")[0])
+	suite.Equal(TagOriginOther, suite.Resolve(tracker, "10.0.0.3")[0])
+}
+
+func (suite *OriginTrackerTestSuite) TestFixedASNs() {
+	tracker := newOriginTracker(OriginOpts{
+		Lookup:  suite.lookup,
+		ASNs:    []uint{2},
+		MaxASNs: 10,
+	})
+
+	suite.Equal(TagOriginOther, suite.Resolve(tracker, "10.0.0.1")[1])
+	suite.Equal("2", suite.Resolve(tracker, "10.0.0.2")[1])
+	suite.Equal(TagOriginOther, suite.Resolve(tracker, "10.0.0.3")[1])
+}
+
+func (suite *OriginTrackerTestSuite) TestMaxASNs() {
+	tracker := newOriginTracker(OriginOpts{
+		Lookup:  suite.lookup,
+		MaxASNs: 2,
+	})
+
+	suite.Equal("1", suite.Resolve(tracker, "10.0.0.1")[1])
+	suite.Equal("2", suite.Resolve(tracker, "10.0.0.2")[1])
+	suite.Equal(TagOriginOther, suite.Resolve(tracker, "10.0.0.3")[1])
+	suite.Equal("1", suite.Resolve(tracker, "10.0.0.1")[1])
+}
+
+func TestOriginTracker(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &OriginTrackerTestSuite{})
+}
@@ -2,9 +2,14 @@ package stats
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/IceCodeNew/mtg/events"
 	"github.com/IceCodeNew/mtg/mtglib"
@@ -12,6 +17,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// handshakeDurationBuckets cover both healthy handshakes which take a few
+// milliseconds and stalled ones which take seconds.
+var handshakeDurationBuckets = []float64{ //nolint: gochecknoglobals
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30,
+}
+
 type prometheusProcessor struct {
 	streams map[string]*streamInfo
 	factory *PrometheusFactory
@@ -26,6 +37,10 @@ func (p prometheusProcessor) EventStart(evt mtglib.EventStart) {
 		info.tags[TagIPFamily] = TagIPFamilyIPv6
 	}
 
+	if p.factory.origin != nil {
+		info.tags[TagCountry], info.tags[TagASN] = p.factory.origin.Resolve(evt.RemoteIP)
+	}
+
 	p.streams[evt.StreamID()] = info
 
 	p.factory.metricClientConnections.
@@ -78,9 +93,20 @@ func (p prometheusProcessor) EventTraffic(evt mtglib.EventTraffic) {
 			WithLabelValues(info.tags[TagTelegramIP], info.tags[TagDC], direction).
 			Add(float64(evt.Traffic))
 	}
+
+	if p.factory.origin != nil {
+		p.factory.metricCountryTraffic.
+			WithLabelValues(info.tags[TagCountry], direction).
+			Add(float64(evt.Traffic))
+		p.factory.metricASNTraffic.
+			WithLabelValues(info.tags[TagASN], direction).
+			Add(float64(evt.Traffic))
+	}
 }
 
 func (p prometheusProcessor) EventFinish(evt mtglib.EventFinish) {
+	p.factory.metricClosedStreams.WithLabelValues(string(evt.Reason)).Inc()
+
 	info, ok := p.streams[evt.StreamID()]
 	if !ok {
 		return
@@ -95,6 +121,12 @@ func (p prometheusProcessor) EventFinish(evt mtglib.EventFinish) {
 		WithLabelValues(info.tags[TagIPFamily]).
 		Dec()
 
+	if fingerprint, ok := info.tags[TagSecretFingerprint]; ok {
+		p.factory.metricActiveConnections.
+			WithLabelValues(fingerprint).
+			Dec()
+	}
+
 	if info.isDomainFronted {
 		p.factory.metricDomainFrontingConnections.
 			WithLabelValues(info.tags[TagIPFamily]).
@@ -132,6 +164,174 @@ func (p prometheusProcessor) EventIPListSize(evt mtglib.EventIPListSize) {
 	p.factory.metricIPListSize.WithLabelValues(tag).Set(float64(evt.Size))
 }
 
+func (p prometheusProcessor) EventSecretMatched(evt mtglib.EventSecretMatched) {
+	info, ok := p.streams[evt.StreamID()]
+	if !ok {
+		return
+	}
+
+	info.tags[TagSecretFingerprint] = evt.SecretFingerprint
+
+	p.factory.metricActiveConnections.
+		WithLabelValues(evt.SecretFingerprint).
+		Inc()
+	p.factory.metricSNIConnections.WithLabelValues(getSNI(evt)).Inc()
+}
+
+func (p prometheusProcessor) EventSecretsConfigured(evt mtglib.EventSecretsConfigured) {
+	p.factory.metricConfiguredSecrets.Set(float64(evt.Count))
+}
+
+func (p prometheusProcessor) EventDNSQuery(evt mtglib.EventDNSQuery) {
+	if evt.IsCached {
+		p.factory.metricDNSCache.WithLabelValues(TagDNSCacheHit).Inc()
+
+		return
+	}
+
+	result := TagDNSResultOK
+	if evt.IsFailed {
+		result = TagDNSResultFailed
+	}
+
+	p.factory.metricDNSCache.WithLabelValues(TagDNSCacheMiss).Inc()
+	p.factory.metricDNSQueries.WithLabelValues(evt.Resolver, result).Inc()
+	p.factory.metricDNSQueryDuration.
+		WithLabelValues(evt.Resolver).
+		Observe(evt.Duration.Seconds())
+}
+
+func (p prometheusProcessor) EventDCEndpointFailover(evt mtglib.EventDCEndpointFailover) {
+	p.factory.metricDCEndpointFailover.WithLabelValues(strconv.Itoa(evt.DC)).Inc()
+}
+
+func (p prometheusProcessor) EventProbeTarpitted(_ mtglib.EventProbeTarpitted) {
+	p.factory.metricProbeTarpitted.Inc()
+}
+
+func (p prometheusProcessor) EventDNSCacheUpdated(evt mtglib.EventDNSCacheUpdated) {
+	p.factory.metricDNSCacheSize.Set(float64(evt.Size))
+	p.factory.metricDNSCacheEvictions.Add(float64(evt.Evicted))
+}
+
+func (p prometheusProcessor) EventHandshakeTooLarge(_ mtglib.EventHandshakeTooLarge) {
+	p.factory.metricHandshakeTooLarge.Inc()
+}
+
+func (p prometheusProcessor) EventGeoIPLoadFailed(evt mtglib.EventGeoIPLoadFailed) {
+	p.factory.metricGeoIPLoadFailures.WithLabelValues(evt.Database).Inc()
+}
+
+func (p prometheusProcessor) EventUpstreamMirrored(evt mtglib.EventUpstreamMirrored) {
+	p.reportMirrorDial(TagUpstreamPrimary, evt.PrimaryDuration, evt.PrimaryFailed)
+	p.reportMirrorDial(TagUpstreamMirror, evt.MirrorDuration, evt.MirrorFailed)
+}
+
+func (p prometheusProcessor) reportMirrorDial(upstream string, duration time.Duration, failed bool) {
+	result := TagDialResultOK
+
+	if failed {
+		result = TagDialResultFailed
+	}
+
+	p.factory.metricUpstreamMirrorDials.WithLabelValues(upstream, result).Inc()
+	p.factory.metricUpstreamMirrorDialDuration.WithLabelValues(upstream).Observe(duration.Seconds())
+}
+
+func (p prometheusProcessor) EventGeoIPUpdated(evt mtglib.EventGeoIPUpdated) {
+	result := TagUpdateResultOK
+
+	if evt.Failed {
+		result = TagUpdateResultFailed
+	}
+
+	p.factory.metricGeoIPUpdates.WithLabelValues(evt.Database, result).Inc()
+}
+
+func (p prometheusProcessor) EventClientTraffic(evt mtglib.EventClientTraffic) {
+	p.factory.metricClientTraffic.
+		WithLabelValues(getClientDirection(evt.IsRead)).
+		Add(float64(evt.Traffic))
+}
+
+func (p prometheusProcessor) EventIPBanned(_ mtglib.EventIPBanned) {
+	p.factory.metricBannedIPs.Inc()
+}
+
+func (p prometheusProcessor) EventIPUnbanned(_ mtglib.EventIPUnbanned) {
+	p.factory.metricBannedIPs.Dec()
+}
+
+func (p prometheusProcessor) EventMissingSNI(_ mtglib.EventMissingSNI) {
+	p.factory.metricMissingSNI.Inc()
+}
+
+func (p prometheusProcessor) EventIncompleteHandshake(_ mtglib.EventIncompleteHandshake) {
+	p.factory.metricIncompleteHandshakes.Inc()
+}
+
+func (p prometheusProcessor) EventUpstreamProxyStateChanged(evt mtglib.EventUpstreamProxyStateChanged) {
+	value := 0.0
+
+	if evt.Ejected {
+		value = 1
+	}
+
+	p.factory.metricUpstreamProxyEjected.WithLabelValues(evt.Proxy).Set(value)
+}
+
+func (p prometheusProcessor) EventDCConnsLimited(evt mtglib.EventDCConnsLimited) {
+	p.factory.metricDCConnsLimited.WithLabelValues(strconv.Itoa(evt.DC)).Inc()
+}
+
+func (p prometheusProcessor) EventHandshakeFinished(evt mtglib.EventHandshakeFinished) {
+	result := TagHandshakeResultOK
+
+	if evt.IsFailed {
+		result = TagHandshakeResultFailed
+	}
+
+	p.factory.metricHandshakeDuration.
+		WithLabelValues(result).
+		Observe(evt.Duration.Seconds())
+}
+
+func (p prometheusProcessor) EventClientRateLimited(_ mtglib.EventClientRateLimited) {
+	p.factory.metricClientRateLimited.Inc()
+}
+
+// EventActiveStreams is ignored: client_connections gauge already has a
+// number of concurrent streams.
+func (p prometheusProcessor) EventActiveStreams(_ mtglib.EventActiveStreams) {}
+
+func (p prometheusProcessor) EventIdleTimeout(_ mtglib.EventIdleTimeout) {
+	p.factory.metricIdleTimeouts.Inc()
+}
+
+func (p prometheusProcessor) EventAntiReplayStats(evt mtglib.EventAntiReplayStats) {
+	p.factory.metricAntiReplayFill.Set(evt.FillRatio)
+	p.factory.metricAntiReplayFPR.Set(evt.FalsePositiveRate)
+}
+
+func (p prometheusProcessor) EventDCTraffic(evt mtglib.EventDCTraffic) {
+	dc := strconv.Itoa(evt.DC)
+
+	p.factory.metricDCTraffic.
+		WithLabelValues(dc, TagDirectionFromClient).
+		Add(float64(evt.Upstream))
+	p.factory.metricDCTraffic.
+		WithLabelValues(dc, TagDirectionToClient).
+		Add(float64(evt.Downstream))
+}
+
+func (p prometheusProcessor) EventTelegramDial(evt mtglib.EventTelegramDial) {
+	family, result := getTelegramDialTags(evt)
+
+	p.factory.metricTelegramDialDuration.
+		WithLabelValues(strconv.Itoa(evt.DC), family, result).
+		Observe(evt.Duration.Seconds())
+}
+
 func (p prometheusProcessor) Shutdown() {
 	for k, v := range p.streams {
 		releaseStreamInfo(v)
@@ -143,22 +343,62 @@ func (p prometheusProcessor) Shutdown() {
 // information in a format suitable for Prometheus.
 //
 // This factory can also serve on a given listener. In that case it starts HTTP
-// server with a single endpoint - a Prometheus-compatible scrape output.
+// server with a single endpoint - a Prometheus-compatible scrape output. Or it
+// can periodically write the same output into a file for a textfile collector
+// of node_exporter.
 type PrometheusFactory struct {
+	ctx        context.Context
+	ctxCancel  context.CancelFunc
+	registry   *prometheus.Registry
 	httpServer *http.Server
+	httpMux    *http.ServeMux
+	origin     *originTracker
 
 	metricClientConnections         *prometheus.GaugeVec
 	metricTelegramConnections       *prometheus.GaugeVec
 	metricDomainFrontingConnections *prometheus.GaugeVec
 	metricIPListSize                *prometheus.GaugeVec
+	metricActiveConnections         *prometheus.GaugeVec
+	metricUpstreamProxyEjected      *prometheus.GaugeVec
 
 	metricTelegramTraffic       *prometheus.CounterVec
+	metricDCTraffic             *prometheus.CounterVec
 	metricDomainFrontingTraffic *prometheus.CounterVec
+	metricClientTraffic         *prometheus.CounterVec
 	metricIPBlocklisted         *prometheus.CounterVec
-
-	metricDomainFronting     prometheus.Counter
-	metricConcurrencyLimited prometheus.Counter
-	metricReplayAttacks      prometheus.Counter
+	metricDNSQueries            *prometheus.CounterVec
+	metricDNSCache              *prometheus.CounterVec
+	metricDCEndpointFailover    *prometheus.CounterVec
+	metricDCConnsLimited        *prometheus.CounterVec
+	metricCountryTraffic        *prometheus.CounterVec
+	metricASNTraffic            *prometheus.CounterVec
+	metricGeoIPLoadFailures     *prometheus.CounterVec
+	metricGeoIPUpdates          *prometheus.CounterVec
+	metricUpstreamMirrorDials   *prometheus.CounterVec
+	metricSNIConnections        *prometheus.CounterVec
+	metricClosedStreams         *prometheus.CounterVec
+
+	metricDNSQueryDuration           *prometheus.HistogramVec
+	metricUpstreamMirrorDialDuration *prometheus.HistogramVec
+	metricHandshakeDuration          *prometheus.HistogramVec
+	metricTelegramDialDuration       *prometheus.HistogramVec
+
+	metricDomainFronting       prometheus.Counter
+	metricConcurrencyLimited   prometheus.Counter
+	metricReplayAttacks        prometheus.Counter
+	metricProbeTarpitted       prometheus.Counter
+	metricClientRateLimited    prometheus.Counter
+	metricMissingSNI           prometheus.Counter
+	metricIncompleteHandshakes prometheus.Counter
+	metricDNSCacheEvictions    prometheus.Counter
+	metricHandshakeTooLarge    prometheus.Counter
+	metricIdleTimeouts         prometheus.Counter
+
+	metricConfiguredSecrets prometheus.Gauge
+	metricDNSCacheSize      prometheus.Gauge
+	metricAntiReplayFill    prometheus.Gauge
+	metricAntiReplayFPR     prometheus.Gauge
+	metricBannedIPs         prometheus.Gauge
 }
 
 // Make builds a new observer.
@@ -174,27 +414,166 @@ func (p *PrometheusFactory) Serve(listener net.Listener) error {
 	return p.httpServer.Serve(listener) //nolint: wrapcheck
 }
 
+// ServeTLS is the same as [PrometheusFactory.Serve] but it serves HTTPS
+// with a given TLS config. A nil config means plain HTTP.
+func (p *PrometheusFactory) ServeTLS(listener net.Listener, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return p.Serve(listener)
+	}
+
+	return p.Serve(tls.NewListener(listener, tlsConfig))
+}
+
+// Handle registers an additional HTTP handler on the server of this
+// factory. It can be called even if server is already running.
+func (p *PrometheusFactory) Handle(pattern string, handler http.Handler) {
+	p.httpMux.Handle(pattern, handler)
+}
+
+// WriteTextfile renders current metrics into a given file. The file is
+// replaced atomically with a rename so readers never get a partial output.
+func (p *PrometheusFactory) WriteTextfile(path string) error {
+	if err := prometheus.WriteToTextfile(path, p.registry); err != nil {
+		return fmt.Errorf("cannot write metrics to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ServeTextfile writes metrics into a given file each interval until
+// the factory is closed. Errors are logged and do not stop the process.
+//
+// This is a blocking method so you probably want to run it in a goroutine.
+func (p *PrometheusFactory) ServeTextfile(path string, interval time.Duration, logger mtglib.Logger) {
+	if interval == 0 {
+		interval = DefaultPrometheusTextfileInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.WriteTextfile(path); err != nil {
+			logger.WarningError("cannot write prometheus textfile", err)
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // Close stops a factory. Please pay attention that underlying listener
 // is not closed.
 func (p *PrometheusFactory) Close() error {
+	p.ctxCancel()
+
 	return p.httpServer.Shutdown(context.Background()) //nolint: wrapcheck
 }
 
+// NewPrometheusTLSConfig builds a TLS config for [PrometheusFactory.ServeTLS]
+// from PEM files of certificate and its private key. If clientCAFile is not
+// empty, clients have to present a certificate signed by one of CAs from
+// this file (mutual TLS).
+func NewPrometheusTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load a certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	data, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in client CA file %s", clientCAFile)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return tlsConfig, nil
+}
+
+// PrometheusOpts defines settings of a factory made by
+// [NewPrometheusWithOpts].
+type PrometheusOpts struct {
+	// MetricPrefix is a namespace of all metrics.
+	//
+	// This is an optional setting. Default is no prefix.
+	MetricPrefix string
+
+	// HTTPPath is a path of HTTP endpoint with scrape data.
+	//
+	// This is a mandatory setting.
+	HTTPPath string
+
+	// InstanceName is a value of a constant 'instance_name' label of all
+	// metrics.
+	//
+	// This is an optional setting. Default is no label.
+	InstanceName string
+
+	// Origin defines how client traffic is grouped by country and
+	// autonomous system of the client.
+	//
+	// This is an optional setting. Default is no grouping.
+	Origin OriginOpts
+}
+
 // NewPrometheus builds an events.ObserverFactory which can serve HTTP
 // endpoint with Prometheus scrape data.
-func NewPrometheus(metricPrefix, httpPath string) *PrometheusFactory { //nolint: funlen
+func NewPrometheus(metricPrefix, httpPath string) *PrometheusFactory {
+	return NewPrometheusWithOpts(PrometheusOpts{
+		MetricPrefix: metricPrefix,
+		HTTPPath:     httpPath,
+	})
+}
+
+// NewPrometheusWithOpts is the same as [NewPrometheus] but it takes all
+// settings of a factory.
+func NewPrometheusWithOpts(opts PrometheusOpts) *PrometheusFactory { //nolint: funlen
+	metricPrefix := opts.MetricPrefix
+
 	registry := prometheus.NewPedanticRegistry()
+	registerer := prometheus.Registerer(registry)
+
+	if opts.InstanceName != "" {
+		registerer = prometheus.WrapRegistererWith(prometheus.Labels{
+			TagInstanceName: opts.InstanceName,
+		}, registry)
+	}
+
 	httpHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
 	})
 	mux := http.NewServeMux()
 
-	mux.Handle(httpPath, httpHandler)
+	mux.Handle(opts.HTTPPath, httpHandler)
 
+	ctx, cancel := context.WithCancel(context.Background())
 	factory := &PrometheusFactory{
+		ctx:       ctx,
+		ctxCancel: cancel,
+		registry:  registry,
 		httpServer: &http.Server{
 			Handler: mux,
 		},
+		httpMux: mux,
+		origin:  newOriginTracker(opts.Origin),
 
 		metricClientConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: metricPrefix,
@@ -216,22 +595,122 @@ func NewPrometheus(metricPrefix, httpPath string) *PrometheusFactory { //nolint:
 			Name:      MetricIPListSize,
 			Help:      "A size of the ip list (blocklist or allowlist)",
 		}, []string{TagIPList}),
+		metricActiveConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricActiveConnections,
+			Help:      "A number of active client connections per secret.",
+		}, []string{TagSecretFingerprint}),
+		metricUpstreamProxyEjected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricUpstreamProxyEjected,
+			Help:      "1 if upstream proxy is ejected from rotation, 0 otherwise.",
+		}, []string{TagProxy}),
 
 		metricTelegramTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricPrefix,
 			Name:      MetricTelegramTraffic,
 			Help:      "Traffic which is generated talking with Telegram servers.",
 		}, []string{TagTelegramIP, TagDC, TagDirection}),
+		metricDCTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDCTraffic,
+			Help:      "Traffic which is proxied to and from Telegram DCs.",
+		}, []string{TagDC, TagDirection}),
 		metricDomainFrontingTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricPrefix,
 			Name:      MetricDomainFrontingTraffic,
 			Help:      "Traffic which is generated talking with front domain.",
 		}, []string{TagDirection}),
+		metricClientTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricClientTraffic,
+			Help:      "Raw traffic of client connections.",
+		}, []string{TagDirection}),
 		metricIPBlocklisted: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: metricPrefix,
 			Name:      MetricIPBlocklisted,
 			Help:      "A number of rejected sessions due to ip blocklisting.",
 		}, []string{TagIPList}),
+		metricDNSQueries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDNSQueries,
+			Help:      "A number of DNS-over-HTTPS queries.",
+		}, []string{TagDOHResolver, TagDNSResult}),
+		metricDNSCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDNSCache,
+			Help:      "A number of DNS lookups served with or without a cache.",
+		}, []string{TagDNSCache}),
+		metricDCEndpointFailover: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDCEndpointFailover,
+			Help:      "A number of connections to Telegram which used a secondary DC endpoint.",
+		}, []string{TagDC}),
+		metricDCConnsLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDCConnsLimited,
+			Help:      "A number of streams which waited for a free connection slot of Telegram DC.",
+		}, []string{TagDC}),
+		metricGeoIPLoadFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricGeoIPLoadFailures,
+			Help:      "A number of failed attempts to load GeoIP databases.",
+		}, []string{TagGeoIPDatabase}),
+		metricGeoIPUpdates: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricGeoIPUpdates,
+			Help:      "A number of GeoIP database downloads.",
+		}, []string{TagGeoIPDatabase, TagUpdateResult}),
+		metricUpstreamMirrorDials: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricUpstreamMirrorDials,
+			Help:      "A number of dials made by traffic mirroring.",
+		}, []string{TagUpstream, TagDialResult}),
+		metricSNIConnections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricSNIConnections,
+			Help:      "A number of client connections grouped by SNI.",
+		}, []string{TagSNI}),
+		metricClosedStreams: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricClosedStreams,
+			Help:      "A number of finished streams grouped by a close reason.",
+		}, []string{TagCloseReason}),
+		metricCountryTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricCountryTraffic,
+			Help:      "Traffic of clients grouped by country.",
+		}, []string{TagCountry, TagDirection}),
+		metricASNTraffic: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricASNTraffic,
+			Help:      "Traffic of clients grouped by autonomous system.",
+		}, []string{TagASN, TagDirection}),
+
+		metricDNSQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDNSQueryDuration,
+			Help:      "A time (in seconds) spent on DNS-over-HTTPS queries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{TagDOHResolver}),
+		metricUpstreamMirrorDialDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Name:      MetricUpstreamMirrorDialDuration,
+			Help:      "A time (in seconds) spent on dials made by traffic mirroring.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{TagUpstream}),
+		metricTelegramDialDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Name:      MetricTelegramDialDuration,
+			Help:      "A time (in seconds) spent on dials to Telegram DCs.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{TagDC, TagIPFamily, TagDialResult}),
+		metricHandshakeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricPrefix,
+			Name:      MetricHandshakeDuration,
+			Help:      "A time (in seconds) spent on client handshakes.",
+			Buckets:   handshakeDurationBuckets,
+		}, []string{TagHandshakeResult}),
 
 		metricDomainFronting: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: metricPrefix,
@@ -248,20 +727,114 @@ func NewPrometheus(metricPrefix, httpPath string) *PrometheusFactory { //nolint:
 			Name:      MetricReplayAttacks,
 			Help:      "A number of detected replay attacks.",
 		}),
-	}
-
-	registry.MustRegister(factory.metricClientConnections)
-	registry.MustRegister(factory.metricTelegramConnections)
-	registry.MustRegister(factory.metricDomainFrontingConnections)
-	registry.MustRegister(factory.metricIPListSize)
+		metricMissingSNI: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricMissingSNI,
+			Help:      "A number of client hellos with a valid secret but without SNI.",
+		}),
+		metricIncompleteHandshakes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricIncompleteHandshakes,
+			Help:      "A number of client connections closed before a handshake was completed.",
+		}),
+		metricIdleTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricIdleTimeouts,
+			Help:      "A number of streams closed because they were idle for too long.",
+		}),
+		metricProbeTarpitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricProbeTarpitted,
+			Help:      "A number of rejected connections which were held in tarpit.",
+		}),
+		metricClientRateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricClientRateLimited,
+			Help:      "A number of connections rejected because their client IP opens connections too often.",
+		}),
+		metricDNSCacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDNSCacheEvictions,
+			Help:      "A number of entries evicted from DNS cache.",
+		}),
+		metricHandshakeTooLarge: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricPrefix,
+			Name:      MetricHandshakeTooLarge,
+			Help:      "A number of connections closed because client hello was too large.",
+		}),
 
-	registry.MustRegister(factory.metricTelegramTraffic)
-	registry.MustRegister(factory.metricDomainFrontingTraffic)
-	registry.MustRegister(factory.metricIPBlocklisted)
+		metricConfiguredSecrets: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricConfiguredSecrets,
+			Help:      "A number of secrets proxy serves.",
+		}),
+		metricDNSCacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricDNSCacheSize,
+			Help:      "A number of entries in DNS cache.",
+		}),
+		metricAntiReplayFill: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricAntiReplayFillRatio,
+			Help:      "An approximate share of anti-replay cache which is in use.",
+		}),
+		metricAntiReplayFPR: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricAntiReplayFalsePositiveRate,
+			Help:      "An estimated false-positive rate of anti-replay cache.",
+		}),
+		metricBannedIPs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricPrefix,
+			Name:      MetricBannedIPs,
+			Help:      "A number of client IP addresses banned because of failed handshakes.",
+		}),
+	}
 
-	registry.MustRegister(factory.metricDomainFronting)
-	registry.MustRegister(factory.metricConcurrencyLimited)
-	registry.MustRegister(factory.metricReplayAttacks)
+	registerer.MustRegister(factory.metricClientConnections)
+	registerer.MustRegister(factory.metricTelegramConnections)
+	registerer.MustRegister(factory.metricDomainFrontingConnections)
+	registerer.MustRegister(factory.metricIPListSize)
+	registerer.MustRegister(factory.metricActiveConnections)
+	registerer.MustRegister(factory.metricUpstreamProxyEjected)
+
+	registerer.MustRegister(factory.metricTelegramTraffic)
+	registerer.MustRegister(factory.metricDCTraffic)
+	registerer.MustRegister(factory.metricDomainFrontingTraffic)
+	registerer.MustRegister(factory.metricClientTraffic)
+	registerer.MustRegister(factory.metricIPBlocklisted)
+	registerer.MustRegister(factory.metricDNSQueries)
+	registerer.MustRegister(factory.metricDNSCache)
+	registerer.MustRegister(factory.metricDCEndpointFailover)
+	registerer.MustRegister(factory.metricDCConnsLimited)
+	registerer.MustRegister(factory.metricCountryTraffic)
+	registerer.MustRegister(factory.metricASNTraffic)
+	registerer.MustRegister(factory.metricGeoIPLoadFailures)
+	registerer.MustRegister(factory.metricGeoIPUpdates)
+	registerer.MustRegister(factory.metricUpstreamMirrorDials)
+	registerer.MustRegister(factory.metricSNIConnections)
+	registerer.MustRegister(factory.metricClosedStreams)
+
+	registerer.MustRegister(factory.metricDNSQueryDuration)
+	registerer.MustRegister(factory.metricTelegramDialDuration)
+	registerer.MustRegister(factory.metricUpstreamMirrorDialDuration)
+	registerer.MustRegister(factory.metricHandshakeDuration)
+
+	registerer.MustRegister(factory.metricDomainFronting)
+	registerer.MustRegister(factory.metricConcurrencyLimited)
+	registerer.MustRegister(factory.metricReplayAttacks)
+	registerer.MustRegister(factory.metricProbeTarpitted)
+	registerer.MustRegister(factory.metricClientRateLimited)
+	registerer.MustRegister(factory.metricMissingSNI)
+	registerer.MustRegister(factory.metricIncompleteHandshakes)
+	registerer.MustRegister(factory.metricDNSCacheEvictions)
+	registerer.MustRegister(factory.metricHandshakeTooLarge)
+	registerer.MustRegister(factory.metricIdleTimeouts)
+
+	registerer.MustRegister(factory.metricConfiguredSecrets)
+	registerer.MustRegister(factory.metricDNSCacheSize)
+	registerer.MustRegister(factory.metricAntiReplayFill)
+	registerer.MustRegister(factory.metricAntiReplayFPR)
+	registerer.MustRegister(factory.metricBannedIPs)
 
 	return factory
 }
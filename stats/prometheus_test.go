@@ -5,10 +5,15 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/geoip"
+	"github.com/IceCodeNew/mtg/logger"
 	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/IceCodeNew/mtg/stats"
 	"github.com/stretchr/testify/suite"
@@ -54,6 +59,21 @@ func (suite *PrometheusTestSuite) TearDownTest() {
 	suite.httpListener.Close()
 }
 
+func (suite *PrometheusTestSuite) TestHandle() {
+	suite.factory.Handle("/extra", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("extra")) //nolint: errcheck
+	}))
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/extra", suite.httpListener.Addr().String())) //nolint: noctx
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal("extra", string(data))
+}
+
 func (suite *PrometheusTestSuite) TestTelegramPath() {
 	suite.prometheus.EventStart(
 		mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
@@ -87,13 +107,14 @@ func (suite *PrometheusTestSuite) TestTelegramPath() {
 	suite.NoError(err)
 	suite.Contains(data, `mtg_telegram_traffic{dc="4",direction="from_client",telegram_ip="10.0.0.1"} 100`)
 
-	suite.prometheus.EventFinish(mtglib.NewEventFinish("connID"))
+	suite.prometheus.EventFinish(mtglib.NewEventFinishWithReason("connID", mtglib.CloseReasonTelegramClosed))
 	time.Sleep(100 * time.Millisecond)
 
 	data, err = suite.Get()
 	suite.NoError(err)
 	suite.Contains(data, `mtg_client_connections{ip_family="ipv4"} 0`)
 	suite.Contains(data, `mtg_telegram_connections{dc="4",telegram_ip="10.0.0.1"} 0`)
+	suite.Contains(data, `mtg_closed_streams{close_reason="telegram-closed"} 1`)
 }
 
 func (suite *PrometheusTestSuite) TestDomainFrontingPath() {
@@ -192,6 +213,410 @@ func (suite *PrometheusTestSuite) TestEventIPListSize() {
 	suite.Contains(data, `mtg_iplist_size{ip_list="blocklist"} 3`)
 }
 
+func (suite *PrometheusTestSuite) TestEventSecretsConfigured() {
+	suite.prometheus.EventSecretsConfigured(mtglib.NewEventSecretsConfigured(3))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_configured_secrets 3`)
+}
+
+func (suite *PrometheusTestSuite) TestEventSecretMatched() {
+	suite.prometheus.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	suite.prometheus.EventSecretMatched(mtglib.NewEventSecretMatched("connID", "0011223344556677"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_active_connections{secret_fp="0011223344556677"} 1`)
+	suite.Contains(data, `mtg_sni_connections{sni="unknown"} 1`)
+
+	suite.prometheus.EventFinish(mtglib.NewEventFinish("connID"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err = suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_active_connections{secret_fp="0011223344556677"} 0`)
+}
+
+func (suite *PrometheusTestSuite) TestEventSecretMatchedWithHost() {
+	suite.prometheus.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	suite.prometheus.EventSecretMatched(
+		mtglib.NewEventSecretMatchedWithOpts("connID", mtglib.EventSecretMatchedOpts{
+			SecretFingerprint: "0011223344556677",
+			Host:              "example.com",
+		}))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_sni_connections{sni="example.com"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventDNSQuery() {
+	suite.prometheus.EventDNSQuery(
+		mtglib.NewEventDNSQuery("9.9.9.9", "google.com", 20*time.Millisecond, false, false))
+	suite.prometheus.EventDNSQuery(
+		mtglib.NewEventDNSQuery("9.9.9.9", "google.com", 0, true, false))
+	suite.prometheus.EventDNSQuery(
+		mtglib.NewEventDNSQuery("9.9.9.9", "example.com", time.Second, false, true))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_dns_queries{dns_result="ok",doh_resolver="9.9.9.9"} 1`)
+	suite.Contains(data, `mtg_dns_queries{dns_result="failed",doh_resolver="9.9.9.9"} 1`)
+	suite.Contains(data, `mtg_dns_cache{dns_cache="hit"} 1`)
+	suite.Contains(data, `mtg_dns_cache{dns_cache="miss"} 2`)
+	suite.Contains(data, `mtg_dns_query_duration_count{doh_resolver="9.9.9.9"} 2`)
+}
+
+func (suite *PrometheusTestSuite) TestEventDCEndpointFailover() {
+	suite.prometheus.EventDCEndpointFailover(mtglib.NewEventDCEndpointFailover("connID", 2, 1))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_dc_endpoint_failover{dc="2"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventProbeTarpitted() {
+	suite.prometheus.EventProbeTarpitted(mtglib.NewEventProbeTarpitted(net.ParseIP("10.0.0.10")))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_probe_tarpitted 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventMissingSNI() {
+	suite.prometheus.EventMissingSNI(mtglib.NewEventMissingSNI("connID"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_missing_sni 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventIncompleteHandshake() {
+	suite.prometheus.EventIncompleteHandshake(mtglib.NewEventIncompleteHandshake("connID"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_incomplete_handshakes 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventDCConnsLimited() {
+	suite.prometheus.EventDCConnsLimited(mtglib.NewEventDCConnsLimited("connID", 2, time.Second))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_dc_conns_limited{dc="2"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventIPBanned() {
+	suite.prometheus.EventIPBanned(mtglib.NewEventIPBanned(net.ParseIP("10.0.0.10"), time.Minute))
+	suite.prometheus.EventIPBanned(mtglib.NewEventIPBanned(net.ParseIP("10.0.0.11"), time.Minute))
+	suite.prometheus.EventIPUnbanned(mtglib.NewEventIPUnbanned(net.ParseIP("10.0.0.10")))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_banned_ips 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventDNSCacheUpdated() {
+	suite.prometheus.EventDNSCacheUpdated(mtglib.NewEventDNSCacheUpdated(10, 0))
+	suite.prometheus.EventDNSCacheUpdated(mtglib.NewEventDNSCacheUpdated(9, 2))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_dns_cache_size 9`)
+	suite.Contains(data, `mtg_dns_cache_evictions 2`)
+}
+
+func (suite *PrometheusTestSuite) TestEventHandshakeTooLarge() {
+	suite.prometheus.EventHandshakeTooLarge(mtglib.NewEventHandshakeTooLarge("connID"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_handshake_too_large 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventGeoIPLoadFailed() {
+	suite.prometheus.EventGeoIPLoadFailed(mtglib.NewEventGeoIPLoadFailed("asn"))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_geoip_load_failures{geoip_db="asn"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventUpstreamMirrored() {
+	suite.prometheus.EventUpstreamMirrored(
+		mtglib.NewEventUpstreamMirrored("127.0.0.1:443", time.Second, 2*time.Second, false, true))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_upstream_mirror_dials{dial_result="ok",upstream="primary"} 1`)
+	suite.Contains(data, `mtg_upstream_mirror_dials{dial_result="failed",upstream="mirror"} 1`)
+	suite.Contains(data, `mtg_upstream_mirror_dial_duration_sum{upstream="mirror"} 2`)
+}
+
+func (suite *PrometheusTestSuite) TestEventGeoIPUpdated() {
+	suite.prometheus.EventGeoIPUpdated(mtglib.NewEventGeoIPUpdated("country", false))
+	suite.prometheus.EventGeoIPUpdated(mtglib.NewEventGeoIPUpdated("asn", true))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_geoip_updates{geoip_db="country",update_result="ok"} 1`)
+	suite.Contains(data, `mtg_geoip_updates{geoip_db="asn",update_result="failed"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventUpstreamProxyStateChanged() {
+	suite.prometheus.EventUpstreamProxyStateChanged(mtglib.NewEventUpstreamProxyStateChanged("127.0.0.1:1080", true))
+	suite.prometheus.EventUpstreamProxyStateChanged(mtglib.NewEventUpstreamProxyStateChanged("127.0.0.2:1080", true))
+	suite.prometheus.EventUpstreamProxyStateChanged(mtglib.NewEventUpstreamProxyStateChanged("127.0.0.2:1080", false))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_upstream_proxy_ejected{proxy="127.0.0.1:1080"} 1`)
+	suite.Contains(data, `mtg_upstream_proxy_ejected{proxy="127.0.0.2:1080"} 0`)
+}
+
+func (suite *PrometheusTestSuite) TestEventHandshakeFinished() {
+	suite.prometheus.EventHandshakeFinished(mtglib.NewEventHandshakeFinished("connID", 20*time.Millisecond, false))
+	suite.prometheus.EventHandshakeFinished(mtglib.NewEventHandshakeFinished("connID", 3*time.Second, true))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_handshake_duration_bucket{handshake_result="ok",le="0.025"} 1`)
+	suite.Contains(data, `mtg_handshake_duration_bucket{handshake_result="failed",le="2.5"} 0`)
+	suite.Contains(data, `mtg_handshake_duration_bucket{handshake_result="failed",le="5"} 1`)
+	suite.Contains(data, `mtg_handshake_duration_count{handshake_result="failed"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventClientRateLimited() {
+	suite.prometheus.EventClientRateLimited(mtglib.NewEventClientRateLimited(net.ParseIP("10.0.0.10")))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_client_rate_limited 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventIdleTimeout() {
+	suite.prometheus.EventIdleTimeout(mtglib.NewEventIdleTimeout("connID"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_idle_timeouts 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventAntiReplayStats() {
+	suite.prometheus.EventAntiReplayStats(mtglib.NewEventAntiReplayStats(0.5, 0.25))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_antireplay_fill_ratio 0.5`)
+	suite.Contains(data, `mtg_antireplay_false_positive_rate 0.25`)
+}
+
+func (suite *PrometheusTestSuite) TestEventDCTraffic() {
+	suite.prometheus.EventDCTraffic(mtglib.NewEventDCTraffic(2, 100, 200))
+	suite.prometheus.EventDCTraffic(mtglib.NewEventDCTraffic(2, 10, 0))
+	suite.prometheus.EventDCTraffic(mtglib.NewEventDCTraffic(4, 0, 50))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_traffic_bytes_total{dc="2",direction="from_client"} 110`)
+	suite.Contains(data, `mtg_traffic_bytes_total{dc="2",direction="to_client"} 200`)
+	suite.Contains(data, `mtg_traffic_bytes_total{dc="4",direction="to_client"} 50`)
+}
+
+func (suite *PrometheusTestSuite) TestEventTelegramDial() {
+	suite.prometheus.EventTelegramDial(mtglib.NewEventTelegramDial("connID", 2, 20*time.Millisecond, false, false))
+	suite.prometheus.EventTelegramDial(mtglib.NewEventTelegramDial("connID", 4, 3*time.Second, false, true))
+	suite.prometheus.EventTelegramDial(mtglib.NewEventTelegramDial("connID", 5, time.Second, true, false))
+
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data,
+		`mtg_telegram_dial_duration_bucket{dc="2",dial_result="ok",ip_family="ipv4",le="0.025"} 1`)
+	suite.Contains(data,
+		`mtg_telegram_dial_duration_count{dc="4",dial_result="failed",ip_family="unknown"} 1`)
+	suite.Contains(data,
+		`mtg_telegram_dial_duration_sum{dc="4",dial_result="failed",ip_family="unknown"} 3`)
+	suite.Contains(data,
+		`mtg_telegram_dial_duration_count{dc="5",dial_result="ok",ip_family="ipv6"} 1`)
+}
+
+func (suite *PrometheusTestSuite) TestEventClientTraffic() {
+	suite.prometheus.EventClientTraffic(mtglib.NewEventClientTraffic("connID", 100, true))
+	suite.prometheus.EventClientTraffic(mtglib.NewEventClientTraffic("connID", 50, false))
+	time.Sleep(100 * time.Millisecond)
+
+	data, err := suite.Get()
+	suite.NoError(err)
+	suite.Contains(data, `mtg_client_traffic{direction="from_client"} 100`)
+	suite.Contains(data, `mtg_client_traffic{direction="to_client"} 50`)
+}
+
+func (suite *PrometheusTestSuite) TestOrigin() {
+	db, err := geoip.NewDB(
+		filepath.Join("..", "geoip", "testdata", "country.mmdb"),
+		filepath.Join("..", "geoip", "testdata", "asn.mmdb"))
+	suite.NoError(err)
+
+	defer db.Close()
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	factory := stats.NewPrometheusWithOpts(stats.PrometheusOpts{
+		MetricPrefix: "mtg",
+		HTTPPath:     "/",
+		Origin: stats.OriginOpts{
+			Lookup: db,
+		},
+	})
+	observer := factory.Make()
+
+	go factory.Serve(listener) //nolint: errcheck
+
+	defer func() {
+		observer.Shutdown()
+		factory.Close()
+		listener.Close()
+	}()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("81.2.69.142")))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID", 200, true))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID", 100, false))
+	observer.EventStart(mtglib.NewEventStart("connID2", net.ParseIP("10.0.0.1")))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID2", 50, true))
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/", listener.Addr().String())) //nolint: noctx
+	suite.NoError(err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	suite.NoError(err)
+
+	data := string(body)
+
+	suite.Contains(data, `mtg_country_traffic{country="GB",direction="to_client"} 200`)
+	suite.Contains(data, `mtg_country_traffic{country="GB",direction="from_client"} 100`)
+	suite.Contains(data, `mtg_country_traffic{country="unknown",direction="to_client"} 50`)
+	suite.Contains(data, `mtg_asn_traffic{asn="20712",direction="to_client"} 200`)
+	suite.Contains(data, `mtg_asn_traffic{asn="unknown",direction="to_client"} 50`)
+}
+
+func (suite *PrometheusTestSuite) TestWriteTextfile() {
+	path := filepath.Join(suite.T().TempDir(), "mtg.prom")
+
+	suite.prometheus.EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+	time.Sleep(100 * time.Millisecond)
+
+	suite.NoError(suite.factory.WriteTextfile(path))
+
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Contains(string(data), `mtg_replay_attacks 1`)
+
+	files, err := os.ReadDir(filepath.Dir(path))
+	suite.NoError(err)
+	suite.Len(files, 1)
+}
+
+func (suite *PrometheusTestSuite) TestWriteTextfileFail() {
+	path := filepath.Join(suite.T().TempDir(), "unknown", "mtg.prom")
+
+	suite.Error(suite.factory.WriteTextfile(path))
+}
+
+func (suite *PrometheusTestSuite) TestServeTextfile() {
+	path := filepath.Join(suite.T().TempDir(), "mtg.prom")
+	done := make(chan struct{})
+
+	go func() {
+		suite.factory.ServeTextfile(path, 10*time.Millisecond, logger.NewNoopLogger())
+		close(done)
+	}()
+
+	suite.prometheus.EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+
+	suite.Eventually(func() bool {
+		data, err := os.ReadFile(path)
+
+		return err == nil && strings.Contains(string(data), `mtg_concurrency_limited 1`)
+	}, time.Second, 10*time.Millisecond)
+
+	suite.NoError(suite.factory.Close())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.FailNow("textfile writer has not been stopped")
+	}
+}
+
+func (suite *PrometheusTestSuite) TestInstanceName() {
+	factory := stats.NewPrometheusWithOpts(stats.PrometheusOpts{
+		MetricPrefix: "mtg",
+		HTTPPath:     "/",
+		InstanceName: "mtg-1",
+	})
+	observer := factory.Make()
+	path := filepath.Join(suite.T().TempDir(), "mtg.prom")
+
+	defer func() {
+		observer.Shutdown()
+		factory.Close()
+	}()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	observer.EventReplayAttack(mtglib.NewEventReplayAttack("connID"))
+
+	suite.NoError(factory.WriteTextfile(path))
+
+	data, err := os.ReadFile(path)
+	suite.NoError(err)
+	suite.Contains(string(data), `mtg_client_connections{instance_name="mtg-1",ip_family="ipv4"} 1`)
+	suite.Contains(string(data), `mtg_replay_attacks{instance_name="mtg-1"} 1`)
+}
+
 func TestPrometheus(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &PrometheusTestSuite{})
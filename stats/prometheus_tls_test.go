@@ -0,0 +1,187 @@
+package stats_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IceCodeNew/mtg/stats"
+	"github.com/stretchr/testify/suite"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+
+	keyPEM []byte
+}
+
+func (t testCertificate) TLS() tls.Certificate {
+	cert, _ := tls.X509KeyPair(t.pem, t.keyPEM)
+
+	return cert
+}
+
+func makeTestCertificate(name string, parent *testCertificate, isServer bool) testCertificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if isServer {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	parentCert, parentKey := template, key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.ExtKeyUsage = nil
+	} else {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+
+	der, _ := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return testCertificate{
+		cert:   cert,
+		key:    key,
+		pem:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+type PrometheusTLSTestSuite struct {
+	suite.Suite
+
+	ca       testCertificate
+	server   testCertificate
+	client   testCertificate
+	dir      string
+	listener net.Listener
+	factory  *stats.PrometheusFactory
+}
+
+func (suite *PrometheusTLSTestSuite) SetupSuite() {
+	suite.ca = makeTestCertificate("ca", nil, false)
+	suite.server = makeTestCertificate("server", &suite.ca, true)
+	suite.client = makeTestCertificate("client", &suite.ca, false)
+	suite.dir = suite.T().TempDir()
+
+	suite.NoError(os.WriteFile(suite.Path("ca.pem"), suite.ca.pem, 0o600))
+	suite.NoError(os.WriteFile(suite.Path("cert.pem"), suite.server.pem, 0o600))
+	suite.NoError(os.WriteFile(suite.Path("key.pem"), suite.server.keyPEM, 0o600))
+}
+
+func (suite *PrometheusTLSTestSuite) SetupTest() {
+	suite.listener, _ = net.Listen("tcp", "127.0.0.1:0")
+	suite.factory = stats.NewPrometheus("mtg", "/")
+}
+
+func (suite *PrometheusTLSTestSuite) TearDownTest() {
+	suite.NoError(suite.factory.Close())
+	suite.listener.Close()
+}
+
+func (suite *PrometheusTLSTestSuite) Path(name string) string {
+	return filepath.Join(suite.dir, name)
+}
+
+func (suite *PrometheusTLSTestSuite) Get(scheme string, certs ...tls.Certificate) (*http.Response, error) {
+	pool := x509.NewCertPool()
+	pool.AddCert(suite.ca.cert)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      pool,
+				Certificates: certs,
+				MinVersion:   tls.VersionTLS12,
+			},
+		},
+	}
+
+	return client.Get(scheme + "://" + suite.listener.Addr().String() + "/") //nolint: noctx
+}
+
+func (suite *PrometheusTLSTestSuite) TestTLS() {
+	tlsConfig, err := stats.NewPrometheusTLSConfig(suite.Path("cert.pem"), suite.Path("key.pem"), "")
+	suite.NoError(err)
+
+	go suite.factory.ServeTLS(suite.listener, tlsConfig) //nolint: errcheck
+
+	resp, err := suite.Get("https")
+	suite.Require().NoError(err)
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	suite.NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Contains(string(data), "mtg_")
+}
+
+func (suite *PrometheusTLSTestSuite) TestMutualTLS() {
+	tlsConfig, err := stats.NewPrometheusTLSConfig(suite.Path("cert.pem"), suite.Path("key.pem"),
+		suite.Path("ca.pem"))
+	suite.NoError(err)
+
+	go suite.factory.ServeTLS(suite.listener, tlsConfig) //nolint: errcheck
+
+	_, err = suite.Get("https") //nolint: bodyclose
+	suite.Error(err)
+
+	resp, err := suite.Get("https", suite.client.TLS())
+	suite.Require().NoError(err)
+
+	defer resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *PrometheusTLSTestSuite) TestPlain() {
+	go suite.factory.ServeTLS(suite.listener, nil) //nolint: errcheck
+
+	resp, err := suite.Get("http")
+	suite.Require().NoError(err)
+
+	defer resp.Body.Close()
+
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *PrometheusTLSTestSuite) TestIncorrectFiles() {
+	_, err := stats.NewPrometheusTLSConfig(suite.Path("cert.pem"), suite.Path("ca.pem"), "")
+	suite.Error(err)
+
+	_, err = stats.NewPrometheusTLSConfig(suite.Path("cert.pem"), suite.Path("key.pem"),
+		suite.Path("key.pem"))
+	suite.Error(err)
+}
+
+func TestPrometheusTLS(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, &PrometheusTLSTestSuite{})
+}
@@ -1,9 +1,13 @@
 package stats
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/IceCodeNew/mtg/events"
 	"github.com/IceCodeNew/mtg/logger"
@@ -12,8 +16,10 @@ import (
 )
 
 type statsdProcessor struct {
-	streams map[string]*streamInfo
-	client  *statsd.Client
+	streams       map[string]*streamInfo
+	client        *statsd.Client
+	origin        *originTracker
+	activeStreams *statsdActiveStreams
 }
 
 func (s statsdProcessor) EventStart(evt mtglib.EventStart) {
@@ -25,6 +31,10 @@ func (s statsdProcessor) EventStart(evt mtglib.EventStart) {
 		info.tags[TagIPFamily] = TagIPFamilyIPv6
 	}
 
+	if s.origin != nil {
+		info.tags[TagCountry], info.tags[TagASN] = s.origin.Resolve(evt.RemoteIP)
+	}
+
 	s.streams[evt.StreamID()] = info
 
 	s.client.GaugeDelta(MetricClientConnections,
@@ -80,9 +90,16 @@ func (s statsdProcessor) EventTraffic(evt mtglib.EventTraffic) {
 			info.T(TagDC),
 			directionTag)
 	}
+
+	if s.origin != nil {
+		s.client.Incr(MetricCountryTraffic, int64(evt.Traffic), info.T(TagCountry), directionTag)
+		s.client.Incr(MetricASNTraffic, int64(evt.Traffic), info.T(TagASN), directionTag)
+	}
 }
 
 func (s statsdProcessor) EventFinish(evt mtglib.EventFinish) {
+	s.client.Incr(MetricClosedStreams, 1, statsd.StringTag(TagCloseReason, string(evt.Reason)))
+
 	info, ok := s.streams[evt.StreamID()]
 	if !ok {
 		return
@@ -97,6 +114,12 @@ func (s statsdProcessor) EventFinish(evt mtglib.EventFinish) {
 		-1,
 		info.T(TagIPFamily))
 
+	if _, ok := info.tags[TagSecretFingerprint]; ok {
+		s.client.GaugeDelta(MetricActiveConnections,
+			-1,
+			info.T(TagSecretFingerprint))
+	}
+
 	if info.isDomainFronted {
 		s.client.GaugeDelta(MetricDomainFrontingConnections,
 			-1,
@@ -135,11 +158,193 @@ func (s statsdProcessor) EventIPListSize(evt mtglib.EventIPListSize) {
 	s.client.Gauge(MetricIPListSize, int64(evt.Size), statsd.StringTag(TagIPList, tag))
 }
 
+func (s statsdProcessor) EventSecretMatched(evt mtglib.EventSecretMatched) {
+	info, ok := s.streams[evt.StreamID()]
+	if !ok {
+		return
+	}
+
+	info.tags[TagSecretFingerprint] = evt.SecretFingerprint
+
+	s.client.GaugeDelta(MetricActiveConnections,
+		1,
+		info.T(TagSecretFingerprint))
+	s.client.Incr(MetricSNIConnections, 1, statsd.StringTag(TagSNI, getSNI(evt)))
+}
+
+func (s statsdProcessor) EventSecretsConfigured(evt mtglib.EventSecretsConfigured) {
+	s.client.Gauge(MetricConfiguredSecrets, int64(evt.Count))
+}
+
+func (s statsdProcessor) EventDNSQuery(evt mtglib.EventDNSQuery) {
+	if evt.IsCached {
+		s.client.Incr(MetricDNSCache, 1, statsd.StringTag(TagDNSCache, TagDNSCacheHit))
+
+		return
+	}
+
+	resolverTag := statsd.StringTag(TagDOHResolver, evt.Resolver)
+	result := TagDNSResultOK
+
+	if evt.IsFailed {
+		result = TagDNSResultFailed
+	}
+
+	s.client.Incr(MetricDNSCache, 1, statsd.StringTag(TagDNSCache, TagDNSCacheMiss))
+	s.client.Incr(MetricDNSQueries, 1, resolverTag, statsd.StringTag(TagDNSResult, result))
+	s.client.PrecisionTiming(MetricDNSQueryDuration, evt.Duration, resolverTag)
+}
+
+func (s statsdProcessor) EventDCEndpointFailover(evt mtglib.EventDCEndpointFailover) {
+	s.client.Incr(MetricDCEndpointFailover, 1, statsd.IntTag(TagDC, evt.DC))
+}
+
+func (s statsdProcessor) EventProbeTarpitted(_ mtglib.EventProbeTarpitted) {
+	s.client.Incr(MetricProbeTarpitted, 1)
+}
+
+func (s statsdProcessor) EventDNSCacheUpdated(evt mtglib.EventDNSCacheUpdated) {
+	s.client.Gauge(MetricDNSCacheSize, int64(evt.Size))
+
+	if evt.Evicted > 0 {
+		s.client.Incr(MetricDNSCacheEvictions, int64(evt.Evicted))
+	}
+}
+
+func (s statsdProcessor) EventHandshakeTooLarge(_ mtglib.EventHandshakeTooLarge) {
+	s.client.Incr(MetricHandshakeTooLarge, 1)
+}
+
+func (s statsdProcessor) EventGeoIPLoadFailed(evt mtglib.EventGeoIPLoadFailed) {
+	s.client.Incr(MetricGeoIPLoadFailures, 1, statsd.StringTag(TagGeoIPDatabase, evt.Database))
+}
+
+func (s statsdProcessor) EventUpstreamMirrored(evt mtglib.EventUpstreamMirrored) {
+	s.reportMirrorDial(TagUpstreamPrimary, evt.PrimaryDuration, evt.PrimaryFailed)
+	s.reportMirrorDial(TagUpstreamMirror, evt.MirrorDuration, evt.MirrorFailed)
+}
+
+func (s statsdProcessor) reportMirrorDial(upstream string, duration time.Duration, failed bool) {
+	upstreamTag := statsd.StringTag(TagUpstream, upstream)
+	result := TagDialResultOK
+
+	if failed {
+		result = TagDialResultFailed
+	}
+
+	s.client.Incr(MetricUpstreamMirrorDials, 1, upstreamTag, statsd.StringTag(TagDialResult, result))
+	s.client.PrecisionTiming(MetricUpstreamMirrorDialDuration, duration, upstreamTag)
+}
+
+func (s statsdProcessor) EventGeoIPUpdated(evt mtglib.EventGeoIPUpdated) {
+	result := TagUpdateResultOK
+
+	if evt.Failed {
+		result = TagUpdateResultFailed
+	}
+
+	s.client.Incr(MetricGeoIPUpdates, 1,
+		statsd.StringTag(TagGeoIPDatabase, evt.Database),
+		statsd.StringTag(TagUpdateResult, result))
+}
+
+func (s statsdProcessor) EventClientTraffic(evt mtglib.EventClientTraffic) {
+	s.client.Incr(MetricClientTraffic,
+		int64(evt.Traffic),
+		statsd.StringTag(TagDirection, getClientDirection(evt.IsRead)))
+}
+
+func (s statsdProcessor) EventIPBanned(_ mtglib.EventIPBanned) {
+	s.client.GaugeDelta(MetricBannedIPs, 1)
+}
+
+func (s statsdProcessor) EventIPUnbanned(_ mtglib.EventIPUnbanned) {
+	s.client.GaugeDelta(MetricBannedIPs, -1)
+}
+
+func (s statsdProcessor) EventMissingSNI(_ mtglib.EventMissingSNI) {
+	s.client.Incr(MetricMissingSNI, 1)
+}
+
+func (s statsdProcessor) EventIncompleteHandshake(_ mtglib.EventIncompleteHandshake) {
+	s.client.Incr(MetricIncompleteHandshakes, 1)
+}
+
+func (s statsdProcessor) EventUpstreamProxyStateChanged(evt mtglib.EventUpstreamProxyStateChanged) {
+	var value int64
+
+	if evt.Ejected {
+		value = 1
+	}
+
+	s.client.Gauge(MetricUpstreamProxyEjected, value, statsd.StringTag(TagProxy, evt.Proxy))
+}
+
+func (s statsdProcessor) EventDCConnsLimited(evt mtglib.EventDCConnsLimited) {
+	s.client.Incr(MetricDCConnsLimited, 1, statsd.IntTag(TagDC, evt.DC))
+}
+
+func (s statsdProcessor) EventHandshakeFinished(evt mtglib.EventHandshakeFinished) {
+	result := TagHandshakeResultOK
+
+	if evt.IsFailed {
+		result = TagHandshakeResultFailed
+	}
+
+	s.client.PrecisionTiming(MetricHandshakeDuration, evt.Duration,
+		statsd.StringTag(TagHandshakeResult, result))
+}
+
+func (s statsdProcessor) EventActiveStreams(evt mtglib.EventActiveStreams) {
+	s.activeStreams.report(s.client, evt)
+}
+
+func (s statsdProcessor) EventClientRateLimited(_ mtglib.EventClientRateLimited) {
+	s.client.Incr(MetricClientRateLimited, 1)
+}
+
+func (s statsdProcessor) EventIdleTimeout(_ mtglib.EventIdleTimeout) {
+	s.client.Incr(MetricIdleTimeouts, 1)
+}
+
+func (s statsdProcessor) EventAntiReplayStats(evt mtglib.EventAntiReplayStats) {
+	s.client.FGauge(MetricAntiReplayFillRatio, evt.FillRatio)
+	s.client.FGauge(MetricAntiReplayFalsePositiveRate, evt.FalsePositiveRate)
+}
+
+func (s statsdProcessor) EventDCTraffic(evt mtglib.EventDCTraffic) {
+	dcTag := statsd.StringTag(TagDC, strconv.Itoa(evt.DC))
+
+	if evt.Upstream > 0 {
+		s.client.Incr(MetricDCTraffic,
+			int64(evt.Upstream),
+			dcTag,
+			statsd.StringTag(TagDirection, TagDirectionFromClient))
+	}
+
+	if evt.Downstream > 0 {
+		s.client.Incr(MetricDCTraffic,
+			int64(evt.Downstream),
+			dcTag,
+			statsd.StringTag(TagDirection, TagDirectionToClient))
+	}
+}
+
+func (s statsdProcessor) EventTelegramDial(evt mtglib.EventTelegramDial) {
+	family, result := getTelegramDialTags(evt)
+
+	s.client.PrecisionTiming(MetricTelegramDialDuration,
+		evt.Duration,
+		statsd.StringTag(TagDC, strconv.Itoa(evt.DC)),
+		statsd.StringTag(TagIPFamily, family),
+		statsd.StringTag(TagDialResult, result))
+}
+
 func (s statsdProcessor) Shutdown() {
 	events := make([]mtglib.EventFinish, 0, len(s.streams))
 
 	for k := range s.streams {
-		events = append(events, mtglib.NewEventFinish(k))
+		events = append(events, mtglib.NewEventFinishWithReason(k, mtglib.CloseReasonShutdown))
 	}
 
 	for i := range events {
@@ -147,6 +352,87 @@ func (s statsdProcessor) Shutdown() {
 	}
 }
 
+// statsdErrorLogPrefix is a prefix of messages statsd client logs if it
+// cannot connect to a server or write to a socket.
+const statsdErrorLogPrefix = "[STATSD] Error"
+
+// statsdErrors counts packets statsd client failed to write. Client does not
+// return these errors: it only reports them to its logger. So this is a
+// logger which is passed to the client.
+// statsdActiveStreams is shared by all processors of the factory. Events of
+// active streams have no stream ID so they are processed by random
+// processors and can be reordered. A gauge is set only by events which are
+// newer than the last reported one, otherwise it could be stuck with a
+// stale value.
+type statsdActiveStreams struct {
+	mutex      sync.Mutex
+	reportedAt time.Time
+}
+
+func (s *statsdActiveStreams) report(client *statsd.Client, evt mtglib.EventActiveStreams) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if evt.Timestamp().Before(s.reportedAt) {
+		return
+	}
+
+	s.reportedAt = evt.Timestamp()
+
+	client.Gauge(MetricActiveStreams, int64(evt.Count))
+}
+
+type statsdErrors struct {
+	// it has to be the first field for atomic operations on 32-bit platforms
+	write uint64
+
+	log logger.StdLikeLogger
+}
+
+func (s *statsdErrors) Printf(format string, args ...interface{}) {
+	if strings.HasPrefix(format, statsdErrorLogPrefix) {
+		atomic.AddUint64(&s.write, 1)
+	}
+
+	s.log.Printf(format, args...)
+}
+
+func (s *statsdErrors) Write() uint64 {
+	return atomic.LoadUint64(&s.write)
+}
+
+// run periodically reports send errors to statsd. If statsd is unreachable,
+// these reports are lost as well but they are counted and delivered as a
+// part of the next report.
+func (s *statsdErrors) run(ctx context.Context, client *statsd.Client) {
+	ticker := time.NewTicker(StatsdSendErrorsReportEach)
+	defer ticker.Stop()
+
+	var reportedWrite, reportedOverflow int64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if write := int64(s.Write()); write > reportedWrite {
+			client.Incr(MetricStatsdSendErrors, write-reportedWrite,
+				statsd.StringTag(TagStatsdError, TagStatsdErrorWrite))
+
+			reportedWrite = write
+		}
+
+		if overflow := client.GetLostPackets(); overflow > reportedOverflow {
+			client.Incr(MetricStatsdSendErrors, overflow-reportedOverflow,
+				statsd.StringTag(TagStatsdError, TagStatsdErrorOverflow))
+
+			reportedOverflow = overflow
+		}
+	}
+}
+
 // StatsdFactory is a factory of [events.Observer] which dumps information to
 // statsd.
 //
@@ -154,35 +440,107 @@ func (s statsdProcessor) Shutdown() {
 // won't use [mtglib.Network] so it won't use a proxy if you provide any. If
 // you need it, I would recommend starting a local statsd and route metrics
 // further by features of the chosen server.
+//
+// Statsd is a lossy channel: packets which cannot be sent are not retried.
+// But they are counted, please see [StatsdFactory.SendErrors].
 type StatsdFactory struct {
-	client *statsd.Client
+	client        *statsd.Client
+	origin        *originTracker
+	errors        *statsdErrors
+	activeStreams *statsdActiveStreams
+	ctxCancel     context.CancelFunc
 }
 
 // Close stops sending requests to statsd.
 func (s StatsdFactory) Close() error {
+	s.ctxCancel()
+
 	return s.client.Close() //nolint: wrapcheck
 }
 
+// SendErrors returns a number of packets which were lost, either because
+// they could not be written to a socket or because send queue was full.
+// This number is also reported to statsd as MetricStatsdSendErrors.
+func (s StatsdFactory) SendErrors() uint64 {
+	return s.errors.Write() + uint64(s.client.GetLostPackets())
+}
+
 // Make build a new observer.
 func (s StatsdFactory) Make() events.Observer {
 	return statsdProcessor{
-		client:  s.client,
-		streams: make(map[string]*streamInfo),
+		client:        s.client,
+		streams:       make(map[string]*streamInfo),
+		origin:        s.origin,
+		activeStreams: s.activeStreams,
 	}
 }
 
+// StatsdOpts defines settings of a factory made by [NewStatsdWithOpts].
+type StatsdOpts struct {
+	// Address is a host:port pair of UDP endpoint of statsd.
+	//
+	// This is a mandatory setting.
+	Address string
+
+	// Logger is used to report errors of sending.
+	//
+	// This is a mandatory setting.
+	Logger logger.StdLikeLogger
+
+	// MetricPrefix is a prefix of all metrics.
+	//
+	// This is an optional setting. Default is no prefix.
+	MetricPrefix string
+
+	// TagFormat is a format of tags. Valid values are 'datadog',
+	// 'influxdb' and 'graphite'.
+	//
+	// This is a mandatory setting.
+	TagFormat string
+
+	// InstanceName is a value of a constant 'instance_name' tag of all
+	// metrics.
+	//
+	// This is an optional setting. Default is no tag.
+	InstanceName string
+
+	// Origin defines how client traffic is grouped by country and
+	// autonomous system of the client.
+	//
+	// This is an optional setting. Default is no grouping.
+	Origin OriginOpts
+}
+
 // NewStatsd builds an [events.ObserverFactory] that sends events to statsd.
 //
 // Valid tagFormats are 'datadog', 'influxdb' and 'graphite'.
 func NewStatsd(address string, log logger.StdLikeLogger,
 	metricPrefix, tagFormat string,
 ) (StatsdFactory, error) {
+	return NewStatsdWithOpts(StatsdOpts{
+		Address:      address,
+		Logger:       log,
+		MetricPrefix: metricPrefix,
+		TagFormat:    tagFormat,
+	})
+}
+
+// NewStatsdWithOpts is the same as [NewStatsd] but it takes all settings of
+// a factory.
+func NewStatsdWithOpts(opts StatsdOpts) (StatsdFactory, error) {
+	sendErrors := &statsdErrors{
+		log: opts.Logger,
+	}
 	options := []statsd.Option{
-		statsd.MetricPrefix(metricPrefix),
-		statsd.Logger(log),
+		statsd.MetricPrefix(opts.MetricPrefix),
+		statsd.Logger(sendErrors),
 	}
 
-	switch strings.ToLower(tagFormat) {
+	if opts.InstanceName != "" {
+		options = append(options, statsd.DefaultTags(statsd.StringTag(TagInstanceName, opts.InstanceName)))
+	}
+
+	switch strings.ToLower(opts.TagFormat) {
 	case "datadog":
 		options = append(options, statsd.TagStyle(statsd.TagFormatDatadog))
 	case "influxdb":
@@ -190,10 +548,19 @@ func NewStatsd(address string, log logger.StdLikeLogger,
 	case "graphite":
 		options = append(options, statsd.TagStyle(statsd.TagFormatGraphite))
 	default:
-		return StatsdFactory{}, fmt.Errorf("unknown tag format %s", tagFormat)
+		return StatsdFactory{}, fmt.Errorf("unknown tag format %s", opts.TagFormat)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	client := statsd.NewClient(opts.Address, options...)
+
+	go sendErrors.run(ctx, client)
+
 	return StatsdFactory{
-		client: statsd.NewClient(address, options...),
+		client:        client,
+		origin:        newOriginTracker(opts.Origin),
+		errors:        sendErrors,
+		activeStreams: &statsdActiveStreams{},
+		ctxCancel:     cancel,
 	}, nil
 }
@@ -3,12 +3,14 @@ package stats_test
 import (
 	"bytes"
 	"net"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/IceCodeNew/mtg/events"
+	"github.com/IceCodeNew/mtg/geoip"
 	"github.com/IceCodeNew/mtg/logger"
 	"github.com/IceCodeNew/mtg/mtglib"
 	"github.com/IceCodeNew/mtg/stats"
@@ -128,8 +130,10 @@ func (suite *StatsdTestSuite) TestTelegramPath() {
 	suite.Contains(suite.statsdServer.String(),
 		"mtg.telegram_traffic:90|c|#telegram_ip:10.1.0.10,dc:2,direction:from_client")
 
-	suite.statsd.EventFinish(mtglib.NewEventFinish("connID"))
+	suite.statsd.EventFinish(mtglib.NewEventFinishWithReason("connID", mtglib.CloseReasonClientClosed))
 	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.closed_streams:1|c|#close_reason:client-closed")
 	suite.Contains(suite.statsdServer.String(),
 		"mtg.telegram_connections:-1|g|#telegram_ip:10.1.0.10,dc:2")
 	suite.Contains(suite.statsdServer.String(),
@@ -163,8 +167,10 @@ func (suite *StatsdTestSuite) TestDomainFrontingPath() {
 	suite.Contains(suite.statsdServer.String(),
 		`mtg.domain_fronting_traffic:90|c|#direction:from_client`)
 
-	suite.statsd.EventFinish(mtglib.NewEventFinish("connID"))
+	suite.statsd.EventFinish(mtglib.NewEventFinishWithReason("connID", mtglib.CloseReasonDomainFronting))
 	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.closed_streams:1|c|#close_reason:domain-fronting")
 	suite.Contains(suite.statsdServer.String(),
 		"mtg.domain_fronting_connections:-1|g|#ip_family:ipv4")
 	suite.Contains(suite.statsdServer.String(),
@@ -220,6 +226,290 @@ func (suite *StatsdTestSuite) TestEventIPListSizeBlocklist() {
 	suite.Contains(suite.statsdServer.String(), "blocklist")
 }
 
+func (suite *StatsdTestSuite) TestEventSecretsConfigured() {
+	suite.statsd.EventSecretsConfigured(mtglib.NewEventSecretsConfigured(3))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.configured_secrets:3|g", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventSecretMatched() {
+	suite.statsd.EventStart(
+		mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	suite.statsd.EventSecretMatched(
+		mtglib.NewEventSecretMatched("connID", "0011223344556677"))
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.active_connections:+1|g|#secret_fp:0011223344556677")
+	suite.Contains(suite.statsdServer.String(), "mtg.sni_connections:1|c|#sni:unknown")
+
+	suite.statsd.EventFinish(mtglib.NewEventFinish("connID"))
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.active_connections:-1|g|#secret_fp:0011223344556677")
+}
+
+func (suite *StatsdTestSuite) TestEventSecretMatchedWithHost() {
+	suite.statsd.EventStart(
+		mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+	suite.statsd.EventSecretMatched(
+		mtglib.NewEventSecretMatchedWithOpts("connID", mtglib.EventSecretMatchedOpts{
+			SecretFingerprint: "0011223344556677",
+			Host:              "example.com",
+		}))
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(), "mtg.sni_connections:1|c|#sni:example.com")
+}
+
+func (suite *StatsdTestSuite) TestEventDNSQuery() {
+	suite.statsd.EventDNSQuery(
+		mtglib.NewEventDNSQuery("9.9.9.9", "google.com", 20*time.Millisecond, false, true))
+	suite.statsd.EventDNSQuery(
+		mtglib.NewEventDNSQuery("9.9.9.9", "google.com", 0, true, false))
+
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.dns_queries:1|c|#doh_resolver:9.9.9.9,dns_result:failed")
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.dns_query_duration:20|ms|#doh_resolver:9.9.9.9")
+	suite.Contains(suite.statsdServer.String(), "mtg.dns_cache:1|c|#dns_cache:miss")
+	suite.Contains(suite.statsdServer.String(), "mtg.dns_cache:1|c|#dns_cache:hit")
+}
+
+func (suite *StatsdTestSuite) TestEventDCEndpointFailover() {
+	suite.statsd.EventDCEndpointFailover(mtglib.NewEventDCEndpointFailover("connID", 2, 1))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.dc_endpoint_failover:1|c|#dc:2", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventProbeTarpitted() {
+	suite.statsd.EventProbeTarpitted(mtglib.NewEventProbeTarpitted(net.ParseIP("10.0.0.10")))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.probe_tarpitted:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventMissingSNI() {
+	suite.statsd.EventMissingSNI(mtglib.NewEventMissingSNI("connID"))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.missing_sni:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventIncompleteHandshake() {
+	suite.statsd.EventIncompleteHandshake(mtglib.NewEventIncompleteHandshake("connID"))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.incomplete_handshakes:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventDCConnsLimited() {
+	suite.statsd.EventDCConnsLimited(mtglib.NewEventDCConnsLimited("connID", 2, time.Second))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.dc_conns_limited:1|c|#dc:2", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventIPBanned() {
+	suite.statsd.EventIPBanned(mtglib.NewEventIPBanned(net.ParseIP("10.0.0.10"), time.Minute))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.banned_ips:+1|g", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventIPUnbanned() {
+	suite.statsd.EventIPUnbanned(mtglib.NewEventIPUnbanned(net.ParseIP("10.0.0.10")))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.banned_ips:-1|g", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventDNSCacheUpdated() {
+	suite.statsd.EventDNSCacheUpdated(mtglib.NewEventDNSCacheUpdated(9, 2))
+
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(), "mtg.dns_cache_size:9|g")
+	suite.Contains(suite.statsdServer.String(), "mtg.dns_cache_evictions:2|c")
+}
+
+func (suite *StatsdTestSuite) TestEventHandshakeTooLarge() {
+	suite.statsd.EventHandshakeTooLarge(mtglib.NewEventHandshakeTooLarge("connID"))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.handshake_too_large:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventGeoIPLoadFailed() {
+	suite.statsd.EventGeoIPLoadFailed(mtglib.NewEventGeoIPLoadFailed("country"))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.geoip_load_failures:1|c|#geoip_db:country", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventUpstreamMirrored() {
+	suite.statsd.EventUpstreamMirrored(
+		mtglib.NewEventUpstreamMirrored("127.0.0.1:443", time.Second, 2*time.Second, false, true))
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.upstream_mirror_dials:1|c|#upstream:primary,dial_result:ok")
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.upstream_mirror_dials:1|c|#upstream:mirror,dial_result:failed")
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.upstream_mirror_dial_duration:2000|ms|#upstream:mirror")
+}
+
+func (suite *StatsdTestSuite) TestEventGeoIPUpdated() {
+	suite.statsd.EventGeoIPUpdated(mtglib.NewEventGeoIPUpdated("asn", true))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.geoip_updates:1|c|#geoip_db:asn,update_result:failed", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventUpstreamProxyStateChanged() {
+	suite.statsd.EventUpstreamProxyStateChanged(mtglib.NewEventUpstreamProxyStateChanged("127.0.0.1:1080", true))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.upstream_proxy_ejected:1|g|#proxy:127.0.0.1:1080", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventHandshakeFinished() {
+	suite.statsd.EventHandshakeFinished(mtglib.NewEventHandshakeFinished("connID", 20*time.Millisecond, true))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.handshake_duration:20|ms|#handshake_result:failed", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventActiveStreams() {
+	older := mtglib.NewEventActiveStreams(2)
+	newer := mtglib.NewEventActiveStreams(1)
+
+	suite.statsd.EventActiveStreams(newer)
+	suite.statsd.EventActiveStreams(older)
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.streams.active:1|g", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventClientRateLimited() {
+	suite.statsd.EventClientRateLimited(mtglib.NewEventClientRateLimited(net.ParseIP("10.0.0.10")))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.client_rate_limited:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventIdleTimeout() {
+	suite.statsd.EventIdleTimeout(mtglib.NewEventIdleTimeout("connID"))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.idle_timeouts:1|c", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventAntiReplayStats() {
+	suite.statsd.EventAntiReplayStats(mtglib.NewEventAntiReplayStats(0.5, 0.25))
+
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(), "mtg.antireplay_fill_ratio:0.5|g")
+	suite.Contains(suite.statsdServer.String(), "mtg.antireplay_false_positive_rate:0.25|g")
+}
+
+func (suite *StatsdTestSuite) TestEventDCTraffic() {
+	suite.statsd.EventDCTraffic(mtglib.NewEventDCTraffic(2, 100, 200))
+
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(), "mtg.traffic_bytes_total:100|c|#dc:2,direction:from_client")
+	suite.Contains(suite.statsdServer.String(), "mtg.traffic_bytes_total:200|c|#dc:2,direction:to_client")
+}
+
+func (suite *StatsdTestSuite) TestEventTelegramDial() {
+	suite.statsd.EventTelegramDial(mtglib.NewEventTelegramDial("connID", 2, time.Second, true, true))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.telegram_dial_duration:1000|ms|#dc:2,ip_family:unknown,dial_result:failed",
+		suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestEventClientTraffic() {
+	suite.statsd.EventClientTraffic(mtglib.NewEventClientTraffic("connID", 100, true))
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.client_traffic:100|c|#direction:from_client", suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestOrigin() {
+	db, err := geoip.NewDB(
+		filepath.Join("..", "geoip", "testdata", "country.mmdb"),
+		filepath.Join("..", "geoip", "testdata", "asn.mmdb"))
+	suite.NoError(err)
+
+	defer db.Close()
+
+	factory, err := stats.NewStatsdWithOpts(stats.StatsdOpts{
+		Address:      suite.statsdServer.Addr(),
+		Logger:       logger.NewNoopLogger(),
+		MetricPrefix: "mtg.",
+		TagFormat:    "datadog",
+		Origin: stats.OriginOpts{
+			Lookup: db,
+		},
+	})
+	suite.NoError(err)
+
+	observer := factory.Make()
+
+	defer func() {
+		observer.Shutdown()
+		factory.Close()
+	}()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("89.160.20.112")))
+	observer.EventTraffic(mtglib.NewEventTraffic("connID", 30, true))
+
+	time.Sleep(statsdSleepTime)
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.country_traffic:30|c|#country:SE,direction:to_client")
+	suite.Contains(suite.statsdServer.String(),
+		"mtg.asn_traffic:30|c|#asn:29518,direction:to_client")
+}
+
+func (suite *StatsdTestSuite) TestInstanceName() {
+	factory, err := stats.NewStatsdWithOpts(stats.StatsdOpts{
+		Address:      suite.statsdServer.Addr(),
+		Logger:       logger.NewNoopLogger(),
+		MetricPrefix: "mtg.",
+		TagFormat:    "datadog",
+		InstanceName: "mtg-1",
+	})
+	suite.NoError(err)
+
+	observer := factory.Make()
+
+	defer func() {
+		observer.Shutdown()
+		factory.Close()
+	}()
+
+	observer.EventStart(mtglib.NewEventStart("connID", net.ParseIP("10.0.0.10")))
+
+	time.Sleep(statsdSleepTime)
+	suite.Equal("mtg.client_connections:+1|g|#instance_name:mtg-1,ip_family:ipv4",
+		suite.statsdServer.String())
+}
+
+func (suite *StatsdTestSuite) TestSendErrors() {
+	suite.EqualValues(0, suite.factory.SendErrors())
+
+	addr := suite.statsdServer.Addr()
+	suite.statsdServer.Close()
+
+	factory, err := stats.NewStatsd(addr, logger.NewNoopLogger(), "mtg.", "datadog")
+	suite.NoError(err)
+
+	defer factory.Close()
+
+	observer := factory.Make()
+	defer observer.Shutdown()
+
+	suite.Eventually(func() bool {
+		observer.EventConcurrencyLimited(mtglib.NewEventConcurrencyLimited())
+
+		return factory.SendErrors() > 0
+	}, 5*time.Second, statsdSleepTime)
+}
+
 func TestStatsd(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, &StatsdTestSuite{})
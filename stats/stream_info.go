@@ -1,6 +1,9 @@
 package stats
 
-import statsd "github.com/smira/go-statsd"
+import (
+	"github.com/IceCodeNew/mtg/mtglib"
+	statsd "github.com/smira/go-statsd"
+)
 
 type streamInfo struct {
 	isDomainFronted bool
@@ -26,3 +29,30 @@ func getDirection(isRead bool) string {
 
 	return TagDirectionFromClient
 }
+
+func getClientDirection(isRead bool) string {
+	if isRead {
+		return TagDirectionFromClient
+	}
+
+	return TagDirectionToClient
+}
+
+func getSNI(evt mtglib.EventSecretMatched) string {
+	if evt.Host == "" {
+		return TagSNIUnknown
+	}
+
+	return evt.Host
+}
+
+func getTelegramDialTags(evt mtglib.EventTelegramDial) (string, string) {
+	switch {
+	case evt.IsFailed:
+		return TagIPFamilyUnknown, TagDialResultFailed
+	case evt.IsIPv6:
+		return TagIPFamilyIPv6, TagDialResultOK
+	}
+
+	return TagIPFamilyIPv4, TagDialResultOK
+}